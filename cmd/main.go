@@ -30,10 +30,15 @@ import (
 	"doligo_001/internal/usecase/auth"
 	bom_uc "doligo_001/internal/usecase/bom"
 	invoice_uc "doligo_001/internal/usecase/invoice"
+	payment_uc "doligo_001/internal/usecase/payment"
 	item_uc "doligo_001/internal/usecase/item"
 	margin_uc "doligo_001/internal/usecase/margin"
+	mrp_uc "doligo_001/internal/usecase/mrp"
+	replenishment_uc "doligo_001/internal/usecase/replenishment"
 	stock_uc "doligo_001/internal/usecase/stock"
 	thirdparty_uc "doligo_001/internal/usecase/thirdparty"
+	uom_uc "doligo_001/internal/usecase/uom"
+	numbering_uc "doligo_001/internal/usecase/numbering"
 )
 
 // initServices initializes database-dependent services and returns the db connection
@@ -64,43 +69,82 @@ func initServices(ctx context.Context, cfg *config.Config, e *echo.Echo) (*gorm.
 	pdfGenerator := pdf.NewMarotoGenerator()
 	txManager := db.NewGormTransactioner(gormDB)
 
-	// Worker Pool for IO tasks (e.g., PDF generation)
-	pdfWorkerPool := worker.NewWorkerPool(cfg.InternalWorker.PoolSize, cfg.InternalWorker.PoolSize*2, "PDF Generator")
+	// Worker Pool for IO and background tasks (e.g., PDF generation, replenishment and MRP runs)
+	workerPool := worker.NewWorkerPool(cfg.InternalWorker.PoolSize, cfg.InternalWorker.PoolSize*2, "Background Tasks")
 
 	// Repositories
 	userRepo := repository.NewGormUserRepository(gormDB)
 	thirdPartyRepo := repository.NewGormThirdPartyRepository(gormDB)
 	itemRepo := repository.NewGormItemRepository(gormDB)
+	unitRepo := repository.NewGormUnitRepository(gormDB)
 	bomRepo := repository.NewGormBomRepository(gormDB, txManager)
+	workCenterRepo := repository.NewGormWorkCenterRepository(gormDB)
 	productionRepo := repository.NewGormProductionRecordRepository(gormDB)
 	marginRepo := repository.NewGormMarginRepository(gormDB)
 	invoiceRepo := repository.NewInvoiceRepository(gormDB)
+	paymentRepo := repository.NewGormPaymentRepository(gormDB)
+	numberingRepo := repository.NewGormNumberingRepository(gormDB)
 	stockRepo := repository.NewGormStockRepository(gormDB)
 	stockMoveRepo := repository.NewGormStockMovementRepository(gormDB)
 	stockLedgerRepo := repository.NewGormStockLedgerRepository(gormDB)
+	lotRepo := repository.NewGormStockLotRepository(gormDB)
+	costLayerRepo := repository.NewGormCostLayerRepository(gormDB)
+	reservationRepo := repository.NewGormReservationRepository(gormDB)
 	warehouseRepo := repository.NewGormWarehouseRepository(gormDB)
 	binRepo := repository.NewGormBinRepository(gormDB)
+	countRepo := repository.NewGormCountSessionRepository(gormDB)
+	batchRepo := repository.NewGormMovementBatchRepository(gormDB)
+	reorderRuleRepo := repository.NewGormReorderRuleRepository(gormDB)
+	proposalRepo := repository.NewGormProposalRepository(gormDB)
+	forecastRepo := repository.NewGormForecastRepository(gormDB)
+	mrpRunRepo := repository.NewGormMrpRunRepository(gormDB)
+	mrpDemandRepo := repository.NewGormMrpDemandRepository(gormDB)
+	orderRepo := repository.NewGormManufacturingOrderRepository(gormDB)
 	auditRepo := db.NewGormAuditRepository(gormDB)
 
 	// Usecases
 	auditService := usecase.NewAuditService(auditRepo)
 	authUsecase := auth.NewAuthUsecase(userRepo, []byte(cfg.JWT.JWTSecret), time.Hour*24, auditService)
 	thirdPartyUsecase := thirdparty_uc.NewUsecase(thirdPartyRepo)
-	itemUsecase := item_uc.NewUsecase(itemRepo, auditService)
-	stockUsecase := stock_uc.NewUseCase(txManager, stockRepo, stockMoveRepo, stockLedgerRepo, warehouseRepo, binRepo, itemRepo, auditService)
-	bomUsecase := bom_uc.NewBOMUsecase(txManager, bomRepo, productionRepo, stockRepo, stockMoveRepo, stockLedgerRepo, itemRepo, auditService)
+	itemUsecase := item_uc.NewUsecase(itemRepo, unitRepo, auditService)
+	uomUsecase := uom_uc.NewUsecase(unitRepo, auditService)
+	numberingUsecase := numbering_uc.NewUsecase(numberingRepo, auditService)
+	stockUsecase := stock_uc.NewUseCase(txManager, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, reservationRepo, warehouseRepo, binRepo, itemRepo, unitRepo, auditService)
+	reservationUsecase := stock_uc.NewReservationUseCase(txManager, reservationRepo, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, warehouseRepo, binRepo, itemRepo, auditService, cfg.Stock.ReservationDefaultTTL)
+	countUsecase := stock_uc.NewCountUseCase(txManager, countRepo, stockRepo, stockMoveRepo, stockLedgerRepo, costLayerRepo, warehouseRepo, binRepo, itemRepo, auditService)
+	valuationUsecase := stock_uc.NewValuationUseCase(stockLedgerRepo, warehouseRepo, binRepo, itemRepo)
+	batchUsecase := stock_uc.NewBatchUseCase(txManager, batchRepo, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, reservationRepo, warehouseRepo, binRepo, itemRepo, unitRepo, auditService)
+	bomUsecase := bom_uc.NewBOMUsecase(txManager, bomRepo, productionRepo, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, reservationRepo, itemRepo, unitRepo, workCenterRepo, auditService)
+	orderUsecase := bom_uc.NewManufacturingOrderUsecase(txManager, orderRepo, bomRepo, productionRepo, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, reservationRepo, itemRepo, unitRepo, workCenterRepo, auditService)
+	workCenterUsecase := bom_uc.NewWorkCenterUsecase(workCenterRepo, auditService)
+	replenishmentUsecase := replenishment_uc.NewUsecase(txManager, reorderRuleRepo, proposalRepo, stockRepo, reservationRepo, warehouseRepo, bomRepo, itemRepo, unitRepo, auditService)
+	mrpUsecase := mrp_uc.NewUsecase(txManager, forecastRepo, mrpRunRepo, mrpDemandRepo, stockRepo, reservationRepo, warehouseRepo, orderRepo, bomRepo, itemRepo, unitRepo, workerPool, auditService)
 	marginUsecase := margin_uc.NewMarginUsecase(marginRepo)
+	agingUsecase := margin_uc.NewAgingUsecase(marginRepo)
 	emailSender := email.NewSimpleEmailSender()
-	invoiceUsecase := invoice_uc.NewUsecase(invoiceRepo, itemRepo, pdfGenerator, emailSender, pdfWorkerPool, auditService, cfg.PDFStoragePath)
+	invoiceUsecase := invoice_uc.NewUsecase(txManager, invoiceRepo, thirdPartyRepo, numberingRepo, itemRepo, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, warehouseRepo, binRepo, unitRepo, pdfGenerator, emailSender, workerPool, auditService, cfg.PDFStoragePath)
+	paymentUsecase := payment_uc.NewUsecase(txManager, paymentRepo, invoiceRepo, thirdPartyRepo, auditService)
 
 	// Handlers
 	authHandler := handlers.NewAuthHandler(authUsecase)
 	thirdPartyHandler := handlers.NewThirdPartyHandler(thirdPartyUsecase)
 	itemHandler := handlers.NewItemHandler(itemUsecase)
+	uomHandler := handlers.NewUoMHandler(uomUsecase)
+	numberingHandler := handlers.NewNumberingHandler(numberingUsecase)
 	stockHandler := handlers.NewStockHandler(stockUsecase)
+	stockCountHandler := handlers.NewStockCountHandler(countUsecase)
+	stockReservationHandler := handlers.NewStockReservationHandler(reservationUsecase)
+	stockValuationHandler := handlers.NewStockValuationHandler(valuationUsecase)
+	stockBatchHandler := handlers.NewStockBatchHandler(batchUsecase)
 	bomHandler := handlers.NewBOMHandler(bomUsecase, validator.NewValidator())
+	orderHandler := handlers.NewManufacturingOrderHandler(orderUsecase, validator.NewValidator())
+	workCenterHandler := handlers.NewWorkCenterHandler(workCenterUsecase)
+	replenishmentHandler := handlers.NewReplenishmentHandler(replenishmentUsecase)
+	mrpHandler := handlers.NewMrpHandler(mrpUsecase)
 	marginHandler := handlers.NewMarginHandler(marginUsecase)
+	arAgingHandler := handlers.NewARAgingHandler(agingUsecase)
 	invoiceHandler := handlers.NewInvoiceHandler(invoiceUsecase)
+	paymentHandler := handlers.NewPaymentHandler(paymentUsecase)
 	metricsHandler := handlers.NewMetricsHandler(appMetrics)
 
 	// Register routes
@@ -125,12 +169,59 @@ func initServices(ctx context.Context, cfg *config.Config, e *echo.Echo) (*gorm.
 	thirdpartiesGroup := v1.Group("/thirdparties")
 	thirdpartiesGroup.POST("", thirdPartyHandler.Create)
 	thirdpartiesGroup.GET("", thirdPartyHandler.List)
+	thirdpartiesGroup.GET("/:id/payments", paymentHandler.ListThirdPartyPayments)
+	thirdpartiesGroup.GET("/:id/statement", paymentHandler.GetStatement)
 
 	itemsGroup := v1.Group("/items")
 	itemsGroup.POST("", itemHandler.Create)
 	itemsGroup.GET("", itemHandler.List)
 
+	uomGroup := v1.Group("/uom")
+	uomGroup.POST("/categories", uomHandler.CreateCategory)
+	uomGroup.GET("/categories", uomHandler.ListCategories)
+	uomGroup.POST("/units", uomHandler.CreateUnit)
+	uomGroup.GET("/units", uomHandler.ListUnits)
+	uomGroup.GET("/units/:code", uomHandler.GetUnit)
+	uomGroup.GET("/convert", uomHandler.Convert)
+
+	warehousesGroup := v1.Group("/warehouses")
+	warehousesGroup.POST("", stockHandler.CreateWarehouse)
+	warehousesGroup.GET("", stockHandler.ListWarehouses)
+	warehousesGroup.GET("/:id", stockHandler.GetWarehouseByID)
+	warehousesGroup.PUT("/:id", stockHandler.UpdateWarehouse)
+	warehousesGroup.DELETE("/:id", stockHandler.DeleteWarehouse)
+	warehousesGroup.GET("/:id/bins", stockHandler.ListBinsByWarehouse)
+	warehousesGroup.GET("/:id/stock", stockHandler.ListWarehouseStock)
+
+	binsGroup := v1.Group("/bins")
+	binsGroup.POST("", stockHandler.CreateBin)
+	binsGroup.GET("/:id", stockHandler.GetBinByID)
+	binsGroup.PUT("/:id", stockHandler.UpdateBin)
+	binsGroup.DELETE("/:id", stockHandler.DeleteBin)
+	binsGroup.GET("/:id/stock", stockHandler.ListBinStock)
+
 	v1.POST("/stock/movements", stockHandler.CreateStockMovement)
+	v1.POST("/stock/movements/batch", stockBatchHandler.ImportStockMovements)
+	v1.POST("/stock/transfers", stockHandler.CreateStockTransfer)
+	v1.GET("/stock/ledger", stockHandler.ListStockLedger)
+	v1.GET("/stock/as-of", stockHandler.GetStockAsOf)
+	v1.GET("/stock/lots", stockHandler.ListStockLots)
+	v1.GET("/stock/availability", stockReservationHandler.GetAvailability)
+
+	reservationGroup := v1.Group("/stock/reservations")
+	reservationGroup.POST("", stockReservationHandler.Reserve)
+	reservationGroup.GET("", stockReservationHandler.ListReservations)
+	reservationGroup.GET("/:id", stockReservationHandler.GetReservation)
+	reservationGroup.POST("/:id/release", stockReservationHandler.Release)
+	reservationGroup.POST("/:id/consume", stockReservationHandler.Consume)
+
+	countGroup := v1.Group("/stock/counts")
+	countGroup.POST("", stockCountHandler.StartCount)
+	countGroup.GET("", stockCountHandler.ListCounts)
+	countGroup.GET("/:id", stockCountHandler.GetCount)
+	countGroup.POST("/:id/entries", stockCountHandler.SubmitCount)
+	countGroup.POST("/:id/approve", stockCountHandler.ApproveCount)
+	countGroup.POST("/:id/cancel", stockCountHandler.CancelCount)
 
 	bomGroup := v1.Group("/boms")
 	bomGroup.POST("", bomHandler.CreateBOM)
@@ -141,20 +232,102 @@ func initServices(ctx context.Context, cfg *config.Config, e *echo.Echo) (*gorm.
 	bomGroup.DELETE("/:id", bomHandler.DeleteBOM)
 	bomGroup.POST("/calculate-cost", bomHandler.CalculatePredictiveCost)
 	bomGroup.POST("/produce", bomHandler.ProduceItem)
+	bomGroup.POST("/productions/:id/reverse", bomHandler.ReverseProduction)
+	bomGroup.POST("/productions/:id/disassemble", bomHandler.DisassembleProduction)
+	bomGroup.GET("/genealogy", bomHandler.TraceLot)
+	bomGroup.GET("/where-used", bomHandler.WhereUsed)
+	bomGroup.GET("/:id/tree", bomHandler.GetBOMTree)
+	bomGroup.GET("/diff", bomHandler.DiffRevisions)
+	bomGroup.POST("/import", bomHandler.ImportBOMs)
+	bomGroup.GET("/export", bomHandler.ExportBOMs)
+	bomGroup.GET("/product/:productID/revisions", bomHandler.ListRevisions)
+	bomGroup.POST("/:id/revisions", bomHandler.CreateRevision)
+	bomGroup.POST("/:id/approve", bomHandler.ApproveRevision)
+	bomGroup.POST("/:id/obsolete", bomHandler.ObsoleteRevision)
+
+	workCenterGroup := v1.Group("/work-centers")
+	workCenterGroup.POST("", workCenterHandler.CreateWorkCenter)
+	workCenterGroup.GET("", workCenterHandler.ListWorkCenters)
+	workCenterGroup.GET("/:id", workCenterHandler.GetWorkCenter)
+	workCenterGroup.PUT("/:id", workCenterHandler.UpdateWorkCenter)
+
+	orderGroup := v1.Group("/manufacturing-orders")
+	orderGroup.POST("", orderHandler.CreateOrder)
+	orderGroup.GET("", orderHandler.ListOrders)
+	orderGroup.GET("/:id", orderHandler.GetOrder)
+	orderGroup.POST("/:id/release", orderHandler.ReleaseOrder)
+	orderGroup.POST("/:id/issue", orderHandler.IssueComponents)
+	orderGroup.POST("/:id/receive", orderHandler.ReceiveProducts)
+	orderGroup.POST("/:id/close", orderHandler.CloseOrder)
+	orderGroup.POST("/:id/cancel", orderHandler.CancelOrder)
+
+	replenishmentGroup := v1.Group("/replenishment")
+	replenishmentGroup.POST("/rules", replenishmentHandler.CreateRule)
+	replenishmentGroup.GET("/rules", replenishmentHandler.ListRules)
+	replenishmentGroup.GET("/rules/:id", replenishmentHandler.GetRule)
+	replenishmentGroup.PUT("/rules/:id", replenishmentHandler.UpdateRule)
+	replenishmentGroup.DELETE("/rules/:id", replenishmentHandler.DeleteRule)
+	replenishmentGroup.POST("/runs", replenishmentHandler.Run)
+	replenishmentGroup.GET("/proposals", replenishmentHandler.ListProposals)
+
+	mrpGroup := v1.Group("/mrp")
+	mrpGroup.POST("/forecasts", mrpHandler.CreateForecast)
+	mrpGroup.GET("/forecasts", mrpHandler.ListForecasts)
+	mrpGroup.GET("/forecasts/:id", mrpHandler.GetForecast)
+	mrpGroup.PUT("/forecasts/:id", mrpHandler.UpdateForecast)
+	mrpGroup.DELETE("/forecasts/:id", mrpHandler.DeleteForecast)
+	mrpGroup.POST("/runs", mrpHandler.StartRun)
+	mrpGroup.GET("/runs", mrpHandler.ListRuns)
+	mrpGroup.GET("/runs/:id", mrpHandler.GetRun)
+	mrpGroup.GET("/runs/:id/planned-orders", mrpHandler.ListPlannedOrders)
+
+	v1.GET("/reports/inventory-valuation", stockValuationHandler.GetInventoryValuation)
 
 	marginGroup := v1.Group("/margin")
 	marginGroup.GET("/products/:productID", marginHandler.GetProductMarginReport)
 	marginGroup.GET("", marginHandler.ListOverallMarginReports)
+	v1.GET("/reports/ar-aging", arAgingHandler.GetARAgingReport)
+
+	numberingGroup := v1.Group("/numbering-sequences")
+	numberingGroup.GET("", numberingHandler.ListSequences)
+	numberingGroup.PUT("/:documentType", numberingHandler.UpdateSequence)
 
 	invoiceGroup := v1.Group("/invoices")
 	invoiceGroup.POST("", invoiceHandler.CreateInvoice)
 	invoiceGroup.GET("/:id", invoiceHandler.GetInvoice)
+	invoiceGroup.PUT("/:id", invoiceHandler.UpdateInvoice)
+	invoiceGroup.POST("/:id/validate", invoiceHandler.ValidateInvoice)
+	invoiceGroup.POST("/:id/send", invoiceHandler.SendInvoice)
+	invoiceGroup.POST("/:id/payments", paymentHandler.PayInvoice)
+	invoiceGroup.POST("/:id/cancel", invoiceHandler.CancelInvoice)
+	invoiceGroup.POST("/:id/credit-notes", invoiceHandler.CreateCreditNote)
+	invoiceGroup.GET("/:id/credit-notes", invoiceHandler.ListCreditNotes)
 	invoiceGroup.POST("/:id/pdf", invoiceHandler.QueueInvoicePDF)
 	invoiceGroup.GET("/:id/status", invoiceHandler.GetInvoicePDFStatus, apiMiddleware.HasPermission("INVOICE_READ"))
 	invoiceGroup.GET("/:id/pdf", invoiceHandler.DownloadInvoicePDF, apiMiddleware.HasPermission("INVOICE_READ"))
 
+	paymentsGroup := v1.Group("/payments")
+	paymentsGroup.POST("", paymentHandler.RecordPayment)
+	paymentsGroup.GET("/:id", paymentHandler.GetPayment)
+	paymentsGroup.POST("/:id/allocations", paymentHandler.AllocatePayment)
+
+	// Background jobs
+	go worker.RunPeriodic(ctx, cfg.Stock.ReservationExpiryInterval, "Reservation Expiry", func(ctx context.Context) error {
+		expired, err := reservationUsecase.ExpireReservations(ctx)
+		if err == nil && expired > 0 {
+			slog.Info("Expired stock reservations", "count", expired)
+		}
+		return err
+	})
+	// Replenishment runs are queued on the worker pool instead of running on the ticker goroutine
+	if cfg.Stock.ReplenishmentInterval > 0 {
+		go worker.RunPeriodic(ctx, cfg.Stock.ReplenishmentInterval, "Replenishment Scheduler", func(ctx context.Context) error {
+			return workerPool.Submit(&replenishment_uc.RunTask{Usecase: replenishmentUsecase})
+		})
+	}
+
 	slog.Info("All services initialized and routes registered.")
-	return gormDB, appMetrics, pdfWorkerPool, nil
+	return gormDB, appMetrics, workerPool, nil
 }
 
 func main() {
@@ -199,12 +372,12 @@ func main() {
 
 	var gormDB *gorm.DB
 	var appMetrics *metrics.Metrics
-	var pdfWorkerPool *worker.WorkerPool
+	var workerPool *worker.WorkerPool
 
 	// Start services in a separate goroutine
 	go func() {
 		var err error
-		gormDB, appMetrics, pdfWorkerPool, err = initServices(ctx, cfg, e)
+		gormDB, appMetrics, workerPool, err = initServices(ctx, cfg, e)
 		if err != nil {
 			slog.Error("Failed to initialize services", "error", err)
 			// The /ready probe will fail, so we don't need to exit
@@ -247,8 +420,8 @@ func main() {
 	}
 
 	// Shutdown Worker Pool
-	if pdfWorkerPool != nil {
-		pdfWorkerPool.Shutdown(cfg.InternalWorker.ShutdownTimeout)
+	if workerPool != nil {
+		workerPool.Shutdown(cfg.InternalWorker.ShutdownTimeout)
 	}
 
 	// Close database connection
@@ -1,8 +1,11 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/bom"
 )
 
 // CreateBOMRequest represents the request body for creating a new Bill of Materials.
@@ -10,7 +13,9 @@ type CreateBOMRequest struct {
 	ProductID  string               `json:"product_id" validate:"required,uuid"`
 	Name       string               `json:"name" validate:"required"`
 	IsActive   bool                 `json:"is_active"`
+	YieldPercent float64            `json:"yield_percent" validate:"omitempty,gt=0,lte=100"` // Defaults to 100
 	Components []BOMComponentRequest `json:"components" validate:"required,min=1"`
+	Operations []RoutingOperationRequest `json:"operations" validate:"omitempty,dive"` // Routing, optional
 }
 
 func (r *CreateBOMRequest) Sanitize() {
@@ -18,27 +23,61 @@ func (r *CreateBOMRequest) Sanitize() {
 	for i := range r.Components {
 		r.Components[i].Sanitize()
 	}
+	for i := range r.Operations {
+		r.Operations[i].Sanitize()
+	}
 }
 
 // BOMComponentRequest represents a single component within a BOM creation request.
 type BOMComponentRequest struct {
 	ComponentItemID string  `json:"component_item_id" validate:"required,uuid"`
 	Quantity        float64 `json:"quantity" validate:"required,gt=0"`
+	ScrapPercent    float64 `json:"scrap_percent" validate:"gte=0"`
 	UnitOfMeasure   string  `json:"unit_of_measure" validate:"required"`
 	IsActive        bool    `json:"is_active"`
+	Alternates      []AlternateComponentRequest `json:"alternates" validate:"omitempty,dive"`
 }
 
 func (r *BOMComponentRequest) Sanitize() {
 	r.UnitOfMeasure = sanitizer.SanitizeString(r.UnitOfMeasure)
 }
 
+// AlternateComponentRequest is an item that may replace a component in production. Priority 1
+// is tried first; ConversionRatio is the base quantity of the alternate per base unit of the
+// component.
+type AlternateComponentRequest struct {
+	ItemID          string  `json:"item_id" validate:"required,uuid"`
+	Priority        int     `json:"priority" validate:"required,gt=0"`
+	ConversionRatio float64 `json:"conversion_ratio" validate:"required,gt=0"`
+}
+
+// RoutingOperationRequest is an operation of the routing of a BOM. Times are in minutes, the
+// run time per unit of product.
+type RoutingOperationRequest struct {
+	Sequence     int     `json:"sequence" validate:"required,gt=0"`
+	Name         string  `json:"name" validate:"required,max=255"`
+	WorkCenterID string  `json:"work_center_id" validate:"required,uuid"`
+	SetupMinutes float64 `json:"setup_minutes" validate:"gte=0"`
+	RunMinutes   float64 `json:"run_minutes" validate:"gte=0"`
+}
+
+func (r *RoutingOperationRequest) Sanitize() {
+	r.Name = sanitizer.SanitizeString(r.Name)
+}
+
 // BOMResponse represents the response body for a Bill of Materials.
 type BOMResponse struct {
 	ID         uuid.UUID             `json:"id"`
 	ProductID  uuid.UUID             `json:"product_id"`
 	Name       string                `json:"name"`
 	IsActive   bool                  `json:"is_active"`
+	Revision      int                   `json:"revision"`
+	Status        string                `json:"status"`
+	EffectiveFrom *time.Time            `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time            `json:"effective_to,omitempty"` // Exclusive
+	YieldPercent  float64               `json:"yield_percent"`
 	Components []BOMComponentResponse `json:"components"`
+	Operations []RoutingOperationResponse `json:"operations"`
 	CreatedAt  string                `json:"created_at"`
 	UpdatedAt  string                `json:"updated_at"`
 	CreatedBy  uuid.UUID             `json:"created_by"`
@@ -51,14 +90,34 @@ type BOMComponentResponse struct {
 	BillOfMaterialsID uuid.UUID `json:"bill_of_materials_id"`
 	ComponentItemID   uuid.UUID `json:"component_item_id"`
 	Quantity        float64   `json:"quantity"`
+	ScrapPercent    float64   `json:"scrap_percent"`
 	UnitOfMeasure   string    `json:"unit_of_measure"`
 	IsActive        bool      `json:"is_active"`
+	Alternates      []AlternateComponentResponse `json:"alternates,omitempty"`
 	CreatedAt       string    `json:"created_at"`
 	UpdatedAt       string    `json:"updated_at"`
 	CreatedBy       uuid.UUID `json:"created_by"`
 	UpdatedBy       uuid.UUID `json:"updated_by"`
 }
 
+// AlternateComponentResponse is an alternate of a BOM component.
+type AlternateComponentResponse struct {
+	ID              uuid.UUID `json:"id"`
+	ItemID          uuid.UUID `json:"item_id"`
+	Priority        int       `json:"priority"`
+	ConversionRatio float64   `json:"conversion_ratio"`
+}
+
+// RoutingOperationResponse is an operation of the routing of a BOM.
+type RoutingOperationResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Sequence     int       `json:"sequence"`
+	Name         string    `json:"name"`
+	WorkCenterID uuid.UUID `json:"work_center_id"`
+	SetupMinutes float64   `json:"setup_minutes"`
+	RunMinutes   float64   `json:"run_minutes"`
+}
+
 // CalculateCostRequest represents the request body for calculating predictive cost.
 type CalculateCostRequest struct {
 	BOMID      string `json:"bom_id" validate:"required,uuid"`
@@ -70,11 +129,258 @@ type CalculateCostResponse struct {
 	TotalCost float64   `json:"total_cost"`
 }
 
+// BOMTreeRequest holds the query parameters of a multi-level BOM explosion.
+type BOMTreeRequest struct {
+	Quantity float64 `query:"quantity" validate:"omitempty,gt=0"` // Defaults to 1
+}
+
+// BOMTreeResponse is an indented BOM: the lines are listed depth first with their level.
+type BOMTreeResponse struct {
+	BOMID       uuid.UUID             `json:"bom_id"`
+	ProductID   uuid.UUID             `json:"product_id"`
+	ProductName string                `json:"product_name"`
+	Quantity    float64               `json:"quantity"`
+	UnitCost    float64               `json:"unit_cost"`
+	TotalCost   float64               `json:"total_cost"`
+	LaborCost    float64              `json:"labor_cost"`    // Of the routing of the product, included in the total cost
+	OverheadCost float64              `json:"overhead_cost"` // Of the routing of the product, included in the total cost
+	Lines       []BOMTreeLineResponse `json:"lines"`
+}
+
+// BOMTreeLineResponse is a component of an indented BOM. BOMID is set for sub-assemblies.
+type BOMTreeLineResponse struct {
+	Level         int        `json:"level"`
+	ItemID        uuid.UUID  `json:"item_id"`
+	ItemName      string     `json:"item_name"`
+	BOMID         *uuid.UUID `json:"bom_id,omitempty"`
+	QuantityPer   float64    `json:"quantity_per"`
+	Quantity      float64    `json:"quantity"`
+	UnitOfMeasure string     `json:"unit_of_measure"`
+	UnitCost      float64    `json:"unit_cost"`
+	TotalCost     float64    `json:"total_cost"`
+	LaborCost     float64    `json:"labor_cost"`    // Of the routing of a sub-assembly
+	OverheadCost  float64    `json:"overhead_cost"` // Of the routing of a sub-assembly
+}
+
+func NewBOMTreeResponse(root *bom.BOMNode) *BOMTreeResponse {
+	res := &BOMTreeResponse{
+		BOMID:       *root.BOMID,
+		ProductID:   root.ItemID,
+		ProductName: root.ItemName,
+		Quantity:    root.Quantity,
+		UnitCost:    root.UnitCost,
+		TotalCost:   root.TotalCost,
+		LaborCost:    root.LaborCost,
+		OverheadCost: root.OverheadCost,
+		Lines:       []BOMTreeLineResponse{},
+	}
+	var appendLines func(nodes []*bom.BOMNode)
+	appendLines = func(nodes []*bom.BOMNode) {
+		for _, n := range nodes {
+			res.Lines = append(res.Lines, BOMTreeLineResponse{
+				Level:         n.Level,
+				ItemID:        n.ItemID,
+				ItemName:      n.ItemName,
+				BOMID:         n.BOMID,
+				QuantityPer:   n.QuantityPer,
+				Quantity:      n.Quantity,
+				UnitOfMeasure: n.UnitOfMeasure,
+				UnitCost:      n.UnitCost,
+				TotalCost:     n.TotalCost,
+				LaborCost:     n.LaborCost,
+				OverheadCost:  n.OverheadCost,
+			})
+			appendLines(n.Components)
+		}
+	}
+	appendLines(root.Components)
+	return res
+}
+
+// WhereUsedRequest holds the query parameters of a where-used lookup.
+type WhereUsedRequest struct {
+	ItemID     string `query:"itemId" validate:"required,uuid"`
+	MultiLevel bool   `query:"multiLevel"` // Also list the BOMs consuming the products that use the item
+}
+
+// WhereUsedResponse is a BOM that consumes the looked up item.
+type WhereUsedResponse struct {
+	BOMID     uuid.UUID `json:"bom_id"`
+	BOMName   string    `json:"bom_name"`
+	ProductID uuid.UUID `json:"product_id"`
+	Revision  int       `json:"revision"`
+	Status    string    `json:"status"`
+	IsActive  bool      `json:"is_active"`
+	Level     int       `json:"level"`
+	Quantity  float64   `json:"quantity"`
+}
+
+func NewWhereUsedResponses(usages []*bom.WhereUsed) []WhereUsedResponse {
+	res := make([]WhereUsedResponse, len(usages))
+	for i, u := range usages {
+		res[i] = WhereUsedResponse{
+			BOMID:     u.BOMID,
+			BOMName:   u.BOMName,
+			ProductID: u.ProductID,
+			Revision:  u.Revision,
+			Status:    string(u.Status),
+			IsActive:  u.IsActive,
+			Level:     u.Level,
+			Quantity:  u.Quantity,
+		}
+	}
+	return res
+}
+
+// ApproveBOMRevisionRequest holds the period in which an approved revision is in force.
+type ApproveBOMRevisionRequest struct {
+	EffectiveFrom *time.Time `json:"effective_from"` // Defaults to now
+	EffectiveTo   *time.Time `json:"effective_to"`   // Exclusive, open-ended if empty
+}
+
+// BOMRevisionDiffRequest holds the query parameters of a revision comparison.
+type BOMRevisionDiffRequest struct {
+	From string `query:"from" validate:"required,uuid"`
+	To   string `query:"to" validate:"required,uuid"`
+}
+
+// BOMRevisionDiffResponse lists the component differences from one revision to another.
+type BOMRevisionDiffResponse struct {
+	FromBOMID    uuid.UUID                    `json:"from_bom_id"`
+	FromRevision int                          `json:"from_revision"`
+	ToBOMID      uuid.UUID                    `json:"to_bom_id"`
+	ToRevision   int                          `json:"to_revision"`
+	FromYieldPercent float64                  `json:"from_yield_percent"`
+	ToYieldPercent   float64                  `json:"to_yield_percent"`
+	Added        []BOMComponentChangeResponse `json:"added"`
+	Removed      []BOMComponentChangeResponse `json:"removed"`
+	Changed      []BOMComponentChangeResponse `json:"changed"`
+}
+
+// BOMComponentChangeResponse is a component of a revision diff. The From fields are empty for
+// added components and the To fields for removed ones.
+type BOMComponentChangeResponse struct {
+	ComponentItemID   uuid.UUID `json:"component_item_id"`
+	FromQuantity      float64   `json:"from_quantity,omitempty"`
+	ToQuantity        float64   `json:"to_quantity,omitempty"`
+	FromScrapPercent  float64   `json:"from_scrap_percent,omitempty"`
+	ToScrapPercent    float64   `json:"to_scrap_percent,omitempty"`
+	FromUnitOfMeasure string    `json:"from_unit_of_measure,omitempty"`
+	ToUnitOfMeasure   string    `json:"to_unit_of_measure,omitempty"`
+}
+
+func NewBOMRevisionDiffResponse(d *bom.RevisionDiff) *BOMRevisionDiffResponse {
+	res := &BOMRevisionDiffResponse{
+		FromBOMID:    d.From.ID,
+		FromRevision: d.From.Revision,
+		ToBOMID:      d.To.ID,
+		ToRevision:   d.To.Revision,
+		FromYieldPercent: d.From.YieldPercent,
+		ToYieldPercent:   d.To.YieldPercent,
+		Added:        []BOMComponentChangeResponse{},
+		Removed:      []BOMComponentChangeResponse{},
+		Changed:      []BOMComponentChangeResponse{},
+	}
+	for _, c := range d.Added {
+		res.Added = append(res.Added, BOMComponentChangeResponse{
+			ComponentItemID: c.ComponentItemID,
+			ToQuantity:      c.Quantity,
+			ToScrapPercent:  c.ScrapPercent,
+			ToUnitOfMeasure: c.UnitOfMeasure,
+		})
+	}
+	for _, c := range d.Removed {
+		res.Removed = append(res.Removed, BOMComponentChangeResponse{
+			ComponentItemID:   c.ComponentItemID,
+			FromQuantity:      c.Quantity,
+			FromScrapPercent:  c.ScrapPercent,
+			FromUnitOfMeasure: c.UnitOfMeasure,
+		})
+	}
+	for _, c := range d.Changed {
+		res.Changed = append(res.Changed, BOMComponentChangeResponse{
+			ComponentItemID:   c.ComponentItemID,
+			FromQuantity:      c.FromQuantity,
+			ToQuantity:        c.ToQuantity,
+			FromScrapPercent:  c.FromScrapPercent,
+			ToScrapPercent:    c.ToScrapPercent,
+			FromUnitOfMeasure: c.FromUnitOfMeasure,
+			ToUnitOfMeasure:   c.ToUnitOfMeasure,
+		})
+	}
+	return res
+}
+
 // ProduceItemRequest represents the request body for initiating a production order.
 type ProduceItemRequest struct {
-	BOMID              string  `json:"bom_id" validate:"required,uuid"`
-	WarehouseID        string  `json:"warehouse_id" validate:"required,uuid"`
-	ProductionQuantity float64 `json:"production_quantity" validate:"required,gt=0"`
+	BOMID              string                 `json:"bom_id" validate:"required,uuid"`
+	WarehouseID        string                 `json:"warehouse_id" validate:"required,uuid"`
+	ProductionQuantity float64                `json:"production_quantity" validate:"required,gt=0"`
+	ComponentLots      []ComponentLotsRequest `json:"component_lots" validate:"omitempty,dive"` // Required for tracked components
+	ProductLots        []LotQuantityRequest   `json:"product_lots" validate:"omitempty,dive"`   // Required for a tracked product
+	ComponentScrap     []ComponentScrapRequest `json:"component_scrap" validate:"omitempty,dive"` // Actual scrap; the planned scrap is used for the other components
+	Substitutes        []SubstituteRequest     `json:"substitutes" validate:"omitempty,dive"`     // Alternates chosen over their components; short components are substituted otherwise
+}
+
+func (r *ProduceItemRequest) Sanitize() {
+	for i := range r.ComponentLots {
+		r.ComponentLots[i].Sanitize()
+	}
+	for i := range r.ProductLots {
+		r.ProductLots[i].Sanitize()
+	}
+}
+
+// ComponentLotsRequest lists the lots of one component consumed by a production order.
+type ComponentLotsRequest struct {
+	ComponentItemID string               `json:"component_item_id" validate:"required,uuid"`
+	Lots            []LotQuantityRequest `json:"lots" validate:"required,min=1,dive"`
+}
+
+func (r *ComponentLotsRequest) Sanitize() {
+	for i := range r.Lots {
+		r.Lots[i].Sanitize()
+	}
+}
+
+// ComponentScrapRequest is the actual scrap quantity of one component in a production order.
+type ComponentScrapRequest struct {
+	ComponentItemID string  `json:"component_item_id" validate:"required,uuid"`
+	Quantity        float64 `json:"quantity" validate:"gte=0"`
+}
+
+// SubstituteRequest chooses an alternate to replace a component entirely in a production order.
+type SubstituteRequest struct {
+	ComponentItemID  string `json:"component_item_id" validate:"required,uuid"`
+	SubstituteItemID string `json:"substitute_item_id" validate:"required,uuid"`
+}
+
+// LotGenealogyRequest holds the query parameters of a lot genealogy lookup.
+type LotGenealogyRequest struct {
+	ItemID    string `query:"itemId" validate:"required,uuid"`
+	LotNumber string `query:"lotNumber" validate:"required,max=100"`
+}
+
+// LotGenealogyResponse is a lot together with the component lots consumed to produce it.
+type LotGenealogyResponse struct {
+	ItemID     uuid.UUID               `json:"item_id"`
+	LotNumber  string                  `json:"lot_number"`
+	Quantity   float64                 `json:"quantity,omitempty"`
+	ProducedBy []uuid.UUID             `json:"produced_by,omitempty"`
+	Components []*LotGenealogyResponse `json:"components,omitempty"`
+}
+
+func NewLotGenealogyResponse(g *bom.LotGenealogy) *LotGenealogyResponse {
+	res := &LotGenealogyResponse{
+		ItemID:     g.ItemID,
+		LotNumber:  g.LotNumber,
+		Quantity:   g.Quantity,
+		ProducedBy: g.ProducedBy,
+	}
+	for _, c := range g.Components {
+		res.Components = append(res.Components, NewLotGenealogyResponse(c))
+	}
+	return res
 }
 
 // ProduceItemResponse represents the response body for a production order.
@@ -83,3 +389,86 @@ type ProduceItemResponse struct {
 	ActualProductionCost float64   `json:"actual_production_cost"`
 	Message             string    `json:"message"`
 }
+
+// ReverseProductionRequest represents the request body for reversing a production run.
+type ReverseProductionRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+func (r *ReverseProductionRequest) Sanitize() {
+	r.Reason = sanitizer.SanitizeString(r.Reason)
+}
+
+// DisassembleProductionRequest represents the request body for taking apart the product of a
+// production run.
+type DisassembleProductionRequest struct {
+	Quantity      float64                `json:"quantity" validate:"required,gt=0"`
+	ComponentLots []ComponentLotsRequest `json:"component_lots" validate:"omitempty,dive"` // Lots the tracked components come back to
+	ProductLots   []LotQuantityRequest   `json:"product_lots" validate:"omitempty,dive"`   // Required for a tracked product
+}
+
+func (r *DisassembleProductionRequest) Sanitize() {
+	for i := range r.ComponentLots {
+		r.ComponentLots[i].Sanitize()
+	}
+	for i := range r.ProductLots {
+		r.ProductLots[i].Sanitize()
+	}
+}
+
+// ProductionRecordResponse represents a production run with its disassembly and reversal.
+type ProductionRecordResponse struct {
+	ID                   uuid.UUID  `json:"id"`
+	BillOfMaterialsID    uuid.UUID  `json:"bill_of_materials_id"`
+	ManufacturingOrderID *uuid.UUID `json:"manufacturing_order_id,omitempty"`
+	ProducedProductID    uuid.UUID  `json:"produced_product_id"`
+	ProductionQuantity   float64    `json:"production_quantity"`
+	ActualProductionCost float64    `json:"actual_production_cost"`
+	DisassembledQuantity float64    `json:"disassembled_quantity"`
+	WarehouseID          uuid.UUID  `json:"warehouse_id"`
+	ProducedAt           time.Time  `json:"produced_at"`
+	ReversedAt           *time.Time `json:"reversed_at,omitempty"`
+	ReversedBy           *uuid.UUID `json:"reversed_by,omitempty"`
+	ReversalReason       string     `json:"reversal_reason,omitempty"`
+	Substitutions        []ProductionSubstitutionResponse `json:"substitutions,omitempty"`
+}
+
+// ProductionSubstitutionResponse is the share of a component replaced by an alternate in a
+// production run. Quantities are in base units.
+type ProductionSubstitutionResponse struct {
+	ComponentItemID    uuid.UUID `json:"component_item_id"`
+	SubstituteItemID   uuid.UUID `json:"substitute_item_id"`
+	Share              float64   `json:"share"`
+	Quantity           float64   `json:"quantity"`
+	SubstituteQuantity float64   `json:"substitute_quantity"`
+	Chosen             bool      `json:"chosen"`
+}
+
+func NewProductionRecordResponse(r *bom.ProductionRecord) ProductionRecordResponse {
+	var substitutions []ProductionSubstitutionResponse
+	for _, s := range r.Substitutions {
+		substitutions = append(substitutions, ProductionSubstitutionResponse{
+			ComponentItemID:    s.ComponentItemID,
+			SubstituteItemID:   s.SubstituteItemID,
+			Share:              s.Share,
+			Quantity:           s.Quantity,
+			SubstituteQuantity: s.SubstituteQuantity,
+			Chosen:             s.Chosen,
+		})
+	}
+	return ProductionRecordResponse{
+		ID:                   r.ID,
+		BillOfMaterialsID:    r.BillOfMaterialsID,
+		ManufacturingOrderID: r.ManufacturingOrderID,
+		ProducedProductID:    r.ProducedProductID,
+		ProductionQuantity:   r.ProductionQuantity,
+		ActualProductionCost: r.ActualProductionCost,
+		DisassembledQuantity: r.DisassembledQuantity,
+		WarehouseID:          r.WarehouseID,
+		ProducedAt:           r.ProducedAt,
+		ReversedAt:           r.ReversedAt,
+		ReversedBy:           r.ReversedBy,
+		ReversalReason:       r.ReversalReason,
+		Substitutions:        substitutions,
+	}
+}
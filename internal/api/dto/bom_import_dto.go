@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"doligo_001/internal/api/sanitizer"
+	"github.com/google/uuid"
+)
+
+// BOMRowRequest is a component line of a bulk BOM import. The rows of a product with the same
+// revision make up one BOM; the revision only groups rows, imported BOMs are new drafts.
+// Exported rows carry the same fields, so an export can be imported back.
+type BOMRowRequest struct {
+	ProductID       string  `json:"product_id" validate:"required,uuid"`
+	Revision        int     `json:"revision" validate:"gte=0"`
+	Name            string  `json:"name" validate:"required,max=255"`
+	YieldPercent    float64 `json:"yield_percent" validate:"omitempty,gt=0,lte=100"` // Defaults to 100
+	ComponentItemID string  `json:"component_item_id" validate:"required,uuid"`
+	Quantity        float64 `json:"quantity" validate:"required,gt=0"`
+	ScrapPercent    float64 `json:"scrap_percent" validate:"gte=0"`
+	UnitOfMeasure   string  `json:"unit_of_measure" validate:"required,max=50"`
+}
+
+func (r *BOMRowRequest) Sanitize() {
+	r.Name = sanitizer.SanitizeString(r.Name)
+	r.UnitOfMeasure = sanitizer.SanitizeString(r.UnitOfMeasure)
+}
+
+// ImportBOMsRequest is a bulk import of BOMs, one row per component. Rows are validated one
+// by one by the handler so that every rejected row can be reported.
+type ImportBOMsRequest struct {
+	Rows []BOMRowRequest `json:"rows"`
+}
+
+func (r *ImportBOMsRequest) Sanitize() {
+	for i := range r.Rows {
+		r.Rows[i].Sanitize()
+	}
+}
+
+// ExportBOMsRequest holds the query parameters of a BOM export.
+type ExportBOMsRequest struct {
+	ProductID string `query:"productId" validate:"omitempty,uuid"`
+	Status    string `query:"status" validate:"omitempty,oneof=DRAFT APPROVED OBSOLETE"`
+	Active    string `query:"active" validate:"omitempty,oneof=true false"`
+	Format    string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// BOMImportRowResponse is the outcome of a row of a bulk BOM import. Lines are numbered from 1.
+type BOMImportRowResponse struct {
+	Line      int        `json:"line"`
+	ProductID uuid.UUID  `json:"product_id"`
+	Status    string     `json:"status"`
+	BOMID     *uuid.UUID `json:"bom_id,omitempty"`
+	Revision  int        `json:"revision,omitempty"`
+	Errors    []string   `json:"errors,omitempty"`
+}
+
+// BOMImportResponse is the per-row report of a bulk BOM import. Nothing is created by a dry
+// run or by an import with a rejected row.
+type BOMImportResponse struct {
+	DryRun  bool                   `json:"dry_run"`
+	BOMs    int                    `json:"boms"`
+	Created int                    `json:"created"`
+	Rows    []BOMImportRowResponse `json:"rows"`
+}
+
+// BOMRowResponse is a component line of a BOM export, with the fields of an import row.
+type BOMRowResponse struct {
+	BOMID           uuid.UUID `json:"bom_id"`
+	ProductID       uuid.UUID `json:"product_id"`
+	Revision        int       `json:"revision"`
+	Status          string    `json:"status"`
+	IsActive        bool      `json:"is_active"`
+	Name            string    `json:"name"`
+	YieldPercent    float64   `json:"yield_percent"`
+	ComponentItemID uuid.UUID `json:"component_item_id"`
+	Quantity        float64   `json:"quantity"`
+	ScrapPercent    float64   `json:"scrap_percent"`
+	UnitOfMeasure   string    `json:"unit_of_measure"`
+}
+
+// BOMExportResponse lists the component lines of the exported BOMs in the shape of an import.
+type BOMExportResponse struct {
+	Rows []BOMRowResponse `json:"rows"`
+}
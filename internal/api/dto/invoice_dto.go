@@ -7,26 +7,70 @@ import (
 	"doligo_001/internal/api/sanitizer"
 )
 
+// CreateInvoiceRequest creates a draft invoice. Its number is assigned by the invoice
+// numbering sequence at validation.
 type CreateInvoiceRequest struct {
 	ThirdPartyID string                `json:"third_party_id" validate:"required,uuid"`
-	Number       string                `json:"number" validate:"required"`
 	Date         string                `json:"date" validate:"required,datetime=2006-01-02"`
+	// PaymentTermDays defaults to the payment term of the customer.
+	PaymentTermDays *int               `json:"payment_term_days" validate:"omitempty,min=0,max=365"`
 	Lines        []CreateInvoiceLineRequest `json:"lines" validate:"required,min=1"`
 }
 
 func (r *CreateInvoiceRequest) Sanitize() {
-	r.Number = sanitizer.SanitizeString(r.Number)
 	for i := range r.Lines {
 		r.Lines[i].Sanitize()
 	}
 }
 
+// UpdateInvoiceRequest replaces the header and the lines of a draft invoice.
+type UpdateInvoiceRequest struct {
+	ThirdPartyID string                     `json:"third_party_id" validate:"required,uuid"`
+	Date         string                     `json:"date" validate:"required,datetime=2006-01-02"`
+	// PaymentTermDays defaults to the current term of the invoice, or to the payment term of
+	// the new customer when the customer changes.
+	PaymentTermDays *int                    `json:"payment_term_days" validate:"omitempty,min=0,max=365"`
+	Lines        []CreateInvoiceLineRequest `json:"lines" validate:"required,min=1,dive"`
+}
+
+func (r *UpdateInvoiceRequest) Sanitize() {
+	for i := range r.Lines {
+		r.Lines[i].Sanitize()
+	}
+}
+
+// CancelInvoiceRequest cancels an invoice nothing was paid on.
+type CancelInvoiceRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+func (r *CancelInvoiceRequest) Sanitize() {
+	r.Reason = sanitizer.SanitizeString(r.Reason)
+}
+
+// CreateCreditNoteRequest creates a draft credit note for an issued invoice. Without lines, it
+// credits all that is left to credit on the invoice. With a return warehouse and bin, the credited
+// goods are received back into the bin when the credit note is validated.
+type CreateCreditNoteRequest struct {
+	Date              string                    `json:"date" validate:"required,datetime=2006-01-02"`
+	ReturnWarehouseID string                    `json:"return_warehouse_id" validate:"omitempty,uuid"`
+	ReturnBinID       string                    `json:"return_bin_id" validate:"required_with=ReturnWarehouseID,omitempty,uuid"`
+	Lines             []CreditNoteLineRequest   `json:"lines" validate:"omitempty,dive"`
+}
+
+// CreditNoteLineRequest credits a quantity of an invoice line, in the unit of the line.
+type CreditNoteLineRequest struct {
+	InvoiceLineID string  `json:"invoice_line_id" validate:"required,uuid"`
+	Quantity      float64 `json:"quantity" validate:"required,gt=0"`
+}
+
 type CreateInvoiceLineRequest struct {
-	ItemID      string  `json:"item_id" validate:"required,uuid"`
-	Description string  `json:"description" validate:"required"`
-	Quantity    float64 `json:"quantity" validate:"required,gt=0"`
-	UnitPrice   float64 `json:"unit_price" validate:"required,gte=0"`
-	TaxRate     float64 `json:"tax_rate" validate:"gte=0"`
+	ItemID        string  `json:"item_id" validate:"required,uuid"`
+	Description   string  `json:"description" validate:"required"`
+	Quantity      float64 `json:"quantity" validate:"required,gt=0"`
+	UnitOfMeasure string  `json:"unit_of_measure" validate:"omitempty,max=20"`
+	UnitPrice     float64 `json:"unit_price" validate:"required,gte=0"`
+	TaxRate       float64 `json:"tax_rate" validate:"gte=0"`
 }
 
 func (r *CreateInvoiceLineRequest) Sanitize() {
@@ -36,8 +80,15 @@ func (r *CreateInvoiceLineRequest) Sanitize() {
 type InvoiceResponse struct {
 	ID           uuid.UUID           `json:"id"`
 	ThirdPartyID uuid.UUID           `json:"third_party_id"`
+	DocumentType string              `json:"document_type"`
+	OriginalInvoiceID *uuid.UUID     `json:"original_invoice_id,omitempty"`
 	Number       string              `json:"number"`
 	Date         time.Time           `json:"date"`
+	PaymentTermDays int              `json:"payment_term_days"`
+	DueDate      time.Time           `json:"due_date"`
+	Status       string              `json:"status"`
+	AmountPaid   float64             `json:"amount_paid"`
+	AmountCredited float64           `json:"amount_credited"`
 	TotalAmount  float64             `json:"total_amount"`
 	TotalCost    float64             `json:"total_cost"`
 	TotalTax     float64             `json:"total_tax"`
@@ -47,19 +98,22 @@ type InvoiceResponse struct {
 }
 
 type InvoiceLineResponse struct {
-	ID          uuid.UUID `json:"id"`
-	ItemID      uuid.UUID `json:"item_id"`
-	Description string    `json:"description"`
-	Quantity    float64   `json:"quantity"`
-	UnitPrice   float64   `json:"unit_price"`
-	UnitCost    float64   `json:"unit_cost"`
-	TaxRate     float64   `json:"tax_rate"`
-	TaxAmount   float64   `json:"tax_amount"`
-	NetPrice    float64   `json:"net_price"`
-	TotalAmount float64   `json:"total_amount"`
-	TotalCost   float64   `json:"total_cost"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uuid.UUID `json:"id"`
+	OriginalLineID *uuid.UUID `json:"original_line_id,omitempty"`
+	ItemID        uuid.UUID `json:"item_id"`
+	Description   string    `json:"description"`
+	Quantity      float64   `json:"quantity"`
+	UnitOfMeasure string    `json:"unit_of_measure,omitempty"`
+	BaseQuantity  float64   `json:"base_quantity"`
+	UnitPrice     float64   `json:"unit_price"`
+	UnitCost      float64   `json:"unit_cost"`
+	TaxRate       float64   `json:"tax_rate"`
+	TaxAmount     float64   `json:"tax_amount"`
+	NetPrice      float64   `json:"net_price"`
+	TotalAmount   float64   `json:"total_amount"`
+	TotalCost     float64   `json:"total_cost"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type InvoicePDFStatusResponse struct {
@@ -10,11 +10,16 @@ import (
 
 // CreateItemRequest defines the structure for creating a new item.
 type CreateItemRequest struct {
-	Name        string  `json:"name" validate:"required,min=2,max=255"`
-	Description string  `json:"description"`
-	Type        string  `json:"type" validate:"required,oneof=STORABLE SERVICE"`
-	CostPrice   float64 `json:"cost_price" validate:"gte=0"`
-	SalePrice   float64 `json:"sale_price" validate:"gte=0"`
+	Name          string  `json:"name" validate:"required,min=2,max=255"`
+	Description   string  `json:"description"`
+	Type          string  `json:"type" validate:"required,oneof=STORABLE SERVICE"`
+	CostPrice     float64 `json:"cost_price" validate:"gte=0"`
+	SalePrice     float64 `json:"sale_price" validate:"gte=0"`
+	TrackingMode  string  `json:"tracking_mode" validate:"omitempty,oneof=NONE LOT SERIAL"`
+	CostingMethod string  `json:"costing_method" validate:"omitempty,oneof=AVERAGE FIFO STANDARD"`
+	StandardCost  float64 `json:"standard_cost" validate:"gte=0"`
+	BaseUnit      string  `json:"base_unit" validate:"omitempty,max=20"` // Unit of measure code, e.g. "kg"
+	LeadTimeDays  int     `json:"lead_time_days" validate:"gte=0"`
 }
 
 func (r *CreateItemRequest) Sanitize() {
@@ -24,12 +29,17 @@ func (r *CreateItemRequest) Sanitize() {
 
 // UpdateItemRequest defines the structure for updating an existing item.
 type UpdateItemRequest struct {
-	Name        string  `json:"name" validate:"required,min=2,max=255"`
-	Description string  `json:"description"`
-	Type        string  `json:"type" validate:"required,oneof=STORABLE SERVICE"`
-	CostPrice   float64 `json:"cost_price" validate:"gte=0"`
-	SalePrice   float64 `json:"sale_price" validate:"gte=0"`
-	IsActive    bool    `json:"is_active"`
+	Name          string  `json:"name" validate:"required,min=2,max=255"`
+	Description   string  `json:"description"`
+	Type          string  `json:"type" validate:"required,oneof=STORABLE SERVICE"`
+	CostPrice     float64 `json:"cost_price" validate:"gte=0"`
+	SalePrice     float64 `json:"sale_price" validate:"gte=0"`
+	TrackingMode  string  `json:"tracking_mode" validate:"omitempty,oneof=NONE LOT SERIAL"`
+	CostingMethod string  `json:"costing_method" validate:"omitempty,oneof=AVERAGE FIFO STANDARD"`
+	StandardCost  float64 `json:"standard_cost" validate:"gte=0"`
+	BaseUnit      string  `json:"base_unit" validate:"omitempty,max=20"` // Can only be set while empty
+	LeadTimeDays  int     `json:"lead_time_days" validate:"gte=0"`
+	IsActive      bool    `json:"is_active"`
 }
 
 func (r *UpdateItemRequest) Sanitize() {
@@ -39,34 +49,44 @@ func (r *UpdateItemRequest) Sanitize() {
 
 // ItemResponse defines the structure for an item response.
 type ItemResponse struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Type        string    `json:"type"`
-	CostPrice   float64   `json:"cost_price"`
-	SalePrice   float64   `json:"sale_price"`
-	AverageCost float64   `json:"average_cost"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CreatedBy   uuid.UUID `json:"created_by"`
-	UpdatedBy   uuid.UUID `json:"updated_by"`
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Type          string    `json:"type"`
+	CostPrice     float64   `json:"cost_price"`
+	SalePrice     float64   `json:"sale_price"`
+	AverageCost   float64   `json:"average_cost"`
+	StandardCost  float64   `json:"standard_cost"`
+	CostingMethod string    `json:"costing_method"`
+	TrackingMode  string    `json:"tracking_mode"`
+	BaseUnit      string    `json:"base_unit,omitempty"`
+	LeadTimeDays  int       `json:"lead_time_days"`
+	IsActive      bool      `json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedBy     uuid.UUID `json:"created_by"`
+	UpdatedBy     uuid.UUID `json:"updated_by"`
 }
 
 // NewItemResponse creates a response DTO from a domain entity.
 func NewItemResponse(i *item.Item) *ItemResponse {
 	return &ItemResponse{
-		ID:          i.ID,
-		Name:        i.Name,
-		Description: i.Description,
-		Type:        string(i.Type),
-		CostPrice:   i.CostPrice,
-		SalePrice:   i.SalePrice,
-		AverageCost: i.AverageCost,
-		IsActive:    i.IsActive,
-		CreatedAt:   i.CreatedAt,
-		UpdatedAt:   i.UpdatedAt,
-		CreatedBy:   i.CreatedBy,
-		UpdatedBy:   i.UpdatedBy,
+		ID:            i.ID,
+		Name:          i.Name,
+		Description:   i.Description,
+		Type:          string(i.Type),
+		CostPrice:     i.CostPrice,
+		SalePrice:     i.SalePrice,
+		AverageCost:   i.AverageCost,
+		StandardCost:  i.StandardCost,
+		CostingMethod: string(i.CostingMethod),
+		TrackingMode:  string(i.TrackingMode),
+		BaseUnit:      i.BaseUnit,
+		LeadTimeDays:  i.LeadTimeDays,
+		IsActive:      i.IsActive,
+		CreatedAt:     i.CreatedAt,
+		UpdatedAt:     i.UpdatedAt,
+		CreatedBy:     i.CreatedBy,
+		UpdatedBy:     i.UpdatedBy,
 	}
 }
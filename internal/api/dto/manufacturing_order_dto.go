@@ -0,0 +1,151 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/domain/bom"
+	"github.com/google/uuid"
+)
+
+// --- Manufacturing Order DTOs ---
+
+// CreateManufacturingOrderRequest plans the production of a quantity of a product.
+type CreateManufacturingOrderRequest struct {
+	ProductID    string     `json:"product_id" validate:"required,uuid"`
+	WarehouseID  string     `json:"warehouse_id" validate:"required,uuid"`
+	Quantity     float64    `json:"quantity" validate:"required,gt=0"`
+	PlannedStart *time.Time `json:"planned_start"`
+	PlannedEnd   *time.Time `json:"planned_end"`
+}
+
+// ListManufacturingOrdersRequest holds the query parameters of a manufacturing order listing.
+type ListManufacturingOrdersRequest struct {
+	ProductID   string `query:"productId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	Status      string `query:"status" validate:"omitempty,oneof=PLANNED RELEASED IN_PROGRESS COMPLETED CANCELLED"`
+}
+
+// IssueComponentsRequest lists the component quantities issued to an order in one step.
+type IssueComponentsRequest struct {
+	Components []ComponentIssueRequest `json:"components" validate:"required,min=1,dive"`
+}
+
+func (r *IssueComponentsRequest) Sanitize() {
+	for i := range r.Components {
+		for j := range r.Components[i].Lots {
+			r.Components[i].Lots[j].Sanitize()
+		}
+	}
+}
+
+// ComponentIssueRequest is a quantity of one component issued to an order.
+type ComponentIssueRequest struct {
+	ComponentItemID string               `json:"component_item_id" validate:"required,uuid"`
+	Quantity        float64              `json:"quantity" validate:"required,gt=0"`
+	Lots            []LotQuantityRequest `json:"lots" validate:"omitempty,dive"` // Required for tracked components
+}
+
+// ReceiveProductsRequest is a quantity of the product received from an order.
+type ReceiveProductsRequest struct {
+	Quantity float64              `json:"quantity" validate:"required,gt=0"`
+	Lots     []LotQuantityRequest `json:"lots" validate:"omitempty,dive"` // Required for a tracked product
+}
+
+func (r *ReceiveProductsRequest) Sanitize() {
+	for i := range r.Lots {
+		r.Lots[i].Sanitize()
+	}
+}
+
+type ManufacturingOrderResponse struct {
+	ID                 uuid.UUID                             `json:"id"`
+	ProductID          uuid.UUID                             `json:"product_id"`
+	BillOfMaterialsID  uuid.UUID                             `json:"bill_of_materials_id"`
+	WarehouseID        uuid.UUID                             `json:"warehouse_id"`
+	Quantity           float64                               `json:"quantity"`
+	ProducedQuantity   float64                               `json:"produced_quantity"`
+	ScrapQuantity      float64                               `json:"scrap_quantity"`
+	Status             string                                `json:"status"`
+	PlannedStart       *time.Time                            `json:"planned_start,omitempty"`
+	PlannedEnd         *time.Time                            `json:"planned_end,omitempty"`
+	ReleasedAt         *time.Time                            `json:"released_at,omitempty"`
+	StartedAt          *time.Time                            `json:"started_at,omitempty"`
+	CompletedAt        *time.Time                            `json:"completed_at,omitempty"`
+	ActualCost         float64                               `json:"actual_cost"`
+	ReceivedCost       float64                               `json:"received_cost"`
+	CostVariance       float64                               `json:"cost_variance"` // ActualCost - ReceivedCost
+	ProductionRecordID *uuid.UUID                            `json:"production_record_id,omitempty"`
+	Components         []ManufacturingOrderComponentResponse `json:"components"`
+	Lots               []ManufacturingOrderLotResponse       `json:"lots"`
+	CreatedAt          time.Time                             `json:"created_at"`
+	UpdatedAt          time.Time                             `json:"updated_at"`
+	CreatedBy          uuid.UUID                             `json:"created_by"`
+	UpdatedBy          uuid.UUID                             `json:"updated_by"`
+}
+
+type ManufacturingOrderComponentResponse struct {
+	ItemID           uuid.UUID  `json:"item_id"`
+	QuantityPer      float64    `json:"quantity_per"`
+	RequiredQuantity float64    `json:"required_quantity"`
+	IssuedQuantity   float64    `json:"issued_quantity"`
+	IssuedCost       float64    `json:"issued_cost"`
+	ScrapQuantity    float64    `json:"scrap_quantity"`
+	UnitOfMeasure    string     `json:"unit_of_measure"`
+	ReservationID    *uuid.UUID `json:"reservation_id,omitempty"`
+}
+
+type ManufacturingOrderLotResponse struct {
+	ItemID    uuid.UUID `json:"item_id"`
+	LotNumber string    `json:"lot_number"`
+	Quantity  float64   `json:"quantity"`
+	Role      string    `json:"role"`
+}
+
+func NewManufacturingOrderResponse(o *bom.ManufacturingOrder) *ManufacturingOrderResponse {
+	res := &ManufacturingOrderResponse{
+		ID:                 o.ID,
+		ProductID:          o.ProductID,
+		BillOfMaterialsID:  o.BillOfMaterialsID,
+		WarehouseID:        o.WarehouseID,
+		Quantity:           o.Quantity,
+		ProducedQuantity:   o.ProducedQuantity,
+		ScrapQuantity:      o.ScrapQuantity,
+		Status:             string(o.Status),
+		PlannedStart:       o.PlannedStart,
+		PlannedEnd:         o.PlannedEnd,
+		ReleasedAt:         o.ReleasedAt,
+		StartedAt:          o.StartedAt,
+		CompletedAt:        o.CompletedAt,
+		ActualCost:         o.ActualCost,
+		ReceivedCost:       o.ReceivedCost,
+		CostVariance:       o.ActualCost - o.ReceivedCost,
+		ProductionRecordID: o.ProductionRecordID,
+		Components:         make([]ManufacturingOrderComponentResponse, len(o.Components)),
+		Lots:               make([]ManufacturingOrderLotResponse, len(o.Lots)),
+		CreatedAt:          o.CreatedAt,
+		UpdatedAt:          o.UpdatedAt,
+		CreatedBy:          o.CreatedBy,
+		UpdatedBy:          o.UpdatedBy,
+	}
+	for i, c := range o.Components {
+		res.Components[i] = ManufacturingOrderComponentResponse{
+			ItemID:           c.ItemID,
+			QuantityPer:      c.QuantityPer,
+			RequiredQuantity: c.RequiredQuantity,
+			IssuedQuantity:   c.IssuedQuantity,
+			IssuedCost:       c.IssuedCost,
+			ScrapQuantity:    c.ScrapQuantity,
+			UnitOfMeasure:    c.UnitOfMeasure,
+			ReservationID:    c.ReservationID,
+		}
+	}
+	for i, l := range o.Lots {
+		res.Lots[i] = ManufacturingOrderLotResponse{
+			ItemID:    l.ItemID,
+			LotNumber: l.LotNumber,
+			Quantity:  l.Quantity,
+			Role:      string(l.Role),
+		}
+	}
+	return res
+}
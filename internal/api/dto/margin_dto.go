@@ -10,3 +10,10 @@ type OverallMarginReportRequest struct {
 	StartDate string `query:"startDate" validate:"required,datetime=2006-01-02"`
 	EndDate   string `query:"endDate" validate:"required,datetime=2006-01-02"`
 }
+
+// ARAgingReportRequest holds the query parameters of the accounts receivable aging report.
+// Without asOf the balances are aged as of today.
+type ARAgingReportRequest struct {
+	AsOf   string `query:"asOf" validate:"omitempty,datetime=2006-01-02"`
+	Format string `query:"format" validate:"omitempty,oneof=json csv"`
+}
@@ -0,0 +1,148 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/mrp"
+	"github.com/google/uuid"
+)
+
+// --- MRP DTOs ---
+
+// CreateForecastRequest enters a demand expected for an item in a warehouse on a date.
+type CreateForecastRequest struct {
+	ItemID      string  `json:"item_id" validate:"required,uuid"`
+	WarehouseID string  `json:"warehouse_id" validate:"required,uuid"`
+	Quantity    float64 `json:"quantity" validate:"required,gt=0"` // In the base unit of the item
+	DueDate     string  `json:"due_date" validate:"required,datetime=2006-01-02"`
+	Notes       string  `json:"notes" validate:"max=1000"`
+}
+
+func (r *CreateForecastRequest) Sanitize() {
+	r.Notes = sanitizer.SanitizeString(r.Notes)
+}
+
+// UpdateForecastRequest replaces the quantity, due date and notes of a forecast.
+type UpdateForecastRequest struct {
+	Quantity float64 `json:"quantity" validate:"required,gt=0"`
+	DueDate  string  `json:"due_date" validate:"required,datetime=2006-01-02"`
+	Notes    string  `json:"notes" validate:"max=1000"`
+}
+
+func (r *UpdateForecastRequest) Sanitize() {
+	r.Notes = sanitizer.SanitizeString(r.Notes)
+}
+
+// ListForecastsRequest holds the query parameters of a forecast search.
+type ListForecastsRequest struct {
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// StartMrpRunRequest queues an MRP run of a warehouse up to the end of its horizon.
+type StartMrpRunRequest struct {
+	WarehouseID     string `json:"warehouse_id" validate:"required,uuid"`
+	HorizonEnd      string `json:"horizon_end" validate:"required,datetime=2006-01-02"`
+	IncludeInvoices bool   `json:"include_invoices"`
+}
+
+// ListMrpRunsRequest holds the query parameters of an MRP run search.
+type ListMrpRunsRequest struct {
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+}
+
+// ListPlannedOrdersRequest holds the query parameters of a planned order search.
+type ListPlannedOrdersRequest struct {
+	ItemID string `query:"itemId" validate:"omitempty,uuid"`
+	Type   string `query:"type" validate:"omitempty,oneof=PURCHASE PRODUCTION"`
+}
+
+type ForecastResponse struct {
+	ID          uuid.UUID `json:"id"`
+	ItemID      uuid.UUID `json:"item_id"`
+	WarehouseID uuid.UUID `json:"warehouse_id"`
+	Quantity    float64   `json:"quantity"`
+	DueDate     time.Time `json:"due_date"`
+	Notes       string    `json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	UpdatedBy   uuid.UUID `json:"updated_by"`
+}
+
+func NewForecastResponse(f *mrp.Forecast) *ForecastResponse {
+	return &ForecastResponse{
+		ID:          f.ID,
+		ItemID:      f.ItemID,
+		WarehouseID: f.WarehouseID,
+		Quantity:    f.Quantity,
+		DueDate:     f.DueDate,
+		Notes:       f.Notes,
+		CreatedAt:   f.CreatedAt,
+		UpdatedAt:   f.UpdatedAt,
+		CreatedBy:   f.CreatedBy,
+		UpdatedBy:   f.UpdatedBy,
+	}
+}
+
+type MrpRunResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	WarehouseID       uuid.UUID  `json:"warehouse_id"`
+	HorizonEnd        time.Time  `json:"horizon_end"`
+	IncludeInvoices   bool       `json:"include_invoices"`
+	Status            string     `json:"status"`
+	ErrorMessage      string     `json:"error_message,omitempty"`
+	PlannedOrderCount int        `json:"planned_order_count"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	CreatedBy         uuid.UUID  `json:"created_by"`
+}
+
+func NewMrpRunResponse(r *mrp.Run) *MrpRunResponse {
+	return &MrpRunResponse{
+		ID:                r.ID,
+		WarehouseID:       r.WarehouseID,
+		HorizonEnd:        r.HorizonEnd,
+		IncludeInvoices:   r.IncludeInvoices,
+		Status:            string(r.Status),
+		ErrorMessage:      r.ErrorMessage,
+		PlannedOrderCount: r.PlannedOrderCount,
+		StartedAt:         r.StartedAt,
+		CompletedAt:       r.CompletedAt,
+		CreatedAt:         r.CreatedAt,
+		CreatedBy:         r.CreatedBy,
+	}
+}
+
+type PlannedOrderResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	RunID       uuid.UUID  `json:"run_id"`
+	ItemID      uuid.UUID  `json:"item_id"`
+	WarehouseID uuid.UUID  `json:"warehouse_id"`
+	Type        string     `json:"type"`
+	BOMID       *uuid.UUID `json:"bom_id,omitempty"`
+	Level       int        `json:"level"`
+	Quantity    float64    `json:"quantity"`
+	StartDate   time.Time  `json:"start_date"`
+	DueDate     time.Time  `json:"due_date"`
+	PastDue     bool       `json:"past_due"`
+}
+
+func NewPlannedOrderResponse(o *mrp.PlannedOrder) *PlannedOrderResponse {
+	return &PlannedOrderResponse{
+		ID:          o.ID,
+		RunID:       o.RunID,
+		ItemID:      o.ItemID,
+		WarehouseID: o.WarehouseID,
+		Type:        string(o.Type),
+		BOMID:       o.BOMID,
+		Level:       o.Level,
+		Quantity:    o.Quantity,
+		StartDate:   o.StartDate,
+		DueDate:     o.DueDate,
+		PastDue:     o.PastDue,
+	}
+}
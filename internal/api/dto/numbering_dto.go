@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/numbering"
+	"github.com/google/uuid"
+)
+
+// UpdateNumberingSequenceRequest changes the pattern of a numbering sequence, e.g.
+// INV-{YYYY}-{0000}, and when its counter starts again from 1.
+type UpdateNumberingSequenceRequest struct {
+	Pattern string `json:"pattern" validate:"required,max=100"`
+	Reset   string `json:"reset" validate:"required,oneof=NEVER YEARLY"`
+}
+
+func (r *UpdateNumberingSequenceRequest) Sanitize() {
+	r.Pattern = sanitizer.SanitizeString(r.Pattern)
+}
+
+// NumberingSequenceResponse is the numbering of a document type, with an example of the
+// first number it gives this year.
+type NumberingSequenceResponse struct {
+	ID           uuid.UUID `json:"id"`
+	DocumentType string    `json:"document_type"`
+	Pattern      string    `json:"pattern"`
+	Reset        string    `json:"reset"`
+	Example      string    `json:"example"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	UpdatedBy    uuid.UUID `json:"updated_by"`
+}
+
+func NewNumberingSequenceResponse(s *numbering.Sequence) *NumberingSequenceResponse {
+	return &NumberingSequenceResponse{
+		ID:           s.ID,
+		DocumentType: string(s.DocumentType),
+		Pattern:      s.Pattern,
+		Reset:        string(s.Reset),
+		Example:      s.Format(1, time.Now()),
+		UpdatedAt:    s.UpdatedAt,
+		UpdatedBy:    s.UpdatedBy,
+	}
+}
@@ -0,0 +1,145 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/payment"
+	"github.com/google/uuid"
+)
+
+// --- Payment DTOs ---
+
+// CreatePaymentRequest records a payment received from a customer and allocates it to invoices
+// of the customer. The part left unallocated is kept as a credit of the customer.
+type CreatePaymentRequest struct {
+	ThirdPartyID string                     `json:"third_party_id" validate:"required,uuid"`
+	Date         string                     `json:"date" validate:"required,datetime=2006-01-02"`
+	Amount       float64                    `json:"amount" validate:"required,gt=0"`
+	Method       string                     `json:"method" validate:"required,oneof=CASH BANK_TRANSFER CARD CHECK OTHER"`
+	Reference    string                     `json:"reference" validate:"max=100"`
+	Allocations  []PaymentAllocationRequest `json:"allocations" validate:"omitempty,dive"`
+}
+
+func (r *CreatePaymentRequest) Sanitize() {
+	r.Reference = sanitizer.SanitizeString(r.Reference)
+}
+
+// PaymentAllocationRequest allocates an amount of a payment to an invoice.
+type PaymentAllocationRequest struct {
+	InvoiceID string  `json:"invoice_id" validate:"required,uuid"`
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// AllocatePaymentRequest allocates the unallocated part of a payment to invoices.
+type AllocatePaymentRequest struct {
+	Allocations []PaymentAllocationRequest `json:"allocations" validate:"required,min=1,dive"`
+}
+
+// InvoicePaymentRequest records a payment of the customer of an invoice, allocated in full to
+// the invoice. The date defaults to today and the method to OTHER.
+type InvoicePaymentRequest struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0"`
+	Date      string  `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	Method    string  `json:"method" validate:"omitempty,oneof=CASH BANK_TRANSFER CARD CHECK OTHER"`
+	Reference string  `json:"reference" validate:"max=100"`
+}
+
+func (r *InvoicePaymentRequest) Sanitize() {
+	r.Reference = sanitizer.SanitizeString(r.Reference)
+}
+
+// StatementRequest holds the query parameters of a customer statement. Without from, the
+// statement starts with the first document of the customer.
+type StatementRequest struct {
+	From string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type PaymentResponse struct {
+	ID                uuid.UUID                   `json:"id"`
+	ThirdPartyID      uuid.UUID                   `json:"third_party_id"`
+	Date              time.Time                   `json:"date"`
+	Amount            float64                     `json:"amount"`
+	Method            string                      `json:"method"`
+	Reference         string                      `json:"reference,omitempty"`
+	UnallocatedAmount float64                     `json:"unallocated_amount"`
+	Allocations       []PaymentAllocationResponse `json:"allocations"`
+	CreatedAt         time.Time                   `json:"created_at"`
+	CreatedBy         uuid.UUID                   `json:"created_by"`
+}
+
+type PaymentAllocationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	InvoiceID uuid.UUID `json:"invoice_id"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewPaymentResponse(p *payment.Payment) *PaymentResponse {
+	allocations := make([]PaymentAllocationResponse, len(p.Allocations))
+	for i, a := range p.Allocations {
+		allocations[i] = PaymentAllocationResponse{
+			ID:        a.ID,
+			InvoiceID: a.InvoiceID,
+			Amount:    a.Amount,
+			CreatedAt: a.CreatedAt,
+		}
+	}
+	return &PaymentResponse{
+		ID:                p.ID,
+		ThirdPartyID:      p.ThirdPartyID,
+		Date:              p.Date,
+		Amount:            p.Amount,
+		Method:            string(p.Method),
+		Reference:         p.Reference,
+		UnallocatedAmount: p.UnallocatedAmount,
+		Allocations:       allocations,
+		CreatedAt:         p.CreatedAt,
+		CreatedBy:         p.CreatedBy,
+	}
+}
+
+type StatementResponse struct {
+	ThirdPartyID      uuid.UUID                `json:"third_party_id"`
+	From              *time.Time               `json:"from,omitempty"`
+	To                *time.Time               `json:"to,omitempty"`
+	OpeningBalance    float64                  `json:"opening_balance"`
+	Entries           []StatementEntryResponse `json:"entries"`
+	ClosingBalance    float64                  `json:"closing_balance"`
+	UnallocatedCredit float64                  `json:"unallocated_credit"`
+}
+
+type StatementEntryResponse struct {
+	Date       time.Time `json:"date"`
+	Type       string    `json:"type"`
+	DocumentID uuid.UUID `json:"document_id"`
+	Reference  string    `json:"reference"`
+	Debit      float64   `json:"debit"`
+	Credit     float64   `json:"credit"`
+	Balance    float64   `json:"balance"`
+}
+
+func NewStatementResponse(s *payment.Statement) *StatementResponse {
+	entries := make([]StatementEntryResponse, len(s.Entries))
+	for i, e := range s.Entries {
+		entries[i] = StatementEntryResponse{
+			Date:       e.Date,
+			Type:       string(e.Type),
+			DocumentID: e.DocumentID,
+			Reference:  e.Reference,
+			Debit:      e.Debit,
+			Credit:     e.Credit,
+			Balance:    e.Balance,
+		}
+	}
+	return &StatementResponse{
+		ThirdPartyID:      s.ThirdPartyID,
+		From:              s.From,
+		To:                s.To,
+		OpeningBalance:    s.OpeningBalance,
+		Entries:           entries,
+		ClosingBalance:    s.ClosingBalance,
+		UnallocatedCredit: s.UnallocatedCredit,
+	}
+}
@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/domain/replenishment"
+	"github.com/google/uuid"
+)
+
+// --- Replenishment DTOs ---
+
+// CreateReorderRuleRequest sets the min/max and reorder-point levels of an item in a warehouse.
+type CreateReorderRuleRequest struct {
+	ItemID       string  `json:"item_id" validate:"required,uuid"`
+	WarehouseID  string  `json:"warehouse_id" validate:"required,uuid"`
+	MinQuantity  float64 `json:"min_quantity" validate:"gte=0"`
+	ReorderPoint float64 `json:"reorder_point" validate:"gte=0"`
+	MaxQuantity  float64 `json:"max_quantity" validate:"required,gt=0"`
+}
+
+// UpdateReorderRuleRequest replaces the levels of a rule. IsActive is kept when omitted.
+type UpdateReorderRuleRequest struct {
+	MinQuantity  float64 `json:"min_quantity" validate:"gte=0"`
+	ReorderPoint float64 `json:"reorder_point" validate:"gte=0"`
+	MaxQuantity  float64 `json:"max_quantity" validate:"required,gt=0"`
+	IsActive     *bool   `json:"is_active"`
+}
+
+// ListReorderRulesRequest holds the query parameters of a reorder rule search.
+type ListReorderRulesRequest struct {
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+}
+
+// ListProposalsRequest holds the query parameters of a proposal search.
+type ListProposalsRequest struct {
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	Type        string `query:"type" validate:"omitempty,oneof=PURCHASE PRODUCTION"`
+}
+
+type ReorderRuleResponse struct {
+	ID           uuid.UUID `json:"id"`
+	ItemID       uuid.UUID `json:"item_id"`
+	WarehouseID  uuid.UUID `json:"warehouse_id"`
+	MinQuantity  float64   `json:"min_quantity"`
+	ReorderPoint float64   `json:"reorder_point"`
+	MaxQuantity  float64   `json:"max_quantity"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CreatedBy    uuid.UUID `json:"created_by"`
+	UpdatedBy    uuid.UUID `json:"updated_by"`
+}
+
+func NewReorderRuleResponse(r *replenishment.ReorderRule) *ReorderRuleResponse {
+	return &ReorderRuleResponse{
+		ID:           r.ID,
+		ItemID:       r.ItemID,
+		WarehouseID:  r.WarehouseID,
+		MinQuantity:  r.MinQuantity,
+		ReorderPoint: r.ReorderPoint,
+		MaxQuantity:  r.MaxQuantity,
+		IsActive:     r.IsActive,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+		CreatedBy:    r.CreatedBy,
+		UpdatedBy:    r.UpdatedBy,
+	}
+}
+
+type ProposalResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	RunID           uuid.UUID  `json:"run_id"`
+	RuleID          uuid.UUID  `json:"rule_id"`
+	ItemID          uuid.UUID  `json:"item_id"`
+	WarehouseID     uuid.UUID  `json:"warehouse_id"`
+	Type            string     `json:"type"`
+	BOMID           *uuid.UUID `json:"bom_id,omitempty"`
+	Quantity        float64    `json:"quantity"`
+	OnHand          float64    `json:"on_hand"`
+	Reserved        float64    `json:"reserved"`
+	ComponentDemand float64    `json:"component_demand"`
+	Projected       float64    `json:"projected"`
+	ReorderPoint    float64    `json:"reorder_point"`
+	MinQuantity     float64    `json:"min_quantity"`
+	MaxQuantity     float64    `json:"max_quantity"`
+	BelowMinimum    bool       `json:"below_minimum"`
+	GeneratedAt     time.Time  `json:"generated_at"`
+}
+
+func NewProposalResponse(p *replenishment.Proposal) *ProposalResponse {
+	return &ProposalResponse{
+		ID:              p.ID,
+		RunID:           p.RunID,
+		RuleID:          p.RuleID,
+		ItemID:          p.ItemID,
+		WarehouseID:     p.WarehouseID,
+		Type:            string(p.Type),
+		BOMID:           p.BOMID,
+		Quantity:        p.Quantity,
+		OnHand:          p.OnHand,
+		Reserved:        p.Reserved,
+		ComponentDemand: p.ComponentDemand,
+		Projected:       p.Projected,
+		ReorderPoint:    p.ReorderPoint,
+		MinQuantity:     p.MinQuantity,
+		MaxQuantity:     p.MaxQuantity,
+		BelowMinimum:    p.BelowMinimum(),
+		GeneratedAt:     p.GeneratedAt,
+	}
+}
+
+func NewProposalListResponse(proposals []*replenishment.Proposal) []*ProposalResponse {
+	res := make([]*ProposalResponse, len(proposals))
+	for i, p := range proposals {
+		res[i] = NewProposalResponse(p)
+	}
+	return res
+}
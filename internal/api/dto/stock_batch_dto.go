@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+)
+
+// CreateStockMovementBatchRequest is a bulk import of stock movements, posted all or nothing.
+// Lines are validated one by one by the handler so that every rejected line can be reported.
+type CreateStockMovementBatchRequest struct {
+	Lines []CreateStockMovementRequest `json:"lines"`
+}
+
+func (r *CreateStockMovementBatchRequest) Sanitize() {
+	for i := range r.Lines {
+		r.Lines[i].Sanitize()
+	}
+}
+
+type StockMovementBatchResponse struct {
+	BatchID   uuid.UUID                `json:"batch_id"`
+	Replayed  bool                     `json:"replayed"`
+	Movements []*StockMovementResponse `json:"movements"`
+}
+
+func NewStockMovementBatchResponse(batchID uuid.UUID, replayed bool, movements []*stock.StockMovement) *StockMovementBatchResponse {
+	res := &StockMovementBatchResponse{
+		BatchID:   batchID,
+		Replayed:  replayed,
+		Movements: make([]*StockMovementResponse, len(movements)),
+	}
+	for i, m := range movements {
+		res.Movements[i] = NewStockMovementResponse(m)
+	}
+	return res
+}
+
+// BatchLineErrorResponse is the reason a line of a bulk import was rejected. Lines are numbered from 1.
+type BatchLineErrorResponse struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// StockMovementBatchErrorResponse lists the lines that caused a bulk import to be rejected.
+type StockMovementBatchErrorResponse struct {
+	Message string                   `json:"message"`
+	Errors  []BatchLineErrorResponse `json:"errors"`
+}
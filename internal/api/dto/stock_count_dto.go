@@ -0,0 +1,130 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+)
+
+// --- Inventory Count DTOs ---
+
+type StartCountRequest struct {
+	WarehouseID string   `json:"warehouse_id" validate:"required,uuid"`
+	BinIDs      []string `json:"bin_ids" validate:"omitempty,dive,uuid"`
+}
+
+type SubmitCountRequest struct {
+	ItemID   string  `json:"item_id" validate:"required,uuid"`
+	BinID    string  `json:"bin_id" validate:"required,uuid"`
+	DeviceID string  `json:"device_id" validate:"required,max=100"`
+	Quantity float64 `json:"quantity" validate:"gte=0"`
+}
+
+func (r *SubmitCountRequest) Sanitize() {
+	r.DeviceID = sanitizer.SanitizeString(r.DeviceID)
+}
+
+type CountEntryResponse struct {
+	ID        uuid.UUID `json:"id"`
+	DeviceID  string    `json:"device_id"`
+	Quantity  float64   `json:"quantity"`
+	CountedAt time.Time `json:"counted_at"`
+	CountedBy uuid.UUID `json:"counted_by"`
+}
+
+type CountLineResponse struct {
+	ID               uuid.UUID             `json:"id"`
+	ItemID           uuid.UUID             `json:"item_id"`
+	BinID            uuid.UUID             `json:"bin_id"`
+	ExpectedQuantity float64               `json:"expected_quantity"`
+	CountedQuantity  *float64              `json:"counted_quantity"`
+	Variance         float64               `json:"variance"`
+	Entries          []*CountEntryResponse `json:"entries"`
+}
+
+func NewCountLineResponse(l *stock.CountLine) *CountLineResponse {
+	res := &CountLineResponse{
+		ID:               l.ID,
+		ItemID:           l.ItemID,
+		BinID:            l.BinID,
+		ExpectedQuantity: l.ExpectedQuantity,
+		Variance:         l.Variance(),
+		Entries:          make([]*CountEntryResponse, len(l.Entries)),
+	}
+	if counted, ok := l.CountedQuantity(); ok {
+		res.CountedQuantity = &counted
+	}
+	for i, e := range l.Entries {
+		res.Entries[i] = &CountEntryResponse{
+			ID:        e.ID,
+			DeviceID:  e.DeviceID,
+			Quantity:  e.Quantity,
+			CountedAt: e.CountedAt,
+			CountedBy: e.CountedBy,
+		}
+	}
+	return res
+}
+
+type CountSessionResponse struct {
+	ID          uuid.UUID            `json:"id"`
+	WarehouseID uuid.UUID            `json:"warehouse_id"`
+	BinIDs      []uuid.UUID          `json:"bin_ids,omitempty"`
+	Status      string               `json:"status"`
+	Lines       []*CountLineResponse `json:"lines,omitempty"`
+	ApprovedAt  *time.Time           `json:"approved_at,omitempty"`
+	ApprovedBy  *uuid.UUID           `json:"approved_by,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CreatedBy   uuid.UUID            `json:"created_by"`
+}
+
+func NewCountSessionResponse(s *stock.CountSession) *CountSessionResponse {
+	res := &CountSessionResponse{
+		ID:          s.ID,
+		WarehouseID: s.WarehouseID,
+		BinIDs:      s.BinIDs,
+		Status:      string(s.Status),
+		ApprovedAt:  s.ApprovedAt,
+		ApprovedBy:  s.ApprovedBy,
+		CreatedAt:   s.CreatedAt,
+		CreatedBy:   s.CreatedBy,
+	}
+	for _, l := range s.Lines {
+		res.Lines = append(res.Lines, NewCountLineResponse(l))
+	}
+	return res
+}
+
+type CountAdjustmentResponse struct {
+	LineID   uuid.UUID              `json:"line_id"`
+	ItemID   uuid.UUID              `json:"item_id"`
+	BinID    uuid.UUID              `json:"bin_id"`
+	Expected float64                `json:"expected_quantity"`
+	Counted  float64                `json:"counted_quantity"`
+	Movement *StockMovementResponse `json:"movement"`
+}
+
+type CountApprovalResponse struct {
+	Session     *CountSessionResponse      `json:"session"`
+	Adjustments []*CountAdjustmentResponse `json:"adjustments"`
+}
+
+func NewCountApprovalResponse(s *stock.CountSession, adjustments []*stock.CountAdjustment) *CountApprovalResponse {
+	res := &CountApprovalResponse{
+		Session:     NewCountSessionResponse(s),
+		Adjustments: make([]*CountAdjustmentResponse, len(adjustments)),
+	}
+	for i, a := range adjustments {
+		res.Adjustments[i] = &CountAdjustmentResponse{
+			LineID:   a.LineID,
+			ItemID:   a.ItemID,
+			BinID:    a.BinID,
+			Expected: a.Expected,
+			Counted:  a.Counted,
+			Movement: NewStockMovementResponse(a.Movement),
+		}
+	}
+	return res
+}
@@ -90,41 +90,244 @@ func NewBinResponse(b *stock.Bin) *BinResponse {
 // --- Stock Movement DTOs ---
 
 type CreateStockMovementRequest struct {
-	ItemID      string  `json:"item_id" validate:"required,uuid"`
-	WarehouseID string  `json:"warehouse_id" validate:"required,uuid"`
-	BinID       string  `json:"bin_id" validate:"required,uuid"`
-	Type        string  `json:"type" validate:"required,oneof=IN OUT"`
-	Quantity    float64 `json:"quantity" validate:"required,gt=0"`
-	UnitPrice   float64 `json:"unit_price" validate:"omitempty,ge=0"` // Required for IN movements to update CMP
-	Reason      string  `json:"reason" validate:"max=255"`
+	ItemID        string               `json:"item_id" validate:"required,uuid"`
+	WarehouseID   string               `json:"warehouse_id" validate:"required,uuid"`
+	BinID         string               `json:"bin_id" validate:"required,uuid"`
+	Type          string               `json:"type" validate:"required,oneof=IN OUT"`
+	Quantity      float64              `json:"quantity" validate:"required,gt=0"`
+	UnitOfMeasure string               `json:"unit_of_measure" validate:"omitempty,max=20"` // Defaults to the base unit of the item
+	UnitPrice     float64              `json:"unit_price" validate:"omitempty,ge=0"`        // Required for IN movements to update CMP; per unit_of_measure
+	Reason        string               `json:"reason" validate:"max=255"`
+	Lots          []LotQuantityRequest `json:"lots" validate:"omitempty,dive"` // Required for lot or serial tracked items
 }
 
 func (r *CreateStockMovementRequest) Sanitize() {
 	r.Reason = sanitizer.SanitizeString(r.Reason)
+	for i := range r.Lots {
+		r.Lots[i].Sanitize()
+	}
+}
+
+// LotQuantityRequest is the quantity of a movement that belongs to one lot or serial number.
+type LotQuantityRequest struct {
+	LotNumber string  `json:"lot_number" validate:"required,max=100"`
+	Quantity  float64 `json:"quantity" validate:"required,gt=0"`
+}
+
+func (r *LotQuantityRequest) Sanitize() {
+	r.LotNumber = sanitizer.SanitizeString(r.LotNumber)
+}
+
+// ToLotQuantities converts lot requests into their domain values.
+func ToLotQuantities(reqs []LotQuantityRequest) []stock.LotQuantity {
+	var lots []stock.LotQuantity
+	for _, r := range reqs {
+		lots = append(lots, stock.LotQuantity{LotNumber: r.LotNumber, Quantity: r.Quantity})
+	}
+	return lots
+}
+
+type LotQuantityResponse struct {
+	LotNumber string  `json:"lot_number"`
+	Quantity  float64 `json:"quantity"`
+}
+
+func NewLotQuantityResponses(lots []stock.LotQuantity) []*LotQuantityResponse {
+	var res []*LotQuantityResponse
+	for _, l := range lots {
+		res = append(res, &LotQuantityResponse{LotNumber: l.LotNumber, Quantity: l.Quantity})
+	}
+	return res
 }
 
 type StockMovementResponse struct {
-	ID          uuid.UUID  `json:"id"`
+	ID           uuid.UUID              `json:"id"`
+	ItemID       uuid.UUID              `json:"item_id"`
+	WarehouseID  uuid.UUID              `json:"warehouse_id"`
+	BinID        *uuid.UUID             `json:"bin_id,omitempty"`
+	Type         string                 `json:"type"`
+	Quantity     float64                `json:"quantity"`
+	Reason       string                 `json:"reason"`
+	TransferID   *uuid.UUID             `json:"transfer_id,omitempty"`
+	Lots         []*LotQuantityResponse `json:"lots,omitempty"`
+	UnitCost     float64                `json:"unit_cost"`
+	CostVariance float64                `json:"cost_variance"`
+	HappenedAt   time.Time              `json:"happened_at"`
+	CreatedBy    uuid.UUID              `json:"created_by"`
+}
+
+func NewStockMovementResponse(sm *stock.StockMovement) *StockMovementResponse {
+	return &StockMovementResponse{
+		ID:           sm.ID,
+		ItemID:       sm.ItemID,
+		WarehouseID:  sm.WarehouseID,
+		BinID:        sm.BinID,
+		Type:         string(sm.Type),
+		Quantity:     sm.Quantity,
+		Reason:       sm.Reason,
+		TransferID:   sm.TransferID,
+		Lots:         NewLotQuantityResponses(sm.Lots),
+		UnitCost:     sm.UnitCost,
+		CostVariance: sm.CostVariance,
+		HappenedAt:   sm.HappenedAt,
+		CreatedBy:    sm.CreatedBy,
+	}
+}
+
+// --- Stock Transfer DTOs ---
+
+
+type CreateStockTransferRequest struct {
+	ItemID          string               `json:"item_id" validate:"required,uuid"`
+	FromWarehouseID string               `json:"from_warehouse_id" validate:"required,uuid"`
+	FromBinID       string               `json:"from_bin_id" validate:"required,uuid"`
+	ToWarehouseID   string               `json:"to_warehouse_id" validate:"required,uuid"`
+	ToBinID         string               `json:"to_bin_id" validate:"required,uuid"`
+	Quantity        float64              `json:"quantity" validate:"required,gt=0"`
+	UnitOfMeasure   string               `json:"unit_of_measure" validate:"omitempty,max=20"` // Defaults to the base unit of the item
+	Reason          string               `json:"reason" validate:"max=255"`
+	Lots            []LotQuantityRequest `json:"lots" validate:"omitempty,dive"`
+}
+
+func (r *CreateStockTransferRequest) Sanitize() {
+	r.Reason = sanitizer.SanitizeString(r.Reason)
+	for i := range r.Lots {
+		r.Lots[i].Sanitize()
+	}
+}
+
+type StockTransferResponse struct {
+	ID          uuid.UUID              `json:"id"`
+	OutMovement *StockMovementResponse `json:"out_movement"`
+	InMovement  *StockMovementResponse `json:"in_movement"`
+}
+
+func NewStockTransferResponse(t *stock.StockTransfer) *StockTransferResponse {
+	return &StockTransferResponse{
+		ID:          t.ID,
+		OutMovement: NewStockMovementResponse(t.OutMovement),
+		InMovement:  NewStockMovementResponse(t.InMovement),
+	}
+}
+
+// --- Stock Ledger DTOs ---
+
+// ListStockLedgerRequest holds the query parameters of a ledger search.
+// Dates accept either YYYY-MM-DD or RFC3339; a bare endDate covers the whole day.
+type ListStockLedgerRequest struct {
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	BinID       string `query:"binId" validate:"omitempty,uuid"`
+	Type        string `query:"type" validate:"omitempty,oneof=IN OUT ADJ_IN ADJ_OUT"`
+	StartDate   string `query:"startDate"`
+	EndDate     string `query:"endDate"`
+	Limit       int    `query:"limit" validate:"omitempty,gte=1,lte=500"`
+	Offset      int    `query:"offset" validate:"omitempty,gte=0"`
+}
+
+// StockAsOfRequest holds the query parameters of an as-of stock snapshot.
+// A bare asOf date is treated as the end of that day.
+type StockAsOfRequest struct {
+	AsOf        string `query:"asOf" validate:"required"`
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	BinID       string `query:"binId" validate:"omitempty,uuid"`
+}
+
+type StockLedgerResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	StockMovementID uuid.UUID  `json:"stock_movement_id"`
+	ItemID          uuid.UUID  `json:"item_id"`
+	WarehouseID     uuid.UUID  `json:"warehouse_id"`
+	BinID           *uuid.UUID `json:"bin_id,omitempty"`
+	MovementType    string     `json:"movement_type"`
+	QuantityChange  float64    `json:"quantity_change"`
+	QuantityBefore  float64    `json:"quantity_before"`
+	QuantityAfter   float64    `json:"quantity_after"`
+	UnitCost        float64    `json:"unit_cost"`
+	CostVariance    float64    `json:"cost_variance"`
+	Reason          string     `json:"reason"`
+	TransferID      *uuid.UUID `json:"transfer_id,omitempty"`
+	HappenedAt      time.Time  `json:"happened_at"`
+	RecordedAt      time.Time  `json:"recorded_at"`
+	RecordedBy      uuid.UUID  `json:"recorded_by"`
+}
+
+func NewStockLedgerResponse(l *stock.StockLedger) *StockLedgerResponse {
+	return &StockLedgerResponse{
+		ID:              l.ID,
+		StockMovementID: l.StockMovementID,
+		ItemID:          l.ItemID,
+		WarehouseID:     l.WarehouseID,
+		BinID:           l.BinID,
+		MovementType:    string(l.MovementType),
+		QuantityChange:  l.QuantityChange,
+		QuantityBefore:  l.QuantityBefore,
+		QuantityAfter:   l.QuantityAfter,
+		UnitCost:        l.UnitCost,
+		CostVariance:    l.CostVariance,
+		Reason:          l.Reason,
+		TransferID:      l.TransferID,
+		HappenedAt:      l.HappenedAt,
+		RecordedAt:      l.RecordedAt,
+		RecordedBy:      l.RecordedBy,
+	}
+}
+
+type StockLedgerPageResponse struct {
+	Entries []*StockLedgerResponse `json:"entries"`
+	Total   int64                  `json:"total"`
+	Limit   int                    `json:"limit"`
+	Offset  int                    `json:"offset"`
+}
+
+type StockResponse struct {
 	ItemID      uuid.UUID  `json:"item_id"`
 	WarehouseID uuid.UUID  `json:"warehouse_id"`
 	BinID       *uuid.UUID `json:"bin_id,omitempty"`
-	Type        string     `json:"type"`
 	Quantity    float64    `json:"quantity"`
-	Reason      string     `json:"reason"`
-	HappenedAt  time.Time  `json:"happened_at"`
-	CreatedBy   uuid.UUID  `json:"created_by"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
-func NewStockMovementResponse(sm *stock.StockMovement) *StockMovementResponse {
-	return &StockMovementResponse{
-		ID:          sm.ID,
-		ItemID:      sm.ItemID,
-		WarehouseID: sm.WarehouseID,
-		BinID:       sm.BinID,
-		Type:        string(sm.Type),
-		Quantity:    sm.Quantity,
-		Reason:      sm.Reason,
-		HappenedAt:  sm.HappenedAt,
-		CreatedBy:   sm.CreatedBy,
+func NewStockResponse(s *stock.Stock) *StockResponse {
+	return &StockResponse{
+		ItemID:      s.ItemID,
+		WarehouseID: s.WarehouseID,
+		BinID:       s.BinID,
+		Quantity:    s.Quantity,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+type StockAsOfResponse struct {
+	AsOf     time.Time        `json:"as_of"`
+	Balances []*StockResponse `json:"balances"`
+}
+
+// --- Stock Lot DTOs ---
+
+// ListStockLotsRequest holds the query parameters of a lot stock search.
+type ListStockLotsRequest struct {
+	ItemID    string `query:"itemId" validate:"required,uuid"`
+	LotNumber string `query:"lotNumber" validate:"max=100"`
+}
+
+type StockLotResponse struct {
+	ItemID      uuid.UUID  `json:"item_id"`
+	WarehouseID uuid.UUID  `json:"warehouse_id"`
+	BinID       *uuid.UUID `json:"bin_id,omitempty"`
+	LotNumber   string     `json:"lot_number"`
+	Quantity    float64    `json:"quantity"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func NewStockLotResponse(l *stock.StockLot) *StockLotResponse {
+	return &StockLotResponse{
+		ItemID:      l.ItemID,
+		WarehouseID: l.WarehouseID,
+		BinID:       l.BinID,
+		LotNumber:   l.LotNumber,
+		Quantity:    l.Quantity,
+		UpdatedAt:   l.UpdatedAt,
 	}
 }
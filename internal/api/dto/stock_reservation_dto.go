@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+)
+
+// --- Stock Reservation DTOs ---
+
+// ReserveStockRequest holds a quantity of an item for a source document.
+// BinID may be omitted to reserve non-binned stock; ExpiresAt defaults to the configured TTL.
+type ReserveStockRequest struct {
+	ItemID      string     `json:"item_id" validate:"required,uuid"`
+	WarehouseID string     `json:"warehouse_id" validate:"required,uuid"`
+	BinID       string     `json:"bin_id" validate:"omitempty,uuid"`
+	SourceType  string     `json:"source_type" validate:"required,max=50"`
+	SourceID    string     `json:"source_id" validate:"required,max=100"`
+	Quantity    float64    `json:"quantity" validate:"required,gt=0"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (r *ReserveStockRequest) Sanitize() {
+	r.SourceType = sanitizer.SanitizeString(r.SourceType)
+	r.SourceID = sanitizer.SanitizeString(r.SourceID)
+}
+
+// ConsumeReservationRequest posts an OUT movement against a reservation.
+// A zero or omitted quantity consumes the whole open quantity.
+type ConsumeReservationRequest struct {
+	Quantity float64              `json:"quantity" validate:"gte=0"`
+	Reason   string               `json:"reason" validate:"max=255"`
+	Lots     []LotQuantityRequest `json:"lots" validate:"omitempty,dive"`
+}
+
+func (r *ConsumeReservationRequest) Sanitize() {
+	r.Reason = sanitizer.SanitizeString(r.Reason)
+	for i := range r.Lots {
+		r.Lots[i].Sanitize()
+	}
+}
+
+// ListReservationsRequest holds the query parameters of a reservation search.
+type ListReservationsRequest struct {
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	SourceType  string `query:"sourceType" validate:"max=50"`
+	SourceID    string `query:"sourceId" validate:"max=100"`
+	Status      string `query:"status" validate:"omitempty,oneof=ACTIVE RELEASED CONSUMED EXPIRED"`
+}
+
+// StockAvailabilityRequest holds the query parameters of an availability lookup.
+type StockAvailabilityRequest struct {
+	ItemID      string `query:"itemId" validate:"required,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"required,uuid"`
+	BinID       string `query:"binId" validate:"omitempty,uuid"`
+}
+
+type ReservationResponse struct {
+	ID               uuid.UUID  `json:"id"`
+	ItemID           uuid.UUID  `json:"item_id"`
+	WarehouseID      uuid.UUID  `json:"warehouse_id"`
+	BinID            *uuid.UUID `json:"bin_id,omitempty"`
+	SourceType       string     `json:"source_type"`
+	SourceID         string     `json:"source_id"`
+	Quantity         float64    `json:"quantity"`
+	ConsumedQuantity float64    `json:"consumed_quantity"`
+	OpenQuantity     float64    `json:"open_quantity"`
+	Status           string     `json:"status"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	CreatedBy        uuid.UUID  `json:"created_by"`
+}
+
+func NewReservationResponse(r *stock.Reservation) *ReservationResponse {
+	return &ReservationResponse{
+		ID:               r.ID,
+		ItemID:           r.ItemID,
+		WarehouseID:      r.WarehouseID,
+		BinID:            r.BinID,
+		SourceType:       r.SourceType,
+		SourceID:         r.SourceID,
+		Quantity:         r.Quantity,
+		ConsumedQuantity: r.ConsumedQuantity,
+		OpenQuantity:     r.OpenQuantity(),
+		Status:           string(r.Status),
+		ExpiresAt:        r.ExpiresAt,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+		CreatedBy:        r.CreatedBy,
+	}
+}
+
+type ConsumeReservationResponse struct {
+	Reservation *ReservationResponse   `json:"reservation"`
+	Movement    *StockMovementResponse `json:"movement"`
+}
+
+type StockAvailabilityResponse struct {
+	ItemID      uuid.UUID  `json:"item_id"`
+	WarehouseID uuid.UUID  `json:"warehouse_id"`
+	BinID       *uuid.UUID `json:"bin_id,omitempty"`
+	OnHand      float64    `json:"on_hand"`
+	Reserved    float64    `json:"reserved"`
+	Available   float64    `json:"available"`
+}
+
+func NewStockAvailabilityResponse(s *stock.Stock) *StockAvailabilityResponse {
+	return &StockAvailabilityResponse{
+		ItemID:      s.ItemID,
+		WarehouseID: s.WarehouseID,
+		BinID:       s.BinID,
+		OnHand:      s.Quantity,
+		Reserved:    s.Reserved,
+		Available:   s.Available(),
+	}
+}
@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+)
+
+// --- Inventory Valuation DTOs ---
+
+// InventoryValuationRequest holds the query parameters of an inventory valuation report.
+// Without asOf the current stock is valued; a bare asOf date is treated as the end of that day.
+// groupBy is a comma-separated list of warehouse, bin and item_type.
+type InventoryValuationRequest struct {
+	AsOf        string `query:"asOf"`
+	ItemID      string `query:"itemId" validate:"omitempty,uuid"`
+	WarehouseID string `query:"warehouseId" validate:"omitempty,uuid"`
+	BinID       string `query:"binId" validate:"omitempty,uuid"`
+	ItemType    string `query:"itemType" validate:"omitempty,oneof=STORABLE SERVICE"`
+	GroupBy     string `query:"groupBy"`
+	Format      string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type ValuationLineResponse struct {
+	ItemID        uuid.UUID     `json:"item_id"`
+	ItemName      string        `json:"item_name"`
+	ItemType      item.ItemType `json:"item_type"`
+	WarehouseID   uuid.UUID     `json:"warehouse_id"`
+	WarehouseName string        `json:"warehouse_name"`
+	BinID         *uuid.UUID    `json:"bin_id,omitempty"`
+	BinName       string        `json:"bin_name,omitempty"`
+	Quantity      float64       `json:"quantity"`
+	UnitCost      float64       `json:"unit_cost"`
+	Value         float64       `json:"value"`
+}
+
+type ValuationTotalResponse struct {
+	WarehouseID   *uuid.UUID    `json:"warehouse_id,omitempty"`
+	WarehouseName string        `json:"warehouse_name,omitempty"`
+	BinID         *uuid.UUID    `json:"bin_id,omitempty"`
+	BinName       string        `json:"bin_name,omitempty"`
+	ItemType      item.ItemType `json:"item_type,omitempty"`
+	Lines         int           `json:"lines"`
+	Value         float64       `json:"value"`
+}
+
+type InventoryValuationResponse struct {
+	AsOf       time.Time                 `json:"as_of"`
+	GroupBy    []stock.ValuationGroup    `json:"group_by"`
+	Lines      []*ValuationLineResponse  `json:"lines"`
+	Totals     []*ValuationTotalResponse `json:"totals"`
+	TotalValue float64                   `json:"total_value"`
+}
+
+func NewInventoryValuationResponse(r *stock.ValuationReport) *InventoryValuationResponse {
+	res := &InventoryValuationResponse{
+		AsOf:       r.AsOf,
+		GroupBy:    r.GroupBy,
+		Lines:      make([]*ValuationLineResponse, len(r.Lines)),
+		Totals:     make([]*ValuationTotalResponse, len(r.Totals)),
+		TotalValue: r.TotalValue,
+	}
+	if res.GroupBy == nil {
+		res.GroupBy = []stock.ValuationGroup{}
+	}
+	for i, l := range r.Lines {
+		res.Lines[i] = &ValuationLineResponse{
+			ItemID:        l.ItemID,
+			ItemName:      l.ItemName,
+			ItemType:      l.ItemType,
+			WarehouseID:   l.WarehouseID,
+			WarehouseName: l.WarehouseName,
+			BinID:         l.BinID,
+			BinName:       l.BinName,
+			Quantity:      l.Quantity,
+			UnitCost:      l.UnitCost,
+			Value:         l.Value,
+		}
+	}
+	for i, t := range r.Totals {
+		res.Totals[i] = &ValuationTotalResponse{
+			WarehouseID:   t.WarehouseID,
+			WarehouseName: t.WarehouseName,
+			BinID:         t.BinID,
+			BinName:       t.BinName,
+			ItemType:      t.ItemType,
+			Lines:         t.Lines,
+			Value:         t.Value,
+		}
+	}
+	return res
+}
@@ -13,6 +13,8 @@ type CreateThirdPartyRequest struct {
 	Name  string `json:"name" validate:"required,min=2,max=255"`
 	Email string `json:"email" validate:"required,email"`
 	Type  string `json:"type" validate:"required,oneof=CUSTOMER SUPPLIER"`
+	// PaymentTermDays defaults to thirdparty.DefaultPaymentTermDays.
+	PaymentTermDays *int `json:"payment_term_days" validate:"omitempty,min=0,max=365"`
 }
 
 func (r *CreateThirdPartyRequest) Sanitize() {
@@ -26,6 +28,8 @@ type UpdateThirdPartyRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Type     string `json:"type" validate:"required,oneof=CUSTOMER SUPPLIER"`
 	IsActive bool   `json:"is_active"`
+	// PaymentTermDays is left unchanged when omitted.
+	PaymentTermDays *int `json:"payment_term_days" validate:"omitempty,min=0,max=365"`
 }
 
 func (r *UpdateThirdPartyRequest) Sanitize() {
@@ -40,6 +44,7 @@ type ThirdPartyResponse struct {
 	Email     string     `json:"email"`
 	Type      string     `json:"type"`
 	IsActive  bool       `json:"is_active"`
+	PaymentTermDays int  `json:"payment_term_days"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	CreatedBy uuid.UUID  `json:"created_by"`
@@ -54,6 +59,7 @@ func NewThirdPartyResponse(tp *thirdparty.ThirdParty) *ThirdPartyResponse {
 		Email:     tp.Email,
 		Type:      string(tp.Type),
 		IsActive:  tp.IsActive,
+		PaymentTermDays: tp.PaymentTermDays,
 		CreatedAt: tp.CreatedAt,
 		UpdatedAt: tp.UpdatedAt,
 		CreatedBy: tp.CreatedBy,
@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/uom"
+	"github.com/google/uuid"
+)
+
+// --- Unit of Measure DTOs ---
+
+type CreateUoMCategoryRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+func (r *CreateUoMCategoryRequest) Sanitize() {
+	r.Name = sanitizer.SanitizeString(r.Name)
+}
+
+type UoMCategoryResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	UpdatedBy uuid.UUID `json:"updated_by"`
+}
+
+func NewUoMCategoryResponse(c *uom.Category) *UoMCategoryResponse {
+	return &UoMCategoryResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		CreatedBy: c.CreatedBy,
+		UpdatedBy: c.UpdatedBy,
+	}
+}
+
+// CreateUoMUnitRequest adds a unit to a category. Factor is the number of reference units of the
+// category in one unit, e.g. 0.001 for the gram when the kilogram is the reference.
+type CreateUoMUnitRequest struct {
+	CategoryID string  `json:"category_id" validate:"required,uuid"`
+	Code       string  `json:"code" validate:"required,max=20"`
+	Name       string  `json:"name" validate:"required,max=100"`
+	Factor     float64 `json:"factor" validate:"required,gt=0"`
+}
+
+func (r *CreateUoMUnitRequest) Sanitize() {
+	r.Code = sanitizer.SanitizeString(r.Code)
+	r.Name = sanitizer.SanitizeString(r.Name)
+}
+
+// ListUoMUnitsRequest holds the query parameters of a unit listing.
+type ListUoMUnitsRequest struct {
+	CategoryID string `query:"categoryId" validate:"omitempty,uuid"`
+}
+
+// ConvertUoMRequest holds the query parameters of a quantity conversion.
+type ConvertUoMRequest struct {
+	Quantity float64 `query:"quantity" validate:"required"`
+	From     string  `query:"from" validate:"required,max=20"`
+	To       string  `query:"to" validate:"required,max=20"`
+}
+
+type UoMUnitResponse struct {
+	ID         uuid.UUID `json:"id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Factor     float64   `json:"factor"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	CreatedBy  uuid.UUID `json:"created_by"`
+	UpdatedBy  uuid.UUID `json:"updated_by"`
+}
+
+func NewUoMUnitResponse(u *uom.Unit) *UoMUnitResponse {
+	return &UoMUnitResponse{
+		ID:         u.ID,
+		CategoryID: u.CategoryID,
+		Code:       u.Code,
+		Name:       u.Name,
+		Factor:     u.Factor,
+		CreatedAt:  u.CreatedAt,
+		UpdatedAt:  u.UpdatedAt,
+		CreatedBy:  u.CreatedBy,
+		UpdatedBy:  u.UpdatedBy,
+	}
+}
+
+type ConvertUoMResponse struct {
+	Quantity  float64 `json:"quantity"`
+	From      string  `json:"from"`
+	Converted float64 `json:"converted"`
+	To        string  `json:"to"`
+}
@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"time"
+
+	"doligo_001/internal/api/sanitizer"
+	"doligo_001/internal/domain/bom"
+	"github.com/google/uuid"
+)
+
+// --- Work Center DTOs ---
+
+// CreateWorkCenterRequest adds a work center. Rates are costs per hour.
+type CreateWorkCenterRequest struct {
+	Code         string  `json:"code" validate:"required,max=50"`
+	Name         string  `json:"name" validate:"required,max=255"`
+	LaborRate    float64 `json:"labor_rate" validate:"gte=0"`
+	OverheadRate float64 `json:"overhead_rate" validate:"gte=0"`
+}
+
+func (r *CreateWorkCenterRequest) Sanitize() {
+	r.Code = sanitizer.SanitizeString(r.Code)
+	r.Name = sanitizer.SanitizeString(r.Name)
+}
+
+// UpdateWorkCenterRequest changes a work center; its code cannot be changed.
+type UpdateWorkCenterRequest struct {
+	Name         string  `json:"name" validate:"required,max=255"`
+	LaborRate    float64 `json:"labor_rate" validate:"gte=0"`
+	OverheadRate float64 `json:"overhead_rate" validate:"gte=0"`
+	IsActive     bool    `json:"is_active"`
+}
+
+func (r *UpdateWorkCenterRequest) Sanitize() {
+	r.Name = sanitizer.SanitizeString(r.Name)
+}
+
+// ListWorkCentersRequest holds the query parameters of a work center listing.
+type ListWorkCentersRequest struct {
+	IncludeInactive bool `query:"includeInactive"`
+}
+
+type WorkCenterResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	LaborRate    float64   `json:"labor_rate"`
+	OverheadRate float64   `json:"overhead_rate"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CreatedBy    uuid.UUID `json:"created_by"`
+	UpdatedBy    uuid.UUID `json:"updated_by"`
+}
+
+func NewWorkCenterResponse(wc *bom.WorkCenter) *WorkCenterResponse {
+	return &WorkCenterResponse{
+		ID:           wc.ID,
+		Code:         wc.Code,
+		Name:         wc.Name,
+		LaborRate:    wc.LaborRate,
+		OverheadRate: wc.OverheadRate,
+		IsActive:     wc.IsActive,
+		CreatedAt:    wc.CreatedAt,
+		UpdatedAt:    wc.UpdatedAt,
+		CreatedBy:    wc.CreatedBy,
+		UpdatedBy:    wc.UpdatedBy,
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"doligo_001/internal/api/dto"
+	domainMargin "doligo_001/internal/domain/margin"
+	marginUseCase "doligo_001/internal/usecase/margin"
+)
+
+// ARAgingHandler handles HTTP requests for the accounts receivable aging report.
+type ARAgingHandler struct {
+	agingUsecase marginUseCase.AgingUsecase
+}
+
+// NewARAgingHandler creates a new ARAgingHandler.
+func NewARAgingHandler(au marginUseCase.AgingUsecase) *ARAgingHandler {
+	return &ARAgingHandler{agingUsecase: au}
+}
+
+// GetARAgingReport godoc
+// @Summary Get the accounts receivable aging report
+// @Description Buckets the balances outstanding as of a date by days past due (current, 1-30, 31-60, 61-90, over 90), per customer and overall. With format=csv the report is downloaded as CSV.
+// @Tags Margin
+// @Produce json
+// @Produce text/csv
+// @Param asOf query string false "Date the balances are aged at (YYYY-MM-DD), today by default"
+// @Param format query string false "json or csv"
+// @Success 200 {object} domainMargin.AgingReport
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /reports/ar-aging [get]
+func (h *ARAgingHandler) GetARAgingReport(c echo.Context) error {
+	var req dto.ARAgingReportRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{Message: "Invalid request parameters", Details: err.Error()})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{Message: "Validation failed", Details: err.Error()})
+	}
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.AsOf != "" {
+		asOf, _ = time.Parse("2006-01-02", req.AsOf) // Already validated
+	}
+
+	report, err := h.agingUsecase.GetARAgingReport(c.Request().Context(), asOf)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Message: "Failed to retrieve the aging report", Details: err.Error()})
+	}
+
+	if req.Format == "csv" {
+		return writeAgingCSV(c, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// writeAgingCSV streams the report as a CSV attachment, one row per customer followed by a total row.
+func writeAgingCSV(c echo.Context, report *domainMargin.AgingReport) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="ar-aging-%s.csv"`, report.AsOf.Format("2006-01-02")))
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	formatAmount := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	buckets := func(b domainMargin.AgingBuckets) []string {
+		return []string{formatAmount(b.Current), formatAmount(b.Days1To30), formatAmount(b.Days31To60),
+			formatAmount(b.Days61To90), formatAmount(b.Over90), formatAmount(b.Total)}
+	}
+	w.Write([]string{"third_party_id", "third_party_name", "invoices", "current", "days_1_30", "days_31_60", "days_61_90", "over_90", "total"})
+	invoices := 0
+	for _, customer := range report.Customers {
+		w.Write(append([]string{customer.ThirdPartyID.String(), customer.ThirdPartyName, strconv.Itoa(customer.Invoices)}, buckets(customer.AgingBuckets)...))
+		invoices += customer.Invoices
+	}
+	w.Write(append([]string{"TOTAL", "", strconv.Itoa(invoices)}, buckets(report.Totals)...))
+	w.Flush()
+	return w.Error()
+}
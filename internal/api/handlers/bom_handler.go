@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,7 +9,9 @@ import (
 	"doligo_001/internal/api/validator"
 	"doligo_001/internal/domain" // For domain.UserIDFromContext
 	"doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/stock"
 	bomUseCase "doligo_001/internal/usecase/bom" // Alias to avoid conflict with domain.bom
+	stock_usecase "doligo_001/internal/usecase/stock"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -49,13 +52,19 @@ func (h *BOMHandler) CreateBOM(c echo.Context) error {
 		if err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
 		}
-		
+		alternates, err := toAlternateComponents(compReq.Alternates)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Alternate Item ID")
+		}
+
 		components[i] = bom.BillOfMaterialsComponent{
 			ID:              uuid.New(), // ID will be overridden by DB on creation
 			ComponentItemID: compID,
 			Quantity:        compReq.Quantity,
+			ScrapPercent:    compReq.ScrapPercent,
 			UnitOfMeasure:   compReq.UnitOfMeasure,
 			IsActive:        compReq.IsActive,
+			Alternates:      alternates,
 		}
 	}
 
@@ -70,13 +79,17 @@ func (h *BOMHandler) CreateBOM(c echo.Context) error {
 		ProductID: productID,
 		Name:      req.Name,
 		IsActive:  req.IsActive,
+		YieldPercent: req.YieldPercent,
 		Components: components,
 	}
+	if newBOM.Operations, err = toRoutingOperations(req.Operations, userID); err != nil {
+		return err
+	}
 	newBOM.SetCreatedBy(userID)
 	newBOM.SetUpdatedBy(userID) // Initial creation also sets updated by
 
 	if err := h.bomUsecase.CreateBOM(c.Request().Context(), newBOM); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return bomError(err)
 	}
 
 	res := toBOMResponse(newBOM)
@@ -103,7 +116,7 @@ func (h *BOMHandler) GetBOMByID(c echo.Context) error {
 	return c.JSON(http.StatusOK, res)
 }
 
-// GetBOMByProductID retrieves a Bill of Materials by the product it produces.
+// GetBOMByProductID retrieves the revision in force of the Bill of Materials of a product.
 func (h *BOMHandler) GetBOMByProductID(c echo.Context) error {
 	productIDStr := c.Param("productID")
 	productID, err := uuid.Parse(productIDStr)
@@ -113,7 +126,7 @@ func (h *BOMHandler) GetBOMByProductID(c echo.Context) error {
 
 	b, err := h.bomUsecase.GetBOMByProductID(c.Request().Context(), productID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return bomError(err)
 	}
 	if b == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "BOM not found for this product")
@@ -167,8 +180,12 @@ func (h *BOMHandler) UpdateBOM(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "BOM not found")
 	}
 
+	// The product identifies the revision history; it cannot move to another product
+	if productID != existingBOM.ProductID {
+		return echo.NewHTTPError(http.StatusBadRequest, "the product of a BOM revision cannot be changed")
+	}
+
 	// Update fields
-	existingBOM.ProductID = productID
 	existingBOM.Name = req.Name
 	existingBOM.IsActive = req.IsActive
 
@@ -179,25 +196,35 @@ func (h *BOMHandler) UpdateBOM(c echo.Context) error {
 		if err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
 		}
+		alternates, err := toAlternateComponents(compReq.Alternates)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Alternate Item ID")
+		}
 
 		newComponents[i] = bom.BillOfMaterialsComponent{
 			ComponentItemID: compID,
 			Quantity:        compReq.Quantity,
+			ScrapPercent:    compReq.ScrapPercent,
 			UnitOfMeasure:   compReq.UnitOfMeasure,
 			IsActive:        compReq.IsActive,
+			Alternates:      alternates,
 		}
 	}
 	existingBOM.Components = newComponents
+	existingBOM.YieldPercent = req.YieldPercent
 
 	// Assume user ID comes from JWT middleware context
 	userID, ok := domain.UserIDFromContext(c.Request().Context())
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "user ID not found in context")
 	}
+	if existingBOM.Operations, err = toRoutingOperations(req.Operations, userID); err != nil {
+		return err
+	}
 	existingBOM.SetUpdatedBy(userID)
 
 	if err := h.bomUsecase.UpdateBOM(c.Request().Context(), existingBOM); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return bomError(err)
 	}
 
 	res := toBOMResponse(existingBOM)
@@ -213,7 +240,7 @@ func (h *BOMHandler) DeleteBOM(c echo.Context) error {
 	}
 
 	if err := h.bomUsecase.DeleteBOM(c.Request().Context(), id); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return bomError(err)
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -237,7 +264,7 @@ func (h *BOMHandler) CalculatePredictiveCost(c echo.Context) error {
 
 	totalCost, err := h.bomUsecase.CalculatePredictiveCost(c.Request().Context(), bomID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return bomError(err)
 	}
 
 	res := dto.CalculateCostResponse{
@@ -247,6 +274,174 @@ func (h *BOMHandler) CalculatePredictiveCost(c echo.Context) error {
 	return c.JSON(http.StatusOK, res)
 }
 
+// GetBOMTree returns the indented multi-level BOM for a quantity of its product, with the cost
+// of every line rolled up through the sub-assemblies.
+func (h *BOMHandler) GetBOMTree(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid BOM ID format")
+	}
+	req := new(dto.BOMTreeRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	root, err := h.bomUsecase.ExplodeBOM(c.Request().Context(), id, quantity)
+	if err != nil {
+		return bomError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewBOMTreeResponse(root))
+}
+
+// WhereUsed lists the BOMs that consume an item, optionally through every level of sub-assemblies.
+func (h *BOMHandler) WhereUsed(c echo.Context) error {
+	req := new(dto.WhereUsedRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID")
+	}
+
+	usages, err := h.bomUsecase.WhereUsed(c.Request().Context(), itemID, req.MultiLevel)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, dto.NewWhereUsedResponses(usages))
+}
+
+// ListRevisions lists every revision of the Bill of Materials of a product.
+func (h *BOMHandler) ListRevisions(c echo.Context) error {
+	productID, err := uuid.Parse(c.Param("productID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid Product ID format")
+	}
+
+	revisions, err := h.bomUsecase.ListRevisions(c.Request().Context(), productID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	resList := make([]dto.BOMResponse, len(revisions))
+	for i, b := range revisions {
+		resList[i] = toBOMResponse(b)
+	}
+	return c.JSON(http.StatusOK, resList)
+}
+
+// CreateRevision creates a draft revision copied from an existing one.
+func (h *BOMHandler) CreateRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid BOM ID format")
+	}
+
+	draft, err := h.bomUsecase.CreateRevision(c.Request().Context(), id)
+	if err != nil {
+		return bomError(err)
+	}
+	return c.JSON(http.StatusCreated, toBOMResponse(draft))
+}
+
+// ApproveRevision approves a draft revision, putting it in force from effective_from (now by
+// default) and ending the revision previously in force.
+func (h *BOMHandler) ApproveRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid BOM ID format")
+	}
+	req := new(dto.ApproveBOMRevisionRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	effectiveFrom := time.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	b, err := h.bomUsecase.ApproveRevision(c.Request().Context(), id, effectiveFrom, req.EffectiveTo)
+	if err != nil {
+		return bomError(err)
+	}
+	return c.JSON(http.StatusOK, toBOMResponse(b))
+}
+
+// ObsoleteRevision takes an approved revision out of force.
+func (h *BOMHandler) ObsoleteRevision(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid BOM ID format")
+	}
+
+	b, err := h.bomUsecase.ObsoleteRevision(c.Request().Context(), id)
+	if err != nil {
+		return bomError(err)
+	}
+	return c.JSON(http.StatusOK, toBOMResponse(b))
+}
+
+// DiffRevisions compares the components of two revisions of the same product.
+func (h *BOMHandler) DiffRevisions(c echo.Context) error {
+	req := new(dto.BOMRevisionDiffRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+	fromID, err := uuid.Parse(req.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid from BOM ID")
+	}
+	toID, err := uuid.Parse(req.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid to BOM ID")
+	}
+
+	diff, err := h.bomUsecase.DiffRevisions(c.Request().Context(), fromID, toID)
+	if err != nil {
+		return bomError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewBOMRevisionDiffResponse(diff))
+}
+
+// bomError maps BOM structure and revision errors to HTTP errors.
+func bomError(err error) error {
+	if unitErr := unitError(err); unitErr != nil {
+		return unitErr
+	}
+	switch {
+	case errors.Is(err, bom.ErrBOMNotFound), errors.Is(err, bom.ErrProductionRecordNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, bom.ErrBOMCycle), errors.Is(err, bom.ErrNoRevisionInForce),
+		errors.Is(err, bom.ErrProductionNotReversible), errors.Is(err, bom.ErrOverDisassembly):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, bom.ErrRevisionNotDraft), errors.Is(err, bom.ErrRevisionNotApproved),
+		errors.Is(err, bom.ErrProductionReversed), errors.Is(err, bom.ErrProductionDisassembled),
+		errors.Is(err, stock_usecase.ErrInsufficientStock), errors.Is(err, stock.ErrCostLayerConsumed):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, bom.ErrInvalidEffectiveDates), errors.Is(err, bom.ErrRevisionProductMismatch),
+		errors.Is(err, bom.ErrInvalidScrapFactor), errors.Is(err, bom.ErrInvalidOperation),
+		errors.Is(err, bom.ErrWorkCenterNotFound), errors.Is(err, bom.ErrInactiveWorkCenter),
+		errors.Is(err, bom.ErrInvalidAlternate), errors.Is(err, bom.ErrInvalidSubstitution),
+		errors.Is(err, stock_usecase.ErrInvalidQuantity):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
 // ProduceItem handles the request for initiating a production order.
 func (h *BOMHandler) ProduceItem(c echo.Context) error {
 	req := new(dto.ProduceItemRequest)
@@ -267,6 +462,42 @@ func (h *BOMHandler) ProduceItem(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID")
 	}
 
+	opts := bom.ProductionOptions{ProductLots: dto.ToLotQuantities(req.ProductLots)}
+	if len(req.ComponentLots) > 0 {
+		opts.ComponentLots = make(map[uuid.UUID][]stock.LotQuantity, len(req.ComponentLots))
+		for _, cl := range req.ComponentLots {
+			compID, err := uuid.Parse(cl.ComponentItemID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
+			}
+			opts.ComponentLots[compID] = append(opts.ComponentLots[compID], dto.ToLotQuantities(cl.Lots)...)
+		}
+	}
+	if len(req.ComponentScrap) > 0 {
+		opts.ComponentScrap = make(map[uuid.UUID]float64, len(req.ComponentScrap))
+		for _, cs := range req.ComponentScrap {
+			compID, err := uuid.Parse(cs.ComponentItemID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
+			}
+			opts.ComponentScrap[compID] += cs.Quantity
+		}
+	}
+	if len(req.Substitutes) > 0 {
+		opts.Substitutes = make(map[uuid.UUID]uuid.UUID, len(req.Substitutes))
+		for _, sub := range req.Substitutes {
+			compID, err := uuid.Parse(sub.ComponentItemID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
+			}
+			substituteID, err := uuid.Parse(sub.SubstituteItemID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid Substitute Item ID")
+			}
+			opts.Substitutes[compID] = substituteID
+		}
+	}
+
 	// Assume user ID comes from JWT middleware context
 	userID, ok := domain.UserIDFromContext(c.Request().Context())
 	if !ok {
@@ -279,9 +510,13 @@ func (h *BOMHandler) ProduceItem(c echo.Context) error {
 		warehouseID,
 		userID,
 		req.ProductionQuantity,
+		opts,
 	)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		return bomError(err)
 	}
 
 	res := dto.ProduceItemResponse{
@@ -292,6 +527,120 @@ func (h *BOMHandler) ProduceItem(c echo.Context) error {
 	return c.JSON(http.StatusOK, res)
 }
 
+// ReverseProduction undoes an instant production run at the cost it recorded.
+func (h *BOMHandler) ReverseProduction(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid production record ID format")
+	}
+	req := new(dto.ReverseProductionRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	record, err := h.bomUsecase.ReverseProduction(c.Request().Context(), id, req.Reason)
+	if err != nil {
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		return bomError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewProductionRecordResponse(record))
+}
+
+// DisassembleProduction takes part of the product of an instant production run apart into its
+// components.
+func (h *BOMHandler) DisassembleProduction(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid production record ID format")
+	}
+	req := new(dto.DisassembleProductionRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	opts := bom.DisassemblyOptions{ProductLots: dto.ToLotQuantities(req.ProductLots)}
+	if len(req.ComponentLots) > 0 {
+		opts.ComponentLots = make(map[uuid.UUID][]stock.LotQuantity, len(req.ComponentLots))
+		for _, cl := range req.ComponentLots {
+			compID, err := uuid.Parse(cl.ComponentItemID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
+			}
+			opts.ComponentLots[compID] = append(opts.ComponentLots[compID], dto.ToLotQuantities(cl.Lots)...)
+		}
+	}
+
+	record, err := h.bomUsecase.DisassembleProduction(c.Request().Context(), id, req.Quantity, opts)
+	if err != nil {
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		return bomError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewProductionRecordResponse(record))
+}
+
+// TraceLot returns the genealogy of a lot: the production runs that produced it and the
+// component lots they consumed, down through every sub-assembly.
+func (h *BOMHandler) TraceLot(c echo.Context) error {
+	req := new(dto.LotGenealogyRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID")
+	}
+
+	genealogy, err := h.bomUsecase.TraceLot(c.Request().Context(), itemID, req.LotNumber)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, dto.NewLotGenealogyResponse(genealogy))
+}
+
+// toAlternateComponents converts the alternates of a component request.
+func toAlternateComponents(reqs []dto.AlternateComponentRequest) ([]bom.AlternateComponent, error) {
+	var alternates []bom.AlternateComponent
+	for _, req := range reqs {
+		itemID, err := uuid.Parse(req.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		alternates = append(alternates, bom.AlternateComponent{
+			ItemID:          itemID,
+			Priority:        req.Priority,
+			ConversionRatio: req.ConversionRatio,
+		})
+	}
+	return alternates, nil
+}
+
+// toAlternateComponentResponses converts the alternates of a component.
+func toAlternateComponentResponses(alternates []bom.AlternateComponent) []dto.AlternateComponentResponse {
+	var res []dto.AlternateComponentResponse
+	for _, a := range alternates {
+		res = append(res, dto.AlternateComponentResponse{
+			ID:              a.ID,
+			ItemID:          a.ItemID,
+			Priority:        a.Priority,
+			ConversionRatio: a.ConversionRatio,
+		})
+	}
+	return res
+}
 
 // toBOMResponse converts a domain.bom.BillOfMaterials entity to a dto.BOMResponse.
 func toBOMResponse(b *bom.BillOfMaterials) dto.BOMResponse {
@@ -302,20 +651,39 @@ func toBOMResponse(b *bom.BillOfMaterials) dto.BOMResponse {
 			BillOfMaterialsID: comp.BillOfMaterialsID,
 			ComponentItemID:   comp.ComponentItemID,
 			Quantity:          comp.Quantity,
+			ScrapPercent:      comp.ScrapPercent,
 			UnitOfMeasure:     comp.UnitOfMeasure,
 			IsActive:          comp.IsActive,
+			Alternates:        toAlternateComponentResponses(comp.Alternates),
 			CreatedAt:         comp.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:         comp.UpdatedAt.Format(time.RFC3339),
 			CreatedBy:         comp.CreatedBy,
 			UpdatedBy:         comp.UpdatedBy,
 		}
 	}
+	operations := make([]dto.RoutingOperationResponse, len(b.Operations))
+	for i, op := range b.Operations {
+		operations[i] = dto.RoutingOperationResponse{
+			ID:           op.ID,
+			Sequence:     op.Sequence,
+			Name:         op.Name,
+			WorkCenterID: op.WorkCenterID,
+			SetupMinutes: op.SetupMinutes,
+			RunMinutes:   op.RunMinutes,
+		}
+	}
 	return dto.BOMResponse{
 		ID:         b.ID,
 		ProductID:  b.ProductID,
 		Name:       b.Name,
 		IsActive:   b.IsActive,
+		Revision:      b.Revision,
+		Status:        string(b.Status),
+		EffectiveFrom: b.EffectiveFrom,
+		EffectiveTo:   b.EffectiveTo,
+		YieldPercent:  b.YieldPercent,
 		Components: components,
+		Operations: operations,
 		CreatedAt:  b.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  b.UpdatedAt.Format(time.RFC3339),
 		CreatedBy:  b.CreatedBy,
@@ -323,4 +691,23 @@ func toBOMResponse(b *bom.BillOfMaterials) dto.BOMResponse {
 	}
 }
 
-
+// toRoutingOperations maps the routing of a BOM request, created by userID.
+func toRoutingOperations(reqs []dto.RoutingOperationRequest, userID uuid.UUID) ([]bom.RoutingOperation, error) {
+	operations := make([]bom.RoutingOperation, len(reqs))
+	for i, opReq := range reqs {
+		workCenterID, err := uuid.Parse(opReq.WorkCenterID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid Work Center ID")
+		}
+		operations[i] = bom.RoutingOperation{
+			Sequence:     opReq.Sequence,
+			Name:         opReq.Name,
+			WorkCenterID: workCenterID,
+			SetupMinutes: opReq.SetupMinutes,
+			RunMinutes:   opReq.RunMinutes,
+			CreatedBy:    userID,
+			UpdatedBy:    userID,
+		}
+	}
+	return operations, nil
+}
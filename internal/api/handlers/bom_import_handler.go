@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/bom"
+	bomUseCase "doligo_001/internal/usecase/bom"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// bomCSVColumns are the columns of a CSV BOM import, one row per component. revision and
+// yield_percent are optional, as is scrap_percent. Exports add the bom_id, status and
+// is_active columns, which an import ignores.
+var bomCSVColumns = []string{"product_id", "name", "component_item_id", "quantity", "unit_of_measure", "revision", "yield_percent", "scrap_percent"}
+
+// ImportBOMs imports a JSON or CSV (Content-Type text/csv) list of component rows as draft
+// BOM revisions, all or nothing. With dryRun=true the rows are only validated. The result of
+// every row is reported: 200 for a dry run, 201 once created and 422 if a row was rejected.
+func (h *BOMHandler) ImportBOMs(c echo.Context) error {
+	dryRun := false
+	if v := c.QueryParam("dryRun"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid dryRun value")
+		}
+	}
+
+	var reqs []dto.BOMRowRequest
+	var lineErrors []dto.BatchLineErrorResponse
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), "text/csv") {
+		var err error
+		if reqs, lineErrors, err = readBOMCSV(c.Request().Body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	} else {
+		req := new(dto.ImportBOMsRequest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		reqs = req.Rows
+	}
+	if len(reqs) == 0 || len(reqs) > bomUseCase.MaxImportRows {
+		return echo.NewHTTPError(http.StatusBadRequest, bomUseCase.ErrImportSize.Error())
+	}
+
+	unreadable := make(map[int]bool, len(lineErrors))
+	for _, l := range lineErrors {
+		unreadable[l.Line] = true
+	}
+	rows := make([]bomUseCase.ImportRow, len(reqs))
+	for i, req := range reqs {
+		if unreadable[i+1] {
+			continue
+		}
+		row, err := h.toImportRow(req)
+		if err != nil {
+			lineErrors = append(lineErrors, dto.BatchLineErrorResponse{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		row.Line = i + 1
+		rows[i] = row
+	}
+	if len(lineErrors) > 0 {
+		sort.Slice(lineErrors, func(i, j int) bool { return lineErrors[i].Line < lineErrors[j].Line })
+		return c.JSON(http.StatusBadRequest, dto.StockMovementBatchErrorResponse{Message: "Invalid BOM rows", Errors: lineErrors})
+	}
+
+	report, err := h.bomUsecase.ImportBOMs(c.Request().Context(), rows, dryRun)
+	if err != nil {
+		if errors.Is(err, bomUseCase.ErrImportSize) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return bomError(err)
+	}
+
+	res := toBOMImportResponse(report)
+	switch {
+	case report.HasErrors():
+		return c.JSON(http.StatusUnprocessableEntity, res)
+	case dryRun:
+		return c.JSON(http.StatusOK, res)
+	default:
+		return c.JSON(http.StatusCreated, res)
+	}
+}
+
+// toImportRow validates a request row and converts it into an import row.
+func (h *BOMHandler) toImportRow(req dto.BOMRowRequest) (bomUseCase.ImportRow, error) {
+	if err := h.validator.Validate(&req); err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return bomUseCase.ImportRow{}, fmt.Errorf("%v", httpErr.Message)
+		}
+		return bomUseCase.ImportRow{}, err
+	}
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		return bomUseCase.ImportRow{}, errors.New("invalid product_id format")
+	}
+	componentID, err := uuid.Parse(req.ComponentItemID)
+	if err != nil {
+		return bomUseCase.ImportRow{}, errors.New("invalid component_item_id format")
+	}
+	return bomUseCase.ImportRow{
+		ProductID:       productID,
+		Revision:        req.Revision,
+		Name:            req.Name,
+		YieldPercent:    req.YieldPercent,
+		ComponentItemID: componentID,
+		Quantity:        req.Quantity,
+		ScrapPercent:    req.ScrapPercent,
+		UnitOfMeasure:   req.UnitOfMeasure,
+	}, nil
+}
+
+// readBOMCSV reads the rows of a CSV BOM import. The header row names the columns, in any order.
+// Rows with an unreadable number are returned as line errors, the others as requests.
+func readBOMCSV(body io.Reader) ([]dto.BOMRowRequest, []dto.BatchLineErrorResponse, error) {
+	r := csv.NewReader(body)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range bomCSVColumns[:5] {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	parseAmount := func(record []string, name string) (float64, error) {
+		value := field(record, name)
+		if value == "" {
+			return 0, nil
+		}
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, value)
+		}
+		return amount, nil
+	}
+
+	var reqs []dto.BOMRowRequest
+	var lineErrors []dto.BatchLineErrorResponse
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		req := dto.BOMRowRequest{
+			ProductID:       field(record, "product_id"),
+			Name:            field(record, "name"),
+			ComponentItemID: field(record, "component_item_id"),
+			UnitOfMeasure:   field(record, "unit_of_measure"),
+		}
+		if value := field(record, "revision"); value != "" {
+			if req.Revision, err = strconv.Atoi(value); err != nil {
+				err = fmt.Errorf("invalid revision %q", value)
+			}
+		}
+		if err == nil {
+			req.Quantity, err = parseAmount(record, "quantity")
+		}
+		if err == nil {
+			req.YieldPercent, err = parseAmount(record, "yield_percent")
+		}
+		if err == nil {
+			req.ScrapPercent, err = parseAmount(record, "scrap_percent")
+		}
+		if err != nil {
+			lineErrors = append(lineErrors, dto.BatchLineErrorResponse{Line: line, Message: err.Error()})
+			reqs = append(reqs, dto.BOMRowRequest{})
+			continue
+		}
+		req.Sanitize()
+		reqs = append(reqs, req)
+	}
+	return reqs, lineErrors, nil
+}
+
+// ExportBOMs exports every BOM, or those of a product, status or activity, one row per
+// component, as JSON or as a CSV attachment (format=csv) that can be imported back.
+func (h *BOMHandler) ExportBOMs(c echo.Context) error {
+	req := new(dto.ExportBOMsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	filter := bomUseCase.ExportFilter{Status: bom.RevisionStatus(req.Status)}
+	if req.ProductID != "" {
+		productID, err := uuid.Parse(req.ProductID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Product ID")
+		}
+		filter.ProductID = &productID
+	}
+	if req.Active != "" {
+		active := req.Active == "true"
+		filter.IsActive = &active
+	}
+
+	boms, err := h.bomUsecase.ExportBOMs(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	rows := []dto.BOMRowResponse{}
+	for _, b := range boms {
+		for _, comp := range b.Components {
+			rows = append(rows, dto.BOMRowResponse{
+				BOMID:           b.ID,
+				ProductID:       b.ProductID,
+				Revision:        b.Revision,
+				Status:          string(b.Status),
+				IsActive:        b.IsActive,
+				Name:            b.Name,
+				YieldPercent:    b.YieldPercent,
+				ComponentItemID: comp.ComponentItemID,
+				Quantity:        comp.Quantity,
+				ScrapPercent:    comp.ScrapPercent,
+				UnitOfMeasure:   comp.UnitOfMeasure,
+			})
+		}
+	}
+
+	if req.Format == "csv" {
+		return writeBOMCSV(c, rows)
+	}
+	return c.JSON(http.StatusOK, dto.BOMExportResponse{Rows: rows})
+}
+
+// writeBOMCSV streams the exported rows as a CSV attachment.
+func writeBOMCSV(c echo.Context, rows []dto.BOMRowResponse) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="boms-%s.csv"`, time.Now().Format("2006-01-02")))
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	formatAmount := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	w.Write([]string{"bom_id", "product_id", "revision", "status", "is_active", "name", "yield_percent",
+		"component_item_id", "quantity", "scrap_percent", "unit_of_measure"})
+	for _, r := range rows {
+		w.Write([]string{
+			r.BOMID.String(), r.ProductID.String(), strconv.Itoa(r.Revision), r.Status,
+			strconv.FormatBool(r.IsActive), r.Name, formatAmount(r.YieldPercent),
+			r.ComponentItemID.String(), formatAmount(r.Quantity), formatAmount(r.ScrapPercent), r.UnitOfMeasure,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// toBOMImportResponse converts the report of a bulk BOM import to its response.
+func toBOMImportResponse(r *bomUseCase.ImportReport) dto.BOMImportResponse {
+	res := dto.BOMImportResponse{
+		DryRun:  r.DryRun,
+		BOMs:    r.BOMs,
+		Created: r.Created,
+		Rows:    make([]dto.BOMImportRowResponse, len(r.Rows)),
+	}
+	for i, row := range r.Rows {
+		res.Rows[i] = dto.BOMImportRowResponse{
+			Line:      row.Line,
+			ProductID: row.ProductID,
+			Status:    string(row.Status),
+			BOMID:     row.BOMID,
+			Revision:  row.Revision,
+			Errors:    row.Errors,
+		}
+	}
+	return res
+}
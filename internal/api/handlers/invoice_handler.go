@@ -1,15 +1,30 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"doligo_001/internal/api/dto"
+	domainInvoice "doligo_001/internal/domain/invoice"
+	"doligo_001/internal/domain/numbering"
 	"doligo_001/internal/usecase/invoice"
+	"gorm.io/gorm"
 )
 
+// invoiceBody is an invoice as rendered by the API, with its balance due.
+type invoiceBody struct {
+	*domainInvoice.Invoice
+	BalanceDue float64
+}
+
+func newInvoiceBody(inv *domainInvoice.Invoice) invoiceBody {
+	return invoiceBody{Invoice: inv, BalanceDue: inv.BalanceDue()}
+}
+
 type InvoiceHandler struct {
 	usecase invoice.Usecase
 }
@@ -30,10 +45,136 @@ func (h *InvoiceHandler) CreateInvoice(c echo.Context) error {
 
 	createdInvoice, err := h.usecase.Create(c.Request().Context(), &req)
 	if err != nil {
+		if unitErr := unitError(err); unitErr != nil {
+			return unitErr
+		}
+		if errors.Is(err, invoice.ErrThirdPartyNotFound) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusCreated, createdInvoice)
+	return c.JSON(http.StatusCreated, newInvoiceBody(createdInvoice))
+}
+
+// UpdateInvoice replaces the header and the lines of a draft invoice.
+func (h *InvoiceHandler) UpdateInvoice(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	var req dto.UpdateInvoiceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	inv, err := h.usecase.Update(c.Request().Context(), id, &req)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(http.StatusOK, newInvoiceBody(inv))
+}
+
+// ValidateInvoice gives a draft its final number from the invoice sequence, locking its lines.
+func (h *InvoiceHandler) ValidateInvoice(c echo.Context) error {
+	return h.transition(c, h.usecase.Validate)
+}
+
+// SendInvoice marks a validated invoice as sent and emails it to the customer.
+func (h *InvoiceHandler) SendInvoice(c echo.Context) error {
+	return h.transition(c, h.usecase.Send)
+}
+
+// CancelInvoice cancels an invoice nothing was paid on.
+func (h *InvoiceHandler) CancelInvoice(c echo.Context) error {
+	var req dto.CancelInvoiceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return h.transition(c, func(ctx context.Context, id uuid.UUID) (*domainInvoice.Invoice, error) {
+		return h.usecase.Cancel(ctx, id, req.Reason)
+	})
+}
+
+// CreateCreditNote creates a draft credit note for all or part of the lines of an issued invoice.
+func (h *InvoiceHandler) CreateCreditNote(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	var req dto.CreateCreditNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	creditNote, err := h.usecase.CreateCreditNote(c.Request().Context(), id, &req)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(http.StatusCreated, newInvoiceBody(creditNote))
+}
+
+// ListCreditNotes lists the credit notes of an invoice.
+func (h *InvoiceHandler) ListCreditNotes(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	creditNotes, err := h.usecase.ListCreditNotes(c.Request().Context(), id)
+	if err != nil {
+		return invoiceError(err)
+	}
+	res := make([]invoiceBody, len(creditNotes))
+	for i, creditNote := range creditNotes {
+		res[i] = newInvoiceBody(creditNote)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// transition runs a lifecycle action on the invoice of the request.
+func (h *InvoiceHandler) transition(c echo.Context, run func(ctx context.Context, id uuid.UUID) (*domainInvoice.Invoice, error)) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	inv, err := run(c.Request().Context(), id)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(http.StatusOK, newInvoiceBody(inv))
+}
+
+// invoiceError maps invoice lifecycle errors to HTTP errors.
+func invoiceError(err error) error {
+	if unitErr := unitError(err); unitErr != nil {
+		return unitErr
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Invoice not found")
+	case errors.Is(err, domainInvoice.ErrInvalidInvoiceStatus), errors.Is(err, domainInvoice.ErrInvoiceNotDraft),
+		errors.Is(err, domainInvoice.ErrInvalidDocumentType):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, domainInvoice.ErrInvoiceNumberRequired), errors.Is(err, domainInvoice.ErrInvalidPaymentAmount),
+		errors.Is(err, domainInvoice.ErrInvalidCreditNoteLine), errors.Is(err, domainInvoice.ErrCreditExceedsInvoice),
+		errors.Is(err, domainInvoice.ErrTrackedItemReturn), errors.Is(err, domainInvoice.ErrCustomerEmailRequired),
+		errors.Is(err, invoice.ErrWarehouseNotFound), errors.Is(err, invoice.ErrBinNotFound),
+		errors.Is(err, invoice.ErrInactiveLocation),
+		errors.Is(err, invoice.ErrThirdPartyNotFound),
+		errors.Is(err, numbering.ErrSequenceNotFound):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
 }
 
 func (h *InvoiceHandler) GetInvoice(c echo.Context) error {
@@ -47,7 +188,7 @@ func (h *InvoiceHandler) GetInvoice(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Invoice not found")
 	}
 
-	return c.JSON(http.StatusOK, inv)
+	return c.JSON(http.StatusOK, newInvoiceBody(inv))
 }
 
 func (h *InvoiceHandler) QueueInvoicePDF(c echo.Context) error {
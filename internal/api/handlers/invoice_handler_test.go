@@ -45,6 +45,42 @@ func (m *MockInvoiceUsecase) QueueInvoicePDFGeneration(ctx context.Context, id u
 	return nil
 }
 
+func (m *MockInvoiceUsecase) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateInvoiceRequest) (*invoice.Invoice, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (m *MockInvoiceUsecase) Validate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) Send(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) Cancel(ctx context.Context, id uuid.UUID, reason string) (*invoice.Invoice, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) CreateCreditNote(ctx context.Context, invoiceID uuid.UUID, req *dto.CreateCreditNoteRequest) (*invoice.Invoice, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) GetPDFStatus(ctx context.Context, id uuid.UUID) (*dto.InvoicePDFStatusResponse, error) {
+	return nil, nil
+}
+
+func (m *MockInvoiceUsecase) GetPDFPath(ctx context.Context, id uuid.UUID) (string, error) {
+	return "", nil
+}
+
 func TestCreateInvoice_SanitizationAndValidation(t *testing.T) {
 	e := echo.New()
 	e.Validator = validator.NewValidator()
@@ -55,7 +91,6 @@ func TestCreateInvoice_SanitizationAndValidation(t *testing.T) {
 	t.Run("Valid Invoice", func(t *testing.T) {
 		reqBody := dto.CreateInvoiceRequest{
 			ThirdPartyID: uuid.New().String(),
-			Number:       "INV-001",
 			Date:         "2023-10-27",
 			Lines: []dto.CreateInvoiceLineRequest{
 				{
@@ -68,7 +103,8 @@ func TestCreateInvoice_SanitizationAndValidation(t *testing.T) {
 		}
 
 		mockUsecase.CreateFunc = func(ctx context.Context, req *dto.CreateInvoiceRequest) (*invoice.Invoice, error) {
-			return &invoice.Invoice{Number: req.Number}, nil
+			id := uuid.New()
+			return &invoice.Invoice{ID: id, Number: invoice.ProvisionalNumber(id)}, nil
 		}
 
 		jsonBody, _ := json.Marshal(reqBody)
@@ -85,7 +121,6 @@ func TestCreateInvoice_SanitizationAndValidation(t *testing.T) {
 	t.Run("Sanitization of XSS", func(t *testing.T) {
 		reqBody := dto.CreateInvoiceRequest{
 			ThirdPartyID: uuid.New().String(),
-			Number:       "<script>alert('xss')</script>INV-002",
 			Date:         "2023-10-27",
 			Lines: []dto.CreateInvoiceLineRequest{
 				{
@@ -98,18 +133,10 @@ func TestCreateInvoice_SanitizationAndValidation(t *testing.T) {
 		}
 
 		mockUsecase.CreateFunc = func(ctx context.Context, req *dto.CreateInvoiceRequest) (*invoice.Invoice, error) {
-			// Verify sanitization
-			assert.Equal(t, "INV-002", req.Number) // Script tags removed (depending on sanitizer config, but usually empty or just text)
-			// Actually bluemonday strict policy strips tags. So <script>... is gone.
-			// Let's assume strict policy which might strip the whole tag content or just the tag. 
-			// Standard behavior for bluemonday.UGCPolicy() preserves text content of some tags but strips script.
-			// But sanitizer package in this project likely uses `UGCPolicy` or `StrictPolicy`. 
-			// If it uses StrictPolicy, it strips tags. 
-			// "alert('xss')INV-002" or just "INV-002" if script is fully removed.
-			// Let's just check it doesn't contain <script>
-			assert.NotContains(t, req.Number, "<script>")
+			// Verify sanitization: the number is assigned at validation, so only the lines carry user text
 			assert.NotContains(t, req.Lines[0].Description, "<img")
-			return &invoice.Invoice{Number: req.Number}, nil
+			id := uuid.New()
+			return &invoice.Invoice{ID: id, Number: invoice.ProvisionalNumber(id)}, nil
 		}
 
 		jsonBody, _ := json.Marshal(reqBody)
@@ -126,7 +153,6 @@ func TestCreateInvoice_SanitizationAndValidation(t *testing.T) {
 	t.Run("Invalid UUID", func(t *testing.T) {
 		reqBody := dto.CreateInvoiceRequest{
 			ThirdPartyID: "invalid-uuid",
-			Number:       "INV-003",
 			Date:         "2023-10-27",
 			Lines: []dto.CreateInvoiceLineRequest{
 				{
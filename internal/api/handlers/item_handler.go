@@ -2,7 +2,10 @@
 package handlers
 
 import (
+	"errors"
+
 	"doligo_001/internal/api/dto"
+	domainItem "doligo_001/internal/domain/item"
 	"doligo_001/internal/usecase/item"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -41,6 +44,13 @@ func (h *ItemHandler) Create(c echo.Context) error {
 
 	i, err := h.usecase.Create(c.Request().Context(), req)
 	if err != nil {
+		if errors.Is(err, domainItem.ErrTrackingRequiresStorable) || errors.Is(err, domainItem.ErrCostingRequiresStorable) ||
+			errors.Is(err, domainItem.ErrBaseUnitChange) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if unitErr := unitError(err); unitErr != nil {
+			return unitErr
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -80,6 +90,13 @@ func (h *ItemHandler) Update(c echo.Context) error {
 
 	i, err := h.usecase.Update(c.Request().Context(), id, req)
 	if err != nil {
+		if errors.Is(err, domainItem.ErrTrackingRequiresStorable) || errors.Is(err, domainItem.ErrCostingRequiresStorable) ||
+			errors.Is(err, domainItem.ErrBaseUnitChange) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if unitErr := unitError(err); unitErr != nil {
+			return unitErr
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/api/validator"
+	"doligo_001/internal/domain/bom"
+	bomUseCase "doligo_001/internal/usecase/bom"
+	stock_usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ManufacturingOrderHandler handles HTTP requests for manufacturing orders.
+type ManufacturingOrderHandler struct {
+	usecase   bomUseCase.ManufacturingOrderUsecase
+	validator *validator.CustomValidator
+}
+
+// NewManufacturingOrderHandler creates a new ManufacturingOrderHandler.
+func NewManufacturingOrderHandler(uc bomUseCase.ManufacturingOrderUsecase, v *validator.CustomValidator) *ManufacturingOrderHandler {
+	return &ManufacturingOrderHandler{usecase: uc, validator: v}
+}
+
+// CreateOrder plans a manufacturing order with the BOM revision of the product in force now.
+func (h *ManufacturingOrderHandler) CreateOrder(c echo.Context) error {
+	req := new(dto.CreateManufacturingOrderRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Product ID")
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID")
+	}
+
+	order := &bom.ManufacturingOrder{
+		ProductID:    productID,
+		WarehouseID:  warehouseID,
+		Quantity:     req.Quantity,
+		PlannedStart: req.PlannedStart,
+		PlannedEnd:   req.PlannedEnd,
+	}
+	if err := h.usecase.CreateOrder(c.Request().Context(), order); err != nil {
+		return orderError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewManufacturingOrderResponse(order))
+}
+
+func (h *ManufacturingOrderHandler) ListOrders(c echo.Context) error {
+	req := new(dto.ListManufacturingOrdersRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	filter := bom.OrderFilter{Status: bom.OrderStatus(req.Status)}
+	if req.ProductID != "" {
+		id, err := uuid.Parse(req.ProductID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Product ID")
+		}
+		filter.ProductID = &id
+	}
+	if req.WarehouseID != "" {
+		id, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID")
+		}
+		filter.WarehouseID = &id
+	}
+
+	orders, err := h.usecase.ListOrders(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.ManufacturingOrderResponse, len(orders))
+	for i, o := range orders {
+		res[i] = dto.NewManufacturingOrderResponse(o)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *ManufacturingOrderHandler) GetOrder(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	order, err := h.usecase.GetOrder(c.Request().Context(), id)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewManufacturingOrderResponse(order))
+}
+
+// ReleaseOrder reserves the storable components of a planned order.
+func (h *ManufacturingOrderHandler) ReleaseOrder(c echo.Context) error {
+	return h.transition(c, h.usecase.ReleaseOrder)
+}
+
+// IssueComponents issues component quantities, with their lots, to a released order.
+func (h *ManufacturingOrderHandler) IssueComponents(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.IssueComponentsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	issues := make([]bom.ComponentIssue, len(req.Components))
+	for i, ci := range req.Components {
+		itemID, err := uuid.Parse(ci.ComponentItemID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Component Item ID")
+		}
+		issues[i] = bom.ComponentIssue{ItemID: itemID, Quantity: ci.Quantity, Lots: dto.ToLotQuantities(ci.Lots)}
+	}
+
+	order, err := h.usecase.IssueComponents(c.Request().Context(), id, issues)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewManufacturingOrderResponse(order))
+}
+
+// ReceiveProducts receives a quantity of the product, with its lots, from an order in progress.
+func (h *ManufacturingOrderHandler) ReceiveProducts(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.ReceiveProductsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.validator.Validate(req); err != nil {
+		return err
+	}
+
+	order, err := h.usecase.ReceiveProducts(c.Request().Context(), id, req.Quantity, dto.ToLotQuantities(req.Lots))
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewManufacturingOrderResponse(order))
+}
+
+// CloseOrder completes an order in progress and creates its ProductionRecord.
+func (h *ManufacturingOrderHandler) CloseOrder(c echo.Context) error {
+	return h.transition(c, h.usecase.CloseOrder)
+}
+
+// CancelOrder cancels an order before anything was issued to it.
+func (h *ManufacturingOrderHandler) CancelOrder(c echo.Context) error {
+	return h.transition(c, h.usecase.CancelOrder)
+}
+
+// transition runs a status change that only takes the order ID from the path.
+func (h *ManufacturingOrderHandler) transition(c echo.Context, run func(ctx context.Context, id uuid.UUID) (*bom.ManufacturingOrder, error)) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	order, err := run(c.Request().Context(), id)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewManufacturingOrderResponse(order))
+}
+
+// orderError maps manufacturing order errors to HTTP errors.
+func orderError(err error) error {
+	if lotErr := lotError(err); lotErr != nil {
+		return lotErr
+	}
+	if unitErr := unitError(err); unitErr != nil {
+		return unitErr
+	}
+	switch {
+	case errors.Is(err, bom.ErrOrderNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, bom.ErrInvalidOrderStatus), errors.Is(err, stock_usecase.ErrInsufficientStock):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, bom.ErrNotOrderComponent), errors.Is(err, bom.ErrOverIssue),
+		errors.Is(err, bom.ErrOverReceipt), errors.Is(err, bom.ErrComponentsNotIssued),
+		errors.Is(err, bom.ErrNoRevisionInForce):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, bom.ErrInvalidPlannedDates), errors.Is(err, stock_usecase.ErrInvalidQuantity):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
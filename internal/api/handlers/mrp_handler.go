@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/mrp"
+	mrp_usecase "doligo_001/internal/usecase/mrp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// MrpHandler handles HTTP requests for forecasts, MRP runs and their planned orders.
+type MrpHandler struct {
+	usecase mrp_usecase.Usecase
+}
+
+// NewMrpHandler creates a new MrpHandler.
+func NewMrpHandler(uc mrp_usecase.Usecase) *MrpHandler {
+	return &MrpHandler{usecase: uc}
+}
+
+func (h *MrpHandler) CreateForecast(c echo.Context) error {
+	req := new(dto.CreateForecastRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+	}
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid due date format")
+	}
+
+	forecast := &mrp.Forecast{
+		ItemID:      itemID,
+		WarehouseID: warehouseID,
+		Quantity:    req.Quantity,
+		DueDate:     dueDate,
+		Notes:       req.Notes,
+	}
+	if err := h.usecase.CreateForecast(c.Request().Context(), forecast); err != nil {
+		return mrpError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewForecastResponse(forecast))
+}
+
+func (h *MrpHandler) ListForecasts(c echo.Context) error {
+	req := new(dto.ListForecastsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	var filter mrp.ForecastFilter
+	if req.ItemID != "" {
+		id, err := uuid.Parse(req.ItemID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+		}
+		filter.ItemID = &id
+	}
+	if req.WarehouseID != "" {
+		id, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+		}
+		filter.WarehouseID = &id
+	}
+	if req.To != "" {
+		to, err := time.Parse("2006-01-02", req.To)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid date format")
+		}
+		filter.To = &to
+	}
+
+	forecasts, err := h.usecase.ListForecasts(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.ForecastResponse, len(forecasts))
+	for i, f := range forecasts {
+		res[i] = dto.NewForecastResponse(f)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *MrpHandler) GetForecast(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	forecast, err := h.usecase.GetForecast(c.Request().Context(), id)
+	if err != nil {
+		return mrpError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewForecastResponse(forecast))
+}
+
+func (h *MrpHandler) UpdateForecast(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.UpdateForecastRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid due date format")
+	}
+	forecast := &mrp.Forecast{
+		ID:       id,
+		Quantity: req.Quantity,
+		DueDate:  dueDate,
+		Notes:    req.Notes,
+	}
+	if err := h.usecase.UpdateForecast(c.Request().Context(), forecast); err != nil {
+		return mrpError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewForecastResponse(forecast))
+}
+
+func (h *MrpHandler) DeleteForecast(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	if err := h.usecase.DeleteForecast(c.Request().Context(), id); err != nil {
+		return mrpError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// StartRun queues an MRP run on the worker pool. The run is returned while still queued;
+// its status and planned orders are read from the run endpoints.
+func (h *MrpHandler) StartRun(c echo.Context) error {
+	req := new(dto.StartMrpRunRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+	}
+	horizonEnd, err := time.Parse("2006-01-02", req.HorizonEnd)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid horizon end format")
+	}
+
+	run := &mrp.Run{
+		WarehouseID:     warehouseID,
+		HorizonEnd:      horizonEnd,
+		IncludeInvoices: req.IncludeInvoices,
+	}
+	if err := h.usecase.StartRun(c.Request().Context(), run); err != nil {
+		return mrpError(err)
+	}
+	return c.JSON(http.StatusAccepted, dto.NewMrpRunResponse(run))
+}
+
+func (h *MrpHandler) ListRuns(c echo.Context) error {
+	req := new(dto.ListMrpRunsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	var warehouseID *uuid.UUID
+	if req.WarehouseID != "" {
+		id, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+		}
+		warehouseID = &id
+	}
+
+	runs, err := h.usecase.ListRuns(c.Request().Context(), warehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.MrpRunResponse, len(runs))
+	for i, r := range runs {
+		res[i] = dto.NewMrpRunResponse(r)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *MrpHandler) GetRun(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	run, err := h.usecase.GetRun(c.Request().Context(), id)
+	if err != nil {
+		return mrpError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewMrpRunResponse(run))
+}
+
+// ListPlannedOrders returns the planned orders of a run by level and start date.
+func (h *MrpHandler) ListPlannedOrders(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.ListPlannedOrdersRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	filter := mrp.PlannedOrderFilter{Type: mrp.OrderType(req.Type)}
+	if req.ItemID != "" {
+		itemID, err := uuid.Parse(req.ItemID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+		}
+		filter.ItemID = &itemID
+	}
+
+	orders, err := h.usecase.ListPlannedOrders(c.Request().Context(), id, filter)
+	if err != nil {
+		return mrpError(err)
+	}
+	res := make([]*dto.PlannedOrderResponse, len(orders))
+	for i, o := range orders {
+		res[i] = dto.NewPlannedOrderResponse(o)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// mrpError maps forecast and MRP run errors to HTTP errors.
+func mrpError(err error) error {
+	switch {
+	case errors.Is(err, mrp.ErrForecastNotFound), errors.Is(err, mrp.ErrRunNotFound),
+		errors.Is(err, mrp_usecase.ErrItemNotFound), errors.Is(err, mrp_usecase.ErrWarehouseNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, mrp.ErrInvalidForecast), errors.Is(err, mrp.ErrInvalidHorizon),
+		errors.Is(err, mrp_usecase.ErrNotStorable):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, mrp_usecase.ErrPlannerBusy):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/numbering"
+	numbering_usecase "doligo_001/internal/usecase/numbering"
+	"github.com/labstack/echo/v4"
+)
+
+// NumberingHandler handles HTTP requests for numbering sequences.
+type NumberingHandler struct {
+	usecase numbering_usecase.Usecase
+}
+
+// NewNumberingHandler creates a new NumberingHandler.
+func NewNumberingHandler(uc numbering_usecase.Usecase) *NumberingHandler {
+	return &NumberingHandler{usecase: uc}
+}
+
+func (h *NumberingHandler) ListSequences(c echo.Context) error {
+	sequences, err := h.usecase.ListSequences(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.NumberingSequenceResponse, len(sequences))
+	for i, sequence := range sequences {
+		res[i] = dto.NewNumberingSequenceResponse(sequence)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// UpdateSequence changes the pattern and the reset policy of the sequence of a document type.
+func (h *NumberingHandler) UpdateSequence(c echo.Context) error {
+	req := new(dto.UpdateNumberingSequenceRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	sequence, err := h.usecase.UpdateSequence(c.Request().Context(), numbering.DocumentType(c.Param("documentType")),
+		req.Pattern, numbering.ResetPolicy(req.Reset))
+	if err != nil {
+		return numberingError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewNumberingSequenceResponse(sequence))
+}
+
+// numberingError maps numbering sequence errors to HTTP errors.
+func numberingError(err error) error {
+	switch {
+	case errors.Is(err, numbering.ErrSequenceNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, numbering.ErrInvalidPattern), errors.Is(err, numbering.ErrInvalidResetPolicy),
+		errors.Is(err, numbering.ErrPatternWithoutYear):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
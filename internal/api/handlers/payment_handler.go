@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"doligo_001/internal/api/dto"
+	domainInvoice "doligo_001/internal/domain/invoice"
+	"doligo_001/internal/domain/payment"
+	payment_usecase "doligo_001/internal/usecase/payment"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// PaymentHandler handles HTTP requests for customer payments and statements.
+type PaymentHandler struct {
+	usecase payment_usecase.Usecase
+}
+
+// NewPaymentHandler creates a new PaymentHandler.
+func NewPaymentHandler(uc payment_usecase.Usecase) *PaymentHandler {
+	return &PaymentHandler{usecase: uc}
+}
+
+// RecordPayment records a payment received from a customer, allocated to the given invoices.
+func (h *PaymentHandler) RecordPayment(c echo.Context) error {
+	req := new(dto.CreatePaymentRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.Sanitize()
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	thirdPartyID, err := uuid.Parse(req.ThirdPartyID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Third Party ID format")
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid date format")
+	}
+	allocations, err := paymentAllocations(req.Allocations)
+	if err != nil {
+		return err
+	}
+
+	p := &payment.Payment{
+		ThirdPartyID: thirdPartyID,
+		Date:         date,
+		Amount:       req.Amount,
+		Method:       payment.Method(req.Method),
+		Reference:    req.Reference,
+	}
+	if err := h.usecase.Record(c.Request().Context(), p, allocations); err != nil {
+		return paymentError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewPaymentResponse(p))
+}
+
+// PayInvoice records a payment of the customer of an invoice, allocated to the invoice up to its
+// balance due; the rest is left unallocated as a credit of the customer.
+func (h *PaymentHandler) PayInvoice(c echo.Context) error {
+	invoiceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	req := new(dto.InvoicePaymentRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.Sanitize()
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	p := &payment.Payment{
+		Date:      time.Now().Truncate(24 * time.Hour),
+		Amount:    req.Amount,
+		Method:    payment.MethodOther,
+		Reference: req.Reference,
+	}
+	if req.Date != "" {
+		if p.Date, err = time.Parse("2006-01-02", req.Date); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid date format")
+		}
+	}
+	if req.Method != "" {
+		p.Method = payment.Method(req.Method)
+	}
+	if err := h.usecase.PayInvoice(c.Request().Context(), invoiceID, p); err != nil {
+		if errors.Is(err, payment_usecase.ErrInvoiceNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Invoice not found")
+		}
+		return paymentError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewPaymentResponse(p))
+}
+
+func (h *PaymentHandler) GetPayment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	p, err := h.usecase.GetPayment(c.Request().Context(), id)
+	if err != nil {
+		return paymentError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewPaymentResponse(p))
+}
+
+// AllocatePayment allocates the unallocated part of a payment to invoices of its customer.
+func (h *PaymentHandler) AllocatePayment(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	req := new(dto.AllocatePaymentRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	allocations, err := paymentAllocations(req.Allocations)
+	if err != nil {
+		return err
+	}
+	p, err := h.usecase.Allocate(c.Request().Context(), id, allocations)
+	if err != nil {
+		return paymentError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewPaymentResponse(p))
+}
+
+func (h *PaymentHandler) ListThirdPartyPayments(c echo.Context) error {
+	thirdPartyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	payments, err := h.usecase.ListPayments(c.Request().Context(), thirdPartyID)
+	if err != nil {
+		return paymentError(err)
+	}
+	res := make([]*dto.PaymentResponse, len(payments))
+	for i, p := range payments {
+		res[i] = dto.NewPaymentResponse(p)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetStatement returns the statement of a customer, optionally bounded by the from and to dates.
+func (h *PaymentHandler) GetStatement(c echo.Context) error {
+	thirdPartyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	req := new(dto.StatementRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	var from, to *time.Time
+	if req.From != "" {
+		date, err := time.Parse("2006-01-02", req.From)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid from date format")
+		}
+		from = &date
+	}
+	if req.To != "" {
+		date, err := time.Parse("2006-01-02", req.To)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid to date format")
+		}
+		to = &date
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return echo.NewHTTPError(http.StatusBadRequest, "to must not be before from")
+	}
+
+	statement, err := h.usecase.Statement(c.Request().Context(), thirdPartyID, from, to)
+	if err != nil {
+		return paymentError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewStatementResponse(statement))
+}
+
+// paymentAllocations converts allocation requests to payment allocations.
+func paymentAllocations(reqs []dto.PaymentAllocationRequest) ([]payment.Allocation, error) {
+	allocations := make([]payment.Allocation, len(reqs))
+	for i, req := range reqs {
+		invoiceID, err := uuid.Parse(req.InvoiceID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid Invoice ID format")
+		}
+		allocations[i] = payment.Allocation{InvoiceID: invoiceID, Amount: req.Amount}
+	}
+	return allocations, nil
+}
+
+// paymentError maps payment errors to HTTP errors.
+func paymentError(err error) error {
+	switch {
+	case errors.Is(err, payment.ErrPaymentNotFound), errors.Is(err, payment_usecase.ErrThirdPartyNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, domainInvoice.ErrInvalidInvoiceStatus), errors.Is(err, domainInvoice.ErrInvalidDocumentType):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, payment_usecase.ErrInvoiceNotFound), errors.Is(err, payment.ErrInvalidAmount),
+		errors.Is(err, payment.ErrInvalidMethod), errors.Is(err, payment.ErrAllocationExceedsPayment),
+		errors.Is(err, payment.ErrInvoiceOfOtherCustomer), errors.Is(err, domainInvoice.ErrInvalidPaymentAmount):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/replenishment"
+	replenishment_usecase "doligo_001/internal/usecase/replenishment"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ReplenishmentHandler handles HTTP requests for reorder rules and replenishment proposals.
+type ReplenishmentHandler struct {
+	usecase replenishment_usecase.Usecase
+}
+
+// NewReplenishmentHandler creates a new ReplenishmentHandler.
+func NewReplenishmentHandler(uc replenishment_usecase.Usecase) *ReplenishmentHandler {
+	return &ReplenishmentHandler{usecase: uc}
+}
+
+func (h *ReplenishmentHandler) CreateRule(c echo.Context) error {
+	req := new(dto.CreateReorderRuleRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+	}
+
+	rule := &replenishment.ReorderRule{
+		ItemID:       itemID,
+		WarehouseID:  warehouseID,
+		MinQuantity:  req.MinQuantity,
+		ReorderPoint: req.ReorderPoint,
+		MaxQuantity:  req.MaxQuantity,
+	}
+	if err := h.usecase.CreateRule(c.Request().Context(), rule); err != nil {
+		return replenishmentError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewReorderRuleResponse(rule))
+}
+
+func (h *ReplenishmentHandler) ListRules(c echo.Context) error {
+	req := new(dto.ListReorderRulesRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	var filter replenishment.RuleFilter
+	if req.ItemID != "" {
+		id, err := uuid.Parse(req.ItemID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+		}
+		filter.ItemID = &id
+	}
+	if req.WarehouseID != "" {
+		id, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+		}
+		filter.WarehouseID = &id
+	}
+
+	rules, err := h.usecase.ListRules(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.ReorderRuleResponse, len(rules))
+	for i, r := range rules {
+		res[i] = dto.NewReorderRuleResponse(r)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *ReplenishmentHandler) GetRule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	rule, err := h.usecase.GetRule(c.Request().Context(), id)
+	if err != nil {
+		return replenishmentError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewReorderRuleResponse(rule))
+}
+
+func (h *ReplenishmentHandler) UpdateRule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.UpdateReorderRuleRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	current, err := h.usecase.GetRule(ctx, id)
+	if err != nil {
+		return replenishmentError(err)
+	}
+	rule := &replenishment.ReorderRule{
+		ID:           id,
+		MinQuantity:  req.MinQuantity,
+		ReorderPoint: req.ReorderPoint,
+		MaxQuantity:  req.MaxQuantity,
+		IsActive:     current.IsActive,
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if err := h.usecase.UpdateRule(ctx, rule); err != nil {
+		return replenishmentError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewReorderRuleResponse(rule))
+}
+
+func (h *ReplenishmentHandler) DeleteRule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	if err := h.usecase.DeleteRule(c.Request().Context(), id); err != nil {
+		return replenishmentError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Run triggers a replenishment run right away instead of waiting for the scheduled job.
+func (h *ReplenishmentHandler) Run(c echo.Context) error {
+	proposals, err := h.usecase.Run(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, dto.NewProposalListResponse(proposals))
+}
+
+// ListProposals returns the proposals of the latest replenishment run.
+func (h *ReplenishmentHandler) ListProposals(c echo.Context) error {
+	req := new(dto.ListProposalsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	filter := replenishment.ProposalFilter{Type: replenishment.ProposalType(req.Type)}
+	if req.ItemID != "" {
+		id, err := uuid.Parse(req.ItemID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+		}
+		filter.ItemID = &id
+	}
+	if req.WarehouseID != "" {
+		id, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+		}
+		filter.WarehouseID = &id
+	}
+
+	proposals, err := h.usecase.ListProposals(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, dto.NewProposalListResponse(proposals))
+}
+
+// replenishmentError maps reorder rule errors to HTTP errors.
+func replenishmentError(err error) error {
+	switch {
+	case errors.Is(err, replenishment.ErrRuleNotFound), errors.Is(err, replenishment_usecase.ErrItemNotFound),
+		errors.Is(err, replenishment_usecase.ErrWarehouseNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, replenishment.ErrRuleExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, replenishment.ErrInvalidRule), errors.Is(err, replenishment_usecase.ErrNotStorable):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
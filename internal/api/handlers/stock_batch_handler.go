@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/stock"
+	stock_usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// batchCSVColumns are the columns of a CSV bulk import. lot_number is optional and books the
+// whole quantity of the row to that lot; unit_of_measure is optional and defaults to the base unit.
+var batchCSVColumns = []string{"item_id", "warehouse_id", "bin_id", "type", "quantity", "unit_price", "reason", "lot_number", "unit_of_measure"}
+
+// StockBatchHandler handles HTTP requests for bulk stock movement imports.
+type StockBatchHandler struct {
+	usecase stock_usecase.BatchUseCase
+}
+
+// NewStockBatchHandler creates a new StockBatchHandler.
+func NewStockBatchHandler(uc stock_usecase.BatchUseCase) *StockBatchHandler {
+	return &StockBatchHandler{usecase: uc}
+}
+
+// ImportStockMovements posts a JSON or CSV (Content-Type text/csv) list of movements in one
+// transaction. Rejected lines are all reported with their line number and nothing is posted.
+// A request sent again with the same Idempotency-Key header returns the first batch with 200.
+func (h *StockBatchHandler) ImportStockMovements(c echo.Context) error {
+	var reqs []dto.CreateStockMovementRequest
+	var lineErrors []dto.BatchLineErrorResponse
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), "text/csv") {
+		var err error
+		if reqs, lineErrors, err = readBatchCSV(c.Request().Body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	} else {
+		req := new(dto.CreateStockMovementBatchRequest)
+		if err := c.Bind(req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		reqs = req.Lines
+	}
+	if len(reqs) == 0 || len(reqs) > stock_usecase.MaxBatchLines {
+		return echo.NewHTTPError(http.StatusBadRequest, stock_usecase.ErrBatchSize.Error())
+	}
+
+	unreadable := make(map[int]bool, len(lineErrors))
+	for _, l := range lineErrors {
+		unreadable[l.Line] = true
+	}
+	lines := make([]stock_usecase.BatchLine, len(reqs))
+	for i, req := range reqs {
+		if unreadable[i+1] {
+			continue
+		}
+		line, err := toBatchLine(c, req)
+		if err != nil {
+			lineErrors = append(lineErrors, dto.BatchLineErrorResponse{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		lines[i] = line
+	}
+	if len(lineErrors) > 0 {
+		sort.Slice(lineErrors, func(i, j int) bool { return lineErrors[i].Line < lineErrors[j].Line })
+		return c.JSON(http.StatusBadRequest, dto.StockMovementBatchErrorResponse{Message: "Invalid batch lines", Errors: lineErrors})
+	}
+
+	result, err := h.usecase.ImportMovements(c.Request().Context(), c.Request().Header.Get("Idempotency-Key"), lines)
+	if err != nil {
+		var validationErr *stock_usecase.BatchValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			res := dto.StockMovementBatchErrorResponse{Message: "Batch rejected"}
+			for _, l := range validationErr.Lines {
+				res.Errors = append(res.Errors, dto.BatchLineErrorResponse{Line: l.Line, Message: l.Message})
+			}
+			return c.JSON(http.StatusUnprocessableEntity, res)
+		case errors.Is(err, stock.ErrBatchKeyReused):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case errors.Is(err, stock_usecase.ErrBatchSize):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	status := http.StatusCreated
+	if result.Replayed {
+		status = http.StatusOK
+	}
+	return c.JSON(status, dto.NewStockMovementBatchResponse(result.BatchID, result.Replayed, result.Movements))
+}
+
+// toBatchLine validates a request line and converts it into a batch line.
+func toBatchLine(c echo.Context, req dto.CreateStockMovementRequest) (stock_usecase.BatchLine, error) {
+	if err := c.Validate(&req); err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return stock_usecase.BatchLine{}, fmt.Errorf("%v", httpErr.Message)
+		}
+		return stock_usecase.BatchLine{}, err
+	}
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return stock_usecase.BatchLine{}, errors.New("invalid item_id format")
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return stock_usecase.BatchLine{}, errors.New("invalid warehouse_id format")
+	}
+	binID, err := uuid.Parse(req.BinID)
+	if err != nil {
+		return stock_usecase.BatchLine{}, errors.New("invalid bin_id format")
+	}
+	return stock_usecase.BatchLine{
+		ItemID:      itemID,
+		WarehouseID: warehouseID,
+		BinID:       binID,
+		Type:        stock.MovementType(req.Type),
+		Quantity:    req.Quantity,
+		Unit:        req.UnitOfMeasure,
+		UnitPrice:   req.UnitPrice,
+		Reason:      req.Reason,
+		Lots:        dto.ToLotQuantities(req.Lots),
+	}, nil
+}
+
+// readBatchCSV reads the lines of a CSV bulk import. The header row names the columns, in any order.
+// Rows with an unreadable number are returned as line errors, the others as requests.
+func readBatchCSV(body io.Reader) ([]dto.CreateStockMovementRequest, []dto.BatchLineErrorResponse, error) {
+	r := csv.NewReader(body)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range batchCSVColumns[:5] {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	parseAmount := func(record []string, name string) (float64, error) {
+		value := field(record, name)
+		if value == "" {
+			return 0, nil
+		}
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, value)
+		}
+		return amount, nil
+	}
+
+	var reqs []dto.CreateStockMovementRequest
+	var lineErrors []dto.BatchLineErrorResponse
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		req := dto.CreateStockMovementRequest{
+			ItemID:        field(record, "item_id"),
+			WarehouseID:   field(record, "warehouse_id"),
+			BinID:         field(record, "bin_id"),
+			Type:          strings.ToUpper(field(record, "type")),
+			Reason:        field(record, "reason"),
+			UnitOfMeasure: field(record, "unit_of_measure"),
+		}
+		if req.Quantity, err = parseAmount(record, "quantity"); err == nil {
+			req.UnitPrice, err = parseAmount(record, "unit_price")
+		}
+		if err != nil {
+			lineErrors = append(lineErrors, dto.BatchLineErrorResponse{Line: line, Message: err.Error()})
+			reqs = append(reqs, dto.CreateStockMovementRequest{})
+			continue
+		}
+		if lot := field(record, "lot_number"); lot != "" {
+			req.Lots = []dto.LotQuantityRequest{{LotNumber: lot, Quantity: req.Quantity}}
+		}
+		req.Sanitize()
+		reqs = append(reqs, req)
+	}
+	return reqs, lineErrors, nil
+}
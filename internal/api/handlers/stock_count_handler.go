@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/stock"
+	stock_usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// StockCountHandler handles HTTP requests for physical inventory counts.
+type StockCountHandler struct {
+	usecase stock_usecase.CountUseCase
+}
+
+// NewStockCountHandler creates a new StockCountHandler.
+func NewStockCountHandler(uc stock_usecase.CountUseCase) *StockCountHandler {
+	return &StockCountHandler{usecase: uc}
+}
+
+func (h *StockCountHandler) StartCount(c echo.Context) error {
+	req := new(dto.StartCountRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+	}
+	binIDs := make([]uuid.UUID, len(req.BinIDs))
+	for i, raw := range req.BinIDs {
+		binIDs[i], err = uuid.Parse(raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Bin ID format")
+		}
+	}
+
+	session, err := h.usecase.StartCount(c.Request().Context(), warehouseID, binIDs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, dto.NewCountSessionResponse(session))
+}
+
+func (h *StockCountHandler) ListCounts(c echo.Context) error {
+	status := stock.CountStatus(c.QueryParam("status"))
+	sessions, err := h.usecase.ListCounts(c.Request().Context(), status)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.CountSessionResponse, len(sessions))
+	for i, s := range sessions {
+		res[i] = dto.NewCountSessionResponse(s)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *StockCountHandler) GetCount(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	session, err := h.usecase.GetCount(c.Request().Context(), id)
+	if err != nil {
+		return countError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewCountSessionResponse(session))
+}
+
+func (h *StockCountHandler) SubmitCount(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.SubmitCountRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+	binID, err := uuid.Parse(req.BinID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Bin ID format")
+	}
+
+	line, err := h.usecase.SubmitCount(c.Request().Context(), id, itemID, binID, req.DeviceID, req.Quantity)
+	if err != nil {
+		return countError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewCountLineResponse(line))
+}
+
+func (h *StockCountHandler) ApproveCount(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	session, adjustments, err := h.usecase.ApproveCount(c.Request().Context(), id)
+	if err != nil {
+		return countError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewCountApprovalResponse(session, adjustments))
+}
+
+func (h *StockCountHandler) CancelCount(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	session, err := h.usecase.CancelCount(c.Request().Context(), id)
+	if err != nil {
+		return countError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewCountSessionResponse(session))
+}
+
+// countError maps inventory count errors to HTTP errors.
+func countError(err error) error {
+	switch {
+	case errors.Is(err, stock.ErrCountSessionNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, stock.ErrCountSessionNotOpen), errors.Is(err, stock_usecase.ErrInsufficientStock):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, stock.ErrBinNotInCount), errors.Is(err, stock_usecase.ErrNegativeCount):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -2,11 +2,15 @@
 package handlers
 
 import (
+	"errors"
+	"time"
+
 	"doligo_001/internal/api/dto"
 	"doligo_001/internal/domain/stock"
 	stock_usecase "doligo_001/internal/usecase/stock"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 	"net/http"
 )
 
@@ -20,21 +24,6 @@ func NewStockHandler(uc stock_usecase.UseCase) *StockHandler {
 	return &StockHandler{usecase: uc}
 }
 
-// RegisterRoutes registers the stock-related routes to an Echo group.
-func (h *StockHandler) RegisterRoutes(g *echo.Group) {
-	// Warehouse routes
-	g.POST("/warehouses", h.CreateWarehouse)
-	g.GET("/warehouses", h.ListWarehouses)
-	g.GET("/warehouses/:id", h.GetWarehouseByID)
-	
-	// Bin routes
-	g.POST("/bins", h.CreateBin)
-	g.GET("/warehouses/:id/bins", h.ListBinsByWarehouse)
-
-	// Stock movement routes
-	g.POST("/stock/movements", h.CreateStockMovement)
-}
-
 func (h *StockHandler) CreateWarehouse(c echo.Context) error {
 	req := new(dto.CreateWarehouseRequest)
 	if err := c.Bind(req); err != nil {
@@ -72,11 +61,56 @@ func (h *StockHandler) GetWarehouseByID(c echo.Context) error {
 	}
 	w, err := h.usecase.GetWarehouseByID(c.Request().Context(), id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return locationError(err)
 	}
 	return c.JSON(http.StatusOK, dto.NewWarehouseResponse(w))
 }
 
+func (h *StockHandler) UpdateWarehouse(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.UpdateWarehouseRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	w, err := h.usecase.UpdateWarehouse(c.Request().Context(), id, req.Name, req.IsActive)
+	if err != nil {
+		return locationError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewWarehouseResponse(w))
+}
+
+func (h *StockHandler) DeleteWarehouse(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	if err := h.usecase.DeleteWarehouse(c.Request().Context(), id); err != nil {
+		return locationError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListWarehouseStock returns the items on hand in all bins of a warehouse.
+func (h *StockHandler) ListWarehouseStock(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	stocks, err := h.usecase.ListWarehouseStock(c.Request().Context(), id)
+	if err != nil {
+		return locationError(err)
+	}
+	return c.JSON(http.StatusOK, newStockResponses(stocks))
+}
+
 func (h *StockHandler) CreateBin(c echo.Context) error {
 	req := new(dto.CreateBinRequest)
 	if err := c.Bind(req); err != nil {
@@ -115,6 +149,84 @@ func (h *StockHandler) ListBinsByWarehouse(c echo.Context) error {
 	return c.JSON(http.StatusOK, res)
 }
 
+func (h *StockHandler) GetBinByID(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	b, err := h.usecase.GetBinByID(c.Request().Context(), id)
+	if err != nil {
+		return locationError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewBinResponse(b))
+}
+
+func (h *StockHandler) UpdateBin(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.UpdateBinRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	b, err := h.usecase.UpdateBin(c.Request().Context(), id, req.Name, req.IsActive)
+	if err != nil {
+		return locationError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewBinResponse(b))
+}
+
+func (h *StockHandler) DeleteBin(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	if err := h.usecase.DeleteBin(c.Request().Context(), id); err != nil {
+		return locationError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListBinStock returns the items on hand in a bin.
+func (h *StockHandler) ListBinStock(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	stocks, err := h.usecase.ListBinStock(c.Request().Context(), id)
+	if err != nil {
+		return locationError(err)
+	}
+	return c.JSON(http.StatusOK, newStockResponses(stocks))
+}
+
+// newStockResponses maps Stock rows to their responses.
+func newStockResponses(stocks []*stock.Stock) []*dto.StockResponse {
+	res := make([]*dto.StockResponse, len(stocks))
+	for i, s := range stocks {
+		res[i] = dto.NewStockResponse(s)
+	}
+	return res
+}
+
+// locationError maps warehouse and bin errors to HTTP errors.
+func locationError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Warehouse or bin not found")
+	case errors.Is(err, stock_usecase.ErrLocationHasStock):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
 func (h *StockHandler) CreateStockMovement(c echo.Context) error {
 	req := new(dto.CreateStockMovementRequest)
 	if err := c.Bind(req); err != nil {
@@ -145,15 +257,261 @@ func (h *StockHandler) CreateStockMovement(c echo.Context) error {
 		binID,
 		stock.MovementType(req.Type),
 		req.Quantity,
+		req.UnitOfMeasure,
 		req.UnitPrice,
 		req.Reason,
+		dto.ToLotQuantities(req.Lots),
 	)
 	if err != nil {
-		if err == stock_usecase.ErrInsufficientStock || err == stock_usecase.ErrBinRequired {
+		if errors.Is(err, stock_usecase.ErrInsufficientStock) || errors.Is(err, stock_usecase.ErrBinRequired) {
 			return echo.NewHTTPError(http.StatusConflict, err.Error())
 		}
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		if unitErr := unitError(err); unitErr != nil {
+			return unitErr
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
 	return c.JSON(http.StatusCreated, dto.NewStockMovementResponse(movement))
 }
+
+func (h *StockHandler) CreateStockTransfer(c echo.Context) error {
+	req := new(dto.CreateStockTransferRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+	fromWarehouseID, err := uuid.Parse(req.FromWarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid source Warehouse ID format")
+	}
+	fromBinID, err := uuid.Parse(req.FromBinID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid source Bin ID format")
+	}
+	toWarehouseID, err := uuid.Parse(req.ToWarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid destination Warehouse ID format")
+	}
+	toBinID, err := uuid.Parse(req.ToBinID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid destination Bin ID format")
+	}
+
+	transfer, err := h.usecase.TransferStock(
+		c.Request().Context(),
+		itemID,
+		fromWarehouseID,
+		fromBinID,
+		toWarehouseID,
+		toBinID,
+		req.Quantity,
+		req.UnitOfMeasure,
+		req.Reason,
+		dto.ToLotQuantities(req.Lots),
+	)
+	if err != nil {
+		if errors.Is(err, stock_usecase.ErrInsufficientStock) || errors.Is(err, stock_usecase.ErrBinRequired) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		if unitErr := unitError(err); unitErr != nil {
+			return unitErr
+		}
+		if errors.Is(err, stock_usecase.ErrSameLocation) || errors.Is(err, stock_usecase.ErrInvalidQuantity) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, dto.NewStockTransferResponse(transfer))
+}
+
+// ListStockLedger returns a page of ledger entries filtered by item, location, type and date range.
+func (h *StockHandler) ListStockLedger(c echo.Context) error {
+	req := new(dto.ListStockLedgerRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	filter, err := parseLedgerLocation(req.ItemID, req.WarehouseID, req.BinID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	filter.MovementType = stock.MovementType(req.Type)
+	filter.Limit = req.Limit
+	filter.Offset = req.Offset
+	if req.StartDate != "" {
+		from, err := parseQueryTime(req.StartDate, false)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid startDate format")
+		}
+		filter.From = &from
+	}
+	if req.EndDate != "" {
+		to, err := parseQueryTime(req.EndDate, true)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid endDate format")
+		}
+		filter.To = &to
+	}
+
+	entries, total, err := h.usecase.ListLedgerEntries(c.Request().Context(), filter)
+	if err != nil {
+		if errors.Is(err, stock_usecase.ErrInvalidDateRange) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = stock_usecase.DefaultLedgerPageSize
+	}
+	res := &dto.StockLedgerPageResponse{
+		Entries: make([]*dto.StockLedgerResponse, len(entries)),
+		Total:   total,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}
+	for i, e := range entries {
+		res.Entries[i] = dto.NewStockLedgerResponse(e)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetStockAsOf returns the stock quantities of every location as they stood at a past moment.
+func (h *StockHandler) GetStockAsOf(c echo.Context) error {
+	req := new(dto.StockAsOfRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	asOf, err := parseQueryTime(req.AsOf, true)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid asOf format")
+	}
+	filter, err := parseLedgerLocation(req.ItemID, req.WarehouseID, req.BinID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	balances, err := h.usecase.GetStockAsOf(c.Request().Context(), asOf, filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := &dto.StockAsOfResponse{
+		AsOf:     asOf,
+		Balances: make([]*dto.StockResponse, len(balances)),
+	}
+	for i, b := range balances {
+		res.Balances[i] = dto.NewStockResponse(b)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ListStockLots returns the lot or serial number buckets holding stock of an item.
+func (h *StockHandler) ListStockLots(c echo.Context) error {
+	req := new(dto.ListStockLotsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+
+	lots, err := h.usecase.ListLots(c.Request().Context(), itemID, req.LotNumber)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := make([]*dto.StockLotResponse, len(lots))
+	for i, l := range lots {
+		res[i] = dto.NewStockLotResponse(l)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// lotError maps lot and serial number errors to HTTP errors. It returns nil for any other error.
+func lotError(err error) error {
+	switch {
+	case errors.Is(err, stock.ErrLotRequired), errors.Is(err, stock.ErrLotNotAllowed),
+		errors.Is(err, stock.ErrInvalidLots), errors.Is(err, stock.ErrInvalidSerial),
+		errors.Is(err, stock.ErrLotQuantityMismatch):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, stock.ErrInsufficientLotStock), errors.Is(err, stock.ErrSerialInStock):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return nil
+	}
+}
+
+// parseLedgerLocation builds a ledger filter from optional item, warehouse and bin IDs.
+func parseLedgerLocation(itemID, warehouseID, binID string) (stock.LedgerFilter, error) {
+	var filter stock.LedgerFilter
+	if itemID != "" {
+		id, err := uuid.Parse(itemID)
+		if err != nil {
+			return filter, errors.New("Invalid Item ID format")
+		}
+		filter.ItemID = &id
+	}
+	if warehouseID != "" {
+		id, err := uuid.Parse(warehouseID)
+		if err != nil {
+			return filter, errors.New("Invalid Warehouse ID format")
+		}
+		filter.WarehouseID = &id
+	}
+	if binID != "" {
+		id, err := uuid.Parse(binID)
+		if err != nil {
+			return filter, errors.New("Invalid Bin ID format")
+		}
+		filter.BinID = &id
+	}
+	return filter, nil
+}
+
+// parseQueryTime accepts an RFC3339 timestamp or a YYYY-MM-DD date. When endOfDay is set,
+// a bare date is moved to the last instant of that day so that it can be used as an inclusive bound.
+func parseQueryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
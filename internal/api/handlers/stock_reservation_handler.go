@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/stock"
+	stock_usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// StockReservationHandler handles HTTP requests for stock reservations and availability.
+type StockReservationHandler struct {
+	usecase stock_usecase.ReservationUseCase
+}
+
+// NewStockReservationHandler creates a new StockReservationHandler.
+func NewStockReservationHandler(uc stock_usecase.ReservationUseCase) *StockReservationHandler {
+	return &StockReservationHandler{usecase: uc}
+}
+
+func (h *StockReservationHandler) Reserve(c echo.Context) error {
+	req := new(dto.ReserveStockRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+	}
+	var binID *uuid.UUID
+	if req.BinID != "" {
+		id, err := uuid.Parse(req.BinID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Bin ID format")
+		}
+		binID = &id
+	}
+
+	reservation, err := h.usecase.Reserve(c.Request().Context(), itemID, warehouseID, binID, req.SourceType, req.SourceID, req.Quantity, req.ExpiresAt)
+	if err != nil {
+		return reservationError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewReservationResponse(reservation))
+}
+
+func (h *StockReservationHandler) ListReservations(c echo.Context) error {
+	req := new(dto.ListReservationsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	filter := stock.ReservationFilter{
+		SourceType: req.SourceType,
+		SourceID:   req.SourceID,
+		Status:     stock.ReservationStatus(req.Status),
+	}
+	if req.ItemID != "" {
+		id, err := uuid.Parse(req.ItemID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+		}
+		filter.ItemID = &id
+	}
+	if req.WarehouseID != "" {
+		id, err := uuid.Parse(req.WarehouseID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+		}
+		filter.WarehouseID = &id
+	}
+
+	reservations, err := h.usecase.ListReservations(c.Request().Context(), filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.ReservationResponse, len(reservations))
+	for i, r := range reservations {
+		res[i] = dto.NewReservationResponse(r)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *StockReservationHandler) GetReservation(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	reservation, err := h.usecase.GetReservation(c.Request().Context(), id)
+	if err != nil {
+		return reservationError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewReservationResponse(reservation))
+}
+
+func (h *StockReservationHandler) Release(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	reservation, err := h.usecase.Release(c.Request().Context(), id)
+	if err != nil {
+		return reservationError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewReservationResponse(reservation))
+}
+
+func (h *StockReservationHandler) Consume(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID format")
+	}
+	req := new(dto.ConsumeReservationRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	reservation, movement, err := h.usecase.Consume(c.Request().Context(), id, req.Quantity, req.Reason, dto.ToLotQuantities(req.Lots))
+	if err != nil {
+		return reservationError(err)
+	}
+	return c.JSON(http.StatusOK, &dto.ConsumeReservationResponse{
+		Reservation: dto.NewReservationResponse(reservation),
+		Movement:    dto.NewStockMovementResponse(movement),
+	})
+}
+
+// GetAvailability returns the on-hand, reserved and available quantities of an item at a location.
+func (h *StockReservationHandler) GetAvailability(c echo.Context) error {
+	req := new(dto.StockAvailabilityRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	itemID, err := uuid.Parse(req.ItemID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Item ID format")
+	}
+	warehouseID, err := uuid.Parse(req.WarehouseID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Warehouse ID format")
+	}
+	var binID *uuid.UUID
+	if req.BinID != "" {
+		id, err := uuid.Parse(req.BinID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Bin ID format")
+		}
+		binID = &id
+	}
+
+	availability, err := h.usecase.GetAvailability(c.Request().Context(), itemID, warehouseID, binID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, dto.NewStockAvailabilityResponse(availability))
+}
+
+// reservationError maps stock reservation errors to HTTP errors.
+func reservationError(err error) error {
+	switch {
+	case errors.Is(err, stock.ErrReservationNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, stock.ErrReservationNotActive), errors.Is(err, stock.ErrReservationExists),
+		errors.Is(err, stock_usecase.ErrInsufficientStock):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, stock.ErrReservationExceeded), errors.Is(err, stock.ErrInvalidExpiry),
+		errors.Is(err, stock_usecase.ErrInvalidQuantity), errors.Is(err, stock_usecase.ErrSourceRequired),
+		errors.Is(err, stock_usecase.ErrNotStorable):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		if lotErr := lotError(err); lotErr != nil {
+			return lotErr
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	stock_usecase "doligo_001/internal/usecase/stock"
+	"github.com/labstack/echo/v4"
+)
+
+// StockValuationHandler handles HTTP requests for inventory valuation reports.
+type StockValuationHandler struct {
+	usecase stock_usecase.ValuationUseCase
+}
+
+// NewStockValuationHandler creates a new StockValuationHandler.
+func NewStockValuationHandler(uc stock_usecase.ValuationUseCase) *StockValuationHandler {
+	return &StockValuationHandler{usecase: uc}
+}
+
+// GetInventoryValuation values the stock on hand, or the stock as of a past date.
+// With format=csv the report is downloaded as CSV: the totals when it is grouped, the lines otherwise.
+func (h *StockValuationHandler) GetInventoryValuation(c echo.Context) error {
+	req := new(dto.InventoryValuationRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	filter, err := parseLedgerLocation(req.ItemID, req.WarehouseID, req.BinID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	query := stock.ValuationQuery{Filter: filter, ItemType: item.ItemType(req.ItemType)}
+	if req.AsOf != "" {
+		asOf, err := parseQueryTime(req.AsOf, true)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid asOf format")
+		}
+		query.AsOf = &asOf
+	}
+	if req.GroupBy != "" {
+		for _, group := range strings.Split(req.GroupBy, ",") {
+			query.GroupBy = append(query.GroupBy, stock.ValuationGroup(strings.TrimSpace(group)))
+		}
+	}
+
+	report, err := h.usecase.GetInventoryValuation(c.Request().Context(), query)
+	if err != nil {
+		if errors.Is(err, stock_usecase.ErrInvalidValuationGroup) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if req.Format == "csv" {
+		return writeValuationCSV(c, report)
+	}
+	return c.JSON(http.StatusOK, dto.NewInventoryValuationResponse(report))
+}
+
+// writeValuationCSV streams the report as a CSV attachment followed by a grand total row.
+func writeValuationCSV(c echo.Context, report *stock.ValuationReport) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="inventory-valuation-%s.csv"`, report.AsOf.Format("2006-01-02")))
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	formatAmount := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+	if len(report.GroupBy) > 0 {
+		w.Write([]string{"warehouse_id", "warehouse_name", "bin_id", "bin_name", "item_type", "lines", "value"})
+		for _, t := range report.Totals {
+			warehouseID, binID := "", ""
+			if t.WarehouseID != nil {
+				warehouseID = t.WarehouseID.String()
+			}
+			if t.BinID != nil {
+				binID = t.BinID.String()
+			}
+			w.Write([]string{warehouseID, t.WarehouseName, binID, t.BinName, string(t.ItemType), strconv.Itoa(t.Lines), formatAmount(t.Value)})
+		}
+		w.Write([]string{"TOTAL", "", "", "", "", strconv.Itoa(len(report.Lines)), formatAmount(report.TotalValue)})
+	} else {
+		w.Write([]string{"warehouse_id", "warehouse_name", "bin_id", "bin_name", "item_id", "item_name", "item_type", "quantity", "unit_cost", "value"})
+		for _, l := range report.Lines {
+			binID := ""
+			if l.BinID != nil {
+				binID = l.BinID.String()
+			}
+			w.Write([]string{
+				l.WarehouseID.String(), l.WarehouseName, binID, l.BinName,
+				l.ItemID.String(), l.ItemName, string(l.ItemType),
+				formatAmount(l.Quantity), formatAmount(l.UnitCost), formatAmount(l.Value),
+			})
+		}
+		w.Write([]string{"TOTAL", "", "", "", "", "", "", "", "", formatAmount(report.TotalValue)})
+	}
+	w.Flush()
+	return w.Error()
+}
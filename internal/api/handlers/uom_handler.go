@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/uom"
+	uom_usecase "doligo_001/internal/usecase/uom"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// UoMHandler handles HTTP requests for units of measure.
+type UoMHandler struct {
+	usecase uom_usecase.Usecase
+}
+
+// NewUoMHandler creates a new UoMHandler.
+func NewUoMHandler(uc uom_usecase.Usecase) *UoMHandler {
+	return &UoMHandler{usecase: uc}
+}
+
+func (h *UoMHandler) CreateCategory(c echo.Context) error {
+	req := new(dto.CreateUoMCategoryRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	category := &uom.Category{Name: req.Name}
+	if err := h.usecase.CreateCategory(c.Request().Context(), category); err != nil {
+		return uomError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewUoMCategoryResponse(category))
+}
+
+func (h *UoMHandler) ListCategories(c echo.Context) error {
+	categories, err := h.usecase.ListCategories(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.UoMCategoryResponse, len(categories))
+	for i, category := range categories {
+		res[i] = dto.NewUoMCategoryResponse(category)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *UoMHandler) CreateUnit(c echo.Context) error {
+	req := new(dto.CreateUoMUnitRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	categoryID, err := uuid.Parse(req.CategoryID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Category ID format")
+	}
+
+	unit := &uom.Unit{CategoryID: categoryID, Code: req.Code, Name: req.Name, Factor: req.Factor}
+	if err := h.usecase.CreateUnit(c.Request().Context(), unit); err != nil {
+		return uomError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewUoMUnitResponse(unit))
+}
+
+func (h *UoMHandler) ListUnits(c echo.Context) error {
+	req := new(dto.ListUoMUnitsRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	var categoryID *uuid.UUID
+	if req.CategoryID != "" {
+		id, err := uuid.Parse(req.CategoryID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid Category ID format")
+		}
+		categoryID = &id
+	}
+
+	units, err := h.usecase.ListUnits(c.Request().Context(), categoryID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.UoMUnitResponse, len(units))
+	for i, unit := range units {
+		res[i] = dto.NewUoMUnitResponse(unit)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *UoMHandler) GetUnit(c echo.Context) error {
+	unit, err := h.usecase.GetUnit(c.Request().Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, uom.ErrUnitNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return uomError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewUoMUnitResponse(unit))
+}
+
+// Convert expresses a quantity given in one unit in another unit of the same category.
+func (h *UoMHandler) Convert(c echo.Context) error {
+	req := new(dto.ConvertUoMRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	converted, err := h.usecase.Convert(c.Request().Context(), req.Quantity, req.From, req.To)
+	if err != nil {
+		return uomError(err)
+	}
+	return c.JSON(http.StatusOK, &dto.ConvertUoMResponse{Quantity: req.Quantity, From: req.From, Converted: converted, To: req.To})
+}
+
+// uomError maps unit of measure errors to HTTP errors.
+func uomError(err error) error {
+	if unitErr := unitError(err); unitErr != nil {
+		return unitErr
+	}
+	switch {
+	case errors.Is(err, uom.ErrCategoryNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, uom.ErrUnitExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, uom.ErrInvalidFactor):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// unitError maps the errors of converting an entered unit of measure to HTTP errors. It returns
+// nil for other errors, so handlers of quantities can fall back to their own mapping.
+func unitError(err error) error {
+	switch {
+	case errors.Is(err, uom.ErrUnitNotFound), errors.Is(err, uom.ErrIncompatibleUnits):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/bom"
+	bomUseCase "doligo_001/internal/usecase/bom"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// WorkCenterHandler handles HTTP requests for the work centers of routings.
+type WorkCenterHandler struct {
+	usecase bomUseCase.WorkCenterUsecase
+}
+
+// NewWorkCenterHandler creates a new WorkCenterHandler.
+func NewWorkCenterHandler(uc bomUseCase.WorkCenterUsecase) *WorkCenterHandler {
+	return &WorkCenterHandler{usecase: uc}
+}
+
+func (h *WorkCenterHandler) CreateWorkCenter(c echo.Context) error {
+	req := new(dto.CreateWorkCenterRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	wc := &bom.WorkCenter{Code: req.Code, Name: req.Name, LaborRate: req.LaborRate, OverheadRate: req.OverheadRate}
+	if err := h.usecase.CreateWorkCenter(c.Request().Context(), wc); err != nil {
+		return workCenterError(err)
+	}
+	return c.JSON(http.StatusCreated, dto.NewWorkCenterResponse(wc))
+}
+
+// ListWorkCenters lists the active work centers, and the inactive ones with includeInactive.
+func (h *WorkCenterHandler) ListWorkCenters(c echo.Context) error {
+	req := new(dto.ListWorkCentersRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	centers, err := h.usecase.ListWorkCenters(c.Request().Context(), req.IncludeInactive)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := make([]*dto.WorkCenterResponse, len(centers))
+	for i, wc := range centers {
+		res[i] = dto.NewWorkCenterResponse(wc)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *WorkCenterHandler) GetWorkCenter(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Work Center ID format")
+	}
+
+	wc, err := h.usecase.GetWorkCenter(c.Request().Context(), id)
+	if err != nil {
+		return workCenterError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewWorkCenterResponse(wc))
+}
+
+// UpdateWorkCenter changes the name, rates and active flag of a work center.
+func (h *WorkCenterHandler) UpdateWorkCenter(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Work Center ID format")
+	}
+
+	req := new(dto.UpdateWorkCenterRequest)
+	if err := c.Bind(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	wc := &bom.WorkCenter{ID: id, Name: req.Name, LaborRate: req.LaborRate, OverheadRate: req.OverheadRate, IsActive: req.IsActive}
+	if err := h.usecase.UpdateWorkCenter(c.Request().Context(), wc); err != nil {
+		return workCenterError(err)
+	}
+	return c.JSON(http.StatusOK, dto.NewWorkCenterResponse(wc))
+}
+
+// workCenterError maps work center errors to HTTP errors.
+func workCenterError(err error) error {
+	switch {
+	case errors.Is(err, bom.ErrWorkCenterNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, bom.ErrWorkCenterExists):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, bom.ErrInvalidRate):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"doligo_001/internal/domain/stock"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -12,20 +13,122 @@ import (
 var (
 	// ErrBOMNotFound is returned when a BillOfMaterials is not found.
 	ErrBOMNotFound = errors.New("bill of materials not found")
+	// ErrBOMCycle is returned when a BOM consumes, directly or through its sub-assemblies, its own product.
+	ErrBOMCycle = errors.New("bill of materials contains a cycle")
+	// ErrRevisionNotDraft is returned when an approved or obsolete revision is changed, approved again or deleted.
+	ErrRevisionNotDraft = errors.New("only draft BOM revisions can be changed")
+	// ErrRevisionNotApproved is returned when a revision that is not approved is made obsolete.
+	ErrRevisionNotApproved = errors.New("only approved BOM revisions can be made obsolete")
+	// ErrNoRevisionInForce is returned when no approved revision of a product is in force at a given time.
+	ErrNoRevisionInForce = errors.New("no approved BOM revision is in force for the product")
+	// ErrInvalidEffectiveDates is returned when a revision would stop being in force before it starts.
+	ErrInvalidEffectiveDates = errors.New("effective_to must be after effective_from")
+	// ErrRevisionProductMismatch is returned when two revisions of different products are compared.
+	ErrRevisionProductMismatch = errors.New("BOM revisions belong to different products")
+	// ErrInvalidScrapFactor is returned when a scrap percentage is negative or a yield is not in (0, 100].
+	ErrInvalidScrapFactor = errors.New("scrap_percent must be at least 0 and yield_percent between 0 (exclusive) and 100")
+	// ErrProductionRecordNotFound is returned when a ProductionRecord is not found.
+	ErrProductionRecordNotFound = errors.New("production record not found")
+	// ErrProductionReversed is returned when a production run that was reversed is reversed or disassembled again.
+	ErrProductionReversed = errors.New("production run has already been reversed")
+	// ErrProductionDisassembled is returned when a production run that was partly disassembled is reversed.
+	ErrProductionDisassembled = errors.New("production run has been disassembled and cannot be reversed")
+	// ErrProductionNotReversible is returned when a production run completing a manufacturing order,
+	// or recorded without a link to its stock movements, is reversed or disassembled.
+	ErrProductionNotReversible = errors.New("only instant production runs linked to their stock movements can be reversed or disassembled")
+	// ErrOverDisassembly is returned when more is disassembled than the quantity of a run not disassembled yet.
+	ErrOverDisassembly = errors.New("cannot disassemble more than the quantity produced and not yet disassembled")
+	// ErrInvalidAlternate is returned when an alternate component has no positive priority and conversion ratio,
+	// shares its priority or item with another alternate of the component, or is the component or product itself.
+	ErrInvalidAlternate = errors.New("alternate components need a distinct item and a positive priority and conversion_ratio, unique per component")
+	// ErrInvalidSubstitution is returned when a production run chooses an item that is not an alternate of the component.
+	ErrInvalidSubstitution = errors.New("the substitute is not an alternate of the component")
+)
+
+// RevisionStatus is the lifecycle state of a BOM revision.
+type RevisionStatus string
+
+const (
+	RevisionDraft    RevisionStatus = "DRAFT"    // Editable, never used by production
+	RevisionApproved RevisionStatus = "APPROVED" // Frozen, in force between its effective dates
+	RevisionObsolete RevisionStatus = "OBSOLETE" // Frozen and no longer in force
 )
 
 // BillOfMaterials represents the definition of how to produce a finished item.
-// It consists of components (inputs) and services required.
+// It consists of components (inputs) and services required, and the routing of operations
+// whose time on the work centers costs the labor and overhead of production.
+// A product has one BillOfMaterials per revision. Only drafts can be changed, so a
+// ProductionRecord that references a revision keeps the recipe that was actually used.
 type BillOfMaterials struct {
-	ID         uuid.UUID
-	ProductID  uuid.UUID // The finished item produced by this BOM
-	Name       string
-	IsActive   bool
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	CreatedBy  uuid.UUID
-	UpdatedBy  uuid.UUID
-	Components []BillOfMaterialsComponent // Inputs needed
+	ID            uuid.UUID
+	ProductID     uuid.UUID // The finished item produced by this BOM
+	Name          string
+	IsActive      bool
+	Revision      int // Numbered from 1 per product
+	Status        RevisionStatus
+	EffectiveFrom *time.Time // Set when the revision is approved
+	EffectiveTo   *time.Time // Exclusive; nil while the revision is in force with no end
+	YieldPercent  float64    // Expected share of good product; 100 when nothing is lost
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	CreatedBy     uuid.UUID
+	UpdatedBy     uuid.UUID
+	Components    []BillOfMaterialsComponent // Inputs needed
+	Operations    []RoutingOperation         // Routing, by sequence
+}
+
+// InForce reports whether the revision is approved and effective at the given time.
+func (b *BillOfMaterials) InForce(at time.Time) bool {
+	return b.Status == RevisionApproved && b.EffectiveFrom != nil && !b.EffectiveFrom.After(at) &&
+		(b.EffectiveTo == nil || at.Before(*b.EffectiveTo))
+}
+
+// NetQuantity returns the quantity of a component consumed per unit of good product, before
+// component scrap: the component quantity grossed up by the expected yield of the BOM.
+func (b *BillOfMaterials) NetQuantity(c BillOfMaterialsComponent) float64 {
+	yield := b.YieldPercent
+	if yield <= 0 {
+		yield = 100
+	}
+	return c.Quantity * 100 / yield
+}
+
+// PlannedQuantity returns the quantity of a component planned per unit of good product: the
+// net quantity plus the scrap percentage of the component.
+func (b *BillOfMaterials) PlannedQuantity(c BillOfMaterialsComponent) float64 {
+	return b.NetQuantity(c) * (1 + c.ScrapPercent/100)
+}
+
+// ValidateScrapFactors checks the yield of the BOM and the scrap percentages of its components.
+func (b *BillOfMaterials) ValidateScrapFactors() error {
+	if b.YieldPercent <= 0 || b.YieldPercent > 100 {
+		return ErrInvalidScrapFactor
+	}
+	for _, c := range b.Components {
+		if c.ScrapPercent < 0 {
+			return ErrInvalidScrapFactor
+		}
+	}
+	return nil
+}
+
+// ValidateAlternates checks the alternates of every component: each needs a positive priority
+// and conversion ratio, both its priority and its item unique among the alternates of the
+// component, and an item other than the component and the product.
+func (b *BillOfMaterials) ValidateAlternates() error {
+	for _, c := range b.Components {
+		priorities := make(map[int]bool, len(c.Alternates))
+		items := make(map[uuid.UUID]bool, len(c.Alternates))
+		for _, a := range c.Alternates {
+			if a.Priority <= 0 || a.ConversionRatio <= 0 || priorities[a.Priority] || items[a.ItemID] ||
+				a.ItemID == c.ComponentItemID || a.ItemID == b.ProductID {
+				return ErrInvalidAlternate
+			}
+			priorities[a.Priority] = true
+			items[a.ItemID] = true
+		}
+	}
+	return nil
 }
 
 // BillOfMaterialsComponent represents a single ingredient (item or service) in a BOM.
@@ -34,24 +137,163 @@ type BillOfMaterialsComponent struct {
 	BillOfMaterialsID uuid.UUID
 	ComponentItemID   uuid.UUID // The item (input or service) that is a component
 	Quantity          float64   // Quantity of the component needed per unit of ProductID
+	ScrapPercent      float64   // Share of the net quantity expected to be lost in production
 	UnitOfMeasure     string    // Unit of measure for the quantity (e.g., "kg", "pcs", "hours")
 	IsActive          bool
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
 	CreatedBy         uuid.UUID
 	UpdatedBy         uuid.UUID
+	Alternates        []AlternateComponent // Approved substitutes, by priority
 }
 
-// ProductionRecord represents a completed production run based on a BOM.
+// AlternateComponent is an approved substitute of a component, consumed in its place when the
+// component is short in production or when a run chooses it. ConversionRatio is the quantity of
+// the alternate, in its base unit, that replaces one base unit of the component item.
+type AlternateComponent struct {
+	ID              uuid.UUID
+	ComponentID     uuid.UUID // The BillOfMaterialsComponent it stands in for
+	ItemID          uuid.UUID
+	Priority        int // 1 is tried first
+	ConversionRatio float64
+}
+
+// ProductionRecord represents a completed production run based on a BOM: an instant run of
+// ProduceItem or the completion of a ManufacturingOrder.
+// BillOfMaterialsID is the revision that was in force when the run was produced.
 type ProductionRecord struct {
-	ID                    uuid.UUID
-	BillOfMaterialsID     uuid.UUID
-	ProducedProductID     uuid.UUID // The finished product item ID
-	ProductionQuantity    float64
-	ActualProductionCost  float64
-	WarehouseID           uuid.UUID
-	ProducedAt            time.Time
-	CreatedBy             uuid.UUID
+	ID                   uuid.UUID
+	BillOfMaterialsID    uuid.UUID
+	ManufacturingOrderID *uuid.UUID // Nil for an instant run
+	ProducedProductID    uuid.UUID  // The finished product item ID
+	ProductionQuantity   float64
+	ScrapQuantity        float64 // Planned quantity of the order that was not produced
+	ActualProductionCost float64 // Components, labor and overhead
+	LaborCost            float64 // Labor of the routing operations
+	OverheadCost         float64 // Overhead of the routing operations
+	YieldVariance        float64 // Cost of the scrap recorded beyond the planned scrap; negative when less was lost
+	DisassembledQuantity float64 // Quantity of the product taken apart since the run
+	WarehouseID          uuid.UUID
+	ProducedAt           time.Time
+	CreatedBy            uuid.UUID
+	ReversedAt           *time.Time // Set once the whole run is reversed
+	ReversedBy           *uuid.UUID
+	ReversalReason       string
+	Lots                 []ProductionLot // Lots consumed and produced by the run
+	Substitutions        []ProductionSubstitution
+}
+
+// ProductionSubstitution records that an alternate was consumed in place of part or all of a
+// component line in a production run. Quantities are planned quantities, scrap included, in the
+// base unit of each item.
+type ProductionSubstitution struct {
+	ID                 uuid.UUID
+	ProductionRecordID uuid.UUID
+	ComponentID        uuid.UUID // The BillOfMaterialsComponent line replaced
+	ComponentItemID    uuid.UUID
+	SubstituteItemID   uuid.UUID
+	Share              float64 // Share of the line replaced, in (0, 1]
+	Quantity           float64 // Of the component not consumed
+	SubstituteQuantity float64 // Of the substitute consumed instead
+	Chosen             bool    // Chosen for the run rather than substituted for a shortage
+}
+
+// LotRole tells whether a lot was consumed or produced by a production run.
+type LotRole string
+
+const (
+	LotConsumed LotRole = "CONSUMED"
+	LotProduced LotRole = "PRODUCED"
+)
+
+// ProductionLot records a lot (or serial number) consumed or produced by a production run.
+type ProductionLot struct {
+	ID                 uuid.UUID
+	ProductionRecordID uuid.UUID
+	ItemID             uuid.UUID
+	LotNumber          string
+	Quantity           float64
+	Role               LotRole
+}
+
+// ProductionOptions carries the lot and serial numbers of a production run.
+// They are required for the components and the product that are lot or serial tracked.
+// The lots of a component cover its whole consumption, scrap included. The lots and scrap of a
+// substitute are keyed by its own item ID.
+type ProductionOptions struct {
+	ComponentLots  map[uuid.UUID][]stock.LotQuantity // Keyed by component item ID
+	ProductLots    []stock.LotQuantity
+	ComponentScrap map[uuid.UUID]float64   // Actual scrap by component item ID; the planned scrap is used for the others
+	Substitutes    map[uuid.UUID]uuid.UUID // Alternate item chosen to replace a component item entirely, by component item ID
+}
+
+// DisassemblyOptions carries the lot and serial numbers of a disassembly: the lots of the product
+// taken apart and the lots the components come back to.
+type DisassemblyOptions struct {
+	ComponentLots map[uuid.UUID][]stock.LotQuantity // Keyed by component item ID
+	ProductLots   []stock.LotQuantity
+}
+
+// LotGenealogy follows a lot back to the component lots consumed to produce it.
+type LotGenealogy struct {
+	ItemID     uuid.UUID
+	LotNumber  string
+	Quantity   float64     // Quantity consumed by the parent run; zero for the traced lot itself
+	ProducedBy []uuid.UUID // Production records that produced the lot
+	Components []*LotGenealogy
+}
+
+// BOMNode is an item of a multi-level BOM explosion. Components that are produced by an
+// active BOM of their own are sub-assemblies: they are exploded in turn and their unit cost
+// is rolled up from their components and routing operations instead of taken from their CostPrice.
+// Quantities are planned quantities, including the component scrap and the BOM yield.
+type BOMNode struct {
+	ItemID        uuid.UUID
+	ItemName      string
+	BOMID         *uuid.UUID // Set for the exploded product and its sub-assemblies
+	Level         int        // 0 for the exploded product
+	QuantityPer   float64    // Quantity per unit of the parent item
+	Quantity      float64    // Quantity needed for the exploded quantity of the top product
+	UnitOfMeasure string
+	UnitCost      float64
+	TotalCost     float64 // Quantity * UnitCost
+	LaborCost     float64 // Of the routing operations of the node for Quantity, in one run
+	OverheadCost  float64 // Of the routing operations of the node for Quantity, in one run
+	Components    []*BOMNode
+}
+
+// WhereUsed is a BOM that consumes an item, directly (level 1) or through the sub-assemblies
+// of the lower levels.
+type WhereUsed struct {
+	BOMID     uuid.UUID
+	BOMName   string
+	ProductID uuid.UUID
+	Revision  int
+	Status    RevisionStatus
+	IsActive  bool
+	Level     int
+	Quantity  float64 // Quantity of the item consumed per unit of ProductID
+}
+
+// ComponentChange is a component whose quantity, scrap percentage or unit of measure differs
+// between two revisions.
+type ComponentChange struct {
+	ComponentItemID   uuid.UUID
+	FromQuantity      float64
+	ToQuantity        float64
+	FromScrapPercent  float64
+	ToScrapPercent    float64
+	FromUnitOfMeasure string
+	ToUnitOfMeasure   string
+}
+
+// RevisionDiff lists the component differences from one revision of a product to another.
+type RevisionDiff struct {
+	From    *BillOfMaterials
+	To      *BillOfMaterials
+	Added   []BillOfMaterialsComponent // In To only
+	Removed []BillOfMaterialsComponent // In From only
+	Changed []ComponentChange
 }
 
 // Ensure BillOfMaterials implements the Auditable interface
@@ -86,14 +328,29 @@ type Repository interface {
 	WithTx(tx *gorm.DB) Repository
 	Create(ctx context.Context, bom *BillOfMaterials) error
 	GetByID(ctx context.Context, id uuid.UUID) (*BillOfMaterials, error)
-	GetByProductID(ctx context.Context, productID uuid.UUID) (*BillOfMaterials, error)
+	// GetEffective returns the revision of the product in force at the given time, or ErrBOMNotFound.
+	GetEffective(ctx context.Context, productID uuid.UUID, at time.Time) (*BillOfMaterials, error)
+	// ListRevisions returns the revisions of a product by revision number.
+	ListRevisions(ctx context.Context, productID uuid.UUID) ([]*BillOfMaterials, error)
+	// ListRevisionsForUpdate is ListRevisions with the revisions locked until the transaction ends.
+	ListRevisionsForUpdate(ctx context.Context, productID uuid.UUID) ([]*BillOfMaterials, error)
+	// UpdateStatus saves the status and effective dates of a revision, leaving its components untouched.
+	UpdateStatus(ctx context.Context, bom *BillOfMaterials) error
 	Update(ctx context.Context, bom *BillOfMaterials) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context) ([]*BillOfMaterials, error)
+	// ListByComponent returns the BOMs that have the item among their components.
+	ListByComponent(ctx context.Context, itemID uuid.UUID) ([]*BillOfMaterials, error)
 }
 
 // ProductionRecordRepository defines the contract for data persistence operations for ProductionRecords.
 type ProductionRecordRepository interface {
 	WithTx(tx *gorm.DB) ProductionRecordRepository
 	Create(ctx context.Context, record *ProductionRecord) error
+	// GetByIDForUpdate returns the production record with its lots and locks it, or ErrProductionRecordNotFound.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*ProductionRecord, error)
+	// Update saves the disassembled quantity and the reversal of a production record.
+	Update(ctx context.Context, record *ProductionRecord) error
+	// ListByProducedLot returns the production runs that produced the lot, with their lots.
+	ListByProducedLot(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*ProductionRecord, error)
 }
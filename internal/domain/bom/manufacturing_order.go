@@ -0,0 +1,154 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOrderNotFound is returned when a manufacturing order does not exist.
+	ErrOrderNotFound = errors.New("manufacturing order not found")
+	// ErrInvalidOrderStatus is returned when an operation is not allowed in the current status of an order.
+	ErrInvalidOrderStatus = errors.New("operation not allowed in the current status of the manufacturing order")
+	// ErrNotOrderComponent is returned when an item that is not a component of the order is issued to it.
+	ErrNotOrderComponent = errors.New("item is not a component of the manufacturing order")
+	// ErrOverIssue is returned when more than the remaining required quantity of a component is issued.
+	ErrOverIssue = errors.New("quantity exceeds the component quantity still to issue")
+	// ErrOverReceipt is returned when more than the remaining planned quantity of an order is received.
+	ErrOverReceipt = errors.New("quantity exceeds the quantity still to produce")
+	// ErrComponentsNotIssued is returned when a receipt is not covered by the components issued to the order.
+	ErrComponentsNotIssued = errors.New("components issued do not cover the quantity received")
+	// ErrInvalidPlannedDates is returned when an order is planned to end before it starts.
+	ErrInvalidPlannedDates = errors.New("planned_end must not be before planned_start")
+)
+
+// OrderStatus is the lifecycle state of a manufacturing order.
+type OrderStatus string
+
+const (
+	OrderPlanned    OrderStatus = "PLANNED"     // Created, nothing reserved
+	OrderReleased   OrderStatus = "RELEASED"    // Storable components reserved
+	OrderInProgress OrderStatus = "IN_PROGRESS" // Components issued or products received
+	OrderCompleted  OrderStatus = "COMPLETED"   // Closed with its completion ProductionRecord
+	OrderCancelled  OrderStatus = "CANCELLED"   // Closed before anything was issued or received
+)
+
+// ManufacturingOrder produces a quantity of a product over time. It keeps the BOM revision
+// in force when it was created; releasing it reserves the storable components, which are then
+// issued and the product received in as many steps as needed. Closing it computes the final
+// cost and creates the ProductionRecord that completes it.
+type ManufacturingOrder struct {
+	ID                 uuid.UUID
+	ProductID          uuid.UUID
+	BillOfMaterialsID  uuid.UUID // Revision the order produces with
+	WarehouseID        uuid.UUID // Components are issued from and products received into this warehouse
+	Quantity           float64   // Planned quantity
+	ProducedQuantity   float64
+	ScrapQuantity      float64 // Planned quantity not produced, recorded at close
+	Status             OrderStatus
+	PlannedStart       *time.Time
+	PlannedEnd         *time.Time
+	ReleasedAt         *time.Time
+	StartedAt          *time.Time
+	CompletedAt        *time.Time
+	ActualCost         float64    // Cost of the components issued, plus labor and overhead once the order is completed
+	ReceivedCost       float64    // Value at which the products were received
+	ProductionRecordID *uuid.UUID // Completion record, set at close
+	Components         []*OrderComponent
+	Lots               []OrderLot
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	CreatedBy          uuid.UUID
+	UpdatedBy          uuid.UUID
+}
+
+func (o *ManufacturingOrder) SetCreatedBy(userID uuid.UUID) {
+	o.CreatedBy = userID
+}
+
+func (o *ManufacturingOrder) SetUpdatedBy(userID uuid.UUID) {
+	o.UpdatedAt = time.Now()
+	o.UpdatedBy = userID
+}
+
+// FindComponent returns the component line of the item, or nil if the item is not a component.
+func (o *ManufacturingOrder) FindComponent(itemID uuid.UUID) *OrderComponent {
+	for _, c := range o.Components {
+		if c.ItemID == itemID {
+			return c
+		}
+	}
+	return nil
+}
+
+// RemainingQuantity returns the planned quantity still to produce.
+func (o *ManufacturingOrder) RemainingQuantity() float64 {
+	return o.Quantity - o.ProducedQuantity
+}
+
+// OrderComponent is the requirement of a manufacturing order for one component item, summed
+// over the BOM lines of that item.
+type OrderComponent struct {
+	ID               uuid.UUID
+	OrderID          uuid.UUID
+	ItemID           uuid.UUID
+	QuantityPer      float64 // Per unit of product
+	RequiredQuantity float64 // QuantityPer times the planned quantity
+	IssuedQuantity   float64
+	IssuedCost       float64
+	ScrapQuantity    float64 // Issued beyond the need of the produced quantity, recorded at close
+	UnitOfMeasure    string
+	ReservationID    *uuid.UUID // Set on release for storable components
+}
+
+// RemainingQuantity returns the required quantity still to issue.
+func (c *OrderComponent) RemainingQuantity() float64 {
+	return c.RequiredQuantity - c.IssuedQuantity
+}
+
+// OrderLot records a lot (or serial number) issued to or received from a manufacturing order.
+// The lots are copied to the completion ProductionRecord when the order is closed.
+type OrderLot struct {
+	ID        uuid.UUID
+	OrderID   uuid.UUID
+	ItemID    uuid.UUID
+	LotNumber string
+	Quantity  float64
+	Role      LotRole
+}
+
+// ComponentIssue is a quantity of a component issued to a manufacturing order, with its lots
+// when the component is lot or serial tracked.
+type ComponentIssue struct {
+	ItemID   uuid.UUID
+	Quantity float64
+	Lots     []stock.LotQuantity
+}
+
+// OrderFilter narrows a manufacturing order query. Zero-valued fields are ignored.
+type OrderFilter struct {
+	ProductID   *uuid.UUID
+	WarehouseID *uuid.UUID
+	Status      OrderStatus
+}
+
+// ManufacturingOrderRepository defines the contract for manufacturing order persistence.
+type ManufacturingOrderRepository interface {
+	WithTx(tx *gorm.DB) ManufacturingOrderRepository
+	// Create persists the order together with its component lines.
+	Create(ctx context.Context, order *ManufacturingOrder) error
+	GetByID(ctx context.Context, id uuid.UUID) (*ManufacturingOrder, error)
+	// GetByIDForUpdate locks the order header so that the operations on an order are serialized.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*ManufacturingOrder, error)
+	List(ctx context.Context, filter OrderFilter) ([]*ManufacturingOrder, error)
+	// Update persists the status, quantities, costs and dates of the order header.
+	Update(ctx context.Context, order *ManufacturingOrder) error
+	// UpdateComponent persists the issued and scrap quantities, cost and reservation of a component line.
+	UpdateComponent(ctx context.Context, component *OrderComponent) error
+	AddLot(ctx context.Context, lot *OrderLot) error
+}
@@ -0,0 +1,95 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWorkCenterNotFound is returned when a work center does not exist.
+	ErrWorkCenterNotFound = errors.New("work center not found")
+	// ErrWorkCenterExists is returned when a work center is created with the code of an existing one.
+	ErrWorkCenterExists = errors.New("a work center with this code already exists")
+	// ErrInvalidRate is returned when a work center has a negative hourly rate.
+	ErrInvalidRate = errors.New("labor_rate and overhead_rate must not be negative")
+	// ErrInvalidOperation is returned when a routing operation has no work center, a sequence
+	// that is not positive or used twice, or a negative time.
+	ErrInvalidOperation = errors.New("routing operations need a work center, a unique positive sequence and times of at least 0")
+	// ErrInactiveWorkCenter is returned when a routing operation is planned on an inactive work center.
+	ErrInactiveWorkCenter = errors.New("work center is inactive")
+)
+
+// WorkCenter is a machine, line or team where routing operations are performed. Its hourly
+// rates cost the time spent on the operations: the labor of the operators and the overhead of
+// the machine and premises.
+type WorkCenter struct {
+	ID           uuid.UUID
+	Code         string
+	Name         string
+	LaborRate    float64 // Cost of one hour of labor
+	OverheadRate float64 // Cost of one hour of machine time and overhead
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CreatedBy    uuid.UUID
+	UpdatedBy    uuid.UUID
+}
+
+func (w *WorkCenter) SetCreatedBy(userID uuid.UUID) {
+	w.CreatedBy = userID
+}
+
+func (w *WorkCenter) SetUpdatedBy(userID uuid.UUID) {
+	w.UpdatedAt = time.Now()
+	w.UpdatedBy = userID
+}
+
+// RoutingOperation is a step of the routing of a BOM revision, performed on a work center.
+// The setup time is spent once per production run and the run time for each unit of product.
+type RoutingOperation struct {
+	ID                uuid.UUID
+	BillOfMaterialsID uuid.UUID
+	Sequence          int // Order of the operation in the routing, unique within the BOM
+	Name              string
+	WorkCenterID      uuid.UUID
+	SetupMinutes      float64
+	RunMinutes        float64 // Per unit of product
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	CreatedBy         uuid.UUID
+	UpdatedBy         uuid.UUID
+}
+
+// Hours returns the time the operation takes to produce quantity units in one run.
+func (o RoutingOperation) Hours(quantity float64) float64 {
+	return (o.SetupMinutes + o.RunMinutes*quantity) / 60
+}
+
+// ValidateOperations checks the sequences and times of the routing of the BOM.
+func (b *BillOfMaterials) ValidateOperations() error {
+	sequences := make(map[int]bool, len(b.Operations))
+	for _, op := range b.Operations {
+		if op.WorkCenterID == uuid.Nil || op.Sequence <= 0 || sequences[op.Sequence] ||
+			op.SetupMinutes < 0 || op.RunMinutes < 0 {
+			return ErrInvalidOperation
+		}
+		sequences[op.Sequence] = true
+	}
+	return nil
+}
+
+// WorkCenterRepository defines the contract for work center persistence.
+type WorkCenterRepository interface {
+	WithTx(tx *gorm.DB) WorkCenterRepository
+	Create(ctx context.Context, wc *WorkCenter) error
+	GetByID(ctx context.Context, id uuid.UUID) (*WorkCenter, error)
+	// GetByCode returns the work center with the code, or ErrWorkCenterNotFound.
+	GetByCode(ctx context.Context, code string) (*WorkCenter, error)
+	// List returns the work centers by code, the inactive ones only if includeInactive is set.
+	List(ctx context.Context, includeInactive bool) ([]*WorkCenter, error)
+	Update(ctx context.Context, wc *WorkCenter) error
+}
@@ -1,28 +1,99 @@
 package invoice
 
 import (
+	"errors"
+	"strings"
 	"time"
+
 	"doligo_001/internal/domain/thirdparty"
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrInvalidInvoiceStatus is returned when an operation is not allowed in the current status of an invoice.
+	ErrInvalidInvoiceStatus = errors.New("operation not allowed in the current status of the invoice")
+	// ErrInvoiceNotDraft is returned when a validated invoice is changed or deleted.
+	ErrInvoiceNotDraft = errors.New("only draft invoices can be changed")
+	// ErrInvoiceNumberRequired is returned when a draft is validated under its provisional number.
+	ErrInvoiceNumberRequired = errors.New("a final invoice number is required to validate the invoice")
+	// ErrInvalidPaymentAmount is returned when a payment is not positive or exceeds the balance due.
+	ErrInvalidPaymentAmount = errors.New("payment amount must be positive and not exceed the balance due")
+	// ErrInvalidDocumentType is returned when an operation does not apply to the type of the document,
+	// e.g. a payment recorded on a credit note.
+	ErrInvalidDocumentType = errors.New("operation not allowed for this document type")
+	// ErrInvalidCreditNoteLine is returned when a credit note line does not refer to a line of the credited invoice.
+	ErrInvalidCreditNoteLine = errors.New("credit note lines must refer to lines of the credited invoice")
+	// ErrCreditExceedsInvoice is returned when a credit note credits more than is left to credit on the invoice.
+	ErrCreditExceedsInvoice = errors.New("credited quantity exceeds the quantity left to credit on the invoice")
+	// ErrTrackedItemReturn is returned when a credit note would return a lot or serial tracked item to stock.
+	ErrTrackedItemReturn = errors.New("lot or serial tracked items cannot be returned to stock by a credit note")
+	// ErrCustomerEmailRequired is returned when an invoice is sent to a customer without an email address.
+	ErrCustomerEmailRequired = errors.New("the customer has no email address to send the invoice to")
+)
+
+// DocumentType tells invoices from the credit notes that undo them.
+type DocumentType string
+
+const (
+	TypeStandard   DocumentType = "STANDARD"    // Invoice billing the customer
+	TypeCreditNote DocumentType = "CREDIT_NOTE" // Credit of all or part of the lines of an issued invoice
+)
+
+// Status is the lifecycle state of an invoice.
+type Status string
+
+const (
+	StatusDraft         Status = "DRAFT"          // Editable, under a provisional number
+	StatusValidated     Status = "VALIDATED"      // Final number assigned, lines locked
+	StatusSent          Status = "SENT"           // Sent to the customer
+	StatusPartiallyPaid Status = "PARTIALLY_PAID" // Part of the total paid
+	StatusPaid          Status = "PAID"           // Fully paid
+	StatusCancelled     Status = "CANCELLED"      // Withdrawn before any payment or credit
+)
+
+// ProvisionalNumberPrefix starts the number of a draft until it is validated.
+const ProvisionalNumberPrefix = "PROV-"
+
+// ProvisionalNumber is the number of a draft created without one.
+func ProvisionalNumber(id uuid.UUID) string {
+	return ProvisionalNumberPrefix + id.String()
+}
+
+// paymentEpsilon absorbs rounding when a payment settles the balance due.
+const paymentEpsilon = 0.005
+
 type Invoice struct {
-	ID              uuid.UUID
-	ThirdPartyID    uuid.UUID
-	ThirdParty      *thirdparty.ThirdParty `gorm:"foreignKey:ThirdPartyID"`
-	Number          string
-	Date            time.Time
-	TotalAmount     float64
-	TotalCost       float64
-	TotalTax        float64
-	Lines           []InvoiceLine
-	PDFStatus       string
-	PDFUrl          string
-	PDFErrorMessage string
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	CreatedBy       uuid.UUID
-	UpdatedBy       uuid.UUID
+	ID                 uuid.UUID
+	ThirdPartyID       uuid.UUID
+	ThirdParty         *thirdparty.ThirdParty `gorm:"foreignKey:ThirdPartyID"`
+	DocumentType       DocumentType
+	OriginalInvoiceID  *uuid.UUID // Invoice credited by a credit note
+	OriginalInvoice    *Invoice   // Loaded with the details of a credit note
+	ReturnWarehouseID  *uuid.UUID // Warehouse a credit note returns the credited goods to at validation
+	ReturnBinID        *uuid.UUID // Bin of the return warehouse the credited goods are received into
+	Number             string
+	Date               time.Time
+	PaymentTermDays    int       // Days after Date within which the invoice is due
+	DueDate            time.Time // Date plus the payment term
+	Status             Status
+	AmountPaid         float64
+	AmountCredited     float64 // Total of the validated credit notes of an invoice
+	ValidatedAt        *time.Time
+	ValidatedBy        *uuid.UUID
+	SentAt             *time.Time
+	CancelledAt        *time.Time
+	CancellationReason string
+	TotalAmount        float64
+	TotalCost          float64
+	TotalTax           float64
+	Lines              []InvoiceLine
+	PDFStatus          string
+	PDFUrl             string
+	PDFErrorMessage    string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	CreatedBy          uuid.UUID
+	UpdatedBy          uuid.UUID
 }
 
 func (i *Invoice) SetCreatedBy(userID uuid.UUID) {
@@ -33,21 +104,171 @@ func (i *Invoice) SetUpdatedBy(userID uuid.UUID) {
 	i.UpdatedBy = userID
 }
 
+// IsDraft reports whether the invoice can still be changed.
+func (i *Invoice) IsDraft() bool {
+	return i.Status == StatusDraft
+}
+
+// IsCreditNote reports whether the document is a credit note.
+func (i *Invoice) IsCreditNote() bool {
+	return i.DocumentType == TypeCreditNote
+}
+
+// SetPaymentTerms sets the payment term of the invoice and its due date, counted from its date.
+func (i *Invoice) SetPaymentTerms(days int) {
+	i.PaymentTermDays = days
+	i.DueDate = i.Date.AddDate(0, 0, days)
+}
+
+// BalanceDue is the part of the total neither paid nor credited yet.
+func (i *Invoice) BalanceDue() float64 {
+	return i.TotalAmount - i.AmountPaid - i.AmountCredited
+}
+
+// Validate assigns the final number of a draft and locks its lines.
+func (i *Invoice) Validate(number string, at time.Time, userID uuid.UUID) error {
+	if i.Status != StatusDraft {
+		return ErrInvalidInvoiceStatus
+	}
+	if number == "" || strings.HasPrefix(number, ProvisionalNumberPrefix) {
+		return ErrInvoiceNumberRequired
+	}
+	i.Number = number
+	i.Status = StatusValidated
+	i.ValidatedAt = &at
+	i.ValidatedBy = &userID
+	return nil
+}
+
+// MarkSent records that a validated invoice was sent to the customer.
+func (i *Invoice) MarkSent(at time.Time) error {
+	if i.Status != StatusValidated {
+		return ErrInvalidInvoiceStatus
+	}
+	i.Status = StatusSent
+	i.SentAt = &at
+	return nil
+}
+
+// ApplyPayment adds a payment to a validated, sent or partially paid invoice, which becomes
+// paid once its balance is settled.
+func (i *Invoice) ApplyPayment(amount float64) error {
+	if i.IsCreditNote() {
+		return ErrInvalidDocumentType
+	}
+	switch i.Status {
+	case StatusValidated, StatusSent, StatusPartiallyPaid:
+	default:
+		return ErrInvalidInvoiceStatus
+	}
+	if amount <= 0 || amount > i.BalanceDue()+paymentEpsilon {
+		return ErrInvalidPaymentAmount
+	}
+	i.AmountPaid += amount
+	if i.BalanceDue() <= paymentEpsilon {
+		i.Status = StatusPaid
+	} else {
+		i.Status = StatusPartiallyPaid
+	}
+	return nil
+}
+
+// CanBeCredited reports whether credit notes can be issued for the document: an issued,
+// not cancelled invoice.
+func (i *Invoice) CanBeCredited() bool {
+	if i.IsCreditNote() {
+		return false
+	}
+	switch i.Status {
+	case StatusValidated, StatusSent, StatusPartiallyPaid, StatusPaid:
+		return true
+	default:
+		return false
+	}
+}
+
+// ApplyCredit deducts the total of a validated credit note from the invoice, which becomes
+// paid once its balance is settled. A credit larger than the balance due of the invoice leaves
+// it owing the customer.
+func (i *Invoice) ApplyCredit(amount float64) error {
+	if i.IsCreditNote() {
+		return ErrInvalidDocumentType
+	}
+	if !i.CanBeCredited() {
+		return ErrInvalidInvoiceStatus
+	}
+	if amount <= 0 || i.AmountCredited+amount > i.TotalAmount+paymentEpsilon {
+		return ErrCreditExceedsInvoice
+	}
+	i.AmountCredited += amount
+	if i.BalanceDue() <= paymentEpsilon {
+		i.Status = StatusPaid
+	}
+	return nil
+}
+
+// Cancel withdraws an invoice nothing was paid on or credited. Credit notes can only be
+// cancelled as drafts: once validated, they have been deducted from their invoice.
+func (i *Invoice) Cancel(reason string, at time.Time) error {
+	switch i.Status {
+	case StatusDraft, StatusValidated, StatusSent:
+	default:
+		return ErrInvalidInvoiceStatus
+	}
+	if i.AmountCredited > 0 || (i.IsCreditNote() && i.Status != StatusDraft) {
+		return ErrInvalidInvoiceStatus
+	}
+	i.Status = StatusCancelled
+	i.CancelledAt = &at
+	i.CancellationReason = reason
+	return nil
+}
+
 type InvoiceLine struct {
-	ID          uuid.UUID
-	InvoiceID   uuid.UUID
-	ItemID      uuid.UUID
-	Description string
-	Quantity    float64
-	UnitPrice   float64
-	UnitCost    float64
-	TaxRate     float64
-	TaxAmount   float64
-	NetPrice    float64
-	TotalAmount float64
-	TotalCost   float64
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	CreatedBy   uuid.UUID
-	UpdatedBy   uuid.UUID
+	ID             uuid.UUID
+	InvoiceID      uuid.UUID
+	OriginalLineID *uuid.UUID // Invoice line credited by a credit note line
+	ItemID         uuid.UUID
+	Description    string
+	Quantity       float64
+	UnitOfMeasure  string  // Unit in which Quantity and UnitPrice are given; empty for the base unit
+	BaseQuantity   float64 // Quantity in the base unit of the item
+	UnitPrice      float64
+	UnitCost       float64
+	TaxRate        float64
+	TaxAmount      float64
+	NetPrice       float64
+	TotalAmount    float64
+	TotalCost      float64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CreatedBy      uuid.UUID
+	UpdatedBy      uuid.UUID
+}
+
+// CreditLine returns the line of creditNoteID crediting quantity of the invoice line l, at its
+// unit, price, cost and tax.
+func (l *InvoiceLine) CreditLine(creditNoteID uuid.UUID, quantity float64) InvoiceLine {
+	baseQuantity := quantity
+	if l.Quantity != 0 {
+		baseQuantity = l.BaseQuantity * quantity / l.Quantity
+	}
+	originalLineID := l.ID
+	return InvoiceLine{
+		ID:             uuid.New(),
+		InvoiceID:      creditNoteID,
+		OriginalLineID: &originalLineID,
+		ItemID:         l.ItemID,
+		Description:    l.Description,
+		Quantity:       quantity,
+		UnitOfMeasure:  l.UnitOfMeasure,
+		BaseQuantity:   baseQuantity,
+		UnitPrice:      l.UnitPrice,
+		UnitCost:       l.UnitCost,
+		TaxRate:        l.TaxRate,
+		TaxAmount:      l.TaxAmount,
+		NetPrice:       l.NetPrice,
+		TotalAmount:    quantity * l.NetPrice,
+		TotalCost:      quantity * l.UnitCost,
+	}
 }
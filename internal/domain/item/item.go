@@ -4,6 +4,7 @@ package item
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,21 +19,54 @@ const (
 	Service  ItemType = "SERVICE"  // Represents a service, like man-hours.
 )
 
+var (
+	// ErrTrackingRequiresStorable is returned when lot or serial tracking is enabled on a service.
+	ErrTrackingRequiresStorable = errors.New("lot and serial tracking is only available for storable items")
+	// ErrCostingRequiresStorable is returned when FIFO or standard costing is enabled on a service.
+	ErrCostingRequiresStorable = errors.New("FIFO and standard costing are only available for storable items")
+	// ErrBaseUnitChange is returned when the base unit of an item is changed once set, which would
+	// silently rescale its stock.
+	ErrBaseUnitChange = errors.New("the base unit of measure of an item cannot be changed once set")
+)
+
+// TrackingMode defines how the stock of a storable item is identified beyond its quantity.
+type TrackingMode string
+
+const (
+	TrackingNone   TrackingMode = "NONE"   // Tracked by quantity only.
+	TrackingLot    TrackingMode = "LOT"    // Every unit belongs to a lot / batch.
+	TrackingSerial TrackingMode = "SERIAL" // Every unit carries its own serial number.
+)
+
+// CostingMethod defines how the stock of a storable item is valued.
+type CostingMethod string
+
+const (
+	CostingAverage  CostingMethod = "AVERAGE"  // Weighted average cost (CMP), updated on every receipt.
+	CostingFIFO     CostingMethod = "FIFO"     // Issues consume the oldest cost layers first.
+	CostingStandard CostingMethod = "STANDARD" // Valued at StandardCost; receipts record the price variance.
+)
+
 // Item represents the core entity for a product or service.
 // It includes pricing information but no business logic for calculations.
 type Item struct {
-	ID          uuid.UUID
-	Name        string
-	Description string
-	Type        ItemType
-	CostPrice   float64 // Purchase price
-	SalePrice   float64 // Selling price
-	AverageCost float64 // Calculated average cost - NO CALCULATION IN THIS FASE
-	IsActive    bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	CreatedBy   uuid.UUID
-	UpdatedBy   uuid.UUID
+	ID            uuid.UUID
+	Name          string
+	Description   string
+	Type          ItemType
+	CostPrice     float64       // Purchase price
+	SalePrice     float64       // Selling price
+	AverageCost   float64       // Weighted average cost, kept up to date for every costing method
+	StandardCost  float64       // Cost at which STANDARD items are valued
+	CostingMethod CostingMethod // Defaults to AVERAGE
+	TrackingMode  TrackingMode  // Lot or serial tracking, only for storable items
+	BaseUnit      string        // Code of the unit of measure in which stock is kept; empty means unconverted
+	LeadTimeDays  int           // Days from ordering or starting the item to its receipt, used by MRP
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	CreatedBy     uuid.UUID
+	UpdatedBy     uuid.UUID
 }
 
 // SetCreatedBy sets the ID of the user who created the entity.
@@ -45,6 +79,11 @@ func (i *Item) SetUpdatedBy(userID uuid.UUID) {
 	i.UpdatedBy = userID
 }
 
+// IsTracked reports whether the stock of the item is kept per lot or serial number.
+func (i *Item) IsTracked() bool {
+	return i.TrackingMode == TrackingLot || i.TrackingMode == TrackingSerial
+}
+
 // Repository defines the contract for data persistence operations for Items.
 // It operates purely on Item domain entities.
 type Repository interface {
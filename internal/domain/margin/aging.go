@@ -0,0 +1,69 @@
+package margin
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Receivable is an invoice with a balance left to collect as of a date.
+type Receivable struct {
+	InvoiceID      uuid.UUID
+	InvoiceNumber  string
+	ThirdPartyID   uuid.UUID
+	ThirdPartyName string
+	Date           time.Time
+	DueDate        time.Time
+	Outstanding    float64 // Total less the payments and credit notes up to the as-of date
+}
+
+// AgingBuckets splits outstanding balances by the number of days they are overdue.
+type AgingBuckets struct {
+	Current    float64 `json:"current"` // Not due yet, or due on the as-of date
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+// Add adds an outstanding amount overdue by daysOverdue days to its bucket and to the total.
+func (b *AgingBuckets) Add(amount float64, daysOverdue int) {
+	switch {
+	case daysOverdue <= 0:
+		b.Current += amount
+	case daysOverdue <= 30:
+		b.Days1To30 += amount
+	case daysOverdue <= 60:
+		b.Days31To60 += amount
+	case daysOverdue <= 90:
+		b.Days61To90 += amount
+	default:
+		b.Over90 += amount
+	}
+	b.Total += amount
+}
+
+// CustomerAging is the aged balance of a customer.
+type CustomerAging struct {
+	ThirdPartyID   uuid.UUID `json:"third_party_id"`
+	ThirdPartyName string    `json:"third_party_name"`
+	Invoices       int       `json:"invoices"` // Invoices with a balance outstanding
+	AgingBuckets
+}
+
+// AgingReport is the accounts receivable aging as of a date: the outstanding balances of the
+// customers, bucketed by days past their due date, and the overall totals.
+type AgingReport struct {
+	AsOf      time.Time       `json:"as_of"`
+	Customers []CustomerAging `json:"customers"`
+	Totals    AgingBuckets    `json:"totals"`
+}
+
+// AgingRepository defines the interface for retrieving the receivables of an aging report.
+type AgingRepository interface {
+	// ListReceivables returns the issued invoices dated up to asOf with a balance outstanding
+	// as of that date.
+	ListReceivables(ctx context.Context, asOf time.Time) ([]*Receivable, error)
+}
@@ -14,8 +14,8 @@ type MarginReport struct {
 	ProductID            uuid.UUID `json:"product_id"`
 	ProductName          string    `json:"product_name"`
 	TotalSellingPrice    float64   `json:"total_selling_price"`
-	TotalInputCost       float64   `json:"total_input_cost"`
-	TotalServiceCost     float64   `json:"total_service_cost"` // Assuming service cost is part of input cost or separate production overhead
+	TotalInputCost       float64   `json:"total_input_cost"` // Cost of goods sold less the service cost
+	TotalServiceCost     float64   `json:"total_service_cost"` // Labor and overhead of the units sold, out of their cost of goods sold
 	TotalTaxes           float64   `json:"total_taxes"`
 	GrossMargin          float64   `json:"gross_margin"` // TotalSellingPrice - TotalInputCost - TotalServiceCost - TotalTaxes
 	GrossMarginPercentage float64   `json:"gross_margin_percentage"`
@@ -0,0 +1,169 @@
+// Package mrp defines the demand forecasts and the runs of material requirements planning,
+// with the time-phased purchase and production orders they plan.
+package mrp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrForecastNotFound is returned when a Forecast is not found.
+	ErrForecastNotFound = errors.New("forecast not found")
+	// ErrInvalidForecast is returned when a forecast has no positive quantity or no due date.
+	ErrInvalidForecast = errors.New("a forecast needs a quantity greater than 0 and a due date")
+	// ErrRunNotFound is returned when a Run is not found.
+	ErrRunNotFound = errors.New("MRP run not found")
+	// ErrInvalidHorizon is returned when the horizon of a run does not end after its start.
+	ErrInvalidHorizon = errors.New("the planning horizon must end after today")
+	// ErrRunNotQueued is returned when a run that already started is executed again.
+	ErrRunNotQueued = errors.New("MRP run is not queued")
+)
+
+// Forecast is a demand expected for an item in a warehouse on a date, entered by a planner.
+type Forecast struct {
+	ID          uuid.UUID
+	ItemID      uuid.UUID
+	WarehouseID uuid.UUID
+	Quantity    float64 // In the base unit of the item
+	DueDate     time.Time
+	Notes       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CreatedBy   uuid.UUID
+	UpdatedBy   uuid.UUID
+}
+
+// Validate checks the quantity and due date of the forecast.
+func (f *Forecast) Validate() error {
+	if f.Quantity <= 0 || f.DueDate.IsZero() {
+		return ErrInvalidForecast
+	}
+	return nil
+}
+
+func (f *Forecast) SetCreatedBy(userID uuid.UUID) {
+	f.CreatedBy = userID
+}
+
+func (f *Forecast) SetUpdatedBy(userID uuid.UUID) {
+	f.UpdatedAt = time.Now()
+	f.UpdatedBy = userID
+}
+
+// RunStatus is the progress of an MRP run on the worker pool.
+type RunStatus string
+
+const (
+	RunQueued    RunStatus = "QUEUED"    // Submitted to the worker pool
+	RunRunning   RunStatus = "RUNNING"   // Picked up by a worker
+	RunCompleted RunStatus = "COMPLETED" // Planned orders stored
+	RunFailed    RunStatus = "FAILED"    // See ErrorMessage; no planned order is stored
+)
+
+// Run is an MRP run over the demand of one warehouse up to the end of its horizon.
+type Run struct {
+	ID                uuid.UUID
+	WarehouseID       uuid.UUID
+	HorizonEnd        time.Time // Demand due after this date is not planned
+	IncludeInvoices   bool      // Invoice lines dated from the start of the run count as demand
+	Status            RunStatus
+	ErrorMessage      string
+	PlannedOrderCount int
+	StartedAt         *time.Time
+	CompletedAt       *time.Time
+	CreatedAt         time.Time
+	CreatedBy         uuid.UUID
+}
+
+// DemandSource tells where a requirement planned by a run comes from.
+type DemandSource string
+
+const (
+	SourceForecast     DemandSource = "FORECAST"
+	SourceReservation  DemandSource = "RESERVATION"         // Open quantity of a held reservation
+	SourceInvoice      DemandSource = "INVOICE"             // Invoice line dated within the horizon
+	SourceOrder        DemandSource = "MANUFACTURING_ORDER" // Component still to issue to an open order
+	SourcePlannedOrder DemandSource = "PLANNED_ORDER"       // Component of a planned production order
+)
+
+// Demand is a gross requirement of an item on a date.
+type Demand struct {
+	ItemID   uuid.UUID
+	Quantity float64 // In the base unit of the item
+	DueDate  time.Time
+	Source   DemandSource
+}
+
+// OrderType tells how a planned order should be fulfilled.
+type OrderType string
+
+const (
+	OrderPurchase   OrderType = "PURCHASE"   // The item has no BOM in force and must be bought
+	OrderProduction OrderType = "PRODUCTION" // The item is produced from its BOM in force
+)
+
+// PlannedOrder is a purchase or production order proposed by a run to cover the net
+// requirement of an item on its due date. It must start its lead time earlier.
+type PlannedOrder struct {
+	ID          uuid.UUID
+	RunID       uuid.UUID
+	ItemID      uuid.UUID
+	WarehouseID uuid.UUID
+	Type        OrderType
+	BOMID       *uuid.UUID // Set for production orders
+	Level       int        // Low-level code of the item: 0 for end items, one more for each BOM level below
+	Quantity    float64    // Net requirement in the base unit of the item
+	StartDate   time.Time  // Due date less the lead time of the item
+	DueDate     time.Time
+	PastDue     bool // The order should have started before the run
+}
+
+// ForecastFilter narrows a forecast query. Nil fields are ignored.
+type ForecastFilter struct {
+	ItemID      *uuid.UUID
+	WarehouseID *uuid.UUID
+	To          *time.Time // Due on or before
+}
+
+// PlannedOrderFilter narrows a planned order query. Nil and zero-valued fields are ignored.
+type PlannedOrderFilter struct {
+	ItemID *uuid.UUID
+	Type   OrderType
+}
+
+// ForecastRepository defines the contract for forecast persistence.
+type ForecastRepository interface {
+	WithTx(tx *gorm.DB) ForecastRepository
+	Create(ctx context.Context, f *Forecast) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Forecast, error)
+	// List returns the forecasts by due date.
+	List(ctx context.Context, filter ForecastFilter) ([]*Forecast, error)
+	Update(ctx context.Context, f *Forecast) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// RunRepository defines the contract for the persistence of MRP runs and their planned orders.
+type RunRepository interface {
+	WithTx(tx *gorm.DB) RunRepository
+	Create(ctx context.Context, run *Run) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Run, error)
+	// List returns the runs, latest first, of one warehouse when warehouseID is set.
+	List(ctx context.Context, warehouseID *uuid.UUID) ([]*Run, error)
+	// Update saves the status, error, count and dates of a run.
+	Update(ctx context.Context, run *Run) error
+	CreatePlannedOrders(ctx context.Context, orders []*PlannedOrder) error
+	// ListPlannedOrders returns the planned orders of a run by level, start date and item.
+	ListPlannedOrders(ctx context.Context, runID uuid.UUID, filter PlannedOrderFilter) ([]*PlannedOrder, error)
+}
+
+// DemandRepository reads the demand of documents owned by other modules.
+type DemandRepository interface {
+	// ListInvoiceDemand returns the base quantities of the invoice lines of storable items dated
+	// within [from, to], summed per item and date.
+	ListInvoiceDemand(ctx context.Context, from, to time.Time) ([]*Demand, error)
+}
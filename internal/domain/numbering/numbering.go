@@ -0,0 +1,133 @@
+// Package numbering defines the numbering sequences that give documents their final, gapless
+// numbers, and the repository contract for their persistence.
+package numbering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSequenceNotFound is returned when no numbering sequence exists for a document type.
+	ErrSequenceNotFound = errors.New("numbering sequence not found")
+	// ErrInvalidPattern is returned when a pattern does not hold exactly one counter token, e.g. {0000}.
+	ErrInvalidPattern = errors.New("numbering pattern must contain exactly one counter token such as {0000}")
+	// ErrInvalidResetPolicy is returned when a sequence is given an unknown reset policy.
+	ErrInvalidResetPolicy = errors.New("reset policy must be NEVER or YEARLY")
+	// ErrPatternWithoutYear is returned when a yearly sequence has no year token, so that the
+	// numbers given after each reset would repeat those of the previous years.
+	ErrPatternWithoutYear = errors.New("numbering pattern of a yearly sequence must contain a {YYYY} or {YY} token")
+)
+
+// DocumentType is the kind of document a sequence numbers. Each type has one sequence.
+type DocumentType string
+
+const (
+	DocumentInvoice    DocumentType = "INVOICE"
+	DocumentCreditNote DocumentType = "CREDIT_NOTE"
+)
+
+// ResetPolicy tells when the counter of a sequence starts again from 1.
+type ResetPolicy string
+
+const (
+	ResetNever  ResetPolicy = "NEVER"  // One counter for all time
+	ResetYearly ResetPolicy = "YEARLY" // One counter per calendar year of the document date
+)
+
+// counterToken matches the counter of a pattern; its zeros give the minimum number of digits.
+var counterToken = regexp.MustCompile(`\{0+\}`)
+
+// Sequence is the numbering of a document type. Pattern is the number format: {YYYY}, {YY}
+// and {MM} are replaced by the year and month of the document date and the counter token,
+// e.g. {0000}, by the counter padded with zeros, so INV-{YYYY}-{0000} gives INV-2026-0042.
+type Sequence struct {
+	ID           uuid.UUID
+	DocumentType DocumentType
+	Pattern      string
+	Reset        ResetPolicy
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CreatedBy    uuid.UUID
+	UpdatedBy    uuid.UUID
+}
+
+func (s *Sequence) SetCreatedBy(userID uuid.UUID) {
+	s.CreatedBy = userID
+}
+
+func (s *Sequence) SetUpdatedBy(userID uuid.UUID) {
+	s.UpdatedAt = time.Now()
+	s.UpdatedBy = userID
+}
+
+// Period returns the counter period of a document dated at: its year for a yearly reset,
+// empty otherwise.
+func (s *Sequence) Period(at time.Time) string {
+	if s.Reset == ResetYearly {
+		return fmt.Sprintf("%04d", at.Year())
+	}
+	return ""
+}
+
+// Format returns the number given by the pattern to the counter value of a document dated at.
+func (s *Sequence) Format(value int64, at time.Time) string {
+	number := strings.NewReplacer(
+		"{YYYY}", fmt.Sprintf("%04d", at.Year()),
+		"{YY}", fmt.Sprintf("%02d", at.Year()%100),
+		"{MM}", fmt.Sprintf("%02d", int(at.Month())),
+	).Replace(s.Pattern)
+	return counterToken.ReplaceAllStringFunc(number, func(token string) string {
+		return fmt.Sprintf("%0*d", len(token)-2, value)
+	})
+}
+
+// Validate checks the pattern and the reset policy of the sequence. A yearly sequence needs a
+// year token, since its counter starts again from 1 each year.
+func (s *Sequence) Validate() error {
+	if len(counterToken.FindAllString(s.Pattern, -1)) != 1 {
+		return ErrInvalidPattern
+	}
+	switch s.Reset {
+	case ResetNever:
+		return nil
+	case ResetYearly:
+		if !strings.Contains(s.Pattern, "{YYYY}") && !strings.Contains(s.Pattern, "{YY}") {
+			return ErrPatternWithoutYear
+		}
+		return nil
+	default:
+		return ErrInvalidResetPolicy
+	}
+}
+
+// Counter is the last value given by a sequence in a period.
+type Counter struct {
+	ID         uuid.UUID
+	SequenceID uuid.UUID
+	Period     string
+	LastValue  int64
+}
+
+// Repository defines the contract for numbering sequence persistence.
+type Repository interface {
+	WithTx(tx *gorm.DB) Repository
+	List(ctx context.Context) ([]*Sequence, error)
+	// GetByDocumentType returns the sequence of a document type, or ErrSequenceNotFound.
+	GetByDocumentType(ctx context.Context, documentType DocumentType) (*Sequence, error)
+	// GetByDocumentTypeForUpdate returns the sequence of a document type, locking its row until
+	// the end of the transaction so that its numbers are handed out one at a time.
+	GetByDocumentTypeForUpdate(ctx context.Context, documentType DocumentType) (*Sequence, error)
+	Update(ctx context.Context, sequence *Sequence) error
+	// GetCounter returns the counter of a sequence for a period, or nil if it gave no number yet.
+	GetCounter(ctx context.Context, sequenceID uuid.UUID, period string) (*Counter, error)
+	// SaveCounter creates a counter without ID and updates the last value of the others.
+	SaveCounter(ctx context.Context, counter *Counter) error
+}
@@ -0,0 +1,125 @@
+// Package payment defines the payments received from customers, their allocation to invoices
+// and the customer statement, and the repository contract for their persistence.
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPaymentNotFound is returned when a payment does not exist.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrInvalidMethod is returned when a payment is given an unknown method.
+	ErrInvalidMethod = errors.New("payment method must be CASH, BANK_TRANSFER, CARD, CHECK or OTHER")
+	// ErrInvalidAmount is returned when a payment or an allocation is not positive.
+	ErrInvalidAmount = errors.New("payment and allocation amounts must be positive")
+	// ErrAllocationExceedsPayment is returned when allocations exceed the unallocated amount of a payment.
+	ErrAllocationExceedsPayment = errors.New("allocations exceed the unallocated amount of the payment")
+	// ErrInvoiceOfOtherCustomer is returned when a payment is allocated to an invoice of another customer.
+	ErrInvoiceOfOtherCustomer = errors.New("payments can only be allocated to invoices of their customer")
+)
+
+// Method is how a payment was made.
+type Method string
+
+const (
+	MethodCash         Method = "CASH"
+	MethodBankTransfer Method = "BANK_TRANSFER"
+	MethodCard         Method = "CARD"
+	MethodCheck        Method = "CHECK"
+	MethodOther        Method = "OTHER"
+)
+
+// amountEpsilon absorbs rounding when allocations use up a payment.
+const amountEpsilon = 0.005
+
+// Payment is an amount received from a customer. It is allocated to invoices of the customer;
+// the part not allocated yet is a credit of the customer, to be allocated to later invoices.
+type Payment struct {
+	ID                uuid.UUID
+	ThirdPartyID      uuid.UUID
+	Date              time.Time
+	Amount            float64
+	Method            Method
+	Reference         string
+	UnallocatedAmount float64
+	Allocations       []Allocation
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	CreatedBy         uuid.UUID
+	UpdatedBy         uuid.UUID
+}
+
+func (p *Payment) SetCreatedBy(userID uuid.UUID) {
+	p.CreatedBy = userID
+}
+
+func (p *Payment) SetUpdatedBy(userID uuid.UUID) {
+	p.UpdatedAt = time.Now()
+	p.UpdatedBy = userID
+}
+
+// Validate checks the amount and the method of the payment.
+func (p *Payment) Validate() error {
+	if p.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	switch p.Method {
+	case MethodCash, MethodBankTransfer, MethodCard, MethodCheck, MethodOther:
+		return nil
+	default:
+		return ErrInvalidMethod
+	}
+}
+
+// Allocate allocates amount of the unallocated part of the payment to an invoice.
+func (p *Payment) Allocate(invoiceID uuid.UUID, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > p.UnallocatedAmount+amountEpsilon {
+		return ErrAllocationExceedsPayment
+	}
+	p.UnallocatedAmount -= amount
+	if p.UnallocatedAmount < amountEpsilon {
+		p.UnallocatedAmount = 0
+	}
+	p.Allocations = append(p.Allocations, Allocation{
+		ID:        uuid.New(),
+		PaymentID: p.ID,
+		InvoiceID: invoiceID,
+		Amount:    amount,
+	})
+	return nil
+}
+
+// Allocation is the part of a payment settling an invoice.
+type Allocation struct {
+	ID        uuid.UUID
+	PaymentID uuid.UUID
+	InvoiceID uuid.UUID
+	Amount    float64
+	CreatedAt time.Time
+	CreatedBy uuid.UUID
+}
+
+// Repository defines the contract for payment persistence.
+type Repository interface {
+	WithTx(tx *gorm.DB) Repository
+	// Create saves a payment with its allocations.
+	Create(ctx context.Context, payment *Payment) error
+	// Update saves the unallocated amount of a payment and creates its new allocations.
+	Update(ctx context.Context, payment *Payment) error
+	// GetByID returns a payment with its allocations, or ErrPaymentNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*Payment, error)
+	// GetByIDForUpdate returns a payment with its allocations, locking its row until the end of
+	// the transaction.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*Payment, error)
+	// ListByThirdParty returns the payments of a customer with their allocations, by date.
+	ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*Payment, error)
+}
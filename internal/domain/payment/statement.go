@@ -0,0 +1,41 @@
+package payment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EntryType is the kind of document of a statement entry.
+type EntryType string
+
+const (
+	EntryInvoice    EntryType = "INVOICE"
+	EntryCreditNote EntryType = "CREDIT_NOTE"
+	EntryPayment    EntryType = "PAYMENT"
+)
+
+// StatementEntry is a document of the account of a customer: invoices are debits, credit notes
+// and payments credits. Balance is the running balance after the entry; a negative balance is
+// owed to the customer.
+type StatementEntry struct {
+	Date       time.Time
+	Type       EntryType
+	DocumentID uuid.UUID
+	Reference  string
+	Debit      float64
+	Credit     float64
+	Balance    float64
+}
+
+// Statement is the account of a customer over a period: the balance brought forward from
+// before the period, the entries of the period and the closing balance.
+type Statement struct {
+	ThirdPartyID      uuid.UUID
+	From              *time.Time
+	To                *time.Time
+	OpeningBalance    float64
+	Entries           []StatementEntry
+	ClosingBalance    float64
+	UnallocatedCredit float64 // Payments not allocated yet and credit notes in excess of their invoice's balance
+}
@@ -0,0 +1,127 @@
+// Package replenishment defines reorder rules and the purchase or production
+// proposals that the replenishment engine derives from them.
+package replenishment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRuleNotFound is returned when a ReorderRule is not found.
+	ErrRuleNotFound = errors.New("reorder rule not found")
+	// ErrRuleExists is returned when an item already has a reorder rule in the warehouse.
+	ErrRuleExists = errors.New("item already has a reorder rule in this warehouse")
+	// ErrInvalidRule is returned when the levels of a rule are not 0 <= min <= reorder point <= max, with max > 0.
+	ErrInvalidRule = errors.New("reorder rule levels must satisfy 0 <= min <= reorder point <= max and max > 0")
+)
+
+// ReorderRule holds the min/max and reorder-point settings of an item in a warehouse.
+// When the projected quantity falls to the reorder point (or below the minimum),
+// the engine proposes enough to bring it back up to the maximum.
+type ReorderRule struct {
+	ID           uuid.UUID
+	ItemID       uuid.UUID
+	WarehouseID  uuid.UUID
+	MinQuantity  float64 // Safety stock, the projected quantity should never fall below it
+	ReorderPoint float64 // Projected quantity that triggers a proposal
+	MaxQuantity  float64 // Order-up-to level of a proposal
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CreatedBy    uuid.UUID
+	UpdatedBy    uuid.UUID
+}
+
+// Validate checks the consistency of the rule levels.
+func (r *ReorderRule) Validate() error {
+	if r.MinQuantity < 0 || r.MinQuantity > r.ReorderPoint || r.ReorderPoint > r.MaxQuantity || r.MaxQuantity <= 0 {
+		return ErrInvalidRule
+	}
+	return nil
+}
+
+// SetCreatedBy sets the ID of the user who created the entity.
+func (r *ReorderRule) SetCreatedBy(userID uuid.UUID) {
+	r.CreatedBy = userID
+}
+
+// SetUpdatedBy sets the ID of the user who last updated the entity.
+func (r *ReorderRule) SetUpdatedBy(userID uuid.UUID) {
+	r.UpdatedAt = time.Now()
+	r.UpdatedBy = userID
+}
+
+// ProposalType tells how a proposal should be fulfilled.
+type ProposalType string
+
+const (
+	ProposalPurchase   ProposalType = "PURCHASE"   // The item has no active BOM and must be bought.
+	ProposalProduction ProposalType = "PRODUCTION" // The item is produced from its active BOM.
+)
+
+// Proposal is a suggested purchase or production order produced by a replenishment run.
+// It keeps the figures the suggestion was computed from so planners can review it.
+type Proposal struct {
+	ID              uuid.UUID
+	RunID           uuid.UUID // Shared by all proposals of the same run
+	RuleID          uuid.UUID
+	ItemID          uuid.UUID
+	WarehouseID     uuid.UUID
+	Type            ProposalType
+	BOMID           *uuid.UUID // Set for production proposals
+	Quantity        float64    // Suggested quantity, MaxQuantity - ProjectedQuantity
+	OnHand          float64
+	Reserved        float64 // Open quantity of the held reservations
+	ComponentDemand float64 // Required by the production proposals of parent items
+	Projected       float64 // OnHand - Reserved - ComponentDemand
+	ReorderPoint    float64
+	MinQuantity     float64
+	MaxQuantity     float64
+	GeneratedAt     time.Time
+}
+
+// BelowMinimum tells whether the projected quantity already breaches the safety stock,
+// which makes the proposal urgent.
+func (p *Proposal) BelowMinimum() bool {
+	return p.Projected < p.MinQuantity
+}
+
+// RuleFilter narrows a reorder rule query. Nil fields are ignored.
+type RuleFilter struct {
+	ItemID      *uuid.UUID
+	WarehouseID *uuid.UUID
+	ActiveOnly  bool
+}
+
+// ProposalFilter narrows a proposal query. Nil and zero-valued fields are ignored.
+type ProposalFilter struct {
+	ItemID      *uuid.UUID
+	WarehouseID *uuid.UUID
+	Type        ProposalType
+}
+
+// RuleRepository defines the contract for reorder rule persistence.
+type RuleRepository interface {
+	WithTx(tx *gorm.DB) RuleRepository
+	Create(ctx context.Context, rule *ReorderRule) error
+	GetByID(ctx context.Context, id uuid.UUID) (*ReorderRule, error)
+	// GetByItemAndWarehouse returns ErrRuleNotFound when the item has no rule in the warehouse.
+	GetByItemAndWarehouse(ctx context.Context, itemID, warehouseID uuid.UUID) (*ReorderRule, error)
+	List(ctx context.Context, filter RuleFilter) ([]*ReorderRule, error)
+	Update(ctx context.Context, rule *ReorderRule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// ProposalRepository defines the contract for replenishment proposal persistence.
+// Only the proposals of the latest run are kept.
+type ProposalRepository interface {
+	WithTx(tx *gorm.DB) ProposalRepository
+	// Replace deletes the proposals of previous runs and stores the given ones.
+	Replace(ctx context.Context, proposals []*Proposal) error
+	List(ctx context.Context, filter ProposalFilter) ([]*Proposal, error)
+}
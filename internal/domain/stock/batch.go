@@ -0,0 +1,38 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrBatchKeyReused is returned when an idempotency key is sent again with different lines.
+var ErrBatchKeyReused = errors.New("idempotency key was already used for a different batch")
+
+// MovementBatch is a bulk import of stock movements posted in a single transaction.
+// A batch sent again with the same idempotency key returns the movements of the first
+// one instead of posting them twice; PayloadHash tells a retry from a different upload.
+type MovementBatch struct {
+	ID             uuid.UUID
+	IdempotencyKey string // Empty when the client did not send one
+	PayloadHash    string
+	LineCount      int
+	CreatedAt      time.Time
+	CreatedBy      uuid.UUID
+}
+
+// MovementBatchRepository defines the contract for bulk movement imports.
+type MovementBatchRepository interface {
+	WithTx(tx *gorm.DB) MovementBatchRepository
+	// Claim inserts the batch unless its idempotency key is already taken and reports whether it did.
+	// A claim of a key held by a transaction in progress waits until that transaction ends.
+	Claim(ctx context.Context, batch *MovementBatch) (bool, error)
+	// GetByKey returns gorm.ErrRecordNotFound if no batch was posted with the key.
+	GetByKey(ctx context.Context, key string) (*MovementBatch, error)
+	AddLine(ctx context.Context, batchID uuid.UUID, line int, movementID uuid.UUID) error
+	// ListMovements returns the movements of a batch in line order.
+	ListMovements(ctx context.Context, batchID uuid.UUID) ([]*StockMovement, error)
+}
@@ -0,0 +1,54 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrCostLayerConsumed is returned when an inbound movement of a FIFO item is reversed
+// after part of the quantity it received has already been issued.
+var ErrCostLayerConsumed = errors.New("the cost layer of the movement has already been consumed")
+
+// CostLayer is the quantity of a FIFO item received by one inbound movement at one unit cost.
+// Outbound movements consume the open layers of the item oldest first, across all locations.
+type CostLayer struct {
+	ID                uuid.UUID
+	ItemID            uuid.UUID
+	MovementID        uuid.UUID // Inbound movement that opened the layer
+	UnitCost          float64
+	OriginalQuantity  float64
+	RemainingQuantity float64
+	ReceivedAt        time.Time
+}
+
+// CostLayerConsumption is the quantity an outbound movement took from a cost layer.
+// It lets a reversal put the quantity back into the layers it came from.
+type CostLayerConsumption struct {
+	ID         uuid.UUID
+	LayerID    uuid.UUID
+	MovementID uuid.UUID
+	Quantity   float64
+	UnitCost   float64
+}
+
+// CostLayerRepository defines the contract for the FIFO cost layers of items.
+type CostLayerRepository interface {
+	WithTx(tx *gorm.DB) CostLayerRepository
+	Create(ctx context.Context, layer *CostLayer) error
+	// ListOpen returns the layers of an item with a remaining quantity, oldest first.
+	ListOpen(ctx context.Context, itemID uuid.UUID) ([]*CostLayer, error)
+	// ListOpenForUpdate locks and returns the layers of an item with a remaining quantity, oldest first.
+	ListOpenForUpdate(ctx context.Context, itemID uuid.UUID) ([]*CostLayer, error)
+	// GetForUpdate locks a layer. It returns gorm.ErrRecordNotFound if there is none.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*CostLayer, error)
+	// GetByMovementForUpdate locks the layer opened by an inbound movement. It returns gorm.ErrRecordNotFound if there is none.
+	GetByMovementForUpdate(ctx context.Context, movementID uuid.UUID) (*CostLayer, error)
+	UpdateRemaining(ctx context.Context, layer *CostLayer) error
+	CreateConsumption(ctx context.Context, consumption *CostLayerConsumption) error
+	// ListConsumptions returns the layer consumptions of an outbound movement.
+	ListConsumptions(ctx context.Context, movementID uuid.UUID) ([]*CostLayerConsumption, error)
+}
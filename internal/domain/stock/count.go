@@ -0,0 +1,148 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCountSessionNotFound is returned when an inventory count session does not exist.
+	ErrCountSessionNotFound = errors.New("inventory count session not found")
+	// ErrCountSessionNotOpen is returned when a closed session receives counts or a second approval.
+	ErrCountSessionNotOpen = errors.New("inventory count session is not open")
+	// ErrBinNotInCount is returned when a count is submitted for a bin outside the session scope.
+	ErrBinNotInCount = errors.New("bin is not part of the inventory count session")
+)
+
+// CountStatus defines the lifecycle state of an inventory count session.
+type CountStatus string
+
+const (
+	CountStatusOpen      CountStatus = "OPEN"
+	CountStatusApproved  CountStatus = "APPROVED"
+	CountStatusCancelled CountStatus = "CANCELLED"
+)
+
+// CountSession is a physical inventory count of a warehouse or a set of its bins.
+// When it is started, the expected Stock quantities of the scope are snapshotted into its lines.
+type CountSession struct {
+	ID          uuid.UUID
+	WarehouseID uuid.UUID
+	BinIDs      []uuid.UUID // Empty when the whole warehouse is counted
+	Status      CountStatus
+	Lines       []*CountLine
+	ApprovedAt  *time.Time
+	ApprovedBy  *uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CreatedBy   uuid.UUID
+	UpdatedBy   uuid.UUID
+}
+
+func (s *CountSession) SetCreatedBy(userID uuid.UUID) {
+	s.CreatedBy = userID
+}
+
+func (s *CountSession) SetUpdatedBy(userID uuid.UUID) {
+	s.UpdatedAt = time.Now()
+	s.UpdatedBy = userID
+}
+
+// CoversBin reports whether the bin belongs to the scope of the session.
+// The caller is responsible for checking that the bin belongs to the session warehouse.
+func (s *CountSession) CoversBin(binID uuid.UUID) bool {
+	if len(s.BinIDs) == 0 {
+		return true
+	}
+	for _, id := range s.BinIDs {
+		if id == binID {
+			return true
+		}
+	}
+	return false
+}
+
+// FindLine returns the line counting the item in the bin, or nil if there is none.
+func (s *CountSession) FindLine(itemID, binID uuid.UUID) *CountLine {
+	for _, line := range s.Lines {
+		if line.ItemID == itemID && line.BinID == binID {
+			return line
+		}
+	}
+	return nil
+}
+
+// CountLine holds the expected quantity of an item in a bin and the counts submitted for it.
+type CountLine struct {
+	ID               uuid.UUID
+	SessionID        uuid.UUID
+	ItemID           uuid.UUID
+	BinID            uuid.UUID
+	ExpectedQuantity float64
+	Entries          []*CountEntry
+}
+
+// CountedQuantity returns the quantity counted for the line and whether it was counted at all.
+// Each device reports the quantity it counted on its own; when a device recounts, only its
+// latest entry is kept, and the counted quantity is the sum over all devices.
+func (l *CountLine) CountedQuantity() (float64, bool) {
+	latest := make(map[string]*CountEntry)
+	for _, e := range l.Entries {
+		if current, ok := latest[e.DeviceID]; !ok || e.CountedAt.After(current.CountedAt) {
+			latest[e.DeviceID] = e
+		}
+	}
+	var total float64
+	for _, e := range latest {
+		total += e.Quantity
+	}
+	return total, len(latest) > 0
+}
+
+// Variance returns the counted minus the expected quantity, or zero if the line was not counted.
+func (l *CountLine) Variance() float64 {
+	counted, ok := l.CountedQuantity()
+	if !ok {
+		return 0
+	}
+	return counted - l.ExpectedQuantity
+}
+
+// CountEntry is an immutable count submitted by a device for a line.
+type CountEntry struct {
+	ID        uuid.UUID
+	LineID    uuid.UUID
+	DeviceID  string
+	Quantity  float64
+	CountedAt time.Time
+	CountedBy uuid.UUID
+}
+
+// CountAdjustment describes the variance posted for one count line when a session is approved.
+type CountAdjustment struct {
+	LineID   uuid.UUID
+	ItemID   uuid.UUID
+	BinID    uuid.UUID
+	Expected float64
+	Counted  float64
+	Movement *StockMovement
+}
+
+// CountSessionRepository defines the contract for inventory count persistence.
+type CountSessionRepository interface {
+	WithTx(tx *gorm.DB) CountSessionRepository
+	// Create persists the session together with its bin scope and lines.
+	Create(ctx context.Context, session *CountSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*CountSession, error)
+	// GetByIDForUpdate locks the session header so that submissions and approval are serialized.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*CountSession, error)
+	List(ctx context.Context, status CountStatus) ([]*CountSession, error)
+	// UpdateStatus persists the status and approval fields of the session header.
+	UpdateStatus(ctx context.Context, session *CountSession) error
+	AddLine(ctx context.Context, line *CountLine) error
+	AddEntry(ctx context.Context, entry *CountEntry) error
+}
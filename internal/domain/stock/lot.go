@@ -0,0 +1,91 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrLotRequired is returned when a movement of a lot or serial tracked item carries no lots.
+	ErrLotRequired = errors.New("lot or serial numbers are required for this item")
+	// ErrLotNotAllowed is returned when lots are given for an item that is not tracked.
+	ErrLotNotAllowed = errors.New("item is not lot or serial tracked")
+	// ErrInvalidLots is returned when a lot number is empty, repeated or has a non-positive quantity.
+	ErrInvalidLots = errors.New("lot numbers must be unique and have a positive quantity")
+	// ErrInvalidSerial is returned when a serial number is given a quantity other than one.
+	ErrInvalidSerial = errors.New("each serial number must have a quantity of exactly 1")
+	// ErrLotQuantityMismatch is returned when the lot quantities do not add up to the movement quantity.
+	ErrLotQuantityMismatch = errors.New("lot quantities must add up to the movement quantity")
+	// ErrSerialInStock is returned when a serial number that is already on hand is received again.
+	ErrSerialInStock = errors.New("serial number is already in stock")
+	// ErrInsufficientLotStock is returned when a lot does not hold enough quantity for an outbound movement.
+	ErrInsufficientLotStock = errors.New("insufficient stock for lot")
+)
+
+// lotQuantityEpsilon absorbs floating point noise when lot quantities are summed.
+const lotQuantityEpsilon = 1e-9
+
+// LotQuantity is the part of a movement that belongs to a single lot or serial number.
+type LotQuantity struct {
+	LotNumber string
+	Quantity  float64
+}
+
+// StockLot is the quantity bucket of an item lot (or serial number) at a location.
+// The sum of the buckets of a location always equals the Stock quantity of a tracked item.
+type StockLot struct {
+	ItemID      uuid.UUID
+	WarehouseID uuid.UUID
+	BinID       *uuid.UUID // Optional
+	LotNumber   string
+	Quantity    float64
+	UpdatedAt   time.Time
+}
+
+// ValidateLots checks the lots of a movement against the tracking mode of its item.
+func ValidateLots(mode item.TrackingMode, quantity float64, lots []LotQuantity) error {
+	if mode != item.TrackingLot && mode != item.TrackingSerial {
+		if len(lots) > 0 {
+			return ErrLotNotAllowed
+		}
+		return nil
+	}
+	if len(lots) == 0 {
+		return ErrLotRequired
+	}
+
+	seen := make(map[string]bool, len(lots))
+	var total float64
+	for _, lot := range lots {
+		if lot.LotNumber == "" || lot.Quantity <= 0 || seen[lot.LotNumber] {
+			return ErrInvalidLots
+		}
+		if mode == item.TrackingSerial && lot.Quantity != 1 {
+			return ErrInvalidSerial
+		}
+		seen[lot.LotNumber] = true
+		total += lot.Quantity
+	}
+	if math.Abs(total-quantity) > lotQuantityEpsilon {
+		return ErrLotQuantityMismatch
+	}
+	return nil
+}
+
+// StockLotRepository defines the contract for the lot quantity buckets.
+type StockLotRepository interface {
+	WithTx(tx *gorm.DB) StockLotRepository
+	// GetForUpdate locks the bucket of a lot at a location. It returns gorm.ErrRecordNotFound if there is none.
+	GetForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, lotNumber string) (*StockLot, error)
+	// GetTotalQuantity returns the quantity of a lot across all locations.
+	GetTotalQuantity(ctx context.Context, itemID uuid.UUID, lotNumber string) (float64, error)
+	Upsert(ctx context.Context, lot *StockLot) error
+	// List returns the non-empty buckets of an item, optionally restricted to one lot number.
+	List(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*StockLot, error)
+}
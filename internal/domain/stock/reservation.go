@@ -0,0 +1,99 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReservationNotFound is returned when a stock reservation does not exist.
+	ErrReservationNotFound = errors.New("stock reservation not found")
+	// ErrReservationNotActive is returned when a released, consumed or expired reservation is changed.
+	ErrReservationNotActive = errors.New("stock reservation is not active")
+	// ErrReservationExists is returned when a source document already holds an active reservation for the location.
+	ErrReservationExists = errors.New("source document already holds an active reservation for this item and location")
+	// ErrReservationExceeded is returned when more than the open quantity of a reservation is consumed.
+	ErrReservationExceeded = errors.New("quantity exceeds the open quantity of the reservation")
+	// ErrInvalidExpiry is returned when a reservation would expire in the past.
+	ErrInvalidExpiry = errors.New("reservation expiry must be in the future")
+)
+
+// ReservationStatus defines the lifecycle state of a stock reservation.
+type ReservationStatus string
+
+const (
+	ReservationActive   ReservationStatus = "ACTIVE"
+	ReservationReleased ReservationStatus = "RELEASED"
+	ReservationConsumed ReservationStatus = "CONSUMED"
+	ReservationExpired  ReservationStatus = "EXPIRED"
+)
+
+// Reservation is a soft allocation of stock at a location to a source document, such as a
+// sales order. The open quantity of active, unexpired reservations is not available to other
+// outbound movements of the location, but stays on hand until the reservation is consumed.
+type Reservation struct {
+	ID               uuid.UUID
+	ItemID           uuid.UUID
+	WarehouseID      uuid.UUID
+	BinID            *uuid.UUID // Nil for non-binned stock
+	SourceType       string     // Kind of source document, e.g. SALES_ORDER
+	SourceID         string     // Identifier of the source document
+	Quantity         float64
+	ConsumedQuantity float64
+	Status           ReservationStatus
+	ExpiresAt        time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	CreatedBy        uuid.UUID
+	UpdatedBy        uuid.UUID
+}
+
+func (r *Reservation) SetCreatedBy(userID uuid.UUID) {
+	r.CreatedBy = userID
+}
+
+func (r *Reservation) SetUpdatedBy(userID uuid.UUID) {
+	r.UpdatedAt = time.Now()
+	r.UpdatedBy = userID
+}
+
+// OpenQuantity returns the reserved quantity that has not been consumed yet.
+func (r *Reservation) OpenQuantity() float64 {
+	return r.Quantity - r.ConsumedQuantity
+}
+
+// IsHeld reports whether the reservation still holds stock at the given time.
+func (r *Reservation) IsHeld(at time.Time) bool {
+	return r.Status == ReservationActive && r.ExpiresAt.After(at)
+}
+
+// ReservationFilter narrows a reservation query. Nil and zero-valued fields are ignored.
+type ReservationFilter struct {
+	ItemID      *uuid.UUID
+	WarehouseID *uuid.UUID
+	SourceType  string
+	SourceID    string
+	Status      ReservationStatus
+}
+
+// ReservationRepository defines the contract for stock reservation persistence.
+type ReservationRepository interface {
+	WithTx(tx *gorm.DB) ReservationRepository
+	Create(ctx context.Context, reservation *Reservation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Reservation, error)
+	// GetByIDForUpdate locks the reservation so that release, consumption and expiry are serialized.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*Reservation, error)
+	// FindActive returns the active reservation of a source document for a location, or ErrReservationNotFound.
+	FindActive(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string) (*Reservation, error)
+	List(ctx context.Context, filter ReservationFilter) ([]*Reservation, error)
+	// Update persists the consumed quantity, status and expiry of the reservation.
+	Update(ctx context.Context, reservation *Reservation) error
+	// ReservedQuantity sums the open quantity of the reservations of a location that are held at asOf.
+	ReservedQuantity(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, asOf time.Time) (float64, error)
+	// ExpireDue marks the active reservations that expired at or before asOf and returns how many were changed.
+	ExpireDue(ctx context.Context, asOf time.Time) (int64, error)
+}
@@ -4,6 +4,7 @@ package stock
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -53,32 +54,60 @@ func (b *Bin) SetUpdatedBy(userID uuid.UUID) {
 type MovementType string
 
 const (
-	MovementTypeIn  MovementType = "IN"
-	MovementTypeOut MovementType = "OUT"
+	MovementTypeIn     MovementType = "IN"
+	MovementTypeOut    MovementType = "OUT"
+	MovementTypeAdjIn  MovementType = "ADJ_IN"  // Positive inventory count variance
+	MovementTypeAdjOut MovementType = "ADJ_OUT" // Negative inventory count variance
 )
 
+// IsInbound reports whether the movement type increases the quantity on hand.
+func (t MovementType) IsInbound() bool {
+	return t == MovementTypeIn || t == MovementTypeAdjIn
+}
+
 // Stock represents the quantity of a specific item in a specific location.
+// Quantity is the quantity on hand; Reserved is only filled by availability queries.
 type Stock struct {
     ItemID      uuid.UUID
     WarehouseID uuid.UUID
     BinID       *uuid.UUID // Optional
     Quantity    float64
+    Reserved    float64 // Open quantity of the active reservations of the location
     UpdatedAt   time.Time
 }
 
+// Available returns the quantity on hand that is not held by reservations.
+func (s *Stock) Available() float64 {
+    return s.Quantity - s.Reserved
+}
+
+
+var (
+	// ErrMovementReversed is returned when a stock movement that was reversed is reversed again.
+	ErrMovementReversed = errors.New("stock movement has already been reversed")
+	// ErrMovementNotReversible is returned when a movement belongs to a transfer or a production
+	// run, whose movements are only reversed together.
+	ErrMovementNotReversible = errors.New("stock movement cannot be reversed on its own")
+)
 
 // StockMovement represents the record of an item moving into or out of a stock location.
 // This is the primary entity for transactional stock operations.
 type StockMovement struct {
-	ID          uuid.UUID
-	ItemID      uuid.UUID
-	WarehouseID uuid.UUID
-	BinID       *uuid.UUID // Optional, if bin tracking is used
-	Type        MovementType
-	Quantity    float64
-	Reason      string
-	HappenedAt  time.Time
-	CreatedBy   uuid.UUID
+	ID                 uuid.UUID
+	ItemID             uuid.UUID
+	WarehouseID        uuid.UUID
+	BinID              *uuid.UUID // Optional, if bin tracking is used
+	Type               MovementType
+	Quantity           float64
+	Reason             string
+	TransferID         *uuid.UUID    // Set on both legs of an inter-location transfer
+	ProductionRecordID *uuid.UUID    // Set on the movements of an instant production run, its reversal and disassemblies
+	Lots               []LotQuantity // Lot or serial breakdown of Quantity for tracked items
+	UnitCost           float64       // Cost per unit the movement was valued at, per the item's costing method
+	CostVariance       float64       // Purchase or production variance of a standard cost receipt, zero otherwise
+	HappenedAt         time.Time
+	ReversedAt         *time.Time // Set once the movement is reversed
+	CreatedBy          uuid.UUID
 }
 // Note: StockMovement is not fully auditable in the sense of CreatedAt/UpdatedAt,
 // as it's a point-in-time record. It only has CreatedBy.
@@ -100,12 +129,23 @@ type StockLedger struct {
 	QuantityChange  float64
 	QuantityBefore  float64
 	QuantityAfter   float64
+	UnitCost        float64
+	CostVariance    float64
 	Reason          string
+	TransferID      *uuid.UUID
 	HappenedAt      time.Time
 	RecordedAt      time.Time
 	RecordedBy      uuid.UUID
 }
 
+// StockTransfer groups the paired OUT and IN movements that move an item
+// between two locations. Both movements and their ledger entries share the same ID.
+type StockTransfer struct {
+	ID          uuid.UUID
+	OutMovement *StockMovement
+	InMovement  *StockMovement
+}
+
 
 // WarehouseRepository defines the contract for warehouse data persistence.
 type WarehouseRepository interface {
@@ -133,12 +173,42 @@ type StockMovementRepository interface {
 	WithTx(tx *gorm.DB) StockMovementRepository
 	Create(ctx context.Context, movement *StockMovement) error
 	GetByID(ctx context.Context, id uuid.UUID) (*StockMovement, error)
+	// GetByIDForUpdate returns a movement and locks it until the end of the transaction.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*StockMovement, error)
+	// MarkReversed stores the ReversedAt of a movement, the only field that changes after its creation.
+	MarkReversed(ctx context.Context, movement *StockMovement) error
+	// ListByProductionRecord returns the movements of a production record, oldest first.
+	ListByProductionRecord(ctx context.Context, recordID uuid.UUID) ([]*StockMovement, error)
+}
+
+// LedgerFilter narrows a stock ledger query. Nil and zero-valued fields are ignored.
+// From and To are inclusive bounds on HappenedAt.
+type LedgerFilter struct {
+	ItemID       *uuid.UUID
+	WarehouseID  *uuid.UUID
+	BinID        *uuid.UUID
+	MovementType MovementType
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
 }
 
-// StockLedgerRepository defines the contract for creating immutable ledger entries.
+// StockLedgerRepository defines the contract for creating and reading immutable ledger entries.
 type StockLedgerRepository interface {
 	WithTx(tx *gorm.DB) StockLedgerRepository
 	Create(ctx context.Context, entry *StockLedger) error
+	// List returns one page of entries in chronological order together with
+	// the total number of entries matching the filter.
+	List(ctx context.Context, filter LedgerFilter) ([]*StockLedger, int64, error)
+	// ListBalancesAsOf rebuilds the quantity of every location from the last
+	// ledger entry that happened at or before asOf. Only the item, warehouse
+	// and bin fields of the filter are applied.
+	ListBalancesAsOf(ctx context.Context, asOf time.Time, filter LedgerFilter) ([]*Stock, error)
+	// ListItemValuesAsOf sums, per item, the signed quantity and value (quantity times
+	// unit cost) of the ledger entries that happened at or before asOf. Only the item
+	// field of the filter is applied.
+	ListItemValuesAsOf(ctx context.Context, asOf time.Time, filter LedgerFilter) ([]*ItemValue, error)
 }
 
 // StockRepository defines the contract for stock-related queries and updates, including pessimistic locking.
@@ -147,5 +217,6 @@ type StockRepository interface {
 	GetStock(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*Stock, error)
 	GetStockForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*Stock, error)
 	GetTotalQuantity(ctx context.Context, itemID uuid.UUID) (float64, error)
+	ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*Stock, error)
 	UpsertStock(ctx context.Context, stock *Stock) error
 }
@@ -0,0 +1,67 @@
+package stock
+
+import (
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"github.com/google/uuid"
+)
+
+// ItemValue is the quantity and inventory value of an item summed over all of its locations.
+type ItemValue struct {
+	ItemID   uuid.UUID
+	Quantity float64
+	Value    float64
+}
+
+// ValuationGroup is a dimension the lines of an inventory valuation can be totalled by.
+type ValuationGroup string
+
+const (
+	GroupByWarehouse ValuationGroup = "warehouse"
+	GroupByBin       ValuationGroup = "bin"
+	GroupByItemType  ValuationGroup = "item_type"
+)
+
+// ValuationQuery selects the stock an inventory valuation is computed for.
+type ValuationQuery struct {
+	AsOf     *time.Time       // Nil values the stock on hand now
+	Filter   LedgerFilter     // Only the item, warehouse and bin fields are applied
+	ItemType item.ItemType    // Empty for all item types
+	GroupBy  []ValuationGroup // Dimensions of the totals, in order; empty for no totals
+}
+
+// ValuationLine is the value of the quantity of one item held in one location.
+type ValuationLine struct {
+	ItemID        uuid.UUID
+	ItemName      string
+	ItemType      item.ItemType
+	WarehouseID   uuid.UUID
+	WarehouseName string
+	BinID         *uuid.UUID
+	BinName       string
+	Quantity      float64
+	UnitCost      float64
+	Value         float64
+}
+
+// ValuationTotal is the value of the lines sharing the same grouped dimensions.
+// Dimensions the report is not grouped by are left empty.
+type ValuationTotal struct {
+	WarehouseID   *uuid.UUID
+	WarehouseName string
+	BinID         *uuid.UUID
+	BinName       string
+	ItemType      item.ItemType
+	Lines         int
+	Value         float64
+}
+
+// ValuationReport is the inventory valuation at a point in time.
+type ValuationReport struct {
+	AsOf       time.Time
+	GroupBy    []ValuationGroup
+	Lines      []*ValuationLine
+	Totals     []*ValuationTotal
+	TotalValue float64
+}
@@ -17,18 +17,22 @@ const (
 	Supplier ThirdPartyType = "SUPPLIER"
 )
 
+// DefaultPaymentTermDays is the payment term of a third party created without one.
+const DefaultPaymentTermDays = 30
+
 // ThirdParty represents the core entity for a customer or a supplier.
 // It is a pure domain model with no infrastructure-specific details.
 type ThirdParty struct {
-	ID        uuid.UUID
-	Name      string
-	Email     string
-	Type      ThirdPartyType
-	IsActive  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	CreatedBy uuid.UUID
-	UpdatedBy uuid.UUID
+	ID              uuid.UUID
+	Name            string
+	Email           string
+	Type            ThirdPartyType
+	IsActive        bool
+	PaymentTermDays int // Days after the invoice date within which its invoices are due
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	CreatedBy       uuid.UUID
+	UpdatedBy       uuid.UUID
 }
 
 // SetCreatedBy sets the ID of the user who created the entity.
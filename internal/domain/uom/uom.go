@@ -0,0 +1,89 @@
+// Package uom defines the units of measure, grouped in categories of units that convert into
+// each other, and the repository contract for their persistence.
+package uom
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCategoryNotFound is returned when a unit category does not exist.
+	ErrCategoryNotFound = errors.New("unit of measure category not found")
+	// ErrUnitNotFound is returned when no unit of measure has the given code.
+	ErrUnitNotFound = errors.New("unit of measure not found")
+	// ErrUnitExists is returned when a unit is created with the code of an existing unit.
+	ErrUnitExists = errors.New("a unit of measure with this code already exists")
+	// ErrIncompatibleUnits is returned when a quantity is converted between units of different categories.
+	ErrIncompatibleUnits = errors.New("units of measure belong to different categories")
+	// ErrInvalidFactor is returned when a unit is defined with a factor that is not positive.
+	ErrInvalidFactor = errors.New("conversion factor must be greater than zero")
+)
+
+// Category groups the units that measure the same dimension, e.g. mass or length.
+type Category struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy uuid.UUID
+	UpdatedBy uuid.UUID
+}
+
+func (c *Category) SetCreatedBy(userID uuid.UUID) {
+	c.CreatedBy = userID
+}
+
+func (c *Category) SetUpdatedBy(userID uuid.UUID) {
+	c.UpdatedAt = time.Now()
+	c.UpdatedBy = userID
+}
+
+// Unit is a unit of measure, identified by its code (e.g. "kg"). Factor is the number of
+// reference units of its category in one unit: with the kilogram as the reference unit of
+// mass, the gram has a factor of 0.001.
+type Unit struct {
+	ID         uuid.UUID
+	CategoryID uuid.UUID
+	Code       string
+	Name       string
+	Factor     float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	CreatedBy  uuid.UUID
+	UpdatedBy  uuid.UUID
+}
+
+func (u *Unit) SetCreatedBy(userID uuid.UUID) {
+	u.CreatedBy = userID
+}
+
+func (u *Unit) SetUpdatedBy(userID uuid.UUID) {
+	u.UpdatedAt = time.Now()
+	u.UpdatedBy = userID
+}
+
+// ConversionFactor returns the number of to units in one from unit.
+func ConversionFactor(from, to *Unit) (float64, error) {
+	if from.CategoryID != to.CategoryID {
+		return 0, ErrIncompatibleUnits
+	}
+	return from.Factor / to.Factor, nil
+}
+
+// Repository defines the contract for unit of measure persistence.
+type Repository interface {
+	WithTx(tx *gorm.DB) Repository
+	CreateCategory(ctx context.Context, category *Category) error
+	GetCategory(ctx context.Context, id uuid.UUID) (*Category, error)
+	ListCategories(ctx context.Context) ([]*Category, error)
+	CreateUnit(ctx context.Context, unit *Unit) error
+	// GetUnitByCode returns the unit with the code, or ErrUnitNotFound.
+	GetUnitByCode(ctx context.Context, code string) (*Unit, error)
+	// ListUnits returns the units by code, restricted to a category if categoryID is set.
+	ListUnits(ctx context.Context, categoryID *uuid.UUID) ([]*Unit, error)
+}
@@ -17,6 +17,7 @@ type Config struct {
 	JWT            AuthConfig     `mapstructure:",squash"`
 	RateLimit      RateLimitConfig `mapstructure:",squash"`
 	Security       SecurityConfig  `mapstructure:",squash"`
+	Stock          StockConfig     `mapstructure:",squash"`
 	PDFStoragePath string          `mapstructure:"PDF_STORAGE_PATH"`
 }
 
@@ -59,6 +60,13 @@ type InternalWorkerConfig struct {
 	ShutdownTimeout time.Duration `mapstructure:"INTERNAL_WORKER_SHUTDOWN_TIMEOUT"`
 }
 
+// StockConfig holds stock reservation and replenishment configuration
+type StockConfig struct {
+	ReservationDefaultTTL     time.Duration `mapstructure:"RESERVATION_DEFAULT_TTL"`
+	ReservationExpiryInterval time.Duration `mapstructure:"RESERVATION_EXPIRY_INTERVAL"`
+	ReplenishmentInterval     time.Duration `mapstructure:"REPLENISHMENT_INTERVAL"` // 0 disables the scheduled run
+}
+
 // AuthConfig holds authentication related configuration
 type AuthConfig struct {
 	JWTSecret string `mapstructure:"JWT_SECRET"`
@@ -89,6 +97,9 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
 	viper.SetDefault("SECURITY_HEADERS_ENABLED", true)
 	viper.SetDefault("PDF_STORAGE_PATH", "storage/pdfs")
+	viper.SetDefault("RESERVATION_DEFAULT_TTL", 72 * time.Hour)
+	viper.SetDefault("RESERVATION_EXPIRY_INTERVAL", time.Minute)
+	viper.SetDefault("REPLENISHMENT_INTERVAL", time.Hour)
 
 
 	viper.AutomaticEnv() // Read from environment variables
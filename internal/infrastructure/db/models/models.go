@@ -78,6 +78,7 @@ type ThirdParty struct {
 	Email     string `gorm:"size:255;not null;uniqueIndex"`
 	Type      string `gorm:"size:50;not null"` // 'CUSTOMER' or 'SUPPLIER'
 	IsActive  bool   `gorm:"default:true"`
+	PaymentTermDays int `gorm:"not null;default:30"`
 	CreatedByUser User `gorm:"foreignKey:CreatedBy"`
 	UpdatedByUser User `gorm:"foreignKey:UpdatedBy"`
 }
@@ -91,11 +92,40 @@ type Item struct {
 	CostPrice   float64 `gorm:"type:numeric(15,4);default:0.0"`
 	SalePrice   float64 `gorm:"type:numeric(15,4);default:0.0"`
 	AverageCost float64 `gorm:"type:numeric(15,4);default:0.0"`
+	StandardCost float64 `gorm:"type:numeric(15,4);not null;default:0.0"`
+	CostingMethod string `gorm:"size:10;not null;default:'AVERAGE'"` // 'AVERAGE', 'FIFO' or 'STANDARD'
+	TrackingMode string `gorm:"size:10;not null;default:'NONE'"` // 'NONE', 'LOT' or 'SERIAL'
+	BaseUoM     *string `gorm:"column:base_uom;size:20"` // Code of the unit in which stock is kept
+	LeadTimeDays int    `gorm:"not null;default:0"` // Days from ordering or starting the item to its receipt
 	IsActive    bool    `gorm:"default:true"`
 	CreatedByUser User `gorm:"foreignKey:CreatedBy"`
 	UpdatedByUser User `gorm:"foreignKey:UpdatedBy"`
 }
 
+// UoMCategory model represents a group of units of measure that convert into each other.
+type UoMCategory struct {
+	BaseModel
+	Name string `gorm:"size:100;not null;uniqueIndex"`
+}
+
+func (UoMCategory) TableName() string {
+	return "uom_categories"
+}
+
+// UoMUnit model represents a unit of measure and its factor to the reference unit of its category.
+type UoMUnit struct {
+	BaseModel
+	CategoryID uuid.UUID   `gorm:"type:uuid;not null;index"`
+	Category   UoMCategory `gorm:"foreignKey:CategoryID"`
+	Code       string      `gorm:"size:20;not null;uniqueIndex"`
+	Name       string      `gorm:"size:100;not null"`
+	Factor     float64     `gorm:"type:numeric(20,10);not null"`
+}
+
+func (UoMUnit) TableName() string {
+	return "uom_units"
+}
+
 // Warehouse model represents the database schema for a stock warehouse.
 type Warehouse struct {
 	BaseModel
@@ -128,19 +158,95 @@ type Stock struct {
 
 // StockMovement model represents a record of stock moving in or out.
 type StockMovement struct {
-	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	ItemID      uuid.UUID  `gorm:"type:uuid;not null;index"`
-	WarehouseID uuid.UUID  `gorm:"type:uuid;not null;index"`
-	BinID       *uuid.UUID `gorm:"type:uuid;index"`
-	Type        string     `gorm:"size:10;not null"` // 'IN' or 'OUT'
-	Quantity    float64    `gorm:"type:numeric(15,4);not null"`
-	Reason      string     `gorm:"size:255"`
-	HappenedAt  time.Time  `gorm:"not null"`
-	CreatedBy   uuid.UUID  `gorm:"type:uuid"`
-	Item        Item       `gorm:"foreignKey:ItemID"`
-	Warehouse   Warehouse  `gorm:"foreignKey:WarehouseID"`
-	Bin         *Bin       `gorm:"foreignKey:BinID"`
-	CreatedByUser User     `gorm:"foreignKey:CreatedBy"`
+	ID                 uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ItemID             uuid.UUID          `gorm:"type:uuid;not null;index"`
+	WarehouseID        uuid.UUID          `gorm:"type:uuid;not null;index"`
+	BinID              *uuid.UUID         `gorm:"type:uuid;index"`
+	Type               string             `gorm:"size:10;not null"` // 'IN' or 'OUT'
+	Quantity           float64            `gorm:"type:numeric(15,4);not null"`
+	UnitCost           float64            `gorm:"type:numeric(15,4);not null;default:0.0"`
+	CostVariance       float64            `gorm:"type:numeric(15,4);not null;default:0.0"`
+	Reason             string             `gorm:"size:255"`
+	TransferID         *uuid.UUID         `gorm:"type:uuid;index"`
+	ProductionRecordID *uuid.UUID         `gorm:"type:uuid;index"`
+	HappenedAt         time.Time          `gorm:"not null"`
+	ReversedAt         *time.Time
+	CreatedBy          uuid.UUID          `gorm:"type:uuid"`
+	Item               Item               `gorm:"foreignKey:ItemID"`
+	Warehouse          Warehouse          `gorm:"foreignKey:WarehouseID"`
+	Bin                *Bin               `gorm:"foreignKey:BinID"`
+	CreatedByUser      User               `gorm:"foreignKey:CreatedBy"`
+	Lots               []StockMovementLot `gorm:"foreignKey:StockMovementID"`
+}
+
+// StockMovementLot model holds the lot or serial breakdown of a stock movement.
+type StockMovementLot struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	StockMovementID uuid.UUID `gorm:"type:uuid;not null;index"`
+	LotNumber       string    `gorm:"size:100;not null;index"`
+	Quantity        float64   `gorm:"type:numeric(15,4);not null"`
+}
+
+// StockCostLayer model is a FIFO cost layer opened by an inbound stock movement.
+type StockCostLayer struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ItemID            uuid.UUID `gorm:"type:uuid;not null;index"`
+	MovementID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	UnitCost          float64   `gorm:"type:numeric(15,4);not null"`
+	OriginalQuantity  float64   `gorm:"type:numeric(15,4);not null"`
+	RemainingQuantity float64   `gorm:"type:numeric(15,4);not null"`
+	ReceivedAt        time.Time `gorm:"not null"`
+}
+
+// StockCostLayerConsumption model is the quantity an outbound stock movement took from a cost layer.
+type StockCostLayerConsumption struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LayerID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	MovementID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Quantity   float64   `gorm:"type:numeric(15,4);not null"`
+	UnitCost   float64   `gorm:"type:numeric(15,4);not null"`
+}
+
+// StockMovementBatch model is a bulk import of stock movements.
+type StockMovementBatch struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IdempotencyKey *string   `gorm:"size:255;uniqueIndex"`
+	PayloadHash    string    `gorm:"size:64;not null"`
+	LineCount      int       `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null;default:now()"`
+	CreatedBy      uuid.UUID `gorm:"type:uuid"`
+}
+
+// StockMovementBatchLine model links a line of a bulk import to the movement it posted.
+type StockMovementBatchLine struct {
+	BatchID    uuid.UUID     `gorm:"type:uuid;primaryKey"`
+	Line       int           `gorm:"primaryKey"`
+	MovementID uuid.UUID     `gorm:"type:uuid;not null"`
+	Movement   StockMovement `gorm:"foreignKey:MovementID"`
+}
+
+// StockLot model is the quantity bucket of an item lot or serial number at a location.
+type StockLot struct {
+	ItemID      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	WarehouseID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BinID       uuid.UUID `gorm:"type:uuid;primaryKey;default:'00000000-0000-0000-0000-000000000000'"` // Use a zero UUID for non-binned stock
+	LotNumber   string    `gorm:"size:100;primaryKey"`
+	Quantity    float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
+	UpdatedAt   time.Time
+}
+
+// StockReservation model is a soft allocation of stock at a location to a source document.
+type StockReservation struct {
+	BaseModel
+	ItemID           uuid.UUID `gorm:"type:uuid;not null;index"`
+	WarehouseID      uuid.UUID `gorm:"type:uuid;not null"`
+	BinID            uuid.UUID `gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000000'"` // Use a zero UUID for non-binned stock
+	SourceType       string    `gorm:"size:50;not null"`
+	SourceID         string    `gorm:"size:100;not null"`
+	Quantity         float64   `gorm:"type:numeric(15,4);not null"`
+	ConsumedQuantity float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
+	Status           string    `gorm:"size:20;not null;index"`
+	ExpiresAt        time.Time `gorm:"not null"`
 }
 
 // StockLedger model is an immutable, append-only log of all stock transactions.
@@ -154,7 +260,10 @@ type StockLedger struct {
 	QuantityChange  float64       `gorm:"type:numeric(15,4);not null"`
 	QuantityBefore  float64       `gorm:"type:numeric(15,4);not null"`
 	QuantityAfter   float64       `gorm:"type:numeric(15,4);not null"`
+	UnitCost        float64       `gorm:"type:numeric(15,4);not null;default:0.0"`
+	CostVariance    float64       `gorm:"type:numeric(15,4);not null;default:0.0"`
 	Reason          string        `gorm:"size:255"`
+	TransferID      *uuid.UUID    `gorm:"type:uuid;index"`
 	HappenedAt      time.Time     `gorm:"not null"`
 	RecordedAt      time.Time     `gorm:"not null;default:now()"`
 	RecordedBy      uuid.UUID     `gorm:"type:uuid"`
@@ -169,14 +278,58 @@ func (StockLedger) TableName() string {
 	return "stock_ledger"
 }
 
+// StockCountSession model is the header of a physical inventory count.
+type StockCountSession struct {
+	BaseModel
+	WarehouseID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Warehouse   Warehouse `gorm:"foreignKey:WarehouseID"`
+	Status      string    `gorm:"size:20;not null;index"`
+	ApprovedAt  *time.Time
+	ApprovedBy  *uuid.UUID             `gorm:"type:uuid"`
+	Bins        []StockCountSessionBin `gorm:"foreignKey:SessionID"`
+	Lines       []StockCountLine       `gorm:"foreignKey:SessionID"`
+}
+
+// StockCountSessionBin model restricts a count session to a set of bins.
+type StockCountSessionBin struct {
+	SessionID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	BinID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+}
+
+// StockCountLine model holds the snapshotted expected quantity of an item in a bin.
+type StockCountLine struct {
+	ID               uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SessionID        uuid.UUID         `gorm:"type:uuid;not null;index"`
+	ItemID           uuid.UUID         `gorm:"type:uuid;not null"`
+	BinID            uuid.UUID         `gorm:"type:uuid;not null"`
+	ExpectedQuantity float64           `gorm:"type:numeric(15,4);not null"`
+	Entries          []StockCountEntry `gorm:"foreignKey:LineID"`
+}
+
+// StockCountEntry model is an append-only count submitted by a device.
+type StockCountEntry struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LineID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	DeviceID  string    `gorm:"size:100;not null"`
+	Quantity  float64   `gorm:"type:numeric(15,4);not null"`
+	CountedAt time.Time `gorm:"not null"`
+	CountedBy uuid.UUID `gorm:"type:uuid"`
+}
+
 // BillOfMaterials model represents the definition of how to produce a finished item.
 type BillOfMaterials struct {
 	BaseModel
-	ProductID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"` // The finished item produced by this BOM
+	ProductID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bill_of_materials_product_revision"` // The finished item produced by this BOM
 	Product   Item      `gorm:"foreignKey:ProductID"`
 	Name      string    `gorm:"size:255;not null"`
 	IsActive  bool      `gorm:"default:true"`
+	Revision  int       `gorm:"not null;default:1;uniqueIndex:idx_bill_of_materials_product_revision"`
+	Status    string    `gorm:"size:20;not null;default:'DRAFT'"` // 'DRAFT', 'APPROVED' or 'OBSOLETE'
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+	YieldPercent  float64   `gorm:"type:numeric(5,2);not null;default:100"`
 	Components []BillOfMaterialsComponent `gorm:"foreignKey:BillOfMaterialsID"`
+	Operations []RoutingOperation         `gorm:"foreignKey:BillOfMaterialsID"`
 }
 
 // BillOfMaterialsComponent model represents a single ingredient (item or service) in a BOM.
@@ -187,8 +340,42 @@ type BillOfMaterialsComponent struct {
 	ComponentItemID   uuid.UUID `gorm:"type:uuid;not null"` // The item (input or service) that is a component
 	ComponentItem     Item      `gorm:"foreignKey:ComponentItemID"`
 	Quantity          float64   `gorm:"type:numeric(15,4);not null"`
+	ScrapPercent      float64   `gorm:"type:numeric(5,2);not null;default:0"`
 	UnitOfMeasure     string    `gorm:"size:50;not null"` // e.g., "kg", "pcs", "hours"
 	IsActive          bool      `gorm:"default:true"`
+	Alternates        []BillOfMaterialsComponentAlternate `gorm:"foreignKey:ComponentID"`
+}
+
+// BillOfMaterialsComponentAlternate model is an approved substitute of a BOM component.
+type BillOfMaterialsComponentAlternate struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ComponentID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bom_component_alternates_priority"`
+	ItemID          uuid.UUID `gorm:"type:uuid;not null"`
+	Item            Item      `gorm:"foreignKey:ItemID"`
+	Priority        int       `gorm:"not null;uniqueIndex:idx_bom_component_alternates_priority"`
+	ConversionRatio float64   `gorm:"type:numeric(15,6);not null;default:1"` // Base units of the alternate per base unit of the component
+}
+
+// WorkCenter model represents a place of production and its hourly cost rates.
+type WorkCenter struct {
+	BaseModel
+	Code         string  `gorm:"size:50;not null;uniqueIndex"`
+	Name         string  `gorm:"size:255;not null"`
+	LaborRate    float64 `gorm:"type:numeric(15,4);not null;default:0"`
+	OverheadRate float64 `gorm:"type:numeric(15,4);not null;default:0"`
+	IsActive     bool    `gorm:"default:true"`
+}
+
+// RoutingOperation model represents an operation of the routing of a BOM revision.
+type RoutingOperation struct {
+	BaseModel
+	BillOfMaterialsID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_routing_operations_bom_sequence"`
+	Sequence          int        `gorm:"not null;uniqueIndex:idx_routing_operations_bom_sequence"`
+	Name              string     `gorm:"size:255;not null"`
+	WorkCenterID      uuid.UUID  `gorm:"type:uuid;not null"`
+	WorkCenter        WorkCenter `gorm:"foreignKey:WorkCenterID"`
+	SetupMinutes      float64    `gorm:"type:numeric(15,4);not null;default:0"`
+	RunMinutes        float64    `gorm:"type:numeric(15,4);not null;default:0"` // Per unit of product
 }
 
 // ProductionRecord model represents a completed production run based on a BOM.
@@ -196,15 +383,182 @@ type ProductionRecord struct {
 	ID                    uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	BillOfMaterialsID     uuid.UUID `gorm:"type:uuid;not null"`
 	BillOfMaterials       BillOfMaterials `gorm:"foreignKey:BillOfMaterialsID"`
+	ManufacturingOrderID  *uuid.UUID `gorm:"type:uuid;uniqueIndex"` // Nil for an instant production run
 	ProducedProductID     uuid.UUID `gorm:"type:uuid;not null"` // The finished product item ID
 	ProducedProduct       Item      `gorm:"foreignKey:ProducedProductID"`
 	ProductionQuantity    float64   `gorm:"type:numeric(15,4);not null"`
+	ScrapQuantity         float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
 	ActualProductionCost  float64   `gorm:"type:numeric(15,4);not null"`
+	LaborCost             float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
+	OverheadCost          float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
+	YieldVariance         float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
+	DisassembledQuantity  float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
 	WarehouseID           uuid.UUID `gorm:"type:uuid;not null"`
 	Warehouse             Warehouse `gorm:"foreignKey:WarehouseID"`
 	ProducedAt            time.Time `gorm:"not null"`
 	CreatedBy             uuid.UUID `gorm:"type:uuid"` // Who initiated the production
 	CreatedByUser         User      `gorm:"foreignKey:CreatedBy"`
+	ReversedAt            *time.Time
+	ReversedBy            *uuid.UUID `gorm:"type:uuid"`
+	ReversalReason        string     `gorm:"size:255"`
+	Lots                  []ProductionLot `gorm:"foreignKey:ProductionRecordID"`
+	Substitutions         []ProductionSubstitution `gorm:"foreignKey:ProductionRecordID"`
+}
+
+// ProductionLot model links a production run to the lots it consumed and produced.
+type ProductionLot struct {
+	ID                 uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductionRecordID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ItemID             uuid.UUID `gorm:"type:uuid;not null"`
+	LotNumber          string    `gorm:"size:100;not null"`
+	Quantity           float64   `gorm:"type:numeric(15,4);not null"`
+	Role               string    `gorm:"size:10;not null"` // 'CONSUMED' or 'PRODUCED'
+}
+
+// ProductionSubstitution model records an alternate consumed in place of a component line by a production run.
+type ProductionSubstitution struct {
+	ID                 uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductionRecordID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ComponentID        uuid.UUID `gorm:"type:uuid;not null"` // The BOM component line replaced
+	ComponentItemID    uuid.UUID `gorm:"type:uuid;not null"`
+	SubstituteItemID   uuid.UUID `gorm:"type:uuid;not null"`
+	Share              float64   `gorm:"type:numeric(9,8);not null"`
+	Quantity           float64   `gorm:"type:numeric(15,4);not null"`
+	SubstituteQuantity float64   `gorm:"type:numeric(15,4);not null"`
+	Chosen             bool      `gorm:"not null;default:false"`
+}
+
+// ManufacturingOrder model is a production order of a product, run over time.
+type ManufacturingOrder struct {
+	BaseModel
+	ProductID          uuid.UUID  `gorm:"type:uuid;not null;index"`
+	BillOfMaterialsID  uuid.UUID  `gorm:"type:uuid;not null"`
+	WarehouseID        uuid.UUID  `gorm:"type:uuid;not null"`
+	Quantity           float64    `gorm:"type:numeric(15,4);not null"`
+	ProducedQuantity   float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	ScrapQuantity      float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	Status             string     `gorm:"size:20;not null;index"` // 'PLANNED', 'RELEASED', 'IN_PROGRESS', 'COMPLETED' or 'CANCELLED'
+	PlannedStart       *time.Time
+	PlannedEnd         *time.Time
+	ReleasedAt         *time.Time
+	StartedAt          *time.Time
+	CompletedAt        *time.Time
+	ActualCost         float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	ReceivedCost       float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	ProductionRecordID *uuid.UUID `gorm:"type:uuid"`
+	Components         []ManufacturingOrderComponent `gorm:"foreignKey:OrderID"`
+	Lots               []ManufacturingOrderLot       `gorm:"foreignKey:OrderID"`
+}
+
+// ManufacturingOrderComponent model is the requirement of a manufacturing order for one component item.
+type ManufacturingOrderComponent struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID          uuid.UUID  `gorm:"type:uuid;not null;index"`
+	ItemID           uuid.UUID  `gorm:"type:uuid;not null"`
+	QuantityPer      float64    `gorm:"type:numeric(15,4);not null"`
+	RequiredQuantity float64    `gorm:"type:numeric(15,4);not null"`
+	IssuedQuantity   float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	IssuedCost       float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	ScrapQuantity    float64    `gorm:"type:numeric(15,4);not null;default:0.0"`
+	UnitOfMeasure    string     `gorm:"size:50"`
+	ReservationID    *uuid.UUID `gorm:"type:uuid"`
+}
+
+// ManufacturingOrderLot model links a manufacturing order to the lots it consumed and produced.
+type ManufacturingOrderLot struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	ItemID    uuid.UUID `gorm:"type:uuid;not null"`
+	LotNumber string    `gorm:"size:100;not null"`
+	Quantity  float64   `gorm:"type:numeric(15,4);not null"`
+	Role      string    `gorm:"size:10;not null"` // 'CONSUMED' or 'PRODUCED'
+}
+
+// ReorderRule model holds the min/max and reorder-point settings of an item in a warehouse.
+type ReorderRule struct {
+	BaseModel
+	ItemID       uuid.UUID `gorm:"type:uuid;not null"`
+	WarehouseID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	MinQuantity  float64   `gorm:"type:numeric(15,4);not null;default:0.0"`
+	ReorderPoint float64   `gorm:"type:numeric(15,4);not null"`
+	MaxQuantity  float64   `gorm:"type:numeric(15,4);not null"`
+	IsActive     bool      `gorm:"default:true"`
+}
+
+// ReplenishmentProposal model is a purchase or production suggestion of the latest replenishment run.
+type ReplenishmentProposal struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RunID           uuid.UUID  `gorm:"type:uuid;not null;index"`
+	RuleID          uuid.UUID  `gorm:"type:uuid;not null"`
+	ItemID          uuid.UUID  `gorm:"type:uuid;not null;index"`
+	WarehouseID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Type            string     `gorm:"size:20;not null"` // 'PURCHASE' or 'PRODUCTION'
+	BOMID           *uuid.UUID `gorm:"column:bom_id;type:uuid"`
+	Quantity        float64    `gorm:"type:numeric(15,4);not null"`
+	OnHand          float64    `gorm:"type:numeric(15,4);not null"`
+	Reserved        float64    `gorm:"type:numeric(15,4);not null"`
+	ComponentDemand float64    `gorm:"type:numeric(15,4);not null"`
+	Projected       float64    `gorm:"type:numeric(15,4);not null"`
+	ReorderPoint    float64    `gorm:"type:numeric(15,4);not null"`
+	MinQuantity     float64    `gorm:"type:numeric(15,4);not null"`
+	MaxQuantity     float64    `gorm:"type:numeric(15,4);not null"`
+	GeneratedAt     time.Time  `gorm:"not null"`
+}
+
+// MrpForecast model is a demand expected by a planner for an item in a warehouse on a date.
+type MrpForecast struct {
+	BaseModel
+	ItemID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	WarehouseID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Quantity    float64   `gorm:"type:numeric(15,4);not null"`
+	DueDate     time.Time `gorm:"type:date;not null"`
+	Notes       string    `gorm:"type:text"`
+}
+
+// MrpRun model is a material requirements planning run of a warehouse, executed on the worker pool.
+type MrpRun struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WarehouseID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	HorizonEnd        time.Time  `gorm:"type:date;not null"`
+	IncludeInvoices   bool       `gorm:"not null;default:false"`
+	Status            string     `gorm:"size:20;not null"` // 'QUEUED', 'RUNNING', 'COMPLETED' or 'FAILED'
+	ErrorMessage      string     `gorm:"type:text"`
+	PlannedOrderCount int        `gorm:"not null;default:0"`
+	StartedAt         *time.Time
+	CompletedAt       *time.Time
+	CreatedAt         time.Time  `gorm:"not null"`
+	CreatedBy         uuid.UUID  `gorm:"type:uuid"`
+}
+
+// MrpPlannedOrder model is a time-phased purchase or production order planned by an MRP run.
+type MrpPlannedOrder struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RunID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	ItemID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	WarehouseID uuid.UUID  `gorm:"type:uuid;not null"`
+	Type        string     `gorm:"size:20;not null"` // 'PURCHASE' or 'PRODUCTION'
+	BOMID       *uuid.UUID `gorm:"column:bom_id;type:uuid"`
+	Level       int        `gorm:"not null"`
+	Quantity    float64    `gorm:"type:numeric(15,4);not null"`
+	StartDate   time.Time  `gorm:"type:date;not null"`
+	DueDate     time.Time  `gorm:"type:date;not null"`
+	PastDue     bool       `gorm:"not null;default:false"`
+}
+
+// NumberingSequence model is the numbering pattern of a document type.
+type NumberingSequence struct {
+	BaseModel
+	DocumentType string `gorm:"size:30;not null;uniqueIndex"`
+	Pattern      string `gorm:"size:100;not null"`
+	Reset        string `gorm:"column:reset_policy;size:20;not null"` // 'NEVER' or 'YEARLY'
+}
+
+// NumberingCounter model is the last value given by a sequence in a period.
+type NumberingCounter struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SequenceID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_numbering_counters_period"`
+	Period     string    `gorm:"size:10;not null;uniqueIndex:idx_numbering_counters_period"`
+	LastValue  int64     `gorm:"not null;default:0"`
 }
 
 // Invoice model represents the database schema for a sales or purchase invoice.
@@ -212,8 +566,23 @@ type Invoice struct {
 	BaseModel
 	ThirdPartyID uuid.UUID  `gorm:"type:uuid;not null;index"`
 	ThirdParty   ThirdParty `gorm:"foreignKey:ThirdPartyID"`
+	DocumentType string     `gorm:"size:20;not null;default:'STANDARD'"` // 'STANDARD' or 'CREDIT_NOTE'
+	OriginalInvoiceID *uuid.UUID `gorm:"type:uuid;index"` // Invoice credited by a credit note
+	OriginalInvoice   *Invoice   `gorm:"foreignKey:OriginalInvoiceID"`
+	ReturnWarehouseID *uuid.UUID `gorm:"type:uuid"`
+	ReturnBinID       *uuid.UUID `gorm:"type:uuid"`
 	Number       string     `gorm:"size:100;not null;uniqueIndex"`
 	Date         time.Time  `gorm:"not null"`
+	PaymentTermDays int     `gorm:"not null;default:30"`
+	DueDate      time.Time  `gorm:"type:date;not null;index"`
+	Status       string     `gorm:"size:20;not null;default:'DRAFT';index"` // 'DRAFT', 'VALIDATED', 'SENT', 'PARTIALLY_PAID', 'PAID' or 'CANCELLED'
+	AmountPaid   float64    `gorm:"type:numeric(15,4);not null;default:0"`
+	AmountCredited float64  `gorm:"type:numeric(15,4);not null;default:0"`
+	ValidatedAt  *time.Time
+	ValidatedBy  *uuid.UUID `gorm:"type:uuid"`
+	SentAt       *time.Time
+	CancelledAt  *time.Time
+	CancellationReason string `gorm:"size:255"`
 	TotalAmount  float64    `gorm:"type:numeric(15,4);not null"`
 	TotalCost    float64    `gorm:"type:numeric(15,4);not null"`
 	TotalTax     float64    `gorm:"type:numeric(15,4);not null;default:0"`
@@ -223,21 +592,47 @@ type Invoice struct {
 	Lines        []InvoiceLine `gorm:"foreignKey:InvoiceID"`
 }
 
+// Payment model is an amount received from a customer; UnallocatedAmount is the part not
+// allocated to invoices yet, a credit of the customer.
+type Payment struct {
+	BaseModel
+	ThirdPartyID      uuid.UUID           `gorm:"type:uuid;not null;index"`
+	Date              time.Time           `gorm:"not null"`
+	Amount            float64             `gorm:"type:numeric(15,4);not null"`
+	Method            string              `gorm:"size:20;not null"` // 'CASH', 'BANK_TRANSFER', 'CARD', 'CHECK' or 'OTHER'
+	Reference         string              `gorm:"size:100"`
+	UnallocatedAmount float64             `gorm:"type:numeric(15,4);not null;default:0"`
+	Allocations       []PaymentAllocation `gorm:"foreignKey:PaymentID"`
+}
+
+// PaymentAllocation model is the part of a payment settling an invoice.
+type PaymentAllocation struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PaymentID uuid.UUID `gorm:"type:uuid;not null;index"`
+	InvoiceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Amount    float64   `gorm:"type:numeric(15,4);not null"`
+	CreatedAt time.Time
+	CreatedBy uuid.UUID `gorm:"type:uuid"`
+}
+
 // InvoiceLine model represents a single line item within an invoice.
 type InvoiceLine struct {
 	BaseModel
-	InvoiceID   uuid.UUID `gorm:"type:uuid;not null;index"`
-	Invoice     Invoice   `gorm:"foreignKey:InvoiceID"`
-	ItemID      uuid.UUID `gorm:"type:uuid;not null;index"`
-	Item        Item      `gorm:"foreignKey:ItemID"`
-	Description string    `gorm:"size:255;not null"`
-	Quantity    float64   `gorm:"type:numeric(15,4);not null"`
-	UnitPrice   float64   `gorm:"type:numeric(15,4);not null"`
-	UnitCost    float64   `gorm:"type:numeric(15,4);not null"`
-	TaxRate     float64   `gorm:"type:numeric(15,4);not null;default:0"`
-	TaxAmount   float64   `gorm:"type:numeric(15,4);not null;default:0"`
-	NetPrice    float64   `gorm:"type:numeric(15,4);not null;default:0"`
-	TotalAmount float64   `gorm:"type:numeric(15,4);not null"`
-	TotalCost   float64   `gorm:"type:numeric(15,4);not null"`
+	InvoiceID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Invoice       Invoice   `gorm:"foreignKey:InvoiceID"`
+	OriginalLineID *uuid.UUID `gorm:"type:uuid;index"` // Invoice line credited by a credit note line
+	ItemID        uuid.UUID `gorm:"type:uuid;not null;index"`
+	Item          Item      `gorm:"foreignKey:ItemID"`
+	Description   string    `gorm:"size:255;not null"`
+	Quantity      float64   `gorm:"type:numeric(15,4);not null"`
+	UnitOfMeasure string    `gorm:"size:20"`
+	BaseQuantity  float64   `gorm:"type:numeric(15,4)"` // Quantity in the base unit of the item
+	UnitPrice     float64   `gorm:"type:numeric(15,4);not null"`
+	UnitCost      float64   `gorm:"type:numeric(15,4);not null"`
+	TaxRate       float64   `gorm:"type:numeric(15,4);not null;default:0"`
+	TaxAmount     float64   `gorm:"type:numeric(15,4);not null;default:0"`
+	NetPrice      float64   `gorm:"type:numeric(15,4);not null;default:0"`
+	TotalAmount   float64   `gorm:"type:numeric(15,4);not null"`
+	TotalCost     float64   `gorm:"type:numeric(15,4);not null"`
 }
 
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_stock_ledger_transfer_id;
+DROP INDEX IF EXISTS idx_stock_movements_transfer_id;
+ALTER TABLE stock_ledger DROP COLUMN transfer_id;
+ALTER TABLE stock_movements DROP COLUMN transfer_id;
@@ -0,0 +1,4 @@
+ALTER TABLE stock_movements ADD COLUMN transfer_id UUID;
+ALTER TABLE stock_ledger ADD COLUMN transfer_id UUID;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_transfer_id ON stock_movements(transfer_id);
+CREATE INDEX IF NOT EXISTS idx_stock_ledger_transfer_id ON stock_ledger(transfer_id);
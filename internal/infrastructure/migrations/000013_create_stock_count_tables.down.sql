@@ -0,0 +1,6 @@
+-- 000013_create_stock_count_tables.down.sql
+
+DROP TABLE IF EXISTS stock_count_entries;
+DROP TABLE IF EXISTS stock_count_lines;
+DROP TABLE IF EXISTS stock_count_session_bins;
+DROP TABLE IF EXISTS stock_count_sessions;
@@ -0,0 +1,50 @@
+-- 000013_create_stock_count_tables.up.sql
+-- This script creates the tables for physical inventory count sessions.
+
+-- Count sessions snapshot the expected stock of a warehouse or a set of its bins
+CREATE TABLE IF NOT EXISTS stock_count_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN', -- 'OPEN', 'APPROVED' or 'CANCELLED'
+    approved_at TIMESTAMP WITH TIME ZONE,
+    approved_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_count_sessions_warehouse_id ON stock_count_sessions(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_stock_count_sessions_status ON stock_count_sessions(status);
+
+
+-- Bin scope of a session; no rows means the whole warehouse is counted
+CREATE TABLE IF NOT EXISTS stock_count_session_bins (
+    session_id UUID NOT NULL REFERENCES stock_count_sessions(id) ON DELETE CASCADE,
+    bin_id UUID NOT NULL REFERENCES bins(id) ON DELETE RESTRICT,
+    PRIMARY KEY (session_id, bin_id)
+);
+
+
+-- One line per item and bin, holding the snapshotted expected quantity
+CREATE TABLE IF NOT EXISTS stock_count_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES stock_count_sessions(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    bin_id UUID NOT NULL REFERENCES bins(id) ON DELETE RESTRICT,
+    expected_quantity NUMERIC(15, 4) NOT NULL,
+    UNIQUE(session_id, item_id, bin_id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_count_lines_session_id ON stock_count_lines(session_id);
+
+
+-- Append-only counts submitted by counting devices
+CREATE TABLE IF NOT EXISTS stock_count_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    line_id UUID NOT NULL REFERENCES stock_count_lines(id) ON DELETE CASCADE,
+    device_id VARCHAR(100) NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL,
+    counted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    counted_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_count_entries_line_id ON stock_count_entries(line_id);
@@ -0,0 +1,6 @@
+-- 000014_add_lot_tracking.down.sql
+
+DROP TABLE IF EXISTS production_lots;
+DROP TABLE IF EXISTS stock_movement_lots;
+DROP TABLE IF EXISTS stock_lots;
+ALTER TABLE items DROP COLUMN IF EXISTS tracking_mode;
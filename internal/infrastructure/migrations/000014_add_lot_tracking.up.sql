@@ -0,0 +1,42 @@
+-- 000014_add_lot_tracking.up.sql
+-- This script adds lot / serial number tracking for storable items.
+
+-- 'NONE', 'LOT' or 'SERIAL'
+ALTER TABLE items ADD COLUMN tracking_mode VARCHAR(10) NOT NULL DEFAULT 'NONE';
+
+
+-- Quantity buckets per lot (or serial number) and location; they add up to the stocks row
+CREATE TABLE IF NOT EXISTS stock_lots (
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    bin_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    lot_number VARCHAR(100) NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (item_id, warehouse_id, bin_id, lot_number)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_lots_item_lot ON stock_lots(item_id, lot_number);
+
+
+-- Lot breakdown of every movement of a tracked item
+CREATE TABLE IF NOT EXISTS stock_movement_lots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stock_movement_id UUID NOT NULL REFERENCES stock_movements(id) ON DELETE CASCADE,
+    lot_number VARCHAR(100) NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movement_lots_movement_id ON stock_movement_lots(stock_movement_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movement_lots_lot_number ON stock_movement_lots(lot_number);
+
+
+-- Lots consumed and produced by a production run, used for the lot genealogy
+CREATE TABLE IF NOT EXISTS production_lots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    production_record_id UUID NOT NULL REFERENCES production_records(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    lot_number VARCHAR(100) NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL,
+    role VARCHAR(10) NOT NULL -- 'CONSUMED' or 'PRODUCED'
+);
+CREATE INDEX IF NOT EXISTS idx_production_lots_record_id ON production_lots(production_record_id);
+CREATE INDEX IF NOT EXISTS idx_production_lots_item_lot ON production_lots(item_id, lot_number);
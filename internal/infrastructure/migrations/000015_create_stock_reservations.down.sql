@@ -0,0 +1,3 @@
+-- 000015_create_stock_reservations.down.sql
+
+DROP TABLE IF EXISTS stock_reservations;
@@ -0,0 +1,28 @@
+-- 000015_create_stock_reservations.up.sql
+-- This script creates the table for stock reservations (soft allocations).
+
+-- Open quantity (quantity - consumed_quantity) of ACTIVE, unexpired rows is not available to other movements
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    bin_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    source_type VARCHAR(50) NOT NULL,
+    source_id VARCHAR(100) NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL CHECK (quantity > 0),
+    consumed_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE', -- 'ACTIVE', 'RELEASED', 'CONSUMED' or 'EXPIRED'
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_location ON stock_reservations(item_id, warehouse_id, bin_id) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations(expires_at) WHERE status = 'ACTIVE';
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_source ON stock_reservations(source_type, source_id);
+
+-- A source document holds at most one active reservation per item and location
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_reservations_active_source
+    ON stock_reservations(source_type, source_id, item_id, warehouse_id, bin_id) WHERE status = 'ACTIVE';
@@ -0,0 +1,4 @@
+-- 000016_create_replenishment_tables.down.sql
+
+DROP TABLE IF EXISTS replenishment_proposals;
+DROP TABLE IF EXISTS reorder_rules;
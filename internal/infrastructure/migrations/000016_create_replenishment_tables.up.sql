@@ -0,0 +1,48 @@
+-- 000016_create_replenishment_tables.up.sql
+-- This script creates the tables for reorder rules and replenishment proposals.
+
+-- Min/max and reorder-point settings of an item in a warehouse
+CREATE TABLE IF NOT EXISTS reorder_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    min_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    reorder_point NUMERIC(15, 4) NOT NULL,
+    max_quantity NUMERIC(15, 4) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    CHECK (min_quantity >= 0 AND min_quantity <= reorder_point AND reorder_point <= max_quantity AND max_quantity > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_reorder_rules_warehouse_id ON reorder_rules(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_reorder_rules_deleted_at ON reorder_rules(deleted_at);
+
+-- An item has at most one live rule per warehouse
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reorder_rules_item_warehouse
+    ON reorder_rules(item_id, warehouse_id) WHERE deleted_at IS NULL;
+
+-- Proposals of the latest replenishment run, replaced on every run
+CREATE TABLE IF NOT EXISTS replenishment_proposals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL,
+    rule_id UUID NOT NULL,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL, -- 'PURCHASE' or 'PRODUCTION'
+    bom_id UUID REFERENCES bill_of_materials(id) ON DELETE SET NULL,
+    quantity NUMERIC(15, 4) NOT NULL,
+    on_hand NUMERIC(15, 4) NOT NULL,
+    reserved NUMERIC(15, 4) NOT NULL,
+    component_demand NUMERIC(15, 4) NOT NULL,
+    projected NUMERIC(15, 4) NOT NULL,
+    reorder_point NUMERIC(15, 4) NOT NULL,
+    min_quantity NUMERIC(15, 4) NOT NULL,
+    max_quantity NUMERIC(15, 4) NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_replenishment_proposals_run_id ON replenishment_proposals(run_id);
+CREATE INDEX IF NOT EXISTS idx_replenishment_proposals_item_id ON replenishment_proposals(item_id);
+CREATE INDEX IF NOT EXISTS idx_replenishment_proposals_warehouse_id ON replenishment_proposals(warehouse_id);
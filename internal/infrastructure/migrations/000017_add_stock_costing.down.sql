@@ -0,0 +1,10 @@
+-- 000017_add_stock_costing.down.sql
+
+DROP TABLE IF EXISTS stock_cost_layer_consumptions;
+DROP TABLE IF EXISTS stock_cost_layers;
+ALTER TABLE stock_ledger DROP COLUMN IF EXISTS cost_variance;
+ALTER TABLE stock_ledger DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS cost_variance;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE items DROP COLUMN IF EXISTS standard_cost;
+ALTER TABLE items DROP COLUMN IF EXISTS costing_method;
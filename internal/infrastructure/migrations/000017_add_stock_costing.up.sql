@@ -0,0 +1,45 @@
+-- 000017_add_stock_costing.up.sql
+-- This script adds per-item costing methods, the unit cost of every stock movement and FIFO cost layers.
+
+-- 'AVERAGE', 'FIFO' or 'STANDARD'
+ALTER TABLE items ADD COLUMN costing_method VARCHAR(10) NOT NULL DEFAULT 'AVERAGE';
+ALTER TABLE items ADD COLUMN standard_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+
+
+-- Unit cost of every movement; existing rows are valued at the current average cost of their item
+ALTER TABLE stock_movements ADD COLUMN unit_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+ALTER TABLE stock_movements ADD COLUMN cost_variance NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+ALTER TABLE stock_ledger ADD COLUMN unit_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+ALTER TABLE stock_ledger ADD COLUMN cost_variance NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+
+UPDATE stock_movements sm SET unit_cost = i.average_cost FROM items i WHERE i.id = sm.item_id;
+UPDATE stock_ledger sl SET unit_cost = i.average_cost FROM items i WHERE i.id = sl.item_id;
+
+
+-- FIFO cost layers, one per inbound movement of a FIFO item. The movement references are
+-- deferred because layers are costed before the movement row is written in the same transaction.
+CREATE TABLE IF NOT EXISTS stock_cost_layers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    movement_id UUID NOT NULL UNIQUE REFERENCES stock_movements(id) ON DELETE RESTRICT DEFERRABLE INITIALLY DEFERRED,
+    unit_cost NUMERIC(15, 4) NOT NULL,
+    original_quantity NUMERIC(15, 4) NOT NULL,
+    remaining_quantity NUMERIC(15, 4) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK (remaining_quantity >= 0 AND remaining_quantity <= original_quantity)
+);
+-- Open layers are read oldest first
+CREATE INDEX IF NOT EXISTS idx_stock_cost_layers_open
+    ON stock_cost_layers(item_id, received_at) WHERE remaining_quantity > 0;
+
+
+-- Quantity taken from each layer by an outbound movement, used to reverse it
+CREATE TABLE IF NOT EXISTS stock_cost_layer_consumptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    layer_id UUID NOT NULL REFERENCES stock_cost_layers(id) ON DELETE CASCADE,
+    movement_id UUID NOT NULL REFERENCES stock_movements(id) ON DELETE RESTRICT DEFERRABLE INITIALLY DEFERRED,
+    quantity NUMERIC(15, 4) NOT NULL,
+    unit_cost NUMERIC(15, 4) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_stock_cost_layer_consumptions_layer_id ON stock_cost_layer_consumptions(layer_id);
+CREATE INDEX IF NOT EXISTS idx_stock_cost_layer_consumptions_movement_id ON stock_cost_layer_consumptions(movement_id);
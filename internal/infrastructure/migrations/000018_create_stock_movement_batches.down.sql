@@ -0,0 +1,4 @@
+-- 000018_create_stock_movement_batches.down.sql
+
+DROP TABLE IF EXISTS stock_movement_batch_lines;
+DROP TABLE IF EXISTS stock_movement_batches;
@@ -0,0 +1,22 @@
+-- 000018_create_stock_movement_batches.up.sql
+-- This script creates the tables for bulk stock movement imports.
+
+-- A batch sent again with the same idempotency key returns the movements it already posted
+CREATE TABLE IF NOT EXISTS stock_movement_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key VARCHAR(255),
+    payload_hash VARCHAR(64) NOT NULL,
+    line_count INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_stock_movement_batches_idempotency_key
+    ON stock_movement_batches(idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS stock_movement_batch_lines (
+    batch_id UUID NOT NULL REFERENCES stock_movement_batches(id) ON DELETE CASCADE,
+    line INTEGER NOT NULL,
+    movement_id UUID NOT NULL REFERENCES stock_movements(id) ON DELETE RESTRICT,
+    PRIMARY KEY (batch_id, line)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movement_batch_lines_movement_id ON stock_movement_batch_lines(movement_id);
@@ -0,0 +1,26 @@
+-- 000019_add_bom_revisions.down.sql
+-- Only the latest revision of each product is kept, as the single BOM of the product, and the
+-- production records of the older revisions are moved to it.
+
+DROP INDEX IF EXISTS idx_bill_of_materials_in_force;
+DROP INDEX IF EXISTS idx_bill_of_materials_product_revision;
+
+UPDATE production_records pr SET bill_of_materials_id = latest.id
+FROM bill_of_materials used
+JOIN bill_of_materials latest ON latest.product_id = used.product_id
+    AND latest.revision = (SELECT MAX(revision) FROM bill_of_materials WHERE product_id = used.product_id)
+WHERE used.id = pr.bill_of_materials_id AND used.id <> latest.id;
+
+DELETE FROM bill_of_materials b
+WHERE EXISTS (
+    SELECT 1 FROM bill_of_materials newer
+    WHERE newer.product_id = b.product_id AND newer.revision > b.revision
+);
+
+ALTER TABLE bill_of_materials DROP CONSTRAINT IF EXISTS chk_bill_of_materials_effective_dates;
+ALTER TABLE bill_of_materials DROP COLUMN IF EXISTS effective_to;
+ALTER TABLE bill_of_materials DROP COLUMN IF EXISTS effective_from;
+ALTER TABLE bill_of_materials DROP COLUMN IF EXISTS status;
+ALTER TABLE bill_of_materials DROP COLUMN IF EXISTS revision;
+
+ALTER TABLE bill_of_materials ADD CONSTRAINT bill_of_materials_product_id_key UNIQUE (product_id);
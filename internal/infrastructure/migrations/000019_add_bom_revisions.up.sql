@@ -0,0 +1,22 @@
+-- 000019_add_bom_revisions.up.sql
+-- This script turns every bill_of_materials row into a revision of its product, so a product can
+-- have several BOMs over time and production records keep the revision they were produced with.
+
+ALTER TABLE bill_of_materials DROP CONSTRAINT IF EXISTS bill_of_materials_product_id_key;
+
+ALTER TABLE bill_of_materials ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+-- 'DRAFT', 'APPROVED' or 'OBSOLETE'
+ALTER TABLE bill_of_materials ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'DRAFT';
+ALTER TABLE bill_of_materials ADD COLUMN effective_from TIMESTAMP WITH TIME ZONE;
+ALTER TABLE bill_of_materials ADD COLUMN effective_to TIMESTAMP WITH TIME ZONE;
+ALTER TABLE bill_of_materials ADD CONSTRAINT chk_bill_of_materials_effective_dates
+    CHECK (effective_to IS NULL OR effective_to > effective_from);
+
+-- Existing BOMs become the first approved revision of their product, in force since their creation
+UPDATE bill_of_materials SET status = 'APPROVED', effective_from = created_at;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bill_of_materials_product_revision
+    ON bill_of_materials(product_id, revision) WHERE deleted_at IS NULL;
+-- The revision in force is looked up by product and date
+CREATE INDEX IF NOT EXISTS idx_bill_of_materials_in_force
+    ON bill_of_materials(product_id, effective_from) WHERE status = 'APPROVED';
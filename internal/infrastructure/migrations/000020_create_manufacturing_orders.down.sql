@@ -0,0 +1,9 @@
+-- 000020_create_manufacturing_orders.down.sql
+
+DROP INDEX IF EXISTS idx_production_records_manufacturing_order_id;
+ALTER TABLE production_records DROP COLUMN IF EXISTS scrap_quantity;
+ALTER TABLE production_records DROP COLUMN IF EXISTS manufacturing_order_id;
+
+DROP TABLE IF EXISTS manufacturing_order_lots;
+DROP TABLE IF EXISTS manufacturing_order_components;
+DROP TABLE IF EXISTS manufacturing_orders;
@@ -0,0 +1,65 @@
+-- 000020_create_manufacturing_orders.up.sql
+-- This script creates the tables for manufacturing orders and links production records to them.
+
+-- Production orders run over time: released, then issued and received in several steps, then closed
+CREATE TABLE IF NOT EXISTS manufacturing_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    product_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    bill_of_materials_id UUID NOT NULL REFERENCES bill_of_materials(id) ON DELETE RESTRICT,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    quantity NUMERIC(15, 4) NOT NULL CHECK (quantity > 0),
+    produced_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    scrap_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    status VARCHAR(20) NOT NULL DEFAULT 'PLANNED', -- 'PLANNED', 'RELEASED', 'IN_PROGRESS', 'COMPLETED' or 'CANCELLED'
+    planned_start TIMESTAMP WITH TIME ZONE,
+    planned_end TIMESTAMP WITH TIME ZONE,
+    released_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    actual_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    received_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    production_record_id UUID REFERENCES production_records(id) ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_manufacturing_orders_product_id ON manufacturing_orders(product_id);
+CREATE INDEX IF NOT EXISTS idx_manufacturing_orders_status ON manufacturing_orders(status);
+
+
+-- One line per component item of the BOM revision
+CREATE TABLE IF NOT EXISTS manufacturing_order_components (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES manufacturing_orders(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    quantity_per NUMERIC(15, 4) NOT NULL,
+    required_quantity NUMERIC(15, 4) NOT NULL,
+    issued_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    issued_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    scrap_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0,
+    unit_of_measure VARCHAR(50),
+    reservation_id UUID REFERENCES stock_reservations(id) ON DELETE SET NULL,
+    UNIQUE(order_id, item_id)
+);
+CREATE INDEX IF NOT EXISTS idx_manufacturing_order_components_order_id ON manufacturing_order_components(order_id);
+
+
+-- Lots issued to and received from an order, copied to the production record at close
+CREATE TABLE IF NOT EXISTS manufacturing_order_lots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES manufacturing_orders(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    lot_number VARCHAR(100) NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL,
+    role VARCHAR(10) NOT NULL -- 'CONSUMED' or 'PRODUCED'
+);
+CREATE INDEX IF NOT EXISTS idx_manufacturing_order_lots_order_id ON manufacturing_order_lots(order_id);
+
+
+-- A production record completes at most one order
+ALTER TABLE production_records ADD COLUMN manufacturing_order_id UUID REFERENCES manufacturing_orders(id) ON DELETE RESTRICT;
+ALTER TABLE production_records ADD COLUMN scrap_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_production_records_manufacturing_order_id
+    ON production_records(manufacturing_order_id) WHERE manufacturing_order_id IS NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE production_records DROP COLUMN IF EXISTS yield_variance;
+
+ALTER TABLE bill_of_materials_components DROP CONSTRAINT IF EXISTS chk_bill_of_materials_components_scrap_percent;
+ALTER TABLE bill_of_materials_components DROP COLUMN IF EXISTS scrap_percent;
+
+ALTER TABLE bill_of_materials DROP CONSTRAINT IF EXISTS chk_bill_of_materials_yield_percent;
+ALTER TABLE bill_of_materials DROP COLUMN IF EXISTS yield_percent;
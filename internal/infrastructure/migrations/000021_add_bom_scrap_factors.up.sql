@@ -0,0 +1,13 @@
+-- This script adds the expected losses of production: a scrap percentage on each BOM component
+-- and an expected yield on each BOM, plus the yield variance of each production run.
+
+ALTER TABLE bill_of_materials ADD COLUMN yield_percent NUMERIC(5, 2) NOT NULL DEFAULT 100;
+ALTER TABLE bill_of_materials ADD CONSTRAINT chk_bill_of_materials_yield_percent
+    CHECK (yield_percent > 0 AND yield_percent <= 100);
+
+ALTER TABLE bill_of_materials_components ADD COLUMN scrap_percent NUMERIC(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE bill_of_materials_components ADD CONSTRAINT chk_bill_of_materials_components_scrap_percent
+    CHECK (scrap_percent >= 0);
+
+-- Cost of the scrap recorded beyond the planned scrap of the run
+ALTER TABLE production_records ADD COLUMN yield_variance NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
@@ -0,0 +1,7 @@
+ALTER TABLE invoice_lines DROP COLUMN IF EXISTS base_quantity;
+ALTER TABLE invoice_lines DROP COLUMN IF EXISTS unit_of_measure;
+
+ALTER TABLE items DROP COLUMN IF EXISTS base_uom;
+
+DROP TABLE IF EXISTS uom_units;
+DROP TABLE IF EXISTS uom_categories;
@@ -0,0 +1,62 @@
+-- 000022_create_units_of_measure.up.sql
+-- This script creates the units of measure, the base unit of each item and the entered unit of
+-- invoice lines.
+
+-- Dimension measured by a group of convertible units, e.g. mass or length
+CREATE TABLE IF NOT EXISTS uom_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    CONSTRAINT uq_uom_categories_name UNIQUE (name)
+);
+
+-- factor is the number of reference units of the category in one unit (the reference unit has 1)
+CREATE TABLE IF NOT EXISTS uom_units (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    category_id UUID NOT NULL REFERENCES uom_categories(id) ON DELETE RESTRICT,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    factor NUMERIC(20, 10) NOT NULL CHECK (factor > 0),
+    CONSTRAINT uq_uom_units_code UNIQUE (code)
+);
+CREATE INDEX IF NOT EXISTS idx_uom_units_category_id ON uom_units(category_id);
+
+-- Common units; more can be added through the API
+INSERT INTO uom_categories (name) VALUES ('Unit'), ('Mass'), ('Length'), ('Volume'), ('Time')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO uom_units (category_id, code, name, factor)
+SELECT c.id, u.code, u.name, u.factor
+FROM (VALUES
+    ('Unit', 'un', 'Unit', 1),
+    ('Unit', 'dz', 'Dozen', 12),
+    ('Mass', 'kg', 'Kilogram', 1),
+    ('Mass', 'g', 'Gram', 0.001),
+    ('Mass', 't', 'Tonne', 1000),
+    ('Length', 'm', 'Metre', 1),
+    ('Length', 'cm', 'Centimetre', 0.01),
+    ('Length', 'mm', 'Millimetre', 0.001),
+    ('Volume', 'l', 'Litre', 1),
+    ('Volume', 'ml', 'Millilitre', 0.001),
+    ('Time', 'h', 'Hour', 1),
+    ('Time', 'min', 'Minute', 0.0166666667)
+) AS u(category, code, name, factor)
+JOIN uom_categories c ON c.name = u.category
+ON CONFLICT (code) DO NOTHING;
+
+-- Unit in which the stock of the item is kept; NULL keeps the quantities unconverted
+ALTER TABLE items ADD COLUMN base_uom VARCHAR(20) REFERENCES uom_units(code) ON DELETE RESTRICT;
+
+-- Unit in which the line was entered and its quantity in the base unit of the item
+ALTER TABLE invoice_lines ADD COLUMN unit_of_measure VARCHAR(20);
+ALTER TABLE invoice_lines ADD COLUMN base_quantity NUMERIC(15, 4);
+UPDATE invoice_lines SET base_quantity = quantity WHERE base_quantity IS NULL;
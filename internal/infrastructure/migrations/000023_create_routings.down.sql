@@ -0,0 +1,5 @@
+ALTER TABLE production_records DROP COLUMN IF EXISTS overhead_cost;
+ALTER TABLE production_records DROP COLUMN IF EXISTS labor_cost;
+
+DROP TABLE IF EXISTS routing_operations;
+DROP TABLE IF EXISTS work_centers;
@@ -0,0 +1,41 @@
+-- 000023_create_routings.up.sql
+-- This script creates the work centers and the routing operations of BOM revisions, and records
+-- the labor and overhead cost of each production run.
+
+-- Places of production, costed by the hour
+CREATE TABLE IF NOT EXISTS work_centers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    labor_rate NUMERIC(15, 4) NOT NULL DEFAULT 0.0 CHECK (labor_rate >= 0),
+    overhead_rate NUMERIC(15, 4) NOT NULL DEFAULT 0.0 CHECK (overhead_rate >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    CONSTRAINT uq_work_centers_code UNIQUE (code)
+);
+
+-- Operations of a BOM revision; they are frozen with the revision once it is approved
+CREATE TABLE IF NOT EXISTS routing_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    bill_of_materials_id UUID NOT NULL REFERENCES bill_of_materials(id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL CHECK (sequence > 0),
+    name VARCHAR(255) NOT NULL,
+    work_center_id UUID NOT NULL REFERENCES work_centers(id) ON DELETE RESTRICT,
+    setup_minutes NUMERIC(15, 4) NOT NULL DEFAULT 0.0 CHECK (setup_minutes >= 0),
+    run_minutes NUMERIC(15, 4) NOT NULL DEFAULT 0.0 CHECK (run_minutes >= 0), -- Per unit of product
+    CONSTRAINT uq_routing_operations_bom_sequence UNIQUE (bill_of_materials_id, sequence)
+);
+CREATE INDEX IF NOT EXISTS idx_routing_operations_work_center_id ON routing_operations(work_center_id);
+
+-- Conversion cost of each run, part of its actual production cost
+ALTER TABLE production_records ADD COLUMN labor_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
+ALTER TABLE production_records ADD COLUMN overhead_cost NUMERIC(15, 4) NOT NULL DEFAULT 0.0;
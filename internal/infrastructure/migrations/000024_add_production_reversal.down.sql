@@ -0,0 +1,7 @@
+ALTER TABLE production_records DROP COLUMN IF EXISTS reversal_reason;
+ALTER TABLE production_records DROP COLUMN IF EXISTS reversed_by;
+ALTER TABLE production_records DROP COLUMN IF EXISTS reversed_at;
+ALTER TABLE production_records DROP COLUMN IF EXISTS disassembled_quantity;
+
+DROP INDEX IF EXISTS idx_stock_movements_production_record_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS production_record_id;
@@ -0,0 +1,13 @@
+-- 000024_add_production_reversal.up.sql
+-- This script links stock movements to the production run that posted them, so that an instant
+-- run can be reversed or disassembled at the cost it recorded.
+
+-- No foreign key: the movements of a run are posted before its record is created
+ALTER TABLE stock_movements ADD COLUMN production_record_id UUID;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_production_record_id ON stock_movements(production_record_id);
+
+-- Quantity of the product taken apart since the run, and the reversal of the whole run
+ALTER TABLE production_records ADD COLUMN disassembled_quantity NUMERIC(15, 4) NOT NULL DEFAULT 0.0 CHECK (disassembled_quantity >= 0);
+ALTER TABLE production_records ADD COLUMN reversed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE production_records ADD COLUMN reversed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE production_records ADD COLUMN reversal_reason VARCHAR(255);
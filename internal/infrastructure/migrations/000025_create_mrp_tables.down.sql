@@ -0,0 +1,6 @@
+-- 000025_create_mrp_tables.down.sql
+
+DROP TABLE IF EXISTS mrp_planned_orders;
+DROP TABLE IF EXISTS mrp_runs;
+DROP TABLE IF EXISTS mrp_forecasts;
+ALTER TABLE items DROP COLUMN IF EXISTS lead_time_days;
@@ -0,0 +1,58 @@
+-- 000025_create_mrp_tables.up.sql
+-- This script adds the lead time of items and creates the tables for demand forecasts and
+-- material requirements planning runs with their planned orders.
+
+-- Days from ordering or starting an item to its receipt
+ALTER TABLE items ADD COLUMN lead_time_days INTEGER NOT NULL DEFAULT 0 CHECK (lead_time_days >= 0);
+
+-- Demand expected by a planner for an item in a warehouse on a date
+CREATE TABLE IF NOT EXISTS mrp_forecasts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    quantity NUMERIC(15, 4) NOT NULL CHECK (quantity > 0),
+    due_date DATE NOT NULL,
+    notes TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_mrp_forecasts_item_id ON mrp_forecasts(item_id);
+CREATE INDEX IF NOT EXISTS idx_mrp_forecasts_warehouse_due_date ON mrp_forecasts(warehouse_id, due_date);
+CREATE INDEX IF NOT EXISTS idx_mrp_forecasts_deleted_at ON mrp_forecasts(deleted_at);
+
+-- MRP runs, queued on the worker pool and kept with their results
+CREATE TABLE IF NOT EXISTS mrp_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    horizon_end DATE NOT NULL,
+    include_invoices BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL, -- 'QUEUED', 'RUNNING', 'COMPLETED' or 'FAILED'
+    error_message TEXT,
+    planned_order_count INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_mrp_runs_warehouse_id ON mrp_runs(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_mrp_runs_created_at ON mrp_runs(created_at);
+
+-- Time-phased purchase and production orders planned by a run
+CREATE TABLE IF NOT EXISTS mrp_planned_orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES mrp_runs(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL, -- 'PURCHASE' or 'PRODUCTION'
+    bom_id UUID REFERENCES bill_of_materials(id) ON DELETE SET NULL,
+    level INTEGER NOT NULL,
+    quantity NUMERIC(15, 4) NOT NULL CHECK (quantity > 0),
+    start_date DATE NOT NULL,
+    due_date DATE NOT NULL,
+    past_due BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS idx_mrp_planned_orders_run_id ON mrp_planned_orders(run_id);
+CREATE INDEX IF NOT EXISTS idx_mrp_planned_orders_item_id ON mrp_planned_orders(item_id);
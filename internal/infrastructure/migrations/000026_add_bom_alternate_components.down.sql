@@ -0,0 +1,4 @@
+-- 000026_add_bom_alternate_components.down.sql
+
+DROP TABLE IF EXISTS production_substitutions;
+DROP TABLE IF EXISTS bill_of_materials_component_alternates;
@@ -0,0 +1,29 @@
+-- 000026_add_bom_alternate_components.up.sql
+-- This script creates the alternate components of BOM lines and the substitutions recorded by
+-- the production runs that consumed them.
+
+-- Approved substitutes of a component, tried by priority
+CREATE TABLE IF NOT EXISTS bill_of_materials_component_alternates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    component_id UUID NOT NULL REFERENCES bill_of_materials_components(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    priority INTEGER NOT NULL CHECK (priority > 0),
+    conversion_ratio NUMERIC(15, 6) NOT NULL DEFAULT 1 CHECK (conversion_ratio > 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bom_component_alternates_priority ON bill_of_materials_component_alternates(component_id, priority);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bom_component_alternates_item ON bill_of_materials_component_alternates(component_id, item_id);
+CREATE INDEX IF NOT EXISTS idx_bom_component_alternates_item_id ON bill_of_materials_component_alternates(item_id);
+
+-- Share of a component line consumed as an alternate by a production run
+CREATE TABLE IF NOT EXISTS production_substitutions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    production_record_id UUID NOT NULL REFERENCES production_records(id) ON DELETE CASCADE,
+    component_id UUID NOT NULL REFERENCES bill_of_materials_components(id) ON DELETE RESTRICT,
+    component_item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    substitute_item_id UUID NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    share NUMERIC(9, 8) NOT NULL CHECK (share > 0 AND share <= 1),
+    quantity NUMERIC(15, 4) NOT NULL,
+    substitute_quantity NUMERIC(15, 4) NOT NULL,
+    chosen BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS idx_production_substitutions_record_id ON production_substitutions(production_record_id);
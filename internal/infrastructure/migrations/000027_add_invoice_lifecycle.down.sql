@@ -0,0 +1,10 @@
+-- 000027_add_invoice_lifecycle.down.sql
+
+DROP INDEX IF EXISTS idx_invoices_status;
+ALTER TABLE invoices DROP COLUMN IF EXISTS cancellation_reason;
+ALTER TABLE invoices DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS sent_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS validated_by;
+ALTER TABLE invoices DROP COLUMN IF EXISTS validated_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS amount_paid;
+ALTER TABLE invoices DROP COLUMN IF EXISTS status;
@@ -0,0 +1,17 @@
+-- 000027_add_invoice_lifecycle.up.sql
+-- This script adds the lifecycle of invoices. Invoices issued before it were final at creation
+-- and are migrated as validated; new invoices start as drafts.
+
+ALTER TABLE invoices ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'VALIDATED'
+    CHECK (status IN ('DRAFT', 'VALIDATED', 'SENT', 'PARTIALLY_PAID', 'PAID', 'CANCELLED'));
+ALTER TABLE invoices ALTER COLUMN status SET DEFAULT 'DRAFT';
+ALTER TABLE invoices ADD COLUMN amount_paid NUMERIC(15, 4) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN validated_at TIMESTAMPTZ;
+ALTER TABLE invoices ADD COLUMN validated_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE invoices ADD COLUMN sent_at TIMESTAMPTZ;
+ALTER TABLE invoices ADD COLUMN cancelled_at TIMESTAMPTZ;
+ALTER TABLE invoices ADD COLUMN cancellation_reason VARCHAR(255);
+
+UPDATE invoices SET validated_at = created_at WHERE validated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_invoices_status ON invoices(status);
@@ -0,0 +1,4 @@
+-- 000028_create_numbering_sequences.down.sql
+
+DROP TABLE IF EXISTS numbering_counters;
+DROP TABLE IF EXISTS numbering_sequences;
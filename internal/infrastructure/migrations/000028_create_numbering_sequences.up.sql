@@ -0,0 +1,29 @@
+-- 000028_create_numbering_sequences.up.sql
+-- This script creates the numbering sequences that give documents their final numbers at
+-- validation, and seeds the invoice sequence.
+
+-- Numbering pattern of a document type
+CREATE TABLE IF NOT EXISTS numbering_sequences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    document_type VARCHAR(30) NOT NULL UNIQUE,
+    pattern VARCHAR(100) NOT NULL,
+    reset_policy VARCHAR(20) NOT NULL CHECK (reset_policy IN ('NEVER', 'YEARLY'))
+);
+
+-- Last value given by a sequence in a period: the year for a yearly reset, '' otherwise
+CREATE TABLE IF NOT EXISTS numbering_counters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sequence_id UUID NOT NULL REFERENCES numbering_sequences(id) ON DELETE CASCADE,
+    period VARCHAR(10) NOT NULL,
+    last_value BIGINT NOT NULL DEFAULT 0 CHECK (last_value >= 0)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_numbering_counters_period ON numbering_counters(sequence_id, period);
+
+INSERT INTO numbering_sequences (document_type, pattern, reset_policy)
+VALUES ('INVOICE', 'INV-{YYYY}-{0000}', 'YEARLY')
+ON CONFLICT (document_type) DO NOTHING;
@@ -0,0 +1,11 @@
+-- 000029_add_credit_notes.down.sql
+
+DELETE FROM numbering_sequences WHERE document_type = 'CREDIT_NOTE';
+
+ALTER TABLE invoice_lines DROP COLUMN IF EXISTS original_line_id;
+
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS chk_invoices_credit_note_original;
+ALTER TABLE invoices DROP COLUMN IF EXISTS amount_credited;
+ALTER TABLE invoices DROP COLUMN IF EXISTS return_warehouse_id;
+ALTER TABLE invoices DROP COLUMN IF EXISTS original_invoice_id;
+ALTER TABLE invoices DROP COLUMN IF EXISTS document_type;
@@ -0,0 +1,20 @@
+-- 000029_add_credit_notes.up.sql
+-- This script adds credit notes: invoices of type CREDIT_NOTE that credit all or part of the
+-- lines of an issued invoice, numbered by their own sequence.
+
+ALTER TABLE invoices ADD COLUMN document_type VARCHAR(20) NOT NULL DEFAULT 'STANDARD'
+    CHECK (document_type IN ('STANDARD', 'CREDIT_NOTE'));
+ALTER TABLE invoices ADD COLUMN original_invoice_id UUID REFERENCES invoices(id) ON DELETE RESTRICT;
+ALTER TABLE invoices ADD COLUMN return_warehouse_id UUID REFERENCES warehouses(id) ON DELETE RESTRICT;
+ALTER TABLE invoices ADD COLUMN amount_credited NUMERIC(15, 4) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD CONSTRAINT chk_invoices_credit_note_original
+    CHECK ((document_type = 'CREDIT_NOTE') = (original_invoice_id IS NOT NULL));
+
+ALTER TABLE invoice_lines ADD COLUMN original_line_id UUID REFERENCES invoice_lines(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_invoices_original_invoice_id ON invoices(original_invoice_id);
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_original_line_id ON invoice_lines(original_line_id);
+
+INSERT INTO numbering_sequences (document_type, pattern, reset_policy)
+VALUES ('CREDIT_NOTE', 'CN-{YYYY}-{0000}', 'YEARLY')
+ON CONFLICT (document_type) DO NOTHING;
@@ -0,0 +1,4 @@
+-- 000030_create_payments.down.sql
+
+DROP TABLE IF EXISTS payment_allocations;
+DROP TABLE IF EXISTS payments;
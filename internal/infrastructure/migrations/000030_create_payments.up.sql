@@ -0,0 +1,44 @@
+-- 000030_create_payments.up.sql
+-- This script creates the payments received from customers and their allocation to invoices.
+-- The amounts already recorded as paid on invoices become one payment per invoice.
+
+-- Amount received from a customer; the unallocated part is a credit of the customer
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    third_party_id UUID NOT NULL REFERENCES third_parties(id) ON DELETE RESTRICT,
+    date TIMESTAMP WITH TIME ZONE NOT NULL,
+    amount NUMERIC(15, 4) NOT NULL CHECK (amount > 0),
+    method VARCHAR(20) NOT NULL CHECK (method IN ('CASH', 'BANK_TRANSFER', 'CARD', 'CHECK', 'OTHER')),
+    reference VARCHAR(100),
+    unallocated_amount NUMERIC(15, 4) NOT NULL DEFAULT 0 CHECK (unallocated_amount >= 0)
+);
+CREATE INDEX IF NOT EXISTS idx_payments_third_party_id ON payments(third_party_id, date);
+CREATE INDEX IF NOT EXISTS idx_payments_deleted_at ON payments(deleted_at);
+
+-- Part of a payment settling an invoice
+CREATE TABLE IF NOT EXISTS payment_allocations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE RESTRICT,
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    amount NUMERIC(15, 4) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_payment_allocations_payment_id ON payment_allocations(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_allocations_invoice_id ON payment_allocations(invoice_id);
+
+-- The payments recorded on invoices so far keep the ID of their invoice
+INSERT INTO payments (id, created_by, updated_by, third_party_id, date, amount, method, reference, unallocated_amount)
+SELECT id, updated_by, updated_by, third_party_id, updated_at, amount_paid, 'OTHER', 'Paid before payment records', 0
+FROM invoices
+WHERE amount_paid > 0 AND deleted_at IS NULL;
+
+INSERT INTO payment_allocations (payment_id, invoice_id, amount, created_by)
+SELECT id, id, amount_paid, updated_by
+FROM invoices
+WHERE amount_paid > 0 AND deleted_at IS NULL;
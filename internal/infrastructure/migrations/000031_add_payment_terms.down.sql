@@ -0,0 +1,8 @@
+-- 000031_add_payment_terms.down.sql
+
+DROP INDEX IF EXISTS idx_invoices_due_date;
+
+ALTER TABLE invoices DROP COLUMN IF EXISTS due_date;
+ALTER TABLE invoices DROP COLUMN IF EXISTS payment_term_days;
+
+ALTER TABLE third_parties DROP COLUMN IF EXISTS payment_term_days;
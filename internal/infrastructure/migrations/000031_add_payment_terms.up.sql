@@ -0,0 +1,17 @@
+-- 000031_add_payment_terms.up.sql
+-- This script adds payment terms to third parties and the due date they give to invoices.
+
+ALTER TABLE third_parties ADD COLUMN payment_term_days INTEGER NOT NULL DEFAULT 30
+    CHECK (payment_term_days >= 0);
+
+ALTER TABLE invoices ADD COLUMN payment_term_days INTEGER NOT NULL DEFAULT 30
+    CHECK (payment_term_days >= 0);
+ALTER TABLE invoices ADD COLUMN due_date DATE;
+
+-- Credit notes are due at their date; existing invoices take the default term
+UPDATE invoices SET payment_term_days = 0 WHERE document_type = 'CREDIT_NOTE';
+UPDATE invoices SET due_date = CAST(date AS DATE) + payment_term_days;
+
+ALTER TABLE invoices ALTER COLUMN due_date SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_invoices_due_date ON invoices(due_date);
@@ -0,0 +1,3 @@
+-- 000032_add_stock_movement_reversal.down.sql
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS reversed_at;
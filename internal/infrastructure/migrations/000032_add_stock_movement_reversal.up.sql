@@ -0,0 +1,4 @@
+-- 000032_add_stock_movement_reversal.up.sql
+-- This script records when a stock movement was reversed, so that it cannot be reversed twice.
+
+ALTER TABLE stock_movements ADD COLUMN reversed_at TIMESTAMP WITH TIME ZONE;
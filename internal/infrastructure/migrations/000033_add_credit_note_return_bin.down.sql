@@ -0,0 +1,4 @@
+-- 000033_add_credit_note_return_bin.down.sql
+
+ALTER TABLE invoices DROP CONSTRAINT IF EXISTS chk_invoices_return_bin;
+ALTER TABLE invoices DROP COLUMN IF EXISTS return_bin_id;
@@ -0,0 +1,6 @@
+-- 000033_add_credit_note_return_bin.up.sql
+-- This script adds the bin of the return warehouse a credit note returns the credited goods to.
+
+ALTER TABLE invoices ADD COLUMN return_bin_id UUID REFERENCES bins(id) ON DELETE RESTRICT;
+ALTER TABLE invoices ADD CONSTRAINT chk_invoices_return_bin
+    CHECK ((return_warehouse_id IS NULL) = (return_bin_id IS NULL));
@@ -54,9 +54,13 @@ func (g *marotoGenerator) Generate(ctx context.Context, inv *invoice.Invoice) ([
 }
 
 func (g *marotoGenerator) buildHeader(m pdf.Maroto, inv *invoice.Invoice) error {
+	title, label := "INVOICE", "Invoice"
+	if inv.IsCreditNote() {
+		title, label = "CREDIT NOTE", "Credit note"
+	}
 	m.Row(20, func() {
 		m.Col(6, func() {
-			m.Text("INVOICE", props.Text{
+			m.Text(title, props.Text{
 				Top:   3,
 				Style: consts.Bold,
 				Size:  24,
@@ -64,8 +68,14 @@ func (g *marotoGenerator) buildHeader(m pdf.Maroto, inv *invoice.Invoice) error
 			})
 		})
 		m.Col(6, func() {
-			m.Text(fmt.Sprintf("Invoice #%s", inv.Number), props.Text{Top: 5, Align: consts.Right})
+			m.Text(fmt.Sprintf("%s #%s", label, inv.Number), props.Text{Top: 5, Align: consts.Right})
 			m.Text(fmt.Sprintf("Date: %s", inv.Date.Format("2006-01-02")), props.Text{Top: 10, Align: consts.Right})
+			// A credit note names the invoice it credits
+			if inv.OriginalInvoice != nil {
+				m.Text(fmt.Sprintf("Credits invoice #%s", inv.OriginalInvoice.Number), props.Text{Top: 15, Align: consts.Right})
+			} else if !inv.IsCreditNote() {
+				m.Text(fmt.Sprintf("Due date: %s", inv.DueDate.Format("2006-01-02")), props.Text{Top: 15, Align: consts.Right})
+			}
 		})
 	})
 	return nil
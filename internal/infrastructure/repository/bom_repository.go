@@ -7,8 +7,11 @@ import (
 	"doligo_001/internal/infrastructure/db"
 	"doligo_001/internal/infrastructure/db/models"
 	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // gormBomRepository is a GORM implementation of the bom.Repository.
@@ -50,7 +53,7 @@ func (r *gormBomRepository) Create(ctx context.Context, b *bom.BillOfMaterials)
 // GetByID retrieves a BillOfMaterials by its ID.
 func (r *gormBomRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.BillOfMaterials, error) {
 	var model models.BillOfMaterials
-	if err := r.db.WithContext(ctx).Preload("Components").First(&model, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withBomLines).First(&model, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, bom.ErrBOMNotFound
 		}
@@ -59,18 +62,60 @@ func (r *gormBomRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.Bil
 	return toBomDomainEntity(&model), nil
 }
 
-// GetByProductID retrieves a BillOfMaterials by the product it produces.
-func (r *gormBomRepository) GetByProductID(ctx context.Context, productID uuid.UUID) (*bom.BillOfMaterials, error) {
+// GetEffective retrieves the approved revision of a product in force at the given time.
+func (r *gormBomRepository) GetEffective(ctx context.Context, productID uuid.UUID, at time.Time) (*bom.BillOfMaterials, error) {
 	var model models.BillOfMaterials
-	if err := r.db.WithContext(ctx).Preload("Components").First(&model, "product_id = ?", productID).Error; err != nil {
+	err := r.db.WithContext(ctx).Scopes(withBomLines).
+		Where("product_id = ? AND status = ? AND effective_from <= ?", productID, bom.RevisionApproved, at).
+		Where("(effective_to IS NULL OR effective_to > ?)", at).
+		Order("effective_from DESC").First(&model).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, bom.ErrBOMNotFound
 		}
-		return nil, fmt.Errorf("failed to get BOM by product ID: %w", err)
+		return nil, fmt.Errorf("failed to get BOM in force: %w", err)
 	}
 	return toBomDomainEntity(&model), nil
 }
 
+// ListRevisions lists the revisions of a product by revision number.
+func (r *gormBomRepository) ListRevisions(ctx context.Context, productID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	return r.listRevisions(r.db.WithContext(ctx), productID)
+}
+
+// ListRevisionsForUpdate lists the revisions of a product and locks them.
+func (r *gormBomRepository) ListRevisionsForUpdate(ctx context.Context, productID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	return r.listRevisions(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), productID)
+}
+
+func (r *gormBomRepository) listRevisions(query *gorm.DB, productID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	var modelsList []models.BillOfMaterials
+	if err := query.Scopes(withBomLines).Where("product_id = ?", productID).Order("revision").Find(&modelsList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list BOM revisions: %w", err)
+	}
+	domainList := make([]*bom.BillOfMaterials, len(modelsList))
+	for i := range modelsList {
+		domainList[i] = toBomDomainEntity(&modelsList[i])
+	}
+	return domainList, nil
+}
+
+// UpdateStatus saves the status and effective dates of a revision.
+func (r *gormBomRepository) UpdateStatus(ctx context.Context, b *bom.BillOfMaterials) error {
+	b.UpdatedAt = time.Now()
+	err := r.db.WithContext(ctx).Model(&models.BillOfMaterials{}).Where("id = ?", b.ID).Updates(map[string]interface{}{
+		"status":         string(b.Status),
+		"effective_from": b.EffectiveFrom,
+		"effective_to":   b.EffectiveTo,
+		"updated_at":     b.UpdatedAt,
+		"updated_by":     b.UpdatedBy,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update BOM revision status: %w", err)
+	}
+	return nil
+}
+
 // Update updates an existing BillOfMaterials in the database using an explicit transactional approach.
 func (r *gormBomRepository) Update(ctx context.Context, b *bom.BillOfMaterials) error {
 	if b.UpdatedBy == uuid.Nil {
@@ -99,10 +144,13 @@ func (r *gormBomRepository) Update(ctx context.Context, b *bom.BillOfMaterials)
 		var compsToAdd []models.BillOfMaterialsComponent
 		var compsToUpdate []models.BillOfMaterialsComponent
 
-		for _, incomingComp := range incomingModel.Components {
+		for i := range incomingModel.Components {
+			incomingComp := incomingModel.Components[i]
 			// If ID is zero, it's a new component
 			if incomingComp.ID == uuid.Nil {
+				incomingComp.ID = uuid.New()
 				incomingComp.BillOfMaterialsID = b.ID // Ensure association is set
+				incomingModel.Components[i].ID = incomingComp.ID
 				compsToAdd = append(compsToAdd, incomingComp)
 				continue
 			}
@@ -121,6 +169,16 @@ func (r *gormBomRepository) Update(ctx context.Context, b *bom.BillOfMaterials)
 		}
 
 		// 4. Execute DB operations
+		// The alternates are replaced with the components: they have no identity of their own
+		existingCompIDs := make([]uuid.UUID, 0, len(existingModel.Components))
+		for _, comp := range existingModel.Components {
+			existingCompIDs = append(existingCompIDs, comp.ID)
+		}
+		if len(existingCompIDs) > 0 {
+			if err := tx.Where("component_id IN ?", existingCompIDs).Delete(&models.BillOfMaterialsComponentAlternate{}).Error; err != nil {
+				return fmt.Errorf("failed to remove alternate components: %w", err)
+			}
+		}
 		// REMOVE
 		if len(compIDsToRemove) > 0 {
 			if err := tx.Delete(&models.BillOfMaterialsComponent{}, "id IN ?", compIDsToRemove).Error; err != nil {
@@ -129,19 +187,46 @@ func (r *gormBomRepository) Update(ctx context.Context, b *bom.BillOfMaterials)
 		}
 		// ADD
 		if len(compsToAdd) > 0 {
-			if err := tx.Create(&compsToAdd).Error; err != nil {
+			if err := tx.Omit("Alternates").Create(&compsToAdd).Error; err != nil {
 				return fmt.Errorf("failed to add new components: %w", err)
 			}
 		}
 		// MODIFY
 		for _, compToUpdate := range compsToUpdate {
-			if err := tx.Save(&compToUpdate).Error; err != nil {
+			if err := tx.Omit("Alternates").Save(&compToUpdate).Error; err != nil {
 				return fmt.Errorf("failed to update component ID %s: %w", compToUpdate.ID, err)
 			}
 		}
+		var alternates []models.BillOfMaterialsComponentAlternate
+		for _, comp := range incomingModel.Components {
+			for _, alt := range comp.Alternates {
+				alt.ID = uuid.New()
+				alt.ComponentID = comp.ID
+				alternates = append(alternates, alt)
+			}
+		}
+		if len(alternates) > 0 {
+			if err := tx.Create(&alternates).Error; err != nil {
+				return fmt.Errorf("failed to add alternate components: %w", err)
+			}
+		}
+
+		// 5. Replace the routing: operations have no identity outside their revision
+		if err := tx.Where("bill_of_materials_id = ?", b.ID).Delete(&models.RoutingOperation{}).Error; err != nil {
+			return fmt.Errorf("failed to remove routing operations: %w", err)
+		}
+		if len(incomingModel.Operations) > 0 {
+			for i := range incomingModel.Operations {
+				incomingModel.Operations[i].ID = uuid.New()
+				incomingModel.Operations[i].BillOfMaterialsID = b.ID
+			}
+			if err := tx.Create(&incomingModel.Operations).Error; err != nil {
+				return fmt.Errorf("failed to add routing operations: %w", err)
+			}
+		}
 
-		// 5. Update the parent BOM object
-		if err := tx.Omit("Components").Save(incomingModel).Error; err != nil {
+		// 6. Update the parent BOM object
+		if err := tx.Omit("Components", "Operations").Save(incomingModel).Error; err != nil {
 			return fmt.Errorf("failed to update BOM header: %w", err)
 		}
 
@@ -154,9 +239,16 @@ func (r *gormBomRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	// Note: GORM's default delete behavior might not cascade correctly without specific config.
 	// A robust implementation should handle deleting associations explicitly in a transaction.
 	return r.transactioner.Transaction(ctx, func(tx *gorm.DB) error {
+		components := tx.Model(&models.BillOfMaterialsComponent{}).Select("id").Where("bill_of_materials_id = ?", id)
+		if err := tx.Where("component_id IN (?)", components).Delete(&models.BillOfMaterialsComponentAlternate{}).Error; err != nil {
+			return fmt.Errorf("failed to delete BOM alternate components: %w", err)
+		}
 		if err := tx.Where("bill_of_materials_id = ?", id).Delete(&models.BillOfMaterialsComponent{}).Error; err != nil {
 			return fmt.Errorf("failed to delete BOM components: %w", err)
 		}
+		if err := tx.Where("bill_of_materials_id = ?", id).Delete(&models.RoutingOperation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete BOM routing operations: %w", err)
+		}
 		if err := tx.Delete(&models.BillOfMaterials{}, "id = ?", id).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return bom.ErrBOMNotFound
@@ -170,7 +262,7 @@ func (r *gormBomRepository) Delete(ctx context.Context, id uuid.UUID) error {
 // List lists all BillOfMaterials.
 func (r *gormBomRepository) List(ctx context.Context) ([]*bom.BillOfMaterials, error) {
 	var modelsList []models.BillOfMaterials
-	if err := r.db.WithContext(ctx).Preload("Components").Find(&modelsList).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(withBomLines).Find(&modelsList).Error; err != nil {
 		return nil, fmt.Errorf("failed to list BOMs: %w", err)
 	}
 	domainList := make([]*bom.BillOfMaterials, len(modelsList))
@@ -180,6 +272,20 @@ func (r *gormBomRepository) List(ctx context.Context) ([]*bom.BillOfMaterials, e
 	return domainList, nil
 }
 
+// ListByComponent lists the BillOfMaterials that consume an item, by name.
+func (r *gormBomRepository) ListByComponent(ctx context.Context, itemID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	var modelsList []models.BillOfMaterials
+	consumers := r.db.Model(&models.BillOfMaterialsComponent{}).Select("bill_of_materials_id").Where("component_item_id = ?", itemID)
+	if err := r.db.WithContext(ctx).Scopes(withBomLines).Where("id IN (?)", consumers).Order("name").Find(&modelsList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list BOMs by component: %w", err)
+	}
+	domainList := make([]*bom.BillOfMaterials, len(modelsList))
+	for i := range modelsList {
+		domainList[i] = toBomDomainEntity(&modelsList[i])
+	}
+	return domainList, nil
+}
+
 // gormProductionRecordRepository is a GORM implementation of the bom.ProductionRecordRepository.
 type gormProductionRecordRepository struct {
 	db *gorm.DB
@@ -209,6 +315,64 @@ func (r *gormProductionRecordRepository) Create(ctx context.Context, pr *bom.Pro
 	return nil
 }
 
+// GetByIDForUpdate retrieves a ProductionRecord with its lots and locks its row.
+func (r *gormProductionRecordRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*bom.ProductionRecord, error) {
+	var model models.ProductionRecord
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Lots").Preload("Substitutions").First(&model, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, bom.ErrProductionRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to get production record: %w", err)
+	}
+	return toProductionRecordDomainEntity(&model), nil
+}
+
+// Update saves the disassembled quantity and the reversal of a ProductionRecord; the run itself is immutable.
+func (r *gormProductionRecordRepository) Update(ctx context.Context, pr *bom.ProductionRecord) error {
+	result := r.db.WithContext(ctx).Model(&models.ProductionRecord{}).Where("id = ?", pr.ID).Updates(map[string]interface{}{
+		"disassembled_quantity": pr.DisassembledQuantity,
+		"reversed_at":           pr.ReversedAt,
+		"reversed_by":           pr.ReversedBy,
+		"reversal_reason":       pr.ReversalReason,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update production record: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bom.ErrProductionRecordNotFound
+	}
+	return nil
+}
+
+// ListByProducedLot retrieves the ProductionRecords that produced the given lot of an item.
+func (r *gormProductionRecordRepository) ListByProducedLot(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*bom.ProductionRecord, error) {
+	var modelList []models.ProductionRecord
+	produced := r.db.Model(&models.ProductionLot{}).Select("production_record_id").
+		Where("item_id = ? AND lot_number = ? AND role = ?", itemID, lotNumber, string(bom.LotProduced))
+	if err := r.db.WithContext(ctx).Preload("Lots").Preload("Substitutions").
+		Where("id IN (?)", produced).
+		Order("produced_at").
+		Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list production records by lot: %w", err)
+	}
+	domainList := make([]*bom.ProductionRecord, len(modelList))
+	for i := range modelList {
+		domainList[i] = toProductionRecordDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+// withBomLines loads the components with their alternates and the routing operations of the BOMs.
+func withBomLines(query *gorm.DB) *gorm.DB {
+	return query.Preload("Components").Preload("Components.Alternates", func(db *gorm.DB) *gorm.DB {
+		return db.Order("priority")
+	}).Preload("Operations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence")
+	})
+}
+
 // --- MAPPING FUNCTIONS ---
 
 func toBomDomainEntity(model *models.BillOfMaterials) *bom.BillOfMaterials {
@@ -219,16 +383,26 @@ func toBomDomainEntity(model *models.BillOfMaterials) *bom.BillOfMaterials {
 	for i := range model.Components {
 		components[i] = *toBomComponentDomainEntity(&model.Components[i])
 	}
+	operations := make([]bom.RoutingOperation, len(model.Operations))
+	for i := range model.Operations {
+		operations[i] = *toRoutingOperationDomainEntity(&model.Operations[i])
+	}
 	return &bom.BillOfMaterials{
-		ID:         model.ID,
-		ProductID:  model.ProductID,
-		Name:       model.Name,
-		IsActive:   model.IsActive,
-		CreatedAt:  model.CreatedAt,
-		UpdatedAt:  model.UpdatedAt,
-		CreatedBy:  model.CreatedBy,
-		UpdatedBy:  model.UpdatedBy,
-		Components: components,
+		ID:            model.ID,
+		ProductID:     model.ProductID,
+		Name:          model.Name,
+		IsActive:      model.IsActive,
+		Revision:      model.Revision,
+		Status:        bom.RevisionStatus(model.Status),
+		EffectiveFrom: model.EffectiveFrom,
+		EffectiveTo:   model.EffectiveTo,
+		YieldPercent:  model.YieldPercent,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
+		CreatedBy:     model.CreatedBy,
+		UpdatedBy:     model.UpdatedBy,
+		Components:    components,
+		Operations:    operations,
 	}
 }
 
@@ -240,6 +414,10 @@ func fromBomDomainEntity(entity *bom.BillOfMaterials) *models.BillOfMaterials {
 	for i := range entity.Components {
 		components[i] = *fromBomComponentDomainEntity(&entity.Components[i])
 	}
+	operations := make([]models.RoutingOperation, len(entity.Operations))
+	for i := range entity.Operations {
+		operations[i] = *fromRoutingOperationDomainEntity(&entity.Operations[i])
+	}
 	return &models.BillOfMaterials{
 		BaseModel: models.BaseModel{
 			ID:        entity.ID,
@@ -248,10 +426,16 @@ func fromBomDomainEntity(entity *bom.BillOfMaterials) *models.BillOfMaterials {
 			CreatedBy: entity.CreatedBy,
 			UpdatedBy: entity.UpdatedBy,
 		},
-		ProductID:  entity.ProductID,
-		Name:       entity.Name,
-		IsActive:   entity.IsActive,
-		Components: components,
+		ProductID:     entity.ProductID,
+		Name:          entity.Name,
+		IsActive:      entity.IsActive,
+		Revision:      entity.Revision,
+		Status:        string(entity.Status),
+		EffectiveFrom: entity.EffectiveFrom,
+		EffectiveTo:   entity.EffectiveTo,
+		YieldPercent:  entity.YieldPercent,
+		Components:    components,
+		Operations:    operations,
 	}
 }
 
@@ -259,17 +443,29 @@ func toBomComponentDomainEntity(model *models.BillOfMaterialsComponent) *bom.Bil
 	if model == nil {
 		return nil
 	}
+	var alternates []bom.AlternateComponent
+	for _, a := range model.Alternates {
+		alternates = append(alternates, bom.AlternateComponent{
+			ID:              a.ID,
+			ComponentID:     a.ComponentID,
+			ItemID:          a.ItemID,
+			Priority:        a.Priority,
+			ConversionRatio: a.ConversionRatio,
+		})
+	}
 	return &bom.BillOfMaterialsComponent{
 		ID:                model.ID,
 		BillOfMaterialsID: model.BillOfMaterialsID,
 		ComponentItemID:   model.ComponentItemID,
 		Quantity:          model.Quantity,
+		ScrapPercent:      model.ScrapPercent,
 		UnitOfMeasure:     model.UnitOfMeasure,
 		IsActive:          model.IsActive,
 		CreatedAt:         model.CreatedAt,
 		UpdatedAt:         model.UpdatedAt,
 		CreatedBy:         model.CreatedBy,
 		UpdatedBy:         model.UpdatedBy,
+		Alternates:        alternates,
 	}
 }
 
@@ -277,6 +473,20 @@ func fromBomComponentDomainEntity(entity *bom.BillOfMaterialsComponent) *models.
 	if entity == nil {
 		return nil
 	}
+	var alternates []models.BillOfMaterialsComponentAlternate
+	for _, a := range entity.Alternates {
+		id := a.ID
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		alternates = append(alternates, models.BillOfMaterialsComponentAlternate{
+			ID:              id,
+			ComponentID:     entity.ID,
+			ItemID:          a.ItemID,
+			Priority:        a.Priority,
+			ConversionRatio: a.ConversionRatio,
+		})
+	}
 	return &models.BillOfMaterialsComponent{
 		BaseModel: models.BaseModel{
 			ID:        entity.ID,
@@ -288,8 +498,44 @@ func fromBomComponentDomainEntity(entity *bom.BillOfMaterialsComponent) *models.
 		BillOfMaterialsID: entity.BillOfMaterialsID,
 		ComponentItemID:   entity.ComponentItemID,
 		Quantity:          entity.Quantity,
+		ScrapPercent:      entity.ScrapPercent,
 		UnitOfMeasure:     entity.UnitOfMeasure,
 		IsActive:          entity.IsActive,
+		Alternates:        alternates,
+	}
+}
+
+func toRoutingOperationDomainEntity(model *models.RoutingOperation) *bom.RoutingOperation {
+	return &bom.RoutingOperation{
+		ID:                model.ID,
+		BillOfMaterialsID: model.BillOfMaterialsID,
+		Sequence:          model.Sequence,
+		Name:              model.Name,
+		WorkCenterID:      model.WorkCenterID,
+		SetupMinutes:      model.SetupMinutes,
+		RunMinutes:        model.RunMinutes,
+		CreatedAt:         model.CreatedAt,
+		UpdatedAt:         model.UpdatedAt,
+		CreatedBy:         model.CreatedBy,
+		UpdatedBy:         model.UpdatedBy,
+	}
+}
+
+func fromRoutingOperationDomainEntity(entity *bom.RoutingOperation) *models.RoutingOperation {
+	return &models.RoutingOperation{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		BillOfMaterialsID: entity.BillOfMaterialsID,
+		Sequence:          entity.Sequence,
+		Name:              entity.Name,
+		WorkCenterID:      entity.WorkCenterID,
+		SetupMinutes:      entity.SetupMinutes,
+		RunMinutes:        entity.RunMinutes,
 	}
 }
 
@@ -297,15 +543,51 @@ func toProductionRecordDomainEntity(model *models.ProductionRecord) *bom.Product
 	if model == nil {
 		return nil
 	}
+	lots := make([]bom.ProductionLot, len(model.Lots))
+	for i, l := range model.Lots {
+		lots[i] = bom.ProductionLot{
+			ID:                 l.ID,
+			ProductionRecordID: l.ProductionRecordID,
+			ItemID:             l.ItemID,
+			LotNumber:          l.LotNumber,
+			Quantity:           l.Quantity,
+			Role:               bom.LotRole(l.Role),
+		}
+	}
+	var substitutions []bom.ProductionSubstitution
+	for _, sub := range model.Substitutions {
+		substitutions = append(substitutions, bom.ProductionSubstitution{
+			ID:                 sub.ID,
+			ProductionRecordID: sub.ProductionRecordID,
+			ComponentID:        sub.ComponentID,
+			ComponentItemID:    sub.ComponentItemID,
+			SubstituteItemID:   sub.SubstituteItemID,
+			Share:              sub.Share,
+			Quantity:           sub.Quantity,
+			SubstituteQuantity: sub.SubstituteQuantity,
+			Chosen:             sub.Chosen,
+		})
+	}
 	return &bom.ProductionRecord{
 		ID:                   model.ID,
 		BillOfMaterialsID:    model.BillOfMaterialsID,
+		ManufacturingOrderID: model.ManufacturingOrderID,
 		ProducedProductID:    model.ProducedProductID,
 		ProductionQuantity:   model.ProductionQuantity,
+		ScrapQuantity:        model.ScrapQuantity,
 		ActualProductionCost: model.ActualProductionCost,
+		LaborCost:            model.LaborCost,
+		OverheadCost:         model.OverheadCost,
+		YieldVariance:        model.YieldVariance,
+		DisassembledQuantity: model.DisassembledQuantity,
 		WarehouseID:          model.WarehouseID,
 		ProducedAt:           model.ProducedAt,
 		CreatedBy:            model.CreatedBy,
+		ReversedAt:           model.ReversedAt,
+		ReversedBy:           model.ReversedBy,
+		ReversalReason:       model.ReversalReason,
+		Lots:                 lots,
+		Substitutions:        substitutions,
 	}
 }
 
@@ -313,14 +595,58 @@ func fromProductionRecordDomainEntity(entity *bom.ProductionRecord) *models.Prod
 	if entity == nil {
 		return nil
 	}
+	var lots []models.ProductionLot
+	for _, l := range entity.Lots {
+		id := l.ID
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		lots = append(lots, models.ProductionLot{
+			ID:                 id,
+			ProductionRecordID: entity.ID,
+			ItemID:             l.ItemID,
+			LotNumber:          l.LotNumber,
+			Quantity:           l.Quantity,
+			Role:               string(l.Role),
+		})
+	}
+	var substitutions []models.ProductionSubstitution
+	for _, sub := range entity.Substitutions {
+		id := sub.ID
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		substitutions = append(substitutions, models.ProductionSubstitution{
+			ID:                 id,
+			ProductionRecordID: entity.ID,
+			ComponentID:        sub.ComponentID,
+			ComponentItemID:    sub.ComponentItemID,
+			SubstituteItemID:   sub.SubstituteItemID,
+			Share:              sub.Share,
+			Quantity:           sub.Quantity,
+			SubstituteQuantity: sub.SubstituteQuantity,
+			Chosen:             sub.Chosen,
+		})
+	}
 	return &models.ProductionRecord{
 		ID:                   entity.ID,
 		BillOfMaterialsID:    entity.BillOfMaterialsID,
+		ManufacturingOrderID: entity.ManufacturingOrderID,
 		ProducedProductID:    entity.ProducedProductID,
 		ProductionQuantity:   entity.ProductionQuantity,
+		ScrapQuantity:        entity.ScrapQuantity,
 		ActualProductionCost: entity.ActualProductionCost,
+		LaborCost:            entity.LaborCost,
+		OverheadCost:         entity.OverheadCost,
+		YieldVariance:        entity.YieldVariance,
+		DisassembledQuantity: entity.DisassembledQuantity,
 		WarehouseID:          entity.WarehouseID,
 		ProducedAt:           entity.ProducedAt,
 		CreatedBy:            entity.CreatedBy,
+		ReversedAt:           entity.ReversedAt,
+		ReversedBy:           entity.ReversedBy,
+		ReversalReason:       entity.ReversalReason,
+		Lots:                 lots,
+		Substitutions:        substitutions,
 	}
 }
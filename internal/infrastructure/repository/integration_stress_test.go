@@ -40,18 +40,23 @@ func TestHighConcurrencyStockAndBOM(t *testing.T) {
 	itemRepo := repository.NewGormItemRepository(gormDB)
 	bomRepo := repository.NewGormBomRepository(gormDB, txManager)
 	productionRepo := repository.NewGormProductionRecordRepository(gormDB)
+	workCenterRepo := repository.NewGormWorkCenterRepository(gormDB)
 	stockRepo := repository.NewGormStockRepository(gormDB)
 	stockMoveRepo := repository.NewGormStockMovementRepository(gormDB)
 	stockLedgerRepo := repository.NewGormStockLedgerRepository(gormDB)
+	lotRepo := repository.NewGormStockLotRepository(gormDB)
+	costLayerRepo := repository.NewGormCostLayerRepository(gormDB)
+	reservationRepo := repository.NewGormReservationRepository(gormDB)
 	warehouseRepo := repository.NewGormWarehouseRepository(gormDB)
 	binRepo := repository.NewGormBinRepository(gormDB)
+	unitRepo := repository.NewGormUnitRepository(gormDB)
 	userRepo := repository.NewGormUserRepository(gormDB)
 	auditRepo := db.NewGormAuditRepository(gormDB)
 
 	// Services
 	auditService := usecase.NewAuditService(auditRepo)
-	stockUsecase := stock_uc.NewUseCase(txManager, stockRepo, stockMoveRepo, stockLedgerRepo, warehouseRepo, binRepo, itemRepo, auditService)
-	bomUsecase := bom_uc.NewBOMUsecase(txManager, bomRepo, productionRepo, stockRepo, stockMoveRepo, stockLedgerRepo, itemRepo, auditService)
+	stockUsecase := stock_uc.NewUseCase(txManager, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, reservationRepo, warehouseRepo, binRepo, itemRepo, unitRepo, auditService)
+	bomUsecase := bom_uc.NewBOMUsecase(txManager, bomRepo, productionRepo, stockRepo, stockMoveRepo, stockLedgerRepo, lotRepo, costLayerRepo, reservationRepo, itemRepo, unitRepo, workCenterRepo, auditService)
 
 	// 0. Setup Test Data
 	testUser := &identity.User{
@@ -128,7 +133,7 @@ func TestHighConcurrencyStockAndBOM(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			<-startSignal
-			_, err := stockUsecase.CreateStockMovement(userCtx, compItem.ID, warehouse.ID, bin.ID, stock.MovementTypeOut, 1.0, "Stress Test Move")
+			_, err := stockUsecase.CreateStockMovement(userCtx, compItem.ID, warehouse.ID, bin.ID, stock.MovementTypeOut, 1.0, "", 0.0, "Stress Test Move", nil)
 			if err != nil {
 				errorsChan <- fmt.Errorf("StockMovement %d failed: %w", id, err)
 			}
@@ -140,7 +145,7 @@ func TestHighConcurrencyStockAndBOM(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			<-startSignal
-			_, _, err := bomUsecase.ProduceItem(userCtx, testBOM.ID, warehouse.ID, testUser.ID, 1.0)
+			_, _, err := bomUsecase.ProduceItem(userCtx, testBOM.ID, warehouse.ID, testUser.ID, 1.0, bom.ProductionOptions{})
 			if err != nil {
 				errorsChan <- fmt.Errorf("ProduceItem %d failed: %w", id, err)
 			}
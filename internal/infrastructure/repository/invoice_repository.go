@@ -8,7 +8,9 @@ import (
 	"github.com/google/uuid"
 	"doligo_001/internal/domain/invoice"
 	"doligo_001/internal/infrastructure/db/models"
+	invoice_uc "doligo_001/internal/usecase/invoice"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type invoiceRepository struct {
@@ -19,6 +21,10 @@ func NewInvoiceRepository(db *gorm.DB) *invoiceRepository {
 	return &invoiceRepository{db: db}
 }
 
+func (r *invoiceRepository) WithTx(tx *gorm.DB) invoice_uc.Repository {
+	return &invoiceRepository{db: tx}
+}
+
 func (r *invoiceRepository) Create(ctx context.Context, domainInvoice *invoice.Invoice) error {
 	if domainInvoice.CreatedBy == uuid.Nil {
 		return errors.New("created_by is required")
@@ -38,19 +44,110 @@ func (r *invoiceRepository) FindByID(ctx context.Context, id uuid.UUID) (*invoic
 
 func (r *invoiceRepository) FindByIDWithDetails(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
 	var modelInvoice models.Invoice
-	err := r.db.WithContext(ctx).Preload("Lines").Preload("ThirdParty").First(&modelInvoice, "id = ?", id).Error
+	err := r.db.WithContext(ctx).Preload("Lines").Preload("ThirdParty").Preload("OriginalInvoice").First(&modelInvoice, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return toInvoiceDomain(&modelInvoice), nil
+}
+
+func (r *invoiceRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	var modelInvoice models.Invoice
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&modelInvoice, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.db.WithContext(ctx).Where("invoice_id = ?", id).Find(&modelInvoice.Lines).Error; err != nil {
+		return nil, err
+	}
 	return toInvoiceDomain(&modelInvoice), nil
 }
 
+func (r *invoiceRepository) ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error) {
+	var modelInvoices []models.Invoice
+	err := r.db.WithContext(ctx).Preload("Lines").
+		Where("original_invoice_id = ? AND document_type = ?", invoiceID, string(invoice.TypeCreditNote)).
+		Order("date, created_at").Find(&modelInvoices).Error
+	if err != nil {
+		return nil, err
+	}
+	creditNotes := make([]*invoice.Invoice, len(modelInvoices))
+	for i := range modelInvoices {
+		creditNotes[i] = toInvoiceDomain(&modelInvoices[i])
+	}
+	return creditNotes, nil
+}
+
+func (r *invoiceRepository) ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*invoice.Invoice, error) {
+	var modelInvoices []models.Invoice
+	err := r.db.WithContext(ctx).Where("third_party_id = ?", thirdPartyID).
+		Order("date, created_at").Find(&modelInvoices).Error
+	if err != nil {
+		return nil, err
+	}
+	invoices := make([]*invoice.Invoice, len(modelInvoices))
+	for i := range modelInvoices {
+		invoices[i] = toInvoiceDomain(&modelInvoices[i])
+	}
+	return invoices, nil
+}
+
+// creditedQuantityRow is a row of the credited quantities query.
+type creditedQuantityRow struct {
+	OriginalLineID uuid.UUID `gorm:"column:original_line_id"`
+	Quantity       float64   `gorm:"column:quantity"`
+}
+
+func (r *invoiceRepository) CreditedQuantities(ctx context.Context, invoiceID uuid.UUID) (map[uuid.UUID]float64, error) {
+	var rows []creditedQuantityRow
+	err := r.db.WithContext(ctx).Table("invoice_lines il").
+		Select("il.original_line_id, SUM(il.quantity) AS quantity").
+		Joins("JOIN invoices cn ON cn.id = il.invoice_id").
+		Where("cn.original_invoice_id = ? AND cn.document_type = ?", invoiceID, string(invoice.TypeCreditNote)).
+		Where("cn.status NOT IN ?", []string{string(invoice.StatusDraft), string(invoice.StatusCancelled)}).
+		Where("cn.deleted_at IS NULL AND il.deleted_at IS NULL AND il.original_line_id IS NOT NULL").
+		Group("il.original_line_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	credited := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		credited[row.OriginalLineID] = row.Quantity
+	}
+	return credited, nil
+}
+
+// Update saves the invoice with its lines; lines no longer on the invoice are deleted.
 func (r *invoiceRepository) Update(ctx context.Context, domainInvoice *invoice.Invoice) error {
 	if domainInvoice.UpdatedBy == uuid.Nil {
 		return errors.New("updated_by is required")
 	}
 	modelInvoice := toInvoiceModel(domainInvoice)
-	return r.db.WithContext(ctx).Save(modelInvoice).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lineIDs := make([]uuid.UUID, len(modelInvoice.Lines))
+		for i, line := range modelInvoice.Lines {
+			lineIDs[i] = line.ID
+		}
+		removed := tx.Where("invoice_id = ?", modelInvoice.ID)
+		if len(lineIDs) > 0 {
+			removed = removed.Where("id NOT IN ?", lineIDs)
+		}
+		if err := removed.Delete(&models.InvoiceLine{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(modelInvoice).Error
+	})
+}
+
+func (r *invoiceRepository) UpdatePDFStatus(ctx context.Context, domainInvoice *invoice.Invoice) error {
+	return r.db.WithContext(ctx).Model(&models.Invoice{}).Where("id = ?", domainInvoice.ID).
+		Updates(map[string]interface{}{
+			"pdf_status":        domainInvoice.PDFStatus,
+			"pdf_url":           domainInvoice.PDFUrl,
+			"pdf_error_message": domainInvoice.PDFErrorMessage,
+		}).Error
 }
 
 func (r *invoiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -76,12 +173,27 @@ func toInvoiceModel(d *invoice.Invoice) *models.Invoice {
 	return &models.Invoice{
 		BaseModel: models.BaseModel{
 			ID:        d.ID,
+			CreatedAt: d.CreatedAt,
 			CreatedBy: d.CreatedBy,
 			UpdatedBy: d.UpdatedBy,
 		},
 		ThirdPartyID: d.ThirdPartyID,
+		DocumentType: string(d.DocumentType),
+		OriginalInvoiceID: d.OriginalInvoiceID,
+		ReturnWarehouseID: d.ReturnWarehouseID,
+		ReturnBinID:       d.ReturnBinID,
 		Number:       d.Number,
 		Date:         d.Date,
+		PaymentTermDays: d.PaymentTermDays,
+		DueDate:      d.DueDate,
+		Status:       string(d.Status),
+		AmountPaid:   d.AmountPaid,
+		AmountCredited: d.AmountCredited,
+		ValidatedAt:  d.ValidatedAt,
+		ValidatedBy:  d.ValidatedBy,
+		SentAt:       d.SentAt,
+		CancelledAt:  d.CancelledAt,
+		CancellationReason: d.CancellationReason,
 		TotalAmount:  d.TotalAmount,
 		TotalCost:    d.TotalCost,
 		TotalTax:     d.TotalTax,
@@ -96,20 +208,24 @@ func toInvoiceLineModel(d *invoice.InvoiceLine) *models.InvoiceLine {
 	return &models.InvoiceLine{
 		BaseModel: models.BaseModel{
 			ID:        d.ID,
+			CreatedAt: d.CreatedAt,
 			CreatedBy: d.CreatedBy,
 			UpdatedBy: d.UpdatedBy,
 		},
-		InvoiceID:   d.InvoiceID,
-		ItemID:      d.ItemID,
-		Description: d.Description,
-		Quantity:    d.Quantity,
-		UnitPrice:   d.UnitPrice,
-		UnitCost:    d.UnitCost,
-		TaxRate:     d.TaxRate,
-		TaxAmount:   d.TaxAmount,
-		NetPrice:    d.NetPrice,
-		TotalAmount: d.TotalAmount,
-		TotalCost:   d.TotalCost,
+		InvoiceID:     d.InvoiceID,
+		OriginalLineID: d.OriginalLineID,
+		ItemID:        d.ItemID,
+		Description:   d.Description,
+		Quantity:      d.Quantity,
+		UnitOfMeasure: d.UnitOfMeasure,
+		BaseQuantity:  d.BaseQuantity,
+		UnitPrice:     d.UnitPrice,
+		UnitCost:      d.UnitCost,
+		TaxRate:       d.TaxRate,
+		TaxAmount:     d.TaxAmount,
+		NetPrice:      d.NetPrice,
+		TotalAmount:   d.TotalAmount,
+		TotalCost:     d.TotalCost,
 	}
 }
 
@@ -122,8 +238,22 @@ func toInvoiceDomain(m *models.Invoice) *invoice.Invoice {
 	domainInvoice := &invoice.Invoice{
 		ID:           m.ID,
 		ThirdPartyID: m.ThirdPartyID,
+		DocumentType: invoice.DocumentType(m.DocumentType),
+		OriginalInvoiceID: m.OriginalInvoiceID,
+		ReturnWarehouseID: m.ReturnWarehouseID,
+		ReturnBinID:       m.ReturnBinID,
 		Number:       m.Number,
 		Date:         m.Date,
+		PaymentTermDays: m.PaymentTermDays,
+		DueDate:      m.DueDate,
+		Status:       invoice.Status(m.Status),
+		AmountPaid:   m.AmountPaid,
+		AmountCredited: m.AmountCredited,
+		ValidatedAt:  m.ValidatedAt,
+		ValidatedBy:  m.ValidatedBy,
+		SentAt:       m.SentAt,
+		CancelledAt:  m.CancelledAt,
+		CancellationReason: m.CancellationReason,
 		TotalAmount:  m.TotalAmount,
 		TotalCost:    m.TotalCost,
 		TotalTax:     m.TotalTax,
@@ -141,26 +271,32 @@ func toInvoiceDomain(m *models.Invoice) *invoice.Invoice {
 	if m.ThirdParty.ID != uuid.Nil {
 		domainInvoice.ThirdParty = toThirdPartyDomain(&m.ThirdParty)
 	}
+	if m.OriginalInvoice != nil {
+		domainInvoice.OriginalInvoice = toInvoiceDomain(m.OriginalInvoice)
+	}
 
 	return domainInvoice
 }
 
 func toInvoiceLineDomain(m *models.InvoiceLine) *invoice.InvoiceLine {
 	return &invoice.InvoiceLine{
-		ID:          m.ID,
-		InvoiceID:   m.InvoiceID,
-		ItemID:      m.ItemID,
-		Description: m.Description,
-		Quantity:    m.Quantity,
-		UnitPrice:   m.UnitPrice,
-		UnitCost:    m.UnitCost,
-		TaxRate:     m.TaxRate,
-		TaxAmount:   m.TaxAmount,
-		NetPrice:    m.NetPrice,
-		TotalAmount: m.TotalAmount,
-		TotalCost:   m.TotalCost,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:            m.ID,
+		InvoiceID:     m.InvoiceID,
+		OriginalLineID: m.OriginalLineID,
+		ItemID:        m.ItemID,
+		Description:   m.Description,
+		Quantity:      m.Quantity,
+		UnitOfMeasure: m.UnitOfMeasure,
+		BaseQuantity:  m.BaseQuantity,
+		UnitPrice:     m.UnitPrice,
+		UnitCost:      m.UnitCost,
+		TaxRate:       m.TaxRate,
+		TaxAmount:     m.TaxAmount,
+		NetPrice:      m.NetPrice,
+		TotalAmount:   m.TotalAmount,
+		TotalCost:     m.TotalCost,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
 	}
 }
 
@@ -75,18 +75,23 @@ func (r *gormItemRepository) List(ctx context.Context) ([]*item.Item, error) {
 // toItemDomainEntity converts a GORM item model to a domain entity.
 func toItemDomainEntity(model *models.Item) *item.Item {
 	return &item.Item{
-		ID:          model.ID,
-		Name:        model.Name,
-		Description: model.Description,
-		Type:        item.ItemType(model.Type),
-		CostPrice:   model.CostPrice,
-		SalePrice:   model.SalePrice,
-		AverageCost: model.AverageCost,
-		IsActive:    model.IsActive,
-		CreatedAt:   model.CreatedAt,
-		UpdatedAt:   model.UpdatedAt,
-		CreatedBy:   model.CreatedBy,
-		UpdatedBy:   model.UpdatedBy,
+		ID:            model.ID,
+		Name:          model.Name,
+		Description:   model.Description,
+		Type:          item.ItemType(model.Type),
+		CostPrice:     model.CostPrice,
+		SalePrice:     model.SalePrice,
+		AverageCost:   model.AverageCost,
+		StandardCost:  model.StandardCost,
+		CostingMethod: item.CostingMethod(model.CostingMethod),
+		TrackingMode:  item.TrackingMode(model.TrackingMode),
+		BaseUnit:      baseUnit(model.BaseUoM),
+		LeadTimeDays:  model.LeadTimeDays,
+		IsActive:      model.IsActive,
+		CreatedAt:     model.CreatedAt,
+		UpdatedAt:     model.UpdatedAt,
+		CreatedBy:     model.CreatedBy,
+		UpdatedBy:     model.UpdatedBy,
 	}
 }
 
@@ -100,12 +105,32 @@ func fromItemDomainEntity(entity *item.Item) *models.Item {
 			CreatedBy: entity.CreatedBy,
 			UpdatedBy: entity.UpdatedBy,
 		},
-		Name:        entity.Name,
-		Description: entity.Description,
-		Type:        string(entity.Type),
-		CostPrice:   entity.CostPrice,
-		SalePrice:   entity.SalePrice,
-		AverageCost: entity.AverageCost,
-		IsActive:    entity.IsActive,
+		Name:          entity.Name,
+		Description:   entity.Description,
+		Type:          string(entity.Type),
+		CostPrice:     entity.CostPrice,
+		SalePrice:     entity.SalePrice,
+		AverageCost:   entity.AverageCost,
+		StandardCost:  entity.StandardCost,
+		CostingMethod: string(entity.CostingMethod),
+		TrackingMode:  string(entity.TrackingMode),
+		BaseUoM:       baseUoM(entity.BaseUnit),
+		LeadTimeDays:  entity.LeadTimeDays,
+		IsActive:      entity.IsActive,
 	}
 }
+
+// baseUnit and baseUoM map an item without base unit to a NULL base_uom column.
+func baseUnit(code *string) string {
+	if code == nil {
+		return ""
+	}
+	return *code
+}
+
+func baseUoM(code string) *string {
+	if code == "" {
+		return nil
+	}
+	return &code
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/bom"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormManufacturingOrderRepository is a GORM implementation of the bom.ManufacturingOrderRepository.
+type gormManufacturingOrderRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormManufacturingOrderRepository) WithTx(tx *gorm.DB) bom.ManufacturingOrderRepository {
+	return NewGormManufacturingOrderRepository(tx)
+}
+
+// NewGormManufacturingOrderRepository creates a new gormManufacturingOrderRepository.
+func NewGormManufacturingOrderRepository(db *gorm.DB) bom.ManufacturingOrderRepository {
+	return &gormManufacturingOrderRepository{db: db}
+}
+
+func (r *gormManufacturingOrderRepository) Create(ctx context.Context, o *bom.ManufacturingOrder) error {
+	if o.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromManufacturingOrderDomainEntity(o)
+	if err := r.db.WithContext(ctx).Omit("Lots").Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create manufacturing order: %w", err)
+	}
+	return nil
+}
+
+func (r *gormManufacturingOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.ManufacturingOrder, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+func (r *gormManufacturingOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*bom.ManufacturingOrder, error) {
+	return r.get(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *gormManufacturingOrderRepository) get(query *gorm.DB, id uuid.UUID) (*bom.ManufacturingOrder, error) {
+	var model models.ManufacturingOrder
+	err := query.Preload("Components").Preload("Lots").First(&model, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, bom.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get manufacturing order: %w", err)
+	}
+	return toManufacturingOrderDomainEntity(&model), nil
+}
+
+func (r *gormManufacturingOrderRepository) List(ctx context.Context, filter bom.OrderFilter) ([]*bom.ManufacturingOrder, error) {
+	query := r.db.WithContext(ctx).Preload("Components").Order("created_at DESC")
+	if filter.ProductID != nil {
+		query = query.Where("product_id = ?", *filter.ProductID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	var modelList []models.ManufacturingOrder
+	if err := query.Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list manufacturing orders: %w", err)
+	}
+	domainList := make([]*bom.ManufacturingOrder, len(modelList))
+	for i := range modelList {
+		domainList[i] = toManufacturingOrderDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormManufacturingOrderRepository) Update(ctx context.Context, o *bom.ManufacturingOrder) error {
+	if o.UpdatedBy == uuid.Nil {
+		return errors.New("updated_by is required")
+	}
+	return r.db.WithContext(ctx).Model(&models.ManufacturingOrder{}).Where("id = ?", o.ID).Updates(map[string]interface{}{
+		"status":               string(o.Status),
+		"produced_quantity":    o.ProducedQuantity,
+		"scrap_quantity":       o.ScrapQuantity,
+		"released_at":          o.ReleasedAt,
+		"started_at":           o.StartedAt,
+		"completed_at":         o.CompletedAt,
+		"actual_cost":          o.ActualCost,
+		"received_cost":        o.ReceivedCost,
+		"production_record_id": o.ProductionRecordID,
+		"updated_at":           o.UpdatedAt,
+		"updated_by":           o.UpdatedBy,
+	}).Error
+}
+
+func (r *gormManufacturingOrderRepository) UpdateComponent(ctx context.Context, c *bom.OrderComponent) error {
+	return r.db.WithContext(ctx).Model(&models.ManufacturingOrderComponent{}).Where("id = ?", c.ID).Updates(map[string]interface{}{
+		"issued_quantity": c.IssuedQuantity,
+		"issued_cost":     c.IssuedCost,
+		"scrap_quantity":  c.ScrapQuantity,
+		"reservation_id":  c.ReservationID,
+	}).Error
+}
+
+func (r *gormManufacturingOrderRepository) AddLot(ctx context.Context, l *bom.OrderLot) error {
+	model := fromOrderLotDomainEntity(l)
+	return r.db.WithContext(ctx).Create(model).Error
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toManufacturingOrderDomainEntity(model *models.ManufacturingOrder) *bom.ManufacturingOrder {
+	o := &bom.ManufacturingOrder{
+		ID:                 model.ID,
+		ProductID:          model.ProductID,
+		BillOfMaterialsID:  model.BillOfMaterialsID,
+		WarehouseID:        model.WarehouseID,
+		Quantity:           model.Quantity,
+		ProducedQuantity:   model.ProducedQuantity,
+		ScrapQuantity:      model.ScrapQuantity,
+		Status:             bom.OrderStatus(model.Status),
+		PlannedStart:       model.PlannedStart,
+		PlannedEnd:         model.PlannedEnd,
+		ReleasedAt:         model.ReleasedAt,
+		StartedAt:          model.StartedAt,
+		CompletedAt:        model.CompletedAt,
+		ActualCost:         model.ActualCost,
+		ReceivedCost:       model.ReceivedCost,
+		ProductionRecordID: model.ProductionRecordID,
+		CreatedAt:          model.CreatedAt,
+		UpdatedAt:          model.UpdatedAt,
+		CreatedBy:          model.CreatedBy,
+		UpdatedBy:          model.UpdatedBy,
+	}
+	for i := range model.Components {
+		c := &model.Components[i]
+		o.Components = append(o.Components, &bom.OrderComponent{
+			ID:               c.ID,
+			OrderID:          c.OrderID,
+			ItemID:           c.ItemID,
+			QuantityPer:      c.QuantityPer,
+			RequiredQuantity: c.RequiredQuantity,
+			IssuedQuantity:   c.IssuedQuantity,
+			IssuedCost:       c.IssuedCost,
+			ScrapQuantity:    c.ScrapQuantity,
+			UnitOfMeasure:    c.UnitOfMeasure,
+			ReservationID:    c.ReservationID,
+		})
+	}
+	for _, l := range model.Lots {
+		o.Lots = append(o.Lots, bom.OrderLot{
+			ID:        l.ID,
+			OrderID:   l.OrderID,
+			ItemID:    l.ItemID,
+			LotNumber: l.LotNumber,
+			Quantity:  l.Quantity,
+			Role:      bom.LotRole(l.Role),
+		})
+	}
+	return o
+}
+
+func fromManufacturingOrderDomainEntity(entity *bom.ManufacturingOrder) *models.ManufacturingOrder {
+	model := &models.ManufacturingOrder{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		ProductID:          entity.ProductID,
+		BillOfMaterialsID:  entity.BillOfMaterialsID,
+		WarehouseID:        entity.WarehouseID,
+		Quantity:           entity.Quantity,
+		ProducedQuantity:   entity.ProducedQuantity,
+		ScrapQuantity:      entity.ScrapQuantity,
+		Status:             string(entity.Status),
+		PlannedStart:       entity.PlannedStart,
+		PlannedEnd:         entity.PlannedEnd,
+		ReleasedAt:         entity.ReleasedAt,
+		StartedAt:          entity.StartedAt,
+		CompletedAt:        entity.CompletedAt,
+		ActualCost:         entity.ActualCost,
+		ReceivedCost:       entity.ReceivedCost,
+		ProductionRecordID: entity.ProductionRecordID,
+	}
+	for _, c := range entity.Components {
+		model.Components = append(model.Components, models.ManufacturingOrderComponent{
+			ID:               c.ID,
+			OrderID:          entity.ID,
+			ItemID:           c.ItemID,
+			QuantityPer:      c.QuantityPer,
+			RequiredQuantity: c.RequiredQuantity,
+			IssuedQuantity:   c.IssuedQuantity,
+			IssuedCost:       c.IssuedCost,
+			ScrapQuantity:    c.ScrapQuantity,
+			UnitOfMeasure:    c.UnitOfMeasure,
+			ReservationID:    c.ReservationID,
+		})
+	}
+	return model
+}
+
+func fromOrderLotDomainEntity(entity *bom.OrderLot) *models.ManufacturingOrderLot {
+	return &models.ManufacturingOrderLot{
+		ID:        entity.ID,
+		OrderID:   entity.OrderID,
+		ItemID:    entity.ItemID,
+		LotNumber: entity.LotNumber,
+		Quantity:  entity.Quantity,
+		Role:      string(entity.Role),
+	}
+}
@@ -21,36 +21,74 @@ func NewGormMarginRepository(db *gorm.DB) *GormMarginRepository {
 	return &GormMarginRepository{db: db}
 }
 
+// serviceCostSelect splits the cost of goods sold of the sales CTE into inputs and services:
+// the services are the labor and overhead of the units sold, at the average conversion cost per
+// unit of the production runs of the product up to the end of the period.
+const serviceCostSelect = `,
+		conversion AS (
+			SELECT
+				produced_product_id AS product_id,
+				SUM(labor_cost + overhead_cost) / NULLIF(SUM(production_quantity), 0) AS unit_cost
+			FROM production_records
+			WHERE produced_at <= ?
+			GROUP BY produced_product_id
+		),
+		split AS (
+			SELECT
+				s.*,
+				LEAST(s.total_cost, s.quantity * COALESCE(c.unit_cost, 0)) AS service_cost
+			FROM sales s
+			LEFT JOIN conversion c ON c.product_id = s.product_id
+		)
+		SELECT
+			s.product_id,
+			s.product_name,
+			s.total_cost - s.service_cost AS total_input_cost,
+			s.service_cost AS total_service_cost,
+			s.total_selling_price,
+			s.total_taxes
+		FROM split s`
+
+// creditSign counts the lines of credit notes against the sales of their period, so that
+// credited quantities, revenue and costs reduce the margin.
+const creditSign = `(CASE WHEN inv.document_type = 'CREDIT_NOTE' THEN -1 ELSE 1 END)`
+
+// marginRow is a row of the margin queries.
+type marginRow struct {
+	ProductID         uuid.UUID `gorm:"column:product_id"`
+	ProductName       string    `gorm:"column:product_name"`
+	TotalInputCost    float64   `gorm:"column:total_input_cost"`
+	TotalServiceCost  float64   `gorm:"column:total_service_cost"`
+	TotalSellingPrice float64   `gorm:"column:total_selling_price"`
+	TotalTaxes        float64   `gorm:"column:total_taxes"`
+}
+
 // GetMarginReport retrieves the margin report for a single product within a given period.
 // This implementation now uses raw SQL to aggregate data from the real `invoice_lines` table.
+// Drafts and cancelled invoices are left out: only issued invoices count as sales, less the
+// issued credit notes dated in the period.
 func (r *GormMarginRepository) GetMarginReport(ctx context.Context, productID uuid.UUID, startDate, endDate time.Time) (*margin.MarginReport, error) {
-	// Technical Debt: This query only considers sales data from invoices.
-	// It does not yet factor in production costs, service costs, or other operational expenses.
-	// TotalTaxes are also not yet implemented in the invoice domain.
+	// Technical Debt: TotalTaxes are not yet implemented in the invoice domain.
 	query := `
-		SELECT
-			il.item_id AS product_id,
-			i.name AS product_name,
-			SUM(il.total_cost) AS total_input_cost,
-			SUM(il.total_amount) AS total_selling_price,
-			SUM(il.tax_amount * il.quantity) AS total_taxes
-		FROM invoice_lines il
-		JOIN items i ON il.item_id = i.id
-		JOIN invoices inv ON il.invoice_id = inv.id
-		WHERE il.item_id = ?
-		AND inv.date >= ? AND inv.date <= ?
-		GROUP BY il.item_id, i.name
-	`
-
-	var result struct {
-		ProductID         uuid.UUID `gorm:"column:product_id"`
-		ProductName       string    `gorm:"column:product_name"`
-		TotalInputCost    float64   `gorm:"column:total_input_cost"`
-		TotalSellingPrice float64   `gorm:"column:total_selling_price"`
-		TotalTaxes        float64   `gorm:"column:total_taxes"`
-	}
-
-	err := r.db.WithContext(ctx).Raw(query, productID, startDate, endDate).Scan(&result).Error
+		WITH sales AS (
+			SELECT
+				il.item_id AS product_id,
+				i.name AS product_name,
+				SUM(` + creditSign + ` * COALESCE(il.base_quantity, il.quantity)) AS quantity,
+				SUM(` + creditSign + ` * il.total_cost) AS total_cost,
+				SUM(` + creditSign + ` * il.total_amount) AS total_selling_price,
+				SUM(` + creditSign + ` * il.tax_amount * il.quantity) AS total_taxes
+			FROM invoice_lines il
+			JOIN items i ON il.item_id = i.id
+			JOIN invoices inv ON il.invoice_id = inv.id
+			WHERE il.item_id = ?
+			AND inv.date >= ? AND inv.date <= ?
+			AND inv.status NOT IN ('DRAFT', 'CANCELLED')
+			GROUP BY il.item_id, i.name
+		)` + serviceCostSelect
+
+	var result marginRow
+	err := r.db.WithContext(ctx).Raw(query, productID, startDate, endDate, endDate).Scan(&result).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil // No records found
@@ -64,7 +102,7 @@ func (r *GormMarginRepository) GetMarginReport(ctx context.Context, productID uu
 		ProductID:         result.ProductID,
 		ProductName:       result.ProductName,
 		TotalInputCost:    result.TotalInputCost,
-		TotalServiceCost:  0, // Technical Debt: Service costs are not yet tracked.
+		TotalServiceCost:  result.TotalServiceCost,
 		TotalTaxes:        result.TotalTaxes,
 		TotalSellingPrice: result.TotalSellingPrice,
 	}
@@ -80,29 +118,26 @@ func (r *GormMarginRepository) GetMarginReport(ctx context.Context, productID uu
 // ListMarginReports retrieves margin reports for all products within a given period.
 func (r *GormMarginRepository) ListMarginReports(ctx context.Context, startDate, endDate time.Time) ([]*margin.MarginReport, error) {
 	query := `
-		SELECT
-			il.item_id AS product_id,
-			i.name AS product_name,
-			SUM(il.total_cost) AS total_input_cost,
-			SUM(il.total_amount) AS total_selling_price,
-			SUM(il.tax_amount * il.quantity) AS total_taxes
-		FROM invoice_lines il
-		JOIN items i ON il.item_id = i.id
-		JOIN invoices inv ON il.invoice_id = inv.id
-		WHERE inv.date >= ? AND inv.date <= ?
-		GROUP BY il.item_id, i.name
-		ORDER BY i.name
+		WITH sales AS (
+			SELECT
+				il.item_id AS product_id,
+				i.name AS product_name,
+				SUM(` + creditSign + ` * COALESCE(il.base_quantity, il.quantity)) AS quantity,
+				SUM(` + creditSign + ` * il.total_cost) AS total_cost,
+				SUM(` + creditSign + ` * il.total_amount) AS total_selling_price,
+				SUM(` + creditSign + ` * il.tax_amount * il.quantity) AS total_taxes
+			FROM invoice_lines il
+			JOIN items i ON il.item_id = i.id
+			JOIN invoices inv ON il.invoice_id = inv.id
+			WHERE inv.date >= ? AND inv.date <= ?
+			AND inv.status NOT IN ('DRAFT', 'CANCELLED')
+			GROUP BY il.item_id, i.name
+		)` + serviceCostSelect + `
+		ORDER BY s.product_name
 	`
 
-	var results []struct {
-		ProductID         uuid.UUID `gorm:"column:product_id"`
-		ProductName       string    `gorm:"column:product_name"`
-		TotalInputCost    float64   `gorm:"column:total_input_cost"`
-		TotalSellingPrice float64   `gorm:"column:total_selling_price"`
-		TotalTaxes        float64   `gorm:"column:total_taxes"`
-	}
-
-	err := r.db.WithContext(ctx).Raw(query, startDate, endDate).Scan(&results).Error
+	var results []marginRow
+	err := r.db.WithContext(ctx).Raw(query, startDate, endDate, endDate).Scan(&results).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to list margin reports: %w", err)
 	}
@@ -115,7 +150,7 @@ func (r *GormMarginRepository) ListMarginReports(ctx context.Context, startDate,
 			ProductID:         result.ProductID,
 			ProductName:       result.ProductName,
 			TotalInputCost:    result.TotalInputCost,
-			TotalServiceCost:  0, // Technical Debt: Not tracked.
+			TotalServiceCost:  result.TotalServiceCost,
 			TotalTaxes:        result.TotalTaxes,
 			TotalSellingPrice: result.TotalSellingPrice,
 		}
@@ -127,4 +162,79 @@ func (r *GormMarginRepository) ListMarginReports(ctx context.Context, startDate,
 	}
 
 	return reports, nil
-}
\ No newline at end of file
+}
+
+// receivableRow is a row of the receivables query.
+type receivableRow struct {
+	InvoiceID      uuid.UUID `gorm:"column:invoice_id"`
+	InvoiceNumber  string    `gorm:"column:invoice_number"`
+	ThirdPartyID   uuid.UUID `gorm:"column:third_party_id"`
+	ThirdPartyName string    `gorm:"column:third_party_name"`
+	Date           time.Time `gorm:"column:date"`
+	DueDate        time.Time `gorm:"column:due_date"`
+	Outstanding    float64   `gorm:"column:outstanding"`
+}
+
+// ListReceivables retrieves the invoices issued up to asOf with a balance outstanding at the end of
+// that day. The balance is rebuilt as of the date: payments allocated to the invoice and credit
+// notes validated after it are added back, and an invoice cancelled after it is still outstanding.
+func (r *GormMarginRepository) ListReceivables(ctx context.Context, asOf time.Time) ([]*margin.Receivable, error) {
+	end := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location()).AddDate(0, 0, 1)
+	query := `
+		SELECT * FROM (
+			SELECT
+				inv.id AS invoice_id,
+				inv.number AS invoice_number,
+				inv.third_party_id,
+				tp.name AS third_party_name,
+				inv.date,
+				inv.due_date,
+				inv.total_amount
+					- COALESCE((
+						SELECT SUM(pa.amount)
+						FROM payment_allocations pa
+						JOIN payments p ON p.id = pa.payment_id
+						WHERE pa.invoice_id = inv.id
+						AND p.deleted_at IS NULL
+						AND p.date < ? AND pa.created_at < ?
+					), 0)
+					- COALESCE((
+						SELECT SUM(cn.total_amount)
+						FROM invoices cn
+						WHERE cn.original_invoice_id = inv.id
+						AND cn.deleted_at IS NULL
+						AND cn.status NOT IN ('DRAFT', 'CANCELLED')
+						AND COALESCE(cn.validated_at, cn.date) < ?
+					), 0) AS outstanding
+			FROM invoices inv
+			JOIN third_parties tp ON tp.id = inv.third_party_id
+			WHERE inv.document_type = 'STANDARD'
+			AND inv.deleted_at IS NULL
+			AND inv.status <> 'DRAFT'
+			AND (inv.status <> 'CANCELLED' OR inv.cancelled_at >= ?)
+			AND inv.date < ? AND COALESCE(inv.validated_at, inv.date) < ?
+		) receivables
+		WHERE outstanding > 0.005
+		ORDER BY third_party_name, third_party_id, due_date, invoice_number
+	`
+
+	var results []receivableRow
+	err := r.db.WithContext(ctx).Raw(query, end, end, end, end, end, end).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receivables: %w", err)
+	}
+
+	receivables := make([]*margin.Receivable, len(results))
+	for i, result := range results {
+		receivables[i] = &margin.Receivable{
+			InvoiceID:      result.InvoiceID,
+			InvoiceNumber:  result.InvoiceNumber,
+			ThirdPartyID:   result.ThirdPartyID,
+			ThirdPartyName: result.ThirdPartyName,
+			Date:           result.Date,
+			DueDate:        result.DueDate,
+			Outstanding:    result.Outstanding,
+		}
+	}
+	return receivables, nil
+}
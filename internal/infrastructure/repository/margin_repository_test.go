@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"doligo_001/internal/domain/identity"
+	"doligo_001/internal/domain/invoice"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/thirdparty"
+	"doligo_001/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarginReport_CreditNotesReduceSales(t *testing.T) {
+	if testDB == nil {
+		t.Skip("testDB not initialized, skipping integration test")
+	}
+	ctx, tx, _ := setupTest(t)
+
+	user := &identity.User{
+		ID:        uuid.New(),
+		FirstName: "Test",
+		LastName:  "User",
+		Email:     fmt.Sprintf("test_margin_%d@example.com", time.Now().UnixNano()),
+		Password:  "hash",
+		IsActive:  true,
+	}
+	require.NoError(t, repository.NewGormUserRepository(tx).Create(ctx, user))
+
+	customer := &thirdparty.ThirdParty{
+		ID:       uuid.New(),
+		Name:     "Margin Customer",
+		Email:    fmt.Sprintf("customer_margin_%d@example.com", time.Now().UnixNano()),
+		Type:     thirdparty.Customer,
+		IsActive: true,
+	}
+	customer.SetCreatedBy(user.ID)
+	customer.SetUpdatedBy(user.ID)
+	require.NoError(t, repository.NewGormThirdPartyRepository(tx).Create(ctx, customer))
+
+	product := &item.Item{ID: uuid.New(), Name: "Margin Widget", Type: item.Storable}
+	product.SetCreatedBy(user.ID)
+	product.SetUpdatedBy(user.ID)
+	require.NoError(t, repository.NewGormItemRepository(tx).Create(ctx, product))
+
+	// 10 units sold at 12 costing 5, of which 4 are credited
+	date := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	sale := &invoice.Invoice{
+		ID: uuid.New(), ThirdPartyID: customer.ID, DocumentType: invoice.TypeStandard, Number: "INV-MARGIN-0001",
+		Date: date, DueDate: date, Status: invoice.StatusSent, AmountCredited: 48, TotalAmount: 120, TotalCost: 50,
+	}
+	sale.Lines = []invoice.InvoiceLine{{
+		ID: uuid.New(), InvoiceID: sale.ID, ItemID: product.ID, Description: "Widget", Quantity: 10, BaseQuantity: 10,
+		UnitPrice: 12, UnitCost: 5, NetPrice: 12, TotalAmount: 120, TotalCost: 50,
+	}}
+	originalID, originalLineID := sale.ID, sale.Lines[0].ID
+	creditNote := &invoice.Invoice{
+		ID: uuid.New(), ThirdPartyID: customer.ID, DocumentType: invoice.TypeCreditNote, OriginalInvoiceID: &originalID,
+		Number: "CN-MARGIN-0001", Date: date.AddDate(0, 0, 5), DueDate: date.AddDate(0, 0, 5), Status: invoice.StatusValidated,
+		TotalAmount: 48, TotalCost: 20,
+	}
+	creditNote.Lines = []invoice.InvoiceLine{sale.Lines[0].CreditLine(creditNote.ID, 4)}
+	creditNote.Lines[0].OriginalLineID = &originalLineID
+	invoiceRepo := repository.NewInvoiceRepository(tx)
+	for _, inv := range []*invoice.Invoice{sale, creditNote} {
+		inv.SetCreatedBy(user.ID)
+		inv.SetUpdatedBy(user.ID)
+		require.NoError(t, invoiceRepo.Create(ctx, inv))
+	}
+
+	report, err := repository.NewGormMarginRepository(tx).GetMarginReport(ctx, product.ID, date, date.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	assert.InDelta(t, 72, report.TotalSellingPrice, 1e-9, "credited revenue is deducted")
+	assert.InDelta(t, 30, report.TotalInputCost+report.TotalServiceCost, 1e-9, "credited cost is deducted")
+	assert.InDelta(t, 42, report.GrossMargin, 1e-9)
+
+	// In a period holding only the credit note, the product shows a negative sale
+	report, err = repository.NewGormMarginRepository(tx).GetMarginReport(ctx, product.ID, date.AddDate(0, 0, 1), date.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	assert.InDelta(t, -48, report.TotalSellingPrice, 1e-9)
+	assert.InDelta(t, -28, report.GrossMargin, 1e-9)
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"doligo_001/internal/domain/mrp"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// gormForecastRepository is a GORM implementation of the mrp.ForecastRepository.
+type gormForecastRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormForecastRepository) WithTx(tx *gorm.DB) mrp.ForecastRepository {
+	return NewGormForecastRepository(tx)
+}
+
+// NewGormForecastRepository creates a new gormForecastRepository.
+func NewGormForecastRepository(db *gorm.DB) mrp.ForecastRepository {
+	return &gormForecastRepository{db: db}
+}
+
+func (r *gormForecastRepository) Create(ctx context.Context, f *mrp.Forecast) error {
+	if f.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromForecastDomainEntity(f)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create forecast: %w", err)
+	}
+	return nil
+}
+
+func (r *gormForecastRepository) GetByID(ctx context.Context, id uuid.UUID) (*mrp.Forecast, error) {
+	var model models.MrpForecast
+	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, mrp.ErrForecastNotFound
+		}
+		return nil, fmt.Errorf("failed to get forecast: %w", err)
+	}
+	return toForecastDomainEntity(&model), nil
+}
+
+func (r *gormForecastRepository) List(ctx context.Context, filter mrp.ForecastFilter) ([]*mrp.Forecast, error) {
+	query := r.db.WithContext(ctx).Model(&models.MrpForecast{})
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.To != nil {
+		query = query.Where("due_date <= ?", *filter.To)
+	}
+
+	var modelList []models.MrpForecast
+	if err := query.Order("due_date, item_id").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list forecasts: %w", err)
+	}
+	domainList := make([]*mrp.Forecast, len(modelList))
+	for i := range modelList {
+		domainList[i] = toForecastDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormForecastRepository) Update(ctx context.Context, f *mrp.Forecast) error {
+	err := r.db.WithContext(ctx).Model(&models.MrpForecast{}).Where("id = ?", f.ID).Updates(map[string]interface{}{
+		"quantity":   f.Quantity,
+		"due_date":   f.DueDate,
+		"notes":      f.Notes,
+		"updated_at": f.UpdatedAt,
+		"updated_by": f.UpdatedBy,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update forecast: %w", err)
+	}
+	return nil
+}
+
+func (r *gormForecastRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.MrpForecast{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete forecast: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return mrp.ErrForecastNotFound
+	}
+	return nil
+}
+
+// gormMrpRunRepository is a GORM implementation of the mrp.RunRepository.
+type gormMrpRunRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormMrpRunRepository) WithTx(tx *gorm.DB) mrp.RunRepository {
+	return NewGormMrpRunRepository(tx)
+}
+
+// NewGormMrpRunRepository creates a new gormMrpRunRepository.
+func NewGormMrpRunRepository(db *gorm.DB) mrp.RunRepository {
+	return &gormMrpRunRepository{db: db}
+}
+
+func (r *gormMrpRunRepository) Create(ctx context.Context, run *mrp.Run) error {
+	if err := r.db.WithContext(ctx).Create(fromMrpRunDomainEntity(run)).Error; err != nil {
+		return fmt.Errorf("failed to create MRP run: %w", err)
+	}
+	return nil
+}
+
+func (r *gormMrpRunRepository) GetByID(ctx context.Context, id uuid.UUID) (*mrp.Run, error) {
+	var model models.MrpRun
+	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, mrp.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("failed to get MRP run: %w", err)
+	}
+	return toMrpRunDomainEntity(&model), nil
+}
+
+func (r *gormMrpRunRepository) List(ctx context.Context, warehouseID *uuid.UUID) ([]*mrp.Run, error) {
+	query := r.db.WithContext(ctx).Model(&models.MrpRun{})
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+
+	var modelList []models.MrpRun
+	if err := query.Order("created_at DESC").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list MRP runs: %w", err)
+	}
+	domainList := make([]*mrp.Run, len(modelList))
+	for i := range modelList {
+		domainList[i] = toMrpRunDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormMrpRunRepository) Update(ctx context.Context, run *mrp.Run) error {
+	err := r.db.WithContext(ctx).Model(&models.MrpRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":              string(run.Status),
+		"error_message":       run.ErrorMessage,
+		"planned_order_count": run.PlannedOrderCount,
+		"started_at":          run.StartedAt,
+		"completed_at":        run.CompletedAt,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update MRP run: %w", err)
+	}
+	return nil
+}
+
+func (r *gormMrpRunRepository) CreatePlannedOrders(ctx context.Context, orders []*mrp.PlannedOrder) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	modelList := make([]*models.MrpPlannedOrder, len(orders))
+	for i, o := range orders {
+		modelList[i] = fromPlannedOrderDomainEntity(o)
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(&modelList, 500).Error; err != nil {
+		return fmt.Errorf("failed to create planned orders: %w", err)
+	}
+	return nil
+}
+
+func (r *gormMrpRunRepository) ListPlannedOrders(ctx context.Context, runID uuid.UUID, filter mrp.PlannedOrderFilter) ([]*mrp.PlannedOrder, error) {
+	query := r.db.WithContext(ctx).Model(&models.MrpPlannedOrder{}).Where("run_id = ?", runID)
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", string(filter.Type))
+	}
+
+	var modelList []models.MrpPlannedOrder
+	if err := query.Order("level, start_date, item_id").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list planned orders: %w", err)
+	}
+	domainList := make([]*mrp.PlannedOrder, len(modelList))
+	for i := range modelList {
+		domainList[i] = toPlannedOrderDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+// gormMrpDemandRepository reads the MRP demand of the invoice tables.
+type gormMrpDemandRepository struct {
+	db *gorm.DB
+}
+
+// NewGormMrpDemandRepository creates a new gormMrpDemandRepository.
+func NewGormMrpDemandRepository(db *gorm.DB) mrp.DemandRepository {
+	return &gormMrpDemandRepository{db: db}
+}
+
+// invoiceDemandRow is a row of the invoice demand query.
+type invoiceDemandRow struct {
+	ItemID   uuid.UUID `gorm:"column:item_id"`
+	DueDate  time.Time `gorm:"column:due_date"`
+	Quantity float64   `gorm:"column:quantity"`
+}
+
+func (r *gormMrpDemandRepository) ListInvoiceDemand(ctx context.Context, from, to time.Time) ([]*mrp.Demand, error) {
+	// Invoice dates carry a time, so the last day is included up to its end
+	query := `
+		SELECT
+			il.item_id,
+			CAST(inv.date AS DATE) AS due_date,
+			SUM(COALESCE(il.base_quantity, il.quantity)) AS quantity
+		FROM invoice_lines il
+		JOIN invoices inv ON il.invoice_id = inv.id
+		JOIN items i ON il.item_id = i.id
+		WHERE inv.date >= ? AND inv.date < ?
+		AND inv.deleted_at IS NULL AND il.deleted_at IS NULL
+		AND inv.status <> 'CANCELLED' AND inv.document_type = 'STANDARD'
+		AND i.type = 'STORABLE'
+		GROUP BY il.item_id, CAST(inv.date AS DATE)
+		ORDER BY due_date, il.item_id
+	`
+
+	var rows []invoiceDemandRow
+	if err := r.db.WithContext(ctx).Raw(query, from, to.AddDate(0, 0, 1)).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list invoice demand: %w", err)
+	}
+	demand := make([]*mrp.Demand, len(rows))
+	for i, row := range rows {
+		demand[i] = &mrp.Demand{
+			ItemID:   row.ItemID,
+			Quantity: row.Quantity,
+			DueDate:  row.DueDate,
+			Source:   mrp.SourceInvoice,
+		}
+	}
+	return demand, nil
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toForecastDomainEntity(model *models.MrpForecast) *mrp.Forecast {
+	return &mrp.Forecast{
+		ID:          model.ID,
+		ItemID:      model.ItemID,
+		WarehouseID: model.WarehouseID,
+		Quantity:    model.Quantity,
+		DueDate:     model.DueDate,
+		Notes:       model.Notes,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+		CreatedBy:   model.CreatedBy,
+		UpdatedBy:   model.UpdatedBy,
+	}
+}
+
+func fromForecastDomainEntity(entity *mrp.Forecast) *models.MrpForecast {
+	return &models.MrpForecast{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		ItemID:      entity.ItemID,
+		WarehouseID: entity.WarehouseID,
+		Quantity:    entity.Quantity,
+		DueDate:     entity.DueDate,
+		Notes:       entity.Notes,
+	}
+}
+
+func toMrpRunDomainEntity(model *models.MrpRun) *mrp.Run {
+	return &mrp.Run{
+		ID:                model.ID,
+		WarehouseID:       model.WarehouseID,
+		HorizonEnd:        model.HorizonEnd,
+		IncludeInvoices:   model.IncludeInvoices,
+		Status:            mrp.RunStatus(model.Status),
+		ErrorMessage:      model.ErrorMessage,
+		PlannedOrderCount: model.PlannedOrderCount,
+		StartedAt:         model.StartedAt,
+		CompletedAt:       model.CompletedAt,
+		CreatedAt:         model.CreatedAt,
+		CreatedBy:         model.CreatedBy,
+	}
+}
+
+func fromMrpRunDomainEntity(entity *mrp.Run) *models.MrpRun {
+	return &models.MrpRun{
+		ID:                entity.ID,
+		WarehouseID:       entity.WarehouseID,
+		HorizonEnd:        entity.HorizonEnd,
+		IncludeInvoices:   entity.IncludeInvoices,
+		Status:            string(entity.Status),
+		ErrorMessage:      entity.ErrorMessage,
+		PlannedOrderCount: entity.PlannedOrderCount,
+		StartedAt:         entity.StartedAt,
+		CompletedAt:       entity.CompletedAt,
+		CreatedAt:         entity.CreatedAt,
+		CreatedBy:         entity.CreatedBy,
+	}
+}
+
+func toPlannedOrderDomainEntity(model *models.MrpPlannedOrder) *mrp.PlannedOrder {
+	return &mrp.PlannedOrder{
+		ID:          model.ID,
+		RunID:       model.RunID,
+		ItemID:      model.ItemID,
+		WarehouseID: model.WarehouseID,
+		Type:        mrp.OrderType(model.Type),
+		BOMID:       model.BOMID,
+		Level:       model.Level,
+		Quantity:    model.Quantity,
+		StartDate:   model.StartDate,
+		DueDate:     model.DueDate,
+		PastDue:     model.PastDue,
+	}
+}
+
+func fromPlannedOrderDomainEntity(entity *mrp.PlannedOrder) *models.MrpPlannedOrder {
+	return &models.MrpPlannedOrder{
+		ID:          entity.ID,
+		RunID:       entity.RunID,
+		ItemID:      entity.ItemID,
+		WarehouseID: entity.WarehouseID,
+		Type:        string(entity.Type),
+		BOMID:       entity.BOMID,
+		Level:       entity.Level,
+		Quantity:    entity.Quantity,
+		StartDate:   entity.StartDate,
+		DueDate:     entity.DueDate,
+		PastDue:     entity.PastDue,
+	}
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/numbering"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormNumberingRepository is a GORM implementation of the numbering.Repository.
+type gormNumberingRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormNumberingRepository) WithTx(tx *gorm.DB) numbering.Repository {
+	return NewGormNumberingRepository(tx)
+}
+
+// NewGormNumberingRepository creates a new gormNumberingRepository.
+func NewGormNumberingRepository(db *gorm.DB) numbering.Repository {
+	return &gormNumberingRepository{db: db}
+}
+
+func (r *gormNumberingRepository) List(ctx context.Context) ([]*numbering.Sequence, error) {
+	var modelList []models.NumberingSequence
+	if err := r.db.WithContext(ctx).Order("document_type").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list numbering sequences: %w", err)
+	}
+	domainList := make([]*numbering.Sequence, len(modelList))
+	for i := range modelList {
+		domainList[i] = toNumberingSequenceDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormNumberingRepository) GetByDocumentType(ctx context.Context, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	return r.getByDocumentType(r.db.WithContext(ctx), documentType)
+}
+
+// GetByDocumentTypeForUpdate locks the sequence row with SELECT ... FOR UPDATE, which both
+// PostgreSQL and MySQL hold until the end of the transaction.
+func (r *gormNumberingRepository) GetByDocumentTypeForUpdate(ctx context.Context, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	return r.getByDocumentType(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), documentType)
+}
+
+func (r *gormNumberingRepository) getByDocumentType(query *gorm.DB, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	var model models.NumberingSequence
+	if err := query.First(&model, "document_type = ?", string(documentType)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, numbering.ErrSequenceNotFound
+		}
+		return nil, fmt.Errorf("failed to get numbering sequence: %w", err)
+	}
+	return toNumberingSequenceDomainEntity(&model), nil
+}
+
+func (r *gormNumberingRepository) Update(ctx context.Context, sequence *numbering.Sequence) error {
+	if sequence.UpdatedBy == uuid.Nil {
+		return errors.New("updated_by is required")
+	}
+	err := r.db.WithContext(ctx).Model(&models.NumberingSequence{}).Where("id = ?", sequence.ID).
+		Updates(map[string]interface{}{
+			"pattern":      sequence.Pattern,
+			"reset_policy": string(sequence.Reset),
+			"updated_at":   sequence.UpdatedAt,
+			"updated_by":   sequence.UpdatedBy,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update numbering sequence: %w", err)
+	}
+	return nil
+}
+
+func (r *gormNumberingRepository) GetCounter(ctx context.Context, sequenceID uuid.UUID, period string) (*numbering.Counter, error) {
+	var model models.NumberingCounter
+	err := r.db.WithContext(ctx).First(&model, "sequence_id = ? AND period = ?", sequenceID, period).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get numbering counter: %w", err)
+	}
+	return &numbering.Counter{ID: model.ID, SequenceID: model.SequenceID, Period: model.Period, LastValue: model.LastValue}, nil
+}
+
+func (r *gormNumberingRepository) SaveCounter(ctx context.Context, counter *numbering.Counter) error {
+	if counter.ID == uuid.Nil {
+		counter.ID = uuid.New()
+		model := &models.NumberingCounter{ID: counter.ID, SequenceID: counter.SequenceID, Period: counter.Period, LastValue: counter.LastValue}
+		if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+			return fmt.Errorf("failed to create numbering counter: %w", err)
+		}
+		return nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.NumberingCounter{}).Where("id = ?", counter.ID).
+		Update("last_value", counter.LastValue).Error
+	if err != nil {
+		return fmt.Errorf("failed to update numbering counter: %w", err)
+	}
+	return nil
+}
+
+func toNumberingSequenceDomainEntity(model *models.NumberingSequence) *numbering.Sequence {
+	return &numbering.Sequence{
+		ID:           model.ID,
+		DocumentType: numbering.DocumentType(model.DocumentType),
+		Pattern:      model.Pattern,
+		Reset:        numbering.ResetPolicy(model.Reset),
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+		CreatedBy:    model.CreatedBy,
+		UpdatedBy:    model.UpdatedBy,
+	}
+}
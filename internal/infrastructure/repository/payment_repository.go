@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/payment"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormPaymentRepository is a GORM implementation of the payment.Repository.
+type gormPaymentRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPaymentRepository creates a new gormPaymentRepository.
+func NewGormPaymentRepository(db *gorm.DB) payment.Repository {
+	return &gormPaymentRepository{db: db}
+}
+
+func (r *gormPaymentRepository) WithTx(tx *gorm.DB) payment.Repository {
+	return NewGormPaymentRepository(tx)
+}
+
+func (r *gormPaymentRepository) Create(ctx context.Context, p *payment.Payment) error {
+	if p.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	if err := r.db.WithContext(ctx).Create(toPaymentModel(p)).Error; err != nil {
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+	return nil
+}
+
+// Update saves the unallocated amount of the payment and inserts the allocations not stored
+// yet; allocations are never changed once created.
+func (r *gormPaymentRepository) Update(ctx context.Context, p *payment.Payment) error {
+	if p.UpdatedBy == uuid.Nil {
+		return errors.New("updated_by is required")
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Payment{}).Where("id = ?", p.ID).
+			Updates(map[string]interface{}{
+				"unallocated_amount": p.UnallocatedAmount,
+				"updated_at":         p.UpdatedAt,
+				"updated_by":         p.UpdatedBy,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+		if len(p.Allocations) == 0 {
+			return nil
+		}
+		allocations := toPaymentModel(p).Allocations
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&allocations).Error; err != nil {
+			return fmt.Errorf("failed to create payment allocations: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *gormPaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*payment.Payment, error) {
+	return r.getByID(ctx, r.db.WithContext(ctx), id)
+}
+
+func (r *gormPaymentRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*payment.Payment, error) {
+	return r.getByID(ctx, r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *gormPaymentRepository) getByID(ctx context.Context, query *gorm.DB, id uuid.UUID) (*payment.Payment, error) {
+	var model models.Payment
+	if err := query.First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, payment.ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	// Loaded apart from the payment so that FOR UPDATE only locks the payment row
+	if err := r.db.WithContext(ctx).Where("payment_id = ?", id).Order("created_at").Find(&model.Allocations).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payment allocations: %w", err)
+	}
+	return toPaymentDomainEntity(&model), nil
+}
+
+func (r *gormPaymentRepository) ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*payment.Payment, error) {
+	var modelList []models.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Allocations", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("third_party_id = ?", thirdPartyID).Order("date, created_at").Find(&modelList).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	domainList := make([]*payment.Payment, len(modelList))
+	for i := range modelList {
+		domainList[i] = toPaymentDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func toPaymentModel(p *payment.Payment) *models.Payment {
+	allocations := make([]models.PaymentAllocation, len(p.Allocations))
+	for i, a := range p.Allocations {
+		allocations[i] = models.PaymentAllocation{
+			ID:        a.ID,
+			PaymentID: p.ID,
+			InvoiceID: a.InvoiceID,
+			Amount:    a.Amount,
+			CreatedAt: a.CreatedAt,
+			CreatedBy: a.CreatedBy,
+		}
+	}
+	return &models.Payment{
+		BaseModel: models.BaseModel{
+			ID:        p.ID,
+			CreatedAt: p.CreatedAt,
+			UpdatedAt: p.UpdatedAt,
+			CreatedBy: p.CreatedBy,
+			UpdatedBy: p.UpdatedBy,
+		},
+		ThirdPartyID:      p.ThirdPartyID,
+		Date:              p.Date,
+		Amount:            p.Amount,
+		Method:            string(p.Method),
+		Reference:         p.Reference,
+		UnallocatedAmount: p.UnallocatedAmount,
+		Allocations:       allocations,
+	}
+}
+
+func toPaymentDomainEntity(model *models.Payment) *payment.Payment {
+	allocations := make([]payment.Allocation, len(model.Allocations))
+	for i, a := range model.Allocations {
+		allocations[i] = payment.Allocation{
+			ID:        a.ID,
+			PaymentID: a.PaymentID,
+			InvoiceID: a.InvoiceID,
+			Amount:    a.Amount,
+			CreatedAt: a.CreatedAt,
+			CreatedBy: a.CreatedBy,
+		}
+	}
+	return &payment.Payment{
+		ID:                model.ID,
+		ThirdPartyID:      model.ThirdPartyID,
+		Date:              model.Date,
+		Amount:            model.Amount,
+		Method:            payment.Method(model.Method),
+		Reference:         model.Reference,
+		UnallocatedAmount: model.UnallocatedAmount,
+		Allocations:       allocations,
+		CreatedAt:         model.CreatedAt,
+		UpdatedAt:         model.UpdatedAt,
+		CreatedBy:         model.CreatedBy,
+		UpdatedBy:         model.UpdatedBy,
+	}
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/replenishment"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// gormReorderRuleRepository is a GORM implementation of the replenishment.RuleRepository.
+type gormReorderRuleRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormReorderRuleRepository) WithTx(tx *gorm.DB) replenishment.RuleRepository {
+	return NewGormReorderRuleRepository(tx)
+}
+
+// NewGormReorderRuleRepository creates a new gormReorderRuleRepository.
+func NewGormReorderRuleRepository(db *gorm.DB) replenishment.RuleRepository {
+	return &gormReorderRuleRepository{db: db}
+}
+
+func (r *gormReorderRuleRepository) Create(ctx context.Context, rule *replenishment.ReorderRule) error {
+	if rule.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromReorderRuleDomainEntity(rule)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create reorder rule: %w", err)
+	}
+	return nil
+}
+
+func (r *gormReorderRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*replenishment.ReorderRule, error) {
+	var model models.ReorderRule
+	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, replenishment.ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get reorder rule: %w", err)
+	}
+	return toReorderRuleDomainEntity(&model), nil
+}
+
+func (r *gormReorderRuleRepository) GetByItemAndWarehouse(ctx context.Context, itemID, warehouseID uuid.UUID) (*replenishment.ReorderRule, error) {
+	var model models.ReorderRule
+	err := r.db.WithContext(ctx).First(&model, "item_id = ? AND warehouse_id = ?", itemID, warehouseID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, replenishment.ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to get reorder rule: %w", err)
+	}
+	return toReorderRuleDomainEntity(&model), nil
+}
+
+func (r *gormReorderRuleRepository) List(ctx context.Context, filter replenishment.RuleFilter) ([]*replenishment.ReorderRule, error) {
+	query := r.db.WithContext(ctx).Model(&models.ReorderRule{})
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var modelList []models.ReorderRule
+	if err := query.Order("created_at").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list reorder rules: %w", err)
+	}
+	domainList := make([]*replenishment.ReorderRule, len(modelList))
+	for i := range modelList {
+		domainList[i] = toReorderRuleDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormReorderRuleRepository) Update(ctx context.Context, rule *replenishment.ReorderRule) error {
+	err := r.db.WithContext(ctx).Model(&models.ReorderRule{}).Where("id = ?", rule.ID).Updates(map[string]interface{}{
+		"min_quantity":  rule.MinQuantity,
+		"reorder_point": rule.ReorderPoint,
+		"max_quantity":  rule.MaxQuantity,
+		"is_active":     rule.IsActive,
+		"updated_at":    rule.UpdatedAt,
+		"updated_by":    rule.UpdatedBy,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update reorder rule: %w", err)
+	}
+	return nil
+}
+
+func (r *gormReorderRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.ReorderRule{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete reorder rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return replenishment.ErrRuleNotFound
+	}
+	return nil
+}
+
+// gormProposalRepository is a GORM implementation of the replenishment.ProposalRepository.
+type gormProposalRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormProposalRepository) WithTx(tx *gorm.DB) replenishment.ProposalRepository {
+	return NewGormProposalRepository(tx)
+}
+
+// NewGormProposalRepository creates a new gormProposalRepository.
+func NewGormProposalRepository(db *gorm.DB) replenishment.ProposalRepository {
+	return &gormProposalRepository{db: db}
+}
+
+// Replace must run inside a transaction so readers never see an empty or mixed set of proposals.
+func (r *gormProposalRepository) Replace(ctx context.Context, proposals []*replenishment.Proposal) error {
+	if err := r.db.WithContext(ctx).Where("1 = 1").Delete(&models.ReplenishmentProposal{}).Error; err != nil {
+		return fmt.Errorf("failed to delete previous replenishment proposals: %w", err)
+	}
+	if len(proposals) == 0 {
+		return nil
+	}
+	modelList := make([]*models.ReplenishmentProposal, len(proposals))
+	for i, p := range proposals {
+		modelList[i] = fromProposalDomainEntity(p)
+	}
+	if err := r.db.WithContext(ctx).Create(&modelList).Error; err != nil {
+		return fmt.Errorf("failed to create replenishment proposals: %w", err)
+	}
+	return nil
+}
+
+func (r *gormProposalRepository) List(ctx context.Context, filter replenishment.ProposalFilter) ([]*replenishment.Proposal, error) {
+	query := r.db.WithContext(ctx).Model(&models.ReplenishmentProposal{})
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", string(filter.Type))
+	}
+
+	var modelList []models.ReplenishmentProposal
+	if err := query.Order("warehouse_id, item_id").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list replenishment proposals: %w", err)
+	}
+	domainList := make([]*replenishment.Proposal, len(modelList))
+	for i := range modelList {
+		domainList[i] = toProposalDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toReorderRuleDomainEntity(model *models.ReorderRule) *replenishment.ReorderRule {
+	return &replenishment.ReorderRule{
+		ID:           model.ID,
+		ItemID:       model.ItemID,
+		WarehouseID:  model.WarehouseID,
+		MinQuantity:  model.MinQuantity,
+		ReorderPoint: model.ReorderPoint,
+		MaxQuantity:  model.MaxQuantity,
+		IsActive:     model.IsActive,
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+		CreatedBy:    model.CreatedBy,
+		UpdatedBy:    model.UpdatedBy,
+	}
+}
+
+func fromReorderRuleDomainEntity(entity *replenishment.ReorderRule) *models.ReorderRule {
+	return &models.ReorderRule{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		ItemID:       entity.ItemID,
+		WarehouseID:  entity.WarehouseID,
+		MinQuantity:  entity.MinQuantity,
+		ReorderPoint: entity.ReorderPoint,
+		MaxQuantity:  entity.MaxQuantity,
+		IsActive:     entity.IsActive,
+	}
+}
+
+func toProposalDomainEntity(model *models.ReplenishmentProposal) *replenishment.Proposal {
+	return &replenishment.Proposal{
+		ID:              model.ID,
+		RunID:           model.RunID,
+		RuleID:          model.RuleID,
+		ItemID:          model.ItemID,
+		WarehouseID:     model.WarehouseID,
+		Type:            replenishment.ProposalType(model.Type),
+		BOMID:           model.BOMID,
+		Quantity:        model.Quantity,
+		OnHand:          model.OnHand,
+		Reserved:        model.Reserved,
+		ComponentDemand: model.ComponentDemand,
+		Projected:       model.Projected,
+		ReorderPoint:    model.ReorderPoint,
+		MinQuantity:     model.MinQuantity,
+		MaxQuantity:     model.MaxQuantity,
+		GeneratedAt:     model.GeneratedAt,
+	}
+}
+
+func fromProposalDomainEntity(entity *replenishment.Proposal) *models.ReplenishmentProposal {
+	return &models.ReplenishmentProposal{
+		ID:              entity.ID,
+		RunID:           entity.RunID,
+		RuleID:          entity.RuleID,
+		ItemID:          entity.ItemID,
+		WarehouseID:     entity.WarehouseID,
+		Type:            string(entity.Type),
+		BOMID:           entity.BOMID,
+		Quantity:        entity.Quantity,
+		OnHand:          entity.OnHand,
+		Reserved:        entity.Reserved,
+		ComponentDemand: entity.ComponentDemand,
+		Projected:       entity.Projected,
+		ReorderPoint:    entity.ReorderPoint,
+		MinQuantity:     entity.MinQuantity,
+		MaxQuantity:     entity.MaxQuantity,
+		GeneratedAt:     entity.GeneratedAt,
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormCostLayerRepository is a GORM implementation of the stock.CostLayerRepository.
+type gormCostLayerRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormCostLayerRepository) WithTx(tx *gorm.DB) stock.CostLayerRepository {
+	return NewGormCostLayerRepository(tx)
+}
+
+// NewGormCostLayerRepository creates a new gormCostLayerRepository.
+func NewGormCostLayerRepository(db *gorm.DB) stock.CostLayerRepository {
+	return &gormCostLayerRepository{db: db}
+}
+
+func (r *gormCostLayerRepository) Create(ctx context.Context, layer *stock.CostLayer) error {
+	return r.db.WithContext(ctx).Create(fromCostLayerDomainEntity(layer)).Error
+}
+
+func (r *gormCostLayerRepository) ListOpen(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	return r.listOpen(r.db.WithContext(ctx), itemID)
+}
+
+func (r *gormCostLayerRepository) ListOpenForUpdate(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	return r.listOpen(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), itemID)
+}
+
+func (r *gormCostLayerRepository) listOpen(query *gorm.DB, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	var modelList []models.StockCostLayer
+	err := query.Where("item_id = ? AND remaining_quantity > 0", itemID).
+		Order("received_at, id").Find(&modelList).Error
+	if err != nil {
+		return nil, err
+	}
+	domainList := make([]*stock.CostLayer, len(modelList))
+	for i := range modelList {
+		domainList[i] = toCostLayerDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormCostLayerRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*stock.CostLayer, error) {
+	var model models.StockCostLayer
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return toCostLayerDomainEntity(&model), nil
+}
+
+func (r *gormCostLayerRepository) GetByMovementForUpdate(ctx context.Context, movementID uuid.UUID) (*stock.CostLayer, error) {
+	var model models.StockCostLayer
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&model, "movement_id = ?", movementID).Error; err != nil {
+		return nil, err
+	}
+	return toCostLayerDomainEntity(&model), nil
+}
+
+func (r *gormCostLayerRepository) UpdateRemaining(ctx context.Context, layer *stock.CostLayer) error {
+	return r.db.WithContext(ctx).Model(&models.StockCostLayer{}).Where("id = ?", layer.ID).
+		Update("remaining_quantity", layer.RemainingQuantity).Error
+}
+
+func (r *gormCostLayerRepository) CreateConsumption(ctx context.Context, c *stock.CostLayerConsumption) error {
+	return r.db.WithContext(ctx).Create(&models.StockCostLayerConsumption{
+		ID:         c.ID,
+		LayerID:    c.LayerID,
+		MovementID: c.MovementID,
+		Quantity:   c.Quantity,
+		UnitCost:   c.UnitCost,
+	}).Error
+}
+
+func (r *gormCostLayerRepository) ListConsumptions(ctx context.Context, movementID uuid.UUID) ([]*stock.CostLayerConsumption, error) {
+	var modelList []models.StockCostLayerConsumption
+	if err := r.db.WithContext(ctx).Where("movement_id = ?", movementID).Order("id").Find(&modelList).Error; err != nil {
+		return nil, err
+	}
+	domainList := make([]*stock.CostLayerConsumption, len(modelList))
+	for i, m := range modelList {
+		domainList[i] = &stock.CostLayerConsumption{
+			ID:         m.ID,
+			LayerID:    m.LayerID,
+			MovementID: m.MovementID,
+			Quantity:   m.Quantity,
+			UnitCost:   m.UnitCost,
+		}
+	}
+	return domainList, nil
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toCostLayerDomainEntity(model *models.StockCostLayer) *stock.CostLayer {
+	return &stock.CostLayer{
+		ID:                model.ID,
+		ItemID:            model.ItemID,
+		MovementID:        model.MovementID,
+		UnitCost:          model.UnitCost,
+		OriginalQuantity:  model.OriginalQuantity,
+		RemainingQuantity: model.RemainingQuantity,
+		ReceivedAt:        model.ReceivedAt,
+	}
+}
+
+func fromCostLayerDomainEntity(entity *stock.CostLayer) *models.StockCostLayer {
+	return &models.StockCostLayer{
+		ID:                entity.ID,
+		ItemID:            entity.ItemID,
+		MovementID:        entity.MovementID,
+		UnitCost:          entity.UnitCost,
+		OriginalQuantity:  entity.OriginalQuantity,
+		RemainingQuantity: entity.RemainingQuantity,
+		ReceivedAt:        entity.ReceivedAt,
+	}
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormCountSessionRepository is a GORM implementation of the stock.CountSessionRepository.
+type gormCountSessionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormCountSessionRepository) WithTx(tx *gorm.DB) stock.CountSessionRepository {
+	return NewGormCountSessionRepository(tx)
+}
+
+// NewGormCountSessionRepository creates a new gormCountSessionRepository.
+func NewGormCountSessionRepository(db *gorm.DB) stock.CountSessionRepository {
+	return &gormCountSessionRepository{db: db}
+}
+
+func (r *gormCountSessionRepository) Create(ctx context.Context, s *stock.CountSession) error {
+	if s.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromCountSessionDomainEntity(s)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create inventory count session: %w", err)
+	}
+	return nil
+}
+
+func (r *gormCountSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.CountSession, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+func (r *gormCountSessionRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.CountSession, error) {
+	return r.get(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *gormCountSessionRepository) get(query *gorm.DB, id uuid.UUID) (*stock.CountSession, error) {
+	var model models.StockCountSession
+	err := query.Preload("Bins").Preload("Lines.Entries").First(&model, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, stock.ErrCountSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get inventory count session: %w", err)
+	}
+	return toCountSessionDomainEntity(&model), nil
+}
+
+func (r *gormCountSessionRepository) List(ctx context.Context, status stock.CountStatus) ([]*stock.CountSession, error) {
+	query := r.db.WithContext(ctx).Preload("Bins").Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", string(status))
+	}
+	var modelList []models.StockCountSession
+	if err := query.Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list inventory count sessions: %w", err)
+	}
+	domainList := make([]*stock.CountSession, len(modelList))
+	for i := range modelList {
+		domainList[i] = toCountSessionDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormCountSessionRepository) UpdateStatus(ctx context.Context, s *stock.CountSession) error {
+	if s.UpdatedBy == uuid.Nil {
+		return errors.New("updated_by is required")
+	}
+	return r.db.WithContext(ctx).Model(&models.StockCountSession{}).Where("id = ?", s.ID).Updates(map[string]interface{}{
+		"status":      string(s.Status),
+		"approved_at": s.ApprovedAt,
+		"approved_by": s.ApprovedBy,
+		"updated_at":  s.UpdatedAt,
+		"updated_by":  s.UpdatedBy,
+	}).Error
+}
+
+func (r *gormCountSessionRepository) AddLine(ctx context.Context, l *stock.CountLine) error {
+	model := fromCountLineDomainEntity(l)
+	return r.db.WithContext(ctx).Omit("Entries").Create(model).Error
+}
+
+func (r *gormCountSessionRepository) AddEntry(ctx context.Context, e *stock.CountEntry) error {
+	if e.CountedBy == uuid.Nil {
+		return errors.New("counted_by is required")
+	}
+	model := fromCountEntryDomainEntity(e)
+	return r.db.WithContext(ctx).Create(model).Error
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toCountSessionDomainEntity(model *models.StockCountSession) *stock.CountSession {
+	s := &stock.CountSession{
+		ID:          model.ID,
+		WarehouseID: model.WarehouseID,
+		Status:      stock.CountStatus(model.Status),
+		ApprovedAt:  model.ApprovedAt,
+		ApprovedBy:  model.ApprovedBy,
+		CreatedAt:   model.CreatedAt,
+		UpdatedAt:   model.UpdatedAt,
+		CreatedBy:   model.CreatedBy,
+		UpdatedBy:   model.UpdatedBy,
+	}
+	for _, b := range model.Bins {
+		s.BinIDs = append(s.BinIDs, b.BinID)
+	}
+	for i := range model.Lines {
+		s.Lines = append(s.Lines, toCountLineDomainEntity(&model.Lines[i]))
+	}
+	return s
+}
+
+func fromCountSessionDomainEntity(entity *stock.CountSession) *models.StockCountSession {
+	model := &models.StockCountSession{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		WarehouseID: entity.WarehouseID,
+		Status:      string(entity.Status),
+		ApprovedAt:  entity.ApprovedAt,
+		ApprovedBy:  entity.ApprovedBy,
+	}
+	for _, binID := range entity.BinIDs {
+		model.Bins = append(model.Bins, models.StockCountSessionBin{SessionID: entity.ID, BinID: binID})
+	}
+	for _, l := range entity.Lines {
+		model.Lines = append(model.Lines, *fromCountLineDomainEntity(l))
+	}
+	return model
+}
+
+func toCountLineDomainEntity(model *models.StockCountLine) *stock.CountLine {
+	l := &stock.CountLine{
+		ID:               model.ID,
+		SessionID:        model.SessionID,
+		ItemID:           model.ItemID,
+		BinID:            model.BinID,
+		ExpectedQuantity: model.ExpectedQuantity,
+	}
+	for i := range model.Entries {
+		l.Entries = append(l.Entries, toCountEntryDomainEntity(&model.Entries[i]))
+	}
+	return l
+}
+
+func fromCountLineDomainEntity(entity *stock.CountLine) *models.StockCountLine {
+	return &models.StockCountLine{
+		ID:               entity.ID,
+		SessionID:        entity.SessionID,
+		ItemID:           entity.ItemID,
+		BinID:            entity.BinID,
+		ExpectedQuantity: entity.ExpectedQuantity,
+	}
+}
+
+func toCountEntryDomainEntity(model *models.StockCountEntry) *stock.CountEntry {
+	return &stock.CountEntry{
+		ID:        model.ID,
+		LineID:    model.LineID,
+		DeviceID:  model.DeviceID,
+		Quantity:  model.Quantity,
+		CountedAt: model.CountedAt,
+		CountedBy: model.CountedBy,
+	}
+}
+
+func fromCountEntryDomainEntity(entity *stock.CountEntry) *models.StockCountEntry {
+	return &models.StockCountEntry{
+		ID:        entity.ID,
+		LineID:    entity.LineID,
+		DeviceID:  entity.DeviceID,
+		Quantity:  entity.Quantity,
+		CountedAt: entity.CountedAt,
+		CountedBy: entity.CountedBy,
+	}
+}
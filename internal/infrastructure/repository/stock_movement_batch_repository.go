@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormMovementBatchRepository is a GORM implementation of the stock.MovementBatchRepository.
+type gormMovementBatchRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormMovementBatchRepository) WithTx(tx *gorm.DB) stock.MovementBatchRepository {
+	return NewGormMovementBatchRepository(tx)
+}
+
+// NewGormMovementBatchRepository creates a new gormMovementBatchRepository.
+func NewGormMovementBatchRepository(db *gorm.DB) stock.MovementBatchRepository {
+	return &gormMovementBatchRepository{db: db}
+}
+
+func (r *gormMovementBatchRepository) Claim(ctx context.Context, batch *stock.MovementBatch) (bool, error) {
+	// The unique index on idempotency_key makes a concurrent insert of the same key wait for
+	// the first transaction and then do nothing if that transaction committed.
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(fromMovementBatchDomainEntity(batch))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *gormMovementBatchRepository) GetByKey(ctx context.Context, key string) (*stock.MovementBatch, error) {
+	var model models.StockMovementBatch
+	if err := r.db.WithContext(ctx).First(&model, "idempotency_key = ?", key).Error; err != nil {
+		return nil, err
+	}
+	return toMovementBatchDomainEntity(&model), nil
+}
+
+func (r *gormMovementBatchRepository) AddLine(ctx context.Context, batchID uuid.UUID, line int, movementID uuid.UUID) error {
+	return r.db.WithContext(ctx).Create(&models.StockMovementBatchLine{
+		BatchID:    batchID,
+		Line:       line,
+		MovementID: movementID,
+	}).Error
+}
+
+func (r *gormMovementBatchRepository) ListMovements(ctx context.Context, batchID uuid.UUID) ([]*stock.StockMovement, error) {
+	var modelList []models.StockMovementBatchLine
+	err := r.db.WithContext(ctx).Preload("Movement.Lots").
+		Where("batch_id = ?", batchID).Order("line").Find(&modelList).Error
+	if err != nil {
+		return nil, err
+	}
+	domainList := make([]*stock.StockMovement, len(modelList))
+	for i := range modelList {
+		domainList[i] = toStockMovementDomainEntity(&modelList[i].Movement)
+	}
+	return domainList, nil
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toMovementBatchDomainEntity(model *models.StockMovementBatch) *stock.MovementBatch {
+	batch := &stock.MovementBatch{
+		ID:          model.ID,
+		PayloadHash: model.PayloadHash,
+		LineCount:   model.LineCount,
+		CreatedAt:   model.CreatedAt,
+		CreatedBy:   model.CreatedBy,
+	}
+	if model.IdempotencyKey != nil {
+		batch.IdempotencyKey = *model.IdempotencyKey
+	}
+	return batch
+}
+
+func fromMovementBatchDomainEntity(entity *stock.MovementBatch) *models.StockMovementBatch {
+	model := &models.StockMovementBatch{
+		ID:          entity.ID,
+		PayloadHash: entity.PayloadHash,
+		LineCount:   entity.LineCount,
+		CreatedAt:   entity.CreatedAt,
+		CreatedBy:   entity.CreatedBy,
+	}
+	if entity.IdempotencyKey != "" {
+		key := entity.IdempotencyKey
+		model.IdempotencyKey = &key
+	}
+	return model
+}
@@ -5,6 +5,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"doligo_001/internal/domain/stock"
 	"doligo_001/internal/infrastructure/db/models"
@@ -169,6 +170,18 @@ func (r *gormStockRepository) GetTotalQuantity(ctx context.Context, itemID uuid.
 	return total, err
 }
 
+func (r *gormStockRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	var modelList []models.Stock
+	if err := r.db.WithContext(ctx).Where("warehouse_id = ?", warehouseID).Order("bin_id, item_id").Find(&modelList).Error; err != nil {
+		return nil, err
+	}
+	domainList := make([]*stock.Stock, len(modelList))
+	for i := range modelList {
+		domainList[i] = toStockDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
 func (r *gormStockRepository) UpsertStock(ctx context.Context, s *stock.Stock) error {
 	model := fromStockDomainEntity(s)
 	// Use Clauses(clause.OnConflict) to perform an upsert.
@@ -202,12 +215,103 @@ func (r *gormStockMovementRepository) Create(ctx context.Context, sm *stock.Stoc
 
 func (r *gormStockMovementRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
 	var model models.StockMovement
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Lots").First(&model, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return toStockMovementDomainEntity(&model), nil
 }
 
+func (r *gormStockMovementRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
+	var model models.StockMovement
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&model, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Where("stock_movement_id = ?", id).Find(&model.Lots).Error; err != nil {
+		return nil, err
+	}
+	return toStockMovementDomainEntity(&model), nil
+}
+
+func (r *gormStockMovementRepository) MarkReversed(ctx context.Context, sm *stock.StockMovement) error {
+	return r.db.WithContext(ctx).Model(&models.StockMovement{}).
+		Where("id = ?", sm.ID).Update("reversed_at", sm.ReversedAt).Error
+}
+
+func (r *gormStockMovementRepository) ListByProductionRecord(ctx context.Context, recordID uuid.UUID) ([]*stock.StockMovement, error) {
+	var modelList []models.StockMovement
+	if err := r.db.WithContext(ctx).Preload("Lots").
+		Where("production_record_id = ?", recordID).
+		Order("happened_at, id").Find(&modelList).Error; err != nil {
+		return nil, err
+	}
+	domainList := make([]*stock.StockMovement, len(modelList))
+	for i := range modelList {
+		domainList[i] = toStockMovementDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+// gormStockLotRepository is a GORM implementation of the stock.StockLotRepository.
+type gormStockLotRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormStockLotRepository) WithTx(tx *gorm.DB) stock.StockLotRepository {
+	return NewGormStockLotRepository(tx)
+}
+
+// NewGormStockLotRepository creates a new gormStockLotRepository.
+func NewGormStockLotRepository(db *gorm.DB) stock.StockLotRepository {
+	return &gormStockLotRepository{db: db}
+}
+
+func (r *gormStockLotRepository) GetForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, lotNumber string) (*stock.StockLot, error) {
+	var model models.StockLot
+	query := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("item_id = ? AND warehouse_id = ? AND lot_number = ?", itemID, warehouseID, lotNumber)
+	if binID != nil {
+		query = query.Where("bin_id = ?", *binID)
+	} else {
+		query = query.Where("bin_id = ?", uuid.Nil)
+	}
+	if err := query.First(&model).Error; err != nil {
+		return nil, err
+	}
+	return toStockLotDomainEntity(&model), nil
+}
+
+func (r *gormStockLotRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID, lotNumber string) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.StockLot{}).
+		Where("item_id = ? AND lot_number = ?", itemID, lotNumber).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *gormStockLotRepository) Upsert(ctx context.Context, l *stock.StockLot) error {
+	model := fromStockLotDomainEntity(l)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "item_id"}, {Name: "warehouse_id"}, {Name: "bin_id"}, {Name: "lot_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+	}).Create(model).Error
+}
+
+func (r *gormStockLotRepository) List(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*stock.StockLot, error) {
+	var modelList []models.StockLot
+	query := r.db.WithContext(ctx).Where("item_id = ? AND quantity > 0", itemID)
+	if lotNumber != "" {
+		query = query.Where("lot_number = ?", lotNumber)
+	}
+	if err := query.Order("lot_number, warehouse_id, bin_id").Find(&modelList).Error; err != nil {
+		return nil, err
+	}
+	domainList := make([]*stock.StockLot, len(modelList))
+	for i := range modelList {
+		domainList[i] = toStockLotDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
 // gormStockLedgerRepository is a GORM implementation of the stock.StockLedgerRepository.
 type gormStockLedgerRepository struct {
 	db *gorm.DB
@@ -230,6 +334,113 @@ func (r *gormStockLedgerRepository) Create(ctx context.Context, sl *stock.StockL
 	return r.db.WithContext(ctx).Create(model).Error
 }
 
+func (r *gormStockLedgerRepository) List(ctx context.Context, filter stock.LedgerFilter) ([]*stock.StockLedger, int64, error) {
+	query := applyLedgerLocationFilter(r.db.WithContext(ctx).Model(&models.StockLedger{}), filter)
+	if filter.MovementType != "" {
+		query = query.Where("movement_type = ?", string(filter.MovementType))
+	}
+	if filter.From != nil {
+		query = query.Where("happened_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("happened_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var modelList []models.StockLedger
+	if err := query.Order("happened_at ASC, recorded_at ASC").Limit(filter.Limit).Offset(filter.Offset).Find(&modelList).Error; err != nil {
+		return nil, 0, err
+	}
+	domainList := make([]*stock.StockLedger, len(modelList))
+	for i, model := range modelList {
+		domainList[i] = toStockLedgerDomainEntity(&model)
+	}
+	return domainList, total, nil
+}
+
+func (r *gormStockLedgerRepository) ListBalancesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.Stock, error) {
+	// Rank the entries of every location from newest to oldest; the first one
+	// carries the quantity that was on hand at asOf.
+	ranked := applyLedgerLocationFilter(r.db.WithContext(ctx).Model(&models.StockLedger{}), filter).
+		Select("item_id, warehouse_id, bin_id, quantity_after, happened_at, " +
+			"ROW_NUMBER() OVER (PARTITION BY item_id, warehouse_id, bin_id ORDER BY happened_at DESC, recorded_at DESC) AS rn").
+		Where("happened_at <= ?", asOf)
+
+	var rows []struct {
+		ItemID        uuid.UUID  `gorm:"column:item_id"`
+		WarehouseID   uuid.UUID  `gorm:"column:warehouse_id"`
+		BinID         *uuid.UUID `gorm:"column:bin_id"`
+		QuantityAfter float64    `gorm:"column:quantity_after"`
+		HappenedAt    time.Time  `gorm:"column:happened_at"`
+	}
+	err := r.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+		Select("item_id, warehouse_id, bin_id, quantity_after, happened_at").
+		Where("rn = 1").
+		Order("item_id, warehouse_id, bin_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*stock.Stock, len(rows))
+	for i, row := range rows {
+		balances[i] = &stock.Stock{
+			ItemID:      row.ItemID,
+			WarehouseID: row.WarehouseID,
+			BinID:       row.BinID,
+			Quantity:    row.QuantityAfter,
+			UpdatedAt:   row.HappenedAt,
+		}
+	}
+	return balances, nil
+}
+
+func (r *gormStockLedgerRepository) ListItemValuesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.ItemValue, error) {
+	// quantity_change is unsigned; the movement type gives its direction.
+	inbound := []string{string(stock.MovementTypeIn), string(stock.MovementTypeAdjIn)}
+	signed := "CASE WHEN movement_type IN ? THEN quantity_change ELSE -quantity_change END"
+
+	query := r.db.WithContext(ctx).Model(&models.StockLedger{}).
+		Select("item_id, SUM("+signed+") AS quantity, SUM(("+signed+") * unit_cost) AS value", inbound, inbound).
+		Where("happened_at <= ?", asOf)
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+
+	var rows []struct {
+		ItemID   uuid.UUID `gorm:"column:item_id"`
+		Quantity float64   `gorm:"column:quantity"`
+		Value    float64   `gorm:"column:value"`
+	}
+	if err := query.Group("item_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	values := make([]*stock.ItemValue, len(rows))
+	for i, row := range rows {
+		values[i] = &stock.ItemValue{ItemID: row.ItemID, Quantity: row.Quantity, Value: row.Value}
+	}
+	return values, nil
+}
+
+// applyLedgerLocationFilter restricts a stock_ledger query to the item and location of the filter.
+func applyLedgerLocationFilter(query *gorm.DB, filter stock.LedgerFilter) *gorm.DB {
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.BinID != nil {
+		query = query.Where("bin_id = ?", *filter.BinID)
+	}
+	return query
+}
+
 
 // --- MAPPING FUNCTIONS ---
 
@@ -312,30 +523,81 @@ func fromStockDomainEntity(entity *stock.Stock) *models.Stock {
 }
 
 func toStockMovementDomainEntity(model *models.StockMovement) *stock.StockMovement {
+	var lots []stock.LotQuantity
+	for _, l := range model.Lots {
+		lots = append(lots, stock.LotQuantity{LotNumber: l.LotNumber, Quantity: l.Quantity})
+	}
 	return &stock.StockMovement{
-		ID:          model.ID,
+		ID:                 model.ID,
+		ItemID:             model.ItemID,
+		WarehouseID:        model.WarehouseID,
+		BinID:              model.BinID,
+		Type:               stock.MovementType(model.Type),
+		Quantity:           model.Quantity,
+		Reason:             model.Reason,
+		TransferID:         model.TransferID,
+		ProductionRecordID: model.ProductionRecordID,
+		Lots:               lots,
+		UnitCost:           model.UnitCost,
+		CostVariance:       model.CostVariance,
+		HappenedAt:         model.HappenedAt,
+		ReversedAt:         model.ReversedAt,
+		CreatedBy:          model.CreatedBy,
+	}
+}
+
+func fromStockMovementDomainEntity(entity *stock.StockMovement) *models.StockMovement {
+	var lots []models.StockMovementLot
+	for _, l := range entity.Lots {
+		lots = append(lots, models.StockMovementLot{
+			ID:              uuid.New(),
+			StockMovementID: entity.ID,
+			LotNumber:       l.LotNumber,
+			Quantity:        l.Quantity,
+		})
+	}
+	return &models.StockMovement{
+		ID:                 entity.ID,
+		ItemID:             entity.ItemID,
+		WarehouseID:        entity.WarehouseID,
+		BinID:              entity.BinID,
+		Type:               string(entity.Type),
+		Quantity:           entity.Quantity,
+		Reason:             entity.Reason,
+		TransferID:         entity.TransferID,
+		ProductionRecordID: entity.ProductionRecordID,
+		Lots:               lots,
+		UnitCost:           entity.UnitCost,
+		CostVariance:       entity.CostVariance,
+		HappenedAt:         entity.HappenedAt,
+		ReversedAt:         entity.ReversedAt,
+		CreatedBy:          entity.CreatedBy,
+	}
+}
+
+func toStockLotDomainEntity(model *models.StockLot) *stock.StockLot {
+	return &stock.StockLot{
 		ItemID:      model.ItemID,
 		WarehouseID: model.WarehouseID,
-		BinID:       model.BinID,
-		Type:        stock.MovementType(model.Type),
+		BinID:       &model.BinID,
+		LotNumber:   model.LotNumber,
 		Quantity:    model.Quantity,
-		Reason:      model.Reason,
-		HappenedAt:  model.HappenedAt,
-		CreatedBy:   model.CreatedBy,
+		UpdatedAt:   model.UpdatedAt,
 	}
 }
 
-func fromStockMovementDomainEntity(entity *stock.StockMovement) *models.StockMovement {
-	return &models.StockMovement{
-		ID:          entity.ID,
+func fromStockLotDomainEntity(entity *stock.StockLot) *models.StockLot {
+	binID := uuid.Nil
+	if entity.BinID != nil {
+		binID = *entity.BinID
+	}
+	return &models.StockLot{
 		ItemID:      entity.ItemID,
 		WarehouseID: entity.WarehouseID,
-		BinID:       entity.BinID,
-		Type:        string(entity.Type),
+		BinID:       binID,
+		LotNumber:   entity.LotNumber,
 		Quantity:    entity.Quantity,
-		Reason:      entity.Reason,
-		HappenedAt:  entity.HappenedAt,
-		CreatedBy:   entity.CreatedBy,
+		UpdatedAt:   entity.UpdatedAt,
 	}
 }
 
@@ -350,7 +612,10 @@ func toStockLedgerDomainEntity(model *models.StockLedger) *stock.StockLedger {
 		QuantityChange:  model.QuantityChange,
 		QuantityBefore:  model.QuantityBefore,
 		QuantityAfter:   model.QuantityAfter,
+		UnitCost:        model.UnitCost,
+		CostVariance:    model.CostVariance,
 		Reason:          model.Reason,
+		TransferID:      model.TransferID,
 		HappenedAt:      model.HappenedAt,
 		RecordedAt:      model.RecordedAt,
 		RecordedBy:      model.RecordedBy,
@@ -368,7 +633,10 @@ func fromStockLedgerDomainEntity(entity *stock.StockLedger) *models.StockLedger
 		QuantityChange:  entity.QuantityChange,
 		QuantityBefore:  entity.QuantityBefore,
 		QuantityAfter:   entity.QuantityAfter,
+		UnitCost:        entity.UnitCost,
+		CostVariance:    entity.CostVariance,
 		Reason:          entity.Reason,
+		TransferID:      entity.TransferID,
 		HappenedAt:      entity.HappenedAt,
 		RecordedAt:      entity.RecordedAt,
 		RecordedBy:      entity.RecordedBy,
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormReservationRepository is a GORM implementation of the stock.ReservationRepository.
+type gormReservationRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormReservationRepository) WithTx(tx *gorm.DB) stock.ReservationRepository {
+	return NewGormReservationRepository(tx)
+}
+
+// NewGormReservationRepository creates a new gormReservationRepository.
+func NewGormReservationRepository(db *gorm.DB) stock.ReservationRepository {
+	return &gormReservationRepository{db: db}
+}
+
+func (r *gormReservationRepository) Create(ctx context.Context, res *stock.Reservation) error {
+	if res.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromReservationDomainEntity(res)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create stock reservation: %w", err)
+	}
+	return nil
+}
+
+func (r *gormReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+func (r *gormReservationRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return r.get(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+func (r *gormReservationRepository) get(query *gorm.DB, id uuid.UUID) (*stock.Reservation, error) {
+	var model models.StockReservation
+	if err := query.First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, stock.ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock reservation: %w", err)
+	}
+	return toReservationDomainEntity(&model), nil
+}
+
+func (r *gormReservationRepository) FindActive(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string) (*stock.Reservation, error) {
+	var model models.StockReservation
+	err := reservationLocation(r.db.WithContext(ctx), itemID, warehouseID, binID).
+		Where("source_type = ? AND source_id = ? AND status = ?", sourceType, sourceID, string(stock.ReservationActive)).
+		First(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, stock.ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to find stock reservation: %w", err)
+	}
+	return toReservationDomainEntity(&model), nil
+}
+
+func (r *gormReservationRepository) List(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error) {
+	query := r.db.WithContext(ctx).Model(&models.StockReservation{})
+	if filter.ItemID != nil {
+		query = query.Where("item_id = ?", *filter.ItemID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.SourceType != "" {
+		query = query.Where("source_type = ?", filter.SourceType)
+	}
+	if filter.SourceID != "" {
+		query = query.Where("source_id = ?", filter.SourceID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+
+	var modelList []models.StockReservation
+	if err := query.Order("created_at DESC").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock reservations: %w", err)
+	}
+	domainList := make([]*stock.Reservation, len(modelList))
+	for i := range modelList {
+		domainList[i] = toReservationDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormReservationRepository) Update(ctx context.Context, res *stock.Reservation) error {
+	err := r.db.WithContext(ctx).Model(&models.StockReservation{}).Where("id = ?", res.ID).Updates(map[string]interface{}{
+		"consumed_quantity": res.ConsumedQuantity,
+		"status":            string(res.Status),
+		"expires_at":        res.ExpiresAt,
+		"updated_at":        res.UpdatedAt,
+		"updated_by":        res.UpdatedBy,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update stock reservation: %w", err)
+	}
+	return nil
+}
+
+func (r *gormReservationRepository) ReservedQuantity(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, asOf time.Time) (float64, error) {
+	var total float64
+	err := reservationLocation(r.db.WithContext(ctx).Model(&models.StockReservation{}), itemID, warehouseID, binID).
+		Where("status = ? AND expires_at > ?", string(stock.ReservationActive), asOf).
+		Select("COALESCE(SUM(quantity - consumed_quantity), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *gormReservationRepository) ExpireDue(ctx context.Context, asOf time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.StockReservation{}).
+		Where("status = ? AND expires_at <= ?", string(stock.ReservationActive), asOf).
+		Updates(map[string]interface{}{
+			"status":     string(stock.ReservationExpired),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to expire stock reservations: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// reservationLocation restricts a stock_reservations query to one item and location.
+func reservationLocation(query *gorm.DB, itemID, warehouseID uuid.UUID, binID *uuid.UUID) *gorm.DB {
+	query = query.Where("item_id = ? AND warehouse_id = ?", itemID, warehouseID)
+	if binID != nil {
+		return query.Where("bin_id = ?", *binID)
+	}
+	return query.Where("bin_id = ?", uuid.Nil)
+}
+
+// --- MAPPING FUNCTIONS ---
+
+func toReservationDomainEntity(model *models.StockReservation) *stock.Reservation {
+	var binID *uuid.UUID
+	if model.BinID != uuid.Nil {
+		id := model.BinID
+		binID = &id
+	}
+	return &stock.Reservation{
+		ID:               model.ID,
+		ItemID:           model.ItemID,
+		WarehouseID:      model.WarehouseID,
+		BinID:            binID,
+		SourceType:       model.SourceType,
+		SourceID:         model.SourceID,
+		Quantity:         model.Quantity,
+		ConsumedQuantity: model.ConsumedQuantity,
+		Status:           stock.ReservationStatus(model.Status),
+		ExpiresAt:        model.ExpiresAt,
+		CreatedAt:        model.CreatedAt,
+		UpdatedAt:        model.UpdatedAt,
+		CreatedBy:        model.CreatedBy,
+		UpdatedBy:        model.UpdatedBy,
+	}
+}
+
+func fromReservationDomainEntity(entity *stock.Reservation) *models.StockReservation {
+	binID := uuid.Nil
+	if entity.BinID != nil {
+		binID = *entity.BinID
+	}
+	return &models.StockReservation{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		ItemID:           entity.ItemID,
+		WarehouseID:      entity.WarehouseID,
+		BinID:            binID,
+		SourceType:       entity.SourceType,
+		SourceID:         entity.SourceID,
+		Quantity:         entity.Quantity,
+		ConsumedQuantity: entity.ConsumedQuantity,
+		Status:           string(entity.Status),
+		ExpiresAt:        entity.ExpiresAt,
+	}
+}
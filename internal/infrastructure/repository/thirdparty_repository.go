@@ -71,15 +71,16 @@ func (r *gormThirdPartyRepository) List(ctx context.Context) ([]*thirdparty.Thir
 // toThirdPartyDomainEntity converts a GORM third party model to a domain entity.
 func toThirdPartyDomainEntity(model *models.ThirdParty) *thirdparty.ThirdParty {
 	return &thirdparty.ThirdParty{
-		ID:        model.ID,
-		Name:      model.Name,
-		Email:     model.Email,
-		Type:      thirdparty.ThirdPartyType(model.Type),
-		IsActive:  model.IsActive,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
-		CreatedBy: model.CreatedBy,
-		UpdatedBy: model.UpdatedBy,
+		ID:              model.ID,
+		Name:            model.Name,
+		Email:           model.Email,
+		Type:            thirdparty.ThirdPartyType(model.Type),
+		IsActive:        model.IsActive,
+		PaymentTermDays: model.PaymentTermDays,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+		CreatedBy:       model.CreatedBy,
+		UpdatedBy:       model.UpdatedBy,
 	}
 }
 
@@ -93,9 +94,10 @@ func fromThirdPartyDomainEntity(entity *thirdparty.ThirdParty) *models.ThirdPart
 			CreatedBy: entity.CreatedBy,
 			UpdatedBy: entity.UpdatedBy,
 		},
-		Name:     entity.Name,
-		Email:    entity.Email,
-		Type:     string(entity.Type),
-		IsActive: entity.IsActive,
+		Name:            entity.Name,
+		Email:           entity.Email,
+		Type:            string(entity.Type),
+		IsActive:        entity.IsActive,
+		PaymentTermDays: entity.PaymentTermDays,
 	}
 }
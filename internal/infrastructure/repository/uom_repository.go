@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/uom"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// gormUnitRepository is a GORM implementation of the uom.Repository.
+type gormUnitRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormUnitRepository) WithTx(tx *gorm.DB) uom.Repository {
+	return NewGormUnitRepository(tx)
+}
+
+// NewGormUnitRepository creates a new gormUnitRepository.
+func NewGormUnitRepository(db *gorm.DB) uom.Repository {
+	return &gormUnitRepository{db: db}
+}
+
+func (r *gormUnitRepository) CreateCategory(ctx context.Context, category *uom.Category) error {
+	if category.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromUoMCategoryDomainEntity(category)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create unit category: %w", err)
+	}
+	return nil
+}
+
+func (r *gormUnitRepository) GetCategory(ctx context.Context, id uuid.UUID) (*uom.Category, error) {
+	var model models.UoMCategory
+	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, uom.ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to get unit category: %w", err)
+	}
+	return toUoMCategoryDomainEntity(&model), nil
+}
+
+func (r *gormUnitRepository) ListCategories(ctx context.Context) ([]*uom.Category, error) {
+	var modelList []models.UoMCategory
+	if err := r.db.WithContext(ctx).Order("name").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list unit categories: %w", err)
+	}
+	domainList := make([]*uom.Category, len(modelList))
+	for i := range modelList {
+		domainList[i] = toUoMCategoryDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormUnitRepository) CreateUnit(ctx context.Context, unit *uom.Unit) error {
+	if unit.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromUoMUnitDomainEntity(unit)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create unit of measure: %w", err)
+	}
+	return nil
+}
+
+func (r *gormUnitRepository) GetUnitByCode(ctx context.Context, code string) (*uom.Unit, error) {
+	var model models.UoMUnit
+	if err := r.db.WithContext(ctx).First(&model, "code = ?", code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, uom.ErrUnitNotFound
+		}
+		return nil, fmt.Errorf("failed to get unit of measure: %w", err)
+	}
+	return toUoMUnitDomainEntity(&model), nil
+}
+
+func (r *gormUnitRepository) ListUnits(ctx context.Context, categoryID *uuid.UUID) ([]*uom.Unit, error) {
+	query := r.db.WithContext(ctx).Model(&models.UoMUnit{})
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+
+	var modelList []models.UoMUnit
+	if err := query.Order("code").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list units of measure: %w", err)
+	}
+	domainList := make([]*uom.Unit, len(modelList))
+	for i := range modelList {
+		domainList[i] = toUoMUnitDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func toUoMCategoryDomainEntity(model *models.UoMCategory) *uom.Category {
+	return &uom.Category{
+		ID:        model.ID,
+		Name:      model.Name,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+		CreatedBy: model.CreatedBy,
+		UpdatedBy: model.UpdatedBy,
+	}
+}
+
+func fromUoMCategoryDomainEntity(entity *uom.Category) *models.UoMCategory {
+	return &models.UoMCategory{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		Name: entity.Name,
+	}
+}
+
+func toUoMUnitDomainEntity(model *models.UoMUnit) *uom.Unit {
+	return &uom.Unit{
+		ID:         model.ID,
+		CategoryID: model.CategoryID,
+		Code:       model.Code,
+		Name:       model.Name,
+		Factor:     model.Factor,
+		CreatedAt:  model.CreatedAt,
+		UpdatedAt:  model.UpdatedAt,
+		CreatedBy:  model.CreatedBy,
+		UpdatedBy:  model.UpdatedBy,
+	}
+}
+
+func fromUoMUnitDomainEntity(entity *uom.Unit) *models.UoMUnit {
+	return &models.UoMUnit{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		CategoryID: entity.CategoryID,
+		Code:       entity.Code,
+		Name:       entity.Name,
+		Factor:     entity.Factor,
+	}
+}
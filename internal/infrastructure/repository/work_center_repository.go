@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"doligo_001/internal/domain/bom"
+	"doligo_001/internal/infrastructure/db/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// gormWorkCenterRepository is a GORM implementation of the bom.WorkCenterRepository.
+type gormWorkCenterRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWorkCenterRepository creates a new gormWorkCenterRepository.
+func NewGormWorkCenterRepository(db *gorm.DB) bom.WorkCenterRepository {
+	return &gormWorkCenterRepository{db: db}
+}
+
+func (r *gormWorkCenterRepository) WithTx(tx *gorm.DB) bom.WorkCenterRepository {
+	return NewGormWorkCenterRepository(tx)
+}
+
+func (r *gormWorkCenterRepository) Create(ctx context.Context, wc *bom.WorkCenter) error {
+	if wc.CreatedBy == uuid.Nil {
+		return errors.New("created_by is required")
+	}
+	model := fromWorkCenterDomainEntity(wc)
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create work center: %w", err)
+	}
+	wc.CreatedAt, wc.UpdatedAt = model.CreatedAt, model.UpdatedAt
+	return nil
+}
+
+func (r *gormWorkCenterRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.WorkCenter, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+func (r *gormWorkCenterRepository) GetByCode(ctx context.Context, code string) (*bom.WorkCenter, error) {
+	return r.get(ctx, "code = ?", code)
+}
+
+func (r *gormWorkCenterRepository) get(ctx context.Context, query string, arg interface{}) (*bom.WorkCenter, error) {
+	var model models.WorkCenter
+	if err := r.db.WithContext(ctx).First(&model, query, arg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, bom.ErrWorkCenterNotFound
+		}
+		return nil, fmt.Errorf("failed to get work center: %w", err)
+	}
+	return toWorkCenterDomainEntity(&model), nil
+}
+
+func (r *gormWorkCenterRepository) List(ctx context.Context, includeInactive bool) ([]*bom.WorkCenter, error) {
+	query := r.db.WithContext(ctx).Model(&models.WorkCenter{})
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var modelList []models.WorkCenter
+	if err := query.Order("code").Find(&modelList).Error; err != nil {
+		return nil, fmt.Errorf("failed to list work centers: %w", err)
+	}
+	domainList := make([]*bom.WorkCenter, len(modelList))
+	for i := range modelList {
+		domainList[i] = toWorkCenterDomainEntity(&modelList[i])
+	}
+	return domainList, nil
+}
+
+func (r *gormWorkCenterRepository) Update(ctx context.Context, wc *bom.WorkCenter) error {
+	if wc.UpdatedBy == uuid.Nil {
+		return errors.New("updated_by is required")
+	}
+	result := r.db.WithContext(ctx).Model(&models.WorkCenter{}).Where("id = ?", wc.ID).Updates(map[string]interface{}{
+		"name":          wc.Name,
+		"labor_rate":    wc.LaborRate,
+		"overhead_rate": wc.OverheadRate,
+		"is_active":     wc.IsActive,
+		"updated_at":    wc.UpdatedAt,
+		"updated_by":    wc.UpdatedBy,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update work center: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return bom.ErrWorkCenterNotFound
+	}
+	return nil
+}
+
+func toWorkCenterDomainEntity(model *models.WorkCenter) *bom.WorkCenter {
+	return &bom.WorkCenter{
+		ID:           model.ID,
+		Code:         model.Code,
+		Name:         model.Name,
+		LaborRate:    model.LaborRate,
+		OverheadRate: model.OverheadRate,
+		IsActive:     model.IsActive,
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+		CreatedBy:    model.CreatedBy,
+		UpdatedBy:    model.UpdatedBy,
+	}
+}
+
+func fromWorkCenterDomainEntity(entity *bom.WorkCenter) *models.WorkCenter {
+	return &models.WorkCenter{
+		BaseModel: models.BaseModel{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			UpdatedAt: entity.UpdatedAt,
+			CreatedBy: entity.CreatedBy,
+			UpdatedBy: entity.UpdatedBy,
+		},
+		Code:         entity.Code,
+		Name:         entity.Name,
+		LaborRate:    entity.LaborRate,
+		OverheadRate: entity.OverheadRate,
+		IsActive:     entity.IsActive,
+	}
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodic executes fn every interval until ctx is canceled. A failed run is logged
+// and retried on the next tick. It blocks, so callers usually start it in a goroutine.
+func RunPeriodic(ctx context.Context, interval time.Duration, taskName string, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	log.Printf("Periodic task '%s' started (every %v).", taskName, interval)
+
+	for {
+		select {
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			if err := fn(runCtx); err != nil {
+				log.Printf("Periodic task '%s' failed: %v", taskName, err)
+			}
+			cancel()
+		case <-ctx.Done():
+			log.Printf("Periodic task '%s' stopping: context canceled.", taskName)
+			return
+		}
+	}
+}
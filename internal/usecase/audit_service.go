@@ -90,7 +90,7 @@ func (s *auditService) calculateSeverity(resource, action string, oldV, newV int
 	if resource == "invoice" && action == "DELETE" {
 		return "CRITICAL"
 	}
-	if resource == "stock" && action == "REVERSAL" {
+	if (resource == "stock" || resource == "production") && action == "REVERSAL" {
 		return "CRITICAL"
 	}
 
@@ -111,6 +111,11 @@ func (s *auditService) calculateSeverity(resource, action string, oldV, newV int
 		}
 	}
 
+	// Inventory count approvals post stock adjustments
+	if resource == "stock_count" && action == "APPROVE" {
+		return "WARN"
+	}
+
 	// Default warnings for deletions
 	if action == "DELETE" {
 		return "WARN"
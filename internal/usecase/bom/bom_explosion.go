@@ -0,0 +1,251 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	"github.com/google/uuid"
+)
+
+// ExplodeBOM returns the multi-level structure of a BOM for quantity units of its product.
+// Components produced by an active BOM of their own are exploded down to the purchased items,
+// which are costed at their CostPrice; every sub-assembly is costed at the rolled-up cost of
+// its own components plus the labor and overhead of its routing, run once for the quantity
+// needed. Quantities are in the base unit of each item. A BOM that consumes its own product
+// at any level fails with ErrBOMCycle.
+func (u *bomUsecase) ExplodeBOM(ctx context.Context, bomID uuid.UUID, quantity float64) (*domainBom.BOMNode, error) {
+	b, err := u.bomRepo.GetByID(ctx, bomID)
+	if err != nil {
+		return nil, err
+	}
+	e := newExplosion(u)
+	product, err := e.item(ctx, b.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &domainBom.BOMNode{
+		ItemID:      b.ProductID,
+		ItemName:    product.Name,
+		BOMID:       &b.ID,
+		QuantityPer: 1,
+		Quantity:    quantity,
+	}
+	if err := e.explode(ctx, root, b, map[uuid.UUID]bool{b.ProductID: true}); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// WhereUsed lists the BOMs that consume an item. With multiLevel, the BOMs that consume those
+// products are listed as well, level by level, with the quantity of the item each of them
+// consumes per unit of its product, in the base unit of the item. Every revision is listed, with
+// its status; a revision reached along several paths is listed once, at its lowest level.
+func (u *bomUsecase) WhereUsed(ctx context.Context, itemID uuid.UUID, multiLevel bool) ([]*domainBom.WhereUsed, error) {
+	type usage struct {
+		itemID   uuid.UUID
+		quantity float64 // Of the looked up item per unit of itemID
+	}
+
+	var res []*domainBom.WhereUsed
+	e := newExplosion(u)
+	seen := make(map[uuid.UUID]bool)
+	level := []usage{{itemID: itemID, quantity: 1}}
+	for depth := 1; len(level) > 0; depth++ {
+		var next []usage
+		for _, used := range level {
+			boms, err := u.bomRepo.ListByComponent(ctx, used.itemID)
+			if err != nil {
+				return nil, err
+			}
+			if len(boms) == 0 {
+				continue
+			}
+			it, err := e.item(ctx, used.itemID)
+			if err != nil {
+				return nil, err
+			}
+			for _, b := range boms {
+				if seen[b.ID] {
+					continue
+				}
+				seen[b.ID] = true
+				var perUnit float64
+				for _, comp := range b.Components {
+					if comp.ComponentItemID == used.itemID {
+						factor, err := u.componentFactor(ctx, it, comp)
+						if err != nil {
+							return nil, err
+						}
+						perUnit += comp.Quantity * factor
+					}
+				}
+				entry := &domainBom.WhereUsed{
+					BOMID:     b.ID,
+					BOMName:   b.Name,
+					ProductID: b.ProductID,
+					Revision:  b.Revision,
+					Status:    b.Status,
+					IsActive:  b.IsActive,
+					Level:     depth,
+					Quantity:  used.quantity * perUnit,
+				}
+				res = append(res, entry)
+				next = append(next, usage{itemID: b.ProductID, quantity: entry.Quantity})
+			}
+		}
+		if !multiLevel {
+			break
+		}
+		level = next
+	}
+	return res, nil
+}
+
+// checkCycle fails with ErrBOMCycle when one of the components of a BOM producing productID, or
+// one of their alternates, is or is made of productID itself.
+func (u *bomUsecase) checkCycle(ctx context.Context, productID uuid.UUID, components []domainBom.BillOfMaterialsComponent) error {
+	e := newExplosion(u)
+	return e.checkCycle(ctx, components, map[uuid.UUID]bool{productID: true})
+}
+
+// explosion walks the BOM structure below a product, caching the items and BOMs it loads.
+// Sub-assemblies are exploded with their revision in force at the time of the explosion.
+type explosion struct {
+	usecase *bomUsecase
+	at      time.Time
+	items   map[uuid.UUID]*item.Item
+	boms    map[uuid.UUID]*domainBom.BillOfMaterials // Active BOM by product ID, nil for purchased items
+}
+
+func newExplosion(u *bomUsecase) *explosion {
+	return &explosion{
+		usecase: u,
+		at:      time.Now(),
+		items:   make(map[uuid.UUID]*item.Item),
+		boms:    make(map[uuid.UUID]*domainBom.BillOfMaterials),
+	}
+}
+
+// explode adds the components of b below node and rolls their cost and the cost of the
+// routing of b up into node. path holds the items of the branch being exploded.
+func (e *explosion) explode(ctx context.Context, node *domainBom.BOMNode, b *domainBom.BillOfMaterials, path map[uuid.UUID]bool) error {
+	var unitCost float64
+	for _, comp := range b.Components {
+		if path[comp.ComponentItemID] {
+			return fmt.Errorf("%w: %s is a component of itself", domainBom.ErrBOMCycle, comp.ComponentItemID)
+		}
+		it, err := e.item(ctx, comp.ComponentItemID)
+		if err != nil {
+			return err
+		}
+		factor, err := e.usecase.componentFactor(ctx, it, comp)
+		if err != nil {
+			return err
+		}
+		child := &domainBom.BOMNode{
+			ItemID:        comp.ComponentItemID,
+			ItemName:      it.Name,
+			Level:         node.Level + 1,
+			QuantityPer:   b.PlannedQuantity(comp) * factor,
+			Quantity:      node.Quantity * b.PlannedQuantity(comp) * factor,
+			UnitOfMeasure: comp.UnitOfMeasure,
+			UnitCost:      it.CostPrice,
+		}
+		if it.BaseUnit != "" {
+			child.UnitOfMeasure = it.BaseUnit
+		}
+		sub, err := e.activeBOM(ctx, comp.ComponentItemID)
+		if err != nil {
+			return err
+		}
+		if sub != nil {
+			child.BOMID = &sub.ID
+			path[comp.ComponentItemID] = true
+			err := e.explode(ctx, child, sub, path)
+			delete(path, comp.ComponentItemID)
+			if err != nil {
+				return err
+			}
+		}
+		child.TotalCost = child.UnitCost * child.Quantity
+		unitCost += child.UnitCost * child.QuantityPer
+		node.Components = append(node.Components, child)
+	}
+
+	labor, overhead, err := routingCost(ctx, e.usecase.workCenterRepo, b, node.Quantity)
+	if err != nil {
+		return err
+	}
+	node.LaborCost, node.OverheadCost = labor, overhead
+	if node.Quantity > 0 {
+		unitCost += (labor + overhead) / node.Quantity
+	}
+	node.UnitCost = unitCost
+	node.TotalCost = unitCost * node.Quantity
+	return nil
+}
+
+func (e *explosion) checkCycle(ctx context.Context, components []domainBom.BillOfMaterialsComponent, path map[uuid.UUID]bool) error {
+	for _, comp := range components {
+		// An alternate is consumed in place of its component, so it must not be made of the product either
+		itemIDs := []uuid.UUID{comp.ComponentItemID}
+		for _, alt := range comp.Alternates {
+			itemIDs = append(itemIDs, alt.ItemID)
+		}
+		for _, itemID := range itemIDs {
+			if path[itemID] {
+				return fmt.Errorf("%w: %s is a component of itself", domainBom.ErrBOMCycle, itemID)
+			}
+			sub, err := e.activeBOM(ctx, itemID)
+			if err != nil {
+				return err
+			}
+			if sub == nil {
+				continue
+			}
+			path[itemID] = true
+			err = e.checkCycle(ctx, sub.Components, path)
+			delete(path, itemID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *explosion) item(ctx context.Context, id uuid.UUID) (*item.Item, error) {
+	if it, ok := e.items[id]; ok {
+		return it, nil
+	}
+	it, err := e.usecase.itemRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch item %s: %w", id, err)
+	}
+	e.items[id] = it
+	return it, nil
+}
+
+// activeBOM returns the active BOM revision in force producing the item, or nil if the item is not produced.
+func (e *explosion) activeBOM(ctx context.Context, productID uuid.UUID) (*domainBom.BillOfMaterials, error) {
+	if b, ok := e.boms[productID]; ok {
+		return b, nil
+	}
+	b, err := e.usecase.bomRepo.GetEffective(ctx, productID, e.at)
+	if err != nil {
+		if !errors.Is(err, domainBom.ErrBOMNotFound) {
+			return nil, err
+		}
+		b = nil
+	}
+	if b != nil && !b.IsActive {
+		b = nil
+	}
+	e.boms[productID] = b
+	return b, nil
+}
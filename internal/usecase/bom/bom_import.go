@@ -0,0 +1,275 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxImportRows caps the number of rows of a bulk BOM import.
+const MaxImportRows = 5000
+
+// ErrImportSize is returned when a bulk BOM import has no rows or more than MaxImportRows.
+var ErrImportSize = fmt.Errorf("a BOM import must contain between 1 and %d rows", MaxImportRows)
+
+// ImportRow is a component line of a bulk BOM import. The rows of a product with the same
+// revision make up one BOM, named and yielded after its first row. The revision only groups
+// the rows: every BOM imported is created as the next draft revision of its product.
+type ImportRow struct {
+	Line            int // Numbered from 1
+	ProductID       uuid.UUID
+	Revision        int
+	Name            string
+	YieldPercent    float64 // Defaults to 100
+	ComponentItemID uuid.UUID
+	Quantity        float64
+	ScrapPercent    float64
+	UnitOfMeasure   string
+}
+
+// ImportRowStatus is the outcome of a row of a bulk BOM import.
+type ImportRowStatus string
+
+const (
+	ImportRowValid    ImportRowStatus = "VALID"    // Passed validation, not created
+	ImportRowCreated  ImportRowStatus = "CREATED"  // Created as a component of a draft revision
+	ImportRowRejected ImportRowStatus = "REJECTED" // In error
+)
+
+// ImportRowResult reports the outcome of a row. Errors are those of the row itself and of
+// the BOM it belongs to. The valid rows of an import with errors are reported VALID but
+// nothing is created.
+type ImportRowResult struct {
+	Line      int
+	ProductID uuid.UUID
+	Status    ImportRowStatus
+	BOMID     *uuid.UUID // Set once created
+	Revision  int        // Set once created
+	Errors    []string
+}
+
+// ImportReport is the per-row result of a bulk BOM import.
+type ImportReport struct {
+	DryRun  bool
+	BOMs    int // BOMs read from the rows
+	Created int // BOMs created
+	Rows    []ImportRowResult
+}
+
+// HasErrors reports whether a row of the import was rejected.
+func (r *ImportReport) HasErrors() bool {
+	for _, row := range r.Rows {
+		if len(row.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ImportReport) reject(i int, message string) {
+	r.Rows[i].Errors = append(r.Rows[i].Errors, message)
+}
+
+// ExportFilter selects the BOMs of an export. The zero value exports every BOM.
+type ExportFilter struct {
+	ProductID *uuid.UUID
+	Status    domainBom.RevisionStatus
+	IsActive  *bool
+}
+
+// importedBOM is a BOM read from the rows of an import.
+type importedBOM struct {
+	bom  *domainBom.BillOfMaterials
+	rows []int // Indexes of its rows in the report
+}
+
+// ImportBOMs validates the rows of a bulk import: the products and components must exist, the
+// unit of measure of every component must convert to the base unit of its item, and no BOM may
+// consume its own product, directly or through a sub-assembly, counting the other BOMs of the
+// import in place of the revisions in force of their products. Every row is reported. When
+// dryRun is not set and no row is rejected, the BOMs are created in one transaction as draft
+// revisions; a single rejected row creates none.
+func (u *bomUsecase) ImportBOMs(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error) {
+	if len(rows) == 0 || len(rows) > MaxImportRows {
+		return nil, ErrImportSize
+	}
+	userID, _ := domain.UserIDFromContext(ctx)
+
+	report := &ImportReport{DryRun: dryRun, Rows: make([]ImportRowResult, len(rows))}
+	type groupKey struct {
+		productID uuid.UUID
+		revision  int
+	}
+	groups := make(map[groupKey]*importedBOM)
+	var imported []*importedBOM
+	for i, row := range rows {
+		report.Rows[i] = ImportRowResult{Line: row.Line, ProductID: row.ProductID}
+		key := groupKey{row.ProductID, row.Revision}
+		g, ok := groups[key]
+		if !ok {
+			g = &importedBOM{bom: &domainBom.BillOfMaterials{
+				ID:           uuid.New(),
+				ProductID:    row.ProductID,
+				Name:         row.Name,
+				IsActive:     true,
+				YieldPercent: row.YieldPercent,
+			}}
+			if g.bom.YieldPercent == 0 {
+				g.bom.YieldPercent = 100
+			}
+			groups[key] = g
+			imported = append(imported, g)
+		} else if row.Name != "" && row.Name != g.bom.Name {
+			report.reject(i, fmt.Sprintf("name %q differs from %q on line %d", row.Name, g.bom.Name, report.Rows[g.rows[0]].Line))
+		} else if row.YieldPercent != 0 && row.YieldPercent != g.bom.YieldPercent {
+			report.reject(i, fmt.Sprintf("yield %v differs from %v on line %d", row.YieldPercent, g.bom.YieldPercent, report.Rows[g.rows[0]].Line))
+		}
+		g.rows = append(g.rows, i)
+
+		comp := domainBom.BillOfMaterialsComponent{
+			ID:                uuid.New(),
+			BillOfMaterialsID: g.bom.ID,
+			ComponentItemID:   row.ComponentItemID,
+			Quantity:          row.Quantity,
+			ScrapPercent:      row.ScrapPercent,
+			UnitOfMeasure:     row.UnitOfMeasure,
+			IsActive:          true,
+		}
+		comp.SetCreatedBy(userID)
+		comp.SetUpdatedBy(userID)
+		g.bom.Components = append(g.bom.Components, comp)
+		if err := u.validateImportRow(ctx, comp); err != nil {
+			report.reject(i, err.Error())
+		}
+	}
+	report.BOMs = len(imported)
+
+	e := newExplosion(u)
+	for _, g := range imported {
+		if _, ok := e.boms[g.bom.ProductID]; !ok {
+			e.boms[g.bom.ProductID] = g.bom
+		}
+	}
+	for _, g := range imported {
+		var bomErr error
+		if g.bom.YieldPercent <= 0 || g.bom.YieldPercent > 100 {
+			bomErr = domainBom.ErrInvalidScrapFactor
+		} else if _, err := e.item(ctx, g.bom.ProductID); err != nil {
+			bomErr = importItemError(g.bom.ProductID, err)
+		} else {
+			bomErr = e.checkCycle(ctx, g.bom.Components, map[uuid.UUID]bool{g.bom.ProductID: true})
+		}
+		if bomErr != nil {
+			for _, i := range g.rows {
+				report.reject(i, bomErr.Error())
+			}
+		}
+	}
+
+	if dryRun || report.HasErrors() {
+		for i := range report.Rows {
+			report.Rows[i].Status = ImportRowValid
+			if len(report.Rows[i].Errors) > 0 {
+				report.Rows[i].Status = ImportRowRejected
+			}
+		}
+		return report, nil
+	}
+
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txBomRepo := u.bomRepo.WithTx(tx)
+		for _, g := range imported {
+			revisions, err := txBomRepo.ListRevisionsForUpdate(ctx, g.bom.ProductID)
+			if err != nil {
+				return err
+			}
+			g.bom.Revision = nextRevision(revisions)
+			g.bom.Status = domainBom.RevisionDraft
+			g.bom.SetCreatedBy(userID)
+			g.bom.SetUpdatedBy(userID)
+			if err := txBomRepo.Create(ctx, g.bom); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	for _, g := range imported {
+		bomID := g.bom.ID
+		for _, i := range g.rows {
+			report.Rows[i].Status = ImportRowCreated
+			report.Rows[i].BOMID = &bomID
+			report.Rows[i].Revision = g.bom.Revision
+		}
+		u.auditService.Log(ctx, userID, "bom", bomID.String(), "IMPORT", nil,
+			map[string]interface{}{"product_id": g.bom.ProductID, "revision": g.bom.Revision, "components": len(g.bom.Components)},
+			corrID)
+	}
+	report.Created = len(imported)
+	return report, nil
+}
+
+// validateImportRow checks the component of a row: its item must exist and its quantity be
+// given in a unit of measure that converts to the base unit of the item.
+func (u *bomUsecase) validateImportRow(ctx context.Context, comp domainBom.BillOfMaterialsComponent) error {
+	if comp.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+	if comp.ScrapPercent < 0 {
+		return domainBom.ErrInvalidScrapFactor
+	}
+	it, err := u.itemRepo.GetByID(ctx, comp.ComponentItemID)
+	if err != nil {
+		return importItemError(comp.ComponentItemID, err)
+	}
+	_, err = u.componentFactor(ctx, it, comp)
+	return err
+}
+
+// importItemError reports an unknown item by its ID.
+func importItemError(itemID uuid.UUID, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("item %s not found", itemID)
+	}
+	return err
+}
+
+// ExportBOMs returns the BOMs selected by the filter, every revision of them, by product and
+// revision.
+func (u *bomUsecase) ExportBOMs(ctx context.Context, filter ExportFilter) ([]*domainBom.BillOfMaterials, error) {
+	boms, err := u.bomRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*domainBom.BillOfMaterials, 0, len(boms))
+	for _, b := range boms {
+		if filter.ProductID != nil && b.ProductID != *filter.ProductID {
+			continue
+		}
+		if filter.Status != "" && b.Status != filter.Status {
+			continue
+		}
+		if filter.IsActive != nil && b.IsActive != *filter.IsActive {
+			continue
+		}
+		res = append(res, b)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ProductID != res[j].ProductID {
+			return res[i].ProductID.String() < res[j].ProductID.String()
+		}
+		return res[i].Revision < res[j].Revision
+	})
+	return res, nil
+}
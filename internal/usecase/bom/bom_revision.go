@@ -0,0 +1,287 @@
+package bom
+
+import (
+	"context"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListRevisions returns every revision of a product, drafts and obsolete ones included.
+func (u *bomUsecase) ListRevisions(ctx context.Context, productID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return u.bomRepo.ListRevisions(ctx, productID)
+}
+
+// CreateRevision copies a revision and its routing into a new draft, numbered after the last revision of the product.
+func (u *bomUsecase) CreateRevision(ctx context.Context, bomID uuid.UUID) (*domainBom.BillOfMaterials, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var draft *domainBom.BillOfMaterials
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txBomRepo := u.bomRepo.WithTx(tx)
+		source, err := txBomRepo.GetByID(ctx, bomID)
+		if err != nil {
+			return err
+		}
+		revisions, err := txBomRepo.ListRevisionsForUpdate(ctx, source.ProductID)
+		if err != nil {
+			return err
+		}
+
+		draft = &domainBom.BillOfMaterials{
+			ID:           uuid.New(),
+			ProductID:    source.ProductID,
+			Name:         source.Name,
+			IsActive:     source.IsActive,
+			Revision:     nextRevision(revisions),
+			Status:       domainBom.RevisionDraft,
+			YieldPercent: source.YieldPercent,
+		}
+		for _, comp := range source.Components {
+			comp.ID = uuid.New()
+			comp.BillOfMaterialsID = draft.ID
+			alternates := comp.Alternates
+			comp.Alternates = nil
+			for _, alt := range alternates {
+				alt.ID = uuid.New()
+				alt.ComponentID = comp.ID
+				comp.Alternates = append(comp.Alternates, alt)
+			}
+			comp.SetCreatedBy(userID)
+			comp.SetUpdatedBy(userID)
+			draft.Components = append(draft.Components, comp)
+		}
+		for _, op := range source.Operations {
+			op.ID = uuid.New()
+			op.BillOfMaterialsID = draft.ID
+			op.CreatedBy, op.UpdatedBy = userID, userID
+			draft.Operations = append(draft.Operations, op)
+		}
+		draft.SetCreatedBy(userID)
+		draft.SetUpdatedBy(userID)
+		return txBomRepo.Create(ctx, draft)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "bom", draft.ID.String(), "CREATE_REVISION", nil,
+		map[string]interface{}{"source_bom_id": bomID, "revision": draft.Revision},
+		corrID)
+	return draft, nil
+}
+
+// ApproveRevision freezes a draft and puts it in force from effectiveFrom, until effectiveTo if
+// given. The approved revisions of the product still in force at effectiveFrom end there, and
+// those that would only start later are made obsolete, so at most one revision is in force at a time.
+func (u *bomUsecase) ApproveRevision(ctx context.Context, bomID uuid.UUID, effectiveFrom time.Time, effectiveTo *time.Time) (*domainBom.BillOfMaterials, error) {
+	if effectiveTo != nil && !effectiveTo.After(effectiveFrom) {
+		return nil, domainBom.ErrInvalidEffectiveDates
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	var approved *domainBom.BillOfMaterials
+	var superseded []uuid.UUID
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txBomRepo := u.bomRepo.WithTx(tx)
+		b, err := txBomRepo.GetByID(ctx, bomID)
+		if err != nil {
+			return err
+		}
+		// Lock the revisions of the product so concurrent approvals are applied one after the other.
+		revisions, err := txBomRepo.ListRevisionsForUpdate(ctx, b.ProductID)
+		if err != nil {
+			return err
+		}
+		for _, r := range revisions {
+			if r.ID == b.ID {
+				b = r
+			}
+		}
+		if b.Status != domainBom.RevisionDraft {
+			return domainBom.ErrRevisionNotDraft
+		}
+		if err := u.checkCycle(ctx, b.ProductID, b.Components); err != nil {
+			return err
+		}
+
+		for _, r := range revisions {
+			if r.ID == b.ID || r.Status != domainBom.RevisionApproved {
+				continue
+			}
+			if r.EffectiveTo != nil && !r.EffectiveTo.After(effectiveFrom) {
+				continue
+			}
+			if r.EffectiveFrom.Before(effectiveFrom) {
+				end := effectiveFrom
+				r.EffectiveTo = &end
+			} else {
+				r.Status = domainBom.RevisionObsolete
+			}
+			r.SetUpdatedBy(userID)
+			if err := txBomRepo.UpdateStatus(ctx, r); err != nil {
+				return err
+			}
+			superseded = append(superseded, r.ID)
+		}
+
+		from := effectiveFrom
+		b.Status = domainBom.RevisionApproved
+		b.EffectiveFrom = &from
+		b.EffectiveTo = effectiveTo
+		b.SetUpdatedBy(userID)
+		if err := txBomRepo.UpdateStatus(ctx, b); err != nil {
+			return err
+		}
+		approved = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "bom", approved.ID.String(), "APPROVE",
+		map[string]interface{}{"status": domainBom.RevisionDraft},
+		map[string]interface{}{
+			"status":         approved.Status,
+			"effective_from": approved.EffectiveFrom,
+			"effective_to":   approved.EffectiveTo,
+			"superseded":     superseded,
+		},
+		corrID)
+	return approved, nil
+}
+
+// ObsoleteRevision takes an approved revision out of force from now on.
+func (u *bomUsecase) ObsoleteRevision(ctx context.Context, bomID uuid.UUID) (*domainBom.BillOfMaterials, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var obsolete *domainBom.BillOfMaterials
+	var oldEffectiveTo *time.Time
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txBomRepo := u.bomRepo.WithTx(tx)
+		b, err := txBomRepo.GetByID(ctx, bomID)
+		if err != nil {
+			return err
+		}
+		if b.Status != domainBom.RevisionApproved {
+			return domainBom.ErrRevisionNotApproved
+		}
+
+		oldEffectiveTo = b.EffectiveTo
+		now := time.Now()
+		if b.EffectiveTo == nil || b.EffectiveTo.After(now) {
+			b.EffectiveTo = &now
+			if !now.After(*b.EffectiveFrom) {
+				// Never in force: keep the dates valid
+				b.EffectiveTo = nil
+			}
+		}
+		b.Status = domainBom.RevisionObsolete
+		b.SetUpdatedBy(userID)
+		if err := txBomRepo.UpdateStatus(ctx, b); err != nil {
+			return err
+		}
+		obsolete = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "bom", obsolete.ID.String(), "OBSOLETE",
+		map[string]interface{}{"status": domainBom.RevisionApproved, "effective_to": oldEffectiveTo},
+		map[string]interface{}{"status": obsolete.Status, "effective_to": obsolete.EffectiveTo},
+		corrID)
+	return obsolete, nil
+}
+
+// DiffRevisions compares the components of two revisions of the same product. Components are
+// matched by item; a component listed on several lines of a revision is compared on its total quantity,
+// in the base unit of the item.
+func (u *bomUsecase) DiffRevisions(ctx context.Context, fromID, toID uuid.UUID) (*domainBom.RevisionDiff, error) {
+	from, err := u.bomRepo.GetByID(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := u.bomRepo.GetByID(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.ProductID != to.ProductID {
+		return nil, domainBom.ErrRevisionProductMismatch
+	}
+
+	fromBase, err := inBaseUnits(ctx, u.itemRepo, u.unitRepo, from)
+	if err != nil {
+		return nil, err
+	}
+	toBase, err := inBaseUnits(ctx, u.itemRepo, u.unitRepo, to)
+	if err != nil {
+		return nil, err
+	}
+	fromComps, fromOrder := componentsByItem(fromBase)
+	toComps, toOrder := componentsByItem(toBase)
+	diff := &domainBom.RevisionDiff{From: from, To: to}
+	for _, itemID := range fromOrder {
+		old := fromComps[itemID]
+		cur, ok := toComps[itemID]
+		if !ok {
+			diff.Removed = append(diff.Removed, old)
+			continue
+		}
+		if old.Quantity != cur.Quantity || old.ScrapPercent != cur.ScrapPercent || old.UnitOfMeasure != cur.UnitOfMeasure {
+			diff.Changed = append(diff.Changed, domainBom.ComponentChange{
+				ComponentItemID:   itemID,
+				FromQuantity:      old.Quantity,
+				ToQuantity:        cur.Quantity,
+				FromScrapPercent:  old.ScrapPercent,
+				ToScrapPercent:    cur.ScrapPercent,
+				FromUnitOfMeasure: old.UnitOfMeasure,
+				ToUnitOfMeasure:   cur.UnitOfMeasure,
+			})
+		}
+	}
+	for _, itemID := range toOrder {
+		if _, ok := fromComps[itemID]; !ok {
+			diff.Added = append(diff.Added, toComps[itemID])
+		}
+	}
+	return diff, nil
+}
+
+// componentsByItem sums the components of a revision by item, keeping the order of the lines.
+// The scrap percentage of an item on several lines is their average weighted by quantity.
+func componentsByItem(b *domainBom.BillOfMaterials) (map[uuid.UUID]domainBom.BillOfMaterialsComponent, []uuid.UUID) {
+	comps := make(map[uuid.UUID]domainBom.BillOfMaterialsComponent, len(b.Components))
+	var order []uuid.UUID
+	for _, comp := range b.Components {
+		if existing, ok := comps[comp.ComponentItemID]; ok {
+			if total := existing.Quantity + comp.Quantity; total > 0 {
+				existing.ScrapPercent = (existing.Quantity*existing.ScrapPercent + comp.Quantity*comp.ScrapPercent) / total
+			}
+			existing.Quantity += comp.Quantity
+			comps[comp.ComponentItemID] = existing
+			continue
+		}
+		comps[comp.ComponentItemID] = comp
+		order = append(order, comp.ComponentItemID)
+	}
+	return comps, order
+}
+
+// nextRevision returns the number of the revision that follows the existing ones.
+func nextRevision(revisions []*domainBom.BillOfMaterials) int {
+	next := 1
+	for _, r := range revisions {
+		if r.Revision >= next {
+			next = r.Revision + 1
+		}
+	}
+	return next
+}
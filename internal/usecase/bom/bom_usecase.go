@@ -10,8 +10,11 @@ import (
 	domainBom "doligo_001/internal/domain/bom"
 	"doligo_001/internal/domain/item"
 	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
 	"doligo_001/internal/infrastructure/db"
 	"doligo_001/internal/usecase"
+	stock_uc "doligo_001/internal/usecase/stock"
+	uom_uc "doligo_001/internal/usecase/uom"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -25,7 +28,19 @@ type BOMUsecase interface {
 	UpdateBOM(ctx context.Context, bom *domainBom.BillOfMaterials) error
 	DeleteBOM(ctx context.Context, id uuid.UUID) error
 	CalculatePredictiveCost(ctx context.Context, bomID uuid.UUID) (float64, error)
-	ProduceItem(ctx context.Context, bomID, warehouseID, userID uuid.UUID, productionQuantity float64) (uuid.UUID, float64, error)
+	ExplodeBOM(ctx context.Context, bomID uuid.UUID, quantity float64) (*domainBom.BOMNode, error)
+	WhereUsed(ctx context.Context, itemID uuid.UUID, multiLevel bool) ([]*domainBom.WhereUsed, error)
+	ListRevisions(ctx context.Context, productID uuid.UUID) ([]*domainBom.BillOfMaterials, error)
+	CreateRevision(ctx context.Context, bomID uuid.UUID) (*domainBom.BillOfMaterials, error)
+	ApproveRevision(ctx context.Context, bomID uuid.UUID, effectiveFrom time.Time, effectiveTo *time.Time) (*domainBom.BillOfMaterials, error)
+	ObsoleteRevision(ctx context.Context, bomID uuid.UUID) (*domainBom.BillOfMaterials, error)
+	DiffRevisions(ctx context.Context, fromID, toID uuid.UUID) (*domainBom.RevisionDiff, error)
+	ProduceItem(ctx context.Context, bomID, warehouseID, userID uuid.UUID, productionQuantity float64, opts domainBom.ProductionOptions) (uuid.UUID, float64, error)
+	ReverseProduction(ctx context.Context, recordID uuid.UUID, reason string) (*domainBom.ProductionRecord, error)
+	DisassembleProduction(ctx context.Context, recordID uuid.UUID, quantity float64, opts domainBom.DisassemblyOptions) (*domainBom.ProductionRecord, error)
+	TraceLot(ctx context.Context, itemID uuid.UUID, lotNumber string) (*domainBom.LotGenealogy, error)
+	ImportBOMs(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error)
+	ExportBOMs(ctx context.Context, filter ExportFilter) ([]*domainBom.BillOfMaterials, error)
 }
 
 type bomUsecase struct {
@@ -35,7 +50,12 @@ type bomUsecase struct {
 	stockRepo       stock.StockRepository
 	stockMoveRepo   stock.StockMovementRepository
 	stockLedgerRepo stock.StockLedgerRepository
+	lotRepo         stock.StockLotRepository
+	costLayerRepo   stock.CostLayerRepository
+	reservationRepo stock.ReservationRepository
 	itemRepo        item.Repository
+	unitRepo        uom.Repository
+	workCenterRepo  domainBom.WorkCenterRepository
 	auditService    usecase.AuditService
 }
 
@@ -46,7 +66,12 @@ func NewBOMUsecase(
 	stockRepo stock.StockRepository,
 	stockMoveRepo stock.StockMovementRepository,
 	stockLedgerRepo stock.StockLedgerRepository,
+	lotRepo stock.StockLotRepository,
+	costLayerRepo stock.CostLayerRepository,
+	reservationRepo stock.ReservationRepository,
 	itemRepo item.Repository,
+	unitRepo uom.Repository,
+	workCenterRepo domainBom.WorkCenterRepository,
 	auditService usecase.AuditService,
 ) BOMUsecase {
 	return &bomUsecase{
@@ -56,12 +81,47 @@ func NewBOMUsecase(
 		stockRepo:       stockRepo,
 		stockMoveRepo:   stockMoveRepo,
 		stockLedgerRepo: stockLedgerRepo,
+		lotRepo:         lotRepo,
+		costLayerRepo:   costLayerRepo,
+		reservationRepo: reservationRepo,
 		itemRepo:        itemRepo,
+		unitRepo:        unitRepo,
+		workCenterRepo:  workCenterRepo,
 		auditService:    auditService,
 	}
 }
 
+// CreateBOM creates a draft BOM as the next revision of its product. It fails with ErrBOMCycle
+// if the BOM consumes its own product, directly or through a sub-assembly.
+// A BOM without a yield is expected to yield 100%. The unit of measure of every component must
+// convert to the base unit of its item, its alternates must be valid, and the operations of its
+// routing must be planned on active work centers.
 func (u *bomUsecase) CreateBOM(ctx context.Context, bom *domainBom.BillOfMaterials) error {
+	if bom.YieldPercent == 0 {
+		bom.YieldPercent = 100
+	}
+	if err := bom.ValidateScrapFactors(); err != nil {
+		return err
+	}
+	if err := bom.ValidateAlternates(); err != nil {
+		return err
+	}
+	if err := u.validateUnits(ctx, bom.Components); err != nil {
+		return err
+	}
+	if err := u.validateOperations(ctx, bom); err != nil {
+		return err
+	}
+	if err := u.checkCycle(ctx, bom.ProductID, bom.Components); err != nil {
+		return err
+	}
+	revisions, err := u.bomRepo.ListRevisions(ctx, bom.ProductID)
+	if err != nil {
+		return err
+	}
+	bom.Revision = nextRevision(revisions)
+	bom.Status = domainBom.RevisionDraft
+	bom.EffectiveFrom, bom.EffectiveTo = nil, nil
 	return u.bomRepo.Create(ctx, bom)
 }
 
@@ -69,207 +129,336 @@ func (u *bomUsecase) GetBOMByID(ctx context.Context, id uuid.UUID) (*domainBom.B
 	return u.bomRepo.GetByID(ctx, id)
 }
 
+// GetBOMByProductID returns the revision of the product in force now.
 func (u *bomUsecase) GetBOMByProductID(ctx context.Context, productID uuid.UUID) (*domainBom.BillOfMaterials, error) {
-	return u.bomRepo.GetByProductID(ctx, productID)
+	return u.bomRepo.GetEffective(ctx, productID, time.Now())
 }
 
 func (u *bomUsecase) ListBOMs(ctx context.Context) ([]*domainBom.BillOfMaterials, error) {
 	return u.bomRepo.List(ctx)
 }
 
+// UpdateBOM changes the name, yield, components and routing of a draft revision. Approved and obsolete
+// revisions are frozen; a new revision is created from them instead, see CreateRevision.
 func (u *bomUsecase) UpdateBOM(ctx context.Context, bom *domainBom.BillOfMaterials) error {
 	if bom.ID == uuid.Nil {
 		return fmt.Errorf("BOM ID is required for update")
 	}
+	current, err := u.bomRepo.GetByID(ctx, bom.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status != domainBom.RevisionDraft {
+		return domainBom.ErrRevisionNotDraft
+	}
+	if bom.ProductID != current.ProductID {
+		return fmt.Errorf("the product of a BOM revision cannot be changed")
+	}
+	if bom.YieldPercent == 0 {
+		bom.YieldPercent = 100
+	}
+	if err := bom.ValidateScrapFactors(); err != nil {
+		return err
+	}
+	if err := bom.ValidateAlternates(); err != nil {
+		return err
+	}
+	if err := u.validateUnits(ctx, bom.Components); err != nil {
+		return err
+	}
+	if err := u.validateOperations(ctx, bom); err != nil {
+		return err
+	}
+	if err := u.checkCycle(ctx, bom.ProductID, bom.Components); err != nil {
+		return err
+	}
 	return u.bomRepo.Update(ctx, bom)
 }
 
+// DeleteBOM deletes a draft revision. Approved revisions may be referenced by production records.
 func (u *bomUsecase) DeleteBOM(ctx context.Context, id uuid.UUID) error {
 	if id == uuid.Nil {
 		return fmt.Errorf("BOM ID is required for deletion")
 	}
+	current, err := u.bomRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Status != domainBom.RevisionDraft {
+		return domainBom.ErrRevisionNotDraft
+	}
 	return u.bomRepo.Delete(ctx, id)
 }
 
+// CalculatePredictiveCost returns the cost of one unit of the BOM product, rolled up through
+// its sub-assemblies and routings, see ExplodeBOM. The setup time of the routing is spent on
+// that single unit.
 func (u *bomUsecase) CalculatePredictiveCost(ctx context.Context, bomID uuid.UUID) (float64, error) {
-	bom, err := u.bomRepo.GetByID(ctx, bomID)
+	root, err := u.ExplodeBOM(ctx, bomID, 1)
 	if err != nil {
 		return 0, err
 	}
-
-	var totalCost float64
-	for _, comp := range bom.Components {
-		item, err := u.itemRepo.GetByID(ctx, comp.ComponentItemID)
-		if err != nil {
-			return 0, fmt.Errorf("failed to fetch item %s: %w", comp.ComponentItemID, err)
-		}
-		totalCost += item.CostPrice * comp.Quantity
-	}
-
-	return totalCost, nil
+	return root.UnitCost, nil
 }
 
-func (u *bomUsecase) ProduceItem(ctx context.Context, bomID, warehouseID, userID uuid.UUID, productionQuantity float64) (uuid.UUID, float64, error) {
-	var productionRecordID uuid.UUID
-	var actualProductionCost float64
+// ProduceItem consumes the BOM components from the warehouse and receives the finished product.
+// bomID may be any revision of the product: the run always uses the revision in force, and the
+// production record references that revision.
+// Components and products that are lot or serial tracked must be given their lots in opts; the
+// consumed and produced lots are kept on the ProductionRecord for the lot genealogy.
+// Components can only be consumed up to their available quantity, i.e. the stock on hand
+// that is not held by reservations. Components are issued at the cost of their costing method
+// (FIFO layers, standard or average cost) and the product is received at the resulting actual unit cost.
+// Each component is consumed at its net quantity, grossed up by the yield of the BOM, and its
+// scrap is consumed by a separate movement: the actual scrap given in opts, or else the scrap
+// percentage of the component. The cost of the scrap beyond the planned scrap is the yield variance
+// of the production record.
+// Component quantities are converted from the unit of measure of the BOM line to the base unit of
+// the component item; the scrap and the lots given in opts are in the base unit.
+// The labor and overhead of the routing of the revision, at the current rates of its work
+// centers, are part of the actual production cost and recorded apart on the production record.
+// Alternates replace the components chosen in opts.Substitutes, and cover the shortfall of the
+// components short of available stock, by priority; the production record notes every substitution.
+func (u *bomUsecase) ProduceItem(ctx context.Context, bomID, warehouseID, userID uuid.UUID, productionQuantity float64, opts domainBom.ProductionOptions) (uuid.UUID, float64, error) {
+	var productionRecordID, revisionID uuid.UUID
+	var actualProductionCost, runYieldVariance, runLaborCost, runOverheadCost float64
+	var runSubstitutions []domainBom.ProductionSubstitution
 
 	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
 		// 1. Initialize transactional repositories
 		txBomRepo := u.bomRepo.WithTx(tx)
 		txStockRepo := u.stockRepo.WithTx(tx)
-		txStockMoveRepo := u.stockMoveRepo.WithTx(tx)
-		txStockLedgerRepo := u.stockLedgerRepo.WithTx(tx)
 		txProductionRepo := u.productionRepo.WithTx(tx)
 		txItemRepo := u.itemRepo.WithTx(tx)
+		txReservationRepo := u.reservationRepo.WithTx(tx)
+		repos := stock_uc.PostingRepositories{
+			Stock:     txStockRepo,
+			Movements: u.stockMoveRepo.WithTx(tx),
+			Ledger:    u.stockLedgerRepo.WithTx(tx),
+			Lots:      u.lotRepo.WithTx(tx),
+		}
+		costRepos := stock_uc.CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: u.costLayerRepo.WithTx(tx)}
 
-		// 2. Fetch BOM
-		bom, err := txBomRepo.GetByID(ctx, bomID)
+		// 2. Fetch the revision of the product in force
+		now := time.Now()
+		requested, err := txBomRepo.GetByID(ctx, bomID)
 		if err != nil {
 			return err
 		}
+		bom, err := txBomRepo.GetEffective(ctx, requested.ProductID, now)
+		if err != nil {
+			if errors.Is(err, domainBom.ErrBOMNotFound) {
+				return fmt.Errorf("%w: %s", domainBom.ErrNoRevisionInForce, requested.ProductID)
+			}
+			return err
+		}
+		for itemID := range opts.ComponentLots {
+			if !usesItem(bom, itemID) {
+				return fmt.Errorf("item %s is not a component of BOM %s", itemID, bom.ID)
+			}
+		}
+		for itemID, qty := range opts.ComponentScrap {
+			if !usesItem(bom, itemID) {
+				return fmt.Errorf("item %s is not a component of BOM %s", itemID, bom.ID)
+			}
+			if qty < 0 {
+				return fmt.Errorf("%w: scrap of component %s", stock_uc.ErrInvalidQuantity, itemID)
+			}
+		}
 
-		now := time.Now()
-		var totalProductionCost float64
+		// Alternates stand in for the components chosen to be replaced or short of stock
+		sub := &substitution{
+			usecase:      u,
+			stocks:       txStockRepo,
+			reservations: txReservationRepo,
+			items:        txItemRepo,
+			warehouseID:  warehouseID,
+			at:           now,
+			available:    make(map[uuid.UUID]float64),
+		}
+		lines, substitutions, err := sub.substitute(ctx, bom, productionQuantity, opts.Substitutes)
+		if err != nil {
+			return err
+		}
 
-		// 3. Process Components (Stock OUT and Cost Calculation)
-		for _, comp := range bom.Components {
-			neededQty := comp.Quantity * productionQuantity
+		var totalProductionCost, yieldVariance float64
+		var lots []domainBom.ProductionLot
+		// The movements of the run are linked to its record, so that it can be reversed
+		recordID := uuid.New()
 
-			// Fetch Item to get CostPrice (captured within transaction)
-			componentItem, err := txItemRepo.GetByID(ctx, comp.ComponentItemID)
+		// issue consumes a quantity of a storable component and returns its unit cost.
+		// Components can only be consumed up to their available quantity.
+		issue := func(componentItem *item.Item, qty float64, componentLots []stock.LotQuantity, reason string) (float64, error) {
+			s, err := txStockRepo.GetStockForUpdate(ctx, componentItem.ID, warehouseID, nil)
 			if err != nil {
-				return fmt.Errorf("failed to fetch item %s for cost calculation: %w", comp.ComponentItemID, err)
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return 0, fmt.Errorf("insufficient stock for component %s: not found", componentItem.ID)
+				}
+				return 0, err
 			}
-			totalProductionCost += componentItem.CostPrice * neededQty
 
-			// Pessimistic Lock on component stock (only for Storable items)
-			if componentItem.Type == item.Storable {
-				s, err := txStockRepo.GetStockForUpdate(ctx, comp.ComponentItemID, warehouseID, nil)
-				if err != nil {
-					if errors.Is(err, gorm.ErrRecordNotFound) {
-						return fmt.Errorf("insufficient stock for component %s: not found", comp.ComponentItemID)
-					}
-					return err
-				}
+			if s.Quantity < qty {
+				return 0, fmt.Errorf("insufficient stock for component %s: have %f, need %f", componentItem.ID, s.Quantity, qty)
+			}
+			reserved, err := txReservationRepo.ReservedQuantity(ctx, componentItem.ID, warehouseID, nil, now)
+			if err != nil {
+				return 0, err
+			}
+			if available := s.Quantity - reserved; available < qty {
+				return 0, fmt.Errorf("insufficient available stock for component %s: have %f available (%f reserved), need %f", componentItem.ID, available, reserved, qty)
+			}
 
-				if s.Quantity < neededQty {
-					return fmt.Errorf("insufficient stock for component %s: have %f, need %f", comp.ComponentItemID, s.Quantity, neededQty)
-				}
+			movementID := uuid.New()
+			valuation, err := stock_uc.CostIssue(ctx, costRepos, componentItem, movementID, qty)
+			if err != nil {
+				return 0, fmt.Errorf("component %s: %w", componentItem.ID, err)
+			}
+			if _, _, err := stock_uc.PostMovement(ctx, repos, stock_uc.Posting{
+				MovementID:         movementID,
+				ItemID:             componentItem.ID,
+				WarehouseID:        warehouseID,
+				Type:               stock.MovementTypeOut,
+				Quantity:           qty,
+				QuantityBefore:     s.Quantity,
+				Reserved:           reserved,
+				Reason:             reason,
+				ProductionRecordID: &recordID,
+				Tracking:           componentItem.TrackingMode,
+				Lots:               componentLots,
+				UnitCost:           valuation.UnitCost,
+				HappenedAt:         now,
+				UserID:             userID,
+			}); err != nil {
+				return 0, fmt.Errorf("component %s: %w", componentItem.ID, err)
+			}
+			lots = append(lots, productionLots(componentItem.ID, componentLots, domainBom.LotConsumed)...)
+			return valuation.UnitCost, nil
+		}
 
-				oldQty := s.Quantity
-				s.Quantity -= neededQty
-				if err := txStockRepo.UpsertStock(ctx, s); err != nil {
-					return err
-				}
+		// 3. Process Components (Stock OUT and Cost Calculation)
+		// The lines consume their net quantity; the scrap of each component is consumed
+		// afterwards by a movement of its own, taking the lots left over by its lines.
+		componentItems := make(map[uuid.UUID]*item.Item)
+		plannedScrap := make(map[uuid.UUID]float64)
+		scrapLots := make(map[uuid.UUID][]stock.LotQuantity)
+		var scrapOrder []uuid.UUID
+		for _, comp := range lines {
+			// Fetch Item to get its cost (captured within transaction)
+			componentItem, err := txItemRepo.GetByID(ctx, comp.ComponentItemID)
+			if err != nil {
+				return fmt.Errorf("failed to fetch item %s for cost calculation: %w", comp.ComponentItemID, err)
+			}
+			factor, err := u.componentFactor(ctx, componentItem, comp)
+			if err != nil {
+				return err
+			}
+			neededQty := bom.NetQuantity(comp) * productionQuantity * factor
+			if _, ok := componentItems[comp.ComponentItemID]; !ok {
+				componentItems[comp.ComponentItemID] = componentItem
+				scrapOrder = append(scrapOrder, comp.ComponentItemID)
+				scrapLots[comp.ComponentItemID] = opts.ComponentLots[comp.ComponentItemID]
+			}
+			plannedScrap[comp.ComponentItemID] += bom.PlannedQuantity(comp)*productionQuantity*factor - neededQty
 
-				// Create Stock Movement
-				move := &stock.StockMovement{
-					ID:          uuid.New(),
-					ItemID:      comp.ComponentItemID,
-					WarehouseID: warehouseID,
-					BinID:       nil,
-					Type:        stock.MovementTypeOut,
-					Quantity:    neededQty,
-					Reason:      fmt.Sprintf("Production of BOM %s", bomID),
-					HappenedAt:  now,
-					CreatedBy:   userID,
-				}
-				if err := txStockMoveRepo.Create(ctx, move); err != nil {
-					return err
-				}
+			if componentItem.Type != item.Storable {
+				totalProductionCost += stock_uc.CurrentUnitCost(componentItem) * neededQty
+				continue
+			}
+			var lineLots []stock.LotQuantity
+			lineLots, scrapLots[comp.ComponentItemID] = splitLots(scrapLots[comp.ComponentItemID], neededQty)
+			unitCost, err := issue(componentItem, neededQty, lineLots, fmt.Sprintf("Production of BOM %s", bom.ID))
+			if err != nil {
+				return err
+			}
+			totalProductionCost += unitCost * neededQty
+		}
 
-				// Create Ledger Entry
-				ledger := &stock.StockLedger{
-					ID:              uuid.New(),
-					StockMovementID: move.ID,
-					ItemID:          comp.ComponentItemID,
-					WarehouseID:     warehouseID,
-					BinID:           nil,
-					MovementType:    stock.MovementTypeOut,
-					QuantityChange:  neededQty,
-					QuantityBefore:  oldQty,
-					QuantityAfter:   s.Quantity,
-					Reason:          move.Reason,
-					HappenedAt:      now,
-					RecordedAt:      now,
-					RecordedBy:      userID,
-				}
-				if err := txStockLedgerRepo.Create(ctx, ledger); err != nil {
+		for _, itemID := range scrapOrder {
+			planned := plannedScrap[itemID]
+			scrapQty, recorded := opts.ComponentScrap[itemID]
+			if !recorded {
+				scrapQty = planned
+			}
+			if scrapQty <= quantityEpsilon && planned <= quantityEpsilon {
+				continue
+			}
+			componentItem := componentItems[itemID]
+			unitCost := stock_uc.CurrentUnitCost(componentItem)
+			if componentItem.Type == item.Storable && scrapQty > quantityEpsilon {
+				unitCost, err = issue(componentItem, scrapQty, scrapLots[itemID], fmt.Sprintf("Scrap of production of BOM %s", bom.ID))
+				if err != nil {
 					return err
 				}
 			}
+			totalProductionCost += unitCost * scrapQty
+			yieldVariance += unitCost * (scrapQty - planned)
 		}
 
-		// 4. Process Product (Stock IN)
-		// Pessimistic Lock on product stock
-		prodStock, err := txStockRepo.GetStockForUpdate(ctx, bom.ProductID, warehouseID, nil)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		// Labor and overhead of the routing operations of the run
+		laborCost, overheadCost, err := routingCost(ctx, u.workCenterRepo, bom, productionQuantity)
+		if err != nil {
 			return err
 		}
+		totalProductionCost += laborCost + overheadCost
 
-		var oldProdQty float64 = 0
-		if prodStock != nil {
-			oldProdQty = prodStock.Quantity
-		} else {
-			prodStock = &stock.Stock{
-				ItemID:      bom.ProductID,
-				WarehouseID: warehouseID,
-				BinID:       nil,
-			}
+		// 4. Process Product (Stock IN)
+		product, err := txItemRepo.GetByID(ctx, bom.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch product %s: %w", bom.ProductID, err)
 		}
 
-		prodStock.Quantity += productionQuantity
-		prodStock.UpdatedAt = now
-		if err := txStockRepo.UpsertStock(ctx, prodStock); err != nil {
+		// Pessimistic Lock on product stock
+		oldProdQty, err := stock_uc.LockedQuantity(ctx, txStockRepo, bom.ProductID, warehouseID, nil)
+		if err != nil {
 			return err
 		}
 
-		// Create Stock Movement for Product
-		prodMove := &stock.StockMovement{
-			ID:          uuid.New(),
-			ItemID:      bom.ProductID,
-			WarehouseID: warehouseID,
-			BinID:       nil,
-			Type:        stock.MovementTypeIn,
-			Quantity:    productionQuantity,
-			Reason:      fmt.Sprintf("Finished production of BOM %s", bomID),
-			HappenedAt:  now,
-			CreatedBy:   userID,
+		// Receive the product at the actual cost of the components it consumed
+		movementID := uuid.New()
+		var unitCost float64
+		if productionQuantity > 0 {
+			unitCost = totalProductionCost / productionQuantity
 		}
-		if err := txStockMoveRepo.Create(ctx, prodMove); err != nil {
-			return err
+		valuation, err := stock_uc.CostReceipt(ctx, costRepos, product, movementID, productionQuantity, unitCost, now)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", bom.ProductID, err)
 		}
 
-		// Create Ledger Entry for Product
-		prodLedger := &stock.StockLedger{
-			ID:              uuid.New(),
-			StockMovementID: prodMove.ID,
-			ItemID:          bom.ProductID,
-			WarehouseID:     warehouseID,
-			BinID:           nil,
-			MovementType:    stock.MovementTypeIn,
-			QuantityChange:  productionQuantity,
-			QuantityBefore:  oldProdQty,
-			QuantityAfter:   prodStock.Quantity,
-			Reason:          prodMove.Reason,
-			HappenedAt:      now,
-			RecordedAt:      now,
-			RecordedBy:      userID,
-		}
-		if err := txStockLedgerRepo.Create(ctx, prodLedger); err != nil {
-			return err
+		if _, _, err := stock_uc.PostMovement(ctx, repos, stock_uc.Posting{
+			MovementID:         movementID,
+			ItemID:             bom.ProductID,
+			WarehouseID:        warehouseID,
+			Type:               stock.MovementTypeIn,
+			Quantity:           productionQuantity,
+			QuantityBefore:     oldProdQty,
+			Reason:             fmt.Sprintf("Finished production of BOM %s", bom.ID),
+			ProductionRecordID: &recordID,
+			Tracking:           product.TrackingMode,
+			Lots:               opts.ProductLots,
+			UnitCost:           valuation.UnitCost,
+			CostVariance:       valuation.CostVariance,
+			HappenedAt:         now,
+			UserID:             userID,
+		}); err != nil {
+			return fmt.Errorf("product %s: %w", bom.ProductID, err)
 		}
+		lots = append(lots, productionLots(bom.ProductID, opts.ProductLots, domainBom.LotProduced)...)
 
 		// 5. Create Production Record
 		record := &domainBom.ProductionRecord{
-			ID:                   uuid.New(),
-			BillOfMaterialsID:    bomID,
+			ID:                   recordID,
+			BillOfMaterialsID:    bom.ID,
 			ProducedProductID:    bom.ProductID,
 			ProductionQuantity:   productionQuantity,
 			ActualProductionCost: totalProductionCost,
+			LaborCost:            laborCost,
+			OverheadCost:         overheadCost,
+			YieldVariance:        yieldVariance,
 			WarehouseID:          warehouseID,
 			ProducedAt:           now,
 			CreatedBy:            userID,
+			Lots:                 lots,
+			Substitutions:        substitutions,
 		}
 		if err := txProductionRepo.Create(ctx, record); err != nil {
 			return err
@@ -277,6 +466,10 @@ func (u *bomUsecase) ProduceItem(ctx context.Context, bomID, warehouseID, userID
 
 		productionRecordID = record.ID
 		actualProductionCost = record.ActualProductionCost
+		runYieldVariance = record.YieldVariance
+		runLaborCost, runOverheadCost = record.LaborCost, record.OverheadCost
+		revisionID = bom.ID
+		runSubstitutions = substitutions
 		return nil
 	})
 
@@ -284,12 +477,156 @@ func (u *bomUsecase) ProduceItem(ctx context.Context, bomID, warehouseID, userID
 		corrID, _ := middleware.FromContext(ctx)
 		u.auditService.Log(ctx, userID, "production", productionRecordID.String(), "CREATE",
 			nil, map[string]interface{}{
-				"bom_id":      bomID,
-				"quantity":    productionQuantity,
-				"actual_cost": actualProductionCost,
+				"bom_id":         revisionID,
+				"quantity":       productionQuantity,
+				"actual_cost":    actualProductionCost,
+				"yield_variance": runYieldVariance,
+				"labor_cost":     runLaborCost,
+				"overhead_cost":  runOverheadCost,
+				"substitutions":  runSubstitutions,
 			},
 			corrID)
 	}
 
 	return productionRecordID, actualProductionCost, err
 }
+
+// validateUnits checks that the unit of measure of every component converts to the base unit of
+// its item, and that the items of its alternates exist.
+func (u *bomUsecase) validateUnits(ctx context.Context, components []domainBom.BillOfMaterialsComponent) error {
+	for _, comp := range components {
+		it, err := u.itemRepo.GetByID(ctx, comp.ComponentItemID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch item %s: %w", comp.ComponentItemID, err)
+		}
+		if _, err := u.componentFactor(ctx, it, comp); err != nil {
+			return err
+		}
+		for _, alt := range comp.Alternates {
+			if _, err := u.itemRepo.GetByID(ctx, alt.ItemID); err != nil {
+				return fmt.Errorf("failed to fetch item %s: %w", alt.ItemID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (u *bomUsecase) componentFactor(ctx context.Context, it *item.Item, comp domainBom.BillOfMaterialsComponent) (float64, error) {
+	return componentFactor(ctx, u.unitRepo, it, comp)
+}
+
+// componentFactor returns the number of base units of the component item in one unit of
+// measure of the BOM line.
+func componentFactor(ctx context.Context, units uom.Repository, it *item.Item, comp domainBom.BillOfMaterialsComponent) (float64, error) {
+	factor, err := uom_uc.BaseFactor(ctx, units, it, comp.UnitOfMeasure)
+	if err != nil {
+		return 0, fmt.Errorf("component %s in %q: %w", comp.ComponentItemID, comp.UnitOfMeasure, err)
+	}
+	return factor, nil
+}
+
+// inBaseUnits returns a copy of b whose component quantities are in the base unit of their item.
+func inBaseUnits(ctx context.Context, items item.Repository, units uom.Repository, b *domainBom.BillOfMaterials) (*domainBom.BillOfMaterials, error) {
+	converted := *b
+	converted.Components = make([]domainBom.BillOfMaterialsComponent, len(b.Components))
+	for i, comp := range b.Components {
+		it, err := items.GetByID(ctx, comp.ComponentItemID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch item %s: %w", comp.ComponentItemID, err)
+		}
+		factor, err := componentFactor(ctx, units, it, comp)
+		if err != nil {
+			return nil, err
+		}
+		comp.Quantity *= factor
+		if it.BaseUnit != "" {
+			comp.UnitOfMeasure = it.BaseUnit
+		}
+		converted.Components[i] = comp
+	}
+	return &converted, nil
+}
+
+// TraceLot follows a finished-good lot back through the production runs that produced it,
+// down to the component lots they consumed, recursively for sub-assemblies.
+func (u *bomUsecase) TraceLot(ctx context.Context, itemID uuid.UUID, lotNumber string) (*domainBom.LotGenealogy, error) {
+	root := &domainBom.LotGenealogy{ItemID: itemID, LotNumber: lotNumber}
+	if err := u.traceLot(ctx, root, map[string]bool{}, 0); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// maxGenealogyDepth bounds the recursion of TraceLot on deeply nested or corrupted data.
+const maxGenealogyDepth = 32
+
+func (u *bomUsecase) traceLot(ctx context.Context, node *domainBom.LotGenealogy, path map[string]bool, depth int) error {
+	key := node.ItemID.String() + "/" + node.LotNumber
+	if depth >= maxGenealogyDepth || path[key] {
+		return nil
+	}
+	path[key] = true
+	defer delete(path, key)
+
+	records, err := u.productionRepo.ListByProducedLot(ctx, node.ItemID, node.LotNumber)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		node.ProducedBy = append(node.ProducedBy, record.ID)
+		for _, l := range record.Lots {
+			if l.Role != domainBom.LotConsumed {
+				continue
+			}
+			child := &domainBom.LotGenealogy{ItemID: l.ItemID, LotNumber: l.LotNumber, Quantity: l.Quantity}
+			if err := u.traceLot(ctx, child, path, depth+1); err != nil {
+				return err
+			}
+			node.Components = append(node.Components, child)
+		}
+	}
+	return nil
+}
+
+// hasComponent reports whether the item is one of the BOM components.
+func hasComponent(bom *domainBom.BillOfMaterials, itemID uuid.UUID) bool {
+	for _, comp := range bom.Components {
+		if comp.ComponentItemID == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLots takes quantity off the front of lots and returns it together with the lots left.
+func splitLots(lots []stock.LotQuantity, quantity float64) (taken, rest []stock.LotQuantity) {
+	for i, l := range lots {
+		if quantity <= quantityEpsilon {
+			return taken, append(rest, lots[i:]...)
+		}
+		if l.Quantity <= quantity+quantityEpsilon {
+			taken = append(taken, l)
+			quantity -= l.Quantity
+			continue
+		}
+		taken = append(taken, stock.LotQuantity{LotNumber: l.LotNumber, Quantity: quantity})
+		rest = append(rest, stock.LotQuantity{LotNumber: l.LotNumber, Quantity: l.Quantity - quantity})
+		quantity = 0
+	}
+	return taken, rest
+}
+
+// productionLots turns the lots of one item into production lot records.
+func productionLots(itemID uuid.UUID, lots []stock.LotQuantity, role domainBom.LotRole) []domainBom.ProductionLot {
+	var res []domainBom.ProductionLot
+	for _, l := range lots {
+		res = append(res, domainBom.ProductionLot{
+			ID:        uuid.New(),
+			ItemID:    itemID,
+			LotNumber: l.LotNumber,
+			Quantity:  l.Quantity,
+			Role:      role,
+		})
+	}
+	return res
+}
@@ -3,10 +3,15 @@ package bom
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -42,13 +47,32 @@ func (f *fakeBomRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.Bil
 	return nil, errors.New("bom not found")
 }
 
-func (f *fakeBomRepository) GetByProductID(ctx context.Context, productID uuid.UUID) (*bom.BillOfMaterials, error) {
+func (f *fakeBomRepository) GetEffective(ctx context.Context, productID uuid.UUID, at time.Time) (*bom.BillOfMaterials, error) {
 	for _, b := range f.boms {
-		if b.ProductID == productID {
+		if b.ProductID == productID && b.InForce(at) {
 			return b, nil
 		}
 	}
-	return nil, errors.New("bom not found for product")
+	return nil, bom.ErrBOMNotFound
+}
+
+func (f *fakeBomRepository) ListRevisions(ctx context.Context, productID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	var revisions []*bom.BillOfMaterials
+	for _, b := range f.boms {
+		if b.ProductID == productID {
+			revisions = append(revisions, b)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	return revisions, nil
+}
+
+func (f *fakeBomRepository) ListRevisionsForUpdate(ctx context.Context, productID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	return f.ListRevisions(ctx, productID)
+}
+
+func (f *fakeBomRepository) UpdateStatus(ctx context.Context, b *bom.BillOfMaterials) error {
+	return f.Update(ctx, b)
 }
 
 func (f *fakeBomRepository) Update(ctx context.Context, bom *bom.BillOfMaterials) error {
@@ -75,9 +99,19 @@ func (f *fakeBomRepository) List(ctx context.Context) ([]*bom.BillOfMaterials, e
 	return bomList, nil
 }
 
+func (f *fakeBomRepository) ListByComponent(ctx context.Context, itemID uuid.UUID) ([]*bom.BillOfMaterials, error) {
+	var bomList []*bom.BillOfMaterials
+	for _, b := range f.boms {
+		if hasComponent(b, itemID) {
+			bomList = append(bomList, b)
+		}
+	}
+	return bomList, nil
+}
+
 func TestBomUsecase_GetBOMByID(t *testing.T) {
 	repo := newFakeBomRepository()
-	usecase := NewBOMUsecase(nil, repo, nil, nil, nil, nil, nil, nil)
+	usecase := NewBOMUsecase(nil, repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	bomID := uuid.New()
 	productID := uuid.New()
@@ -128,3 +162,1121 @@ func TestBomUsecase_GetBOMByID(t *testing.T) {
 		})
 	}
 }
+
+// --- In-memory fakes for production runs ---
+
+type fakeTx struct{}
+
+func (fakeTx) Transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return fc(nil)
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
+type fakeItemRepository struct {
+	items map[uuid.UUID]*item.Item
+}
+
+func (f *fakeItemRepository) WithTx(tx *gorm.DB) item.Repository { return f }
+func (f *fakeItemRepository) Create(ctx context.Context, i *item.Item) error {
+	f.items[i.ID] = i
+	return nil
+}
+func (f *fakeItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*item.Item, error) {
+	if i, ok := f.items[id]; ok {
+		return i, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeItemRepository) Update(ctx context.Context, i *item.Item) error { return nil }
+func (f *fakeItemRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeItemRepository) List(ctx context.Context) ([]*item.Item, error) { return nil, nil }
+
+// fakeStockRepository keeps non-binned stock per item.
+type fakeStockRepository struct {
+	quantities map[uuid.UUID]float64
+}
+
+func (f *fakeStockRepository) WithTx(tx *gorm.DB) stock.StockRepository { return f }
+func (f *fakeStockRepository) GetStock(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	return f.GetStockForUpdate(ctx, itemID, warehouseID, binID)
+}
+func (f *fakeStockRepository) GetStockForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	qty, ok := f.quantities[itemID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &stock.Stock{ItemID: itemID, WarehouseID: warehouseID, BinID: binID, Quantity: qty}, nil
+}
+func (f *fakeStockRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID) (float64, error) {
+	return f.quantities[itemID], nil
+}
+func (f *fakeStockRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	return nil, nil
+}
+func (f *fakeStockRepository) UpsertStock(ctx context.Context, s *stock.Stock) error {
+	f.quantities[s.ItemID] = s.Quantity
+	return nil
+}
+
+type fakeMovementRepository struct {
+	movements []*stock.StockMovement
+}
+
+func (f *fakeMovementRepository) WithTx(tx *gorm.DB) stock.StockMovementRepository { return f }
+func (f *fakeMovementRepository) Create(ctx context.Context, m *stock.StockMovement) error {
+	f.movements = append(f.movements, m)
+	return nil
+}
+func (f *fakeMovementRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeMovementRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
+	return f.GetByID(ctx, id)
+}
+func (f *fakeMovementRepository) MarkReversed(ctx context.Context, movement *stock.StockMovement) error {
+	return nil
+}
+func (f *fakeMovementRepository) ListByProductionRecord(ctx context.Context, recordID uuid.UUID) ([]*stock.StockMovement, error) {
+	var res []*stock.StockMovement
+	for _, m := range f.movements {
+		if m.ProductionRecordID != nil && *m.ProductionRecordID == recordID {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+type fakeLedgerRepository struct{}
+
+func (f *fakeLedgerRepository) WithTx(tx *gorm.DB) stock.StockLedgerRepository { return f }
+func (f *fakeLedgerRepository) Create(ctx context.Context, entry *stock.StockLedger) error {
+	return nil
+}
+func (f *fakeLedgerRepository) List(ctx context.Context, filter stock.LedgerFilter) ([]*stock.StockLedger, int64, error) {
+	return nil, 0, nil
+}
+func (f *fakeLedgerRepository) ListBalancesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.Stock, error) {
+	return nil, nil
+}
+func (f *fakeLedgerRepository) ListItemValuesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.ItemValue, error) {
+	return nil, nil
+}
+
+// fakeLotRepository keeps non-binned lot buckets per item and lot number.
+type fakeLotRepository struct {
+	quantities map[string]float64
+}
+
+func lotKey(itemID uuid.UUID, lotNumber string) string { return itemID.String() + "/" + lotNumber }
+
+func (f *fakeLotRepository) WithTx(tx *gorm.DB) stock.StockLotRepository { return f }
+func (f *fakeLotRepository) GetForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, lotNumber string) (*stock.StockLot, error) {
+	qty, ok := f.quantities[lotKey(itemID, lotNumber)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &stock.StockLot{ItemID: itemID, WarehouseID: warehouseID, LotNumber: lotNumber, Quantity: qty}, nil
+}
+func (f *fakeLotRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID, lotNumber string) (float64, error) {
+	return f.quantities[lotKey(itemID, lotNumber)], nil
+}
+func (f *fakeLotRepository) Upsert(ctx context.Context, l *stock.StockLot) error {
+	f.quantities[lotKey(l.ItemID, l.LotNumber)] = l.Quantity
+	return nil
+}
+func (f *fakeLotRepository) List(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*stock.StockLot, error) {
+	return nil, nil
+}
+
+// fakeCostLayerRepository keeps the FIFO layers in creation order, which is also their age order.
+type fakeCostLayerRepository struct {
+	layers       []*stock.CostLayer
+	consumptions []*stock.CostLayerConsumption
+}
+
+func (f *fakeCostLayerRepository) WithTx(tx *gorm.DB) stock.CostLayerRepository { return f }
+func (f *fakeCostLayerRepository) Create(ctx context.Context, layer *stock.CostLayer) error {
+	f.layers = append(f.layers, layer)
+	return nil
+}
+func (f *fakeCostLayerRepository) ListOpen(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	var res []*stock.CostLayer
+	for _, l := range f.layers {
+		if l.ItemID == itemID && l.RemainingQuantity > 0 {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+func (f *fakeCostLayerRepository) ListOpenForUpdate(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	return f.ListOpen(ctx, itemID)
+}
+func (f *fakeCostLayerRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*stock.CostLayer, error) {
+	for _, l := range f.layers {
+		if l.ID == id {
+			return l, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeCostLayerRepository) GetByMovementForUpdate(ctx context.Context, movementID uuid.UUID) (*stock.CostLayer, error) {
+	for _, l := range f.layers {
+		if l.MovementID == movementID {
+			return l, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeCostLayerRepository) UpdateRemaining(ctx context.Context, layer *stock.CostLayer) error {
+	return nil
+}
+func (f *fakeCostLayerRepository) CreateConsumption(ctx context.Context, c *stock.CostLayerConsumption) error {
+	f.consumptions = append(f.consumptions, c)
+	return nil
+}
+func (f *fakeCostLayerRepository) ListConsumptions(ctx context.Context, movementID uuid.UUID) ([]*stock.CostLayerConsumption, error) {
+	var res []*stock.CostLayerConsumption
+	for _, c := range f.consumptions {
+		if c.MovementID == movementID {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// fakeReservationRepository holds a fixed reserved quantity per item, on top of the
+// reservations created through it.
+type fakeReservationRepository struct {
+	reserved     map[uuid.UUID]float64
+	reservations map[uuid.UUID]*stock.Reservation
+}
+
+func (f *fakeReservationRepository) WithTx(tx *gorm.DB) stock.ReservationRepository { return f }
+func (f *fakeReservationRepository) Create(ctx context.Context, r *stock.Reservation) error {
+	if f.reservations == nil {
+		f.reservations = make(map[uuid.UUID]*stock.Reservation)
+	}
+	f.reservations[r.ID] = r
+	return nil
+}
+func (f *fakeReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	if r, ok := f.reservations[id]; ok {
+		return r, nil
+	}
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return f.GetByID(ctx, id)
+}
+func (f *fakeReservationRepository) FindActive(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) List(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error) {
+	return nil, nil
+}
+func (f *fakeReservationRepository) Update(ctx context.Context, r *stock.Reservation) error {
+	return nil
+}
+func (f *fakeReservationRepository) ReservedQuantity(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, asOf time.Time) (float64, error) {
+	reserved := f.reserved[itemID]
+	for _, r := range f.reservations {
+		if r.ItemID == itemID && r.IsHeld(asOf) {
+			reserved += r.OpenQuantity()
+		}
+	}
+	return reserved, nil
+}
+func (f *fakeReservationRepository) ExpireDue(ctx context.Context, asOf time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeProductionRepository struct {
+	records []*bom.ProductionRecord
+}
+
+func (f *fakeProductionRepository) WithTx(tx *gorm.DB) bom.ProductionRecordRepository { return f }
+func (f *fakeProductionRepository) Create(ctx context.Context, record *bom.ProductionRecord) error {
+	f.records = append(f.records, record)
+	return nil
+}
+func (f *fakeProductionRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*bom.ProductionRecord, error) {
+	for _, r := range f.records {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, bom.ErrProductionRecordNotFound
+}
+func (f *fakeProductionRepository) Update(ctx context.Context, record *bom.ProductionRecord) error {
+	return nil
+}
+func (f *fakeProductionRepository) ListByProducedLot(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*bom.ProductionRecord, error) {
+	var res []*bom.ProductionRecord
+	for _, r := range f.records {
+		for _, l := range r.Lots {
+			if l.Role == bom.LotProduced && l.ItemID == itemID && l.LotNumber == lotNumber {
+				res = append(res, r)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// fakeUnitRepository holds units of measure by code.
+type fakeUnitRepository struct {
+	units map[string]*uom.Unit
+}
+
+func (f *fakeUnitRepository) WithTx(tx *gorm.DB) uom.Repository { return f }
+func (f *fakeUnitRepository) CreateCategory(ctx context.Context, c *uom.Category) error {
+	return nil
+}
+func (f *fakeUnitRepository) GetCategory(ctx context.Context, id uuid.UUID) (*uom.Category, error) {
+	return &uom.Category{ID: id}, nil
+}
+func (f *fakeUnitRepository) ListCategories(ctx context.Context) ([]*uom.Category, error) {
+	return nil, nil
+}
+func (f *fakeUnitRepository) CreateUnit(ctx context.Context, u *uom.Unit) error {
+	f.units[u.Code] = u
+	return nil
+}
+func (f *fakeUnitRepository) GetUnitByCode(ctx context.Context, code string) (*uom.Unit, error) {
+	if u, ok := f.units[code]; ok {
+		return u, nil
+	}
+	return nil, uom.ErrUnitNotFound
+}
+func (f *fakeUnitRepository) ListUnits(ctx context.Context, categoryID *uuid.UUID) ([]*uom.Unit, error) {
+	return nil, nil
+}
+
+// newFakeUnitRepository returns the kilogram and the gram, and the litre in another category.
+func newFakeUnitRepository() *fakeUnitRepository {
+	mass, volume := uuid.New(), uuid.New()
+	return &fakeUnitRepository{units: map[string]*uom.Unit{
+		"kg": {ID: uuid.New(), CategoryID: mass, Code: "kg", Factor: 1},
+		"g":  {ID: uuid.New(), CategoryID: mass, Code: "g", Factor: 0.001},
+		"l":  {ID: uuid.New(), CategoryID: volume, Code: "l", Factor: 1},
+	}}
+}
+
+// fakeWorkCenterRepository holds work centers by ID.
+type fakeWorkCenterRepository struct {
+	centers map[uuid.UUID]*bom.WorkCenter
+}
+
+func (f *fakeWorkCenterRepository) WithTx(tx *gorm.DB) bom.WorkCenterRepository { return f }
+func (f *fakeWorkCenterRepository) Create(ctx context.Context, wc *bom.WorkCenter) error {
+	f.centers[wc.ID] = wc
+	return nil
+}
+func (f *fakeWorkCenterRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.WorkCenter, error) {
+	if wc, ok := f.centers[id]; ok {
+		return wc, nil
+	}
+	return nil, bom.ErrWorkCenterNotFound
+}
+func (f *fakeWorkCenterRepository) GetByCode(ctx context.Context, code string) (*bom.WorkCenter, error) {
+	for _, wc := range f.centers {
+		if wc.Code == code {
+			return wc, nil
+		}
+	}
+	return nil, bom.ErrWorkCenterNotFound
+}
+func (f *fakeWorkCenterRepository) List(ctx context.Context, includeInactive bool) ([]*bom.WorkCenter, error) {
+	return nil, nil
+}
+func (f *fakeWorkCenterRepository) Update(ctx context.Context, wc *bom.WorkCenter) error {
+	f.centers[wc.ID] = wc
+	return nil
+}
+
+type productionFixture struct {
+	usecase   BOMUsecase
+	bomRepo   *fakeBomRepository
+	items     *fakeItemRepository
+	units     *fakeUnitRepository
+	centers   *fakeWorkCenterRepository
+	stocks    *fakeStockRepository
+	lots      *fakeLotRepository
+	layers    *fakeCostLayerRepository
+	reserved  *fakeReservationRepository
+	records   *fakeProductionRepository
+	movements *fakeMovementRepository
+	warehouse uuid.UUID
+	userID    uuid.UUID
+}
+
+func newProductionFixture() *productionFixture {
+	f := &productionFixture{
+		bomRepo:   newFakeBomRepository(),
+		items:     &fakeItemRepository{items: make(map[uuid.UUID]*item.Item)},
+		units:     newFakeUnitRepository(),
+		centers:   &fakeWorkCenterRepository{centers: make(map[uuid.UUID]*bom.WorkCenter)},
+		stocks:    &fakeStockRepository{quantities: make(map[uuid.UUID]float64)},
+		lots:      &fakeLotRepository{quantities: make(map[string]float64)},
+		layers:    &fakeCostLayerRepository{},
+		reserved:  &fakeReservationRepository{reserved: make(map[uuid.UUID]float64)},
+		records:   &fakeProductionRepository{},
+		movements: &fakeMovementRepository{},
+		warehouse: uuid.New(),
+		userID:    uuid.New(),
+	}
+	f.usecase = NewBOMUsecase(fakeTx{}, f.bomRepo, f.records, f.stocks, f.movements, &fakeLedgerRepository{}, f.lots, f.layers, f.reserved, f.items, f.units, f.centers, fakeAudit{})
+	return f
+}
+
+// addBOM registers a single-component BOM producing product from quantity units of component,
+// approved as revision 1 and in force since yesterday.
+func (f *productionFixture) addBOM(productID, componentID uuid.UUID, quantity float64) uuid.UUID {
+	since := time.Now().AddDate(0, 0, -1)
+	b := &bom.BillOfMaterials{
+		ID:            uuid.New(),
+		ProductID:     productID,
+		Name:          "BOM",
+		IsActive:      true,
+		Revision:      1,
+		Status:        bom.RevisionApproved,
+		EffectiveFrom: &since,
+		Components: []bom.BillOfMaterialsComponent{
+			{ID: uuid.New(), ComponentItemID: componentID, Quantity: quantity},
+		},
+	}
+	f.bomRepo.Create(context.Background(), b)
+	return b.ID
+}
+
+func (f *productionFixture) addItem(tracking item.TrackingMode) uuid.UUID {
+	id := uuid.New()
+	f.items.items[id] = &item.Item{ID: id, Type: item.Storable, TrackingMode: tracking, CostPrice: 2}
+	return id
+}
+
+func TestBomUsecase_ProduceItem_ConsumesComponentLots(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingLot)
+	productID := f.addItem(item.TrackingLot)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.stocks.quantities[componentID] = 10
+	f.lots.quantities[lotKey(componentID, "RAW-1")] = 6
+	f.lots.quantities[lotKey(componentID, "RAW-2")] = 4
+
+	opts := bom.ProductionOptions{
+		ComponentLots: map[uuid.UUID][]stock.LotQuantity{
+			componentID: {{LotNumber: "RAW-1", Quantity: 5}, {LotNumber: "RAW-2", Quantity: 1}},
+		},
+		ProductLots: []stock.LotQuantity{{LotNumber: "FG-1", Quantity: 3}},
+	}
+	recordID, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 3, opts)
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	if got := f.lots.quantities[lotKey(componentID, "RAW-1")]; got != 1 {
+		t.Errorf("RAW-1 quantity = %v, want 1", got)
+	}
+	if got := f.lots.quantities[lotKey(componentID, "RAW-2")]; got != 3 {
+		t.Errorf("RAW-2 quantity = %v, want 3", got)
+	}
+	if got := f.lots.quantities[lotKey(productID, "FG-1")]; got != 3 {
+		t.Errorf("FG-1 quantity = %v, want 3", got)
+	}
+	if len(f.records.records) != 1 || f.records.records[0].ID != recordID {
+		t.Fatalf("expected one production record %s", recordID)
+	}
+	if got := len(f.records.records[0].Lots); got != 3 {
+		t.Errorf("production lots = %d, want 3", got)
+	}
+}
+
+func TestBomUsecase_ProduceItem_TrackedComponentRequiresLots(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingLot)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 1)
+	f.stocks.quantities[componentID] = 10
+
+	_, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 2, bom.ProductionOptions{})
+
+	if !errors.Is(err, stock.ErrLotRequired) {
+		t.Fatalf("ProduceItem() error = %v, want %v", err, stock.ErrLotRequired)
+	}
+	if len(f.records.records) != 0 {
+		t.Errorf("no production record expected")
+	}
+}
+
+func TestBomUsecase_ProduceItem_ReservedComponentsAreNotConsumed(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 1)
+	f.stocks.quantities[componentID] = 10
+	f.reserved.reserved[componentID] = 7
+
+	_, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 4, bom.ProductionOptions{})
+
+	if err == nil {
+		t.Fatal("expected production to fail on reserved component stock")
+	}
+	if got := f.stocks.quantities[componentID]; got != 10 {
+		t.Errorf("component quantity = %v, want 10", got)
+	}
+	if len(f.movements.movements) != 0 {
+		t.Errorf("no movement expected, got %d", len(f.movements.movements))
+	}
+}
+
+func TestBomUsecase_ProduceItem_CostsFIFOComponentsAndStandardProduct(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.items.items[componentID].CostingMethod = item.CostingFIFO
+	f.items.items[productID].CostingMethod = item.CostingStandard
+	f.items.items[productID].StandardCost = 20
+	f.stocks.quantities[componentID] = 10
+	f.layers.layers = []*stock.CostLayer{
+		{ID: uuid.New(), ItemID: componentID, UnitCost: 10, OriginalQuantity: 4, RemainingQuantity: 4},
+		{ID: uuid.New(), ItemID: componentID, UnitCost: 13, OriginalQuantity: 6, RemainingQuantity: 6},
+	}
+
+	_, cost, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 3, bom.ProductionOptions{})
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	// 6 components: 4 from the oldest layer at 10 and 2 from the next one at 13.
+	if cost != 66 {
+		t.Errorf("actual production cost = %v, want 66", cost)
+	}
+	if got := f.layers.layers[1].RemainingQuantity; got != 4 {
+		t.Errorf("remaining quantity of the second layer = %v, want 4", got)
+	}
+	if len(f.movements.movements) != 2 {
+		t.Fatalf("expected 2 movements, got %d", len(f.movements.movements))
+	}
+	if got := f.movements.movements[0].UnitCost; got != 11 {
+		t.Errorf("component unit cost = %v, want 11", got)
+	}
+	// The product is received at its standard cost; the 22 - 20 per unit is the production variance.
+	product := f.movements.movements[1]
+	if product.UnitCost != 20 || product.CostVariance != 6 {
+		t.Errorf("product unit cost = %v, variance = %v, want 20 and 6", product.UnitCost, product.CostVariance)
+	}
+}
+
+func TestBomUsecase_TraceLot_FollowsSubAssemblies(t *testing.T) {
+	f := newProductionFixture()
+	rawID := f.addItem(item.TrackingLot)
+	subID := f.addItem(item.TrackingLot)
+	finishedID := f.addItem(item.TrackingSerial)
+	subBOM := f.addBOM(subID, rawID, 2)
+	finishedBOM := f.addBOM(finishedID, subID, 1)
+	f.stocks.quantities[rawID] = 4
+	f.lots.quantities[lotKey(rawID, "RAW-9")] = 4
+
+	ctx := context.Background()
+	if _, _, err := f.usecase.ProduceItem(ctx, subBOM, f.warehouse, f.userID, 2, bom.ProductionOptions{
+		ComponentLots: map[uuid.UUID][]stock.LotQuantity{rawID: {{LotNumber: "RAW-9", Quantity: 4}}},
+		ProductLots:   []stock.LotQuantity{{LotNumber: "SUB-1", Quantity: 2}},
+	}); err != nil {
+		t.Fatalf("sub-assembly ProduceItem() error = %v", err)
+	}
+	if _, _, err := f.usecase.ProduceItem(ctx, finishedBOM, f.warehouse, f.userID, 1, bom.ProductionOptions{
+		ComponentLots: map[uuid.UUID][]stock.LotQuantity{subID: {{LotNumber: "SUB-1", Quantity: 1}}},
+		ProductLots:   []stock.LotQuantity{{LotNumber: "SN-100", Quantity: 1}},
+	}); err != nil {
+		t.Fatalf("finished ProduceItem() error = %v", err)
+	}
+
+	genealogy, err := f.usecase.TraceLot(ctx, finishedID, "SN-100")
+	if err != nil {
+		t.Fatalf("TraceLot() error = %v", err)
+	}
+	if len(genealogy.ProducedBy) != 1 || len(genealogy.Components) != 1 {
+		t.Fatalf("expected one producing run and one component lot, got %+v", genealogy)
+	}
+	sub := genealogy.Components[0]
+	if sub.ItemID != subID || sub.LotNumber != "SUB-1" || sub.Quantity != 1 {
+		t.Errorf("unexpected sub-assembly lot %+v", sub)
+	}
+	if len(sub.Components) != 1 || sub.Components[0].LotNumber != "RAW-9" || sub.Components[0].Quantity != 4 {
+		t.Errorf("unexpected raw material lots %+v", sub.Components)
+	}
+}
+
+func TestBomUsecase_ProduceItem_RecordsActualScrap(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.bomRepo.boms[bomID].YieldPercent = 80
+	f.bomRepo.boms[bomID].Components[0].ScrapPercent = 50
+	f.items.items[componentID].AverageCost = 2
+	f.stocks.quantities[componentID] = 20
+
+	// Net quantity 2 / 0.8 = 2.5 per unit, so 10 for 4 units, with 5 of planned scrap.
+	opts := bom.ProductionOptions{ComponentScrap: map[uuid.UUID]float64{componentID: 6}}
+	_, cost, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 4, opts)
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+	if cost != 32 {
+		t.Errorf("actual production cost = %v, want 32", cost)
+	}
+	if got := f.stocks.quantities[componentID]; got != 4 {
+		t.Errorf("component stock = %v, want 4", got)
+	}
+	if len(f.movements.movements) != 3 {
+		t.Fatalf("expected 3 movements, got %d", len(f.movements.movements))
+	}
+	if line, scrap := f.movements.movements[0], f.movements.movements[1]; line.Quantity != 10 || scrap.Quantity != 6 {
+		t.Errorf("component movements of %v and %v, want 10 and 6", line.Quantity, scrap.Quantity)
+	}
+	if got := f.records.records[0].YieldVariance; got != 2 {
+		t.Errorf("yield variance = %v, want 2", got)
+	}
+}
+
+func TestBomUsecase_ProduceItem_ConvertsComponentUnits(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	f.items.items[componentID].BaseUnit = "kg"
+	f.items.items[componentID].AverageCost = 4
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 250)
+	f.bomRepo.boms[bomID].Components[0].UnitOfMeasure = "g"
+	f.stocks.quantities[componentID] = 10
+
+	// 250 g per unit are 2 kg for 8 units
+	_, cost, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 8, bom.ProductionOptions{})
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+	if got := f.stocks.quantities[componentID]; got != 8 {
+		t.Errorf("component stock = %v kg, want 8", got)
+	}
+	if cost != 8 {
+		t.Errorf("actual production cost = %v, want 8", cost)
+	}
+}
+
+func TestBomUsecase_CreateBOM_RejectsIncompatibleUnit(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	f.items.items[componentID].BaseUnit = "kg"
+	productID := f.addItem(item.TrackingNone)
+
+	err := f.usecase.CreateBOM(context.Background(), &bom.BillOfMaterials{
+		ID:         uuid.New(),
+		ProductID:  productID,
+		IsActive:   true,
+		Components: []bom.BillOfMaterialsComponent{{ComponentItemID: componentID, Quantity: 1, UnitOfMeasure: "l"}},
+	})
+	if !errors.Is(err, uom.ErrIncompatibleUnits) {
+		t.Errorf("CreateBOM() error = %v, want %v", err, uom.ErrIncompatibleUnits)
+	}
+}
+
+func TestBomUsecase_CalculatePredictiveCost_AppliesScrapAndYield(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.bomRepo.boms[bomID].YieldPercent = 80
+	f.bomRepo.boms[bomID].Components[0].ScrapPercent = 50
+
+	cost, err := f.usecase.CalculatePredictiveCost(context.Background(), bomID)
+	if err != nil || cost != 7.5 {
+		t.Errorf("CalculatePredictiveCost() = %v, %v, want 7.5", cost, err)
+	}
+}
+
+func TestBomUsecase_ExplodeBOM_RollsUpSubAssemblyCost(t *testing.T) {
+	f := newProductionFixture()
+	rawID := f.addItem(item.TrackingNone)
+	subID := f.addItem(item.TrackingNone)
+	finishedID := f.addItem(item.TrackingNone)
+	f.items.items[subID].CostPrice = 100 // Stale price, ignored in favour of the sub-assembly BOM
+	f.addBOM(subID, rawID, 3)
+	finishedBOM := f.addBOM(finishedID, subID, 2)
+
+	root, err := f.usecase.ExplodeBOM(context.Background(), finishedBOM, 5)
+	if err != nil {
+		t.Fatalf("ExplodeBOM() error = %v", err)
+	}
+	if root.UnitCost != 12 || root.TotalCost != 60 {
+		t.Errorf("expected unit cost 12 and total cost 60, got %v and %v", root.UnitCost, root.TotalCost)
+	}
+	if len(root.Components) != 1 || root.Components[0].BOMID == nil {
+		t.Fatalf("expected one sub-assembly, got %+v", root.Components)
+	}
+	sub := root.Components[0]
+	if sub.Level != 1 || sub.Quantity != 10 || sub.UnitCost != 6 {
+		t.Errorf("unexpected sub-assembly node %+v", sub)
+	}
+	if len(sub.Components) != 1 || sub.Components[0].Level != 2 || sub.Components[0].Quantity != 30 {
+		t.Errorf("unexpected raw material nodes %+v", sub.Components)
+	}
+
+	cost, err := f.usecase.CalculatePredictiveCost(context.Background(), finishedBOM)
+	if err != nil || cost != 12 {
+		t.Errorf("CalculatePredictiveCost() = %v, %v, want 12", cost, err)
+	}
+}
+
+func TestBomUsecase_CreateBOM_RejectsCycle(t *testing.T) {
+	f := newProductionFixture()
+	rawID := f.addItem(item.TrackingNone)
+	subID := f.addItem(item.TrackingNone)
+	finishedID := f.addItem(item.TrackingNone)
+	f.addBOM(subID, rawID, 1)
+	f.addBOM(finishedID, subID, 1)
+
+	err := f.usecase.CreateBOM(context.Background(), &bom.BillOfMaterials{
+		ID:         uuid.New(),
+		ProductID:  rawID,
+		IsActive:   true,
+		Components: []bom.BillOfMaterialsComponent{{ComponentItemID: finishedID, Quantity: 1}},
+	})
+	if !errors.Is(err, bom.ErrBOMCycle) {
+		t.Errorf("CreateBOM() error = %v, want %v", err, bom.ErrBOMCycle)
+	}
+}
+
+func TestBomUsecase_WhereUsed_MultiLevel(t *testing.T) {
+	f := newProductionFixture()
+	rawID := f.addItem(item.TrackingNone)
+	subID := f.addItem(item.TrackingNone)
+	finishedID := f.addItem(item.TrackingNone)
+	subBOM := f.addBOM(subID, rawID, 3)
+	finishedBOM := f.addBOM(finishedID, subID, 2)
+
+	direct, err := f.usecase.WhereUsed(context.Background(), rawID, false)
+	if err != nil {
+		t.Fatalf("WhereUsed() error = %v", err)
+	}
+	if len(direct) != 1 || direct[0].BOMID != subBOM || direct[0].Quantity != 3 {
+		t.Errorf("unexpected direct usage %+v", direct)
+	}
+
+	all, err := f.usecase.WhereUsed(context.Background(), rawID, true)
+	if err != nil {
+		t.Fatalf("WhereUsed() error = %v", err)
+	}
+	if len(all) != 2 || all[1].BOMID != finishedBOM || all[1].Level != 2 || all[1].Quantity != 6 {
+		t.Errorf("unexpected multi-level usage %+v", all)
+	}
+}
+
+func TestBomUsecase_ApproveRevision_SupersedesRevisionInForce(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	firstID := f.addBOM(productID, componentID, 2)
+	f.stocks.quantities[componentID] = 100
+
+	draft, err := f.usecase.CreateRevision(context.Background(), firstID)
+	if err != nil {
+		t.Fatalf("CreateRevision() error = %v", err)
+	}
+	if draft.Revision != 2 || draft.Status != bom.RevisionDraft {
+		t.Fatalf("draft = revision %d %s, want revision 2 DRAFT", draft.Revision, draft.Status)
+	}
+	edited := *draft
+	edited.Components = []bom.BillOfMaterialsComponent{{ID: uuid.New(), ComponentItemID: componentID, Quantity: 3}}
+	if err := f.usecase.UpdateBOM(context.Background(), &edited); err != nil {
+		t.Fatalf("UpdateBOM() error = %v", err)
+	}
+
+	from := time.Now().Add(-time.Minute)
+	if _, err := f.usecase.ApproveRevision(context.Background(), draft.ID, from, nil); err != nil {
+		t.Fatalf("ApproveRevision() error = %v", err)
+	}
+	first, _ := f.bomRepo.GetByID(context.Background(), firstID)
+	if first.Status != bom.RevisionApproved || first.EffectiveTo == nil || !first.EffectiveTo.Equal(from) {
+		t.Errorf("revision 1 = %s until %v, want APPROVED until %v", first.Status, first.EffectiveTo, from)
+	}
+
+	// Production requested on the old revision runs on the one in force
+	if _, _, err := f.usecase.ProduceItem(context.Background(), firstID, f.warehouse, f.userID, 2, bom.ProductionOptions{}); err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+	if got := f.stocks.quantities[componentID]; got != 94 {
+		t.Errorf("component quantity = %v, want 94", got)
+	}
+	if got := f.records.records[0].BillOfMaterialsID; got != draft.ID {
+		t.Errorf("production record revision = %s, want %s", got, draft.ID)
+	}
+}
+
+func TestBomUsecase_UpdateBOM_RejectsApprovedRevision(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 1)
+
+	err := f.usecase.UpdateBOM(context.Background(), &bom.BillOfMaterials{
+		ID:         bomID,
+		ProductID:  productID,
+		Components: []bom.BillOfMaterialsComponent{{ComponentItemID: componentID, Quantity: 5}},
+	})
+	if !errors.Is(err, bom.ErrRevisionNotDraft) {
+		t.Errorf("UpdateBOM() error = %v, want %v", err, bom.ErrRevisionNotDraft)
+	}
+	if err := f.usecase.DeleteBOM(context.Background(), bomID); !errors.Is(err, bom.ErrRevisionNotDraft) {
+		t.Errorf("DeleteBOM() error = %v, want %v", err, bom.ErrRevisionNotDraft)
+	}
+}
+
+func TestBomUsecase_ProduceItem_NoRevisionInForce(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 1)
+	if _, err := f.usecase.ObsoleteRevision(context.Background(), bomID); err != nil {
+		t.Fatalf("ObsoleteRevision() error = %v", err)
+	}
+
+	_, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 1, bom.ProductionOptions{})
+	if !errors.Is(err, bom.ErrNoRevisionInForce) {
+		t.Errorf("ProduceItem() error = %v, want %v", err, bom.ErrNoRevisionInForce)
+	}
+}
+
+func TestBomUsecase_DiffRevisions(t *testing.T) {
+	f := newProductionFixture()
+	keptID := f.addItem(item.TrackingNone)
+	removedID := f.addItem(item.TrackingNone)
+	addedID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	firstID := f.addBOM(productID, keptID, 1)
+	first, _ := f.bomRepo.GetByID(context.Background(), firstID)
+	first.Components = append(first.Components, bom.BillOfMaterialsComponent{ComponentItemID: removedID, Quantity: 4})
+
+	draft, err := f.usecase.CreateRevision(context.Background(), firstID)
+	if err != nil {
+		t.Fatalf("CreateRevision() error = %v", err)
+	}
+	draft.Components = []bom.BillOfMaterialsComponent{
+		{ComponentItemID: keptID, Quantity: 1},
+		{ComponentItemID: keptID, Quantity: 1},
+		{ComponentItemID: addedID, Quantity: 2},
+	}
+
+	diff, err := f.usecase.DiffRevisions(context.Background(), firstID, draft.ID)
+	if err != nil {
+		t.Fatalf("DiffRevisions() error = %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].ComponentItemID != addedID {
+		t.Errorf("added = %+v, want %s", diff.Added, addedID)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ComponentItemID != removedID {
+		t.Errorf("removed = %+v, want %s", diff.Removed, removedID)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].FromQuantity != 1 || diff.Changed[0].ToQuantity != 2 {
+		t.Errorf("changed = %+v, want %s from 1 to 2", diff.Changed, keptID)
+	}
+
+	otherID := f.addBOM(f.addItem(item.TrackingNone), keptID, 1)
+	if _, err := f.usecase.DiffRevisions(context.Background(), firstID, otherID); !errors.Is(err, bom.ErrRevisionProductMismatch) {
+		t.Errorf("DiffRevisions() error = %v, want %v", err, bom.ErrRevisionProductMismatch)
+	}
+}
+
+func TestBomUsecase_ProduceItem_CostsRoutingLaborAndOverhead(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.items.items[componentID].AverageCost = 2
+	f.stocks.quantities[componentID] = 10
+	wc := &bom.WorkCenter{ID: uuid.New(), Code: "ASM", LaborRate: 30, OverheadRate: 12, IsActive: true}
+	f.centers.Create(context.Background(), wc)
+	f.bomRepo.boms[bomID].Operations = []bom.RoutingOperation{
+		{ID: uuid.New(), Sequence: 10, Name: "Assembly", WorkCenterID: wc.ID, SetupMinutes: 30, RunMinutes: 6},
+	}
+
+	_, cost, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 5, bom.ProductionOptions{})
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	// 30 minutes of setup and 5 x 6 minutes of run: one hour at 30 + 12, on top of 10 components at 2.
+	record := f.records.records[0]
+	if record.LaborCost != 30 || record.OverheadCost != 12 {
+		t.Errorf("labor and overhead = %v and %v, want 30 and 12", record.LaborCost, record.OverheadCost)
+	}
+	if cost != 62 || record.ActualProductionCost != 62 {
+		t.Errorf("actual production cost = %v, recorded %v, want 62", cost, record.ActualProductionCost)
+	}
+}
+
+func TestBomUsecase_CreateBOM_RejectsInactiveWorkCenter(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	wc := &bom.WorkCenter{ID: uuid.New(), Code: "OLD", IsActive: false}
+	f.centers.Create(context.Background(), wc)
+
+	err := f.usecase.CreateBOM(context.Background(), &bom.BillOfMaterials{
+		ID:         uuid.New(),
+		ProductID:  productID,
+		IsActive:   true,
+		Components: []bom.BillOfMaterialsComponent{{ComponentItemID: componentID, Quantity: 1}},
+		Operations: []bom.RoutingOperation{{Sequence: 10, WorkCenterID: wc.ID, RunMinutes: 1}},
+	})
+	if !errors.Is(err, bom.ErrInactiveWorkCenter) {
+		t.Errorf("CreateBOM() error = %v, want %v", err, bom.ErrInactiveWorkCenter)
+	}
+}
+
+func TestBomUsecase_ReverseProduction_RestoresStockAtRecordedCost(t *testing.T) {
+	f := newProductionFixture()
+	ctx := context.Background()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.items.items[componentID].CostingMethod = item.CostingFIFO
+	f.stocks.quantities[componentID] = 10
+	f.layers.layers = []*stock.CostLayer{
+		{ID: uuid.New(), ItemID: componentID, UnitCost: 10, OriginalQuantity: 4, RemainingQuantity: 4},
+		{ID: uuid.New(), ItemID: componentID, UnitCost: 13, OriginalQuantity: 6, RemainingQuantity: 6},
+	}
+	recordID, _, err := f.usecase.ProduceItem(ctx, bomID, f.warehouse, f.userID, 3, bom.ProductionOptions{})
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	record, err := f.usecase.ReverseProduction(ctx, recordID, "wrong product")
+	if err != nil {
+		t.Fatalf("ReverseProduction() error = %v", err)
+	}
+	if record.ReversedAt == nil || record.ReversalReason != "wrong product" {
+		t.Errorf("record not marked as reversed: %+v", record)
+	}
+	if f.stocks.quantities[componentID] != 10 || f.stocks.quantities[productID] != 0 {
+		t.Errorf("stock after reversal = %v components and %v products, want 10 and 0",
+			f.stocks.quantities[componentID], f.stocks.quantities[productID])
+	}
+	// The components come back at the 11 they were issued at, into the layers they came from.
+	returned := f.movements.movements[len(f.movements.movements)-1]
+	if returned.ItemID != componentID || returned.Type != stock.MovementTypeIn || returned.UnitCost != 11 {
+		t.Errorf("component reversal = %s of %s at %v, want IN at 11", returned.Type, returned.ItemID, returned.UnitCost)
+	}
+	if f.layers.layers[0].RemainingQuantity != 4 || f.layers.layers[1].RemainingQuantity != 6 {
+		t.Errorf("component layers = %v and %v, want 4 and 6", f.layers.layers[0].RemainingQuantity, f.layers.layers[1].RemainingQuantity)
+	}
+
+	if _, err := f.usecase.ReverseProduction(ctx, recordID, "again"); !errors.Is(err, bom.ErrProductionReversed) {
+		t.Errorf("second ReverseProduction() error = %v, want %v", err, bom.ErrProductionReversed)
+	}
+}
+
+func TestBomUsecase_DisassembleProduction_ReturnsComponentsUpToProducedQuantity(t *testing.T) {
+	f := newProductionFixture()
+	ctx := context.Background()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.items.items[componentID].AverageCost = 4
+	f.stocks.quantities[componentID] = 10
+	recordID, _, err := f.usecase.ProduceItem(ctx, bomID, f.warehouse, f.userID, 3, bom.ProductionOptions{})
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	record, err := f.usecase.DisassembleProduction(ctx, recordID, 2, bom.DisassemblyOptions{})
+	if err != nil {
+		t.Fatalf("DisassembleProduction() error = %v", err)
+	}
+	if record.DisassembledQuantity != 2 {
+		t.Errorf("disassembled quantity = %v, want 2", record.DisassembledQuantity)
+	}
+	if f.stocks.quantities[componentID] != 8 || f.stocks.quantities[productID] != 1 {
+		t.Errorf("stock after disassembly = %v components and %v products, want 8 and 1",
+			f.stocks.quantities[componentID], f.stocks.quantities[productID])
+	}
+	returned := f.movements.movements[len(f.movements.movements)-1]
+	if returned.ItemID != componentID || returned.Quantity != 4 || returned.UnitCost != 4 {
+		t.Errorf("component return = %v of %s at %v, want 4 at 4", returned.Quantity, returned.ItemID, returned.UnitCost)
+	}
+
+	if _, err := f.usecase.DisassembleProduction(ctx, recordID, 2, bom.DisassemblyOptions{}); !errors.Is(err, bom.ErrOverDisassembly) {
+		t.Errorf("DisassembleProduction() error = %v, want %v", err, bom.ErrOverDisassembly)
+	}
+	if _, err := f.usecase.ReverseProduction(ctx, recordID, "too late"); !errors.Is(err, bom.ErrProductionDisassembled) {
+		t.Errorf("ReverseProduction() error = %v, want %v", err, bom.ErrProductionDisassembled)
+	}
+}
+
+func TestBomUsecase_ImportBOMs_DryRunReportsRows(t *testing.T) {
+	f := newProductionFixture()
+	rawID := f.addItem(item.TrackingNone)
+	subID := f.addItem(item.TrackingNone)
+	finishedID := f.addItem(item.TrackingNone)
+	f.addBOM(subID, rawID, 1)
+	unknownID := uuid.New()
+
+	// The imported BOM of rawID closes a cycle through the sub-assembly imported for finishedID
+	rows := []ImportRow{
+		{Line: 1, ProductID: finishedID, Name: "Finished", ComponentItemID: subID, Quantity: 2},
+		{Line: 2, ProductID: finishedID, Name: "Finished", ComponentItemID: unknownID, Quantity: 1},
+		{Line: 3, ProductID: rawID, Name: "Raw", ComponentItemID: finishedID, Quantity: 1},
+	}
+	report, err := f.usecase.ImportBOMs(context.Background(), rows, true)
+	if err != nil {
+		t.Fatalf("ImportBOMs() error = %v", err)
+	}
+	if report.BOMs != 2 || report.Created != 0 || len(f.bomRepo.boms) != 1 {
+		t.Fatalf("expected 2 BOMs read and none created, got %+v", report)
+	}
+	for _, row := range report.Rows {
+		if row.Status != ImportRowRejected {
+			t.Errorf("line %d: status = %s, want %s", row.Line, row.Status, ImportRowRejected)
+		}
+	}
+	if !strings.Contains(strings.Join(report.Rows[1].Errors, ";"), "not found") {
+		t.Errorf("line 2: errors = %v, want the unknown item", report.Rows[1].Errors)
+	}
+	if !strings.Contains(strings.Join(report.Rows[2].Errors, ";"), bom.ErrBOMCycle.Error()) {
+		t.Errorf("line 3: errors = %v, want a cycle", report.Rows[2].Errors)
+	}
+}
+
+func TestBomUsecase_ImportBOMs_CreatesDraftRevisions(t *testing.T) {
+	f := newProductionFixture()
+	rawID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	f.addBOM(productID, rawID, 1)
+
+	rows := []ImportRow{
+		{Line: 1, ProductID: productID, Name: "Imported", YieldPercent: 90, ComponentItemID: rawID, Quantity: 3, ScrapPercent: 5},
+	}
+	report, err := f.usecase.ImportBOMs(context.Background(), rows, true)
+	if err != nil || report.Rows[0].Status != ImportRowValid || len(f.bomRepo.boms) != 1 {
+		t.Fatalf("dry run: report = %+v, error = %v", report, err)
+	}
+
+	report, err = f.usecase.ImportBOMs(context.Background(), rows, false)
+	if err != nil {
+		t.Fatalf("ImportBOMs() error = %v", err)
+	}
+	row := report.Rows[0]
+	if report.Created != 1 || row.Status != ImportRowCreated || row.BOMID == nil || row.Revision != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	created := f.bomRepo.boms[*row.BOMID]
+	if created.Status != bom.RevisionDraft || created.YieldPercent != 90 || len(created.Components) != 1 {
+		t.Errorf("unexpected imported BOM %+v", created)
+	}
+
+	exported, err := f.usecase.ExportBOMs(context.Background(), ExportFilter{ProductID: &productID, Status: bom.RevisionDraft})
+	if err != nil || len(exported) != 1 || exported[0].ID != *row.BOMID {
+		t.Errorf("ExportBOMs() = %v, %v, want the imported draft", exported, err)
+	}
+}
+
+func TestBomUsecase_ProduceItem_SubstitutesShortComponentByPriority(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	firstID := f.addItem(item.TrackingNone)
+	secondID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.bomRepo.boms[bomID].Components[0].Alternates = []bom.AlternateComponent{
+		{ID: uuid.New(), ItemID: secondID, Priority: 2, ConversionRatio: 1},
+		{ID: uuid.New(), ItemID: firstID, Priority: 1, ConversionRatio: 2},
+	}
+	f.stocks.quantities[componentID] = 4
+	f.stocks.quantities[firstID] = 4
+	f.stocks.quantities[secondID] = 10
+
+	recordID, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 5, bom.ProductionOptions{})
+	if err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	// 10 needed: 4 of the component, 2 covered by 4 of the first alternate, 4 by the second
+	if got := f.stocks.quantities[componentID]; got != 0 {
+		t.Errorf("component quantity = %v, want 0", got)
+	}
+	if got := f.stocks.quantities[firstID]; got != 0 {
+		t.Errorf("first alternate quantity = %v, want 0", got)
+	}
+	if got := f.stocks.quantities[secondID]; got != 6 {
+		t.Errorf("second alternate quantity = %v, want 6", got)
+	}
+	record := f.records.records[0]
+	if record.ID != recordID || len(record.Substitutions) != 2 {
+		t.Fatalf("substitutions = %+v, want 2", record.Substitutions)
+	}
+	if sub := record.Substitutions[0]; sub.SubstituteItemID != firstID || sub.Quantity != 2 || sub.SubstituteQuantity != 4 || sub.Chosen {
+		t.Errorf("first substitution = %+v", sub)
+	}
+}
+
+func TestBomUsecase_ProduceItem_ChosenSubstituteReplacesComponent(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	alternateID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.bomRepo.boms[bomID].Components[0].Alternates = []bom.AlternateComponent{
+		{ID: uuid.New(), ItemID: alternateID, Priority: 1, ConversionRatio: 1},
+	}
+	f.stocks.quantities[componentID] = 10
+	f.stocks.quantities[alternateID] = 10
+
+	opts := bom.ProductionOptions{Substitutes: map[uuid.UUID]uuid.UUID{componentID: alternateID}}
+	if _, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 3, opts); err != nil {
+		t.Fatalf("ProduceItem() error = %v", err)
+	}
+
+	if got := f.stocks.quantities[componentID]; got != 10 {
+		t.Errorf("component quantity = %v, want 10", got)
+	}
+	if got := f.stocks.quantities[alternateID]; got != 4 {
+		t.Errorf("alternate quantity = %v, want 4", got)
+	}
+	if subs := f.records.records[0].Substitutions; len(subs) != 1 || !subs[0].Chosen || subs[0].Share != 1 {
+		t.Errorf("substitutions = %+v, want one chosen for the whole line", subs)
+	}
+
+	unknown := bom.ProductionOptions{Substitutes: map[uuid.UUID]uuid.UUID{componentID: productID}}
+	_, _, err := f.usecase.ProduceItem(context.Background(), bomID, f.warehouse, f.userID, 1, unknown)
+	if !errors.Is(err, bom.ErrInvalidSubstitution) {
+		t.Errorf("ProduceItem() error = %v, want %v", err, bom.ErrInvalidSubstitution)
+	}
+}
+
+func TestBomUsecase_CreateBOM_RejectsInvalidAlternate(t *testing.T) {
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	productID := f.addItem(item.TrackingNone)
+	alternateID := f.addItem(item.TrackingNone)
+
+	b := &bom.BillOfMaterials{
+		ProductID: productID,
+		Name:      "BOM",
+		Components: []bom.BillOfMaterialsComponent{{
+			ComponentItemID: componentID,
+			Quantity:        1,
+			Alternates: []bom.AlternateComponent{
+				{ItemID: alternateID, Priority: 1, ConversionRatio: 1},
+				{ItemID: productID, Priority: 2, ConversionRatio: 1},
+			},
+		}},
+	}
+	if err := f.usecase.CreateBOM(context.Background(), b); !errors.Is(err, bom.ErrInvalidAlternate) {
+		t.Fatalf("CreateBOM() error = %v, want %v", err, bom.ErrInvalidAlternate)
+	}
+}
@@ -0,0 +1,684 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/usecase"
+	stock_uc "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderReservationSource is the source type of the reservations held by manufacturing orders.
+const OrderReservationSource = "MANUFACTURING_ORDER"
+
+// quantityEpsilon absorbs the rounding of quantities computed from BOM ratios.
+const quantityEpsilon = 1e-9
+
+// ManufacturingOrderUsecase defines the interface for manufacturing orders.
+type ManufacturingOrderUsecase interface {
+	CreateOrder(ctx context.Context, order *domainBom.ManufacturingOrder) error
+	GetOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error)
+	ListOrders(ctx context.Context, filter domainBom.OrderFilter) ([]*domainBom.ManufacturingOrder, error)
+	ReleaseOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error)
+	IssueComponents(ctx context.Context, id uuid.UUID, issues []domainBom.ComponentIssue) (*domainBom.ManufacturingOrder, error)
+	ReceiveProducts(ctx context.Context, id uuid.UUID, quantity float64, lots []stock.LotQuantity) (*domainBom.ManufacturingOrder, error)
+	CloseOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error)
+	CancelOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error)
+}
+
+type manufacturingOrderUsecase struct {
+	txManager       db.Transactioner
+	orderRepo       domainBom.ManufacturingOrderRepository
+	bomRepo         domainBom.Repository
+	productionRepo  domainBom.ProductionRecordRepository
+	stockRepo       stock.StockRepository
+	stockMoveRepo   stock.StockMovementRepository
+	stockLedgerRepo stock.StockLedgerRepository
+	lotRepo         stock.StockLotRepository
+	costLayerRepo   stock.CostLayerRepository
+	reservationRepo stock.ReservationRepository
+	itemRepo        item.Repository
+	unitRepo        uom.Repository
+	workCenterRepo  domainBom.WorkCenterRepository
+	auditService    usecase.AuditService
+}
+
+func NewManufacturingOrderUsecase(
+	txManager db.Transactioner,
+	orderRepo domainBom.ManufacturingOrderRepository,
+	bomRepo domainBom.Repository,
+	productionRepo domainBom.ProductionRecordRepository,
+	stockRepo stock.StockRepository,
+	stockMoveRepo stock.StockMovementRepository,
+	stockLedgerRepo stock.StockLedgerRepository,
+	lotRepo stock.StockLotRepository,
+	costLayerRepo stock.CostLayerRepository,
+	reservationRepo stock.ReservationRepository,
+	itemRepo item.Repository,
+	unitRepo uom.Repository,
+	workCenterRepo domainBom.WorkCenterRepository,
+	auditService usecase.AuditService,
+) ManufacturingOrderUsecase {
+	return &manufacturingOrderUsecase{
+		txManager:       txManager,
+		orderRepo:       orderRepo,
+		bomRepo:         bomRepo,
+		productionRepo:  productionRepo,
+		stockRepo:       stockRepo,
+		stockMoveRepo:   stockMoveRepo,
+		stockLedgerRepo: stockLedgerRepo,
+		lotRepo:         lotRepo,
+		costLayerRepo:   costLayerRepo,
+		reservationRepo: reservationRepo,
+		itemRepo:        itemRepo,
+		unitRepo:        unitRepo,
+		workCenterRepo:  workCenterRepo,
+		auditService:    auditService,
+	}
+}
+
+// CreateOrder plans an order for the ProductID, WarehouseID, Quantity and planned dates of order.
+// The order produces with the BOM revision of the product in force now; its components are
+// the requirements of that revision for the planned quantity, scrap and yield included, in the
+// base unit of each component item.
+func (u *manufacturingOrderUsecase) CreateOrder(ctx context.Context, order *domainBom.ManufacturingOrder) error {
+	if order.Quantity <= 0 {
+		return stock_uc.ErrInvalidQuantity
+	}
+	if order.PlannedStart != nil && order.PlannedEnd != nil && order.PlannedEnd.Before(*order.PlannedStart) {
+		return domainBom.ErrInvalidPlannedDates
+	}
+	revision, err := u.bomRepo.GetEffective(ctx, order.ProductID, time.Now())
+	if err != nil {
+		if errors.Is(err, domainBom.ErrBOMNotFound) {
+			return fmt.Errorf("%w: %s", domainBom.ErrNoRevisionInForce, order.ProductID)
+		}
+		return err
+	}
+	base, err := inBaseUnits(ctx, u.itemRepo, u.unitRepo, revision)
+	if err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	order.ID = uuid.New()
+	order.BillOfMaterialsID = revision.ID
+	order.Status = domainBom.OrderPlanned
+	order.ProducedQuantity, order.ScrapQuantity, order.ActualCost, order.ReceivedCost = 0, 0, 0, 0
+	order.Components = nil
+	comps, itemIDs := componentsByItem(base)
+	// Components are kept by item ID, the order in which their stock rows are locked
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i].String() < itemIDs[j].String() })
+	for _, itemID := range itemIDs {
+		comp := comps[itemID]
+		order.Components = append(order.Components, &domainBom.OrderComponent{
+			ID:               uuid.New(),
+			OrderID:          order.ID,
+			ItemID:           itemID,
+			QuantityPer:      base.PlannedQuantity(comp),
+			RequiredQuantity: base.PlannedQuantity(comp) * order.Quantity,
+			UnitOfMeasure:    comp.UnitOfMeasure,
+		})
+	}
+	order.SetCreatedBy(userID)
+	order.SetUpdatedBy(userID)
+	if err := u.orderRepo.Create(ctx, order); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "manufacturing_order", order.ID.String(), "CREATE", nil,
+		map[string]interface{}{"product_id": order.ProductID, "bom_id": order.BillOfMaterialsID, "quantity": order.Quantity},
+		corrID)
+	return nil
+}
+
+func (u *manufacturingOrderUsecase) GetOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error) {
+	return u.orderRepo.GetByID(ctx, id)
+}
+
+func (u *manufacturingOrderUsecase) ListOrders(ctx context.Context, filter domainBom.OrderFilter) ([]*domainBom.ManufacturingOrder, error) {
+	return u.orderRepo.List(ctx, filter)
+}
+
+// ReleaseOrder reserves the required quantity of every storable component in the warehouse of
+// a planned order. It fails with ErrInsufficientStock if a component is not available in full.
+// The reservations are held for DefaultReservationTTL after the planned end, or after now without one.
+func (u *manufacturingOrderUsecase) ReleaseOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var order *domainBom.ManufacturingOrder
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txOrderRepo := u.orderRepo.WithTx(tx)
+		txStockRepo := u.stockRepo.WithTx(tx)
+		txReservationRepo := u.reservationRepo.WithTx(tx)
+		txItemRepo := u.itemRepo.WithTx(tx)
+
+		var err error
+		order, err = txOrderRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != domainBom.OrderPlanned {
+			return domainBom.ErrInvalidOrderStatus
+		}
+
+		now := time.Now()
+		expiry := now
+		if order.PlannedEnd != nil && order.PlannedEnd.After(now) {
+			expiry = *order.PlannedEnd
+		}
+		expiry = expiry.Add(stock_uc.DefaultReservationTTL)
+		for _, comp := range order.Components {
+			it, err := txItemRepo.GetByID(ctx, comp.ItemID)
+			if err != nil {
+				return fmt.Errorf("failed to fetch component %s: %w", comp.ItemID, err)
+			}
+			if it.Type != item.Storable {
+				continue
+			}
+
+			// Lock the location, then check the reservations held against it
+			onHand, err := stock_uc.LockedQuantity(ctx, txStockRepo, comp.ItemID, order.WarehouseID, nil)
+			if err != nil {
+				return err
+			}
+			reserved, err := txReservationRepo.ReservedQuantity(ctx, comp.ItemID, order.WarehouseID, nil, now)
+			if err != nil {
+				return err
+			}
+			if available := onHand - reserved; available+quantityEpsilon < comp.RequiredQuantity {
+				return fmt.Errorf("%w: component %s has %f available (%f reserved), need %f",
+					stock_uc.ErrInsufficientStock, comp.ItemID, available, reserved, comp.RequiredQuantity)
+			}
+
+			reservation := &stock.Reservation{
+				ID:          uuid.New(),
+				ItemID:      comp.ItemID,
+				WarehouseID: order.WarehouseID,
+				SourceType:  OrderReservationSource,
+				SourceID:    order.ID.String(),
+				Quantity:    comp.RequiredQuantity,
+				Status:      stock.ReservationActive,
+				ExpiresAt:   expiry,
+			}
+			reservation.SetCreatedBy(userID)
+			reservation.SetUpdatedBy(userID)
+			if err := txReservationRepo.Create(ctx, reservation); err != nil {
+				return err
+			}
+			comp.ReservationID = &reservation.ID
+			if err := txOrderRepo.UpdateComponent(ctx, comp); err != nil {
+				return err
+			}
+		}
+
+		order.Status = domainBom.OrderReleased
+		order.ReleasedAt = &now
+		order.SetUpdatedBy(userID)
+		return txOrderRepo.Update(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "manufacturing_order", order.ID.String(), "RELEASE",
+		map[string]interface{}{"status": domainBom.OrderPlanned},
+		map[string]interface{}{"status": order.Status},
+		corrID)
+	return order, nil
+}
+
+// IssueComponents takes components out of stock for a released or in-progress order, up to
+// their required quantity. Storable components draw their reservation down first; service
+// components are charged at their cost without a stock movement. Components are issued at the
+// cost of their costing method, which is added to the actual cost of the order.
+func (u *manufacturingOrderUsecase) IssueComponents(ctx context.Context, id uuid.UUID, issues []domainBom.ComponentIssue) (*domainBom.ManufacturingOrder, error) {
+	if len(issues) == 0 {
+		return nil, stock_uc.ErrInvalidQuantity
+	}
+	issues = append([]domainBom.ComponentIssue(nil), issues...)
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].ItemID.String() < issues[j].ItemID.String() })
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	var order *domainBom.ManufacturingOrder
+	var issuedCost float64
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txOrderRepo := u.orderRepo.WithTx(tx)
+		txStockRepo := u.stockRepo.WithTx(tx)
+		txReservationRepo := u.reservationRepo.WithTx(tx)
+		txItemRepo := u.itemRepo.WithTx(tx)
+		repos := stock_uc.PostingRepositories{
+			Stock:     txStockRepo,
+			Movements: u.stockMoveRepo.WithTx(tx),
+			Ledger:    u.stockLedgerRepo.WithTx(tx),
+			Lots:      u.lotRepo.WithTx(tx),
+		}
+		costRepos := stock_uc.CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: u.costLayerRepo.WithTx(tx)}
+
+		var err error
+		order, err = txOrderRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != domainBom.OrderReleased && order.Status != domainBom.OrderInProgress {
+			return domainBom.ErrInvalidOrderStatus
+		}
+
+		now := time.Now()
+		for _, issue := range issues {
+			comp := order.FindComponent(issue.ItemID)
+			if comp == nil {
+				return fmt.Errorf("%w: %s", domainBom.ErrNotOrderComponent, issue.ItemID)
+			}
+			if issue.Quantity <= 0 {
+				return stock_uc.ErrInvalidQuantity
+			}
+			if issue.Quantity > comp.RemainingQuantity()+quantityEpsilon {
+				return fmt.Errorf("%w: component %s has %f to issue, requested %f", domainBom.ErrOverIssue, comp.ItemID, comp.RemainingQuantity(), issue.Quantity)
+			}
+			it, err := txItemRepo.GetByID(ctx, comp.ItemID)
+			if err != nil {
+				return fmt.Errorf("failed to fetch component %s: %w", comp.ItemID, err)
+			}
+
+			unitCost := stock_uc.CurrentUnitCost(it)
+			if it.Type == item.Storable {
+				if unitCost, err = u.issueStock(ctx, txReservationRepo, repos, costRepos, order, comp, it, issue, now, userID); err != nil {
+					return fmt.Errorf("component %s: %w", comp.ItemID, err)
+				}
+				for _, l := range issue.Lots {
+					lot := &domainBom.OrderLot{ID: uuid.New(), OrderID: order.ID, ItemID: comp.ItemID, LotNumber: l.LotNumber, Quantity: l.Quantity, Role: domainBom.LotConsumed}
+					if err := txOrderRepo.AddLot(ctx, lot); err != nil {
+						return err
+					}
+					order.Lots = append(order.Lots, *lot)
+				}
+			}
+
+			comp.IssuedQuantity += issue.Quantity
+			comp.IssuedCost += unitCost * issue.Quantity
+			if err := txOrderRepo.UpdateComponent(ctx, comp); err != nil {
+				return err
+			}
+			issuedCost += unitCost * issue.Quantity
+		}
+
+		order.ActualCost += issuedCost
+		markStarted(order, now)
+		order.SetUpdatedBy(userID)
+		return txOrderRepo.Update(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "manufacturing_order", order.ID.String(), "ISSUE", nil,
+		map[string]interface{}{"issues": issues, "issued_cost": issuedCost, "actual_cost": order.ActualCost},
+		corrID)
+	return order, nil
+}
+
+// issueStock posts the OUT movement of a storable component and draws the reservation of the
+// component down by the quantity issued. It returns the unit cost of the movement.
+func (u *manufacturingOrderUsecase) issueStock(
+	ctx context.Context,
+	reservationRepo stock.ReservationRepository,
+	repos stock_uc.PostingRepositories,
+	costRepos stock_uc.CostingRepositories,
+	order *domainBom.ManufacturingOrder,
+	comp *domainBom.OrderComponent,
+	it *item.Item,
+	issue domainBom.ComponentIssue,
+	now time.Time,
+	userID uuid.UUID,
+) (float64, error) {
+	// Lock the location before the reservation, as reservation consumption does
+	quantityBefore, err := stock_uc.LockedQuantity(ctx, repos.Stock, comp.ItemID, order.WarehouseID, nil)
+	if err != nil {
+		return 0, err
+	}
+	var reservation *stock.Reservation
+	var open float64
+	if comp.ReservationID != nil {
+		reservation, err = reservationRepo.GetByIDForUpdate(ctx, *comp.ReservationID)
+		if err != nil {
+			return 0, err
+		}
+		if reservation.IsHeld(now) {
+			open = reservation.OpenQuantity()
+		}
+	}
+	reserved, err := reservationRepo.ReservedQuantity(ctx, comp.ItemID, order.WarehouseID, nil, now)
+	if err != nil {
+		return 0, err
+	}
+
+	movementID := uuid.New()
+	valuation, err := stock_uc.CostIssue(ctx, costRepos, it, movementID, issue.Quantity)
+	if err != nil {
+		return 0, err
+	}
+	if _, _, err := stock_uc.PostMovement(ctx, repos, stock_uc.Posting{
+		MovementID:     movementID,
+		ItemID:         comp.ItemID,
+		WarehouseID:    order.WarehouseID,
+		Type:           stock.MovementTypeOut,
+		Quantity:       issue.Quantity,
+		QuantityBefore: quantityBefore,
+		Reserved:       reserved - open,
+		Reason:         fmt.Sprintf("Issue to manufacturing order %s", order.ID),
+		Tracking:       it.TrackingMode,
+		Lots:           issue.Lots,
+		UnitCost:       valuation.UnitCost,
+		HappenedAt:     now,
+		UserID:         userID,
+	}); err != nil {
+		return 0, err
+	}
+
+	if open > 0 {
+		reservation.ConsumedQuantity += min(issue.Quantity, open)
+		if reservation.OpenQuantity() < quantityEpsilon {
+			reservation.Status = stock.ReservationConsumed
+		}
+		reservation.SetUpdatedBy(userID)
+		if err := reservationRepo.Update(ctx, reservation); err != nil {
+			return 0, err
+		}
+	}
+	return valuation.UnitCost, nil
+}
+
+// ReceiveProducts puts a quantity of the product of a released or in-progress order into
+// stock, up to the planned quantity not produced yet. The components issued must cover the
+// total quantity produced; the product is received at the unit cost of the components as
+// they were issued plus the planned labor and overhead per unit, and the final cost is settled
+// when the order is closed.
+func (u *manufacturingOrderUsecase) ReceiveProducts(ctx context.Context, id uuid.UUID, quantity float64, lots []stock.LotQuantity) (*domainBom.ManufacturingOrder, error) {
+	if quantity <= 0 {
+		return nil, stock_uc.ErrInvalidQuantity
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	var order *domainBom.ManufacturingOrder
+	var unitCost float64
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txOrderRepo := u.orderRepo.WithTx(tx)
+		txStockRepo := u.stockRepo.WithTx(tx)
+		txItemRepo := u.itemRepo.WithTx(tx)
+		repos := stock_uc.PostingRepositories{
+			Stock:     txStockRepo,
+			Movements: u.stockMoveRepo.WithTx(tx),
+			Ledger:    u.stockLedgerRepo.WithTx(tx),
+			Lots:      u.lotRepo.WithTx(tx),
+		}
+		costRepos := stock_uc.CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: u.costLayerRepo.WithTx(tx)}
+
+		var err error
+		order, err = txOrderRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != domainBom.OrderReleased && order.Status != domainBom.OrderInProgress {
+			return domainBom.ErrInvalidOrderStatus
+		}
+		if quantity > order.RemainingQuantity()+quantityEpsilon {
+			return fmt.Errorf("%w: %f to produce, received %f", domainBom.ErrOverReceipt, order.RemainingQuantity(), quantity)
+		}
+
+		// The unit cost of the product is rolled up from the average cost of each component issued,
+		// plus the labor and overhead of the routing with its setup spread over the planned quantity
+		revision, err := u.bomRepo.WithTx(tx).GetByID(ctx, order.BillOfMaterialsID)
+		if err != nil {
+			return err
+		}
+		labor, overhead, err := routingCost(ctx, u.workCenterRepo, revision, order.Quantity)
+		if err != nil {
+			return err
+		}
+		unitCost = (labor + overhead) / order.Quantity
+		produced := order.ProducedQuantity + quantity
+		for _, comp := range order.Components {
+			if comp.IssuedQuantity+quantityEpsilon < comp.QuantityPer*produced {
+				return fmt.Errorf("%w: component %s issued %f, need %f", domainBom.ErrComponentsNotIssued, comp.ItemID, comp.IssuedQuantity, comp.QuantityPer*produced)
+			}
+			unitCost += comp.IssuedCost / comp.IssuedQuantity * comp.QuantityPer
+		}
+
+		product, err := txItemRepo.GetByID(ctx, order.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch product %s: %w", order.ProductID, err)
+		}
+		quantityBefore, err := stock_uc.LockedQuantity(ctx, txStockRepo, order.ProductID, order.WarehouseID, nil)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		movementID := uuid.New()
+		valuation, err := stock_uc.CostReceipt(ctx, costRepos, product, movementID, quantity, unitCost, now)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", order.ProductID, err)
+		}
+		if _, _, err := stock_uc.PostMovement(ctx, repos, stock_uc.Posting{
+			MovementID:     movementID,
+			ItemID:         order.ProductID,
+			WarehouseID:    order.WarehouseID,
+			Type:           stock.MovementTypeIn,
+			Quantity:       quantity,
+			QuantityBefore: quantityBefore,
+			Reason:         fmt.Sprintf("Receipt from manufacturing order %s", order.ID),
+			Tracking:       product.TrackingMode,
+			Lots:           lots,
+			UnitCost:       valuation.UnitCost,
+			CostVariance:   valuation.CostVariance,
+			HappenedAt:     now,
+			UserID:         userID,
+		}); err != nil {
+			return fmt.Errorf("product %s: %w", order.ProductID, err)
+		}
+		for _, l := range lots {
+			lot := &domainBom.OrderLot{ID: uuid.New(), OrderID: order.ID, ItemID: order.ProductID, LotNumber: l.LotNumber, Quantity: l.Quantity, Role: domainBom.LotProduced}
+			if err := txOrderRepo.AddLot(ctx, lot); err != nil {
+				return err
+			}
+			order.Lots = append(order.Lots, *lot)
+		}
+
+		order.ProducedQuantity = produced
+		order.ReceivedCost += unitCost * quantity
+		markStarted(order, now)
+		order.SetUpdatedBy(userID)
+		return txOrderRepo.Update(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "manufacturing_order", order.ID.String(), "RECEIVE", nil,
+		map[string]interface{}{"quantity": quantity, "unit_cost": unitCost, "produced_quantity": order.ProducedQuantity},
+		corrID)
+	return order, nil
+}
+
+// CloseOrder completes an in-progress order. The reservations still open are released, the
+// planned quantity not produced and the components issued beyond the need of the produced
+// quantity are recorded as scrap, and the ProductionRecord completing the order is created
+// with the actual cost of every component issued and the lots consumed and produced. The
+// labor and overhead of the routing, run once for the produced quantity, are added to the
+// actual cost of the order; no setup is charged when nothing was produced.
+func (u *manufacturingOrderUsecase) CloseOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var order *domainBom.ManufacturingOrder
+	var laborCost, overheadCost float64
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txOrderRepo := u.orderRepo.WithTx(tx)
+
+		var err error
+		order, err = txOrderRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != domainBom.OrderInProgress {
+			return domainBom.ErrInvalidOrderStatus
+		}
+		if err := u.releaseReservations(ctx, u.reservationRepo.WithTx(tx), order, userID); err != nil {
+			return err
+		}
+
+		for _, comp := range order.Components {
+			comp.ScrapQuantity = max(comp.IssuedQuantity-comp.QuantityPer*order.ProducedQuantity, 0)
+			if err := txOrderRepo.UpdateComponent(ctx, comp); err != nil {
+				return err
+			}
+		}
+		order.ScrapQuantity = max(order.RemainingQuantity(), 0)
+
+		if order.ProducedQuantity > 0 {
+			revision, err := u.bomRepo.WithTx(tx).GetByID(ctx, order.BillOfMaterialsID)
+			if err != nil {
+				return err
+			}
+			laborCost, overheadCost, err = routingCost(ctx, u.workCenterRepo, revision, order.ProducedQuantity)
+			if err != nil {
+				return err
+			}
+			order.ActualCost += laborCost + overheadCost
+		}
+
+		now := time.Now()
+		record := &domainBom.ProductionRecord{
+			ID:                   uuid.New(),
+			BillOfMaterialsID:    order.BillOfMaterialsID,
+			ManufacturingOrderID: &order.ID,
+			ProducedProductID:    order.ProductID,
+			ProductionQuantity:   order.ProducedQuantity,
+			ScrapQuantity:        order.ScrapQuantity,
+			ActualProductionCost: order.ActualCost,
+			LaborCost:            laborCost,
+			OverheadCost:         overheadCost,
+			WarehouseID:          order.WarehouseID,
+			ProducedAt:           now,
+			CreatedBy:            userID,
+		}
+		for _, l := range order.Lots {
+			record.Lots = append(record.Lots, domainBom.ProductionLot{
+				ID:        uuid.New(),
+				ItemID:    l.ItemID,
+				LotNumber: l.LotNumber,
+				Quantity:  l.Quantity,
+				Role:      l.Role,
+			})
+		}
+		if err := u.productionRepo.WithTx(tx).Create(ctx, record); err != nil {
+			return err
+		}
+
+		order.Status = domainBom.OrderCompleted
+		order.CompletedAt = &now
+		order.ProductionRecordID = &record.ID
+		order.SetUpdatedBy(userID)
+		return txOrderRepo.Update(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "manufacturing_order", order.ID.String(), "CLOSE",
+		map[string]interface{}{"status": domainBom.OrderInProgress},
+		map[string]interface{}{
+			"status":               order.Status,
+			"production_record_id": order.ProductionRecordID,
+			"produced_quantity":    order.ProducedQuantity,
+			"scrap_quantity":       order.ScrapQuantity,
+			"actual_cost":          order.ActualCost,
+			"labor_cost":           laborCost,
+			"overhead_cost":        overheadCost,
+			"cost_variance":        order.ActualCost - order.ReceivedCost,
+		},
+		corrID)
+	return order, nil
+}
+
+// CancelOrder cancels a planned or released order and releases its reservations. Orders
+// with components issued or products received must be closed instead.
+func (u *manufacturingOrderUsecase) CancelOrder(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var order *domainBom.ManufacturingOrder
+	var oldStatus domainBom.OrderStatus
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txOrderRepo := u.orderRepo.WithTx(tx)
+
+		var err error
+		order, err = txOrderRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != domainBom.OrderPlanned && order.Status != domainBom.OrderReleased {
+			return domainBom.ErrInvalidOrderStatus
+		}
+		if err := u.releaseReservations(ctx, u.reservationRepo.WithTx(tx), order, userID); err != nil {
+			return err
+		}
+
+		oldStatus = order.Status
+		order.Status = domainBom.OrderCancelled
+		order.SetUpdatedBy(userID)
+		return txOrderRepo.Update(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "manufacturing_order", order.ID.String(), "CANCEL",
+		map[string]interface{}{"status": oldStatus},
+		map[string]interface{}{"status": order.Status},
+		corrID)
+	return order, nil
+}
+
+// releaseReservations gives the open quantity of the active reservations of an order back to the available stock.
+func (u *manufacturingOrderUsecase) releaseReservations(ctx context.Context, reservationRepo stock.ReservationRepository, order *domainBom.ManufacturingOrder, userID uuid.UUID) error {
+	for _, comp := range order.Components {
+		if comp.ReservationID == nil {
+			continue
+		}
+		reservation, err := reservationRepo.GetByIDForUpdate(ctx, *comp.ReservationID)
+		if err != nil {
+			return err
+		}
+		if reservation.Status != stock.ReservationActive {
+			continue
+		}
+		reservation.Status = stock.ReservationReleased
+		reservation.SetUpdatedBy(userID)
+		if err := reservationRepo.Update(ctx, reservation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markStarted moves a released order in progress on its first issue or receipt.
+func markStarted(order *domainBom.ManufacturingOrder, at time.Time) {
+	if order.Status == domainBom.OrderReleased {
+		order.Status = domainBom.OrderInProgress
+		order.StartedAt = &at
+	}
+}
@@ -0,0 +1,164 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	stock_uc "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeOrderRepository struct {
+	orders map[uuid.UUID]*bom.ManufacturingOrder
+}
+
+func (f *fakeOrderRepository) WithTx(tx *gorm.DB) bom.ManufacturingOrderRepository { return f }
+func (f *fakeOrderRepository) Create(ctx context.Context, o *bom.ManufacturingOrder) error {
+	f.orders[o.ID] = o
+	return nil
+}
+func (f *fakeOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*bom.ManufacturingOrder, error) {
+	if o, ok := f.orders[id]; ok {
+		return o, nil
+	}
+	return nil, bom.ErrOrderNotFound
+}
+func (f *fakeOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*bom.ManufacturingOrder, error) {
+	return f.GetByID(ctx, id)
+}
+func (f *fakeOrderRepository) List(ctx context.Context, filter bom.OrderFilter) ([]*bom.ManufacturingOrder, error) {
+	return nil, nil
+}
+func (f *fakeOrderRepository) Update(ctx context.Context, o *bom.ManufacturingOrder) error {
+	return nil
+}
+func (f *fakeOrderRepository) UpdateComponent(ctx context.Context, c *bom.OrderComponent) error {
+	return nil
+}
+func (f *fakeOrderRepository) AddLot(ctx context.Context, l *bom.OrderLot) error { return nil }
+
+// newOrderFixture returns a production fixture with a manufacturing order use case on the same fakes,
+// and an order planned for 10 units of a product made of 2 units of a component costing 4.
+func newOrderFixture(t *testing.T) (*productionFixture, ManufacturingOrderUsecase, *bom.ManufacturingOrder) {
+	t.Helper()
+	f := newProductionFixture()
+	componentID := f.addItem(item.TrackingNone)
+	f.items.items[componentID].AverageCost = 4
+	productID := f.addItem(item.TrackingNone)
+	f.addBOM(productID, componentID, 2)
+	f.stocks.quantities[componentID] = 100
+
+	uc := NewManufacturingOrderUsecase(fakeTx{}, &fakeOrderRepository{orders: make(map[uuid.UUID]*bom.ManufacturingOrder)},
+		f.bomRepo, f.records, f.stocks, f.movements, &fakeLedgerRepository{}, f.lots, f.layers, f.reserved, f.items, f.units, f.centers, fakeAudit{})
+	order := &bom.ManufacturingOrder{ProductID: productID, WarehouseID: f.warehouse, Quantity: 10}
+	if err := uc.CreateOrder(context.Background(), order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	return f, uc, order
+}
+
+func TestManufacturingOrder_Lifecycle(t *testing.T) {
+	f, uc, order := newOrderFixture(t)
+	ctx := context.Background()
+	comp := order.Components[0]
+	if comp.RequiredQuantity != 20 {
+		t.Fatalf("required quantity = %v, want 20", comp.RequiredQuantity)
+	}
+
+	if _, err := uc.ReleaseOrder(ctx, order.ID); err != nil {
+		t.Fatalf("ReleaseOrder() error = %v", err)
+	}
+	if reserved, _ := f.reserved.ReservedQuantity(ctx, comp.ItemID, f.warehouse, nil, order.UpdatedAt); reserved != 20 {
+		t.Errorf("reserved = %v, want 20", reserved)
+	}
+
+	if _, err := uc.IssueComponents(ctx, order.ID, []bom.ComponentIssue{{ItemID: comp.ItemID, Quantity: 10}}); err != nil {
+		t.Fatalf("IssueComponents() error = %v", err)
+	}
+	if order.Status != bom.OrderInProgress || f.stocks.quantities[comp.ItemID] != 90 {
+		t.Errorf("after issue: status %s, component stock %v, want IN_PROGRESS and 90", order.Status, f.stocks.quantities[comp.ItemID])
+	}
+	if _, err := uc.ReceiveProducts(ctx, order.ID, 5, nil); err != nil {
+		t.Fatalf("ReceiveProducts() error = %v", err)
+	}
+	if _, err := uc.ReceiveProducts(ctx, order.ID, 1, nil); !errors.Is(err, bom.ErrComponentsNotIssued) {
+		t.Errorf("ReceiveProducts() error = %v, want %v", err, bom.ErrComponentsNotIssued)
+	}
+	if got := f.stocks.quantities[order.ProductID]; got != 5 {
+		t.Errorf("product stock = %v, want 5", got)
+	}
+
+	if _, err := uc.CloseOrder(ctx, order.ID); err != nil {
+		t.Fatalf("CloseOrder() error = %v", err)
+	}
+	if order.Status != bom.OrderCompleted || order.ScrapQuantity != 5 || order.ActualCost != 40 {
+		t.Errorf("closed order = %s, scrap %v, cost %v, want COMPLETED, 5, 40", order.Status, order.ScrapQuantity, order.ActualCost)
+	}
+	if reserved, _ := f.reserved.ReservedQuantity(ctx, comp.ItemID, f.warehouse, nil, order.UpdatedAt); reserved != 0 {
+		t.Errorf("reserved after close = %v, want 0", reserved)
+	}
+	if len(f.records.records) != 1 {
+		t.Fatalf("production records = %d, want 1", len(f.records.records))
+	}
+	record := f.records.records[0]
+	if record.ManufacturingOrderID == nil || *record.ManufacturingOrderID != order.ID || record.ProductionQuantity != 5 || record.ActualProductionCost != 40 {
+		t.Errorf("unexpected completion record %+v", record)
+	}
+}
+
+func TestManufacturingOrder_ReleaseRequiresAvailableComponents(t *testing.T) {
+	f, uc, order := newOrderFixture(t)
+	f.reserved.reserved[order.Components[0].ItemID] = 90
+
+	if _, err := uc.ReleaseOrder(context.Background(), order.ID); !errors.Is(err, stock_uc.ErrInsufficientStock) {
+		t.Errorf("ReleaseOrder() error = %v, want %v", err, stock_uc.ErrInsufficientStock)
+	}
+	if order.Status != bom.OrderPlanned {
+		t.Errorf("status = %s, want PLANNED", order.Status)
+	}
+}
+
+func TestManufacturingOrder_StatusRules(t *testing.T) {
+	_, uc, order := newOrderFixture(t)
+	ctx := context.Background()
+	comp := order.Components[0]
+
+	if _, err := uc.IssueComponents(ctx, order.ID, []bom.ComponentIssue{{ItemID: comp.ItemID, Quantity: 1}}); !errors.Is(err, bom.ErrInvalidOrderStatus) {
+		t.Errorf("IssueComponents() on a planned order error = %v, want %v", err, bom.ErrInvalidOrderStatus)
+	}
+	if _, err := uc.ReleaseOrder(ctx, order.ID); err != nil {
+		t.Fatalf("ReleaseOrder() error = %v", err)
+	}
+	if _, err := uc.IssueComponents(ctx, order.ID, []bom.ComponentIssue{{ItemID: comp.ItemID, Quantity: 21}}); !errors.Is(err, bom.ErrOverIssue) {
+		t.Errorf("IssueComponents() error = %v, want %v", err, bom.ErrOverIssue)
+	}
+	if _, err := uc.IssueComponents(ctx, order.ID, []bom.ComponentIssue{{ItemID: uuid.New(), Quantity: 1}}); !errors.Is(err, bom.ErrNotOrderComponent) {
+		t.Errorf("IssueComponents() error = %v, want %v", err, bom.ErrNotOrderComponent)
+	}
+	if _, err := uc.IssueComponents(ctx, order.ID, []bom.ComponentIssue{{ItemID: comp.ItemID, Quantity: 2}}); err != nil {
+		t.Fatalf("IssueComponents() error = %v", err)
+	}
+	if _, err := uc.CancelOrder(ctx, order.ID); !errors.Is(err, bom.ErrInvalidOrderStatus) {
+		t.Errorf("CancelOrder() on an order in progress error = %v, want %v", err, bom.ErrInvalidOrderStatus)
+	}
+}
+
+func TestManufacturingOrder_CancelReleasesReservations(t *testing.T) {
+	f, uc, order := newOrderFixture(t)
+	ctx := context.Background()
+	if _, err := uc.ReleaseOrder(ctx, order.ID); err != nil {
+		t.Fatalf("ReleaseOrder() error = %v", err)
+	}
+	if _, err := uc.CancelOrder(ctx, order.ID); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+	reservation := f.reserved.reservations[*order.Components[0].ReservationID]
+	if order.Status != bom.OrderCancelled || reservation.Status != stock.ReservationReleased {
+		t.Errorf("order %s, reservation %s, want CANCELLED and RELEASED", order.Status, reservation.Status)
+	}
+}
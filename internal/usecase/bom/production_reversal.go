@@ -0,0 +1,305 @@
+package bom
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	stock_uc "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReverseProduction undoes an instant production run in one transaction: its product leaves the
+// stock and the components it consumed, scrap included, come back with the same lots. Every
+// movement is reversed at the cost it was recorded at by the run rather than at the current cost
+// of the item, so a FIFO product that was partly issued since cannot be reversed
+// (stock.ErrCostLayerConsumed). A run that was partly disassembled cannot be reversed either.
+func (u *bomUsecase) ReverseProduction(ctx context.Context, recordID uuid.UUID, reason string) (*domainBom.ProductionRecord, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var record *domainBom.ProductionRecord
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txProductionRepo := u.productionRepo.WithTx(tx)
+		var err error
+		record, err = txProductionRepo.GetByIDForUpdate(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		movements, receipt, err := u.runMovements(ctx, tx, record)
+		if err != nil {
+			return err
+		}
+		if record.DisassembledQuantity > 0 {
+			return domainBom.ErrProductionDisassembled
+		}
+
+		// The product leaves first: nothing comes back if it is no longer in stock
+		now := time.Now()
+		reversalReason := "REVERSAL: " + reason
+		if err := u.reverseMovement(ctx, tx, receipt, record.ID, reversalReason, now, userID); err != nil {
+			return err
+		}
+		for _, m := range movements {
+			if m.Type.IsInbound() {
+				continue
+			}
+			if err := u.reverseMovement(ctx, tx, m, record.ID, reversalReason, now, userID); err != nil {
+				return err
+			}
+		}
+
+		record.ReversedAt = &now
+		record.ReversedBy = &userID
+		record.ReversalReason = reason
+		return txProductionRepo.Update(ctx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "production", record.ID.String(), "REVERSAL",
+		map[string]interface{}{"quantity": record.ProductionQuantity, "actual_cost": record.ActualProductionCost},
+		map[string]interface{}{"reversed_at": record.ReversedAt, "reason": reason},
+		corrID)
+	return record, nil
+}
+
+// DisassembleProduction takes quantity units of the product of an instant production run apart
+// in one transaction. The product leaves the stock at the unit cost the run received it at, and
+// the component quantity of the revision the run used comes back for each unit, at the average
+// unit cost the run issued the component at; the substitutes the run consumed come back in
+// place of the components they replaced. The scrap, labor and overhead of the disassembled
+// units are not recovered: they are written off with the product. A run can be disassembled in
+// several times up to its quantity; tracked items need their lots in opts.
+func (u *bomUsecase) DisassembleProduction(ctx context.Context, recordID uuid.UUID, quantity float64, opts domainBom.DisassemblyOptions) (*domainBom.ProductionRecord, error) {
+	if quantity <= 0 {
+		return nil, stock_uc.ErrInvalidQuantity
+	}
+	userID, _ := domain.UserIDFromContext(ctx)
+	var record *domainBom.ProductionRecord
+	var disassembledBefore float64
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txProductionRepo := u.productionRepo.WithTx(tx)
+		txItemRepo := u.itemRepo.WithTx(tx)
+		var err error
+		record, err = txProductionRepo.GetByIDForUpdate(ctx, recordID)
+		if err != nil {
+			return err
+		}
+		movements, receipt, err := u.runMovements(ctx, tx, record)
+		if err != nil {
+			return err
+		}
+		disassembledBefore = record.DisassembledQuantity
+		if left := record.ProductionQuantity - record.DisassembledQuantity; quantity > left+quantityEpsilon {
+			return fmt.Errorf("%w: %f left, %f requested", domainBom.ErrOverDisassembly, left, quantity)
+		}
+		bom, err := u.bomRepo.WithTx(tx).GetByID(ctx, record.BillOfMaterialsID)
+		if err != nil {
+			return err
+		}
+		for itemID := range opts.ComponentLots {
+			if !usesItem(bom, itemID) {
+				return fmt.Errorf("item %s is not a component of BOM %s", itemID, bom.ID)
+			}
+		}
+
+		// Quantity and value of each component issued by the run
+		issuedQty := make(map[uuid.UUID]float64)
+		issuedValue := make(map[uuid.UUID]float64)
+		for _, m := range movements {
+			if !m.Type.IsInbound() && m.ItemID != record.ProducedProductID {
+				issuedQty[m.ItemID] += m.Quantity
+				issuedValue[m.ItemID] += m.Quantity * m.UnitCost
+			}
+		}
+
+		// The product leaves as a partial reversal of its receipt
+		now := time.Now()
+		reason := fmt.Sprintf("Disassembly of production %s", record.ID)
+		part := *receipt
+		part.Quantity = quantity
+		part.CostVariance = receipt.CostVariance * quantity / receipt.Quantity
+		part.Lots = opts.ProductLots
+		if err := u.reverseMovement(ctx, tx, &part, record.ID, reason, now, userID); err != nil {
+			return err
+		}
+
+		// Components the run issued come back at the quantity of the BOM lines, without scrap,
+		// and the substitutes of the run in place of the components they replaced
+		lines, err := u.substitutedLines(ctx, txItemRepo, bom, record.Substitutions)
+		if err != nil {
+			return err
+		}
+		returned := make(map[uuid.UUID]float64)
+		var order []uuid.UUID
+		for _, comp := range lines {
+			if issuedQty[comp.ComponentItemID] <= quantityEpsilon {
+				continue
+			}
+			componentItem, err := txItemRepo.GetByID(ctx, comp.ComponentItemID)
+			if err != nil {
+				return fmt.Errorf("failed to fetch item %s: %w", comp.ComponentItemID, err)
+			}
+			factor, err := u.componentFactor(ctx, componentItem, comp)
+			if err != nil {
+				return err
+			}
+			if _, ok := returned[comp.ComponentItemID]; !ok {
+				order = append(order, comp.ComponentItemID)
+			}
+			returned[comp.ComponentItemID] += comp.Quantity * quantity * factor
+		}
+		for _, itemID := range order {
+			unitCost := issuedValue[itemID] / issuedQty[itemID]
+			if err := u.returnComponent(ctx, tx, itemID, record, returned[itemID], unitCost, opts.ComponentLots[itemID], reason, now, userID); err != nil {
+				return err
+			}
+		}
+
+		record.DisassembledQuantity += quantity
+		return txProductionRepo.Update(ctx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "production", record.ID.String(), "DISASSEMBLY",
+		map[string]interface{}{"disassembled_quantity": disassembledBefore},
+		map[string]interface{}{"quantity": quantity, "disassembled_quantity": record.DisassembledQuantity},
+		corrID)
+	return record, nil
+}
+
+// runMovements returns the stock movements of an instant production run that was not reversed,
+// and the receipt of its product among them.
+func (u *bomUsecase) runMovements(ctx context.Context, tx *gorm.DB, record *domainBom.ProductionRecord) ([]*stock.StockMovement, *stock.StockMovement, error) {
+	if record.ReversedAt != nil {
+		return nil, nil, domainBom.ErrProductionReversed
+	}
+	if record.ManufacturingOrderID != nil {
+		return nil, nil, fmt.Errorf("%w: the run completes manufacturing order %s", domainBom.ErrProductionNotReversible, *record.ManufacturingOrderID)
+	}
+	movements, err := u.stockMoveRepo.WithTx(tx).ListByProductionRecord(ctx, record.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range movements {
+		if m.ItemID == record.ProducedProductID && m.Type.IsInbound() {
+			return movements, m, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: no receipt of the product is linked to the run", domainBom.ErrProductionNotReversible)
+}
+
+// reverseMovement posts the opposite of orig at the cost orig was recorded at, with the lots of
+// orig. An outbound reversal cannot take the reserved quantity.
+func (u *bomUsecase) reverseMovement(ctx context.Context, tx *gorm.DB, orig *stock.StockMovement, recordID uuid.UUID, reason string, now time.Time, userID uuid.UUID) error {
+	txStockRepo := u.stockRepo.WithTx(tx)
+	txItemRepo := u.itemRepo.WithTx(tx)
+	it, err := txItemRepo.GetByID(ctx, orig.ItemID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch item %s: %w", orig.ItemID, err)
+	}
+	quantityBefore, err := stock_uc.LockedQuantity(ctx, txStockRepo, orig.ItemID, orig.WarehouseID, orig.BinID)
+	if err != nil {
+		return err
+	}
+	reverseType := stock.MovementTypeIn
+	var reserved float64
+	if orig.Type.IsInbound() {
+		reverseType = stock.MovementTypeOut
+		reserved, err = u.reservationRepo.WithTx(tx).ReservedQuantity(ctx, orig.ItemID, orig.WarehouseID, orig.BinID, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	movementID := uuid.New()
+	costRepos := stock_uc.CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: u.costLayerRepo.WithTx(tx)}
+	valuation, err := stock_uc.CostReversal(ctx, costRepos, it, orig, movementID, now)
+	if err != nil {
+		return fmt.Errorf("item %s: %w", orig.ItemID, err)
+	}
+	if _, _, err := stock_uc.PostMovement(ctx, u.postingRepositories(tx), stock_uc.Posting{
+		MovementID:         movementID,
+		ItemID:             orig.ItemID,
+		WarehouseID:        orig.WarehouseID,
+		BinID:              orig.BinID,
+		Type:               reverseType,
+		Quantity:           orig.Quantity,
+		QuantityBefore:     quantityBefore,
+		Reserved:           reserved,
+		Reason:             reason,
+		ProductionRecordID: &recordID,
+		Tracking:           it.TrackingMode,
+		Lots:               orig.Lots,
+		UnitCost:           valuation.UnitCost,
+		CostVariance:       valuation.CostVariance,
+		HappenedAt:         now,
+		UserID:             userID,
+	}); err != nil {
+		return fmt.Errorf("item %s: %w", orig.ItemID, err)
+	}
+	return nil
+}
+
+// returnComponent receives quantity of a component taken out of the product of record back into
+// the warehouse of the run at unitCost.
+func (u *bomUsecase) returnComponent(ctx context.Context, tx *gorm.DB, itemID uuid.UUID, record *domainBom.ProductionRecord, quantity, unitCost float64, lots []stock.LotQuantity, reason string, now time.Time, userID uuid.UUID) error {
+	txStockRepo := u.stockRepo.WithTx(tx)
+	txItemRepo := u.itemRepo.WithTx(tx)
+	componentItem, err := txItemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch item %s: %w", itemID, err)
+	}
+	if componentItem.Type != item.Storable {
+		return nil
+	}
+	quantityBefore, err := stock_uc.LockedQuantity(ctx, txStockRepo, itemID, record.WarehouseID, nil)
+	if err != nil {
+		return err
+	}
+
+	movementID := uuid.New()
+	costRepos := stock_uc.CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: u.costLayerRepo.WithTx(tx)}
+	valuation, err := stock_uc.CostReceipt(ctx, costRepos, componentItem, movementID, quantity, unitCost, now)
+	if err != nil {
+		return fmt.Errorf("component %s: %w", itemID, err)
+	}
+	if _, _, err := stock_uc.PostMovement(ctx, u.postingRepositories(tx), stock_uc.Posting{
+		MovementID:         movementID,
+		ItemID:             itemID,
+		WarehouseID:        record.WarehouseID,
+		Type:               stock.MovementTypeIn,
+		Quantity:           quantity,
+		QuantityBefore:     quantityBefore,
+		Reason:             reason,
+		ProductionRecordID: &record.ID,
+		Tracking:           componentItem.TrackingMode,
+		Lots:               lots,
+		UnitCost:           valuation.UnitCost,
+		CostVariance:       valuation.CostVariance,
+		HappenedAt:         now,
+		UserID:             userID,
+	}); err != nil {
+		return fmt.Errorf("component %s: %w", itemID, err)
+	}
+	return nil
+}
+
+func (u *bomUsecase) postingRepositories(tx *gorm.DB) stock_uc.PostingRepositories {
+	return stock_uc.PostingRepositories{
+		Stock:     u.stockRepo.WithTx(tx),
+		Movements: u.stockMoveRepo.WithTx(tx),
+		Ledger:    u.stockLedgerRepo.WithTx(tx),
+		Lots:      u.lotRepo.WithTx(tx),
+	}
+}
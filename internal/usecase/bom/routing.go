@@ -0,0 +1,41 @@
+package bom
+
+import (
+	"context"
+	"fmt"
+
+	domainBom "doligo_001/internal/domain/bom"
+)
+
+// validateOperations checks the routing of a BOM: its sequences and times, and that every
+// operation is planned on an active work center.
+func (u *bomUsecase) validateOperations(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	if err := b.ValidateOperations(); err != nil {
+		return err
+	}
+	for _, op := range b.Operations {
+		wc, err := u.workCenterRepo.GetByID(ctx, op.WorkCenterID)
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", op.Sequence, err)
+		}
+		if !wc.IsActive {
+			return fmt.Errorf("operation %d: %w: %s", op.Sequence, domainBom.ErrInactiveWorkCenter, wc.Code)
+		}
+	}
+	return nil
+}
+
+// routingCost returns the labor and overhead of the routing of b to produce quantity units in
+// one run, at the current rates of the work centers.
+func routingCost(ctx context.Context, workCenters domainBom.WorkCenterRepository, b *domainBom.BillOfMaterials, quantity float64) (labor, overhead float64, err error) {
+	for _, op := range b.Operations {
+		wc, err := workCenters.GetByID(ctx, op.WorkCenterID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("operation %d: %w", op.Sequence, err)
+		}
+		hours := op.Hours(quantity)
+		labor += hours * wc.LaborRate
+		overhead += hours * wc.OverheadRate
+	}
+	return labor, overhead, nil
+}
@@ -0,0 +1,255 @@
+package bom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// substitution holds what a production run needs to decide its substitutions.
+type substitution struct {
+	usecase      *bomUsecase
+	stocks       stock.StockRepository
+	reservations stock.ReservationRepository
+	items        item.Repository
+	warehouseID  uuid.UUID
+	at           time.Time
+	available    map[uuid.UUID]float64 // Left to consume, on hand less reserved, by item
+}
+
+// substitute returns the component lines a production run consumes and the substitutions made.
+// A component chosen in substitutes is replaced entirely by the alternate chosen for it. Otherwise,
+// when the stock available (on hand less reserved) of a storable component with alternates does
+// not cover the planned quantity of its line, scrap included, the shortfall is covered by its
+// alternates by priority, each up to its own available stock. The share of a line taken by an
+// alternate becomes a line of its own, in the base unit of the alternate. Shortfalls the
+// alternates cannot cover are left to the component, whose issue then fails as before.
+func (s *substitution) substitute(ctx context.Context, b *domainBom.BillOfMaterials, quantity float64, substitutes map[uuid.UUID]uuid.UUID) ([]domainBom.BillOfMaterialsComponent, []domainBom.ProductionSubstitution, error) {
+	for componentID, substituteID := range substitutes {
+		if !hasAlternate(b, componentID, substituteID) {
+			return nil, nil, fmt.Errorf("%w: %s for %s", domainBom.ErrInvalidSubstitution, substituteID, componentID)
+		}
+	}
+
+	// Only the stock of the items that may be substituted is followed across the lines
+	watched := make(map[uuid.UUID]bool)
+	for _, comp := range b.Components {
+		if _, chosen := substitutes[comp.ComponentItemID]; !chosen && len(comp.Alternates) > 0 {
+			watched[comp.ComponentItemID] = true
+			for _, a := range comp.Alternates {
+				watched[a.ItemID] = true
+			}
+		}
+	}
+
+	var lines []domainBom.BillOfMaterialsComponent
+	var made []domainBom.ProductionSubstitution
+	for _, comp := range b.Components {
+		componentItem, err := s.items.GetByID(ctx, comp.ComponentItemID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch item %s: %w", comp.ComponentItemID, err)
+		}
+		factor, err := s.usecase.componentFactor(ctx, componentItem, comp)
+		if err != nil {
+			return nil, nil, err
+		}
+		required := b.PlannedQuantity(comp) * quantity * factor
+
+		type replacement struct {
+			alternate domainBom.AlternateComponent
+			item      *item.Item
+			share     float64
+		}
+		var replacements []replacement
+		substituteID, chosen := substitutes[comp.ComponentItemID]
+		switch {
+		case chosen:
+			for _, a := range comp.Alternates {
+				if a.ItemID == substituteID {
+					it, err := s.items.GetByID(ctx, a.ItemID)
+					if err != nil {
+						return nil, nil, fmt.Errorf("failed to fetch item %s: %w", a.ItemID, err)
+					}
+					replacements = append(replacements, replacement{alternate: a, item: it, share: 1})
+				}
+			}
+		case watched[comp.ComponentItemID]:
+			have, err := s.take(ctx, comp.ComponentItemID, required)
+			if err != nil {
+				return nil, nil, err
+			}
+			short := required - have
+			if componentItem.Type != item.Storable || short <= quantityEpsilon {
+				break
+			}
+			alternates := append([]domainBom.AlternateComponent(nil), comp.Alternates...)
+			sort.Slice(alternates, func(i, j int) bool { return alternates[i].Priority < alternates[j].Priority })
+			for _, a := range alternates {
+				if short <= quantityEpsilon {
+					break
+				}
+				it, err := s.items.GetByID(ctx, a.ItemID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to fetch item %s: %w", a.ItemID, err)
+				}
+				if it.Type != item.Storable {
+					continue
+				}
+				got, err := s.take(ctx, a.ItemID, short*a.ConversionRatio)
+				if err != nil {
+					return nil, nil, err
+				}
+				if got <= quantityEpsilon {
+					continue
+				}
+				short -= got / a.ConversionRatio
+				replacements = append(replacements, replacement{alternate: a, item: it, share: math.Min(got/a.ConversionRatio/required, 1)})
+			}
+		}
+
+		left := 1.0
+		for _, r := range replacements {
+			left -= r.share
+		}
+		if left > quantityEpsilon {
+			line := comp
+			line.Quantity = comp.Quantity * left
+			lines = append(lines, line)
+		}
+		for _, r := range replacements {
+			lines = append(lines, domainBom.BillOfMaterialsComponent{
+				ID:                comp.ID,
+				BillOfMaterialsID: comp.BillOfMaterialsID,
+				ComponentItemID:   r.item.ID,
+				Quantity:          comp.Quantity * factor * r.share * r.alternate.ConversionRatio,
+				ScrapPercent:      comp.ScrapPercent,
+				UnitOfMeasure:     r.item.BaseUnit,
+				IsActive:          comp.IsActive,
+			})
+			made = append(made, domainBom.ProductionSubstitution{
+				ID:                 uuid.New(),
+				ComponentID:        comp.ID,
+				ComponentItemID:    comp.ComponentItemID,
+				SubstituteItemID:   r.item.ID,
+				Share:              r.share,
+				Quantity:           required * r.share,
+				SubstituteQuantity: required * r.share * r.alternate.ConversionRatio,
+				Chosen:             chosen,
+			})
+		}
+	}
+	return lines, made, nil
+}
+
+// take consumes up to qty of the stock left available for an item and returns what it got.
+func (s *substitution) take(ctx context.Context, itemID uuid.UUID, qty float64) (float64, error) {
+	available, ok := s.available[itemID]
+	if !ok {
+		st, err := s.stocks.GetStockForUpdate(ctx, itemID, s.warehouseID, nil)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+		if st != nil {
+			reserved, err := s.reservations.ReservedQuantity(ctx, itemID, s.warehouseID, nil, s.at)
+			if err != nil {
+				return 0, err
+			}
+			available = st.Quantity - reserved
+		}
+	}
+	got := qty
+	if available < got {
+		got = available
+	}
+	if got < 0 {
+		got = 0
+	}
+	s.available[itemID] = available - got
+	return got, nil
+}
+
+// substitutedLines returns the component lines a production run consumed, applying the
+// substitutions recorded on the run to the lines of its revision.
+func (u *bomUsecase) substitutedLines(ctx context.Context, items item.Repository, b *domainBom.BillOfMaterials, made []domainBom.ProductionSubstitution) ([]domainBom.BillOfMaterialsComponent, error) {
+	if len(made) == 0 {
+		return b.Components, nil
+	}
+	var lines []domainBom.BillOfMaterialsComponent
+	for _, comp := range b.Components {
+		left := 1.0
+		var replaced []domainBom.BillOfMaterialsComponent
+		for _, sub := range made {
+			if sub.ComponentID != comp.ID || sub.ComponentItemID != comp.ComponentItemID || sub.Quantity <= 0 {
+				continue
+			}
+			componentItem, err := items.GetByID(ctx, comp.ComponentItemID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch item %s: %w", comp.ComponentItemID, err)
+			}
+			factor, err := u.componentFactor(ctx, componentItem, comp)
+			if err != nil {
+				return nil, err
+			}
+			substituteItem, err := items.GetByID(ctx, sub.SubstituteItemID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch item %s: %w", sub.SubstituteItemID, err)
+			}
+			left -= sub.Share
+			replaced = append(replaced, domainBom.BillOfMaterialsComponent{
+				ID:                comp.ID,
+				BillOfMaterialsID: comp.BillOfMaterialsID,
+				ComponentItemID:   sub.SubstituteItemID,
+				Quantity:          comp.Quantity * factor * sub.Share * sub.SubstituteQuantity / sub.Quantity,
+				ScrapPercent:      comp.ScrapPercent,
+				UnitOfMeasure:     substituteItem.BaseUnit,
+				IsActive:          comp.IsActive,
+			})
+		}
+		if left > quantityEpsilon {
+			line := comp
+			line.Quantity = comp.Quantity * left
+			lines = append(lines, line)
+		}
+		lines = append(lines, replaced...)
+	}
+	return lines, nil
+}
+
+// hasAlternate reports whether the item is an alternate of a component of the BOM.
+func hasAlternate(b *domainBom.BillOfMaterials, componentItemID, itemID uuid.UUID) bool {
+	for _, comp := range b.Components {
+		if comp.ComponentItemID != componentItemID {
+			continue
+		}
+		for _, a := range comp.Alternates {
+			if a.ItemID == itemID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesItem reports whether the item is a component of the BOM or an alternate of one.
+func usesItem(b *domainBom.BillOfMaterials, itemID uuid.UUID) bool {
+	if hasComponent(b, itemID) {
+		return true
+	}
+	for _, comp := range b.Components {
+		for _, a := range comp.Alternates {
+			if a.ItemID == itemID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package bom
+
+import (
+	"context"
+	"errors"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/usecase"
+	"github.com/google/uuid"
+)
+
+// WorkCenterUsecase defines the contract for the work centers of routing operations.
+type WorkCenterUsecase interface {
+	CreateWorkCenter(ctx context.Context, wc *domainBom.WorkCenter) error
+	GetWorkCenter(ctx context.Context, id uuid.UUID) (*domainBom.WorkCenter, error)
+	ListWorkCenters(ctx context.Context, includeInactive bool) ([]*domainBom.WorkCenter, error)
+	UpdateWorkCenter(ctx context.Context, wc *domainBom.WorkCenter) error
+}
+
+type workCenterUsecase struct {
+	repo         domainBom.WorkCenterRepository
+	auditService usecase.AuditService
+}
+
+// NewWorkCenterUsecase creates a new work center usecase.
+func NewWorkCenterUsecase(repo domainBom.WorkCenterRepository, auditService usecase.AuditService) WorkCenterUsecase {
+	return &workCenterUsecase{repo: repo, auditService: auditService}
+}
+
+// CreateWorkCenter stores a new active work center. Codes are unique.
+func (uc *workCenterUsecase) CreateWorkCenter(ctx context.Context, wc *domainBom.WorkCenter) error {
+	if wc.LaborRate < 0 || wc.OverheadRate < 0 {
+		return domainBom.ErrInvalidRate
+	}
+	if _, err := uc.repo.GetByCode(ctx, wc.Code); err == nil {
+		return domainBom.ErrWorkCenterExists
+	} else if !errors.Is(err, domainBom.ErrWorkCenterNotFound) {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	wc.ID = uuid.New()
+	wc.IsActive = true
+	wc.SetCreatedBy(userID)
+	wc.SetUpdatedBy(userID)
+	if err := uc.repo.Create(ctx, wc); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "work_center", wc.ID.String(), "CREATE", nil, wc, corrID)
+	return nil
+}
+
+func (uc *workCenterUsecase) GetWorkCenter(ctx context.Context, id uuid.UUID) (*domainBom.WorkCenter, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *workCenterUsecase) ListWorkCenters(ctx context.Context, includeInactive bool) ([]*domainBom.WorkCenter, error) {
+	return uc.repo.List(ctx, includeInactive)
+}
+
+// UpdateWorkCenter changes the name, rates and active flag of a work center; its code is kept.
+// New rates apply to the production runs from then on, past runs keep the cost they recorded.
+func (uc *workCenterUsecase) UpdateWorkCenter(ctx context.Context, wc *domainBom.WorkCenter) error {
+	if wc.LaborRate < 0 || wc.OverheadRate < 0 {
+		return domainBom.ErrInvalidRate
+	}
+	old, err := uc.repo.GetByID(ctx, wc.ID)
+	if err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	wc.Code = old.Code
+	wc.CreatedAt = old.CreatedAt
+	wc.CreatedBy = old.CreatedBy
+	wc.SetUpdatedBy(userID)
+	if err := uc.repo.Update(ctx, wc); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "work_center", wc.ID.String(), "UPDATE", old, wc, corrID)
+	return nil
+}
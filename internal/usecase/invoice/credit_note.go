@@ -0,0 +1,296 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/invoice"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	stock_uc "doligo_001/internal/usecase/stock"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// quantityEpsilon absorbs rounding when a credit note credits what is left on an invoice line.
+const quantityEpsilon = 1e-9
+
+// CreateCreditNote creates a draft credit note for an issued invoice, crediting the requested
+// quantities of its lines, or all that is left to credit on them when no line is given. The
+// credit note is deducted from the invoice, and its goods returned to stock, when it is validated.
+func (u *usecase) CreateCreditNote(ctx context.Context, invoiceID uuid.UUID, req *dto.CreateCreditNoteRequest) (*invoice.Invoice, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	date, _ := time.Parse("2006-01-02", req.Date)
+
+	original, err := u.invoiceRepo.FindByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if original.IsCreditNote() {
+		return nil, invoice.ErrInvalidDocumentType
+	}
+	if !original.CanBeCredited() {
+		return nil, invoice.ErrInvalidInvoiceStatus
+	}
+	credited, err := u.invoiceRepo.CreditedQuantities(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalID := original.ID
+	creditNote := &invoice.Invoice{
+		ID:                uuid.New(),
+		ThirdPartyID:      original.ThirdPartyID,
+		DocumentType:      invoice.TypeCreditNote,
+		OriginalInvoiceID: &originalID,
+		Date:              date,
+		Status:            invoice.StatusDraft,
+	}
+	creditNote.Number = invoice.ProvisionalNumber(creditNote.ID)
+	creditNote.SetPaymentTerms(0)
+	if creditNote.Lines, err = creditLines(original, credited, creditNote.ID, req.Lines); err != nil {
+		return nil, err
+	}
+	setCreditNoteTotals(creditNote, userID)
+
+	if req.ReturnWarehouseID != "" {
+		warehouseID, _ := uuid.Parse(req.ReturnWarehouseID)
+		binID, _ := uuid.Parse(req.ReturnBinID)
+		if err := u.validateReturn(ctx, warehouseID, binID, creditNote.Lines); err != nil {
+			return nil, err
+		}
+		creditNote.ReturnWarehouseID = &warehouseID
+		creditNote.ReturnBinID = &binID
+	}
+
+	creditNote.SetCreatedBy(userID)
+	creditNote.SetUpdatedBy(userID)
+	if err := u.invoiceRepo.Create(ctx, creditNote); err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "invoice", creditNote.ID.String(), "CREATE", nil, creditNote, corrID)
+	return creditNote, nil
+}
+
+// ListCreditNotes returns the credit notes of an invoice, drafts and cancelled ones included.
+func (u *usecase) ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error) {
+	if _, err := u.invoiceRepo.FindByID(ctx, invoiceID); err != nil {
+		return nil, err
+	}
+	return u.invoiceRepo.ListCreditNotes(ctx, invoiceID)
+}
+
+// creditLines returns the lines of the credit note creditNoteID for the requested quantities of
+// the lines of original, given the quantities its validated credit notes already credited.
+func creditLines(original *invoice.Invoice, credited map[uuid.UUID]float64, creditNoteID uuid.UUID, reqs []dto.CreditNoteLineRequest) ([]invoice.InvoiceLine, error) {
+	remaining := make(map[uuid.UUID]float64, len(original.Lines))
+	for _, line := range original.Lines {
+		remaining[line.ID] = line.Quantity - credited[line.ID]
+	}
+
+	var lines []invoice.InvoiceLine
+	if len(reqs) == 0 {
+		for i := range original.Lines {
+			if quantity := remaining[original.Lines[i].ID]; quantity > quantityEpsilon {
+				lines = append(lines, original.Lines[i].CreditLine(creditNoteID, quantity))
+			}
+		}
+		if len(lines) == 0 {
+			return nil, invoice.ErrCreditExceedsInvoice
+		}
+		return lines, nil
+	}
+
+	for _, req := range reqs {
+		lineID, _ := uuid.Parse(req.InvoiceLineID)
+		var originalLine *invoice.InvoiceLine
+		for i := range original.Lines {
+			if original.Lines[i].ID == lineID {
+				originalLine = &original.Lines[i]
+				break
+			}
+		}
+		if originalLine == nil {
+			return nil, fmt.Errorf("%w: %s", invoice.ErrInvalidCreditNoteLine, req.InvoiceLineID)
+		}
+		if req.Quantity > remaining[lineID]+quantityEpsilon {
+			return nil, fmt.Errorf("%w: line %s has %g left to credit", invoice.ErrCreditExceedsInvoice, req.InvoiceLineID, remaining[lineID])
+		}
+		remaining[lineID] -= req.Quantity
+		lines = append(lines, originalLine.CreditLine(creditNoteID, req.Quantity))
+	}
+	return lines, nil
+}
+
+// setCreditNoteTotals sums the lines of a credit note into its totals.
+func setCreditNoteTotals(creditNote *invoice.Invoice, userID uuid.UUID) {
+	creditNote.TotalAmount, creditNote.TotalCost, creditNote.TotalTax = 0, 0, 0
+	for i := range creditNote.Lines {
+		line := &creditNote.Lines[i]
+		line.CreatedBy = userID
+		line.UpdatedBy = userID
+		creditNote.TotalAmount += line.TotalAmount
+		creditNote.TotalCost += line.TotalCost
+		creditNote.TotalTax += line.Quantity * line.TaxAmount
+	}
+}
+
+// validateReturn checks that the goods of lines can be returned to the bin of the warehouse: both
+// exist, are active and belong together, and none of the storable items is tracked by lot or
+// serial, whose lots a credit note does not know.
+func (u *usecase) validateReturn(ctx context.Context, warehouseID, binID uuid.UUID, lines []invoice.InvoiceLine) error {
+	warehouse, err := u.warehouseRepo.GetByID(ctx, warehouseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWarehouseNotFound
+		}
+		return err
+	}
+	bin, err := u.binRepo.GetByID(ctx, binID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBinNotFound
+		}
+		return err
+	}
+	if bin.WarehouseID != warehouseID {
+		return ErrBinNotFound
+	}
+	if !warehouse.IsActive || !bin.IsActive {
+		return ErrInactiveLocation
+	}
+	for _, line := range lines {
+		it, err := u.itemRepo.GetByID(ctx, line.ItemID)
+		if err != nil {
+			return err
+		}
+		if it.Type == item.Storable && isTracked(it) {
+			return fmt.Errorf("%w: %s", invoice.ErrTrackedItemReturn, it.Name)
+		}
+	}
+	return nil
+}
+
+// applyCreditNote deducts a credit note being validated from its invoice, locked for update so
+// that concurrent credit notes cannot credit the same quantities twice, and returns its goods to
+// stock. It returns the credited invoice and its audited state before the credit.
+func (u *usecase) applyCreditNote(ctx context.Context, tx *gorm.DB, creditNote *invoice.Invoice, now time.Time, userID uuid.UUID) (*invoice.Invoice, map[string]interface{}, error) {
+	txInvoiceRepo := u.invoiceRepo.WithTx(tx)
+	original, err := txInvoiceRepo.FindByIDForUpdate(ctx, *creditNote.OriginalInvoiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	credited, err := txInvoiceRepo.CreditedQuantities(ctx, original.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	quantities := make(map[uuid.UUID]float64, len(original.Lines))
+	for _, line := range original.Lines {
+		quantities[line.ID] = line.Quantity
+	}
+	for _, line := range creditNote.Lines {
+		lineID := *line.OriginalLineID
+		credited[lineID] += line.Quantity
+		if credited[lineID] > quantities[lineID]+quantityEpsilon {
+			return nil, nil, fmt.Errorf("%w: line %s", invoice.ErrCreditExceedsInvoice, lineID)
+		}
+	}
+
+	oldValues := invoiceState(original)
+	if err := original.ApplyCredit(creditNote.TotalAmount); err != nil {
+		return nil, nil, err
+	}
+	original.SetUpdatedBy(userID)
+	if err := txInvoiceRepo.Update(ctx, original); err != nil {
+		return nil, nil, err
+	}
+
+	if creditNote.ReturnWarehouseID != nil {
+		if err := u.returnToStock(ctx, tx, creditNote, now, userID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return original, oldValues, nil
+}
+
+// returnToStock receives the storable goods of a credit note back into its return bin, at
+// the cost they were invoiced at. Stock rows are locked in item order so concurrent returns
+// cannot deadlock.
+func (u *usecase) returnToStock(ctx context.Context, tx *gorm.DB, creditNote *invoice.Invoice, now time.Time, userID uuid.UUID) error {
+	txStockRepo := u.stockRepo.WithTx(tx)
+	txItemRepo := u.itemRepo.WithTx(tx)
+	costRepos := stock_uc.CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: u.costLayerRepo.WithTx(tx)}
+	postingRepos := stock_uc.PostingRepositories{
+		Stock:     txStockRepo,
+		Movements: u.stockMoveRepo.WithTx(tx),
+		Ledger:    u.stockLedgerRepo.WithTx(tx),
+		Lots:      u.lotRepo.WithTx(tx),
+	}
+	warehouseID, binID := *creditNote.ReturnWarehouseID, creditNote.ReturnBinID
+	reason := fmt.Sprintf("Credit note %s", creditNote.Number)
+
+	lines := append([]invoice.InvoiceLine(nil), creditNote.Lines...)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].ItemID.String() < lines[j].ItemID.String() })
+	for _, line := range lines {
+		it, err := txItemRepo.GetByID(ctx, line.ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch item %s: %w", line.ItemID, err)
+		}
+		if it.Type != item.Storable || line.BaseQuantity <= 0 {
+			continue
+		}
+		if isTracked(it) {
+			return fmt.Errorf("%w: %s", invoice.ErrTrackedItemReturn, it.Name)
+		}
+		quantityBefore, err := stock_uc.LockedQuantity(ctx, txStockRepo, it.ID, warehouseID, binID)
+		if err != nil {
+			return err
+		}
+
+		movementID := uuid.New()
+		valuation, err := stock_uc.CostReceipt(ctx, costRepos, it, movementID, line.BaseQuantity, line.TotalCost/line.BaseQuantity, now)
+		if err != nil {
+			return fmt.Errorf("item %s: %w", it.ID, err)
+		}
+		if _, _, err := stock_uc.PostMovement(ctx, postingRepos, stock_uc.Posting{
+			MovementID:     movementID,
+			ItemID:         it.ID,
+			WarehouseID:    warehouseID,
+			BinID:          binID,
+			Type:           stock.MovementTypeIn,
+			Quantity:       line.BaseQuantity,
+			QuantityBefore: quantityBefore,
+			Reason:         reason,
+			Tracking:       it.TrackingMode,
+			UnitCost:       valuation.UnitCost,
+			CostVariance:   valuation.CostVariance,
+			HappenedAt:     now,
+			UserID:         userID,
+		}); err != nil {
+			return fmt.Errorf("item %s: %w", it.ID, err)
+		}
+	}
+	return nil
+}
+
+// documentTitle is the name of the document type of inv in emails and file names.
+func documentTitle(inv *invoice.Invoice) string {
+	if inv.IsCreditNote() {
+		return "Credit note"
+	}
+	return "Invoice"
+}
+
+// isTracked reports whether the stock of an item is tracked by lot or serial.
+func isTracked(it *item.Item) bool {
+	return it.TrackingMode == item.TrackingLot || it.TrackingMode == item.TrackingSerial
+}
@@ -0,0 +1,498 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/invoice"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeStockRepository is an in-memory stock.StockRepository that records the bins it locks.
+type fakeStockRepository struct {
+	stocks     map[string]*stock.Stock
+	lockedBins []*uuid.UUID
+}
+
+func stockKey(itemID, warehouseID uuid.UUID, binID *uuid.UUID) string {
+	key := itemID.String() + "/" + warehouseID.String()
+	if binID != nil {
+		key += "/" + binID.String()
+	}
+	return key
+}
+
+func (f *fakeStockRepository) WithTx(tx *gorm.DB) stock.StockRepository { return f }
+func (f *fakeStockRepository) GetStock(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	s, ok := f.stocks[stockKey(itemID, warehouseID, binID)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+func (f *fakeStockRepository) GetStockForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	f.lockedBins = append(f.lockedBins, binID)
+	return f.GetStock(ctx, itemID, warehouseID, binID)
+}
+func (f *fakeStockRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID) (float64, error) {
+	var total float64
+	for _, s := range f.stocks {
+		if s.ItemID == itemID {
+			total += s.Quantity
+		}
+	}
+	return total, nil
+}
+func (f *fakeStockRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	return nil, nil
+}
+func (f *fakeStockRepository) UpsertStock(ctx context.Context, s *stock.Stock) error {
+	copied := *s
+	f.stocks[stockKey(s.ItemID, s.WarehouseID, s.BinID)] = &copied
+	return nil
+}
+
+type fakeMovementRepository struct {
+	movements []*stock.StockMovement
+}
+
+func (f *fakeMovementRepository) WithTx(tx *gorm.DB) stock.StockMovementRepository { return f }
+func (f *fakeMovementRepository) Create(ctx context.Context, movement *stock.StockMovement) error {
+	f.movements = append(f.movements, movement)
+	return nil
+}
+func (f *fakeMovementRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeMovementRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
+	return f.GetByID(ctx, id)
+}
+func (f *fakeMovementRepository) MarkReversed(ctx context.Context, movement *stock.StockMovement) error {
+	return nil
+}
+func (f *fakeMovementRepository) ListByProductionRecord(ctx context.Context, recordID uuid.UUID) ([]*stock.StockMovement, error) {
+	return nil, nil
+}
+
+type fakeLedgerRepository struct {
+	entries []*stock.StockLedger
+}
+
+func (f *fakeLedgerRepository) WithTx(tx *gorm.DB) stock.StockLedgerRepository { return f }
+func (f *fakeLedgerRepository) Create(ctx context.Context, entry *stock.StockLedger) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+func (f *fakeLedgerRepository) List(ctx context.Context, filter stock.LedgerFilter) ([]*stock.StockLedger, int64, error) {
+	return nil, 0, nil
+}
+func (f *fakeLedgerRepository) ListBalancesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.Stock, error) {
+	return nil, nil
+}
+func (f *fakeLedgerRepository) ListItemValuesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.ItemValue, error) {
+	return nil, nil
+}
+
+// fakeLotRepository holds no lots: credit notes only return untracked goods.
+type fakeLotRepository struct{}
+
+func (f fakeLotRepository) WithTx(tx *gorm.DB) stock.StockLotRepository { return f }
+func (fakeLotRepository) GetForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, lotNumber string) (*stock.StockLot, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (fakeLotRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID, lotNumber string) (float64, error) {
+	return 0, nil
+}
+func (fakeLotRepository) Upsert(ctx context.Context, lot *stock.StockLot) error { return nil }
+func (fakeLotRepository) List(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*stock.StockLot, error) {
+	return nil, nil
+}
+
+type fakeCostLayerRepository struct {
+	layers []*stock.CostLayer
+}
+
+func (f *fakeCostLayerRepository) WithTx(tx *gorm.DB) stock.CostLayerRepository { return f }
+func (f *fakeCostLayerRepository) Create(ctx context.Context, layer *stock.CostLayer) error {
+	f.layers = append(f.layers, layer)
+	return nil
+}
+func (f *fakeCostLayerRepository) ListOpen(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	return nil, nil
+}
+func (f *fakeCostLayerRepository) ListOpenForUpdate(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	return nil, nil
+}
+func (f *fakeCostLayerRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*stock.CostLayer, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeCostLayerRepository) GetByMovementForUpdate(ctx context.Context, movementID uuid.UUID) (*stock.CostLayer, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeCostLayerRepository) UpdateRemaining(ctx context.Context, layer *stock.CostLayer) error {
+	return nil
+}
+func (f *fakeCostLayerRepository) CreateConsumption(ctx context.Context, consumption *stock.CostLayerConsumption) error {
+	return nil
+}
+func (f *fakeCostLayerRepository) ListConsumptions(ctx context.Context, movementID uuid.UUID) ([]*stock.CostLayerConsumption, error) {
+	return nil, nil
+}
+
+type fakeWarehouseRepository struct {
+	warehouses map[uuid.UUID]*stock.Warehouse
+}
+
+func (f *fakeWarehouseRepository) WithTx(tx *gorm.DB) stock.WarehouseRepository { return f }
+func (f *fakeWarehouseRepository) Create(ctx context.Context, warehouse *stock.Warehouse) error {
+	f.warehouses[warehouse.ID] = warehouse
+	return nil
+}
+func (f *fakeWarehouseRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Warehouse, error) {
+	w, ok := f.warehouses[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return w, nil
+}
+func (f *fakeWarehouseRepository) Update(ctx context.Context, warehouse *stock.Warehouse) error {
+	return nil
+}
+func (f *fakeWarehouseRepository) List(ctx context.Context) ([]*stock.Warehouse, error) {
+	return nil, nil
+}
+func (f *fakeWarehouseRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+type fakeBinRepository struct {
+	bins map[uuid.UUID]*stock.Bin
+}
+
+func (f *fakeBinRepository) WithTx(tx *gorm.DB) stock.BinRepository { return f }
+func (f *fakeBinRepository) Create(ctx context.Context, bin *stock.Bin) error {
+	f.bins[bin.ID] = bin
+	return nil
+}
+func (f *fakeBinRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Bin, error) {
+	b, ok := f.bins[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return b, nil
+}
+func (f *fakeBinRepository) Update(ctx context.Context, bin *stock.Bin) error { return nil }
+func (f *fakeBinRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Bin, error) {
+	return nil, nil
+}
+func (f *fakeBinRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+// creditNoteFixture is an invoice fixture with stock, a warehouse with a bin, and a storable
+// product sold by boxes of 6 units.
+type creditNoteFixture struct {
+	*invoiceFixture
+	stocks      *fakeStockRepository
+	movements   *fakeMovementRepository
+	ledger      *fakeLedgerRepository
+	layers      *fakeCostLayerRepository
+	bins        *fakeBinRepository
+	warehouseID uuid.UUID
+	binID       uuid.UUID
+	productID   uuid.UUID
+}
+
+func newCreditNoteFixture() *creditNoteFixture {
+	f := &creditNoteFixture{
+		invoiceFixture: newInvoiceFixture(),
+		stocks:         &fakeStockRepository{stocks: make(map[string]*stock.Stock)},
+		movements:      &fakeMovementRepository{},
+		ledger:         &fakeLedgerRepository{},
+		layers:         &fakeCostLayerRepository{},
+		warehouseID:    uuid.New(),
+		binID:          uuid.New(),
+		productID:      uuid.New(),
+	}
+	f.items.items[f.productID] = &item.Item{
+		ID: f.productID, Name: "Widget", Type: item.Storable,
+		CostingMethod: item.CostingAverage, TrackingMode: item.TrackingNone,
+	}
+	f.usecase.stockRepo = f.stocks
+	f.usecase.stockMoveRepo = f.movements
+	f.usecase.stockLedgerRepo = f.ledger
+	f.usecase.lotRepo = fakeLotRepository{}
+	f.usecase.costLayerRepo = f.layers
+	f.usecase.warehouseRepo = &fakeWarehouseRepository{warehouses: map[uuid.UUID]*stock.Warehouse{
+		f.warehouseID: {ID: f.warehouseID, Name: "Main", IsActive: true},
+	}}
+	f.bins = &fakeBinRepository{bins: map[uuid.UUID]*stock.Bin{
+		f.binID: {ID: f.binID, WarehouseID: f.warehouseID, Name: "Returns", IsActive: true},
+	}}
+	f.usecase.binRepo = f.bins
+	return f
+}
+
+// addSale registers an issued invoice of 2 boxes of the product at 60, i.e. 12 units whose
+// cost totals 60, and returns its ID and the ID of its line.
+func (f *creditNoteFixture) addSale(status invoice.Status, amountPaid float64) (uuid.UUID, uuid.UUID) {
+	id, lineID := uuid.New(), uuid.New()
+	f.invoices.invoices[id] = &invoice.Invoice{
+		ID:           id,
+		ThirdPartyID: f.customerID,
+		DocumentType: invoice.TypeStandard,
+		Number:       "INV-" + id.String()[:8],
+		Date:         time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		Status:       status,
+		AmountPaid:   amountPaid,
+		TotalAmount:  120,
+		TotalCost:    60,
+		Lines: []invoice.InvoiceLine{{
+			ID: lineID, InvoiceID: id, ItemID: f.productID, Description: "Widget", Quantity: 2, UnitOfMeasure: "BOX",
+			BaseQuantity: 12, UnitPrice: 60, UnitCost: 30, NetPrice: 60, TotalAmount: 120, TotalCost: 60,
+		}},
+	}
+	return id, lineID
+}
+
+// credit creates a draft credit note of quantity boxes of the line, returned to the bin of the warehouse.
+func (f *creditNoteFixture) credit(invoiceID, lineID uuid.UUID, quantity float64) (*invoice.Invoice, error) {
+	return f.usecase.CreateCreditNote(context.Background(), invoiceID, &dto.CreateCreditNoteRequest{
+		Date:              "2026-03-20",
+		ReturnWarehouseID: f.warehouseID.String(),
+		ReturnBinID:       f.binID.String(),
+		Lines:             []dto.CreditNoteLineRequest{{InvoiceLineID: lineID.String(), Quantity: quantity}},
+	})
+}
+
+func TestCreateCreditNote_RejectsMoreThanRemaining(t *testing.T) {
+	f := newCreditNoteFixture()
+	invoiceID, lineID := f.addSale(invoice.StatusSent, 0)
+
+	if _, err := f.credit(invoiceID, lineID, 3); !errors.Is(err, invoice.ErrCreditExceedsInvoice) {
+		t.Fatalf("credit of 3 boxes out of 2: error = %v, want %v", err, invoice.ErrCreditExceedsInvoice)
+	}
+
+	first, err := f.credit(invoiceID, lineID, 1.5)
+	if err != nil {
+		t.Fatalf("CreateCreditNote() error = %v", err)
+	}
+	// A draft credit note does not hold the quantity yet
+	if _, err := f.credit(invoiceID, lineID, 2); err != nil {
+		t.Fatalf("credit of 2 boxes beside a draft: error = %v", err)
+	}
+	if _, err := f.usecase.Validate(context.Background(), first.ID); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	// Once validated, only the other half box is left to credit
+	if _, err := f.credit(invoiceID, lineID, 1); !errors.Is(err, invoice.ErrCreditExceedsInvoice) {
+		t.Errorf("credit of 1 box with 0.5 left: error = %v, want %v", err, invoice.ErrCreditExceedsInvoice)
+	}
+	rest, err := f.usecase.CreateCreditNote(context.Background(), invoiceID, &dto.CreateCreditNoteRequest{Date: "2026-03-20"})
+	if err != nil {
+		t.Fatalf("CreateCreditNote() of the rest error = %v", err)
+	}
+	if len(rest.Lines) != 1 || rest.Lines[0].Quantity != 0.5 || rest.Lines[0].BaseQuantity != 3 || rest.TotalAmount != 30 {
+		t.Errorf("credit note of the rest = %+v, want 0.5 box (3 units) for 30", rest.Lines)
+	}
+}
+
+func TestValidateCreditNote_RechecksQuantitiesUnderLock(t *testing.T) {
+	f := newCreditNoteFixture()
+	invoiceID, lineID := f.addSale(invoice.StatusSent, 0)
+
+	// Both drafts fit on their own, not together
+	first, err := f.credit(invoiceID, lineID, 1.5)
+	if err != nil {
+		t.Fatalf("CreateCreditNote() error = %v", err)
+	}
+	second, err := f.credit(invoiceID, lineID, 1)
+	if err != nil {
+		t.Fatalf("CreateCreditNote() error = %v", err)
+	}
+	if _, err := f.usecase.Validate(context.Background(), first.ID); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if _, err := f.usecase.Validate(context.Background(), second.ID); !errors.Is(err, invoice.ErrCreditExceedsInvoice) {
+		t.Fatalf("Validate() of the second credit note error = %v, want %v", err, invoice.ErrCreditExceedsInvoice)
+	}
+	if got := f.invoices.invoices[second.ID].Status; got != invoice.StatusDraft {
+		t.Errorf("second credit note status = %s, want DRAFT", got)
+	}
+	if got := f.invoices.invoices[invoiceID].AmountCredited; got != 90 {
+		t.Errorf("amount credited = %v, want 90 from the first credit note only", got)
+	}
+	if len(f.movements.movements) != 1 {
+		t.Errorf("stock movements = %d, want 1 from the first credit note only", len(f.movements.movements))
+	}
+}
+
+func TestValidateCreditNote_UpdatesOriginalInvoice(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      invoice.Status
+		amountPaid  float64
+		boxes       float64
+		wantStatus  invoice.Status
+		wantBalance float64
+	}{
+		{"partial credit of a sent invoice", invoice.StatusSent, 0, 1, invoice.StatusSent, 60},
+		{"full credit of a sent invoice", invoice.StatusSent, 0, 2, invoice.StatusPaid, 0},
+		{"credit of the balance of a partially paid invoice", invoice.StatusPartiallyPaid, 60, 1, invoice.StatusPaid, 0},
+		{"partial credit of a partially paid invoice", invoice.StatusPartiallyPaid, 30, 1, invoice.StatusPartiallyPaid, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCreditNoteFixture()
+			invoiceID, lineID := f.addSale(tt.status, tt.amountPaid)
+			creditNote, err := f.credit(invoiceID, lineID, tt.boxes)
+			if err != nil {
+				t.Fatalf("CreateCreditNote() error = %v", err)
+			}
+
+			validated, err := f.usecase.Validate(context.Background(), creditNote.ID)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if validated.Status != invoice.StatusValidated || validated.Number != "CN-2026-0001" {
+				t.Errorf("credit note = %s %q, want VALIDATED CN-2026-0001", validated.Status, validated.Number)
+			}
+			original := f.invoices.invoices[invoiceID]
+			if original.Status != tt.wantStatus || original.BalanceDue() != tt.wantBalance {
+				t.Errorf("invoice = %s with %v due, want %s with %v due", original.Status, original.BalanceDue(), tt.wantStatus, tt.wantBalance)
+			}
+			if original.AmountCredited != creditNote.TotalAmount {
+				t.Errorf("amount credited = %v, want %v", original.AmountCredited, creditNote.TotalAmount)
+			}
+		})
+	}
+}
+
+func TestCreateCreditNote_RejectsTrackedItemReturn(t *testing.T) {
+	for _, tracking := range []item.TrackingMode{item.TrackingLot, item.TrackingSerial} {
+		t.Run(string(tracking), func(t *testing.T) {
+			f := newCreditNoteFixture()
+			f.items.items[f.productID].TrackingMode = tracking
+			invoiceID, lineID := f.addSale(invoice.StatusSent, 0)
+
+			if _, err := f.credit(invoiceID, lineID, 1); !errors.Is(err, invoice.ErrTrackedItemReturn) {
+				t.Fatalf("CreateCreditNote() error = %v, want %v", err, invoice.ErrTrackedItemReturn)
+			}
+
+			// Without a return, the credit note only credits the amount
+			if _, err := f.usecase.CreateCreditNote(context.Background(), invoiceID, &dto.CreateCreditNoteRequest{
+				Date:  "2026-03-20",
+				Lines: []dto.CreditNoteLineRequest{{InvoiceLineID: lineID.String(), Quantity: 1}},
+			}); err != nil {
+				t.Errorf("CreateCreditNote() without return error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateCreditNote_ValidatesReturnBin(t *testing.T) {
+	otherWarehouseID := uuid.New()
+	tests := []struct {
+		name    string
+		bin     *stock.Bin
+		wantErr error
+	}{
+		{"unknown bin", nil, ErrBinNotFound},
+		{"bin of another warehouse", &stock.Bin{ID: uuid.New(), WarehouseID: otherWarehouseID, Name: "Other", IsActive: true}, ErrBinNotFound},
+		{"inactive bin", &stock.Bin{ID: uuid.New(), Name: "Closed", IsActive: false}, ErrInactiveLocation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCreditNoteFixture()
+			binID := uuid.New()
+			if tt.bin != nil {
+				if tt.bin.WarehouseID == uuid.Nil {
+					tt.bin.WarehouseID = f.warehouseID
+				}
+				binID = tt.bin.ID
+				f.bins.bins[binID] = tt.bin
+			}
+			invoiceID, lineID := f.addSale(invoice.StatusSent, 0)
+
+			_, err := f.usecase.CreateCreditNote(context.Background(), invoiceID, &dto.CreateCreditNoteRequest{
+				Date:              "2026-03-20",
+				ReturnWarehouseID: f.warehouseID.String(),
+				ReturnBinID:       binID.String(),
+				Lines:             []dto.CreditNoteLineRequest{{InvoiceLineID: lineID.String(), Quantity: 1}},
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateCreditNote() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCreditNote_ReturnsGoodsToStock(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       item.CostingMethod
+		wantUnitCost float64
+		wantVariance float64
+		wantAverage  float64
+		wantLayers   int
+	}{
+		// 6 units back at 30 / 6 = 5 each, beside 4 units at an average of 8
+		{"average", item.CostingAverage, 5, 0, 6.2, 0},
+		{"fifo", item.CostingFIFO, 5, 0, 6.2, 1},
+		{"standard", item.CostingStandard, 7, -12, 6.2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCreditNoteFixture()
+			product := f.items.items[f.productID]
+			product.CostingMethod, product.AverageCost, product.StandardCost = tt.method, 8, 7
+			f.stocks.stocks[stockKey(f.productID, f.warehouseID, &f.binID)] = &stock.Stock{ItemID: f.productID, WarehouseID: f.warehouseID, BinID: &f.binID, Quantity: 4}
+			invoiceID, lineID := f.addSale(invoice.StatusSent, 0)
+			creditNote, err := f.credit(invoiceID, lineID, 1)
+			if err != nil {
+				t.Fatalf("CreateCreditNote() error = %v", err)
+			}
+
+			if _, err := f.usecase.Validate(context.Background(), creditNote.ID); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			if len(f.stocks.lockedBins) != 1 || f.stocks.lockedBins[0] == nil || *f.stocks.lockedBins[0] != f.binID {
+				t.Errorf("locked bins = %v, want the return bin", f.stocks.lockedBins)
+			}
+			if len(f.movements.movements) != 1 {
+				t.Fatalf("stock movements = %d, want 1", len(f.movements.movements))
+			}
+			movement := f.movements.movements[0]
+			if movement.Type != stock.MovementTypeIn || movement.BinID == nil || *movement.BinID != f.binID || movement.WarehouseID != f.warehouseID || movement.Quantity != 6 {
+				t.Errorf("movement = %s of %v at bin %v of %s, want IN of 6 at the return bin", movement.Type, movement.Quantity, movement.BinID, movement.WarehouseID)
+			}
+			if movement.UnitCost != tt.wantUnitCost || movement.CostVariance != tt.wantVariance {
+				t.Errorf("movement cost = %v with variance %v, want %v with variance %v", movement.UnitCost, movement.CostVariance, tt.wantUnitCost, tt.wantVariance)
+			}
+			if got := f.stocks.stocks[stockKey(f.productID, f.warehouseID, &f.binID)].Quantity; got != 10 {
+				t.Errorf("stock = %v, want 10", got)
+			}
+			if entry := f.ledger.entries[0]; entry.QuantityBefore != 4 || entry.QuantityAfter != 10 {
+				t.Errorf("ledger entry = %v to %v, want 4 to 10", entry.QuantityBefore, entry.QuantityAfter)
+			}
+			if got := f.items.items[f.productID].AverageCost; math.Abs(got-tt.wantAverage) > 1e-9 {
+				t.Errorf("average cost = %v, want %v", got, tt.wantAverage)
+			}
+			if len(f.layers.layers) != tt.wantLayers {
+				t.Fatalf("cost layers = %d, want %d", len(f.layers.layers), tt.wantLayers)
+			}
+			if tt.wantLayers > 0 {
+				if layer := f.layers.layers[0]; layer.UnitCost != 5 || layer.OriginalQuantity != 6 || layer.MovementID != movement.ID {
+					t.Errorf("cost layer = %+v, want 6 units at 5 opened by the return", layer)
+				}
+			}
+		})
+	}
+}
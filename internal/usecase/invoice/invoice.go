@@ -2,25 +2,56 @@ package invoice
 
 import (
 	"context"
+	"errors"
 
 	"doligo_001/internal/api/dto"
 	"github.com/google/uuid"
 	"doligo_001/internal/domain/invoice"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWarehouseNotFound is returned when a credit note returns goods to an unknown warehouse.
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	// ErrBinNotFound is returned when a credit note returns goods to an unknown bin or to a bin of
+	// another warehouse than its return warehouse.
+	ErrBinNotFound = errors.New("bin not found in the return warehouse")
+	// ErrInactiveLocation is returned when a credit note returns goods to an inactive warehouse or bin.
+	ErrInactiveLocation = errors.New("return warehouse or bin is inactive")
+	// ErrThirdPartyNotFound is returned when an invoice is made out to an unknown customer.
+	ErrThirdPartyNotFound = errors.New("third party not found")
 )
 
 type Usecase interface {
 	Create(ctx context.Context, req *dto.CreateInvoiceRequest) (*invoice.Invoice, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error)
+	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateInvoiceRequest) (*invoice.Invoice, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	Validate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error)
+	Send(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error)
+	Cancel(ctx context.Context, id uuid.UUID, reason string) (*invoice.Invoice, error)
+	CreateCreditNote(ctx context.Context, invoiceID uuid.UUID, req *dto.CreateCreditNoteRequest) (*invoice.Invoice, error)
+	ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error)
 	QueueInvoicePDFGeneration(ctx context.Context, invoiceID uuid.UUID) error
 	GetPDFStatus(ctx context.Context, id uuid.UUID) (*dto.InvoicePDFStatusResponse, error)
 	GetPDFPath(ctx context.Context, id uuid.UUID) (string, error)
 }
 
 type Repository interface {
+	WithTx(tx *gorm.DB) Repository
 	Create(ctx context.Context, invoice *invoice.Invoice) error
 	Update(ctx context.Context, invoice *invoice.Invoice) error
+	// UpdatePDFStatus saves the PDF status, URL and error of an invoice, leaving the rest untouched.
+	UpdatePDFStatus(ctx context.Context, invoice *invoice.Invoice) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	FindByID(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error)
 	FindByIDWithDetails(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error)
+	// FindByIDForUpdate returns an invoice with its lines, locking its row until the end of the transaction.
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error)
+	ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error)
+	// ListByThirdParty returns the invoices and credit notes of a customer, without their lines, by date.
+	ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*invoice.Invoice, error)
+	// CreditedQuantities returns the quantity credited on each line of an invoice by its
+	// validated credit notes, keyed by invoice line ID.
+	CreditedQuantities(ctx context.Context, invoiceID uuid.UUID) (map[uuid.UUID]float64, error)
 }
@@ -2,6 +2,7 @@ package invoice
 
 import (
 	"context"
+	"errors"
 	"doligo_001/internal/domain/invoice"
 	"doligo_001/internal/infrastructure/email"
 	"fmt"
@@ -10,17 +11,37 @@ import (
 	"doligo_001/internal/api/dto"
 	"doligo_001/internal/api/middleware"
 	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/numbering"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/thirdparty"
+	"doligo_001/internal/domain/uom"
+	"doligo_001/internal/infrastructure/db"
 	"doligo_001/internal/infrastructure/pdf"
 	"doligo_001/internal/infrastructure/worker"
 	audit_uc "doligo_001/internal/usecase"
-	item_usecase "doligo_001/internal/usecase/item"
+	numbering_uc "doligo_001/internal/usecase/numbering"
+	stock_uc "doligo_001/internal/usecase/stock"
+	uom_uc "doligo_001/internal/usecase/uom"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type usecase struct {
-	invoiceRepo    Repository
-	itemRepo       item_usecase.Repository
+	txManager       db.Transactioner
+	invoiceRepo     Repository
+	thirdPartyRepo  thirdparty.Repository
+	sequenceRepo    numbering.Repository
+	itemRepo        item.Repository
+	stockRepo       stock.StockRepository
+	stockMoveRepo   stock.StockMovementRepository
+	stockLedgerRepo stock.StockLedgerRepository
+	lotRepo         stock.StockLotRepository
+	costLayerRepo   stock.CostLayerRepository
+	warehouseRepo   stock.WarehouseRepository
+	binRepo         stock.BinRepository
+	unitRepo        uom.Repository
 	pdfGen         pdf.Generator
 	emailSender    email.EmailSender
 	workerPool     *worker.WorkerPool
@@ -28,10 +49,40 @@ type usecase struct {
 	pdfStoragePath string
 }
 
-func NewUsecase(invoiceRepo Repository, itemRepo item_usecase.Repository, pdfGen pdf.Generator, emailSender email.EmailSender, workerPool *worker.WorkerPool, auditService audit_uc.AuditService, pdfStoragePath string) Usecase {
+func NewUsecase(
+	txManager db.Transactioner,
+	invoiceRepo Repository,
+	thirdPartyRepo thirdparty.Repository,
+	sequenceRepo numbering.Repository,
+	itemRepo item.Repository,
+	stockRepo stock.StockRepository,
+	stockMoveRepo stock.StockMovementRepository,
+	stockLedgerRepo stock.StockLedgerRepository,
+	lotRepo stock.StockLotRepository,
+	costLayerRepo stock.CostLayerRepository,
+	warehouseRepo stock.WarehouseRepository,
+	binRepo stock.BinRepository,
+	unitRepo uom.Repository,
+	pdfGen pdf.Generator,
+	emailSender email.EmailSender,
+	workerPool *worker.WorkerPool,
+	auditService audit_uc.AuditService,
+	pdfStoragePath string,
+) Usecase {
 	return &usecase{
-		invoiceRepo:    invoiceRepo,
-		itemRepo:       itemRepo,
+		txManager:       txManager,
+		invoiceRepo:     invoiceRepo,
+		thirdPartyRepo:  thirdPartyRepo,
+		sequenceRepo:    sequenceRepo,
+		itemRepo:        itemRepo,
+		stockRepo:       stockRepo,
+		stockMoveRepo:   stockMoveRepo,
+		stockLedgerRepo: stockLedgerRepo,
+		lotRepo:         lotRepo,
+		costLayerRepo:   costLayerRepo,
+		warehouseRepo:   warehouseRepo,
+		binRepo:         binRepo,
+		unitRepo:        unitRepo,
 		pdfGen:         pdfGen,
 		emailSender:    emailSender,
 		workerPool:     workerPool,
@@ -40,6 +91,7 @@ func NewUsecase(invoiceRepo Repository, itemRepo item_usecase.Repository, pdfGen
 	}
 }
 
+// Create creates a draft invoice under a provisional number; its final number is given at validation.
 func (u *usecase) Create(ctx context.Context, req *dto.CreateInvoiceRequest) (*invoice.Invoice, error) {
 	userID, _ := domain.UserIDFromContext(ctx)
 	thirdPartyID, _ := uuid.Parse(req.ThirdPartyID)
@@ -48,20 +100,72 @@ func (u *usecase) Create(ctx context.Context, req *dto.CreateInvoiceRequest) (*i
 	newInvoice := &invoice.Invoice{
 		ID:           uuid.New(),
 		ThirdPartyID: thirdPartyID,
-		Number:       req.Number,
+		DocumentType: invoice.TypeStandard,
 		Date:         invoiceDate,
+		Status:       invoice.StatusDraft,
+	}
+	newInvoice.Number = invoice.ProvisionalNumber(newInvoice.ID)
+	terms, err := u.paymentTermDays(ctx, thirdPartyID, req.PaymentTermDays)
+	if err != nil {
+		return nil, err
+	}
+	newInvoice.SetPaymentTerms(terms)
+	if err := u.setLines(ctx, newInvoice, req.Lines, userID); err != nil {
+		return nil, err
+	}
+
+	newInvoice.SetCreatedBy(userID)
+	newInvoice.SetUpdatedBy(userID)
+
+	if err := u.invoiceRepo.Create(ctx, newInvoice); err != nil {
+		return nil, err
 	}
 
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "invoice", newInvoice.ID.String(), "CREATE", nil, newInvoice, corrID)
+	return newInvoice, nil
+}
+
+// paymentTermDays returns the requested payment term, or else the payment term of the customer.
+func (u *usecase) paymentTermDays(ctx context.Context, thirdPartyID uuid.UUID, requested *int) (int, error) {
+	if requested != nil {
+		return *requested, nil
+	}
+	tp, err := u.thirdPartyRepo.GetByID(ctx, thirdPartyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrThirdPartyNotFound
+		}
+		return 0, err
+	}
+	return tp.PaymentTermDays, nil
+}
+
+// setLines prices and costs the requested lines and replaces the lines and totals of the invoice with them.
+func (u *usecase) setLines(ctx context.Context, inv *invoice.Invoice, reqs []dto.CreateInvoiceLineRequest, userID uuid.UUID) error {
 	var totalAmount float64
 	var totalCost float64
 	var totalTax float64
 
-	for _, lineReq := range req.Lines {
+	inv.Lines = nil
+	for _, lineReq := range reqs {
 		itemID, _ := uuid.Parse(lineReq.ItemID)
 		item, err := u.itemRepo.GetByID(ctx, itemID)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		// Quantities are entered in any unit of the item's category; stock is costed in its base unit
+		factor, err := uom_uc.BaseFactor(ctx, u.unitRepo, item, lineReq.UnitOfMeasure)
+		if err != nil {
+			return err
+		}
+		baseQuantity := lineReq.Quantity * factor
+		// Lines are costed the way the item would be issued from stock, e.g. from its oldest FIFO layers
+		baseUnitCost, err := stock_uc.EstimateIssueCost(ctx, u.costLayerRepo, item, baseQuantity)
+		if err != nil {
+			return err
+		}
+		unitCost := baseUnitCost * factor
 
 		// Calculate tax (assuming TaxRate is percentage, e.g. 10 for 10%)
 		taxAmount := lineReq.UnitPrice * (lineReq.TaxRate / 100)
@@ -70,47 +174,33 @@ func (u *usecase) Create(ctx context.Context, req *dto.CreateInvoiceRequest) (*i
 		lineTotalTax := lineReq.Quantity * taxAmount
 
 		line := invoice.InvoiceLine{
-			ID:          uuid.New(),
-			InvoiceID:   newInvoice.ID,
-			ItemID:      itemID,
-			Description: lineReq.Description,
-			Quantity:    lineReq.Quantity,
-			UnitPrice:   lineReq.UnitPrice,
-			UnitCost:    item.CostPrice,
-			TaxRate:     lineReq.TaxRate,
-			TaxAmount:   taxAmount,
-			NetPrice:    netPrice,
-			TotalAmount: lineTotalAmount,
-			TotalCost:   lineReq.Quantity * item.CostPrice,
-			CreatedBy:   userID,
-			UpdatedBy:   userID,
+			ID:            uuid.New(),
+			InvoiceID:     inv.ID,
+			ItemID:        itemID,
+			Description:   lineReq.Description,
+			Quantity:      lineReq.Quantity,
+			UnitOfMeasure: lineReq.UnitOfMeasure,
+			BaseQuantity:  baseQuantity,
+			UnitPrice:     lineReq.UnitPrice,
+			UnitCost:      unitCost,
+			TaxRate:       lineReq.TaxRate,
+			TaxAmount:     taxAmount,
+			NetPrice:      netPrice,
+			TotalAmount:   lineTotalAmount,
+			TotalCost:     lineReq.Quantity * unitCost,
+			CreatedBy:     userID,
+			UpdatedBy:     userID,
 		}
 		totalAmount += line.TotalAmount
 		totalCost += line.TotalCost
 		totalTax += lineTotalTax
-		newInvoice.Lines = append(newInvoice.Lines, line)
+		inv.Lines = append(inv.Lines, line)
 	}
 
-	newInvoice.TotalAmount = totalAmount
-	newInvoice.TotalCost = totalCost
-	newInvoice.TotalTax = totalTax
-
-	newInvoice.SetCreatedBy(userID)
-	newInvoice.SetUpdatedBy(userID)
-
-	err := u.invoiceRepo.Create(ctx, newInvoice)
-	if err != nil {
-		return nil, err
-	}
-
-	// Send email
-	go func() {
-		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		u.emailSender.Send(emailCtx, "test@example.com", "New Invoice Created", fmt.Sprintf("Invoice %s has been created.", newInvoice.Number))
-	}()
-
-	return newInvoice, nil
+	inv.TotalAmount = totalAmount
+	inv.TotalCost = totalCost
+	inv.TotalTax = totalTax
+	return nil
 }
 
 func (u *usecase) GetByID(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
@@ -126,7 +216,7 @@ func (u *usecase) QueueInvoicePDFGeneration(ctx context.Context, invoiceID uuid.
 
 	// 2. Update status to processing
 	inv.PDFStatus = "processing"
-	if err := u.invoiceRepo.Update(ctx, inv); err != nil {
+	if err := u.invoiceRepo.UpdatePDFStatus(ctx, inv); err != nil {
 		return fmt.Errorf("failed to update invoice status: %w", err)
 	}
 
@@ -140,7 +230,7 @@ func (u *usecase) QueueInvoicePDFGeneration(ctx context.Context, invoiceID uuid.
 		// If submission fails, try to revert status or mark as failed
 		inv.PDFStatus = "failed"
 		inv.PDFErrorMessage = err.Error()
-		_ = u.invoiceRepo.Update(ctx, inv)
+		_ = u.invoiceRepo.UpdatePDFStatus(ctx, inv)
 		return fmt.Errorf("failed to submit PDF task: %w", err)
 	}
 
@@ -210,6 +300,7 @@ func (u *usecase) GetPDFPath(ctx context.Context, id uuid.UUID) (string, error)
 	return inv.PDFUrl, nil
 }
 
+// Delete deletes a draft invoice. Validated invoices are immutable and can only be cancelled.
 func (u *usecase) Delete(ctx context.Context, id uuid.UUID) error {
 	userID, _ := domain.UserIDFromContext(ctx)
 	
@@ -217,6 +308,9 @@ func (u *usecase) Delete(ctx context.Context, id uuid.UUID) error {
 	if err != nil {
 		return err
 	}
+	if !oldInvoice.IsDraft() {
+		return invoice.ErrInvoiceNotDraft
+	}
 
 	if err := u.invoiceRepo.Delete(ctx, id); err != nil {
 		return err
@@ -227,3 +321,174 @@ func (u *usecase) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// Update replaces the header and the lines of a draft invoice. Validated invoices are immutable.
+func (u *usecase) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateInvoiceRequest) (*invoice.Invoice, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	thirdPartyID, _ := uuid.Parse(req.ThirdPartyID)
+	invoiceDate, _ := time.Parse("2006-01-02", req.Date)
+
+	var inv *invoice.Invoice
+	var oldValues map[string]interface{}
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txInvoiceRepo := u.invoiceRepo.WithTx(tx)
+
+		var err error
+		inv, err = txInvoiceRepo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !inv.IsDraft() {
+			return invoice.ErrInvoiceNotDraft
+		}
+		if inv.IsCreditNote() {
+			// The lines of a credit note come from its invoice; a draft is deleted and created again
+			return invoice.ErrInvalidDocumentType
+		}
+		oldValues = map[string]interface{}{"third_party_id": inv.ThirdPartyID, "date": inv.Date, "due_date": inv.DueDate, "total_amount": inv.TotalAmount, "lines": len(inv.Lines)}
+
+		terms := req.PaymentTermDays
+		if terms == nil && thirdPartyID == inv.ThirdPartyID {
+			terms = &inv.PaymentTermDays
+		}
+		paymentTermDays, err := u.paymentTermDays(ctx, thirdPartyID, terms)
+		if err != nil {
+			return err
+		}
+		inv.ThirdPartyID = thirdPartyID
+		inv.Date = invoiceDate
+		inv.SetPaymentTerms(paymentTermDays)
+		if err := u.setLines(ctx, inv, req.Lines, userID); err != nil {
+			return err
+		}
+		inv.SetUpdatedBy(userID)
+		return txInvoiceRepo.Update(ctx, inv)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "invoice", id.String(), "UPDATE", oldValues,
+		map[string]interface{}{"third_party_id": inv.ThirdPartyID, "date": inv.Date, "due_date": inv.DueDate, "total_amount": inv.TotalAmount, "lines": len(inv.Lines)},
+		corrID)
+	return inv, nil
+}
+
+// Validate gives a draft the next number of the sequence of its document type for its date and
+// locks its lines. The number is allocated in the validation transaction, so a failed validation
+// leaves no gap. A credit note is deducted from its invoice and returns its goods to stock in
+// the same transaction, see applyCreditNote.
+func (u *usecase) Validate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var credited *invoice.Invoice
+	var creditedOld map[string]interface{}
+	inv, err := u.transition(ctx, id, "VALIDATE", func(tx *gorm.DB, inv *invoice.Invoice) error {
+		if !inv.IsDraft() {
+			return invoice.ErrInvalidInvoiceStatus
+		}
+		sequence := numbering.DocumentInvoice
+		if inv.IsCreditNote() {
+			sequence = numbering.DocumentCreditNote
+		}
+		number, err := numbering_uc.Allocate(ctx, u.sequenceRepo.WithTx(tx), sequence, inv.Date)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := inv.Validate(number, now, userID); err != nil {
+			return err
+		}
+		if inv.IsCreditNote() {
+			credited, creditedOld, err = u.applyCreditNote(ctx, tx, inv, now, userID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if credited != nil {
+		corrID, _ := middleware.FromContext(ctx)
+		newValues := invoiceState(credited)
+		newValues["credit_note_id"] = inv.ID
+		u.auditService.Log(ctx, userID, "invoice", credited.ID.String(), "CREDIT", creditedOld, newValues, corrID)
+	}
+	return inv, nil
+}
+
+// Send marks a validated invoice or credit note as sent and emails the customer, who must have an
+// email address.
+func (u *usecase) Send(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	var to string
+	inv, err := u.transition(ctx, id, "SEND", func(tx *gorm.DB, inv *invoice.Invoice) error {
+		tp, err := u.thirdPartyRepo.GetByID(ctx, inv.ThirdPartyID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrThirdPartyNotFound
+			}
+			return err
+		}
+		if tp.Email == "" {
+			return fmt.Errorf("%w: %s", invoice.ErrCustomerEmailRequired, tp.Name)
+		}
+		to = tp.Email
+		return inv.MarkSent(time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		u.emailSender.Send(emailCtx, to, fmt.Sprintf("%s %s", documentTitle(inv), inv.Number), fmt.Sprintf("%s %s has been issued.", documentTitle(inv), inv.Number))
+	}()
+	return inv, nil
+}
+
+// Cancel cancels an invoice nothing was paid on, keeping it and its number on record.
+func (u *usecase) Cancel(ctx context.Context, id uuid.UUID, reason string) (*invoice.Invoice, error) {
+	return u.transition(ctx, id, "CANCEL", func(tx *gorm.DB, inv *invoice.Invoice) error {
+		return inv.Cancel(reason, time.Now())
+	})
+}
+
+// transition applies a lifecycle change to an invoice locked for update and audits it.
+func (u *usecase) transition(ctx context.Context, id uuid.UUID, action string, apply func(tx *gorm.DB, inv *invoice.Invoice) error) (*invoice.Invoice, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var inv *invoice.Invoice
+	var oldValues map[string]interface{}
+	err := u.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txInvoiceRepo := u.invoiceRepo.WithTx(tx)
+
+		var err error
+		inv, err = txInvoiceRepo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		oldValues = invoiceState(inv)
+		if err := apply(tx, inv); err != nil {
+			return err
+		}
+		inv.SetUpdatedBy(userID)
+		return txInvoiceRepo.Update(ctx, inv)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	u.auditService.Log(ctx, userID, "invoice", id.String(), action, oldValues, invoiceState(inv), corrID)
+	return inv, nil
+}
+
+// invoiceState is the audited lifecycle state of an invoice.
+func invoiceState(inv *invoice.Invoice) map[string]interface{} {
+	state := map[string]interface{}{"status": inv.Status, "number": inv.Number, "amount_paid": inv.AmountPaid, "amount_credited": inv.AmountCredited}
+	if inv.CancellationReason != "" {
+		state["cancellation_reason"] = inv.CancellationReason
+	}
+	return state
+}
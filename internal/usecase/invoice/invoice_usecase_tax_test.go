@@ -7,10 +7,12 @@ import (
 	"doligo_001/internal/api/dto"
 	domain_invoice "doligo_001/internal/domain/invoice"
 	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/thirdparty"
 	uc_invoice "doligo_001/internal/usecase/invoice"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // Mocks
@@ -19,6 +21,8 @@ type MockInvoiceRepo struct {
 	mock.Mock
 }
 
+func (m *MockInvoiceRepo) WithTx(tx *gorm.DB) uc_invoice.Repository { return m }
+
 func (m *MockInvoiceRepo) Create(ctx context.Context, inv *domain_invoice.Invoice) error {
 	args := m.Called(ctx, inv)
 	return args.Error(0)
@@ -45,6 +49,23 @@ func (m *MockInvoiceRepo) FindByIDWithDetails(ctx context.Context, id uuid.UUID)
 	return args.Get(0).(*domain_invoice.Invoice), args.Error(1)
 }
 
+func (m *MockInvoiceRepo) UpdatePDFStatus(ctx context.Context, inv *domain_invoice.Invoice) error {
+	return nil
+}
+func (m *MockInvoiceRepo) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (m *MockInvoiceRepo) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain_invoice.Invoice, error) {
+	return m.FindByID(ctx, id)
+}
+func (m *MockInvoiceRepo) ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*domain_invoice.Invoice, error) {
+	return nil, nil
+}
+func (m *MockInvoiceRepo) ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*domain_invoice.Invoice, error) {
+	return nil, nil
+}
+func (m *MockInvoiceRepo) CreditedQuantities(ctx context.Context, invoiceID uuid.UUID) (map[uuid.UUID]float64, error) {
+	return nil, nil
+}
+
 type MockItemRepo struct {
 	mock.Mock
 }
@@ -58,11 +79,25 @@ func (m *MockItemRepo) GetByID(ctx context.Context, id uuid.UUID) (*item.Item, e
 }
 
 // Add other ItemRepo methods if needed, stubbing them for now
+func (m *MockItemRepo) WithTx(tx *gorm.DB) item.Repository { return m }
 func (m *MockItemRepo) Create(ctx context.Context, i *item.Item) error { return nil }
 func (m *MockItemRepo) Update(ctx context.Context, i *item.Item) error { return nil }
-func (m *MockItemRepo) List(ctx context.Context, limit, offset int) ([]*item.Item, error) { return nil, nil }
+func (m *MockItemRepo) List(ctx context.Context) ([]*item.Item, error) { return nil, nil }
 func (m *MockItemRepo) Delete(ctx context.Context, id uuid.UUID) error { return nil }
 
+// MockThirdPartyRepo returns every customer with the default payment term.
+type MockThirdPartyRepo struct{}
+
+func (MockThirdPartyRepo) GetByID(ctx context.Context, id uuid.UUID) (*thirdparty.ThirdParty, error) {
+	return &thirdparty.ThirdParty{ID: id, PaymentTermDays: thirdparty.DefaultPaymentTermDays}, nil
+}
+func (MockThirdPartyRepo) Create(ctx context.Context, tp *thirdparty.ThirdParty) error { return nil }
+func (MockThirdPartyRepo) Update(ctx context.Context, tp *thirdparty.ThirdParty) error { return nil }
+func (MockThirdPartyRepo) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (MockThirdPartyRepo) List(ctx context.Context) ([]*thirdparty.ThirdParty, error) {
+	return nil, nil
+}
+
 type MockPDFGen struct {
 	mock.Mock
 }
@@ -80,6 +115,11 @@ func (m *MockEmailSender) Send(ctx context.Context, to, subject, body string) er
 	return nil
 }
 
+type MockAuditService struct{}
+
+func (MockAuditService) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
 // Tests
 
 func TestCreateInvoice_TaxCalculation(t *testing.T) {
@@ -89,7 +129,8 @@ func TestCreateInvoice_TaxCalculation(t *testing.T) {
 	mockPDFGen := new(MockPDFGen)
 	mockEmailSender := new(MockEmailSender)
 
-	usecase := uc_invoice.NewUsecase(mockInvoiceRepo, mockItemRepo, mockPDFGen, mockEmailSender, nil, "storage/pdfs")
+	usecase := uc_invoice.NewUsecase(nil, mockInvoiceRepo, MockThirdPartyRepo{}, nil, mockItemRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+		mockPDFGen, mockEmailSender, nil, MockAuditService{}, "storage/pdfs")
 
 	ctx := context.Background()
 	thirdPartyID := uuid.New().String()
@@ -97,7 +138,6 @@ func TestCreateInvoice_TaxCalculation(t *testing.T) {
 	
 	req := &dto.CreateInvoiceRequest{
 		ThirdPartyID: thirdPartyID,
-		Number:       "INV-001",
 		Date:         "2023-10-27",
 		Lines: []dto.CreateInvoiceLineRequest{
 			{
@@ -0,0 +1,429 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"doligo_001/internal/api/dto"
+	"doligo_001/internal/domain/invoice"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/numbering"
+	"doligo_001/internal/domain/thirdparty"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeInvoiceRepository is an in-memory invoice Repository.
+type fakeInvoiceRepository struct {
+	invoices map[uuid.UUID]*invoice.Invoice
+}
+
+func (f *fakeInvoiceRepository) WithTx(tx *gorm.DB) Repository { return f }
+func (f *fakeInvoiceRepository) Create(ctx context.Context, inv *invoice.Invoice) error {
+	f.invoices[inv.ID] = copyInvoice(inv)
+	return nil
+}
+func (f *fakeInvoiceRepository) Update(ctx context.Context, inv *invoice.Invoice) error {
+	f.invoices[inv.ID] = copyInvoice(inv)
+	return nil
+}
+func (f *fakeInvoiceRepository) UpdatePDFStatus(ctx context.Context, inv *invoice.Invoice) error {
+	return nil
+}
+func (f *fakeInvoiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.invoices, id)
+	return nil
+}
+func (f *fakeInvoiceRepository) FindByID(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	inv, ok := f.invoices[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return copyInvoice(inv), nil
+}
+func (f *fakeInvoiceRepository) FindByIDWithDetails(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	return f.FindByID(ctx, id)
+}
+func (f *fakeInvoiceRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	return f.FindByID(ctx, id)
+}
+func (f *fakeInvoiceRepository) ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error) {
+	var list []*invoice.Invoice
+	for _, inv := range f.invoices {
+		if inv.OriginalInvoiceID != nil && *inv.OriginalInvoiceID == invoiceID {
+			list = append(list, copyInvoice(inv))
+		}
+	}
+	return list, nil
+}
+func (f *fakeInvoiceRepository) ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*invoice.Invoice, error) {
+	return nil, nil
+}
+func (f *fakeInvoiceRepository) CreditedQuantities(ctx context.Context, invoiceID uuid.UUID) (map[uuid.UUID]float64, error) {
+	credited := make(map[uuid.UUID]float64)
+	for _, inv := range f.invoices {
+		if inv.OriginalInvoiceID == nil || *inv.OriginalInvoiceID != invoiceID ||
+			inv.Status == invoice.StatusDraft || inv.Status == invoice.StatusCancelled {
+			continue
+		}
+		for _, line := range inv.Lines {
+			credited[*line.OriginalLineID] += line.Quantity
+		}
+	}
+	return credited, nil
+}
+
+// copyInvoice copies an invoice and its lines, so that changes are only seen once saved.
+func copyInvoice(inv *invoice.Invoice) *invoice.Invoice {
+	copied := *inv
+	copied.Lines = append([]invoice.InvoiceLine(nil), inv.Lines...)
+	return &copied
+}
+
+// fakeSequenceRepository is an in-memory numbering.Repository.
+type fakeSequenceRepository struct {
+	sequences map[numbering.DocumentType]*numbering.Sequence
+	counters  map[string]*numbering.Counter
+}
+
+func (f *fakeSequenceRepository) WithTx(tx *gorm.DB) numbering.Repository { return f }
+func (f *fakeSequenceRepository) List(ctx context.Context) ([]*numbering.Sequence, error) {
+	return nil, nil
+}
+func (f *fakeSequenceRepository) GetByDocumentType(ctx context.Context, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	s, ok := f.sequences[documentType]
+	if !ok {
+		return nil, numbering.ErrSequenceNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+func (f *fakeSequenceRepository) GetByDocumentTypeForUpdate(ctx context.Context, documentType numbering.DocumentType) (*numbering.Sequence, error) {
+	return f.GetByDocumentType(ctx, documentType)
+}
+func (f *fakeSequenceRepository) Update(ctx context.Context, sequence *numbering.Sequence) error {
+	f.sequences[sequence.DocumentType] = sequence
+	return nil
+}
+func (f *fakeSequenceRepository) GetCounter(ctx context.Context, sequenceID uuid.UUID, period string) (*numbering.Counter, error) {
+	c, ok := f.counters[sequenceID.String()+"/"+period]
+	if !ok {
+		return nil, nil
+	}
+	copied := *c
+	return &copied, nil
+}
+func (f *fakeSequenceRepository) SaveCounter(ctx context.Context, counter *numbering.Counter) error {
+	if counter.ID == uuid.Nil {
+		counter.ID = uuid.New()
+	}
+	copied := *counter
+	f.counters[counter.SequenceID.String()+"/"+counter.Period] = &copied
+	return nil
+}
+
+// fakeItemRepository is an in-memory item.Repository.
+type fakeItemRepository struct {
+	items map[uuid.UUID]*item.Item
+}
+
+func (f *fakeItemRepository) WithTx(tx *gorm.DB) item.Repository { return f }
+func (f *fakeItemRepository) Create(ctx context.Context, it *item.Item) error {
+	f.items[it.ID] = it
+	return nil
+}
+func (f *fakeItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*item.Item, error) {
+	it, ok := f.items[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *it
+	return &copied, nil
+}
+func (f *fakeItemRepository) Update(ctx context.Context, it *item.Item) error {
+	f.items[it.ID] = it
+	return nil
+}
+func (f *fakeItemRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeItemRepository) List(ctx context.Context) ([]*item.Item, error) { return nil, nil }
+
+// fakeThirdPartyRepository is an in-memory thirdparty.Repository.
+type fakeThirdPartyRepository struct {
+	thirdParties map[uuid.UUID]*thirdparty.ThirdParty
+}
+
+func (f *fakeThirdPartyRepository) Create(ctx context.Context, tp *thirdparty.ThirdParty) error {
+	f.thirdParties[tp.ID] = tp
+	return nil
+}
+func (f *fakeThirdPartyRepository) GetByID(ctx context.Context, id uuid.UUID) (*thirdparty.ThirdParty, error) {
+	tp, ok := f.thirdParties[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return tp, nil
+}
+func (f *fakeThirdPartyRepository) Update(ctx context.Context, tp *thirdparty.ThirdParty) error {
+	return nil
+}
+func (f *fakeThirdPartyRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeThirdPartyRepository) List(ctx context.Context) ([]*thirdparty.ThirdParty, error) {
+	return nil, nil
+}
+
+type fakeEmailSender struct{}
+
+func (fakeEmailSender) Send(ctx context.Context, to, subject, body string) error { return nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return fc(nil)
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
+// invoiceFixture wires the invoice usecase to in-memory repositories, with a customer, a
+// service item and the invoice and credit note sequences.
+type invoiceFixture struct {
+	invoices     *fakeInvoiceRepository
+	sequences    *fakeSequenceRepository
+	items        *fakeItemRepository
+	thirdParties *fakeThirdPartyRepository
+	customerID   uuid.UUID
+	serviceID    uuid.UUID
+	usecase      *usecase
+}
+
+func newInvoiceFixture() *invoiceFixture {
+	f := &invoiceFixture{
+		invoices: &fakeInvoiceRepository{invoices: make(map[uuid.UUID]*invoice.Invoice)},
+		sequences: &fakeSequenceRepository{
+			sequences: map[numbering.DocumentType]*numbering.Sequence{
+				numbering.DocumentInvoice:    {ID: uuid.New(), DocumentType: numbering.DocumentInvoice, Pattern: "INV-{YYYY}-{0000}", Reset: numbering.ResetYearly},
+				numbering.DocumentCreditNote: {ID: uuid.New(), DocumentType: numbering.DocumentCreditNote, Pattern: "CN-{YYYY}-{0000}", Reset: numbering.ResetYearly},
+			},
+			counters: make(map[string]*numbering.Counter),
+		},
+		items:      &fakeItemRepository{items: make(map[uuid.UUID]*item.Item)},
+		customerID: uuid.New(),
+		serviceID:  uuid.New(),
+	}
+	f.items.items[f.serviceID] = &item.Item{ID: f.serviceID, Name: "Consulting", Type: item.Service, CostPrice: 40}
+	f.thirdParties = &fakeThirdPartyRepository{thirdParties: map[uuid.UUID]*thirdparty.ThirdParty{
+		f.customerID: {ID: f.customerID, Name: "Customer", Email: "customer@example.com", Type: thirdparty.Customer, PaymentTermDays: 30},
+	}}
+	f.usecase = NewUsecase(fakeTx{}, f.invoices, f.thirdParties, f.sequences, f.items, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, fakeEmailSender{}, nil, fakeAudit{}, "").(*usecase)
+	return f
+}
+
+// addInvoice registers an invoice of 100 for one unit of the service item in status.
+func (f *invoiceFixture) addInvoice(status invoice.Status, amountPaid float64) uuid.UUID {
+	id := uuid.New()
+	inv := &invoice.Invoice{
+		ID:           id,
+		ThirdPartyID: f.customerID,
+		DocumentType: invoice.TypeStandard,
+		Number:       "INV-" + id.String()[:8],
+		Date:         time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		Status:       status,
+		AmountPaid:   amountPaid,
+		TotalAmount:  100,
+		TotalCost:    40,
+		Lines: []invoice.InvoiceLine{{
+			ID: uuid.New(), InvoiceID: id, ItemID: f.serviceID, Description: "Consulting",
+			Quantity: 1, BaseQuantity: 1, UnitPrice: 100, UnitCost: 40, NetPrice: 100, TotalAmount: 100, TotalCost: 40,
+		}},
+	}
+	if status == invoice.StatusDraft {
+		inv.Number = invoice.ProvisionalNumber(id)
+	}
+	f.invoices.invoices[id] = inv
+	return id
+}
+
+func (f *invoiceFixture) lineRequest() []dto.CreateInvoiceLineRequest {
+	return []dto.CreateInvoiceLineRequest{{ItemID: f.serviceID.String(), Description: "Consulting", Quantity: 2, UnitPrice: 100}}
+}
+
+func TestInvoiceUsecase_Create_ProvisionalNumber(t *testing.T) {
+	f := newInvoiceFixture()
+
+	inv, err := f.usecase.Create(context.Background(), &dto.CreateInvoiceRequest{
+		ThirdPartyID: f.customerID.String(), Date: "2026-03-10", Lines: f.lineRequest(),
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if inv.Status != invoice.StatusDraft {
+		t.Errorf("status = %s, want DRAFT", inv.Status)
+	}
+	if want := "PROV-" + inv.ID.String(); inv.Number != want || invoice.ProvisionalNumber(inv.ID) != want {
+		t.Errorf("number = %q, want %q", inv.Number, want)
+	}
+	if _, err := uuid.Parse(strings.TrimPrefix(inv.Number, invoice.ProvisionalNumberPrefix)); err != nil {
+		t.Errorf("provisional number %q does not end with the invoice ID: %v", inv.Number, err)
+	}
+
+	// A draft cannot be validated under its provisional number
+	if err := inv.Validate(inv.Number, time.Now(), uuid.New()); !errors.Is(err, invoice.ErrInvoiceNumberRequired) {
+		t.Errorf("Validate(provisional number) error = %v, want %v", err, invoice.ErrInvoiceNumberRequired)
+	}
+}
+
+func TestInvoiceUsecase_Transitions(t *testing.T) {
+	ctx := context.Background()
+	validate := func(uc *usecase, id uuid.UUID) (*invoice.Invoice, error) { return uc.Validate(ctx, id) }
+	send := func(uc *usecase, id uuid.UUID) (*invoice.Invoice, error) { return uc.Send(ctx, id) }
+	cancel := func(uc *usecase, id uuid.UUID) (*invoice.Invoice, error) { return uc.Cancel(ctx, id, "Ordered twice") }
+
+	tests := []struct {
+		name       string
+		status     invoice.Status
+		amountPaid float64
+		action     func(uc *usecase, id uuid.UUID) (*invoice.Invoice, error)
+		wantStatus invoice.Status
+		wantErr    error
+	}{
+		{"validate draft", invoice.StatusDraft, 0, validate, invoice.StatusValidated, nil},
+		{"validate validated", invoice.StatusValidated, 0, validate, invoice.StatusValidated, invoice.ErrInvalidInvoiceStatus},
+		{"validate sent", invoice.StatusSent, 0, validate, invoice.StatusSent, invoice.ErrInvalidInvoiceStatus},
+		{"validate cancelled", invoice.StatusCancelled, 0, validate, invoice.StatusCancelled, invoice.ErrInvalidInvoiceStatus},
+		{"send validated", invoice.StatusValidated, 0, send, invoice.StatusSent, nil},
+		{"send draft", invoice.StatusDraft, 0, send, invoice.StatusDraft, invoice.ErrInvalidInvoiceStatus},
+		{"send sent", invoice.StatusSent, 0, send, invoice.StatusSent, invoice.ErrInvalidInvoiceStatus},
+		{"send partially paid", invoice.StatusPartiallyPaid, 40, send, invoice.StatusPartiallyPaid, invoice.ErrInvalidInvoiceStatus},
+		{"send paid", invoice.StatusPaid, 100, send, invoice.StatusPaid, invoice.ErrInvalidInvoiceStatus},
+		{"cancel draft", invoice.StatusDraft, 0, cancel, invoice.StatusCancelled, nil},
+		{"cancel validated", invoice.StatusValidated, 0, cancel, invoice.StatusCancelled, nil},
+		{"cancel sent", invoice.StatusSent, 0, cancel, invoice.StatusCancelled, nil},
+		{"cancel partially paid", invoice.StatusPartiallyPaid, 40, cancel, invoice.StatusPartiallyPaid, invoice.ErrInvalidInvoiceStatus},
+		{"cancel paid", invoice.StatusPaid, 100, cancel, invoice.StatusPaid, invoice.ErrInvalidInvoiceStatus},
+		{"cancel cancelled", invoice.StatusCancelled, 0, cancel, invoice.StatusCancelled, invoice.ErrInvalidInvoiceStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newInvoiceFixture()
+			id := f.addInvoice(tt.status, tt.amountPaid)
+
+			_, err := tt.action(f.usecase, id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got := f.invoices.invoices[id].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestInvoiceUsecase_Send_RequiresCustomerEmail(t *testing.T) {
+	f := newInvoiceFixture()
+	f.thirdParties.thirdParties[f.customerID].Email = ""
+	id := f.addInvoice(invoice.StatusValidated, 0)
+
+	if _, err := f.usecase.Send(context.Background(), id); !errors.Is(err, invoice.ErrCustomerEmailRequired) {
+		t.Fatalf("Send() error = %v, want %v", err, invoice.ErrCustomerEmailRequired)
+	}
+	if got := f.invoices.invoices[id].Status; got != invoice.StatusValidated {
+		t.Errorf("status = %s, want %s", got, invoice.StatusValidated)
+	}
+}
+
+func TestInvoiceUsecase_Validate_AssignsNextNumber(t *testing.T) {
+	f := newInvoiceFixture()
+	first, second := f.addInvoice(invoice.StatusDraft, 0), f.addInvoice(invoice.StatusDraft, 0)
+
+	for i, id := range []uuid.UUID{first, second} {
+		inv, err := f.usecase.Validate(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		want := []string{"INV-2026-0001", "INV-2026-0002"}[i]
+		if inv.Number != want || inv.ValidatedAt == nil {
+			t.Errorf("validated invoice = %q at %v, want %q with a validation time", inv.Number, inv.ValidatedAt, want)
+		}
+	}
+}
+
+func TestInvoice_ApplyPayment(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     invoice.Status
+		amountPaid float64
+		amount     float64
+		wantStatus invoice.Status
+		wantErr    error
+	}{
+		{"partial payment of a validated invoice", invoice.StatusValidated, 0, 40, invoice.StatusPartiallyPaid, nil},
+		{"full payment of a sent invoice", invoice.StatusSent, 0, 100, invoice.StatusPaid, nil},
+		{"balance of a partially paid invoice", invoice.StatusPartiallyPaid, 40, 60, invoice.StatusPaid, nil},
+		{"second partial payment", invoice.StatusPartiallyPaid, 40, 20, invoice.StatusPartiallyPaid, nil},
+		{"payment of a draft", invoice.StatusDraft, 0, 40, invoice.StatusDraft, invoice.ErrInvalidInvoiceStatus},
+		{"payment of a paid invoice", invoice.StatusPaid, 100, 10, invoice.StatusPaid, invoice.ErrInvalidInvoiceStatus},
+		{"payment of a cancelled invoice", invoice.StatusCancelled, 0, 40, invoice.StatusCancelled, invoice.ErrInvalidInvoiceStatus},
+		{"payment above the balance due", invoice.StatusPartiallyPaid, 40, 61, invoice.StatusPartiallyPaid, invoice.ErrInvalidPaymentAmount},
+		{"zero payment", invoice.StatusValidated, 0, 0, invoice.StatusValidated, invoice.ErrInvalidPaymentAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := &invoice.Invoice{DocumentType: invoice.TypeStandard, Status: tt.status, AmountPaid: tt.amountPaid, TotalAmount: 100}
+
+			if err := inv.ApplyPayment(tt.amount); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ApplyPayment(%v) error = %v, want %v", tt.amount, err, tt.wantErr)
+			}
+			if inv.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", inv.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestInvoiceUsecase_UpdateAndDelete_DraftOnly(t *testing.T) {
+	statuses := []struct {
+		status  invoice.Status
+		wantErr error
+	}{
+		{invoice.StatusDraft, nil},
+		{invoice.StatusValidated, invoice.ErrInvoiceNotDraft},
+		{invoice.StatusSent, invoice.ErrInvoiceNotDraft},
+		{invoice.StatusPartiallyPaid, invoice.ErrInvoiceNotDraft},
+		{invoice.StatusPaid, invoice.ErrInvoiceNotDraft},
+		{invoice.StatusCancelled, invoice.ErrInvoiceNotDraft},
+	}
+	for _, tt := range statuses {
+		t.Run(string(tt.status), func(t *testing.T) {
+			f := newInvoiceFixture()
+			id := f.addInvoice(tt.status, 0)
+
+			_, err := f.usecase.Update(context.Background(), id, &dto.UpdateInvoiceRequest{
+				ThirdPartyID: f.customerID.String(), Date: "2026-03-12", Lines: f.lineRequest(),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			wantTotal := 100.0
+			if tt.wantErr == nil {
+				wantTotal = 200
+			}
+			if got := f.invoices.invoices[id].TotalAmount; got != wantTotal {
+				t.Errorf("total after Update() = %v, want %v", got, wantTotal)
+			}
+
+			err = f.usecase.Delete(context.Background(), id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, kept := f.invoices.invoices[id]; kept != (tt.wantErr != nil) {
+				t.Errorf("invoice kept after Delete() = %v, want %v", kept, tt.wantErr != nil)
+			}
+		})
+	}
+}
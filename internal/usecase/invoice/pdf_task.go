@@ -27,7 +27,7 @@ func (t *InvoicePDFTask) Execute(ctx context.Context) error {
 		// Update status to failed
 		inv.PDFStatus = "failed"
 		inv.PDFErrorMessage = err.Error()
-		_ = t.Usecase.invoiceRepo.Update(ctx, inv)
+		_ = t.Usecase.invoiceRepo.UpdatePDFStatus(ctx, inv)
 		return fmt.Errorf("failed to generate PDF for invoice %s: %w", t.InvoiceID, err)
 	}
 
@@ -37,7 +37,11 @@ func (t *InvoicePDFTask) Execute(ctx context.Context) error {
 		return fmt.Errorf("failed to create storage directory: %w", err)
 	}
 
-	filename := fmt.Sprintf("invoice-%s.pdf", inv.Number)
+	prefix := "invoice"
+	if inv.IsCreditNote() {
+		prefix = "credit-note"
+	}
+	filename := fmt.Sprintf("%s-%s.pdf", prefix, inv.Number)
 	filePath := filepath.Join(storageDir, filename)
 	if err := os.WriteFile(filePath, pdfBytes, 0644); err != nil {
 		return fmt.Errorf("failed to write PDF file: %w", err)
@@ -46,7 +50,7 @@ func (t *InvoicePDFTask) Execute(ctx context.Context) error {
 	// 4. Update Invoice status and URL
 	inv.PDFStatus = "completed"
 	inv.PDFUrl = filePath
-	if err := t.Usecase.invoiceRepo.Update(ctx, inv); err != nil {
+	if err := t.Usecase.invoiceRepo.UpdatePDFStatus(ctx, inv); err != nil {
 		return fmt.Errorf("failed to update invoice status: %w", err)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"doligo_001/internal/api/middleware"
 	"doligo_001/internal/domain"
 	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/uom"
 	uc "doligo_001/internal/usecase"
 	"github.com/google/uuid"
 )
@@ -22,13 +23,15 @@ type Usecase interface {
 
 type usecase struct {
 	repo         item.Repository
+	unitRepo     uom.Repository
 	auditService uc.AuditService
 }
 
 // NewUsecase creates a new item usecase.
-func NewUsecase(repo item.Repository, auditService uc.AuditService) Usecase {
+func NewUsecase(repo item.Repository, unitRepo uom.Repository, auditService uc.AuditService) Usecase {
 	return &usecase{
 		repo:         repo,
+		unitRepo:     unitRepo,
 		auditService: auditService,
 	}
 }
@@ -37,14 +40,31 @@ func NewUsecase(repo item.Repository, auditService uc.AuditService) Usecase {
 func (u *usecase) Create(ctx context.Context, req *dto.CreateItemRequest) (*item.Item, error) {
 	userID, _ := domain.UserIDFromContext(ctx)
 
+	tracking, err := trackingMode(item.ItemType(req.Type), req.TrackingMode)
+	if err != nil {
+		return nil, err
+	}
+	costing, err := costingMethod(item.ItemType(req.Type), req.CostingMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.validateBaseUnit(ctx, "", req.BaseUnit); err != nil {
+		return nil, err
+	}
+
 	i := &item.Item{
-		ID:          uuid.New(),
-		Name:        req.Name,
-		Description: req.Description,
-		Type:        item.ItemType(req.Type),
-		CostPrice:   req.CostPrice,
-		SalePrice:   req.SalePrice,
-		IsActive:    true,
+		ID:            uuid.New(),
+		Name:          req.Name,
+		Description:   req.Description,
+		Type:          item.ItemType(req.Type),
+		CostPrice:     req.CostPrice,
+		SalePrice:     req.SalePrice,
+		StandardCost:  req.StandardCost,
+		CostingMethod: costing,
+		TrackingMode:  tracking,
+		BaseUnit:      req.BaseUnit,
+		LeadTimeDays:  req.LeadTimeDays,
+		IsActive:      true,
 	}
 	i.SetCreatedBy(userID)
 	i.SetUpdatedBy(userID)
@@ -75,12 +95,29 @@ func (u *usecase) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateItemR
 	// Create a shallow copy for audit
 	oldValues := *oldItem
 
+	tracking, err := trackingMode(item.ItemType(req.Type), req.TrackingMode)
+	if err != nil {
+		return nil, err
+	}
+	costing, err := costingMethod(item.ItemType(req.Type), req.CostingMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.validateBaseUnit(ctx, oldItem.BaseUnit, req.BaseUnit); err != nil {
+		return nil, err
+	}
+
 	i := oldItem
 	i.Name = req.Name
 	i.Description = req.Description
 	i.Type = item.ItemType(req.Type)
 	i.CostPrice = req.CostPrice
 	i.SalePrice = req.SalePrice
+	i.StandardCost = req.StandardCost
+	i.CostingMethod = costing
+	i.TrackingMode = tracking
+	i.BaseUnit = req.BaseUnit
+	i.LeadTimeDays = req.LeadTimeDays
 	i.IsActive = req.IsActive
 	i.SetUpdatedBy(userID)
 
@@ -112,6 +149,45 @@ func (u *usecase) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// trackingMode resolves the requested tracking mode, defaulting to no tracking.
+// Only storable items can be tracked by lot or serial number.
+func trackingMode(itemType item.ItemType, requested string) (item.TrackingMode, error) {
+	mode := item.TrackingMode(requested)
+	if mode == "" {
+		mode = item.TrackingNone
+	}
+	if mode != item.TrackingNone && itemType != item.Storable {
+		return "", item.ErrTrackingRequiresStorable
+	}
+	return mode, nil
+}
+
+// costingMethod resolves the requested costing method, defaulting to the weighted average.
+// Only storable items can be valued by FIFO layers or at a standard cost.
+func costingMethod(itemType item.ItemType, requested string) (item.CostingMethod, error) {
+	method := item.CostingMethod(requested)
+	if method == "" {
+		method = item.CostingAverage
+	}
+	if method != item.CostingAverage && itemType != item.Storable {
+		return "", item.ErrCostingRequiresStorable
+	}
+	return method, nil
+}
+
+// validateBaseUnit checks that the requested base unit exists. The base unit can be set on an
+// item without one, which declares the unit its quantities were kept in, but never changed.
+func (u *usecase) validateBaseUnit(ctx context.Context, current, requested string) error {
+	if requested == current {
+		return nil
+	}
+	if current != "" {
+		return item.ErrBaseUnitChange
+	}
+	_, err := u.unitRepo.GetUnitByCode(ctx, requested)
+	return err
+}
+
 // List retrieves all items.
 func (u *usecase) List(ctx context.Context) ([]*item.Item, error) {
 	return u.repo.List(ctx)
@@ -0,0 +1,58 @@
+package margin
+
+import (
+	"context"
+	"time"
+
+	"doligo_001/internal/domain/margin"
+)
+
+// AgingUsecase defines the interface for the accounts receivable aging report.
+type AgingUsecase interface {
+	GetARAgingReport(ctx context.Context, asOf time.Time) (*margin.AgingReport, error)
+}
+
+type agingUsecase struct {
+	agingRepo margin.AgingRepository
+}
+
+// NewAgingUsecase creates a new instance of the aging usecase.
+func NewAgingUsecase(ar margin.AgingRepository) AgingUsecase {
+	return &agingUsecase{agingRepo: ar}
+}
+
+// GetARAgingReport ages the balances outstanding at the end of the asOf day.
+func (uc *agingUsecase) GetARAgingReport(ctx context.Context, asOf time.Time) (*margin.AgingReport, error) {
+	receivables, err := uc.agingRepo.ListReceivables(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return BuildAgingReport(asOf, receivables), nil
+}
+
+// BuildAgingReport buckets the receivables by the number of days between their due date and
+// asOf, per customer in the order of the receivables and overall.
+func BuildAgingReport(asOf time.Time, receivables []*margin.Receivable) *margin.AgingReport {
+	report := &margin.AgingReport{AsOf: asOf, Customers: []margin.CustomerAging{}}
+	index := make(map[string]int)
+	for _, r := range receivables {
+		i, ok := index[r.ThirdPartyID.String()]
+		if !ok {
+			i = len(report.Customers)
+			index[r.ThirdPartyID.String()] = i
+			report.Customers = append(report.Customers, margin.CustomerAging{ThirdPartyID: r.ThirdPartyID, ThirdPartyName: r.ThirdPartyName})
+		}
+		days := daysBetween(r.DueDate, asOf)
+		report.Customers[i].Invoices++
+		report.Customers[i].Add(r.Outstanding, days)
+		report.Totals.Add(r.Outstanding, days)
+	}
+	return report
+}
+
+// daysBetween counts the calendar days from from to to, ignoring the time of day.
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
@@ -0,0 +1,85 @@
+package margin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"doligo_001/internal/domain/margin"
+	"github.com/google/uuid"
+)
+
+// fakeAgingRepository is a simple fake for the margin.AgingRepository for testing.
+type fakeAgingRepository struct {
+	receivables []*margin.Receivable
+	err         error
+}
+
+func (f *fakeAgingRepository) ListReceivables(ctx context.Context, asOf time.Time) ([]*margin.Receivable, error) {
+	return f.receivables, f.err
+}
+
+func TestBuildAgingReport_BucketsByDaysOverdue(t *testing.T) {
+	asOf := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	acme, globex := uuid.New(), uuid.New()
+	due := func(daysAgo int) time.Time { return asOf.AddDate(0, 0, -daysAgo) }
+	receivables := []*margin.Receivable{
+		{ThirdPartyID: acme, ThirdPartyName: "Acme", DueDate: due(-10), Outstanding: 100}, // Not due yet
+		{ThirdPartyID: acme, ThirdPartyName: "Acme", DueDate: due(0), Outstanding: 10},    // Due today
+		{ThirdPartyID: acme, ThirdPartyName: "Acme", DueDate: due(1), Outstanding: 20},
+		{ThirdPartyID: acme, ThirdPartyName: "Acme", DueDate: due(30), Outstanding: 30},
+		{ThirdPartyID: globex, ThirdPartyName: "Globex", DueDate: due(31), Outstanding: 40},
+		{ThirdPartyID: globex, ThirdPartyName: "Globex", DueDate: due(90), Outstanding: 50},
+		{ThirdPartyID: globex, ThirdPartyName: "Globex", DueDate: due(91), Outstanding: 60},
+	}
+
+	report := BuildAgingReport(asOf, receivables)
+
+	if len(report.Customers) != 2 {
+		t.Fatalf("customers = %d, want 2", len(report.Customers))
+	}
+	wantAcme := margin.AgingBuckets{Current: 110, Days1To30: 50, Total: 160}
+	if got := report.Customers[0]; got.ThirdPartyID != acme || got.Invoices != 4 || got.AgingBuckets != wantAcme {
+		t.Errorf("Acme = %+v, want 4 invoices and %+v", got, wantAcme)
+	}
+	wantGlobex := margin.AgingBuckets{Days31To60: 40, Days61To90: 50, Over90: 60, Total: 150}
+	if got := report.Customers[1]; got.ThirdPartyID != globex || got.Invoices != 3 || got.AgingBuckets != wantGlobex {
+		t.Errorf("Globex = %+v, want 3 invoices and %+v", got, wantGlobex)
+	}
+	wantTotals := margin.AgingBuckets{Current: 110, Days1To30: 50, Days31To60: 40, Days61To90: 50, Over90: 60, Total: 310}
+	if report.Totals != wantTotals {
+		t.Errorf("totals = %+v, want %+v", report.Totals, wantTotals)
+	}
+}
+
+func TestBuildAgingReport_IgnoresTimeOfDay(t *testing.T) {
+	asOf := time.Date(2026, 6, 30, 23, 59, 0, 0, time.UTC)
+	receivables := []*margin.Receivable{
+		{ThirdPartyID: uuid.New(), DueDate: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), Outstanding: 10},
+		{ThirdPartyID: uuid.New(), DueDate: time.Date(2026, 6, 29, 0, 0, 0, 0, time.UTC), Outstanding: 20},
+	}
+
+	report := BuildAgingReport(asOf, receivables)
+
+	if report.Totals.Current != 10 || report.Totals.Days1To30 != 20 {
+		t.Errorf("totals = %+v, want current 10 and 1-30 days 20", report.Totals)
+	}
+}
+
+func TestAgingUsecase_GetARAgingReport(t *testing.T) {
+	asOf := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	empty, err := NewAgingUsecase(&fakeAgingRepository{}).GetARAgingReport(context.Background(), asOf)
+	if err != nil {
+		t.Fatalf("GetARAgingReport() error = %v", err)
+	}
+	if empty.Customers == nil || len(empty.Customers) != 0 || empty.Totals.Total != 0 {
+		t.Errorf("report = %+v, want no customers and a zero total", empty)
+	}
+
+	repoErr := errors.New("database down")
+	if _, err := NewAgingUsecase(&fakeAgingRepository{err: repoErr}).GetARAgingReport(context.Background(), asOf); !errors.Is(err, repoErr) {
+		t.Errorf("GetARAgingReport() error = %v, want %v", err, repoErr)
+	}
+}
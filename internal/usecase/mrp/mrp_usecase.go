@@ -0,0 +1,297 @@
+// Package mrp contains the use case for demand forecasts and the material requirements
+// planning engine, which runs in the background on the worker pool.
+package mrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	domainMrp "doligo_001/internal/domain/mrp"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/infrastructure/worker"
+	"doligo_001/internal/usecase"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrItemNotFound is returned when a forecast refers to an unknown item.
+	ErrItemNotFound = errors.New("item not found")
+	// ErrNotStorable is returned when a forecast is entered for a service item.
+	ErrNotStorable = errors.New("forecasts are only available for storable items")
+	// ErrWarehouseNotFound is returned when a forecast or a run refers to an unknown warehouse.
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	// ErrPlannerBusy is returned when a run cannot be submitted to the worker pool.
+	ErrPlannerBusy = errors.New("the planner is busy, try again later")
+)
+
+// Usecase defines the contract for forecasts and MRP runs.
+type Usecase interface {
+	CreateForecast(ctx context.Context, f *domainMrp.Forecast) error
+	GetForecast(ctx context.Context, id uuid.UUID) (*domainMrp.Forecast, error)
+	ListForecasts(ctx context.Context, filter domainMrp.ForecastFilter) ([]*domainMrp.Forecast, error)
+	UpdateForecast(ctx context.Context, f *domainMrp.Forecast) error
+	DeleteForecast(ctx context.Context, id uuid.UUID) error
+	// StartRun stores a run and queues it on the worker pool.
+	StartRun(ctx context.Context, run *domainMrp.Run) error
+	// ExecuteRun plans a queued run; it is called by the RunTask of the run.
+	ExecuteRun(ctx context.Context, runID uuid.UUID) error
+	GetRun(ctx context.Context, id uuid.UUID) (*domainMrp.Run, error)
+	ListRuns(ctx context.Context, warehouseID *uuid.UUID) ([]*domainMrp.Run, error)
+	ListPlannedOrders(ctx context.Context, runID uuid.UUID, filter domainMrp.PlannedOrderFilter) ([]*domainMrp.PlannedOrder, error)
+}
+
+type mrpUsecase struct {
+	txManager       db.Transactioner
+	forecastRepo    domainMrp.ForecastRepository
+	runRepo         domainMrp.RunRepository
+	demandRepo      domainMrp.DemandRepository
+	stockRepo       stock.StockRepository
+	reservationRepo stock.ReservationRepository
+	warehouseRepo   stock.WarehouseRepository
+	orderRepo       domainBom.ManufacturingOrderRepository
+	bomRepo         domainBom.Repository
+	itemRepo        item.Repository
+	unitRepo        uom.Repository
+	workerPool      *worker.WorkerPool
+	auditService    usecase.AuditService
+}
+
+// NewUsecase creates a new MRP usecase.
+func NewUsecase(
+	txManager db.Transactioner,
+	forecastRepo domainMrp.ForecastRepository,
+	runRepo domainMrp.RunRepository,
+	demandRepo domainMrp.DemandRepository,
+	stockRepo stock.StockRepository,
+	reservationRepo stock.ReservationRepository,
+	warehouseRepo stock.WarehouseRepository,
+	orderRepo domainBom.ManufacturingOrderRepository,
+	bomRepo domainBom.Repository,
+	itemRepo item.Repository,
+	unitRepo uom.Repository,
+	workerPool *worker.WorkerPool,
+	auditService usecase.AuditService,
+) Usecase {
+	return &mrpUsecase{
+		txManager:       txManager,
+		forecastRepo:    forecastRepo,
+		runRepo:         runRepo,
+		demandRepo:      demandRepo,
+		stockRepo:       stockRepo,
+		reservationRepo: reservationRepo,
+		warehouseRepo:   warehouseRepo,
+		orderRepo:       orderRepo,
+		bomRepo:         bomRepo,
+		itemRepo:        itemRepo,
+		unitRepo:        unitRepo,
+		workerPool:      workerPool,
+		auditService:    auditService,
+	}
+}
+
+// CreateForecast validates and stores a forecast of a storable item in a warehouse.
+func (uc *mrpUsecase) CreateForecast(ctx context.Context, f *domainMrp.Forecast) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if err := uc.validateItem(ctx, f.ItemID); err != nil {
+		return err
+	}
+	if err := uc.validateWarehouse(ctx, f.WarehouseID); err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	f.ID = uuid.New()
+	f.SetCreatedBy(userID)
+	f.SetUpdatedBy(userID)
+	if err := uc.forecastRepo.Create(ctx, f); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "forecast", f.ID.String(), "CREATE", nil, f, corrID)
+	return nil
+}
+
+func (uc *mrpUsecase) GetForecast(ctx context.Context, id uuid.UUID) (*domainMrp.Forecast, error) {
+	return uc.forecastRepo.GetByID(ctx, id)
+}
+
+func (uc *mrpUsecase) ListForecasts(ctx context.Context, filter domainMrp.ForecastFilter) ([]*domainMrp.Forecast, error) {
+	return uc.forecastRepo.List(ctx, filter)
+}
+
+// UpdateForecast changes the quantity, due date and notes of a forecast; its item and
+// warehouse are kept. Runs already completed keep the orders they planned.
+func (uc *mrpUsecase) UpdateForecast(ctx context.Context, f *domainMrp.Forecast) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	old, err := uc.forecastRepo.GetByID(ctx, f.ID)
+	if err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	f.ItemID = old.ItemID
+	f.WarehouseID = old.WarehouseID
+	f.CreatedAt = old.CreatedAt
+	f.CreatedBy = old.CreatedBy
+	f.SetUpdatedBy(userID)
+	if err := uc.forecastRepo.Update(ctx, f); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "forecast", f.ID.String(), "UPDATE", old, f, corrID)
+	return nil
+}
+
+func (uc *mrpUsecase) DeleteForecast(ctx context.Context, id uuid.UUID) error {
+	old, err := uc.forecastRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.forecastRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "forecast", id.String(), "DELETE", old, nil, corrID)
+	return nil
+}
+
+// StartRun stores a run of the warehouse up to its horizon and queues it on the worker pool.
+// The run is returned in the QUEUED status; a run the pool cannot take is stored as FAILED
+// and ErrPlannerBusy is returned.
+func (uc *mrpUsecase) StartRun(ctx context.Context, run *domainMrp.Run) error {
+	now := time.Now()
+	if !run.HorizonEnd.After(startOfDay(now)) {
+		return domainMrp.ErrInvalidHorizon
+	}
+	if err := uc.validateWarehouse(ctx, run.WarehouseID); err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	run.ID = uuid.New()
+	run.Status = domainMrp.RunQueued
+	run.ErrorMessage = ""
+	run.PlannedOrderCount = 0
+	run.StartedAt, run.CompletedAt = nil, nil
+	run.CreatedAt = now
+	run.CreatedBy = userID
+	if err := uc.runRepo.Create(ctx, run); err != nil {
+		return err
+	}
+
+	if err := uc.workerPool.Submit(&RunTask{RunID: run.ID, Usecase: uc}); err != nil {
+		run.Status = domainMrp.RunFailed
+		run.ErrorMessage = err.Error()
+		run.CompletedAt = &now
+		_ = uc.runRepo.Update(ctx, run)
+		return fmt.Errorf("%w: %v", ErrPlannerBusy, err)
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "mrp_run", run.ID.String(), "CREATE", nil, run, corrID)
+	return nil
+}
+
+// ExecuteRun plans a queued run and stores its planned orders. A run that fails is stored as
+// FAILED with the error, without any planned order.
+func (uc *mrpUsecase) ExecuteRun(ctx context.Context, runID uuid.UUID) error {
+	run, err := uc.runRepo.GetByID(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if run.Status != domainMrp.RunQueued {
+		return domainMrp.ErrRunNotQueued
+	}
+	startedAt := time.Now()
+	run.Status = domainMrp.RunRunning
+	run.StartedAt = &startedAt
+	if err := uc.runRepo.Update(ctx, run); err != nil {
+		return err
+	}
+
+	orders, err := uc.plan(ctx, run, startedAt)
+	if err == nil {
+		err = uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+			txRunRepo := uc.runRepo.WithTx(tx)
+			if err := txRunRepo.CreatePlannedOrders(ctx, orders); err != nil {
+				return err
+			}
+			completedAt := time.Now()
+			run.Status = domainMrp.RunCompleted
+			run.PlannedOrderCount = len(orders)
+			run.CompletedAt = &completedAt
+			return txRunRepo.Update(ctx, run)
+		})
+	}
+	if err != nil {
+		// The worker context may be what expired, the failure is recorded regardless
+		failedAt := time.Now()
+		run.Status = domainMrp.RunFailed
+		run.ErrorMessage = err.Error()
+		run.PlannedOrderCount = 0
+		run.CompletedAt = &failedAt
+		if updateErr := uc.runRepo.Update(context.WithoutCancel(ctx), run); updateErr != nil {
+			return fmt.Errorf("%w (recording the failure: %v)", err, updateErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (uc *mrpUsecase) GetRun(ctx context.Context, id uuid.UUID) (*domainMrp.Run, error) {
+	return uc.runRepo.GetByID(ctx, id)
+}
+
+func (uc *mrpUsecase) ListRuns(ctx context.Context, warehouseID *uuid.UUID) ([]*domainMrp.Run, error) {
+	return uc.runRepo.List(ctx, warehouseID)
+}
+
+// ListPlannedOrders returns the planned orders of a run.
+func (uc *mrpUsecase) ListPlannedOrders(ctx context.Context, runID uuid.UUID, filter domainMrp.PlannedOrderFilter) ([]*domainMrp.PlannedOrder, error) {
+	if _, err := uc.runRepo.GetByID(ctx, runID); err != nil {
+		return nil, err
+	}
+	return uc.runRepo.ListPlannedOrders(ctx, runID, filter)
+}
+
+// validateItem ensures the item of a forecast exists and is storable.
+func (uc *mrpUsecase) validateItem(ctx context.Context, itemID uuid.UUID) error {
+	it, err := uc.itemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrItemNotFound
+		}
+		return err
+	}
+	if it.Type != item.Storable {
+		return ErrNotStorable
+	}
+	return nil
+}
+
+func (uc *mrpUsecase) validateWarehouse(ctx context.Context, warehouseID uuid.UUID) error {
+	if _, err := uc.warehouseRepo.GetByID(ctx, warehouseID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWarehouseNotFound
+		}
+		return err
+	}
+	return nil
+}
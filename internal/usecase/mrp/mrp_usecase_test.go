@@ -0,0 +1,490 @@
+package mrp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	domainMrp "doligo_001/internal/domain/mrp"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/worker"
+	bom_uc "doligo_001/internal/usecase/bom"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- In-memory fakes ---
+
+type fakeTx struct{}
+
+func (fakeTx) Transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return fc(nil)
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
+type fakeForecastRepository struct {
+	forecasts []*domainMrp.Forecast
+}
+
+func (f *fakeForecastRepository) WithTx(tx *gorm.DB) domainMrp.ForecastRepository { return f }
+func (f *fakeForecastRepository) Create(ctx context.Context, fc *domainMrp.Forecast) error {
+	f.forecasts = append(f.forecasts, fc)
+	return nil
+}
+func (f *fakeForecastRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainMrp.Forecast, error) {
+	for _, fc := range f.forecasts {
+		if fc.ID == id {
+			return fc, nil
+		}
+	}
+	return nil, domainMrp.ErrForecastNotFound
+}
+func (f *fakeForecastRepository) List(ctx context.Context, filter domainMrp.ForecastFilter) ([]*domainMrp.Forecast, error) {
+	var list []*domainMrp.Forecast
+	for _, fc := range f.forecasts {
+		if fc.WarehouseID == *filter.WarehouseID && !fc.DueDate.After(*filter.To) {
+			list = append(list, fc)
+		}
+	}
+	return list, nil
+}
+func (f *fakeForecastRepository) Update(ctx context.Context, fc *domainMrp.Forecast) error {
+	return nil
+}
+func (f *fakeForecastRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+type fakeRunRepository struct {
+	runs   map[uuid.UUID]*domainMrp.Run
+	orders []*domainMrp.PlannedOrder
+}
+
+func (f *fakeRunRepository) WithTx(tx *gorm.DB) domainMrp.RunRepository { return f }
+func (f *fakeRunRepository) Create(ctx context.Context, run *domainMrp.Run) error {
+	f.runs[run.ID] = run
+	return nil
+}
+func (f *fakeRunRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainMrp.Run, error) {
+	if run, ok := f.runs[id]; ok {
+		return run, nil
+	}
+	return nil, domainMrp.ErrRunNotFound
+}
+func (f *fakeRunRepository) List(ctx context.Context, warehouseID *uuid.UUID) ([]*domainMrp.Run, error) {
+	return nil, nil
+}
+func (f *fakeRunRepository) Update(ctx context.Context, run *domainMrp.Run) error { return nil }
+func (f *fakeRunRepository) CreatePlannedOrders(ctx context.Context, orders []*domainMrp.PlannedOrder) error {
+	f.orders = append(f.orders, orders...)
+	return nil
+}
+func (f *fakeRunRepository) ListPlannedOrders(ctx context.Context, runID uuid.UUID, filter domainMrp.PlannedOrderFilter) ([]*domainMrp.PlannedOrder, error) {
+	return f.orders, nil
+}
+
+type fakeDemandRepository struct{}
+
+func (fakeDemandRepository) ListInvoiceDemand(ctx context.Context, from, to time.Time) ([]*domainMrp.Demand, error) {
+	return nil, nil
+}
+
+type fakeStockRepository struct {
+	rows []*stock.Stock
+}
+
+func (f *fakeStockRepository) WithTx(tx *gorm.DB) stock.StockRepository { return f }
+func (f *fakeStockRepository) GetStock(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeStockRepository) GetStockForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeStockRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID) (float64, error) {
+	return 0, errors.New("not implemented")
+}
+func (f *fakeStockRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	var list []*stock.Stock
+	for _, s := range f.rows {
+		if s.WarehouseID == warehouseID {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+func (f *fakeStockRepository) UpsertStock(ctx context.Context, s *stock.Stock) error { return nil }
+
+type fakeReservationRepository struct {
+	reservations []*stock.Reservation
+}
+
+func (f *fakeReservationRepository) WithTx(tx *gorm.DB) stock.ReservationRepository { return f }
+func (f *fakeReservationRepository) Create(ctx context.Context, r *stock.Reservation) error {
+	return nil
+}
+func (f *fakeReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) FindActive(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) List(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error) {
+	var list []*stock.Reservation
+	for _, r := range f.reservations {
+		if r.WarehouseID == *filter.WarehouseID && r.Status == filter.Status {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+func (f *fakeReservationRepository) Update(ctx context.Context, r *stock.Reservation) error {
+	return nil
+}
+func (f *fakeReservationRepository) ReservedQuantity(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, asOf time.Time) (float64, error) {
+	return 0, nil
+}
+func (f *fakeReservationRepository) ExpireDue(ctx context.Context, asOf time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeWarehouseRepository struct{}
+
+func (f *fakeWarehouseRepository) WithTx(tx *gorm.DB) stock.WarehouseRepository { return f }
+func (f *fakeWarehouseRepository) Create(ctx context.Context, w *stock.Warehouse) error {
+	return nil
+}
+func (f *fakeWarehouseRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Warehouse, error) {
+	return &stock.Warehouse{ID: id, IsActive: true}, nil
+}
+func (f *fakeWarehouseRepository) Update(ctx context.Context, w *stock.Warehouse) error {
+	return nil
+}
+func (f *fakeWarehouseRepository) List(ctx context.Context) ([]*stock.Warehouse, error) {
+	return nil, nil
+}
+func (f *fakeWarehouseRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+type fakeOrderRepository struct {
+	orders []*domainBom.ManufacturingOrder
+}
+
+func (f *fakeOrderRepository) WithTx(tx *gorm.DB) domainBom.ManufacturingOrderRepository { return f }
+func (f *fakeOrderRepository) Create(ctx context.Context, o *domainBom.ManufacturingOrder) error {
+	return nil
+}
+func (f *fakeOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error) {
+	return nil, domainBom.ErrOrderNotFound
+}
+func (f *fakeOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domainBom.ManufacturingOrder, error) {
+	return nil, domainBom.ErrOrderNotFound
+}
+func (f *fakeOrderRepository) List(ctx context.Context, filter domainBom.OrderFilter) ([]*domainBom.ManufacturingOrder, error) {
+	return f.orders, nil
+}
+func (f *fakeOrderRepository) Update(ctx context.Context, o *domainBom.ManufacturingOrder) error {
+	return nil
+}
+func (f *fakeOrderRepository) UpdateComponent(ctx context.Context, c *domainBom.OrderComponent) error {
+	return nil
+}
+func (f *fakeOrderRepository) AddLot(ctx context.Context, l *domainBom.OrderLot) error { return nil }
+
+type fakeBomRepository struct {
+	boms map[uuid.UUID]*domainBom.BillOfMaterials // Revision in force, keyed by product ID
+}
+
+func (f *fakeBomRepository) WithTx(tx *gorm.DB) domainBom.Repository { return f }
+func (f *fakeBomRepository) Create(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	return nil
+}
+func (f *fakeBomRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainBom.BillOfMaterials, error) {
+	return nil, domainBom.ErrBOMNotFound
+}
+func (f *fakeBomRepository) GetEffective(ctx context.Context, productID uuid.UUID, at time.Time) (*domainBom.BillOfMaterials, error) {
+	if b, ok := f.boms[productID]; ok {
+		return b, nil
+	}
+	return nil, domainBom.ErrBOMNotFound
+}
+func (f *fakeBomRepository) ListRevisions(ctx context.Context, productID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+func (f *fakeBomRepository) ListRevisionsForUpdate(ctx context.Context, productID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+func (f *fakeBomRepository) UpdateStatus(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	return nil
+}
+func (f *fakeBomRepository) Update(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	return nil
+}
+func (f *fakeBomRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeBomRepository) List(ctx context.Context) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+func (f *fakeBomRepository) ListByComponent(ctx context.Context, itemID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+
+type fakeItemRepository struct {
+	items map[uuid.UUID]*item.Item
+}
+
+func (f *fakeItemRepository) WithTx(tx *gorm.DB) item.Repository { return f }
+func (f *fakeItemRepository) Create(ctx context.Context, i *item.Item) error {
+	f.items[i.ID] = i
+	return nil
+}
+func (f *fakeItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*item.Item, error) {
+	if i, ok := f.items[id]; ok {
+		return i, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeItemRepository) Update(ctx context.Context, i *item.Item) error { return nil }
+func (f *fakeItemRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeItemRepository) List(ctx context.Context) ([]*item.Item, error) { return nil, nil }
+
+// mrpFixture wires the usecase to in-memory repositories for one warehouse.
+type mrpFixture struct {
+	uc           Usecase
+	warehouseID  uuid.UUID
+	today        time.Time
+	forecasts    *fakeForecastRepository
+	runs         *fakeRunRepository
+	stock        *fakeStockRepository
+	reservations *fakeReservationRepository
+	orders       *fakeOrderRepository
+	boms         *fakeBomRepository
+	items        *fakeItemRepository
+}
+
+func newMrpFixture(pool *worker.WorkerPool) *mrpFixture {
+	f := &mrpFixture{
+		warehouseID:  uuid.New(),
+		today:        startOfDay(time.Now()),
+		forecasts:    &fakeForecastRepository{},
+		runs:         &fakeRunRepository{runs: make(map[uuid.UUID]*domainMrp.Run)},
+		stock:        &fakeStockRepository{},
+		reservations: &fakeReservationRepository{},
+		orders:       &fakeOrderRepository{},
+		boms:         &fakeBomRepository{boms: make(map[uuid.UUID]*domainBom.BillOfMaterials)},
+		items:        &fakeItemRepository{items: make(map[uuid.UUID]*item.Item)},
+	}
+	f.uc = NewUsecase(fakeTx{}, f.forecasts, f.runs, fakeDemandRepository{}, f.stock, f.reservations, &fakeWarehouseRepository{},
+		f.orders, f.boms, f.items, nil, pool, fakeAudit{})
+	return f
+}
+
+// addItem creates a storable item with a lead time and on-hand stock.
+func (f *mrpFixture) addItem(leadTimeDays int, onHand float64) uuid.UUID {
+	itemID := uuid.New()
+	f.items.items[itemID] = &item.Item{ID: itemID, Type: item.Storable, IsActive: true, LeadTimeDays: leadTimeDays}
+	f.stock.rows = append(f.stock.rows, &stock.Stock{ItemID: itemID, WarehouseID: f.warehouseID, Quantity: onHand})
+	return itemID
+}
+
+func (f *mrpFixture) addBOM(productID, componentID uuid.UUID, quantity float64) uuid.UUID {
+	bomID := uuid.New()
+	f.boms.boms[productID] = &domainBom.BillOfMaterials{
+		ID:           bomID,
+		ProductID:    productID,
+		IsActive:     true,
+		YieldPercent: 100,
+		Components:   []domainBom.BillOfMaterialsComponent{{ComponentItemID: componentID, Quantity: quantity, IsActive: true}},
+	}
+	return bomID
+}
+
+func (f *mrpFixture) forecast(itemID uuid.UUID, quantity float64, inDays int) {
+	f.forecasts.forecasts = append(f.forecasts.forecasts, &domainMrp.Forecast{
+		ID:          uuid.New(),
+		ItemID:      itemID,
+		WarehouseID: f.warehouseID,
+		Quantity:    quantity,
+		DueDate:     f.today.AddDate(0, 0, inDays),
+	})
+}
+
+func (f *mrpFixture) reserve(itemID uuid.UUID, quantity float64, sourceType string) {
+	f.reservations.reservations = append(f.reservations.reservations, &stock.Reservation{
+		ID:          uuid.New(),
+		ItemID:      itemID,
+		WarehouseID: f.warehouseID,
+		Quantity:    quantity,
+		Status:      stock.ReservationActive,
+		SourceType:  sourceType,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+}
+
+// queueAndExecute stores a queued run with a 30-day horizon and executes it.
+func (f *mrpFixture) queueAndExecute() (*domainMrp.Run, error) {
+	run := &domainMrp.Run{
+		ID:          uuid.New(),
+		WarehouseID: f.warehouseID,
+		HorizonEnd:  f.today.AddDate(0, 0, 30),
+		Status:      domainMrp.RunQueued,
+	}
+	f.runs.runs[run.ID] = run
+	return run, f.uc.ExecuteRun(context.Background(), run.ID)
+}
+
+func ordersFor(orders []*domainMrp.PlannedOrder, itemID uuid.UUID) []*domainMrp.PlannedOrder {
+	var list []*domainMrp.PlannedOrder
+	for _, o := range orders {
+		if o.ItemID == itemID {
+			list = append(list, o)
+		}
+	}
+	return list
+}
+
+func TestMrp_ExecuteRun_PlansLevelsWithLeadTimes(t *testing.T) {
+	f := newMrpFixture(nil)
+	componentID := f.addItem(10, 10)
+	productID := f.addItem(2, 5)
+	bomID := f.addBOM(productID, componentID, 2)
+	f.reserve(productID, 3, "SALES_ORDER")
+	f.reserve(componentID, 50, bom_uc.OrderReservationSource) // counted through the order components
+	f.forecast(productID, 15, 5)
+	f.forecast(productID, 100, 45) // beyond the horizon
+
+	run, err := f.queueAndExecute()
+	if err != nil {
+		t.Fatalf("ExecuteRun failed: %v", err)
+	}
+	if run.Status != domainMrp.RunCompleted || run.PlannedOrderCount != 2 {
+		t.Fatalf("expected a completed run with 2 planned orders, got %s with %d", run.Status, run.PlannedOrderCount)
+	}
+
+	// 5 on hand, 3 reserved today, 15 forecast in 5 days
+	product := ordersFor(f.runs.orders, productID)
+	if len(product) != 1 {
+		t.Fatalf("expected 1 order for the product, got %d", len(product))
+	}
+	p := product[0]
+	if p.Type != domainMrp.OrderProduction || p.BOMID == nil || *p.BOMID != bomID || p.Level != 0 || p.Quantity != 13 {
+		t.Errorf("unexpected product order: %+v", p)
+	}
+	if !p.DueDate.Equal(f.today.AddDate(0, 0, 5)) || !p.StartDate.Equal(f.today.AddDate(0, 0, 3)) || p.PastDue {
+		t.Errorf("expected the product order to start in 3 days for 5, got %v to %v (past due %v)", p.StartDate, p.DueDate, p.PastDue)
+	}
+
+	// 13 x 2 needed when the production starts, 10 on hand
+	component := ordersFor(f.runs.orders, componentID)
+	if len(component) != 1 {
+		t.Fatalf("expected 1 order for the component, got %d", len(component))
+	}
+	c := component[0]
+	if c.Type != domainMrp.OrderPurchase || c.Level != 1 || c.Quantity != 16 {
+		t.Errorf("unexpected component order: %+v", c)
+	}
+	if !c.DueDate.Equal(p.StartDate) || !c.PastDue {
+		t.Errorf("expected a past due component order due on %v, got due %v (past due %v)", p.StartDate, c.DueDate, c.PastDue)
+	}
+}
+
+func TestMrp_ExecuteRun_NetsOpenManufacturingOrders(t *testing.T) {
+	f := newMrpFixture(nil)
+	componentID := f.addItem(0, 0)
+	productID := f.addItem(0, 0)
+	f.addBOM(productID, componentID, 1)
+	plannedStart, plannedEnd := f.today.AddDate(0, 0, 1), f.today.AddDate(0, 0, 2)
+	f.orders.orders = append(f.orders.orders, &domainBom.ManufacturingOrder{
+		ID:               uuid.New(),
+		ProductID:        productID,
+		WarehouseID:      f.warehouseID,
+		Quantity:         10,
+		ProducedQuantity: 2,
+		Status:           domainBom.OrderInProgress,
+		PlannedStart:     &plannedStart,
+		PlannedEnd:       &plannedEnd,
+		Components:       []*domainBom.OrderComponent{{ItemID: componentID, RequiredQuantity: 10, IssuedQuantity: 6}},
+	}, &domainBom.ManufacturingOrder{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		WarehouseID: f.warehouseID,
+		Quantity:    50,
+		Status:      domainBom.OrderCancelled,
+	})
+	f.forecast(productID, 12, 5)
+
+	if _, err := f.queueAndExecute(); err != nil {
+		t.Fatalf("ExecuteRun failed: %v", err)
+	}
+
+	// The 8 still to receive cover part of the forecast
+	product := ordersFor(f.runs.orders, productID)
+	if len(product) != 1 || product[0].Quantity != 4 {
+		t.Fatalf("expected a single product order of 4, got %+v", product)
+	}
+
+	// The 4 still to issue to the order, then the 4 of the planned production
+	component := ordersFor(f.runs.orders, componentID)
+	if len(component) != 2 {
+		t.Fatalf("expected 2 component orders, got %d", len(component))
+	}
+	if component[0].Quantity != 4 || !component[0].DueDate.Equal(plannedStart) {
+		t.Errorf("expected 4 due on %v, got %f due on %v", plannedStart, component[0].Quantity, component[0].DueDate)
+	}
+	if component[1].Quantity != 4 || !component[1].DueDate.Equal(product[0].StartDate) {
+		t.Errorf("expected 4 due on %v, got %f due on %v", product[0].StartDate, component[1].Quantity, component[1].DueDate)
+	}
+}
+
+func TestMrp_ExecuteRun_FailureIsRecorded(t *testing.T) {
+	f := newMrpFixture(nil)
+	productID := f.addItem(0, 0)
+	subID := f.addItem(0, 0)
+	f.addBOM(productID, subID, 1)
+	f.addBOM(subID, productID, 1)
+	f.forecast(productID, 1, 1)
+
+	run, err := f.queueAndExecute()
+	if !errors.Is(err, domainBom.ErrBOMCycle) {
+		t.Fatalf("expected %v, got %v", domainBom.ErrBOMCycle, err)
+	}
+	if run.Status != domainMrp.RunFailed || run.ErrorMessage == "" || run.CompletedAt == nil || len(f.runs.orders) != 0 {
+		t.Errorf("expected a failed run without planned orders, got %+v and %d orders", run, len(f.runs.orders))
+	}
+
+	if err := f.uc.ExecuteRun(context.Background(), run.ID); !errors.Is(err, domainMrp.ErrRunNotQueued) {
+		t.Errorf("expected %v when executing the run again, got %v", domainMrp.ErrRunNotQueued, err)
+	}
+}
+
+func TestMrp_StartRun_QueuesOnTheWorkerPool(t *testing.T) {
+	// No worker: the pool buffers one task and rejects the next
+	f := newMrpFixture(worker.NewWorkerPool(0, 1, "MRP test"))
+	ctx := context.Background()
+
+	past := &domainMrp.Run{WarehouseID: f.warehouseID, HorizonEnd: f.today}
+	if err := f.uc.StartRun(ctx, past); !errors.Is(err, domainMrp.ErrInvalidHorizon) {
+		t.Errorf("expected %v, got %v", domainMrp.ErrInvalidHorizon, err)
+	}
+
+	queued := &domainMrp.Run{WarehouseID: f.warehouseID, HorizonEnd: f.today.AddDate(0, 0, 7)}
+	if err := f.uc.StartRun(ctx, queued); err != nil {
+		t.Fatalf("StartRun failed: %v", err)
+	}
+	if queued.Status != domainMrp.RunQueued || f.runs.runs[queued.ID] == nil {
+		t.Errorf("expected a stored queued run, got %s", queued.Status)
+	}
+
+	rejected := &domainMrp.Run{WarehouseID: f.warehouseID, HorizonEnd: f.today.AddDate(0, 0, 7)}
+	if err := f.uc.StartRun(ctx, rejected); !errors.Is(err, ErrPlannerBusy) {
+		t.Fatalf("expected %v, got %v", ErrPlannerBusy, err)
+	}
+	if rejected.Status != domainMrp.RunFailed {
+		t.Errorf("expected the rejected run to be stored as failed, got %s", rejected.Status)
+	}
+}
@@ -0,0 +1,339 @@
+package mrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	domainMrp "doligo_001/internal/domain/mrp"
+	"doligo_001/internal/domain/stock"
+	bom_uc "doligo_001/internal/usecase/bom"
+	uom_uc "doligo_001/internal/usecase/uom"
+	"github.com/google/uuid"
+)
+
+// quantityEpsilon absorbs the rounding left by unit conversions and scrap factors.
+const quantityEpsilon = 1e-9
+
+// planner holds the state of one run while it is planned.
+type planner struct {
+	uc      *mrpUsecase
+	run     *domainMrp.Run
+	now     time.Time
+	today   time.Time
+	horizon time.Time
+
+	onHand   map[uuid.UUID]float64
+	demand   map[uuid.UUID]map[time.Time]float64
+	receipts map[uuid.UUID]map[time.Time]float64
+	levels   map[uuid.UUID]int
+	boms     map[uuid.UUID]*domainBom.BillOfMaterials // nil for items without an active BOM in force
+	items    map[uuid.UUID]*item.Item
+}
+
+// plan computes the planned orders of a run. The gross requirements of the warehouse (held
+// reservations, forecasts, invoice lines when the run includes them, and the components still
+// to issue to open manufacturing orders) are netted lot-for-lot, date by date, against the
+// on-hand stock and the products still to receive from open manufacturing orders. Items are
+// planned by low-level code so that the components of a planned production order are netted
+// after every parent that uses them. Requirements dated before the run are due on its day.
+func (uc *mrpUsecase) plan(ctx context.Context, run *domainMrp.Run, now time.Time) ([]*domainMrp.PlannedOrder, error) {
+	p := &planner{
+		uc:       uc,
+		run:      run,
+		now:      now,
+		today:    startOfDay(now),
+		horizon:  startOfDay(run.HorizonEnd),
+		onHand:   make(map[uuid.UUID]float64),
+		demand:   make(map[uuid.UUID]map[time.Time]float64),
+		receipts: make(map[uuid.UUID]map[time.Time]float64),
+		levels:   make(map[uuid.UUID]int),
+		boms:     make(map[uuid.UUID]*domainBom.BillOfMaterials),
+		items:    make(map[uuid.UUID]*item.Item),
+	}
+	if err := p.loadSupplyAndDemand(ctx); err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(p.demand))
+	for itemID := range p.demand {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sortIDs(itemIDs)
+	for _, itemID := range itemIDs {
+		if err := p.assignLevel(ctx, itemID, 0, make(map[uuid.UUID]bool)); err != nil {
+			return nil, err
+		}
+	}
+
+	maxLevel := 0
+	for _, level := range p.levels {
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+	orders := []*domainMrp.PlannedOrder{}
+	for level := 0; level <= maxLevel; level++ {
+		var atLevel []uuid.UUID
+		for itemID, l := range p.levels {
+			if l == level {
+				atLevel = append(atLevel, itemID)
+			}
+		}
+		sortIDs(atLevel)
+		for _, itemID := range atLevel {
+			planned, err := p.net(ctx, itemID, level)
+			if err != nil {
+				return nil, err
+			}
+			orders = append(orders, planned...)
+		}
+	}
+	return orders, nil
+}
+
+// loadSupplyAndDemand reads the stock, the gross requirements and the scheduled receipts of the
+// warehouse of the run.
+func (p *planner) loadSupplyAndDemand(ctx context.Context) error {
+	uc, warehouseID := p.uc, p.run.WarehouseID
+
+	stocks, err := uc.stockRepo.ListByWarehouse(ctx, warehouseID)
+	if err != nil {
+		return err
+	}
+	for _, s := range stocks {
+		p.onHand[s.ItemID] += s.Quantity
+	}
+
+	// The reservations of manufacturing orders are counted through their open components
+	reservations, err := uc.reservationRepo.List(ctx, stock.ReservationFilter{WarehouseID: &warehouseID, Status: stock.ReservationActive})
+	if err != nil {
+		return err
+	}
+	for _, r := range reservations {
+		if r.SourceType != bom_uc.OrderReservationSource && r.IsHeld(p.now) {
+			p.addDemand(r.ItemID, p.today, r.OpenQuantity())
+		}
+	}
+
+	forecasts, err := uc.forecastRepo.List(ctx, domainMrp.ForecastFilter{WarehouseID: &warehouseID, To: &p.horizon})
+	if err != nil {
+		return err
+	}
+	for _, f := range forecasts {
+		p.addDemand(f.ItemID, f.DueDate, f.Quantity)
+	}
+
+	if p.run.IncludeInvoices {
+		invoiced, err := uc.demandRepo.ListInvoiceDemand(ctx, p.today, p.horizon)
+		if err != nil {
+			return err
+		}
+		for _, d := range invoiced {
+			p.addDemand(d.ItemID, d.DueDate, d.Quantity)
+		}
+	}
+
+	orders, err := uc.orderRepo.List(ctx, domainBom.OrderFilter{WarehouseID: &warehouseID})
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if o.Status != domainBom.OrderPlanned && o.Status != domainBom.OrderReleased && o.Status != domainBom.OrderInProgress {
+			continue
+		}
+		if remaining := o.RemainingQuantity(); remaining > quantityEpsilon {
+			p.addReceipt(o.ProductID, dateOr(o.PlannedEnd, p.today), remaining)
+		}
+		for _, c := range o.Components {
+			if remaining := c.RemainingQuantity(); remaining > quantityEpsilon {
+				p.addDemand(c.ItemID, dateOr(o.PlannedStart, p.today), remaining)
+			}
+		}
+	}
+	return nil
+}
+
+// assignLevel gives the item the deepest BOM level it is used at, and does the same for the
+// components of its BOM. A BOM that uses its own product is reported as a cycle.
+func (p *planner) assignLevel(ctx context.Context, itemID uuid.UUID, level int, path map[uuid.UUID]bool) error {
+	if path[itemID] {
+		return fmt.Errorf("%w: item %s", domainBom.ErrBOMCycle, itemID)
+	}
+	if known, ok := p.levels[itemID]; ok && known >= level {
+		return nil
+	}
+	p.levels[itemID] = level
+
+	b, err := p.bomOf(ctx, itemID)
+	if err != nil || b == nil {
+		return err
+	}
+	path[itemID] = true
+	defer delete(path, itemID)
+	for _, component := range b.Components {
+		if !component.IsActive {
+			continue
+		}
+		if err := p.assignLevel(ctx, component.ComponentItemID, level+1, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// net projects the stock of an item date by date and plans an order for every shortfall.
+// A production order passes the demand of its components down at its start date.
+func (p *planner) net(ctx context.Context, itemID uuid.UUID, level int) ([]*domainMrp.PlannedOrder, error) {
+	it, err := p.itemOf(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if it.Type != item.Storable {
+		return nil, nil
+	}
+	b, err := p.bomOf(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]time.Time, 0, len(p.demand[itemID])+len(p.receipts[itemID]))
+	seen := make(map[time.Time]bool)
+	for _, byDate := range []map[time.Time]float64{p.demand[itemID], p.receipts[itemID]} {
+		for date := range byDate {
+			if !seen[date] {
+				seen[date] = true
+				dates = append(dates, date)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	var orders []*domainMrp.PlannedOrder
+	projected := p.onHand[itemID]
+	for _, date := range dates {
+		projected += p.receipts[itemID][date] - p.demand[itemID][date]
+		if projected >= -quantityEpsilon {
+			continue
+		}
+
+		order := &domainMrp.PlannedOrder{
+			ID:          uuid.New(),
+			RunID:       p.run.ID,
+			ItemID:      itemID,
+			WarehouseID: p.run.WarehouseID,
+			Type:        domainMrp.OrderPurchase,
+			Level:       level,
+			Quantity:    -projected,
+			StartDate:   date.AddDate(0, 0, -it.LeadTimeDays),
+			DueDate:     date,
+		}
+		order.PastDue = order.StartDate.Before(p.today)
+		projected = 0
+		if b != nil {
+			bomID := b.ID
+			order.Type = domainMrp.OrderProduction
+			order.BOMID = &bomID
+			for _, component := range b.Components {
+				if !component.IsActive {
+					continue
+				}
+				factor, err := p.componentFactor(ctx, component)
+				if err != nil {
+					return nil, err
+				}
+				p.addDemand(component.ComponentItemID, order.StartDate, order.Quantity*b.PlannedQuantity(component)*factor)
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// addDemand records a gross requirement. Requirements dated before the run are due on its day,
+// those after the horizon are left out.
+func (p *planner) addDemand(itemID uuid.UUID, date time.Time, quantity float64) {
+	p.addDated(p.demand, itemID, date, quantity)
+}
+
+func (p *planner) addReceipt(itemID uuid.UUID, date time.Time, quantity float64) {
+	p.addDated(p.receipts, itemID, date, quantity)
+}
+
+func (p *planner) addDated(byItem map[uuid.UUID]map[time.Time]float64, itemID uuid.UUID, date time.Time, quantity float64) {
+	date = startOfDay(date)
+	if date.After(p.horizon) {
+		return
+	}
+	if date.Before(p.today) {
+		date = p.today
+	}
+	if byItem[itemID] == nil {
+		byItem[itemID] = make(map[time.Time]float64)
+	}
+	byItem[itemID][date] += quantity
+}
+
+// bomOf returns the active BOM in force for the item at the start of the run, or nil.
+func (p *planner) bomOf(ctx context.Context, itemID uuid.UUID) (*domainBom.BillOfMaterials, error) {
+	if b, ok := p.boms[itemID]; ok {
+		return b, nil
+	}
+	b, err := p.uc.bomRepo.GetEffective(ctx, itemID, p.now)
+	if err != nil {
+		if !errors.Is(err, domainBom.ErrBOMNotFound) {
+			return nil, err
+		}
+		b = nil
+	} else if !b.IsActive {
+		b = nil
+	}
+	p.boms[itemID] = b
+	return b, nil
+}
+
+func (p *planner) itemOf(ctx context.Context, itemID uuid.UUID) (*item.Item, error) {
+	if it, ok := p.items[itemID]; ok {
+		return it, nil
+	}
+	it, err := p.uc.itemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	p.items[itemID] = it
+	return it, nil
+}
+
+// componentFactor returns the number of base units of the component item in one unit of the
+// BOM line, as requirements are planned in the base unit.
+func (p *planner) componentFactor(ctx context.Context, component domainBom.BillOfMaterialsComponent) (float64, error) {
+	if component.UnitOfMeasure == "" {
+		return 1, nil
+	}
+	it, err := p.itemOf(ctx, component.ComponentItemID)
+	if err != nil {
+		return 0, err
+	}
+	return uom_uc.BaseFactor(ctx, p.uc.unitRepo, it, component.UnitOfMeasure)
+}
+
+// startOfDay returns the calendar day of t as midnight UTC, the form dates are stored in.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func dateOr(t *time.Time, fallback time.Time) time.Time {
+	if t == nil {
+		return fallback
+	}
+	return *t
+}
+
+// sortIDs sorts IDs so that runs are deterministic.
+func sortIDs(ids []uuid.UUID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+}
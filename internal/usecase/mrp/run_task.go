@@ -0,0 +1,23 @@
+package mrp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// RunTask executes a queued MRP run on a worker.WorkerPool.
+type RunTask struct {
+	RunID   uuid.UUID
+	Usecase Usecase
+}
+
+func (t *RunTask) Execute(ctx context.Context) error {
+	if err := t.Usecase.ExecuteRun(ctx, t.RunID); err != nil {
+		return fmt.Errorf("MRP run %s failed: %w", t.RunID, err)
+	}
+	slog.Info("MRP run completed", "run_id", t.RunID)
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package numbering contains the use case for numbering sequences and the allocation of the
+// final numbers of documents.
+package numbering
+
+import (
+	"context"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainNumbering "doligo_001/internal/domain/numbering"
+	"doligo_001/internal/usecase"
+)
+
+// Usecase defines the contract for configuring numbering sequences.
+type Usecase interface {
+	ListSequences(ctx context.Context) ([]*domainNumbering.Sequence, error)
+	// UpdateSequence changes the pattern and the reset policy of the sequence of a document
+	// type. Numbers already given are kept; the counters go on from their last value.
+	UpdateSequence(ctx context.Context, documentType domainNumbering.DocumentType, pattern string, reset domainNumbering.ResetPolicy) (*domainNumbering.Sequence, error)
+}
+
+type numberingUsecase struct {
+	repo         domainNumbering.Repository
+	auditService usecase.AuditService
+}
+
+// NewUsecase creates a new numbering sequence usecase.
+func NewUsecase(repo domainNumbering.Repository, auditService usecase.AuditService) Usecase {
+	return &numberingUsecase{repo: repo, auditService: auditService}
+}
+
+func (uc *numberingUsecase) ListSequences(ctx context.Context) ([]*domainNumbering.Sequence, error) {
+	return uc.repo.List(ctx)
+}
+
+func (uc *numberingUsecase) UpdateSequence(ctx context.Context, documentType domainNumbering.DocumentType, pattern string, reset domainNumbering.ResetPolicy) (*domainNumbering.Sequence, error) {
+	sequence, err := uc.repo.GetByDocumentType(ctx, documentType)
+	if err != nil {
+		return nil, err
+	}
+	old := *sequence
+	sequence.Pattern = pattern
+	sequence.Reset = reset
+	if err := sequence.Validate(); err != nil {
+		return nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	sequence.SetUpdatedBy(userID)
+	if err := uc.repo.Update(ctx, sequence); err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "numbering_sequence", sequence.ID.String(), "UPDATE",
+		map[string]interface{}{"pattern": old.Pattern, "reset": old.Reset},
+		map[string]interface{}{"pattern": sequence.Pattern, "reset": sequence.Reset},
+		corrID)
+	return sequence, nil
+}
+
+// Allocate gives the next number of the sequence of a document type to a document dated at.
+// It must run in the transaction that issues the document, with sequences a repository bound
+// to it: the sequence stays locked until the transaction ends and a rollback gives the number
+// back, so numbers are gapless under concurrency.
+func Allocate(ctx context.Context, sequences domainNumbering.Repository, documentType domainNumbering.DocumentType, at time.Time) (string, error) {
+	sequence, err := sequences.GetByDocumentTypeForUpdate(ctx, documentType)
+	if err != nil {
+		return "", err
+	}
+	period := sequence.Period(at)
+	counter, err := sequences.GetCounter(ctx, sequence.ID, period)
+	if err != nil {
+		return "", err
+	}
+	if counter == nil {
+		counter = &domainNumbering.Counter{SequenceID: sequence.ID, Period: period}
+	}
+	counter.LastValue++
+	if err := sequences.SaveCounter(ctx, counter); err != nil {
+		return "", err
+	}
+	return sequence.Format(counter.LastValue, at), nil
+}
@@ -0,0 +1,166 @@
+package numbering
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domainNumbering "doligo_001/internal/domain/numbering"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeNumberingRepository is an in-memory numbering.Repository.
+type fakeNumberingRepository struct {
+	sequences map[domainNumbering.DocumentType]*domainNumbering.Sequence
+	counters  map[string]*domainNumbering.Counter
+}
+
+func newFakeNumberingRepository() *fakeNumberingRepository {
+	return &fakeNumberingRepository{
+		sequences: make(map[domainNumbering.DocumentType]*domainNumbering.Sequence),
+		counters:  make(map[string]*domainNumbering.Counter),
+	}
+}
+
+func (f *fakeNumberingRepository) WithTx(tx *gorm.DB) domainNumbering.Repository { return f }
+func (f *fakeNumberingRepository) List(ctx context.Context) ([]*domainNumbering.Sequence, error) {
+	var list []*domainNumbering.Sequence
+	for _, s := range f.sequences {
+		list = append(list, s)
+	}
+	return list, nil
+}
+func (f *fakeNumberingRepository) GetByDocumentType(ctx context.Context, documentType domainNumbering.DocumentType) (*domainNumbering.Sequence, error) {
+	s, ok := f.sequences[documentType]
+	if !ok {
+		return nil, domainNumbering.ErrSequenceNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+func (f *fakeNumberingRepository) GetByDocumentTypeForUpdate(ctx context.Context, documentType domainNumbering.DocumentType) (*domainNumbering.Sequence, error) {
+	return f.GetByDocumentType(ctx, documentType)
+}
+func (f *fakeNumberingRepository) Update(ctx context.Context, sequence *domainNumbering.Sequence) error {
+	f.sequences[sequence.DocumentType] = sequence
+	return nil
+}
+func (f *fakeNumberingRepository) GetCounter(ctx context.Context, sequenceID uuid.UUID, period string) (*domainNumbering.Counter, error) {
+	c, ok := f.counters[sequenceID.String()+"/"+period]
+	if !ok {
+		return nil, nil
+	}
+	copied := *c
+	return &copied, nil
+}
+func (f *fakeNumberingRepository) SaveCounter(ctx context.Context, counter *domainNumbering.Counter) error {
+	if counter.ID == uuid.Nil {
+		counter.ID = uuid.New()
+	}
+	copied := *counter
+	f.counters[counter.SequenceID.String()+"/"+counter.Period] = &copied
+	return nil
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
+func newInvoiceSequence(repo *fakeNumberingRepository, pattern string, reset domainNumbering.ResetPolicy) {
+	repo.sequences[domainNumbering.DocumentInvoice] = &domainNumbering.Sequence{
+		ID:           uuid.New(),
+		DocumentType: domainNumbering.DocumentInvoice,
+		Pattern:      pattern,
+		Reset:        reset,
+	}
+}
+
+func TestAllocate_GaplessWithYearlyReset(t *testing.T) {
+	repo := newFakeNumberingRepository()
+	newInvoiceSequence(repo, "INV-{YYYY}-{0000}", domainNumbering.ResetYearly)
+
+	dates := []time.Time{
+		time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC),
+	}
+	want := []string{"INV-2025-0001", "INV-2025-0002", "INV-2026-0001", "INV-2026-0002"}
+	for i, at := range dates {
+		got, err := Allocate(context.Background(), repo, domainNumbering.DocumentInvoice, at)
+		if err != nil {
+			t.Fatalf("Allocate() error = %v", err)
+		}
+		if got != want[i] {
+			t.Errorf("Allocate() = %q, want %q", got, want[i])
+		}
+	}
+}
+
+func TestAllocate_NeverResetKeepsCounting(t *testing.T) {
+	repo := newFakeNumberingRepository()
+	newInvoiceSequence(repo, "F{YY}{MM}-{00000}", domainNumbering.ResetNever)
+
+	first, _ := Allocate(context.Background(), repo, domainNumbering.DocumentInvoice, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC))
+	second, err := Allocate(context.Background(), repo, domainNumbering.DocumentInvoice, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+	if first != "F2512-00001" || second != "F2603-00002" {
+		t.Errorf("Allocate() = %q, %q, want F2512-00001, F2603-00002", first, second)
+	}
+}
+
+func TestAllocate_UnknownDocumentType(t *testing.T) {
+	_, err := Allocate(context.Background(), newFakeNumberingRepository(), domainNumbering.DocumentInvoice, time.Now())
+	if !errors.Is(err, domainNumbering.ErrSequenceNotFound) {
+		t.Fatalf("Allocate() error = %v, want %v", err, domainNumbering.ErrSequenceNotFound)
+	}
+}
+
+func TestNumberingUsecase_UpdateSequence_RejectsPatternWithoutCounter(t *testing.T) {
+	repo := newFakeNumberingRepository()
+	newInvoiceSequence(repo, "INV-{YYYY}-{0000}", domainNumbering.ResetYearly)
+	uc := NewUsecase(repo, fakeAudit{})
+
+	for _, pattern := range []string{"INV-{YYYY}", "INV-{000}-{000}"} {
+		_, err := uc.UpdateSequence(context.Background(), domainNumbering.DocumentInvoice, pattern, domainNumbering.ResetYearly)
+		if !errors.Is(err, domainNumbering.ErrInvalidPattern) {
+			t.Errorf("UpdateSequence(%q) error = %v, want %v", pattern, err, domainNumbering.ErrInvalidPattern)
+		}
+	}
+	if got := repo.sequences[domainNumbering.DocumentInvoice].Pattern; got != "INV-{YYYY}-{0000}" {
+		t.Errorf("pattern = %q, want it unchanged", got)
+	}
+
+	if _, err := uc.UpdateSequence(context.Background(), domainNumbering.DocumentInvoice, "FA{YY}-{000000}", domainNumbering.ResetNever); err != nil {
+		t.Fatalf("UpdateSequence() error = %v", err)
+	}
+}
+
+func TestNumberingUsecase_UpdateSequence_RejectsYearlyPatternWithoutYear(t *testing.T) {
+	repo := newFakeNumberingRepository()
+	newInvoiceSequence(repo, "INV-{YYYY}-{0000}", domainNumbering.ResetYearly)
+	uc := NewUsecase(repo, fakeAudit{})
+
+	for _, pattern := range []string{"INV-{0000}", "INV-{MM}-{0000}"} {
+		_, err := uc.UpdateSequence(context.Background(), domainNumbering.DocumentInvoice, pattern, domainNumbering.ResetYearly)
+		if !errors.Is(err, domainNumbering.ErrPatternWithoutYear) {
+			t.Errorf("UpdateSequence(%q) error = %v, want %v", pattern, err, domainNumbering.ErrPatternWithoutYear)
+		}
+	}
+	if got := repo.sequences[domainNumbering.DocumentInvoice].Pattern; got != "INV-{YYYY}-{0000}" {
+		t.Errorf("pattern = %q, want it unchanged", got)
+	}
+
+	// Without a reset the counter alone keeps the numbers unique
+	if _, err := uc.UpdateSequence(context.Background(), domainNumbering.DocumentInvoice, "INV-{0000}", domainNumbering.ResetNever); err != nil {
+		t.Fatalf("UpdateSequence() error = %v", err)
+	}
+	if _, err := uc.UpdateSequence(context.Background(), domainNumbering.DocumentInvoice, "FA{YY}-{0000}", domainNumbering.ResetYearly); err != nil {
+		t.Fatalf("UpdateSequence() error = %v", err)
+	}
+}
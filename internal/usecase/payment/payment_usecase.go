@@ -0,0 +1,264 @@
+// Package payment contains the use case for customer payments, their allocation to invoices
+// and the customer statement.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/invoice"
+	domainPayment "doligo_001/internal/domain/payment"
+	"doligo_001/internal/domain/thirdparty"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/usecase"
+	invoice_uc "doligo_001/internal/usecase/invoice"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrThirdPartyNotFound is returned when a payment or a statement refers to an unknown customer.
+	ErrThirdPartyNotFound = errors.New("third party not found")
+	// ErrInvoiceNotFound is returned when a payment is allocated to an unknown invoice.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+)
+
+// Usecase defines the contract for customer payments.
+type Usecase interface {
+	// Record stores a payment and allocates it to invoices of its customer, whose amount paid
+	// and status are updated. The part of the payment left unallocated is a credit of the customer.
+	Record(ctx context.Context, p *domainPayment.Payment, allocations []domainPayment.Allocation) error
+	// PayInvoice records a payment of the customer of an invoice, allocated to the invoice up to
+	// its balance due. The rest of an overpayment is left unallocated, a credit of the customer.
+	PayInvoice(ctx context.Context, invoiceID uuid.UUID, p *domainPayment.Payment) error
+	// Allocate allocates the unallocated part of a payment to invoices of its customer.
+	Allocate(ctx context.Context, id uuid.UUID, allocations []domainPayment.Allocation) (*domainPayment.Payment, error)
+	GetPayment(ctx context.Context, id uuid.UUID) (*domainPayment.Payment, error)
+	ListPayments(ctx context.Context, thirdPartyID uuid.UUID) ([]*domainPayment.Payment, error)
+	// Statement returns the account of a customer between from and to, both optional.
+	Statement(ctx context.Context, thirdPartyID uuid.UUID, from, to *time.Time) (*domainPayment.Statement, error)
+}
+
+type paymentUsecase struct {
+	txManager      db.Transactioner
+	paymentRepo    domainPayment.Repository
+	invoiceRepo    invoice_uc.Repository
+	thirdPartyRepo thirdparty.Repository
+	auditService   usecase.AuditService
+}
+
+// NewUsecase creates a new payment usecase.
+func NewUsecase(
+	txManager db.Transactioner,
+	paymentRepo domainPayment.Repository,
+	invoiceRepo invoice_uc.Repository,
+	thirdPartyRepo thirdparty.Repository,
+	auditService usecase.AuditService,
+) Usecase {
+	return &paymentUsecase{
+		txManager:      txManager,
+		paymentRepo:    paymentRepo,
+		invoiceRepo:    invoiceRepo,
+		thirdPartyRepo: thirdPartyRepo,
+		auditService:   auditService,
+	}
+}
+
+// invoiceChange is the audited state of an invoice before and after a payment allocation.
+type invoiceChange struct {
+	id       uuid.UUID
+	old, new map[string]interface{}
+}
+
+func (uc *paymentUsecase) Record(ctx context.Context, p *domainPayment.Payment, allocations []domainPayment.Allocation) error {
+	return uc.record(ctx, p, allocations, false)
+}
+
+// record creates p with its allocations. With capToBalance, each allocation is reduced to the
+// balance due of its invoice once the invoice is locked, leaving the excess unallocated.
+func (uc *paymentUsecase) record(ctx context.Context, p *domainPayment.Payment, allocations []domainPayment.Allocation, capToBalance bool) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if err := uc.validateThirdParty(ctx, p.ThirdPartyID); err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	p.UnallocatedAmount = p.Amount
+	p.Allocations = nil
+	p.SetCreatedBy(userID)
+	p.SetUpdatedBy(userID)
+
+	var changes []invoiceChange
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		if changes, err = uc.allocate(ctx, tx, p, allocations, userID, capToBalance); err != nil {
+			return err
+		}
+		return uc.paymentRepo.WithTx(tx).Create(ctx, p)
+	})
+	if err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "payment", p.ID.String(), "CREATE", nil, p, corrID)
+	uc.logInvoiceChanges(ctx, userID, p.ID, changes, corrID)
+	return nil
+}
+
+func (uc *paymentUsecase) PayInvoice(ctx context.Context, invoiceID uuid.UUID, p *domainPayment.Payment) error {
+	inv, err := uc.invoiceRepo.FindByID(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvoiceNotFound
+		}
+		return err
+	}
+	p.ThirdPartyID = inv.ThirdPartyID
+	return uc.record(ctx, p, []domainPayment.Allocation{{InvoiceID: invoiceID, Amount: p.Amount}}, true)
+}
+
+func (uc *paymentUsecase) Allocate(ctx context.Context, id uuid.UUID, allocations []domainPayment.Allocation) (*domainPayment.Payment, error) {
+	userID, _ := domain.UserIDFromContext(ctx)
+	var p *domainPayment.Payment
+	var oldUnallocated float64
+	var changes []invoiceChange
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txPaymentRepo := uc.paymentRepo.WithTx(tx)
+		var err error
+		if p, err = txPaymentRepo.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		oldUnallocated = p.UnallocatedAmount
+		if changes, err = uc.allocate(ctx, tx, p, allocations, userID, false); err != nil {
+			return err
+		}
+		p.SetUpdatedBy(userID)
+		return txPaymentRepo.Update(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "payment", p.ID.String(), "ALLOCATE",
+		map[string]interface{}{"unallocated_amount": oldUnallocated},
+		map[string]interface{}{"unallocated_amount": p.UnallocatedAmount, "allocations": allocations},
+		corrID)
+	uc.logInvoiceChanges(ctx, userID, p.ID, changes, corrID)
+	return p, nil
+}
+
+// allocate allocates amounts of p to invoices of its customer and applies them to the invoices.
+// Allocations to the same invoice are merged, and the invoices are locked in ID order so that
+// concurrent allocations cannot deadlock. With capToBalance, an amount beyond the balance due of
+// the locked invoice is left unallocated.
+func (uc *paymentUsecase) allocate(ctx context.Context, tx *gorm.DB, p *domainPayment.Payment, allocations []domainPayment.Allocation, userID uuid.UUID, capToBalance bool) ([]invoiceChange, error) {
+	amounts := make(map[uuid.UUID]float64, len(allocations))
+	var invoiceIDs []uuid.UUID
+	for _, a := range allocations {
+		if a.Amount <= 0 {
+			return nil, domainPayment.ErrInvalidAmount
+		}
+		if _, ok := amounts[a.InvoiceID]; !ok {
+			invoiceIDs = append(invoiceIDs, a.InvoiceID)
+		}
+		amounts[a.InvoiceID] += a.Amount
+	}
+	sort.Slice(invoiceIDs, func(i, j int) bool { return invoiceIDs[i].String() < invoiceIDs[j].String() })
+
+	txInvoiceRepo := uc.invoiceRepo.WithTx(tx)
+	changes := make([]invoiceChange, 0, len(invoiceIDs))
+	for _, invoiceID := range invoiceIDs {
+		inv, err := txInvoiceRepo.FindByIDForUpdate(ctx, invoiceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrInvoiceNotFound, invoiceID)
+			}
+			return nil, err
+		}
+		if inv.ThirdPartyID != p.ThirdPartyID {
+			return nil, fmt.Errorf("%w: %s", domainPayment.ErrInvoiceOfOtherCustomer, inv.Number)
+		}
+		old := invoicePaymentState(inv)
+		amount := amounts[invoiceID]
+		if balance := inv.BalanceDue(); capToBalance && balance > 0 && balance < amount {
+			amount = balance
+		}
+		if err := p.Allocate(invoiceID, amount); err != nil {
+			return nil, err
+		}
+		if err := inv.ApplyPayment(amount); err != nil {
+			return nil, fmt.Errorf("invoice %s: %w", inv.Number, err)
+		}
+		inv.SetUpdatedBy(userID)
+		if err := txInvoiceRepo.Update(ctx, inv); err != nil {
+			return nil, err
+		}
+		changes = append(changes, invoiceChange{id: inv.ID, old: old, new: invoicePaymentState(inv)})
+	}
+	for i := range p.Allocations {
+		if p.Allocations[i].CreatedBy == uuid.Nil {
+			p.Allocations[i].CreatedBy = userID
+		}
+	}
+	return changes, nil
+}
+
+// invoicePaymentState is the audited payment state of an invoice.
+func invoicePaymentState(inv *invoice.Invoice) map[string]interface{} {
+	return map[string]interface{}{"status": inv.Status, "amount_paid": inv.AmountPaid, "balance_due": inv.BalanceDue()}
+}
+
+func (uc *paymentUsecase) logInvoiceChanges(ctx context.Context, userID, paymentID uuid.UUID, changes []invoiceChange, corrID string) {
+	for _, change := range changes {
+		change.new["payment_id"] = paymentID
+		uc.auditService.Log(ctx, userID, "invoice", change.id.String(), "PAYMENT", change.old, change.new, corrID)
+	}
+}
+
+func (uc *paymentUsecase) GetPayment(ctx context.Context, id uuid.UUID) (*domainPayment.Payment, error) {
+	return uc.paymentRepo.GetByID(ctx, id)
+}
+
+func (uc *paymentUsecase) ListPayments(ctx context.Context, thirdPartyID uuid.UUID) ([]*domainPayment.Payment, error) {
+	if err := uc.validateThirdParty(ctx, thirdPartyID); err != nil {
+		return nil, err
+	}
+	return uc.paymentRepo.ListByThirdParty(ctx, thirdPartyID)
+}
+
+func (uc *paymentUsecase) Statement(ctx context.Context, thirdPartyID uuid.UUID, from, to *time.Time) (*domainPayment.Statement, error) {
+	if err := uc.validateThirdParty(ctx, thirdPartyID); err != nil {
+		return nil, err
+	}
+	invoices, err := uc.invoiceRepo.ListByThirdParty(ctx, thirdPartyID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := uc.paymentRepo.ListByThirdParty(ctx, thirdPartyID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildStatement(thirdPartyID, invoices, payments, from, to), nil
+}
+
+func (uc *paymentUsecase) validateThirdParty(ctx context.Context, thirdPartyID uuid.UUID) error {
+	if _, err := uc.thirdPartyRepo.GetByID(ctx, thirdPartyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrThirdPartyNotFound
+		}
+		return err
+	}
+	return nil
+}
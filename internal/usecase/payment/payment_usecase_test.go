@@ -0,0 +1,356 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"doligo_001/internal/domain/invoice"
+	domainPayment "doligo_001/internal/domain/payment"
+	"doligo_001/internal/domain/thirdparty"
+	invoice_uc "doligo_001/internal/usecase/invoice"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakePaymentRepository is an in-memory payment.Repository.
+type fakePaymentRepository struct {
+	payments map[uuid.UUID]*domainPayment.Payment
+}
+
+func (f *fakePaymentRepository) WithTx(tx *gorm.DB) domainPayment.Repository { return f }
+func (f *fakePaymentRepository) Create(ctx context.Context, p *domainPayment.Payment) error {
+	f.payments[p.ID] = p
+	return nil
+}
+func (f *fakePaymentRepository) Update(ctx context.Context, p *domainPayment.Payment) error {
+	f.payments[p.ID] = p
+	return nil
+}
+func (f *fakePaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainPayment.Payment, error) {
+	p, ok := f.payments[id]
+	if !ok {
+		return nil, domainPayment.ErrPaymentNotFound
+	}
+	copied := *p
+	copied.Allocations = append([]domainPayment.Allocation(nil), p.Allocations...)
+	return &copied, nil
+}
+func (f *fakePaymentRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domainPayment.Payment, error) {
+	return f.GetByID(ctx, id)
+}
+func (f *fakePaymentRepository) ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*domainPayment.Payment, error) {
+	var list []*domainPayment.Payment
+	for _, p := range f.payments {
+		if p.ThirdPartyID == thirdPartyID {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+// fakeInvoiceRepository is an in-memory invoice repository.
+type fakeInvoiceRepository struct {
+	invoices   map[uuid.UUID]*invoice.Invoice
+	beforeLock func(id uuid.UUID) // Simulates a concurrent change before the invoice is locked
+}
+
+func (f *fakeInvoiceRepository) WithTx(tx *gorm.DB) invoice_uc.Repository { return f }
+func (f *fakeInvoiceRepository) Create(ctx context.Context, inv *invoice.Invoice) error {
+	f.invoices[inv.ID] = inv
+	return nil
+}
+func (f *fakeInvoiceRepository) Update(ctx context.Context, inv *invoice.Invoice) error {
+	f.invoices[inv.ID] = inv
+	return nil
+}
+func (f *fakeInvoiceRepository) UpdatePDFStatus(ctx context.Context, inv *invoice.Invoice) error {
+	return nil
+}
+func (f *fakeInvoiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.invoices, id)
+	return nil
+}
+func (f *fakeInvoiceRepository) FindByID(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	inv, ok := f.invoices[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *inv
+	return &copied, nil
+}
+func (f *fakeInvoiceRepository) FindByIDWithDetails(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	return f.FindByID(ctx, id)
+}
+func (f *fakeInvoiceRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*invoice.Invoice, error) {
+	if f.beforeLock != nil {
+		f.beforeLock(id)
+	}
+	return f.FindByID(ctx, id)
+}
+func (f *fakeInvoiceRepository) ListCreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*invoice.Invoice, error) {
+	return nil, nil
+}
+func (f *fakeInvoiceRepository) ListByThirdParty(ctx context.Context, thirdPartyID uuid.UUID) ([]*invoice.Invoice, error) {
+	var list []*invoice.Invoice
+	for _, inv := range f.invoices {
+		if inv.ThirdPartyID == thirdPartyID {
+			list = append(list, inv)
+		}
+	}
+	return list, nil
+}
+func (f *fakeInvoiceRepository) CreditedQuantities(ctx context.Context, invoiceID uuid.UUID) (map[uuid.UUID]float64, error) {
+	return nil, nil
+}
+
+// fakeThirdPartyRepository is an in-memory thirdparty.Repository.
+type fakeThirdPartyRepository struct {
+	thirdParties map[uuid.UUID]*thirdparty.ThirdParty
+}
+
+func (f *fakeThirdPartyRepository) Create(ctx context.Context, tp *thirdparty.ThirdParty) error {
+	f.thirdParties[tp.ID] = tp
+	return nil
+}
+func (f *fakeThirdPartyRepository) GetByID(ctx context.Context, id uuid.UUID) (*thirdparty.ThirdParty, error) {
+	tp, ok := f.thirdParties[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return tp, nil
+}
+func (f *fakeThirdPartyRepository) Update(ctx context.Context, tp *thirdparty.ThirdParty) error {
+	return nil
+}
+func (f *fakeThirdPartyRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeThirdPartyRepository) List(ctx context.Context) ([]*thirdparty.ThirdParty, error) {
+	return nil, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return fc(nil)
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
+// paymentFixture wires the payment usecase to in-memory repositories.
+type paymentFixture struct {
+	payments     *fakePaymentRepository
+	invoices     *fakeInvoiceRepository
+	thirdParties *fakeThirdPartyRepository
+	usecase      Usecase
+}
+
+func newPaymentFixture() *paymentFixture {
+	f := &paymentFixture{
+		payments:     &fakePaymentRepository{payments: make(map[uuid.UUID]*domainPayment.Payment)},
+		invoices:     &fakeInvoiceRepository{invoices: make(map[uuid.UUID]*invoice.Invoice)},
+		thirdParties: &fakeThirdPartyRepository{thirdParties: make(map[uuid.UUID]*thirdparty.ThirdParty)},
+	}
+	f.usecase = NewUsecase(fakeTx{}, f.payments, f.invoices, f.thirdParties, fakeAudit{})
+	return f
+}
+
+func (f *paymentFixture) addCustomer() uuid.UUID {
+	id := uuid.New()
+	f.thirdParties.thirdParties[id] = &thirdparty.ThirdParty{ID: id, Name: "Customer", Type: thirdparty.Customer, IsActive: true}
+	return id
+}
+
+// addInvoice registers a validated invoice of total for the customer.
+func (f *paymentFixture) addInvoice(customerID uuid.UUID, number string, total float64) uuid.UUID {
+	id := uuid.New()
+	f.invoices.invoices[id] = &invoice.Invoice{
+		ID:           id,
+		ThirdPartyID: customerID,
+		Number:       number,
+		Date:         time.Now(),
+		Status:       invoice.StatusValidated,
+		DocumentType: invoice.TypeStandard,
+		TotalAmount:  total,
+	}
+	return id
+}
+
+func TestPaymentUsecase_Record_PartialAllocation(t *testing.T) {
+	f := newPaymentFixture()
+	customerID := f.addCustomer()
+	invoiceID := f.addInvoice(customerID, "INV-0001", 100)
+
+	p := &domainPayment.Payment{ThirdPartyID: customerID, Date: time.Now(), Amount: 40, Method: domainPayment.MethodBankTransfer}
+	if err := f.usecase.Record(context.Background(), p, []domainPayment.Allocation{{InvoiceID: invoiceID, Amount: 40}}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	inv := f.invoices.invoices[invoiceID]
+	if inv.Status != invoice.StatusPartiallyPaid || inv.AmountPaid != 40 || inv.BalanceDue() != 60 {
+		t.Errorf("invoice = %s, paid %v, due %v, want PARTIALLY_PAID, paid 40, due 60", inv.Status, inv.AmountPaid, inv.BalanceDue())
+	}
+	if p.UnallocatedAmount != 0 || len(p.Allocations) != 1 {
+		t.Errorf("payment unallocated = %v with %d allocations, want 0 with 1", p.UnallocatedAmount, len(p.Allocations))
+	}
+}
+
+func TestPaymentUsecase_Overpayment_IsCreditAllocatedLater(t *testing.T) {
+	f := newPaymentFixture()
+	customerID := f.addCustomer()
+	first := f.addInvoice(customerID, "INV-0001", 100)
+
+	p := &domainPayment.Payment{ThirdPartyID: customerID, Date: time.Now(), Amount: 150, Method: domainPayment.MethodCash}
+	if err := f.usecase.Record(context.Background(), p, []domainPayment.Allocation{{InvoiceID: first, Amount: 100}}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if f.invoices.invoices[first].Status != invoice.StatusPaid || p.UnallocatedAmount != 50 {
+		t.Fatalf("invoice = %s, unallocated = %v, want PAID, 50", f.invoices.invoices[first].Status, p.UnallocatedAmount)
+	}
+
+	second := f.addInvoice(customerID, "INV-0002", 30)
+	if _, err := f.usecase.Allocate(context.Background(), p.ID, []domainPayment.Allocation{{InvoiceID: second, Amount: 60}}); !errors.Is(err, domainPayment.ErrAllocationExceedsPayment) {
+		t.Fatalf("Allocate(60) error = %v, want %v", err, domainPayment.ErrAllocationExceedsPayment)
+	}
+	allocated, err := f.usecase.Allocate(context.Background(), p.ID, []domainPayment.Allocation{{InvoiceID: second, Amount: 30}})
+	if err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+	if f.invoices.invoices[second].Status != invoice.StatusPaid {
+		t.Errorf("second invoice = %s, want PAID", f.invoices.invoices[second].Status)
+	}
+	if allocated.UnallocatedAmount != 20 || len(allocated.Allocations) != 2 {
+		t.Errorf("payment unallocated = %v with %d allocations, want 20 with 2", allocated.UnallocatedAmount, len(allocated.Allocations))
+	}
+}
+
+func TestPaymentUsecase_PayInvoice_OverpaymentLeftUnallocated(t *testing.T) {
+	f := newPaymentFixture()
+	customerID := f.addCustomer()
+	invoiceID := f.addInvoice(customerID, "INV-0001", 100)
+	f.invoices.invoices[invoiceID].Status, f.invoices.invoices[invoiceID].AmountPaid = invoice.StatusPartiallyPaid, 30
+
+	p := &domainPayment.Payment{Date: time.Now(), Amount: 100, Method: domainPayment.MethodCash}
+	if err := f.usecase.PayInvoice(context.Background(), invoiceID, p); err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+
+	inv := f.invoices.invoices[invoiceID]
+	if inv.Status != invoice.StatusPaid || inv.AmountPaid != 100 {
+		t.Errorf("invoice = %s, paid %v, want PAID, paid 100", inv.Status, inv.AmountPaid)
+	}
+	if p.ThirdPartyID != customerID || p.UnallocatedAmount != 30 || len(p.Allocations) != 1 || p.Allocations[0].Amount != 70 {
+		t.Errorf("payment = %v unallocated with allocations %+v, want 30 unallocated and 70 allocated to the invoice", p.UnallocatedAmount, p.Allocations)
+	}
+
+	// A paid invoice takes no further payment
+	again := &domainPayment.Payment{Date: time.Now(), Amount: 10, Method: domainPayment.MethodCash}
+	if err := f.usecase.PayInvoice(context.Background(), invoiceID, again); !errors.Is(err, invoice.ErrInvalidInvoiceStatus) {
+		t.Errorf("PayInvoice() of a paid invoice error = %v, want %v", err, invoice.ErrInvalidInvoiceStatus)
+	}
+}
+
+func TestPaymentUsecase_PayInvoice_CapsAtBalanceOfLockedInvoice(t *testing.T) {
+	f := newPaymentFixture()
+	customerID := f.addCustomer()
+	invoiceID := f.addInvoice(customerID, "INV-0001", 100)
+	// Another payment of 60 is applied after PayInvoice has read the invoice and before it locks it
+	f.invoices.beforeLock = func(id uuid.UUID) {
+		f.invoices.invoices[id].Status, f.invoices.invoices[id].AmountPaid = invoice.StatusPartiallyPaid, 60
+		f.invoices.beforeLock = nil
+	}
+
+	p := &domainPayment.Payment{Date: time.Now(), Amount: 100, Method: domainPayment.MethodCash}
+	if err := f.usecase.PayInvoice(context.Background(), invoiceID, p); err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+
+	inv := f.invoices.invoices[invoiceID]
+	if inv.Status != invoice.StatusPaid || inv.AmountPaid != 100 {
+		t.Errorf("invoice = %s, paid %v, want PAID, paid 100", inv.Status, inv.AmountPaid)
+	}
+	if p.UnallocatedAmount != 60 || len(p.Allocations) != 1 || p.Allocations[0].Amount != 40 {
+		t.Errorf("payment = %v unallocated with allocations %+v, want 60 unallocated and 40 allocated to the invoice", p.UnallocatedAmount, p.Allocations)
+	}
+}
+
+func TestPaymentUsecase_Record_RejectsInvoiceOfOtherCustomer(t *testing.T) {
+	f := newPaymentFixture()
+	customerID := f.addCustomer()
+	otherInvoice := f.addInvoice(f.addCustomer(), "INV-0001", 100)
+
+	p := &domainPayment.Payment{ThirdPartyID: customerID, Date: time.Now(), Amount: 100, Method: domainPayment.MethodCard}
+	err := f.usecase.Record(context.Background(), p, []domainPayment.Allocation{{InvoiceID: otherInvoice, Amount: 100}})
+	if !errors.Is(err, domainPayment.ErrInvoiceOfOtherCustomer) {
+		t.Fatalf("Record() error = %v, want %v", err, domainPayment.ErrInvoiceOfOtherCustomer)
+	}
+	if len(f.payments.payments) != 0 || f.invoices.invoices[otherInvoice].AmountPaid != 0 {
+		t.Errorf("payment recorded or invoice paid despite the error")
+	}
+}
+
+func TestBuildStatement_RunningBalance(t *testing.T) {
+	customerID := uuid.New()
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	invoices := []*invoice.Invoice{
+		{ID: uuid.New(), Number: "INV-0001", Date: day(1), Status: invoice.StatusPaid, DocumentType: invoice.TypeStandard, TotalAmount: 100},
+		{ID: uuid.New(), Number: "INV-0002", Date: day(10), Status: invoice.StatusValidated, DocumentType: invoice.TypeStandard, TotalAmount: 200},
+		{ID: uuid.New(), Number: "CN-0001", Date: day(12), Status: invoice.StatusValidated, DocumentType: invoice.TypeCreditNote, TotalAmount: 50},
+		{ID: uuid.New(), Date: day(11), Status: invoice.StatusDraft, DocumentType: invoice.TypeStandard, TotalAmount: 999},
+		{ID: uuid.New(), Number: "INV-0003", Date: day(20), Status: invoice.StatusValidated, DocumentType: invoice.TypeStandard, TotalAmount: 80},
+	}
+	payments := []*domainPayment.Payment{
+		{ID: uuid.New(), Date: day(1), Amount: 100, Method: domainPayment.MethodCash},
+		{ID: uuid.New(), Date: day(15), Amount: 170, Method: domainPayment.MethodBankTransfer, Reference: "TR-1", UnallocatedAmount: 20},
+	}
+
+	from, to := day(5), day(15)
+	statement := BuildStatement(customerID, invoices, payments, &from, &to)
+
+	if statement.OpeningBalance != 0 {
+		t.Errorf("opening balance = %v, want 0", statement.OpeningBalance)
+	}
+	want := []struct {
+		reference string
+		balance   float64
+	}{{"INV-0002", 200}, {"CN-0001", 150}, {"TR-1", -20}}
+	if len(statement.Entries) != len(want) {
+		t.Fatalf("entries = %d, want %d", len(statement.Entries), len(want))
+	}
+	for i, w := range want {
+		if e := statement.Entries[i]; e.Reference != w.reference || math.Abs(e.Balance-w.balance) > 1e-9 {
+			t.Errorf("entry %d = %s balance %v, want %s balance %v", i, e.Reference, e.Balance, w.reference, w.balance)
+		}
+	}
+	if statement.ClosingBalance != -20 || statement.UnallocatedCredit != 20 {
+		t.Errorf("closing balance = %v, unallocated credit = %v, want -20, 20", statement.ClosingBalance, statement.UnallocatedCredit)
+	}
+}
+
+func TestBuildStatement_CreditBeyondBalanceIsCustomerCredit(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	// INV-0001 was paid in full before CN-0001 credited 30 of it; INV-0002 is credited within its balance
+	invoices := []*invoice.Invoice{
+		{ID: uuid.New(), Number: "INV-0001", Date: day(1), Status: invoice.StatusPaid, DocumentType: invoice.TypeStandard, TotalAmount: 100, AmountPaid: 100, AmountCredited: 30},
+		{ID: uuid.New(), Number: "CN-0001", Date: day(5), Status: invoice.StatusValidated, DocumentType: invoice.TypeCreditNote, TotalAmount: 30},
+		{ID: uuid.New(), Number: "INV-0002", Date: day(6), Status: invoice.StatusValidated, DocumentType: invoice.TypeStandard, TotalAmount: 50, AmountCredited: 20},
+		{ID: uuid.New(), Number: "CN-0002", Date: day(7), Status: invoice.StatusValidated, DocumentType: invoice.TypeCreditNote, TotalAmount: 20},
+	}
+	payments := []*domainPayment.Payment{
+		{ID: uuid.New(), Date: day(2), Amount: 110, Method: domainPayment.MethodCash, UnallocatedAmount: 10},
+	}
+
+	statement := BuildStatement(uuid.New(), invoices, payments, nil, nil)
+
+	// The customer owes 30 on INV-0002 and is owed the 10 left on the payment and the 30 credited beyond INV-0001
+	if math.Abs(statement.ClosingBalance-(-10)) > 1e-9 {
+		t.Errorf("closing balance = %v, want -10", statement.ClosingBalance)
+	}
+	if math.Abs(statement.UnallocatedCredit-40) > 1e-9 {
+		t.Errorf("unallocated credit = %v, want 40", statement.UnallocatedCredit)
+	}
+}
@@ -0,0 +1,76 @@
+package payment
+
+import (
+	"sort"
+	"time"
+
+	"doligo_001/internal/domain/invoice"
+	domainPayment "doligo_001/internal/domain/payment"
+	"github.com/google/uuid"
+)
+
+// entryOrder orders the entries of a day: the documents billing the customer first, so that
+// a payment of the day settles an invoice of the day.
+var entryOrder = map[domainPayment.EntryType]int{
+	domainPayment.EntryInvoice:    0,
+	domainPayment.EntryCreditNote: 1,
+	domainPayment.EntryPayment:    2,
+}
+
+// balanceEpsilon ignores the rounding left on the balance of a fully paid or credited invoice.
+const balanceEpsilon = 0.005
+
+// BuildStatement builds the statement of a customer from its invoices, credit notes and
+// payments. Drafts and cancelled documents are left out. Entries dated before from make up the
+// opening balance and entries after the day to are left out; nil bounds are open. The
+// unallocated credit holds the payments not allocated yet and the credit notes in excess of the
+// balance of their invoice.
+func BuildStatement(thirdPartyID uuid.UUID, invoices []*invoice.Invoice, payments []*domainPayment.Payment, from, to *time.Time) *domainPayment.Statement {
+	statement := &domainPayment.Statement{ThirdPartyID: thirdPartyID, From: from, To: to}
+	var entries []domainPayment.StatementEntry
+	for _, inv := range invoices {
+		if inv.Status == invoice.StatusDraft || inv.Status == invoice.StatusCancelled {
+			continue
+		}
+		entry := domainPayment.StatementEntry{Date: inv.Date, Type: domainPayment.EntryInvoice, DocumentID: inv.ID, Reference: inv.Number, Debit: inv.TotalAmount}
+		if inv.IsCreditNote() {
+			entry.Type, entry.Debit, entry.Credit = domainPayment.EntryCreditNote, 0, inv.TotalAmount
+		} else if balance := inv.BalanceDue(); balance < -balanceEpsilon {
+			// Credited beyond what was left to pay: the excess is owed to the customer
+			statement.UnallocatedCredit -= balance
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, p := range payments {
+		reference := p.Reference
+		if reference == "" {
+			reference = string(p.Method)
+		}
+		entries = append(entries, domainPayment.StatementEntry{Date: p.Date, Type: domainPayment.EntryPayment, DocumentID: p.ID, Reference: reference, Credit: p.Amount})
+		statement.UnallocatedCredit += p.UnallocatedAmount
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		di, dj := entries[i].Date.Truncate(24*time.Hour), entries[j].Date.Truncate(24*time.Hour)
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return entryOrder[entries[i].Type] < entryOrder[entries[j].Type]
+	})
+
+	balance := 0.0
+	for _, entry := range entries {
+		if to != nil && !entry.Date.Before(to.AddDate(0, 0, 1)) {
+			break
+		}
+		balance += entry.Debit - entry.Credit
+		if from != nil && entry.Date.Before(*from) {
+			statement.OpeningBalance = balance
+			continue
+		}
+		entry.Balance = balance
+		statement.Entries = append(statement.Entries, entry)
+	}
+	statement.ClosingBalance = balance
+	return statement
+}
@@ -0,0 +1,382 @@
+// Package replenishment contains the use case for reorder rules and the
+// replenishment engine that turns them into purchase or production proposals.
+package replenishment
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	domainReplenishment "doligo_001/internal/domain/replenishment"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/usecase"
+	uom_uc "doligo_001/internal/usecase/uom"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrItemNotFound is returned when a rule refers to an unknown item.
+	ErrItemNotFound = errors.New("item not found")
+	// ErrNotStorable is returned when a rule is set on a service item.
+	ErrNotStorable = errors.New("reorder rules are only available for storable items")
+	// ErrWarehouseNotFound is returned when a rule refers to an unknown warehouse.
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+)
+
+// Usecase defines the contract for reorder rules and replenishment runs.
+type Usecase interface {
+	CreateRule(ctx context.Context, rule *domainReplenishment.ReorderRule) error
+	GetRule(ctx context.Context, id uuid.UUID) (*domainReplenishment.ReorderRule, error)
+	ListRules(ctx context.Context, filter domainReplenishment.RuleFilter) ([]*domainReplenishment.ReorderRule, error)
+	UpdateRule(ctx context.Context, rule *domainReplenishment.ReorderRule) error
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	// Run computes the proposals of every active rule and replaces the proposals of the previous run.
+	Run(ctx context.Context) ([]*domainReplenishment.Proposal, error)
+	ListProposals(ctx context.Context, filter domainReplenishment.ProposalFilter) ([]*domainReplenishment.Proposal, error)
+}
+
+type replenishmentUsecase struct {
+	txManager       db.Transactioner
+	ruleRepo        domainReplenishment.RuleRepository
+	proposalRepo    domainReplenishment.ProposalRepository
+	stockRepo       stock.StockRepository
+	reservationRepo stock.ReservationRepository
+	warehouseRepo   stock.WarehouseRepository
+	bomRepo         domainBom.Repository
+	itemRepo        item.Repository
+	unitRepo        uom.Repository
+	auditService    usecase.AuditService
+}
+
+// NewUsecase creates a new replenishment usecase.
+func NewUsecase(
+	txManager db.Transactioner,
+	ruleRepo domainReplenishment.RuleRepository,
+	proposalRepo domainReplenishment.ProposalRepository,
+	stockRepo stock.StockRepository,
+	reservationRepo stock.ReservationRepository,
+	warehouseRepo stock.WarehouseRepository,
+	bomRepo domainBom.Repository,
+	itemRepo item.Repository,
+	unitRepo uom.Repository,
+	auditService usecase.AuditService,
+) Usecase {
+	return &replenishmentUsecase{
+		txManager:       txManager,
+		ruleRepo:        ruleRepo,
+		proposalRepo:    proposalRepo,
+		stockRepo:       stockRepo,
+		reservationRepo: reservationRepo,
+		warehouseRepo:   warehouseRepo,
+		bomRepo:         bomRepo,
+		itemRepo:        itemRepo,
+		unitRepo:        unitRepo,
+		auditService:    auditService,
+	}
+}
+
+// CreateRule validates and stores a new reorder rule. An item has at most one rule per warehouse.
+func (uc *replenishmentUsecase) CreateRule(ctx context.Context, rule *domainReplenishment.ReorderRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if err := uc.validateTarget(ctx, rule.ItemID, rule.WarehouseID); err != nil {
+		return err
+	}
+	if _, err := uc.ruleRepo.GetByItemAndWarehouse(ctx, rule.ItemID, rule.WarehouseID); err == nil {
+		return domainReplenishment.ErrRuleExists
+	} else if !errors.Is(err, domainReplenishment.ErrRuleNotFound) {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	rule.ID = uuid.New()
+	rule.IsActive = true
+	rule.SetCreatedBy(userID)
+	rule.SetUpdatedBy(userID)
+	if err := uc.ruleRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "reorder_rule", rule.ID.String(), "CREATE", nil, rule, corrID)
+	return nil
+}
+
+// GetRule retrieves a reorder rule by its ID.
+func (uc *replenishmentUsecase) GetRule(ctx context.Context, id uuid.UUID) (*domainReplenishment.ReorderRule, error) {
+	return uc.ruleRepo.GetByID(ctx, id)
+}
+
+// ListRules retrieves the reorder rules matching the filter.
+func (uc *replenishmentUsecase) ListRules(ctx context.Context, filter domainReplenishment.RuleFilter) ([]*domainReplenishment.ReorderRule, error) {
+	return uc.ruleRepo.List(ctx, filter)
+}
+
+// UpdateRule changes the levels and the active flag of a rule. Its item and warehouse are kept.
+func (uc *replenishmentUsecase) UpdateRule(ctx context.Context, rule *domainReplenishment.ReorderRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	oldRule, err := uc.ruleRepo.GetByID(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	rule.ItemID = oldRule.ItemID
+	rule.WarehouseID = oldRule.WarehouseID
+	rule.CreatedAt = oldRule.CreatedAt
+	rule.CreatedBy = oldRule.CreatedBy
+	rule.SetUpdatedBy(userID)
+	if err := uc.ruleRepo.Update(ctx, rule); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "reorder_rule", rule.ID.String(), "UPDATE", oldRule, rule, corrID)
+	return nil
+}
+
+// DeleteRule removes a reorder rule. The proposals of the current run are kept until the next run.
+func (uc *replenishmentUsecase) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	oldRule, err := uc.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.ruleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "reorder_rule", id.String(), "DELETE", oldRule, nil, corrID)
+	return nil
+}
+
+// ListProposals retrieves the proposals of the latest run.
+func (uc *replenishmentUsecase) ListProposals(ctx context.Context, filter domainReplenishment.ProposalFilter) ([]*domainReplenishment.Proposal, error) {
+	return uc.proposalRepo.List(ctx, filter)
+}
+
+// Run compares every active rule with the projected quantity of its item in the warehouse:
+// the on-hand stock, minus the open quantity of the held reservations, minus the component
+// demand of the production proposals of parent items. A rule whose projected quantity is at
+// or below its reorder point yields a proposal up to its maximum. Items with an active BOM
+// are proposed for production, the others for purchase.
+func (uc *replenishmentUsecase) Run(ctx context.Context) ([]*domainReplenishment.Proposal, error) {
+	rules, err := uc.ruleRepo.List(ctx, domainReplenishment.RuleFilter{ActiveOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	rulesByWarehouse := make(map[uuid.UUID][]*domainReplenishment.ReorderRule)
+	var warehouseIDs []uuid.UUID
+	for _, rule := range rules {
+		if _, ok := rulesByWarehouse[rule.WarehouseID]; !ok {
+			warehouseIDs = append(warehouseIDs, rule.WarehouseID)
+		}
+		rulesByWarehouse[rule.WarehouseID] = append(rulesByWarehouse[rule.WarehouseID], rule)
+	}
+	sortIDs(warehouseIDs)
+
+	runID := uuid.New()
+	now := time.Now()
+	proposals := []*domainReplenishment.Proposal{}
+	for _, warehouseID := range warehouseIDs {
+		planned, err := uc.planWarehouse(ctx, runID, now, warehouseID, rulesByWarehouse[warehouseID])
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, planned...)
+	}
+
+	err = uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		return uc.proposalRepo.WithTx(tx).Replace(ctx, proposals)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// planWarehouse computes the proposals of the rules of one warehouse. Parent items are planned
+// before their components so the demand of a production proposal reaches the components.
+func (uc *replenishmentUsecase) planWarehouse(ctx context.Context, runID uuid.UUID, now time.Time, warehouseID uuid.UUID, rules []*domainReplenishment.ReorderRule) ([]*domainReplenishment.Proposal, error) {
+	// 1. Supply: on-hand stock of every bin, minus the reservations still held
+	onHand := make(map[uuid.UUID]float64)
+	stocks, err := uc.stockRepo.ListByWarehouse(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stocks {
+		onHand[s.ItemID] += s.Quantity
+	}
+	reserved := make(map[uuid.UUID]float64)
+	reservations, err := uc.reservationRepo.List(ctx, stock.ReservationFilter{WarehouseID: &warehouseID, Status: stock.ReservationActive})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reservations {
+		if r.IsHeld(now) {
+			reserved[r.ItemID] += r.OpenQuantity()
+		}
+	}
+
+	// 2. Active BOMs of the planned items
+	boms := make(map[uuid.UUID]*domainBom.BillOfMaterials)
+	for _, rule := range rules {
+		b, err := uc.bomRepo.GetEffective(ctx, rule.ItemID, now)
+		if err != nil {
+			if errors.Is(err, domainBom.ErrBOMNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if b.IsActive {
+			boms[rule.ItemID] = b
+		}
+	}
+
+	// 3. Plan parents first and pass the component demand down
+	demand := make(map[uuid.UUID]float64)
+	var proposals []*domainReplenishment.Proposal
+	for _, rule := range planningOrder(rules, boms) {
+		projected := onHand[rule.ItemID] - reserved[rule.ItemID] - demand[rule.ItemID]
+		if projected > rule.ReorderPoint {
+			continue
+		}
+
+		proposal := &domainReplenishment.Proposal{
+			ID:              uuid.New(),
+			RunID:           runID,
+			RuleID:          rule.ID,
+			ItemID:          rule.ItemID,
+			WarehouseID:     warehouseID,
+			Type:            domainReplenishment.ProposalPurchase,
+			Quantity:        rule.MaxQuantity - projected,
+			OnHand:          onHand[rule.ItemID],
+			Reserved:        reserved[rule.ItemID],
+			ComponentDemand: demand[rule.ItemID],
+			Projected:       projected,
+			ReorderPoint:    rule.ReorderPoint,
+			MinQuantity:     rule.MinQuantity,
+			MaxQuantity:     rule.MaxQuantity,
+			GeneratedAt:     now,
+		}
+		if b, ok := boms[rule.ItemID]; ok {
+			bomID := b.ID
+			proposal.Type = domainReplenishment.ProposalProduction
+			proposal.BOMID = &bomID
+			for _, component := range b.Components {
+				if !component.IsActive {
+					continue
+				}
+				factor, err := uc.componentFactor(ctx, component)
+				if err != nil {
+					return nil, err
+				}
+				demand[component.ComponentItemID] += proposal.Quantity * b.PlannedQuantity(component) * factor
+			}
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+// componentFactor returns the number of base units of the component item in one unit of the
+// BOM line, as stock and rules are kept in the base unit.
+func (uc *replenishmentUsecase) componentFactor(ctx context.Context, component domainBom.BillOfMaterialsComponent) (float64, error) {
+	if component.UnitOfMeasure == "" {
+		return 1, nil
+	}
+	it, err := uc.itemRepo.GetByID(ctx, component.ComponentItemID)
+	if err != nil {
+		return 0, err
+	}
+	return uom_uc.BaseFactor(ctx, uc.unitRepo, it, component.UnitOfMeasure)
+}
+
+// planningOrder sorts the rules so that an item comes after every item whose BOM uses it.
+// Items caught in a BOM cycle are appended in ID order once no other item can be planned.
+func planningOrder(rules []*domainReplenishment.ReorderRule, boms map[uuid.UUID]*domainBom.BillOfMaterials) []*domainReplenishment.ReorderRule {
+	ruleByItem := make(map[uuid.UUID]*domainReplenishment.ReorderRule, len(rules))
+	itemIDs := make([]uuid.UUID, 0, len(rules))
+	for _, rule := range rules {
+		ruleByItem[rule.ItemID] = rule
+		itemIDs = append(itemIDs, rule.ItemID)
+	}
+	sortIDs(itemIDs)
+
+	parents := make(map[uuid.UUID]int)
+	for _, b := range boms {
+		for _, component := range b.Components {
+			if _, ok := ruleByItem[component.ComponentItemID]; ok && component.IsActive {
+				parents[component.ComponentItemID]++
+			}
+		}
+	}
+
+	ordered := make([]*domainReplenishment.ReorderRule, 0, len(rules))
+	planned := make(map[uuid.UUID]bool, len(rules))
+	for len(ordered) < len(rules) {
+		progress := false
+		for _, itemID := range itemIDs {
+			if planned[itemID] || parents[itemID] > 0 {
+				continue
+			}
+			planned[itemID] = true
+			ordered = append(ordered, ruleByItem[itemID])
+			progress = true
+			if b, ok := boms[itemID]; ok {
+				for _, component := range b.Components {
+					if component.IsActive {
+						parents[component.ComponentItemID]--
+					}
+				}
+			}
+		}
+		if !progress {
+			for _, itemID := range itemIDs {
+				if !planned[itemID] {
+					planned[itemID] = true
+					ordered = append(ordered, ruleByItem[itemID])
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// validateTarget ensures the item of a rule is storable and its warehouse exists.
+func (uc *replenishmentUsecase) validateTarget(ctx context.Context, itemID, warehouseID uuid.UUID) error {
+	it, err := uc.itemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrItemNotFound
+		}
+		return err
+	}
+	if it.Type != item.Storable {
+		return ErrNotStorable
+	}
+	if _, err := uc.warehouseRepo.GetByID(ctx, warehouseID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWarehouseNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// sortIDs sorts IDs so that runs are deterministic.
+func sortIDs(ids []uuid.UUID) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+}
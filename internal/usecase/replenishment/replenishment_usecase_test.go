@@ -0,0 +1,395 @@
+package replenishment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	domainBom "doligo_001/internal/domain/bom"
+	"doligo_001/internal/domain/item"
+	domainReplenishment "doligo_001/internal/domain/replenishment"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// --- In-memory fakes ---
+
+type fakeTx struct{}
+
+func (fakeTx) Transaction(ctx context.Context, fc func(tx *gorm.DB) error) error {
+	return fc(nil)
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) Log(ctx context.Context, userID uuid.UUID, resourceName, resourceID, action string, oldValues, newValues interface{}, correlationID string) {
+}
+
+type fakeRuleRepository struct {
+	rules []*domainReplenishment.ReorderRule
+}
+
+func (f *fakeRuleRepository) WithTx(tx *gorm.DB) domainReplenishment.RuleRepository { return f }
+func (f *fakeRuleRepository) Create(ctx context.Context, rule *domainReplenishment.ReorderRule) error {
+	f.rules = append(f.rules, rule)
+	return nil
+}
+func (f *fakeRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainReplenishment.ReorderRule, error) {
+	for _, r := range f.rules {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, domainReplenishment.ErrRuleNotFound
+}
+func (f *fakeRuleRepository) GetByItemAndWarehouse(ctx context.Context, itemID, warehouseID uuid.UUID) (*domainReplenishment.ReorderRule, error) {
+	for _, r := range f.rules {
+		if r.ItemID == itemID && r.WarehouseID == warehouseID {
+			return r, nil
+		}
+	}
+	return nil, domainReplenishment.ErrRuleNotFound
+}
+func (f *fakeRuleRepository) List(ctx context.Context, filter domainReplenishment.RuleFilter) ([]*domainReplenishment.ReorderRule, error) {
+	var list []*domainReplenishment.ReorderRule
+	for _, r := range f.rules {
+		if !filter.ActiveOnly || r.IsActive {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+func (f *fakeRuleRepository) Update(ctx context.Context, rule *domainReplenishment.ReorderRule) error {
+	return nil
+}
+func (f *fakeRuleRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+type fakeProposalRepository struct {
+	proposals []*domainReplenishment.Proposal
+}
+
+func (f *fakeProposalRepository) WithTx(tx *gorm.DB) domainReplenishment.ProposalRepository {
+	return f
+}
+func (f *fakeProposalRepository) Replace(ctx context.Context, proposals []*domainReplenishment.Proposal) error {
+	f.proposals = proposals
+	return nil
+}
+func (f *fakeProposalRepository) List(ctx context.Context, filter domainReplenishment.ProposalFilter) ([]*domainReplenishment.Proposal, error) {
+	return f.proposals, nil
+}
+
+// fakeStockRepository keeps the stock rows of every warehouse.
+type fakeStockRepository struct {
+	rows []*stock.Stock
+}
+
+func (f *fakeStockRepository) WithTx(tx *gorm.DB) stock.StockRepository { return f }
+func (f *fakeStockRepository) GetStock(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeStockRepository) GetStockForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeStockRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID) (float64, error) {
+	return 0, errors.New("not implemented")
+}
+func (f *fakeStockRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	var list []*stock.Stock
+	for _, s := range f.rows {
+		if s.WarehouseID == warehouseID {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+func (f *fakeStockRepository) UpsertStock(ctx context.Context, s *stock.Stock) error { return nil }
+
+type fakeReservationRepository struct {
+	reservations []*stock.Reservation
+}
+
+func (f *fakeReservationRepository) WithTx(tx *gorm.DB) stock.ReservationRepository { return f }
+func (f *fakeReservationRepository) Create(ctx context.Context, r *stock.Reservation) error {
+	return nil
+}
+func (f *fakeReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) FindActive(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string) (*stock.Reservation, error) {
+	return nil, stock.ErrReservationNotFound
+}
+func (f *fakeReservationRepository) List(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error) {
+	var list []*stock.Reservation
+	for _, r := range f.reservations {
+		if r.WarehouseID == *filter.WarehouseID && r.Status == filter.Status {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+func (f *fakeReservationRepository) Update(ctx context.Context, r *stock.Reservation) error {
+	return nil
+}
+func (f *fakeReservationRepository) ReservedQuantity(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, asOf time.Time) (float64, error) {
+	return 0, nil
+}
+func (f *fakeReservationRepository) ExpireDue(ctx context.Context, asOf time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeWarehouseRepository struct{}
+
+func (f *fakeWarehouseRepository) WithTx(tx *gorm.DB) stock.WarehouseRepository { return f }
+func (f *fakeWarehouseRepository) Create(ctx context.Context, w *stock.Warehouse) error {
+	return nil
+}
+func (f *fakeWarehouseRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Warehouse, error) {
+	return &stock.Warehouse{ID: id, IsActive: true}, nil
+}
+func (f *fakeWarehouseRepository) Update(ctx context.Context, w *stock.Warehouse) error {
+	return nil
+}
+func (f *fakeWarehouseRepository) List(ctx context.Context) ([]*stock.Warehouse, error) {
+	return nil, nil
+}
+func (f *fakeWarehouseRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+
+type fakeBomRepository struct {
+	boms map[uuid.UUID]*domainBom.BillOfMaterials // Revision in force, keyed by product ID
+}
+
+func (f *fakeBomRepository) WithTx(tx *gorm.DB) domainBom.Repository { return f }
+func (f *fakeBomRepository) Create(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	return nil
+}
+func (f *fakeBomRepository) GetByID(ctx context.Context, id uuid.UUID) (*domainBom.BillOfMaterials, error) {
+	return nil, domainBom.ErrBOMNotFound
+}
+func (f *fakeBomRepository) GetEffective(ctx context.Context, productID uuid.UUID, at time.Time) (*domainBom.BillOfMaterials, error) {
+	if b, ok := f.boms[productID]; ok {
+		return b, nil
+	}
+	return nil, domainBom.ErrBOMNotFound
+}
+func (f *fakeBomRepository) ListRevisions(ctx context.Context, productID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+func (f *fakeBomRepository) ListRevisionsForUpdate(ctx context.Context, productID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+func (f *fakeBomRepository) UpdateStatus(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	return nil
+}
+func (f *fakeBomRepository) Update(ctx context.Context, b *domainBom.BillOfMaterials) error {
+	return nil
+}
+func (f *fakeBomRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeBomRepository) List(ctx context.Context) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+
+func (f *fakeBomRepository) ListByComponent(ctx context.Context, itemID uuid.UUID) ([]*domainBom.BillOfMaterials, error) {
+	return nil, nil
+}
+
+type fakeItemRepository struct {
+	items map[uuid.UUID]*item.Item
+}
+
+func (f *fakeItemRepository) WithTx(tx *gorm.DB) item.Repository { return f }
+func (f *fakeItemRepository) Create(ctx context.Context, i *item.Item) error {
+	f.items[i.ID] = i
+	return nil
+}
+func (f *fakeItemRepository) GetByID(ctx context.Context, id uuid.UUID) (*item.Item, error) {
+	if i, ok := f.items[id]; ok {
+		return i, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (f *fakeItemRepository) Update(ctx context.Context, i *item.Item) error { return nil }
+func (f *fakeItemRepository) Delete(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeItemRepository) List(ctx context.Context) ([]*item.Item, error) { return nil, nil }
+
+// replenishmentFixture wires the usecase to in-memory repositories for one warehouse.
+type replenishmentFixture struct {
+	uc           Usecase
+	warehouseID  uuid.UUID
+	rules        *fakeRuleRepository
+	proposals    *fakeProposalRepository
+	stock        *fakeStockRepository
+	reservations *fakeReservationRepository
+	boms         *fakeBomRepository
+	items        *fakeItemRepository
+}
+
+func newReplenishmentFixture() *replenishmentFixture {
+	f := &replenishmentFixture{
+		warehouseID:  uuid.New(),
+		rules:        &fakeRuleRepository{},
+		proposals:    &fakeProposalRepository{},
+		stock:        &fakeStockRepository{},
+		reservations: &fakeReservationRepository{},
+		boms:         &fakeBomRepository{boms: make(map[uuid.UUID]*domainBom.BillOfMaterials)},
+		items:        &fakeItemRepository{items: make(map[uuid.UUID]*item.Item)},
+	}
+	f.uc = NewUsecase(fakeTx{}, f.rules, f.proposals, f.stock, f.reservations, &fakeWarehouseRepository{}, f.boms, f.items, nil, fakeAudit{})
+	return f
+}
+
+// addRule creates a storable item with a rule and on-hand stock spread over two bins.
+func (f *replenishmentFixture) addRule(onHand, min, reorderPoint, max float64) uuid.UUID {
+	itemID := uuid.New()
+	f.items.items[itemID] = &item.Item{ID: itemID, Type: item.Storable, IsActive: true}
+	f.rules.rules = append(f.rules.rules, &domainReplenishment.ReorderRule{
+		ID:           uuid.New(),
+		ItemID:       itemID,
+		WarehouseID:  f.warehouseID,
+		MinQuantity:  min,
+		ReorderPoint: reorderPoint,
+		MaxQuantity:  max,
+		IsActive:     true,
+	})
+	binA, binB := uuid.New(), uuid.New()
+	f.stock.rows = append(f.stock.rows,
+		&stock.Stock{ItemID: itemID, WarehouseID: f.warehouseID, BinID: &binA, Quantity: onHand / 2},
+		&stock.Stock{ItemID: itemID, WarehouseID: f.warehouseID, BinID: &binB, Quantity: onHand / 2},
+	)
+	return itemID
+}
+
+func (f *replenishmentFixture) addBOM(productID, componentID uuid.UUID, quantity float64) uuid.UUID {
+	bomID := uuid.New()
+	f.boms.boms[productID] = &domainBom.BillOfMaterials{
+		ID:         bomID,
+		ProductID:  productID,
+		IsActive:   true,
+		Components: []domainBom.BillOfMaterialsComponent{{ComponentItemID: componentID, Quantity: quantity, IsActive: true}},
+	}
+	return bomID
+}
+
+func (f *replenishmentFixture) reserve(itemID uuid.UUID, quantity float64, expiresAt time.Time) {
+	f.reservations.reservations = append(f.reservations.reservations, &stock.Reservation{
+		ID:          uuid.New(),
+		ItemID:      itemID,
+		WarehouseID: f.warehouseID,
+		Quantity:    quantity,
+		Status:      stock.ReservationActive,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+func proposalFor(proposals []*domainReplenishment.Proposal, itemID uuid.UUID) *domainReplenishment.Proposal {
+	for _, p := range proposals {
+		if p.ItemID == itemID {
+			return p
+		}
+	}
+	return nil
+}
+
+func TestReplenishment_Run_SubtractsHeldReservations(t *testing.T) {
+	f := newReplenishmentFixture()
+	itemID := f.addRule(30, 5, 10, 50)
+	f.reserve(itemID, 22, time.Now().Add(time.Hour))
+	f.reserve(itemID, 100, time.Now().Add(-time.Minute)) // expired, no longer held
+
+	proposals, err := f.uc.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	p := proposalFor(proposals, itemID)
+	if p == nil {
+		t.Fatalf("expected a proposal for the item, got %d proposals", len(proposals))
+	}
+	if p.Type != domainReplenishment.ProposalPurchase || p.Projected != 8 {
+		t.Errorf("expected a purchase proposal projected at 8, got %s projected at %f", p.Type, p.Projected)
+	}
+	if p.Quantity != 42 {
+		t.Errorf("expected a quantity up to the maximum (42), got %f", p.Quantity)
+	}
+	if len(f.proposals.proposals) != 1 {
+		t.Errorf("expected the run to replace the stored proposals, got %d", len(f.proposals.proposals))
+	}
+}
+
+func TestReplenishment_Run_AboveReorderPointIsSkipped(t *testing.T) {
+	f := newReplenishmentFixture()
+	f.addRule(40, 5, 10, 50)
+
+	proposals, err := f.uc.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(proposals) != 0 {
+		t.Errorf("expected no proposal, got %d", len(proposals))
+	}
+}
+
+func TestReplenishment_Run_ProductionDemandReachesComponents(t *testing.T) {
+	f := newReplenishmentFixture()
+	componentID := f.addRule(60, 10, 20, 100)
+	productID := f.addRule(2, 0, 5, 20)
+	bomID := f.addBOM(productID, componentID, 3)
+
+	proposals, err := f.uc.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	product := proposalFor(proposals, productID)
+	if product == nil || product.Type != domainReplenishment.ProposalProduction || *product.BOMID != bomID {
+		t.Fatalf("expected a production proposal from the BOM, got %+v", product)
+	}
+	if product.Quantity != 18 {
+		t.Errorf("expected to produce 18, got %f", product.Quantity)
+	}
+
+	// 60 on hand minus 18 x 3 for the production proposal
+	component := proposalFor(proposals, componentID)
+	if component == nil {
+		t.Fatal("expected the component demand to trigger a purchase proposal")
+	}
+	if component.ComponentDemand != 54 || component.Projected != 6 || component.Quantity != 94 {
+		t.Errorf("unexpected component proposal: demand %f, projected %f, quantity %f", component.ComponentDemand, component.Projected, component.Quantity)
+	}
+	if !component.BelowMinimum() {
+		t.Error("expected the component proposal to be below its minimum")
+	}
+}
+
+func TestReplenishment_CreateRule_Validation(t *testing.T) {
+	f := newReplenishmentFixture()
+	existingID := f.addRule(0, 1, 2, 3)
+	serviceID := uuid.New()
+	f.items.items[serviceID] = &item.Item{ID: serviceID, Type: item.Service}
+
+	testCases := []struct {
+		name string
+		rule *domainReplenishment.ReorderRule
+		want error
+	}{
+		{"reorder point above maximum", &domainReplenishment.ReorderRule{ItemID: uuid.New(), MinQuantity: 0, ReorderPoint: 20, MaxQuantity: 10}, domainReplenishment.ErrInvalidRule},
+		{"minimum above reorder point", &domainReplenishment.ReorderRule{ItemID: uuid.New(), MinQuantity: 8, ReorderPoint: 5, MaxQuantity: 10}, domainReplenishment.ErrInvalidRule},
+		{"service item", &domainReplenishment.ReorderRule{ItemID: serviceID, ReorderPoint: 5, MaxQuantity: 10}, ErrNotStorable},
+		{"unknown item", &domainReplenishment.ReorderRule{ItemID: uuid.New(), ReorderPoint: 5, MaxQuantity: 10}, ErrItemNotFound},
+		{"second rule in the warehouse", &domainReplenishment.ReorderRule{ItemID: existingID, ReorderPoint: 5, MaxQuantity: 10}, domainReplenishment.ErrRuleExists},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.rule.WarehouseID = f.warehouseID
+			if err := f.uc.CreateRule(context.Background(), tc.rule); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
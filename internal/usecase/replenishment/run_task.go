@@ -0,0 +1,21 @@
+package replenishment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// RunTask executes a replenishment run on a worker.WorkerPool.
+type RunTask struct {
+	Usecase Usecase
+}
+
+func (t *RunTask) Execute(ctx context.Context) error {
+	proposals, err := t.Usecase.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("replenishment run failed: %w", err)
+	}
+	slog.Info("Replenishment run completed", "proposals", len(proposals))
+	return nil
+}
@@ -0,0 +1,403 @@
+package stock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/usecase"
+	uom_uc "doligo_001/internal/usecase/uom"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxBatchLines caps the number of lines of a bulk movement import.
+const MaxBatchLines = 1000
+
+// ErrBatchSize is returned when a bulk import has no lines or more than MaxBatchLines.
+var ErrBatchSize = fmt.Errorf("a batch must contain between 1 and %d lines", MaxBatchLines)
+
+// BatchLine is one movement of a bulk import.
+type BatchLine struct {
+	ItemID      uuid.UUID           `json:"item_id"`
+	WarehouseID uuid.UUID           `json:"warehouse_id"`
+	BinID       uuid.UUID           `json:"bin_id"`
+	Type        stock.MovementType  `json:"type"`
+	Quantity    float64             `json:"quantity"`
+	Unit        string              `json:"unit_of_measure,omitempty"` // Unit of Quantity, UnitPrice and Lots; empty is the base unit
+	UnitPrice   float64             `json:"unit_price"`
+	Reason      string              `json:"reason"`
+	Lots        []stock.LotQuantity `json:"lots"`
+}
+
+// BatchLineError is the reason a line of a bulk import was rejected. Lines are numbered from 1.
+type BatchLineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// BatchValidationError is returned when lines of a bulk import are rejected. Nothing is posted.
+type BatchValidationError struct {
+	Lines []BatchLineError
+}
+
+func (e *BatchValidationError) Error() string {
+	messages := make([]string, len(e.Lines))
+	for i, l := range e.Lines {
+		messages[i] = fmt.Sprintf("line %d: %s", l.Line, l.Message)
+	}
+	return "batch rejected: " + strings.Join(messages, "; ")
+}
+
+// BatchResult is the outcome of a bulk import. Replayed is set when the idempotency key had
+// already been posted and the movements of that first batch are returned.
+type BatchResult struct {
+	BatchID   uuid.UUID
+	Replayed  bool
+	Movements []*stock.StockMovement
+}
+
+// BatchUseCase defines the interface for bulk stock movement imports.
+type BatchUseCase interface {
+	ImportMovements(ctx context.Context, idempotencyKey string, lines []BatchLine) (*BatchResult, error)
+}
+
+// batchUseCase implements the BatchUseCase interface.
+type batchUseCase struct {
+	txManager       db.Transactioner
+	batchRepo       stock.MovementBatchRepository
+	stockRepo       stock.StockRepository
+	stockMoveRepo   stock.StockMovementRepository
+	stockLedgerRepo stock.StockLedgerRepository
+	lotRepo         stock.StockLotRepository
+	costLayerRepo   stock.CostLayerRepository
+	reservationRepo stock.ReservationRepository
+	warehouseRepo   stock.WarehouseRepository
+	binRepo         stock.BinRepository
+	itemRepo        item.Repository
+	unitRepo        uom.Repository
+	auditService    usecase.AuditService
+}
+
+// NewBatchUseCase creates a new batchUseCase.
+func NewBatchUseCase(
+	txManager db.Transactioner,
+	batchRepo stock.MovementBatchRepository,
+	stockRepo stock.StockRepository,
+	stockMoveRepo stock.StockMovementRepository,
+	stockLedgerRepo stock.StockLedgerRepository,
+	lotRepo stock.StockLotRepository,
+	costLayerRepo stock.CostLayerRepository,
+	reservationRepo stock.ReservationRepository,
+	warehouseRepo stock.WarehouseRepository,
+	binRepo stock.BinRepository,
+	itemRepo item.Repository,
+	unitRepo uom.Repository,
+	auditService usecase.AuditService,
+) BatchUseCase {
+	return &batchUseCase{
+		txManager:       txManager,
+		batchRepo:       batchRepo,
+		stockRepo:       stockRepo,
+		stockMoveRepo:   stockMoveRepo,
+		stockLedgerRepo: stockLedgerRepo,
+		lotRepo:         lotRepo,
+		costLayerRepo:   costLayerRepo,
+		reservationRepo: reservationRepo,
+		warehouseRepo:   warehouseRepo,
+		binRepo:         binRepo,
+		itemRepo:        itemRepo,
+		unitRepo:        unitRepo,
+		auditService:    auditService,
+	}
+}
+
+// batchStockKey identifies the Stock row a batch line posts to.
+type batchStockKey struct {
+	ItemID      uuid.UUID
+	WarehouseID uuid.UUID
+	BinID       uuid.UUID
+}
+
+// errBatchKeyTaken signals, inside the transaction, that another batch holds the idempotency key.
+var errBatchKeyTaken = errors.New("idempotency key taken")
+
+// ImportMovements posts all lines in one transaction, or none of them. Every line is validated
+// first and all rejected lines are returned together in a *BatchValidationError. The Stock rows
+// of the batch are then locked by warehouse, bin and item ID, whatever the order of the lines,
+// and the lines are posted in their own order, each valued like CreateStockMovement values it.
+// When idempotencyKey was already posted with the same lines, the movements of that batch are
+// returned instead; with different lines, stock.ErrBatchKeyReused is returned.
+func (uc *batchUseCase) ImportMovements(ctx context.Context, idempotencyKey string, lines []BatchLine) (*BatchResult, error) {
+	if len(lines) == 0 || len(lines) > MaxBatchLines {
+		return nil, ErrBatchSize
+	}
+	if lineErrors := validateBatchLines(lines); len(lineErrors) > 0 {
+		return nil, &BatchValidationError{Lines: lineErrors}
+	}
+	payload, err := json.Marshal(lines)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(hash[:])
+
+	if idempotencyKey != "" {
+		if result, err := uc.replay(ctx, idempotencyKey, payloadHash); result != nil || err != nil {
+			return result, err
+		}
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	batch := &stock.MovementBatch{
+		ID:             uuid.New(),
+		IdempotencyKey: idempotencyKey,
+		PayloadHash:    payloadHash,
+		LineCount:      len(lines),
+		CreatedAt:      time.Now(),
+		CreatedBy:      userID,
+	}
+	result := &BatchResult{BatchID: batch.ID}
+
+	err = uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txBatchRepo := uc.batchRepo.WithTx(tx)
+		txStockRepo := uc.stockRepo.WithTx(tx)
+		txItemRepo := uc.itemRepo.WithTx(tx)
+		txReservationRepo := uc.reservationRepo.WithTx(tx)
+
+		claimed, err := txBatchRepo.Claim(ctx, batch)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return errBatchKeyTaken
+		}
+
+		// 1. Check the items, locations and lots of every line before locking anything, and
+		// express the lines in the base unit of their item.
+		items := make(map[uuid.UUID]*item.Item)
+		var lineErrors []BatchLineError
+		lines := append([]BatchLine(nil), lines...)
+		for i := range lines {
+			if err := uc.validateBatchLine(ctx, tx, items, &lines[i]); err != nil {
+				lineErrors = append(lineErrors, BatchLineError{Line: i + 1, Message: err.Error()})
+			}
+		}
+		if len(lineErrors) > 0 {
+			return &BatchValidationError{Lines: lineErrors}
+		}
+
+		// 2. Lock every Stock row of the batch in a stable order, then check the running
+		// quantity of each outbound line against what is on hand and not reserved.
+		quantities := make(map[batchStockKey]float64)
+		reserved := make(map[batchStockKey]float64)
+		for _, key := range sortedBatchStockKeys(lines) {
+			binID := key.BinID
+			quantities[key], err = LockedQuantity(ctx, txStockRepo, key.ItemID, key.WarehouseID, &binID)
+			if err != nil {
+				return err
+			}
+		}
+		running := make(map[batchStockKey]float64, len(quantities))
+		for key, qty := range quantities {
+			running[key] = qty
+		}
+		now := time.Now()
+		for i, line := range lines {
+			key := batchStockKey{ItemID: line.ItemID, WarehouseID: line.WarehouseID, BinID: line.BinID}
+			if line.Type.IsInbound() {
+				running[key] += line.Quantity
+				continue
+			}
+			if _, ok := reserved[key]; !ok {
+				binID := key.BinID
+				reserved[key], err = txReservationRepo.ReservedQuantity(ctx, key.ItemID, key.WarehouseID, &binID, now)
+				if err != nil {
+					return err
+				}
+			}
+			if available := running[key] - reserved[key]; available < line.Quantity-quantityEpsilon {
+				lineErrors = append(lineErrors, BatchLineError{
+					Line:    i + 1,
+					Message: fmt.Sprintf("%s: %f available, %f reserved", ErrInsufficientStock, available, reserved[key]),
+				})
+			}
+			running[key] -= line.Quantity
+		}
+		if len(lineErrors) > 0 {
+			return &BatchValidationError{Lines: lineErrors}
+		}
+
+		// 3. Value and post the lines in their own order.
+		repos := PostingRepositories{Stock: txStockRepo, Movements: uc.stockMoveRepo.WithTx(tx), Ledger: uc.stockLedgerRepo.WithTx(tx), Lots: uc.lotRepo.WithTx(tx)}
+		costRepos := CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: uc.costLayerRepo.WithTx(tx)}
+		for i, line := range lines {
+			key := batchStockKey{ItemID: line.ItemID, WarehouseID: line.WarehouseID, BinID: line.BinID}
+			it := items[line.ItemID]
+			movementID := uuid.New()
+			var valuation Valuation
+			switch line.Type {
+			case stock.MovementTypeIn:
+				it.CostPrice = line.UnitPrice // Same as CreateStockMovement: the latest purchase price
+				valuation, err = CostReceipt(ctx, costRepos, it, movementID, line.Quantity, line.UnitPrice, now)
+			default:
+				valuation, err = CostIssue(ctx, costRepos, it, movementID, line.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+
+			binID := line.BinID
+			movement, after, err := PostMovement(ctx, repos, Posting{
+				MovementID:     movementID,
+				ItemID:         line.ItemID,
+				WarehouseID:    line.WarehouseID,
+				BinID:          &binID,
+				Type:           line.Type,
+				Quantity:       line.Quantity,
+				QuantityBefore: quantities[key],
+				Reserved:       reserved[key],
+				Reason:         line.Reason,
+				Tracking:       it.TrackingMode,
+				Lots:           line.Lots,
+				UnitCost:       valuation.UnitCost,
+				CostVariance:   valuation.CostVariance,
+				HappenedAt:     now,
+				UserID:         userID,
+			})
+			if err != nil {
+				return &BatchValidationError{Lines: []BatchLineError{{Line: i + 1, Message: err.Error()}}}
+			}
+			quantities[key] = after
+			if err := txBatchRepo.AddLine(ctx, batch.ID, i+1, movement.ID); err != nil {
+				return err
+			}
+			result.Movements = append(result.Movements, movement)
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchKeyTaken) {
+		// A concurrent upload with the same key committed first.
+		return uc.replay(ctx, idempotencyKey, payloadHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_batch", batch.ID.String(), "IMPORT", nil,
+		map[string]interface{}{"idempotency_key": idempotencyKey, "lines": len(lines)},
+		corrID)
+	return result, nil
+}
+
+// replay returns the result of the batch already posted with the key, or nil if there is none.
+func (uc *batchUseCase) replay(ctx context.Context, idempotencyKey, payloadHash string) (*BatchResult, error) {
+	batch, err := uc.batchRepo.GetByKey(ctx, idempotencyKey)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if batch.PayloadHash != payloadHash {
+		return nil, stock.ErrBatchKeyReused
+	}
+	movements, err := uc.batchRepo.ListMovements(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchResult{BatchID: batch.ID, Replayed: true, Movements: movements}, nil
+}
+
+// validateBatchLine checks the item, location and lots of a line, caching the items it loads,
+// and converts the line to the base unit of the item.
+func (uc *batchUseCase) validateBatchLine(ctx context.Context, tx *gorm.DB, items map[uuid.UUID]*item.Item, line *BatchLine) error {
+	it, ok := items[line.ItemID]
+	if !ok {
+		var err error
+		it, err = uc.itemRepo.WithTx(tx).GetByID(ctx, line.ItemID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("item not found")
+			}
+			return err
+		}
+		items[line.ItemID] = it
+	}
+	if it.Type == item.Service {
+		return ErrNotStorable
+	}
+	factor, err := uom_uc.BaseFactor(ctx, uc.unitRepo, it, line.Unit)
+	if err != nil {
+		return err
+	}
+	line.Quantity, line.UnitPrice, line.Lots = line.Quantity*factor, line.UnitPrice/factor, ScaleLots(line.Lots, factor)
+	if err := stock.ValidateLots(it.TrackingMode, line.Quantity, line.Lots); err != nil {
+		return err
+	}
+	return validateLocation(ctx, uc.warehouseRepo.WithTx(tx), uc.binRepo.WithTx(tx), line.WarehouseID, line.BinID)
+}
+
+// validateBatchLines checks the fields of every line that do not need the database.
+func validateBatchLines(lines []BatchLine) []BatchLineError {
+	var lineErrors []BatchLineError
+	for i, line := range lines {
+		var err error
+		switch {
+		case line.ItemID == uuid.Nil || line.WarehouseID == uuid.Nil:
+			err = errors.New("item_id and warehouse_id are required")
+		case line.BinID == uuid.Nil:
+			err = ErrBinRequired
+		case line.Type != stock.MovementTypeIn && line.Type != stock.MovementTypeOut:
+			err = fmt.Errorf("type must be %s or %s", stock.MovementTypeIn, stock.MovementTypeOut)
+		case line.Quantity <= 0:
+			err = ErrInvalidQuantity
+		case line.UnitPrice < 0:
+			err = errors.New("unit_price must not be negative")
+		case len(line.Reason) > 255:
+			err = errors.New("reason must not exceed 255 characters")
+		}
+		if err != nil {
+			lineErrors = append(lineErrors, BatchLineError{Line: i + 1, Message: err.Error()})
+		}
+	}
+	return lineErrors
+}
+
+// sortedBatchStockKeys returns the distinct Stock rows of the lines ordered by warehouse, bin and
+// item ID, the location order shared by every use case that locks more than one Stock row.
+func sortedBatchStockKeys(lines []BatchLine) []batchStockKey {
+	seen := make(map[batchStockKey]bool)
+	var keys []batchStockKey
+	for _, line := range lines {
+		key := batchStockKey{ItemID: line.ItemID, WarehouseID: line.WarehouseID, BinID: line.BinID}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := bytes.Compare(keys[i].WarehouseID[:], keys[j].WarehouseID[:]); c != 0 {
+			return c < 0
+		}
+		if c := bytes.Compare(keys[i].BinID[:], keys[j].BinID[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(keys[i].ItemID[:], keys[j].ItemID[:]) < 0
+	})
+	return keys
+}
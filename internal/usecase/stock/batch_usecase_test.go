@@ -0,0 +1,188 @@
+package stock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockMovementBatchRepository
+type MockMovementBatchRepository struct {
+	mock.Mock
+}
+
+func (m *MockMovementBatchRepository) WithTx(tx *gorm.DB) stock.MovementBatchRepository {
+	m.Called(tx)
+	return m
+}
+func (m *MockMovementBatchRepository) Claim(ctx context.Context, batch *stock.MovementBatch) (bool, error) {
+	args := m.Called(ctx, batch)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockMovementBatchRepository) GetByKey(ctx context.Context, key string) (*stock.MovementBatch, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.MovementBatch), args.Error(1)
+}
+func (m *MockMovementBatchRepository) AddLine(ctx context.Context, batchID uuid.UUID, line int, movementID uuid.UUID) error {
+	args := m.Called(ctx, batchID, line, movementID)
+	return args.Error(0)
+}
+func (m *MockMovementBatchRepository) ListMovements(ctx context.Context, batchID uuid.UUID) ([]*stock.StockMovement, error) {
+	args := m.Called(ctx, batchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.StockMovement), args.Error(1)
+}
+
+func setupBatchTest() (*stockUseCaseTestSuite, *MockMovementBatchRepository, usecase.BatchUseCase) {
+	s := setupTestSuite()
+	batchRepo := new(MockMovementBatchRepository)
+	batchRepo.On("WithTx", mock.Anything).Return(batchRepo).Maybe()
+	uc := usecase.NewBatchUseCase(s.txManager, batchRepo, s.stockRepo, s.stockMoveRepo, s.stockLedgerRepo, s.lotRepo, s.costLayerRepo, s.reservationRepo, s.warehouseRepo, s.binRepo, s.itemRepo, s.unitRepo, s.auditService)
+	return s, batchRepo, uc
+}
+
+func TestImportMovements_PostsLinesAfterLockingInStableOrder(t *testing.T) {
+	s, batchRepo, uc := setupBatchTest()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", Type: item.Storable, AverageCost: 10.0}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 2.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	batchRepo.On("GetByKey", mock.Anything, "upload-1").Return(nil, gorm.ErrRecordNotFound).Once()
+	batchRepo.On("Claim", mock.Anything, mock.AnythingOfType("*stock.MovementBatch")).Return(true, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Twice()
+	// Both lines post to the same Stock row, which is locked once.
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(0.0, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(2.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.AnythingOfType("*item.Item")).Return(nil).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Twice()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool { return st.Quantity == 10.0 })).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool { return st.Quantity == 3.0 })).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Twice()
+	batchRepo.On("AddLine", mock.Anything, mock.Anything, 1, mock.Anything).Return(nil).Once()
+	batchRepo.On("AddLine", mock.Anything, mock.Anything, 2, mock.Anything).Return(nil).Once()
+
+	// The issue only fits because the receipt before it is posted first.
+	result, err := uc.ImportMovements(s.ctx, "upload-1", []usecase.BatchLine{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: s.binID, Type: stock.MovementTypeIn, Quantity: 8.0, UnitPrice: 12.5},
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: s.binID, Type: stock.MovementTypeOut, Quantity: 7.0},
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, result.Replayed)
+	assert.Len(t, result.Movements, 2)
+	assert.Equal(t, 12.5, result.Movements[0].UnitCost)
+	s.stockRepo.AssertExpectations(t)
+	batchRepo.AssertExpectations(t)
+}
+
+func TestImportMovements_ReportsEveryRejectedLine(t *testing.T) {
+	s, batchRepo, uc := setupBatchTest()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", Type: item.Storable}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	missingBinID := uuid.New()
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	batchRepo.On("Claim", mock.Anything, mock.AnythingOfType("*stock.MovementBatch")).Return(true, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil)
+	s.binRepo.On("GetByID", mock.Anything, missingBinID).Return(nil, gorm.ErrRecordNotFound).Once()
+
+	lines := []usecase.BatchLine{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: s.binID, Type: stock.MovementTypeOut, Quantity: 1.0},
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: missingBinID, Type: stock.MovementTypeIn, Quantity: 1.0},
+	}
+	_, err := uc.ImportMovements(s.ctx, "", lines)
+
+	var validationErr *usecase.BatchValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []usecase.BatchLineError{{Line: 2, Message: "bin not found"}}, validationErr.Lines)
+	// Nothing is locked while a line refers to an unknown location.
+	s.stockRepo.AssertNotCalled(t, "GetStockForUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Static checks reject every bad line before the database is touched.
+	lines[0].Quantity = 0
+	lines[1].Type = "MOVE"
+	_, err = uc.ImportMovements(s.ctx, "", lines)
+
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Lines, 2)
+	assert.Equal(t, 1, validationErr.Lines[0].Line)
+	assert.Equal(t, 2, validationErr.Lines[1].Line)
+	s.txManager.AssertNumberOfCalls(t, "Transaction", 1)
+}
+
+func TestImportMovements_InsufficientStockIsALineError(t *testing.T) {
+	s, batchRepo, uc := setupBatchTest()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", Type: item.Storable}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 5.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	batchRepo.On("Claim", mock.Anything, mock.AnythingOfType("*stock.MovementBatch")).Return(true, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil)
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(1.0, nil).Once()
+
+	_, err := uc.ImportMovements(s.ctx, "", []usecase.BatchLine{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: s.binID, Type: stock.MovementTypeOut, Quantity: 3.0},
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: s.binID, Type: stock.MovementTypeOut, Quantity: 3.0},
+	})
+
+	var validationErr *usecase.BatchValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Lines, 1)
+	assert.Equal(t, 2, validationErr.Lines[0].Line)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestImportMovements_ReplaysKnownIdempotencyKey(t *testing.T) {
+	s, batchRepo, uc := setupBatchTest()
+	lines := []usecase.BatchLine{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: s.binID, Type: stock.MovementTypeIn, Quantity: 4.0, UnitPrice: 2.0},
+	}
+	posted := []*stock.StockMovement{{ID: uuid.New(), ItemID: s.itemID, Type: stock.MovementTypeIn, Quantity: 4.0}}
+
+	// A concurrent upload claimed the key first, with other lines.
+	var claimed *stock.MovementBatch
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	batchRepo.On("GetByKey", mock.Anything, "upload-2").Return(nil, gorm.ErrRecordNotFound).Once()
+	batchRepo.On("Claim", mock.Anything, mock.AnythingOfType("*stock.MovementBatch")).Run(func(args mock.Arguments) {
+		claimed = args.Get(1).(*stock.MovementBatch)
+	}).Return(false, nil).Once()
+	batchRepo.On("GetByKey", mock.Anything, "upload-2").Return(&stock.MovementBatch{ID: uuid.New(), IdempotencyKey: "upload-2", PayloadHash: "other"}, nil).Once()
+
+	_, err := uc.ImportMovements(s.ctx, "upload-2", lines)
+
+	assert.ErrorIs(t, err, stock.ErrBatchKeyReused)
+
+	// A retry of a committed upload returns its movements.
+	batch := &stock.MovementBatch{ID: uuid.New(), IdempotencyKey: "upload-2", PayloadHash: claimed.PayloadHash}
+	batchRepo.On("GetByKey", mock.Anything, "upload-2").Return(batch, nil).Once()
+	batchRepo.On("ListMovements", mock.Anything, batch.ID).Return(posted, nil).Once()
+
+	result, err := uc.ImportMovements(s.ctx, "upload-2", lines)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Replayed)
+	assert.Equal(t, batch.ID, result.BatchID)
+	assert.Equal(t, posted, result.Movements)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.txManager.AssertNumberOfCalls(t, "Transaction", 1)
+}
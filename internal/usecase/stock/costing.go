@@ -0,0 +1,265 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CostingRepositories groups the transactional repositories the costing of a movement reads and writes.
+type CostingRepositories struct {
+	Stock  stock.StockRepository
+	Items  item.Repository
+	Layers stock.CostLayerRepository
+}
+
+// Valuation is the cost a movement is recorded at.
+type Valuation struct {
+	UnitCost     float64
+	CostVariance float64 // (actual - standard) * quantity for receipts of standard cost items
+}
+
+// CurrentUnitCost returns the cost a unit of the item is carried at outside of its FIFO layers:
+// the standard cost of standard cost items and the weighted average cost of the others.
+// Items that are not stocked have no inventory value and are costed at their CostPrice.
+func CurrentUnitCost(it *item.Item) float64 {
+	if it.Type == item.Service {
+		return it.CostPrice
+	}
+	if it.CostingMethod == item.CostingStandard {
+		return it.StandardCost
+	}
+	return it.AverageCost
+}
+
+// CostReceipt values an inbound movement received at unitPrice. The weighted average cost of the
+// item is recomputed and saved whatever its costing method, so it stays available for valuation.
+// FIFO items open a new cost layer; standard cost items are received at their standard cost and
+// the difference with the actual price is recorded as the variance of the movement.
+func CostReceipt(ctx context.Context, repos CostingRepositories, it *item.Item, movementID uuid.UUID, quantity, unitPrice float64, at time.Time) (Valuation, error) {
+	totalQtyBefore, err := repos.Stock.GetTotalQuantity(ctx, it.ID)
+	if err != nil {
+		return Valuation{}, err
+	}
+
+	// Novo CMP = (Valor Total Antigo + Valor da Nova Entrada) / (Qtd Antiga + Qtd Nova)
+	if totalQtyAfter := totalQtyBefore + quantity; totalQtyAfter > 0 {
+		it.AverageCost = (totalQtyBefore*it.AverageCost + quantity*unitPrice) / totalQtyAfter
+	}
+	if err := saveItemCost(ctx, repos.Items, it); err != nil {
+		return Valuation{}, err
+	}
+
+	switch it.CostingMethod {
+	case item.CostingStandard:
+		return Valuation{UnitCost: it.StandardCost, CostVariance: (unitPrice - it.StandardCost) * quantity}, nil
+	case item.CostingFIFO:
+		if err := openLayer(ctx, repos.Layers, it.ID, movementID, quantity, unitPrice, at); err != nil {
+			return Valuation{}, err
+		}
+	}
+	return Valuation{UnitCost: unitPrice}, nil
+}
+
+// CostAdjustment values an inbound movement that has no price of its own, such as a count gain,
+// at the current unit cost of the item. The weighted average is left unchanged; FIFO items open
+// a layer at that cost so the gained quantity can be issued later.
+func CostAdjustment(ctx context.Context, repos CostingRepositories, it *item.Item, movementID uuid.UUID, quantity float64, at time.Time) (Valuation, error) {
+	unitCost := CurrentUnitCost(it)
+	if it.CostingMethod == item.CostingFIFO {
+		if err := openLayer(ctx, repos.Layers, it.ID, movementID, quantity, unitCost, at); err != nil {
+			return Valuation{}, err
+		}
+	}
+	return Valuation{UnitCost: unitCost}, nil
+}
+
+// CostIssue values an outbound movement. FIFO items consume their open layers oldest first and
+// record what was taken from each layer; quantity that is not covered by any layer, e.g. stock
+// received before the item switched to FIFO, is issued at the weighted average cost.
+// The caller must already hold the lock on the Stock row the quantity leaves from.
+func CostIssue(ctx context.Context, repos CostingRepositories, it *item.Item, movementID uuid.UUID, quantity float64) (Valuation, error) {
+	if it.CostingMethod != item.CostingFIFO || quantity <= 0 {
+		return Valuation{UnitCost: CurrentUnitCost(it)}, nil
+	}
+
+	layers, err := repos.Layers.ListOpenForUpdate(ctx, it.ID)
+	if err != nil {
+		return Valuation{}, err
+	}
+	var cost float64
+	remaining := quantity
+	for _, layer := range layers {
+		if remaining < quantityEpsilon {
+			break
+		}
+		taken := math.Min(remaining, layer.RemainingQuantity)
+		layer.RemainingQuantity -= taken
+		if err := repos.Layers.UpdateRemaining(ctx, layer); err != nil {
+			return Valuation{}, err
+		}
+		if err := repos.Layers.CreateConsumption(ctx, &stock.CostLayerConsumption{
+			ID:         uuid.New(),
+			LayerID:    layer.ID,
+			MovementID: movementID,
+			Quantity:   taken,
+			UnitCost:   layer.UnitCost,
+		}); err != nil {
+			return Valuation{}, err
+		}
+		cost += taken * layer.UnitCost
+		remaining -= taken
+	}
+	if remaining > quantityEpsilon {
+		cost += remaining * it.AverageCost
+	}
+	return Valuation{UnitCost: cost / quantity}, nil
+}
+
+// EstimateIssueCost returns the unit cost an outbound movement of quantity would be valued at
+// right now, without consuming any layer. It is used by documents that do not move stock.
+func EstimateIssueCost(ctx context.Context, layerRepo stock.CostLayerRepository, it *item.Item, quantity float64) (float64, error) {
+	if it.CostingMethod != item.CostingFIFO || quantity <= 0 {
+		return CurrentUnitCost(it), nil
+	}
+
+	layers, err := layerRepo.ListOpen(ctx, it.ID)
+	if err != nil {
+		return 0, err
+	}
+	var cost float64
+	remaining := quantity
+	for _, layer := range layers {
+		if remaining < quantityEpsilon {
+			break
+		}
+		taken := math.Min(remaining, layer.RemainingQuantity)
+		cost += taken * layer.UnitCost
+		remaining -= taken
+	}
+	if remaining > quantityEpsilon {
+		cost += remaining * it.AverageCost
+	}
+	return cost / quantity, nil
+}
+
+// CostReversal values the reversal of orig at the cost orig was recorded at, with its variance
+// negated, and takes that value back out of the weighted average. A reversed FIFO receipt removes
+// its quantity from the layer it opened, which fails with stock.ErrCostLayerConsumed once part of
+// that quantity has been issued; a reversed FIFO issue puts its quantity back into the layers it
+// was taken from. The caller must already hold the lock on the Stock row of orig.
+func CostReversal(ctx context.Context, repos CostingRepositories, it *item.Item, orig *stock.StockMovement, reversalID uuid.UUID, at time.Time) (Valuation, error) {
+	valuation := Valuation{UnitCost: orig.UnitCost, CostVariance: -orig.CostVariance}
+
+	if it.CostingMethod == item.CostingFIFO {
+		// Lock the open layers first, in the same order as CostIssue.
+		if _, err := repos.Layers.ListOpenForUpdate(ctx, it.ID); err != nil {
+			return Valuation{}, err
+		}
+		if orig.Type.IsInbound() {
+			err := removeReceiptLayer(ctx, repos.Layers, orig)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// The receipt opened no layer of its own, so it is issued like any other outbound movement.
+				issued, issueErr := CostIssue(ctx, repos, it, reversalID, orig.Quantity)
+				valuation.UnitCost = issued.UnitCost
+				err = issueErr
+			}
+			if err != nil {
+				return Valuation{}, err
+			}
+		} else if err := restoreConsumedLayers(ctx, repos.Layers, it.ID, orig, reversalID, at); err != nil {
+			return Valuation{}, err
+		}
+	}
+
+	totalQtyBefore, err := repos.Stock.GetTotalQuantity(ctx, it.ID)
+	if err != nil {
+		return Valuation{}, err
+	}
+	// The average moves by the actual value of orig, i.e. including the variance of a standard cost receipt.
+	value := orig.Quantity*valuation.UnitCost + orig.CostVariance
+	totalQtyAfter := totalQtyBefore + orig.Quantity
+	if orig.Type.IsInbound() {
+		value = -value
+		totalQtyAfter = totalQtyBefore - orig.Quantity
+	}
+	if totalQtyAfter > 0 {
+		it.AverageCost = (totalQtyBefore*it.AverageCost + value) / totalQtyAfter
+		if err := saveItemCost(ctx, repos.Items, it); err != nil {
+			return Valuation{}, err
+		}
+	}
+	return valuation, nil
+}
+
+// removeReceiptLayer takes the quantity of a reversed receipt back out of the layer it opened.
+func removeReceiptLayer(ctx context.Context, layerRepo stock.CostLayerRepository, orig *stock.StockMovement) error {
+	layer, err := layerRepo.GetByMovementForUpdate(ctx, orig.ID)
+	if err != nil {
+		return err
+	}
+	if layer.RemainingQuantity < orig.Quantity-quantityEpsilon {
+		return fmt.Errorf("%w: %f of %f left", stock.ErrCostLayerConsumed, layer.RemainingQuantity, orig.Quantity)
+	}
+	layer.RemainingQuantity = math.Max(layer.RemainingQuantity-orig.Quantity, 0)
+	return layerRepo.UpdateRemaining(ctx, layer)
+}
+
+// restoreConsumedLayers puts the quantity of a reversed issue back into the layers it consumed,
+// in layer ID order. Quantity that was issued at the average cost opens a new layer at the cost of orig.
+func restoreConsumedLayers(ctx context.Context, layerRepo stock.CostLayerRepository, itemID uuid.UUID, orig *stock.StockMovement, reversalID uuid.UUID, at time.Time) error {
+	consumptions, err := layerRepo.ListConsumptions(ctx, orig.ID)
+	if err != nil {
+		return err
+	}
+	sort.Slice(consumptions, func(i, j int) bool {
+		return consumptions[i].LayerID.String() < consumptions[j].LayerID.String()
+	})
+
+	var restored float64
+	for _, c := range consumptions {
+		layer, err := layerRepo.GetForUpdate(ctx, c.LayerID)
+		if err != nil {
+			return err
+		}
+		layer.RemainingQuantity += c.Quantity
+		if err := layerRepo.UpdateRemaining(ctx, layer); err != nil {
+			return err
+		}
+		restored += c.Quantity
+	}
+	if leftover := orig.Quantity - restored; leftover > quantityEpsilon {
+		return openLayer(ctx, layerRepo, itemID, reversalID, leftover, orig.UnitCost, at)
+	}
+	return nil
+}
+
+// openLayer creates the FIFO layer of an inbound movement.
+func openLayer(ctx context.Context, layerRepo stock.CostLayerRepository, itemID, movementID uuid.UUID, quantity, unitCost float64, at time.Time) error {
+	return layerRepo.Create(ctx, &stock.CostLayer{
+		ID:                uuid.New(),
+		ItemID:            itemID,
+		MovementID:        movementID,
+		UnitCost:          unitCost,
+		OriginalQuantity:  quantity,
+		RemainingQuantity: quantity,
+		ReceivedAt:        at,
+	})
+}
+
+// saveItemCost persists the recomputed cost fields of an item.
+func saveItemCost(ctx context.Context, itemRepo item.Repository, it *item.Item) error {
+	userID, _ := domain.UserIDFromContext(ctx)
+	it.UpdatedAt = time.Now()
+	it.UpdatedBy = userID
+	return itemRepo.Update(ctx, it)
+}
@@ -0,0 +1,375 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/usecase"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// quantityEpsilon absorbs floating point noise when deciding whether a count differs from the snapshot.
+const quantityEpsilon = 1e-9
+
+// ErrNegativeCount is returned when a device submits a negative counted quantity.
+var ErrNegativeCount = errors.New("counted quantity must not be negative")
+
+// CountUseCase defines the interface for physical inventory count sessions.
+type CountUseCase interface {
+	StartCount(ctx context.Context, warehouseID uuid.UUID, binIDs []uuid.UUID) (*stock.CountSession, error)
+	GetCount(ctx context.Context, id uuid.UUID) (*stock.CountSession, error)
+	ListCounts(ctx context.Context, status stock.CountStatus) ([]*stock.CountSession, error)
+	SubmitCount(ctx context.Context, sessionID, itemID, binID uuid.UUID, deviceID string, quantity float64) (*stock.CountLine, error)
+	ApproveCount(ctx context.Context, sessionID uuid.UUID) (*stock.CountSession, []*stock.CountAdjustment, error)
+	CancelCount(ctx context.Context, sessionID uuid.UUID) (*stock.CountSession, error)
+}
+
+// countUseCase implements the CountUseCase interface.
+type countUseCase struct {
+	txManager       db.Transactioner
+	countRepo       stock.CountSessionRepository
+	stockRepo       stock.StockRepository
+	stockMoveRepo   stock.StockMovementRepository
+	stockLedgerRepo stock.StockLedgerRepository
+	costLayerRepo   stock.CostLayerRepository
+	warehouseRepo   stock.WarehouseRepository
+	binRepo         stock.BinRepository
+	itemRepo        item.Repository
+	auditService    usecase.AuditService
+}
+
+// NewCountUseCase creates a new countUseCase.
+func NewCountUseCase(
+	txManager db.Transactioner,
+	countRepo stock.CountSessionRepository,
+	stockRepo stock.StockRepository,
+	stockMoveRepo stock.StockMovementRepository,
+	stockLedgerRepo stock.StockLedgerRepository,
+	costLayerRepo stock.CostLayerRepository,
+	warehouseRepo stock.WarehouseRepository,
+	binRepo stock.BinRepository,
+	itemRepo item.Repository,
+	auditService usecase.AuditService,
+) CountUseCase {
+	return &countUseCase{
+		txManager:       txManager,
+		countRepo:       countRepo,
+		stockRepo:       stockRepo,
+		stockMoveRepo:   stockMoveRepo,
+		stockLedgerRepo: stockLedgerRepo,
+		costLayerRepo:   costLayerRepo,
+		warehouseRepo:   warehouseRepo,
+		binRepo:         binRepo,
+		itemRepo:        itemRepo,
+		auditService:    auditService,
+	}
+}
+
+// StartCount opens a count session for a warehouse, or for a set of its bins, and
+// snapshots the current Stock quantities of that scope as the expected quantities.
+func (uc *countUseCase) StartCount(ctx context.Context, warehouseID uuid.UUID, binIDs []uuid.UUID) (*stock.CountSession, error) {
+	var session *stock.CountSession
+
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txWarehouseRepo := uc.warehouseRepo.WithTx(tx)
+		txBinRepo := uc.binRepo.WithTx(tx)
+
+		warehouse, err := txWarehouseRepo.GetByID(ctx, warehouseID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("warehouse not found")
+			}
+			return err
+		}
+		if !warehouse.IsActive {
+			return errors.New("warehouse is inactive")
+		}
+		for _, binID := range binIDs {
+			if err := validateLocation(ctx, txWarehouseRepo, txBinRepo, warehouseID, binID); err != nil {
+				return err
+			}
+		}
+
+		userID, _ := domain.UserIDFromContext(ctx)
+		session = &stock.CountSession{
+			ID:          uuid.New(),
+			WarehouseID: warehouseID,
+			BinIDs:      binIDs,
+			Status:      stock.CountStatusOpen,
+		}
+		session.SetCreatedBy(userID)
+		session.SetUpdatedBy(userID)
+
+		stocks, err := uc.stockRepo.WithTx(tx).ListByWarehouse(ctx, warehouseID)
+		if err != nil {
+			return err
+		}
+		for _, s := range stocks {
+			// Stock held outside of any bin cannot be counted bin by bin.
+			if s.BinID == nil || *s.BinID == uuid.Nil || !session.CoversBin(*s.BinID) {
+				continue
+			}
+			session.Lines = append(session.Lines, &stock.CountLine{
+				ID:               uuid.New(),
+				SessionID:        session.ID,
+				ItemID:           s.ItemID,
+				BinID:            *s.BinID,
+				ExpectedQuantity: s.Quantity,
+			})
+		}
+
+		return uc.countRepo.WithTx(tx).Create(ctx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_count", session.ID.String(), "CREATE", nil,
+		map[string]interface{}{"warehouse_id": warehouseID, "bin_ids": binIDs, "lines": len(session.Lines)},
+		corrID)
+
+	return session, nil
+}
+
+func (uc *countUseCase) GetCount(ctx context.Context, id uuid.UUID) (*stock.CountSession, error) {
+	return uc.countRepo.GetByID(ctx, id)
+}
+
+func (uc *countUseCase) ListCounts(ctx context.Context, status stock.CountStatus) ([]*stock.CountSession, error) {
+	return uc.countRepo.List(ctx, status)
+}
+
+// SubmitCount appends the quantity counted by a device for an item in a bin.
+// Items found in a bin of the scope without a snapshotted line get a new line with an expected quantity of zero.
+func (uc *countUseCase) SubmitCount(ctx context.Context, sessionID, itemID, binID uuid.UUID, deviceID string, quantity float64) (*stock.CountLine, error) {
+	if quantity < 0 {
+		return nil, ErrNegativeCount
+	}
+
+	var line *stock.CountLine
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txCountRepo := uc.countRepo.WithTx(tx)
+
+		session, err := txCountRepo.GetByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != stock.CountStatusOpen {
+			return stock.ErrCountSessionNotOpen
+		}
+
+		line = session.FindLine(itemID, binID)
+		if line == nil {
+			if !session.CoversBin(binID) {
+				return stock.ErrBinNotInCount
+			}
+			if err := validateLocation(ctx, uc.warehouseRepo.WithTx(tx), uc.binRepo.WithTx(tx), session.WarehouseID, binID); err != nil {
+				return err
+			}
+			if _, err := uc.itemRepo.WithTx(tx).GetByID(ctx, itemID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("item not found")
+				}
+				return err
+			}
+			line = &stock.CountLine{
+				ID:        uuid.New(),
+				SessionID: session.ID,
+				ItemID:    itemID,
+				BinID:     binID,
+			}
+			if err := txCountRepo.AddLine(ctx, line); err != nil {
+				return err
+			}
+		}
+
+		userID, _ := domain.UserIDFromContext(ctx)
+		entry := &stock.CountEntry{
+			ID:        uuid.New(),
+			LineID:    line.ID,
+			DeviceID:  deviceID,
+			Quantity:  quantity,
+			CountedAt: time.Now(),
+			CountedBy: userID,
+		}
+		if err := txCountRepo.AddEntry(ctx, entry); err != nil {
+			return err
+		}
+		line.Entries = append(line.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// ApproveCount closes the session and posts every non-zero variance as an ADJ_IN or
+// ADJ_OUT movement, in the same transaction. Lines that were never counted are left untouched.
+// Variances are applied to the current quantity, so movements recorded after the snapshot are preserved.
+// Gains are valued at the current unit cost of the item and losses are issued like any other outbound movement.
+func (uc *countUseCase) ApproveCount(ctx context.Context, sessionID uuid.UUID) (*stock.CountSession, []*stock.CountAdjustment, error) {
+	var session *stock.CountSession
+	var adjustments []*stock.CountAdjustment
+
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txCountRepo := uc.countRepo.WithTx(tx)
+		txStockRepo := uc.stockRepo.WithTx(tx)
+		txMovementRepo := uc.stockMoveRepo.WithTx(tx)
+		txLedgerRepo := uc.stockLedgerRepo.WithTx(tx)
+		txItemRepo := uc.itemRepo.WithTx(tx)
+
+		var err error
+		session, err = txCountRepo.GetByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != stock.CountStatusOpen {
+			return stock.ErrCountSessionNotOpen
+		}
+
+		linesByLocation := make(map[stockLocation][]*stock.CountLine)
+		var locations []stockLocation
+		for _, line := range session.Lines {
+			if math.Abs(line.Variance()) < quantityEpsilon {
+				continue
+			}
+			loc := stockLocation{WarehouseID: session.WarehouseID, BinID: line.BinID}
+			if _, ok := linesByLocation[loc]; !ok {
+				locations = append(locations, loc)
+			}
+			linesByLocation[loc] = append(linesByLocation[loc], line)
+		}
+
+		userID, _ := domain.UserIDFromContext(ctx)
+		now := time.Now()
+		reason := fmt.Sprintf("Inventory count %s", session.ID)
+		repos := PostingRepositories{Stock: txStockRepo, Movements: txMovementRepo, Ledger: txLedgerRepo}
+		costRepos := CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: uc.costLayerRepo.WithTx(tx)}
+
+		// Rows are locked in the shared location and item order to avoid deadlocks with transfers
+		// and batch imports.
+		for _, loc := range sortLocations(locations...) {
+			lines := linesByLocation[loc]
+			sort.Slice(lines, func(i, j int) bool { return lines[i].ItemID.String() < lines[j].ItemID.String() })
+			for _, line := range lines {
+				binID := line.BinID
+				quantityBefore, err := LockedQuantity(ctx, txStockRepo, line.ItemID, loc.WarehouseID, &binID)
+				if err != nil {
+					return err
+				}
+				// Count lines are not kept per lot, so variances of tracked items are rejected by the posting.
+				it, err := txItemRepo.GetByID(ctx, line.ItemID)
+				if err != nil {
+					return err
+				}
+
+				variance := line.Variance()
+				movementID := uuid.New()
+				movementType := stock.MovementTypeAdjIn
+				var valuation Valuation
+				if variance < 0 {
+					movementType = stock.MovementTypeAdjOut
+					valuation, err = CostIssue(ctx, costRepos, it, movementID, math.Abs(variance))
+				} else {
+					valuation, err = CostAdjustment(ctx, costRepos, it, movementID, variance, now)
+				}
+				if err != nil {
+					return err
+				}
+				movement, _, err := PostMovement(ctx, repos, Posting{
+					MovementID:     movementID,
+					ItemID:         line.ItemID,
+					WarehouseID:    loc.WarehouseID,
+					BinID:          &binID,
+					Type:           movementType,
+					Quantity:       math.Abs(variance),
+					QuantityBefore: quantityBefore,
+					Reason:         reason,
+					Tracking:       it.TrackingMode,
+					UnitCost:       valuation.UnitCost,
+					HappenedAt:     now,
+					UserID:         userID,
+				})
+				if err != nil {
+					return fmt.Errorf("item %s in bin %s: %w", line.ItemID, binID, err)
+				}
+
+				counted, _ := line.CountedQuantity()
+				adjustments = append(adjustments, &stock.CountAdjustment{
+					LineID:   line.ID,
+					ItemID:   line.ItemID,
+					BinID:    binID,
+					Expected: line.ExpectedQuantity,
+					Counted:  counted,
+					Movement: movement,
+				})
+			}
+		}
+
+		session.Status = stock.CountStatusApproved
+		session.ApprovedAt = &now
+		session.ApprovedBy = &userID
+		session.SetUpdatedBy(userID)
+		return txCountRepo.UpdateStatus(ctx, session)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_count", session.ID.String(), "APPROVE",
+		map[string]interface{}{"status": stock.CountStatusOpen},
+		map[string]interface{}{"status": session.Status, "adjustments": adjustments},
+		corrID)
+
+	return session, adjustments, nil
+}
+
+// CancelCount closes an open session without posting any variance.
+func (uc *countUseCase) CancelCount(ctx context.Context, sessionID uuid.UUID) (*stock.CountSession, error) {
+	var session *stock.CountSession
+
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txCountRepo := uc.countRepo.WithTx(tx)
+
+		var err error
+		session, err = txCountRepo.GetByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Status != stock.CountStatusOpen {
+			return stock.ErrCountSessionNotOpen
+		}
+
+		userID, _ := domain.UserIDFromContext(ctx)
+		session.Status = stock.CountStatusCancelled
+		session.SetUpdatedBy(userID)
+		return txCountRepo.UpdateStatus(ctx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_count", session.ID.String(), "CANCEL",
+		map[string]interface{}{"status": stock.CountStatusOpen},
+		map[string]interface{}{"status": session.Status},
+		corrID)
+
+	return session, nil
+}
@@ -0,0 +1,255 @@
+package stock_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockCountSessionRepository
+type MockCountSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockCountSessionRepository) WithTx(tx *gorm.DB) stock.CountSessionRepository {
+	m.Called(tx)
+	return m
+}
+func (m *MockCountSessionRepository) Create(ctx context.Context, session *stock.CountSession) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+func (m *MockCountSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.CountSession, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.CountSession), args.Error(1)
+}
+func (m *MockCountSessionRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.CountSession, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.CountSession), args.Error(1)
+}
+func (m *MockCountSessionRepository) List(ctx context.Context, status stock.CountStatus) ([]*stock.CountSession, error) {
+	args := m.Called(ctx, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.CountSession), args.Error(1)
+}
+func (m *MockCountSessionRepository) UpdateStatus(ctx context.Context, session *stock.CountSession) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+func (m *MockCountSessionRepository) AddLine(ctx context.Context, line *stock.CountLine) error {
+	args := m.Called(ctx, line)
+	return args.Error(0)
+}
+func (m *MockCountSessionRepository) AddEntry(ctx context.Context, entry *stock.CountEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func setupCountTest() (*stockUseCaseTestSuite, *MockCountSessionRepository, usecase.CountUseCase) {
+	s := setupTestSuite()
+	countRepo := new(MockCountSessionRepository)
+	countRepo.On("WithTx", mock.Anything).Return(countRepo).Maybe()
+	uc := usecase.NewCountUseCase(s.txManager, countRepo, s.stockRepo, s.stockMoveRepo, s.stockLedgerRepo, s.costLayerRepo, s.warehouseRepo, s.binRepo, s.itemRepo, s.auditService)
+	return s, countRepo, uc
+}
+
+func TestStartCount_SnapshotsBinScope(t *testing.T) {
+	s, countRepo, uc := setupCountTest()
+	otherBinID := uuid.New()
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	stocks := []*stock.Stock{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10},
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &otherBinID, Quantity: 3},
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil)
+	s.stockRepo.On("ListByWarehouse", mock.Anything, s.warehouseID).Return(stocks, nil).Once()
+	countRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.CountSession")).Return(nil).Once()
+
+	session, err := uc.StartCount(s.ctx, s.warehouseID, []uuid.UUID{s.binID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, stock.CountStatusOpen, session.Status)
+	assert.Len(t, session.Lines, 1)
+	assert.Equal(t, s.binID, session.Lines[0].BinID)
+	assert.Equal(t, 10.0, session.Lines[0].ExpectedQuantity)
+	countRepo.AssertExpectations(t)
+}
+
+func TestApproveCount_PostsVariances(t *testing.T) {
+	s, countRepo, uc := setupCountTest()
+	shortItemID := uuid.New()
+	uncountedItemID := uuid.New()
+	sessionID := uuid.New()
+	now := time.Now()
+
+	session := &stock.CountSession{
+		ID:          sessionID,
+		WarehouseID: s.warehouseID,
+		Status:      stock.CountStatusOpen,
+		Lines: []*stock.CountLine{
+			{
+				// Two devices counted parts of the bin; device A recounted, so only its latest entry counts: 8 + 4 = 12.
+				ID: uuid.New(), SessionID: sessionID, ItemID: s.itemID, BinID: s.binID, ExpectedQuantity: 10,
+				Entries: []*stock.CountEntry{
+					{ID: uuid.New(), DeviceID: "A", Quantity: 5, CountedAt: now.Add(-time.Minute)},
+					{ID: uuid.New(), DeviceID: "A", Quantity: 8, CountedAt: now},
+					{ID: uuid.New(), DeviceID: "B", Quantity: 4, CountedAt: now},
+				},
+			},
+			{
+				ID: uuid.New(), SessionID: sessionID, ItemID: shortItemID, BinID: s.binID, ExpectedQuantity: 6,
+				Entries: []*stock.CountEntry{{ID: uuid.New(), DeviceID: "A", Quantity: 5, CountedAt: now}},
+			},
+			{ID: uuid.New(), SessionID: sessionID, ItemID: uncountedItemID, BinID: s.binID, ExpectedQuantity: 7},
+		},
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	countRepo.On("GetByIDForUpdate", mock.Anything, sessionID).Return(session, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(&item.Item{ID: s.itemID, Type: item.Storable}, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, shortItemID).Return(&item.Item{ID: shortItemID, Type: item.Storable}, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).
+		Return(&stock.Stock{ItemID: s.itemID, Quantity: 10}, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, shortItemID, s.warehouseID, &s.binID).
+		Return(&stock.Stock{ItemID: shortItemID, Quantity: 6}, nil).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *stock.StockMovement) bool {
+		return m.ItemID == s.itemID && m.Type == stock.MovementTypeAdjIn && m.Quantity == 2
+	})).Return(nil).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *stock.StockMovement) bool {
+		return m.ItemID == shortItemID && m.Type == stock.MovementTypeAdjOut && m.Quantity == 1
+	})).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool {
+		return st.ItemID == s.itemID && st.Quantity == 12
+	})).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool {
+		return st.ItemID == shortItemID && st.Quantity == 5
+	})).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Twice()
+	countRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(cs *stock.CountSession) bool {
+		return cs.Status == stock.CountStatusApproved && cs.ApprovedBy != nil
+	})).Return(nil).Once()
+
+	approved, adjustments, err := uc.ApproveCount(s.ctx, sessionID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stock.CountStatusApproved, approved.Status)
+	assert.Len(t, adjustments, 2)
+	s.stockRepo.AssertNotCalled(t, "GetStockForUpdate", mock.Anything, uncountedItemID, mock.Anything, mock.Anything)
+	s.stockMoveRepo.AssertExpectations(t)
+	s.stockRepo.AssertExpectations(t)
+	countRepo.AssertExpectations(t)
+}
+
+func TestApproveCount_TrackedItemRequiresLots(t *testing.T) {
+	s, countRepo, uc := setupCountTest()
+	sessionID := uuid.New()
+	session := &stock.CountSession{
+		ID:          sessionID,
+		WarehouseID: s.warehouseID,
+		Status:      stock.CountStatusOpen,
+		Lines: []*stock.CountLine{{
+			ID: uuid.New(), SessionID: sessionID, ItemID: s.itemID, BinID: s.binID, ExpectedQuantity: 4,
+			Entries: []*stock.CountEntry{{ID: uuid.New(), DeviceID: "A", Quantity: 3, CountedAt: time.Now()}},
+		}},
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	countRepo.On("GetByIDForUpdate", mock.Anything, sessionID).Return(session, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).
+		Return(&stock.Stock{ItemID: s.itemID, Quantity: 4}, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).
+		Return(&item.Item{ID: s.itemID, Type: item.Storable, TrackingMode: item.TrackingLot}, nil).Once()
+
+	_, _, err := uc.ApproveCount(s.ctx, sessionID)
+
+	assert.ErrorIs(t, err, stock.ErrLotRequired)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	countRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func TestApproveCount_AlreadyApproved(t *testing.T) {
+	s, countRepo, uc := setupCountTest()
+	sessionID := uuid.New()
+	session := &stock.CountSession{ID: sessionID, WarehouseID: s.warehouseID, Status: stock.CountStatusApproved}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	countRepo.On("GetByIDForUpdate", mock.Anything, sessionID).Return(session, nil).Once()
+
+	_, _, err := uc.ApproveCount(s.ctx, sessionID)
+
+	assert.ErrorIs(t, err, stock.ErrCountSessionNotOpen)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSubmitCount_BinOutsideScope(t *testing.T) {
+	s, countRepo, uc := setupCountTest()
+	sessionID := uuid.New()
+	session := &stock.CountSession{
+		ID:          sessionID,
+		WarehouseID: s.warehouseID,
+		BinIDs:      []uuid.UUID{s.binID},
+		Status:      stock.CountStatusOpen,
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	countRepo.On("GetByIDForUpdate", mock.Anything, sessionID).Return(session, nil).Once()
+
+	_, err := uc.SubmitCount(s.ctx, sessionID, s.itemID, uuid.New(), "scanner-1", 3)
+
+	assert.ErrorIs(t, err, stock.ErrBinNotInCount)
+	countRepo.AssertNotCalled(t, "AddEntry", mock.Anything, mock.Anything)
+}
+
+func TestApproveCount_LocksItemsOfABinInIDOrder(t *testing.T) {
+	s, countRepo, uc := setupCountTest()
+	sessionID := uuid.New()
+	itemIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	// Lines come in reverse ID order, as the preload may return them in any order
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i].String() > itemIDs[j].String() })
+	session := &stock.CountSession{ID: sessionID, WarehouseID: s.warehouseID, Status: stock.CountStatusOpen}
+	for _, itemID := range itemIDs {
+		session.Lines = append(session.Lines, &stock.CountLine{
+			ID: uuid.New(), SessionID: sessionID, ItemID: itemID, BinID: s.binID, ExpectedQuantity: 1,
+			Entries: []*stock.CountEntry{{ID: uuid.New(), DeviceID: "A", Quantity: 2, CountedAt: time.Now()}},
+		})
+	}
+
+	var locked []string
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	countRepo.On("GetByIDForUpdate", mock.Anything, sessionID).Return(session, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, mock.Anything, s.warehouseID, &s.binID).
+		Run(func(args mock.Arguments) { locked = append(locked, args.Get(1).(uuid.UUID).String()) }).
+		Return(&stock.Stock{Quantity: 1}, nil).Times(3)
+	for _, itemID := range itemIDs {
+		s.itemRepo.On("GetByID", mock.Anything, itemID).Return(&item.Item{ID: itemID, Type: item.Storable}, nil).Once()
+	}
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Times(3)
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Times(3)
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Times(3)
+	countRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*stock.CountSession")).Return(nil).Once()
+
+	_, _, err := uc.ApproveCount(s.ctx, sessionID)
+
+	assert.NoError(t, err)
+	assert.Len(t, locked, 3)
+	assert.True(t, sort.StringsAreSorted(locked), "stock rows locked in order %v", locked)
+}
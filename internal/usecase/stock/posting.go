@@ -0,0 +1,258 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostingRepositories groups the transactional repositories a posting writes to.
+type PostingRepositories struct {
+	Stock     stock.StockRepository
+	Movements stock.StockMovementRepository
+	Ledger    stock.StockLedgerRepository
+	Lots      stock.StockLotRepository
+}
+
+// Posting describes a single movement to be written against a location
+// whose Stock row has already been locked by the caller.
+type Posting struct {
+	MovementID         uuid.UUID // Generated when nil; set it when the movement was costed beforehand
+	ItemID             uuid.UUID
+	WarehouseID        uuid.UUID
+	BinID              *uuid.UUID
+	Type               stock.MovementType
+	Quantity           float64
+	QuantityBefore     float64
+	Reserved           float64 // Quantity held by reservations that an outbound posting must leave on hand
+	Reason             string
+	TransferID         *uuid.UUID
+	ProductionRecordID *uuid.UUID
+	Tracking           item.TrackingMode
+	Lots               []stock.LotQuantity
+	UnitCost           float64
+	CostVariance       float64
+	HappenedAt         time.Time
+	UserID             uuid.UUID
+}
+
+// PostMovement validates the posting against the locked quantity, less the reserved quantity for
+// outbound postings, and then writes the StockMovement, the resulting Stock row, the lot buckets
+// of tracked items and the matching StockLedger entry. It returns the created movement and the quantity left at the location.
+func PostMovement(ctx context.Context, repos PostingRepositories, p Posting) (*stock.StockMovement, float64, error) {
+	if err := stock.ValidateLots(p.Tracking, p.Quantity, p.Lots); err != nil {
+		return nil, 0, err
+	}
+
+	quantityAfter := p.QuantityBefore + p.Quantity
+	if !p.Type.IsInbound() {
+		if p.QuantityBefore < p.Quantity {
+			return nil, 0, ErrInsufficientStock
+		}
+		if available := p.QuantityBefore - p.Reserved; available < p.Quantity {
+			return nil, 0, fmt.Errorf("%w: %f available, %f reserved", ErrInsufficientStock, available, p.Reserved)
+		}
+		quantityAfter = p.QuantityBefore - p.Quantity
+	}
+
+	if err := postLots(ctx, repos.Lots, p); err != nil {
+		return nil, 0, err
+	}
+
+	movementID := p.MovementID
+	if movementID == uuid.Nil {
+		movementID = uuid.New()
+	}
+	movement := &stock.StockMovement{
+		ID:                 movementID,
+		ItemID:             p.ItemID,
+		WarehouseID:        p.WarehouseID,
+		BinID:              p.BinID,
+		Type:               p.Type,
+		Quantity:           p.Quantity,
+		Reason:             p.Reason,
+		TransferID:         p.TransferID,
+		ProductionRecordID: p.ProductionRecordID,
+		Lots:               p.Lots,
+		UnitCost:           p.UnitCost,
+		CostVariance:       p.CostVariance,
+		HappenedAt:         p.HappenedAt,
+	}
+	movement.SetCreatedBy(p.UserID)
+
+	if err := repos.Movements.Create(ctx, movement); err != nil {
+		return nil, 0, err
+	}
+
+	stockToUpdate := &stock.Stock{
+		ItemID:      p.ItemID,
+		WarehouseID: p.WarehouseID,
+		BinID:       p.BinID,
+		Quantity:    quantityAfter,
+		UpdatedAt:   time.Now(),
+	}
+	if err := repos.Stock.UpsertStock(ctx, stockToUpdate); err != nil {
+		return nil, 0, err
+	}
+
+	ledgerEntry := &stock.StockLedger{
+		ID:              uuid.New(),
+		StockMovementID: movement.ID,
+		ItemID:          p.ItemID,
+		WarehouseID:     p.WarehouseID,
+		BinID:           p.BinID,
+		MovementType:    p.Type,
+		QuantityChange:  p.Quantity,
+		QuantityBefore:  p.QuantityBefore,
+		QuantityAfter:   quantityAfter,
+		UnitCost:        p.UnitCost,
+		CostVariance:    p.CostVariance,
+		Reason:          p.Reason,
+		TransferID:      p.TransferID,
+		HappenedAt:      movement.HappenedAt,
+		RecordedAt:      time.Now(),
+		RecordedBy:      p.UserID,
+	}
+	if err := repos.Ledger.Create(ctx, ledgerEntry); err != nil {
+		return nil, 0, err
+	}
+
+	return movement, quantityAfter, nil
+}
+
+// postLots updates the lot buckets of the location. The buckets are locked in lot number
+// order, after the Stock row of the location, so concurrent postings cannot deadlock.
+func postLots(ctx context.Context, lotRepo stock.StockLotRepository, p Posting) error {
+	lots := append([]stock.LotQuantity(nil), p.Lots...)
+	sort.Slice(lots, func(i, j int) bool { return lots[i].LotNumber < lots[j].LotNumber })
+
+	for _, lot := range lots {
+		var before float64
+		bucket, err := lotRepo.GetForUpdate(ctx, p.ItemID, p.WarehouseID, p.BinID, lot.LotNumber)
+		if err == nil {
+			before = bucket.Quantity
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		after := before + lot.Quantity
+		if p.Type.IsInbound() {
+			if p.Tracking == item.TrackingSerial {
+				total, err := lotRepo.GetTotalQuantity(ctx, p.ItemID, lot.LotNumber)
+				if err != nil {
+					return err
+				}
+				if total > 0 {
+					return fmt.Errorf("%w: %s", stock.ErrSerialInStock, lot.LotNumber)
+				}
+			}
+		} else {
+			if before < lot.Quantity {
+				return fmt.Errorf("%w %s: have %f, need %f", stock.ErrInsufficientLotStock, lot.LotNumber, before, lot.Quantity)
+			}
+			after = before - lot.Quantity
+		}
+
+		if err := lotRepo.Upsert(ctx, &stock.StockLot{
+			ItemID:      p.ItemID,
+			WarehouseID: p.WarehouseID,
+			BinID:       p.BinID,
+			LotNumber:   lot.LotNumber,
+			Quantity:    after,
+			UpdatedAt:   time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockedQuantity locks the Stock row of a location and returns its current quantity.
+// A missing row is treated as zero stock.
+func LockedQuantity(ctx context.Context, stockRepo stock.StockRepository, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (float64, error) {
+	currentStock, err := stockRepo.GetStockForUpdate(ctx, itemID, warehouseID, binID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return currentStock.Quantity, nil
+}
+
+// ScaleLots returns the lot quantities multiplied by factor, e.g. to express lots entered in
+// another unit of measure in the base unit of the item.
+func ScaleLots(lots []stock.LotQuantity, factor float64) []stock.LotQuantity {
+	if factor == 1 {
+		return lots
+	}
+	scaled := make([]stock.LotQuantity, len(lots))
+	for i, l := range lots {
+		scaled[i] = stock.LotQuantity{LotNumber: l.LotNumber, Quantity: l.Quantity * factor}
+	}
+	return scaled
+}
+
+// validateWarehouse ensures the warehouse exists and is active.
+func validateWarehouse(ctx context.Context, warehouseRepo stock.WarehouseRepository, warehouseID uuid.UUID) error {
+	warehouse, err := warehouseRepo.GetByID(ctx, warehouseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("warehouse not found")
+		}
+		return err
+	}
+	if !warehouse.IsActive {
+		return errors.New("warehouse is inactive")
+	}
+	return nil
+}
+
+// validateLocation ensures the warehouse and bin exist, are active and belong together.
+func validateLocation(ctx context.Context, warehouseRepo stock.WarehouseRepository, binRepo stock.BinRepository, warehouseID, binID uuid.UUID) error {
+	if err := validateWarehouse(ctx, warehouseRepo, warehouseID); err != nil {
+		return err
+	}
+
+	bin, err := binRepo.GetByID(ctx, binID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("bin not found")
+		}
+		return err
+	}
+	if bin.WarehouseID != warehouseID {
+		return errors.New("bin does not belong to the specified warehouse")
+	}
+	if !bin.IsActive {
+		return errors.New("bin is inactive")
+	}
+	return nil
+}
+
+// stockLocation identifies a warehouse/bin pair holding stock of an item.
+type stockLocation struct {
+	WarehouseID uuid.UUID
+	BinID       uuid.UUID
+}
+
+// sortLocations returns the locations ordered by warehouse and then bin ID.
+// Every use case that locks more than one Stock row must lock them in this order,
+// and the rows of one location in item ID order.
+func sortLocations(locations ...stockLocation) []stockLocation {
+	sorted := append([]stockLocation(nil), locations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].WarehouseID != sorted[j].WarehouseID {
+			return sorted[i].WarehouseID.String() < sorted[j].WarehouseID.String()
+		}
+		return sorted[i].BinID.String() < sorted[j].BinID.String()
+	})
+	return sorted
+}
@@ -0,0 +1,352 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/infrastructure/db"
+	"doligo_001/internal/usecase"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultReservationTTL is the lifetime of a reservation created without an explicit expiry.
+const DefaultReservationTTL = 72 * time.Hour
+
+var (
+	// ErrSourceRequired is returned when a reservation is not tied to a source document.
+	ErrSourceRequired = errors.New("source_type and source_id are required")
+	// ErrNotStorable is returned when stock of a service item is reserved.
+	ErrNotStorable = errors.New("only storable items can be reserved")
+)
+
+// ReservationUseCase defines the interface for stock reservations (soft allocations).
+type ReservationUseCase interface {
+	Reserve(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string, quantity float64, expiresAt *time.Time) (*stock.Reservation, error)
+	Release(ctx context.Context, id uuid.UUID) (*stock.Reservation, error)
+	Consume(ctx context.Context, id uuid.UUID, quantity float64, reason string, lots []stock.LotQuantity) (*stock.Reservation, *stock.StockMovement, error)
+	GetReservation(ctx context.Context, id uuid.UUID) (*stock.Reservation, error)
+	ListReservations(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error)
+	GetAvailability(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error)
+	ExpireReservations(ctx context.Context) (int64, error)
+}
+
+// reservationUseCase implements the ReservationUseCase interface.
+type reservationUseCase struct {
+	txManager       db.Transactioner
+	reservationRepo stock.ReservationRepository
+	stockRepo       stock.StockRepository
+	stockMoveRepo   stock.StockMovementRepository
+	stockLedgerRepo stock.StockLedgerRepository
+	lotRepo         stock.StockLotRepository
+	costLayerRepo   stock.CostLayerRepository
+	warehouseRepo   stock.WarehouseRepository
+	binRepo         stock.BinRepository
+	itemRepo        item.Repository
+	auditService    usecase.AuditService
+	defaultTTL      time.Duration
+}
+
+// NewReservationUseCase creates a new reservationUseCase. A non-positive defaultTTL
+// falls back to DefaultReservationTTL.
+func NewReservationUseCase(
+	txManager db.Transactioner,
+	reservationRepo stock.ReservationRepository,
+	stockRepo stock.StockRepository,
+	stockMoveRepo stock.StockMovementRepository,
+	stockLedgerRepo stock.StockLedgerRepository,
+	lotRepo stock.StockLotRepository,
+	costLayerRepo stock.CostLayerRepository,
+	warehouseRepo stock.WarehouseRepository,
+	binRepo stock.BinRepository,
+	itemRepo item.Repository,
+	auditService usecase.AuditService,
+	defaultTTL time.Duration,
+) ReservationUseCase {
+	if defaultTTL <= 0 {
+		defaultTTL = DefaultReservationTTL
+	}
+	return &reservationUseCase{
+		txManager:       txManager,
+		reservationRepo: reservationRepo,
+		stockRepo:       stockRepo,
+		stockMoveRepo:   stockMoveRepo,
+		stockLedgerRepo: stockLedgerRepo,
+		lotRepo:         lotRepo,
+		costLayerRepo:   costLayerRepo,
+		warehouseRepo:   warehouseRepo,
+		binRepo:         binRepo,
+		itemRepo:        itemRepo,
+		auditService:    auditService,
+		defaultTTL:      defaultTTL,
+	}
+}
+
+// Reserve holds a quantity of an item at a location for a source document. The Stock row of
+// the location is locked first, exactly as for a movement, so the available quantity cannot
+// change between the check and the insert. A nil binID reserves non-binned stock.
+func (uc *reservationUseCase) Reserve(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string, quantity float64, expiresAt *time.Time) (*stock.Reservation, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if sourceType == "" || sourceID == "" {
+		return nil, ErrSourceRequired
+	}
+	now := time.Now()
+	expiry := now.Add(uc.defaultTTL)
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, stock.ErrInvalidExpiry
+		}
+		expiry = *expiresAt
+	}
+
+	var reservation *stock.Reservation
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txReservationRepo := uc.reservationRepo.WithTx(tx)
+
+		it, err := uc.itemRepo.WithTx(tx).GetByID(ctx, itemID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("item not found")
+			}
+			return err
+		}
+		if it.Type != item.Storable {
+			return ErrNotStorable
+		}
+		if binID != nil {
+			err = validateLocation(ctx, uc.warehouseRepo.WithTx(tx), uc.binRepo.WithTx(tx), warehouseID, *binID)
+		} else {
+			err = validateWarehouse(ctx, uc.warehouseRepo.WithTx(tx), warehouseID)
+		}
+		if err != nil {
+			return err
+		}
+
+		// 1. Lock the location, then check the reservations held against it
+		onHand, err := LockedQuantity(ctx, uc.stockRepo.WithTx(tx), itemID, warehouseID, binID)
+		if err != nil {
+			return err
+		}
+		if _, err := txReservationRepo.FindActive(ctx, itemID, warehouseID, binID, sourceType, sourceID); err == nil {
+			return stock.ErrReservationExists
+		} else if !errors.Is(err, stock.ErrReservationNotFound) {
+			return err
+		}
+		reserved, err := txReservationRepo.ReservedQuantity(ctx, itemID, warehouseID, binID, now)
+		if err != nil {
+			return err
+		}
+		if available := onHand - reserved; available < quantity {
+			return fmt.Errorf("%w: %f available, %f reserved", ErrInsufficientStock, available, reserved)
+		}
+
+		// 2. Hold the quantity
+		userID, _ := domain.UserIDFromContext(ctx)
+		reservation = &stock.Reservation{
+			ID:          uuid.New(),
+			ItemID:      itemID,
+			WarehouseID: warehouseID,
+			BinID:       binID,
+			SourceType:  sourceType,
+			SourceID:    sourceID,
+			Quantity:    quantity,
+			Status:      stock.ReservationActive,
+			ExpiresAt:   expiry,
+		}
+		reservation.SetCreatedBy(userID)
+		reservation.SetUpdatedBy(userID)
+		return txReservationRepo.Create(ctx, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_reservation", reservation.ID.String(), "CREATE", nil, reservation, corrID)
+
+	return reservation, nil
+}
+
+// Release gives the open quantity of an active reservation back to the available stock.
+func (uc *reservationUseCase) Release(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	var reservation *stock.Reservation
+
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txReservationRepo := uc.reservationRepo.WithTx(tx)
+
+		var err error
+		reservation, err = txReservationRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if reservation.Status != stock.ReservationActive {
+			return stock.ErrReservationNotActive
+		}
+
+		userID, _ := domain.UserIDFromContext(ctx)
+		reservation.Status = stock.ReservationReleased
+		reservation.SetUpdatedBy(userID)
+		return txReservationRepo.Update(ctx, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_reservation", reservation.ID.String(), "RELEASE",
+		map[string]interface{}{"status": stock.ReservationActive},
+		map[string]interface{}{"status": reservation.Status, "open_quantity": reservation.OpenQuantity()},
+		corrID)
+
+	return reservation, nil
+}
+
+// Consume posts an OUT movement for part or all of the open quantity of a held reservation,
+// at the location of the reservation. A zero quantity consumes the whole open quantity.
+// The movement may use the quantity of this reservation, but not the quantity held by others.
+func (uc *reservationUseCase) Consume(ctx context.Context, id uuid.UUID, quantity float64, reason string, lots []stock.LotQuantity) (*stock.Reservation, *stock.StockMovement, error) {
+	if quantity < 0 {
+		return nil, nil, ErrInvalidQuantity
+	}
+
+	var reservation *stock.Reservation
+	var movement *stock.StockMovement
+
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txStockRepo := uc.stockRepo.WithTx(tx)
+		txReservationRepo := uc.reservationRepo.WithTx(tx)
+
+		// 1. Find the location of the reservation and lock its Stock row before the reservation
+		current, err := txReservationRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		quantityBefore, err := LockedQuantity(ctx, txStockRepo, current.ItemID, current.WarehouseID, current.BinID)
+		if err != nil {
+			return err
+		}
+		reservation, err = txReservationRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if !reservation.IsHeld(now) {
+			return stock.ErrReservationNotActive
+		}
+		open := reservation.OpenQuantity()
+		if quantity == 0 {
+			quantity = open
+		}
+		if quantity > open+quantityEpsilon {
+			return fmt.Errorf("%w: open %f, requested %f", stock.ErrReservationExceeded, open, quantity)
+		}
+
+		txItemRepo := uc.itemRepo.WithTx(tx)
+		it, err := txItemRepo.GetByID(ctx, reservation.ItemID)
+		if err != nil {
+			return err
+		}
+		reserved, err := txReservationRepo.ReservedQuantity(ctx, reservation.ItemID, reservation.WarehouseID, reservation.BinID, now)
+		if err != nil {
+			return err
+		}
+
+		// 2. Post the movement, leaving the quantity of the other reservations on hand
+		if reason == "" {
+			reason = fmt.Sprintf("Reservation %s %s", reservation.SourceType, reservation.SourceID)
+		}
+		userID, _ := domain.UserIDFromContext(ctx)
+		repos := PostingRepositories{
+			Stock:     txStockRepo,
+			Movements: uc.stockMoveRepo.WithTx(tx),
+			Ledger:    uc.stockLedgerRepo.WithTx(tx),
+			Lots:      uc.lotRepo.WithTx(tx),
+		}
+		movementID := uuid.New()
+		costRepos := CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: uc.costLayerRepo.WithTx(tx)}
+		valuation, err := CostIssue(ctx, costRepos, it, movementID, quantity)
+		if err != nil {
+			return err
+		}
+		movement, _, err = PostMovement(ctx, repos, Posting{
+			MovementID:     movementID,
+			ItemID:         reservation.ItemID,
+			WarehouseID:    reservation.WarehouseID,
+			BinID:          reservation.BinID,
+			Type:           stock.MovementTypeOut,
+			Quantity:       quantity,
+			QuantityBefore: quantityBefore,
+			Reserved:       reserved - open,
+			Reason:         reason,
+			Tracking:       it.TrackingMode,
+			Lots:           lots,
+			UnitCost:       valuation.UnitCost,
+			HappenedAt:     now,
+			UserID:         userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 3. Draw the reservation down
+		reservation.ConsumedQuantity += quantity
+		if reservation.OpenQuantity() < quantityEpsilon {
+			reservation.Status = stock.ReservationConsumed
+		}
+		reservation.SetUpdatedBy(userID)
+		return txReservationRepo.Update(ctx, reservation)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock_reservation", reservation.ID.String(), "CONSUME",
+		map[string]interface{}{"open_quantity": reservation.OpenQuantity() + quantity},
+		map[string]interface{}{"open_quantity": reservation.OpenQuantity(), "status": reservation.Status, "movement_id": movement.ID},
+		corrID)
+
+	return reservation, movement, nil
+}
+
+func (uc *reservationUseCase) GetReservation(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	return uc.reservationRepo.GetByID(ctx, id)
+}
+
+func (uc *reservationUseCase) ListReservations(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error) {
+	return uc.reservationRepo.List(ctx, filter)
+}
+
+// GetAvailability returns the on-hand, reserved and available quantities of an item at a location.
+func (uc *reservationUseCase) GetAvailability(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID) (*stock.Stock, error) {
+	s, err := uc.stockRepo.GetStock(ctx, itemID, warehouseID, binID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		s = &stock.Stock{ItemID: itemID, WarehouseID: warehouseID, BinID: binID}
+	}
+	s.Reserved, err = uc.reservationRepo.ReservedQuantity(ctx, itemID, warehouseID, binID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ExpireReservations marks the active reservations whose expiry has passed as expired.
+// Expired reservations already stop holding stock at their expiry; this only records it.
+func (uc *reservationUseCase) ExpireReservations(ctx context.Context) (int64, error) {
+	return uc.reservationRepo.ExpireDue(ctx, time.Now())
+}
@@ -0,0 +1,203 @@
+package stock_test
+
+import (
+	"testing"
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupReservationTest() (*stockUseCaseTestSuite, usecase.ReservationUseCase) {
+	s := setupTestSuite()
+	uc := usecase.NewReservationUseCase(s.txManager, s.reservationRepo, s.stockRepo, s.stockMoveRepo, s.stockLedgerRepo, s.lotRepo, s.costLayerRepo, s.warehouseRepo, s.binRepo, s.itemRepo, s.auditService, 0)
+	return s, uc
+}
+
+// expectReserveLocation mocks the item, location and locked stock lookups of a reservation.
+func (s *stockUseCaseTestSuite) expectReserveLocation(onHand float64) {
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", Type: item.Storable}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: onHand}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+}
+
+func TestReserve_HoldsAvailableQuantity(t *testing.T) {
+	s, uc := setupReservationTest()
+	s.expectReserveLocation(10)
+	s.reservationRepo.On("FindActive", mock.Anything, s.itemID, s.warehouseID, &s.binID, "SALES_ORDER", "SO-1").Return(nil, stock.ErrReservationNotFound).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(4.0, nil).Once()
+	s.reservationRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.Reservation")).Return(nil).Once()
+
+	before := time.Now()
+	reservation, err := uc.Reserve(s.ctx, s.itemID, s.warehouseID, &s.binID, "SALES_ORDER", "SO-1", 6, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stock.ReservationActive, reservation.Status)
+	assert.Equal(t, 6.0, reservation.OpenQuantity())
+	assert.Equal(t, s.userID, reservation.CreatedBy)
+	assert.True(t, reservation.ExpiresAt.After(before.Add(usecase.DefaultReservationTTL-time.Minute)))
+	s.reservationRepo.AssertExpectations(t)
+}
+
+func TestReserve_InsufficientAvailableStock(t *testing.T) {
+	s, uc := setupReservationTest()
+	s.expectReserveLocation(10)
+	s.reservationRepo.On("FindActive", mock.Anything, s.itemID, s.warehouseID, &s.binID, "SALES_ORDER", "SO-2").Return(nil, stock.ErrReservationNotFound).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(8.0, nil).Once()
+
+	reservation, err := uc.Reserve(s.ctx, s.itemID, s.warehouseID, &s.binID, "SALES_ORDER", "SO-2", 3, nil)
+
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	assert.Nil(t, reservation)
+	s.reservationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReserve_SourceAlreadyHoldsReservation(t *testing.T) {
+	s, uc := setupReservationTest()
+	s.expectReserveLocation(10)
+	existing := &stock.Reservation{ID: uuid.New(), Status: stock.ReservationActive}
+	s.reservationRepo.On("FindActive", mock.Anything, s.itemID, s.warehouseID, &s.binID, "SALES_ORDER", "SO-1").Return(existing, nil).Once()
+
+	reservation, err := uc.Reserve(s.ctx, s.itemID, s.warehouseID, &s.binID, "SALES_ORDER", "SO-1", 1, nil)
+
+	assert.ErrorIs(t, err, stock.ErrReservationExists)
+	assert.Nil(t, reservation)
+}
+
+func TestReserve_ExpiryInThePast(t *testing.T) {
+	s, uc := setupReservationTest()
+	past := time.Now().Add(-time.Hour)
+
+	reservation, err := uc.Reserve(s.ctx, uuid.New(), uuid.New(), nil, "SALES_ORDER", "SO-1", 1, &past)
+
+	assert.ErrorIs(t, err, stock.ErrInvalidExpiry)
+	assert.Nil(t, reservation)
+}
+
+func TestConsume_PartialQuantityKeepsOtherReservationsOnHand(t *testing.T) {
+	s, uc := setupReservationTest()
+	reservationID := uuid.New()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", Type: item.Storable}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+	reservation := &stock.Reservation{
+		ID:          reservationID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		SourceType:  "SALES_ORDER",
+		SourceID:    "SO-1",
+		Quantity:    6,
+		Status:      stock.ReservationActive,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.reservationRepo.On("GetByID", mock.Anything, reservationID).Return(reservation, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("GetByIDForUpdate", mock.Anything, reservationID).Return(reservation, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	// 6 held by this reservation and 4 by others: all 10 units are reserved.
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(10.0, nil).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool { return st.Quantity == 6.0 })).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
+	s.reservationRepo.On("Update", mock.Anything, reservation).Return(nil).Once()
+
+	updated, movement, err := uc.Consume(s.ctx, reservationID, 4, "", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stock.MovementTypeOut, movement.Type)
+	assert.Equal(t, "Reservation SALES_ORDER SO-1", movement.Reason)
+	assert.Equal(t, 2.0, updated.OpenQuantity())
+	assert.Equal(t, stock.ReservationActive, updated.Status)
+	s.stockRepo.AssertExpectations(t)
+}
+
+func TestConsume_MoreThanOpenQuantity(t *testing.T) {
+	s, uc := setupReservationTest()
+	reservationID := uuid.New()
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+	reservation := &stock.Reservation{
+		ID:               reservationID,
+		ItemID:           s.itemID,
+		WarehouseID:      s.warehouseID,
+		BinID:            &s.binID,
+		Quantity:         5,
+		ConsumedQuantity: 3,
+		Status:           stock.ReservationActive,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.reservationRepo.On("GetByID", mock.Anything, reservationID).Return(reservation, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("GetByIDForUpdate", mock.Anything, reservationID).Return(reservation, nil).Once()
+
+	_, movement, err := uc.Consume(s.ctx, reservationID, 3, "", nil)
+
+	assert.ErrorIs(t, err, stock.ErrReservationExceeded)
+	assert.Nil(t, movement)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestConsume_ExpiredReservation(t *testing.T) {
+	s, uc := setupReservationTest()
+	reservationID := uuid.New()
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+	reservation := &stock.Reservation{
+		ID:          reservationID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		Quantity:    5,
+		Status:      stock.ReservationActive,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.reservationRepo.On("GetByID", mock.Anything, reservationID).Return(reservation, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("GetByIDForUpdate", mock.Anything, reservationID).Return(reservation, nil).Once()
+
+	_, _, err := uc.Consume(s.ctx, reservationID, 0, "", nil)
+
+	assert.ErrorIs(t, err, stock.ErrReservationNotActive)
+}
+
+func TestRelease_OnlyActiveReservations(t *testing.T) {
+	s, uc := setupReservationTest()
+	reservationID := uuid.New()
+	reservation := &stock.Reservation{ID: reservationID, Quantity: 5, ConsumedQuantity: 5, Status: stock.ReservationConsumed}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.reservationRepo.On("GetByIDForUpdate", mock.Anything, reservationID).Return(reservation, nil).Once()
+
+	released, err := uc.Release(s.ctx, reservationID)
+
+	assert.ErrorIs(t, err, stock.ErrReservationNotActive)
+	assert.Nil(t, released)
+	s.reservationRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestGetAvailability_SubtractsReservedQuantity(t *testing.T) {
+	s, uc := setupReservationTest()
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+	s.stockRepo.On("GetStock", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(7.5, nil).Once()
+
+	availability, err := uc.GetAvailability(s.ctx, s.itemID, s.warehouseID, &s.binID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, availability.Quantity)
+	assert.Equal(t, 7.5, availability.Reserved)
+	assert.Equal(t, 2.5, availability.Available())
+}
@@ -4,14 +4,18 @@ package stock
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"doligo_001/internal/domain"
 	"doligo_001/internal/domain/item"
 	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
 	"doligo_001/internal/infrastructure/db"
 	"doligo_001/internal/api/middleware"
 	"doligo_001/internal/usecase"
+	uom_uc "doligo_001/internal/usecase/uom"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -19,17 +23,42 @@ import (
 var (
 	ErrInsufficientStock = errors.New("insufficient stock for movement")
 	ErrBinRequired       = errors.New("bin_id is required for all stock movements")
+	ErrSameLocation      = errors.New("source and destination locations must be different")
+	ErrInvalidQuantity   = errors.New("quantity must be greater than zero")
+	ErrInvalidDateRange  = errors.New("start date must not be after end date")
+	// ErrLocationHasStock is returned when a warehouse or bin that still holds stock is deactivated or deleted.
+	ErrLocationHasStock = errors.New("location still holds stock")
+)
+
+const (
+	// DefaultLedgerPageSize is used when a ledger query does not specify a limit.
+	DefaultLedgerPageSize = 50
+	// MaxLedgerPageSize caps the number of ledger entries returned by a single query.
+	MaxLedgerPageSize = 500
 )
 
 // UseCase defines the interface for stock management use cases.
 type UseCase interface {
-	CreateStockMovement(ctx context.Context, itemID, warehouseID, binID uuid.UUID, movementType stock.MovementType, quantity float64, unitPrice float64, reason string) (*stock.StockMovement, error)
+	// CreateStockMovement and TransferStock take the quantity, unit price and lots in unit, converted
+	// to the base unit of the item; an empty unit means the base unit.
+	CreateStockMovement(ctx context.Context, itemID, warehouseID, binID uuid.UUID, movementType stock.MovementType, quantity float64, unit string, unitPrice float64, reason string, lots []stock.LotQuantity) (*stock.StockMovement, error)
 	ReverseStockMovement(ctx context.Context, movementID uuid.UUID, reason string) (*stock.StockMovement, error)
+	TransferStock(ctx context.Context, itemID, fromWarehouseID, fromBinID, toWarehouseID, toBinID uuid.UUID, quantity float64, unit string, reason string, lots []stock.LotQuantity) (*stock.StockTransfer, error)
 	CreateWarehouse(ctx context.Context, name string) (*stock.Warehouse, error)
 	ListWarehouses(ctx context.Context) ([]*stock.Warehouse, error)
 	GetWarehouseByID(ctx context.Context, id uuid.UUID) (*stock.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id uuid.UUID, name string, isActive bool) (*stock.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, id uuid.UUID) error
 	CreateBin(ctx context.Context, name string, warehouseID uuid.UUID) (*stock.Bin, error)
+	GetBinByID(ctx context.Context, id uuid.UUID) (*stock.Bin, error)
+	UpdateBin(ctx context.Context, id uuid.UUID, name string, isActive bool) (*stock.Bin, error)
+	DeleteBin(ctx context.Context, id uuid.UUID) error
 	ListBinsByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Bin, error)
+	ListWarehouseStock(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error)
+	ListBinStock(ctx context.Context, binID uuid.UUID) ([]*stock.Stock, error)
+	ListLedgerEntries(ctx context.Context, filter stock.LedgerFilter) ([]*stock.StockLedger, int64, error)
+	GetStockAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.Stock, error)
+	ListLots(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*stock.StockLot, error)
 }
 
 // stockUseCase implements the UseCase interface.
@@ -38,9 +67,13 @@ type stockUseCase struct {
 	stockRepo    stock.StockRepository
 	stockMoveRepo stock.StockMovementRepository
 	stockLedgerRepo stock.StockLedgerRepository
+	lotRepo      stock.StockLotRepository
+	costLayerRepo stock.CostLayerRepository
+	reservationRepo stock.ReservationRepository
 	warehouseRepo stock.WarehouseRepository
 	binRepo      stock.BinRepository
 	itemRepo     item.Repository
+	unitRepo     uom.Repository
 	auditService usecase.AuditService
 }
 
@@ -50,9 +83,13 @@ func NewUseCase(
 	stockRepo stock.StockRepository,
 	stockMoveRepo stock.StockMovementRepository,
 	stockLedgerRepo stock.StockLedgerRepository,
+	lotRepo stock.StockLotRepository,
+	costLayerRepo stock.CostLayerRepository,
+	reservationRepo stock.ReservationRepository,
 	warehouseRepo stock.WarehouseRepository,
 	binRepo stock.BinRepository,
 	itemRepo item.Repository,
+	unitRepo uom.Repository,
 	auditService usecase.AuditService,
 ) UseCase {
 	return &stockUseCase{
@@ -60,15 +97,23 @@ func NewUseCase(
 		stockRepo:    stockRepo,
 		stockMoveRepo: stockMoveRepo,
 		stockLedgerRepo: stockLedgerRepo,
+		lotRepo:      lotRepo,
+		costLayerRepo: costLayerRepo,
+		reservationRepo: reservationRepo,
 		warehouseRepo: warehouseRepo,
 		binRepo:      binRepo,
 		itemRepo:     itemRepo,
+		unitRepo:     unitRepo,
 		auditService: auditService,
 	}
 }
 
 // CreateStockMovement handles the logic for creating a stock movement atomically.
-func (uc *stockUseCase) CreateStockMovement(ctx context.Context, itemID, warehouseID, binID uuid.UUID, movementType stock.MovementType, quantity float64, unitPrice float64, reason string) (*stock.StockMovement, error) {
+// Movements of lot or serial tracked items must break the quantity down into lots.
+// Outbound movements may only take the quantity that is not held by reservations.
+// The movement is valued according to the costing method of the item, see CostReceipt and CostIssue.
+// It is recorded in the base unit of the item, with the unit price per base unit.
+func (uc *stockUseCase) CreateStockMovement(ctx context.Context, itemID, warehouseID, binID uuid.UUID, movementType stock.MovementType, quantity float64, unit string, unitPrice float64, reason string, lots []stock.LotQuantity) (*stock.StockMovement, error) {
 	var createdMovement *stock.StockMovement
 	var quantityBefore float64
 	var quantityAfter float64
@@ -83,9 +128,13 @@ func (uc *stockUseCase) CreateStockMovement(ctx context.Context, itemID, warehou
 		txStockRepo := uc.stockRepo.WithTx(tx)
 		txMovementRepo := uc.stockMoveRepo.WithTx(tx)
 		txLedgerRepo := uc.stockLedgerRepo.WithTx(tx)
+		txLotRepo := uc.lotRepo.WithTx(tx)
 		txItemRepo := uc.itemRepo.WithTx(tx)
 		txWarehouseRepo := uc.warehouseRepo.WithTx(tx)
 		txBinRepo := uc.binRepo.WithTx(tx)
+		costRepos := CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: uc.costLayerRepo.WithTx(tx)}
+		movementID := uuid.New()
+		var valuation Valuation
 
 		// Validate ItemID and get current CMP
 		it, err := txItemRepo.GetByID(ctx, itemID)
@@ -96,132 +145,82 @@ func (uc *stockUseCase) CreateStockMovement(ctx context.Context, itemID, warehou
 			return err
 		}
 
-		// Calculate CMP if it's an IN movement
-		if movementType == stock.MovementTypeIn {
-			totalQtyBefore, err := txStockRepo.GetTotalQuantity(ctx, itemID)
+		// Express the quantities and the price in the base unit of the item
+		factor, err := uom_uc.BaseFactor(ctx, uc.unitRepo, it, unit)
+		if err != nil {
+			return err
+		}
+		quantity, unitPrice, lots = quantity*factor, unitPrice/factor, ScaleLots(lots, factor)
+
+		// Reject missing or malformed lots before touching the item or the stock
+		if err := stock.ValidateLots(it.TrackingMode, quantity, lots); err != nil {
+			return err
+		}
+
+		// Calculate CMP, and open the FIFO layer or the standard cost variance, if it's an IN movement
+		switch movementType {
+		case stock.MovementTypeIn:
+			it.CostPrice = unitPrice // Update CostPrice with the latest purchase price as well
+			valuation, err = CostReceipt(ctx, costRepos, it, movementID, quantity, unitPrice, time.Now())
 			if err != nil {
 				return err
 			}
-
-			// Novo CMP = (Valor Total Antigo + Valor da Nova Entrada) / (Qtd Antiga + Qtd Nova)
-			// Valor Total Antigo = totalQtyBefore * it.AverageCost
-			// Valor da Nova Entrada = quantity * unitPrice
-			
-			oldTotalValue := totalQtyBefore * it.AverageCost
-			newEntryValue := quantity * unitPrice
-			totalQtyAfter := totalQtyBefore + quantity
-			
-			newCMP := (oldTotalValue + newEntryValue) / totalQtyAfter
-			
-			it.AverageCost = newCMP
-			it.CostPrice = unitPrice // Update CostPrice with the latest purchase price as well
-			it.UpdatedAt = time.Now()
-			userID, _ := domain.UserIDFromContext(ctx)
-			it.UpdatedBy = userID
-			
-			if err := txItemRepo.Update(ctx, it); err != nil {
+		case stock.MovementTypeAdjIn:
+			valuation, err = CostAdjustment(ctx, costRepos, it, movementID, quantity, time.Now())
+			if err != nil {
 				return err
 			}
 		}
 
-		// Validate WarehouseID
-		warehouse, err := txWarehouseRepo.GetByID(ctx, warehouseID)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("warehouse not found")
-			}
+		// Validate WarehouseID and BinID
+		if err := validateLocation(ctx, txWarehouseRepo, txBinRepo, warehouseID, binID); err != nil {
 			return err
 		}
-		if !warehouse.IsActive {
-			return errors.New("warehouse is inactive")
-		}
 
-		// Validate BinID
-		bin, err := txBinRepo.GetByID(ctx, binID)
+		// 1. Get current stock with pessimistic lock, then the quantity reserved at the location
+		quantityBefore, err = LockedQuantity(ctx, txStockRepo, itemID, warehouseID, &binID)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("bin not found")
-			}
-			return err
-		}
-		if bin.WarehouseID != warehouseID {
-			return errors.New("bin does not belong to the specified warehouse")
-		}
-		if !bin.IsActive {
-			return errors.New("bin is inactive")
-		}
-
-		// 1. Get current stock with pessimistic lock
-		currentStock, err := txStockRepo.GetStockForUpdate(ctx, itemID, warehouseID, &binID)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
-
-		quantityBefore = 0.0
-		if currentStock != nil {
-			quantityBefore = currentStock.Quantity
-		}
-
-		// 2. Validate movement
-		if movementType == stock.MovementTypeOut {
-			if quantityBefore < quantity {
-				return ErrInsufficientStock
+		var reserved float64
+		if !movementType.IsInbound() {
+			reserved, err = uc.reservationRepo.WithTx(tx).ReservedQuantity(ctx, itemID, warehouseID, &binID, time.Now())
+			if err != nil {
+				return err
+			}
+			valuation, err = CostIssue(ctx, costRepos, it, movementID, quantity)
+			if err != nil {
+				return err
 			}
-			quantityAfter = quantityBefore - quantity
-		} else {
-			quantityAfter = quantityBefore + quantity
 		}
 
-		// 3. Create StockMovement
+		// 2. Validate and post the movement, stock and ledger entry
 		userID, _ := domain.UserIDFromContext(ctx)
-		movement := &stock.StockMovement{
-			ID:          uuid.New(),
-			ItemID:      itemID,
-			WarehouseID: warehouseID,
-			BinID:       &binID,
-			Type:        movementType,
-			Quantity:    quantity,
-			Reason:      reason,
-			HappenedAt:  time.Now(),
-		}
-		movement.SetCreatedBy(userID)
-
-		if err := txMovementRepo.Create(ctx, movement); err != nil {
+		repos := PostingRepositories{Stock: txStockRepo, Movements: txMovementRepo, Ledger: txLedgerRepo, Lots: txLotRepo}
+		movement, after, err := PostMovement(ctx, repos, Posting{
+			MovementID:     movementID,
+			ItemID:         itemID,
+			WarehouseID:    warehouseID,
+			BinID:          &binID,
+			Type:           movementType,
+			Quantity:       quantity,
+			QuantityBefore: quantityBefore,
+			Reserved:       reserved,
+			Reason:         reason,
+			Tracking:       it.TrackingMode,
+			Lots:           lots,
+			UnitCost:       valuation.UnitCost,
+			CostVariance:   valuation.CostVariance,
+			HappenedAt:     time.Now(),
+			UserID:         userID,
+		})
+		if err != nil {
 			return err
 		}
 
 		createdMovement = movement
-
-		// 4. Upsert Stock
-		stockToUpdate := &stock.Stock{
-			ItemID:      itemID,
-			WarehouseID: warehouseID,
-			BinID:       &binID,
-			Quantity:    quantityAfter,
-			UpdatedAt:   time.Now(),
-		}
-		if err := txStockRepo.UpsertStock(ctx, stockToUpdate); err != nil {
-			return err
-		}
-
-		// 5. Create StockLedger entry
-		ledgerEntry := &stock.StockLedger{
-			ID:              uuid.New(),
-			StockMovementID: movement.ID,
-			ItemID:          itemID,
-			WarehouseID:     warehouseID,
-			BinID:           &binID,
-			MovementType:    movementType,
-			QuantityChange:  quantity,
-			QuantityBefore:  quantityBefore,
-			QuantityAfter:   quantityAfter,
-			Reason:          reason,
-			HappenedAt:      movement.HappenedAt,
-			RecordedAt:      time.Now(),
-			RecordedBy:      userID,
-		}
-
-		return txLedgerRepo.Create(ctx, ledgerEntry)
+		quantityAfter = after
+		return nil
 	})
 
 	if err == nil {
@@ -295,139 +294,317 @@ func (uc *stockUseCase) ListBinsByWarehouse(ctx context.Context, warehouseID uui
 	return uc.binRepo.ListByWarehouse(ctx, warehouseID)
 }
 
+// UpdateWarehouse renames a warehouse and activates or deactivates it.
+// A warehouse that still holds stock in any of its bins cannot be deactivated.
+func (uc *stockUseCase) UpdateWarehouse(ctx context.Context, id uuid.UUID, name string, isActive bool) (*stock.Warehouse, error) {
+	var oldWarehouse, updatedWarehouse *stock.Warehouse
+	userID, _ := domain.UserIDFromContext(ctx)
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := uc.warehouseRepo.WithTx(tx)
+		warehouse, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if warehouse.IsActive && !isActive {
+			if err := ensureLocationEmpty(ctx, uc.stockRepo.WithTx(tx), id, nil); err != nil {
+				return err
+			}
+		}
+
+		old := *warehouse
+		oldWarehouse = &old
+		warehouse.Name = name
+		warehouse.IsActive = isActive
+		warehouse.UpdatedAt = time.Now()
+		warehouse.SetUpdatedBy(userID)
+		if err := repo.Update(ctx, warehouse); err != nil {
+			return err
+		}
+		updatedWarehouse = warehouse
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "warehouse", id.String(), "UPDATE", oldWarehouse, updatedWarehouse, corrID)
+	return updatedWarehouse, nil
+}
+
+// DeleteWarehouse deletes a warehouse together with its bins.
+// A warehouse that still holds stock in any of its bins cannot be deleted.
+func (uc *stockUseCase) DeleteWarehouse(ctx context.Context, id uuid.UUID) error {
+	var oldWarehouse *stock.Warehouse
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := uc.warehouseRepo.WithTx(tx)
+		txBinRepo := uc.binRepo.WithTx(tx)
+		warehouse, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := ensureLocationEmpty(ctx, uc.stockRepo.WithTx(tx), id, nil); err != nil {
+			return err
+		}
+
+		bins, err := txBinRepo.ListByWarehouse(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, bin := range bins {
+			if err := txBinRepo.Delete(ctx, bin.ID); err != nil {
+				return err
+			}
+		}
+		oldWarehouse = warehouse
+		return repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "warehouse", id.String(), "DELETE", oldWarehouse, nil, corrID)
+	return nil
+}
+
+func (uc *stockUseCase) GetBinByID(ctx context.Context, id uuid.UUID) (*stock.Bin, error) {
+	return uc.binRepo.GetByID(ctx, id)
+}
+
+// UpdateBin renames a bin and activates or deactivates it.
+// A bin that still holds stock cannot be deactivated.
+func (uc *stockUseCase) UpdateBin(ctx context.Context, id uuid.UUID, name string, isActive bool) (*stock.Bin, error) {
+	var oldBin, updatedBin *stock.Bin
+	userID, _ := domain.UserIDFromContext(ctx)
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := uc.binRepo.WithTx(tx)
+		bin, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if bin.IsActive && !isActive {
+			if err := ensureLocationEmpty(ctx, uc.stockRepo.WithTx(tx), bin.WarehouseID, &id); err != nil {
+				return err
+			}
+		}
+
+		old := *bin
+		oldBin = &old
+		bin.Name = name
+		bin.IsActive = isActive
+		bin.UpdatedAt = time.Now()
+		bin.SetUpdatedBy(userID)
+		if err := repo.Update(ctx, bin); err != nil {
+			return err
+		}
+		updatedBin = bin
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "bin", id.String(), "UPDATE", oldBin, updatedBin, corrID)
+	return updatedBin, nil
+}
+
+// DeleteBin deletes a bin. A bin that still holds stock cannot be deleted.
+func (uc *stockUseCase) DeleteBin(ctx context.Context, id uuid.UUID) error {
+	var oldBin *stock.Bin
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		repo := uc.binRepo.WithTx(tx)
+		bin, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := ensureLocationEmpty(ctx, uc.stockRepo.WithTx(tx), bin.WarehouseID, &id); err != nil {
+			return err
+		}
+		oldBin = bin
+		return repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "bin", id.String(), "DELETE", oldBin, nil, corrID)
+	return nil
+}
+
+// ListWarehouseStock returns the items on hand in all bins of a warehouse.
+func (uc *stockUseCase) ListWarehouseStock(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	if _, err := uc.warehouseRepo.GetByID(ctx, warehouseID); err != nil {
+		return nil, err
+	}
+	return stockOnHand(ctx, uc.stockRepo, warehouseID, nil)
+}
+
+// ListBinStock returns the items on hand in a bin.
+func (uc *stockUseCase) ListBinStock(ctx context.Context, binID uuid.UUID) ([]*stock.Stock, error) {
+	bin, err := uc.binRepo.GetByID(ctx, binID)
+	if err != nil {
+		return nil, err
+	}
+	return stockOnHand(ctx, uc.stockRepo, bin.WarehouseID, &binID)
+}
+
+// stockOnHand returns the Stock rows of a warehouse, or of one of its bins, with a non-zero quantity.
+func stockOnHand(ctx context.Context, stockRepo stock.StockRepository, warehouseID uuid.UUID, binID *uuid.UUID) ([]*stock.Stock, error) {
+	stocks, err := stockRepo.ListByWarehouse(ctx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	onHand := make([]*stock.Stock, 0, len(stocks))
+	for _, s := range stocks {
+		if binID != nil && (s.BinID == nil || *s.BinID != *binID) {
+			continue
+		}
+		if math.Abs(s.Quantity) > quantityEpsilon {
+			onHand = append(onHand, s)
+		}
+	}
+	return onHand, nil
+}
+
+// ensureLocationEmpty returns ErrLocationHasStock when the warehouse, or one of its bins, holds any stock.
+func ensureLocationEmpty(ctx context.Context, stockRepo stock.StockRepository, warehouseID uuid.UUID, binID *uuid.UUID) error {
+	onHand, err := stockOnHand(ctx, stockRepo, warehouseID, binID)
+	if err != nil {
+		return err
+	}
+	if len(onHand) > 0 {
+		return fmt.Errorf("%w: %d item(s) on hand", ErrLocationHasStock, len(onHand))
+	}
+	return nil
+}
+
+// ListLedgerEntries returns one page of stock ledger entries matching the filter,
+// along with the total number of matching entries.
+func (uc *stockUseCase) ListLedgerEntries(ctx context.Context, filter stock.LedgerFilter) ([]*stock.StockLedger, int64, error) {
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, 0, ErrInvalidDateRange
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLedgerPageSize
+	}
+	if filter.Limit > MaxLedgerPageSize {
+		filter.Limit = MaxLedgerPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return uc.stockLedgerRepo.List(ctx, filter)
+}
+
+// GetStockAsOf rebuilds the stock quantities of every location as they stood at asOf,
+// using the QuantityAfter of the last ledger entry recorded for each location.
+func (uc *stockUseCase) GetStockAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.Stock, error) {
+	return uc.stockLedgerRepo.ListBalancesAsOf(ctx, asOf, filter)
+}
+
+// ListLots returns the lot buckets holding stock of an item, optionally for a single lot or serial number.
+func (uc *stockUseCase) ListLots(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*stock.StockLot, error) {
+	return uc.lotRepo.List(ctx, itemID, lotNumber)
+}
+
+// ReverseStockMovement posts the opposite of a movement at the cost it was recorded at and marks
+// it reversed, so that it cannot be reversed twice. Transfer legs and the movements of production
+// runs cannot be reversed on their own: a transfer is undone by transferring the stock back and a
+// production run through its own reversal.
 func (uc *stockUseCase) ReverseStockMovement(ctx context.Context, movementID uuid.UUID, reason string) (*stock.StockMovement, error) {
 	var reversedMovement *stock.StockMovement
-	var quantityBefore float64
-	var quantityAfter float64
 
 	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
 		txStockRepo := uc.stockRepo.WithTx(tx)
 		txMovementRepo := uc.stockMoveRepo.WithTx(tx)
 		txLedgerRepo := uc.stockLedgerRepo.WithTx(tx)
+		txLotRepo := uc.lotRepo.WithTx(tx)
 		txItemRepo := uc.itemRepo.WithTx(tx)
 
-		// 1. Find original movement
-		origMove, err := txMovementRepo.GetByID(ctx, movementID)
+		// 1. Find and lock the original movement, then its item
+		origMove, err := txMovementRepo.GetByIDForUpdate(ctx, movementID)
+		if err != nil {
+			return err
+		}
+		if origMove.ReversedAt != nil {
+			return stock.ErrMovementReversed
+		}
+		if origMove.TransferID != nil {
+			return fmt.Errorf("%w: it is a leg of transfer %s", stock.ErrMovementNotReversible, *origMove.TransferID)
+		}
+		if origMove.ProductionRecordID != nil {
+			return fmt.Errorf("%w: it belongs to production run %s", stock.ErrMovementNotReversible, *origMove.ProductionRecordID)
+		}
+		it, err := txItemRepo.GetByID(ctx, origMove.ItemID)
 		if err != nil {
 			return err
 		}
 
 		// 2. Calculate reverse type and quantity
 		reverseType := stock.MovementTypeIn
-		if origMove.Type == stock.MovementTypeIn {
+		if origMove.Type.IsInbound() {
 			reverseType = stock.MovementTypeOut
 		}
 
-		// CMP Logic: If we are putting stock BACK (Reverse Type IN), we must update CMP.
-		// Since we lack the specific unit cost of the original movement, we use the current AverageCost
-		// as a neutral proxy to maintain consistency without introducing artificial variance.
-		if reverseType == stock.MovementTypeIn {
-			it, err := txItemRepo.GetByID(ctx, origMove.ItemID)
-			if err != nil {
-				return err
-			}
-
-			totalQtyBefore, err := txStockRepo.GetTotalQuantity(ctx, origMove.ItemID)
+		// 3. Get current stock with lock and, when the reversal takes stock out, the quantity
+		// reserved at the location, which it must leave on hand
+		quantityBefore, err := LockedQuantity(ctx, txStockRepo, origMove.ItemID, origMove.WarehouseID, origMove.BinID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		var reserved float64
+		if reverseType == stock.MovementTypeOut {
+			reserved, err = uc.reservationRepo.WithTx(tx).ReservedQuantity(ctx, origMove.ItemID, origMove.WarehouseID, origMove.BinID, now)
 			if err != nil {
 				return err
 			}
-
-			// CMP Calculation
-			// Current Value = Current Total Qty * Current Average Cost
-			// Value of Return = Return Qty * Current Average Cost (Proxy)
-			// New CMP = (Current Value + Value of Return) / (Current Total Qty + Return Qty)
-			
-			// Note: Mathematically, using the same AverageCost yields the same result,
-			// but we perform the logic to support future enhancements (e.g. if we fetch historical cost).
-			
-			oldTotalValue := totalQtyBefore * it.AverageCost
-			reversalValue := origMove.Quantity * it.AverageCost 
-			totalQtyAfter := totalQtyBefore + origMove.Quantity
-			
-			if totalQtyAfter > 0 {
-				newCMP := (oldTotalValue + reversalValue) / totalQtyAfter
-				it.AverageCost = newCMP
-				it.UpdatedAt = time.Now()
-				userID, _ := domain.UserIDFromContext(ctx)
-				it.UpdatedBy = userID
-				
-				if err := txItemRepo.Update(ctx, it); err != nil {
-					return err
-				}
-			}
 		}
 
-		// 3. Get current stock with lock
-		currentStock, err := txStockRepo.GetStockForUpdate(ctx, origMove.ItemID, origMove.WarehouseID, origMove.BinID)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		// CMP Logic: the reversal is valued at the unit cost recorded on the original movement,
+		// so the value it moves out of (or back into) the average and the FIFO layers is exact.
+		movementID := uuid.New()
+		costRepos := CostingRepositories{Stock: txStockRepo, Items: txItemRepo, Layers: uc.costLayerRepo.WithTx(tx)}
+		valuation, err := CostReversal(ctx, costRepos, it, origMove, movementID, now)
+		if err != nil {
 			return err
 		}
 
-		quantityBefore = 0.0
-		if currentStock != nil {
-			quantityBefore = currentStock.Quantity
-		}
-
-		// 4. Validate reverse movement
-		if reverseType == stock.MovementTypeOut {
-			if quantityBefore < origMove.Quantity {
-				return ErrInsufficientStock
-			}
-			quantityAfter = quantityBefore - origMove.Quantity
-		} else {
-			quantityAfter = quantityBefore + origMove.Quantity
-		}
-
-		// 5. Create Reversal StockMovement
+		// 4. Validate and post the reversal movement, stock and ledger entry, returning the same lots
 		userID, _ := domain.UserIDFromContext(ctx)
-		movement := &stock.StockMovement{
-			ID:          uuid.New(),
-			ItemID:      origMove.ItemID,
-			WarehouseID: origMove.WarehouseID,
-			BinID:       origMove.BinID,
-			Type:        reverseType,
-			Quantity:    origMove.Quantity,
-			Reason:      "REVERSAL: " + reason,
-			HappenedAt:  time.Now(),
-		}
-		movement.SetCreatedBy(userID)
-
-		if err := txMovementRepo.Create(ctx, movement); err != nil {
+		repos := PostingRepositories{Stock: txStockRepo, Movements: txMovementRepo, Ledger: txLedgerRepo, Lots: txLotRepo}
+		movement, _, err := PostMovement(ctx, repos, Posting{
+			MovementID:     movementID,
+			ItemID:         origMove.ItemID,
+			WarehouseID:    origMove.WarehouseID,
+			BinID:          origMove.BinID,
+			Type:           reverseType,
+			Quantity:       origMove.Quantity,
+			QuantityBefore: quantityBefore,
+			Reserved:       reserved,
+			Reason:         "REVERSAL: " + reason,
+			Tracking:       it.TrackingMode,
+			Lots:           origMove.Lots,
+			UnitCost:       valuation.UnitCost,
+			CostVariance:   valuation.CostVariance,
+			HappenedAt:     now,
+			UserID:         userID,
+		})
+		if err != nil {
 			return err
 		}
 
-		reversedMovement = movement
-
-		// 6. Upsert Stock
-		stockToUpdate := &stock.Stock{
-			ItemID:      origMove.ItemID,
-			WarehouseID: origMove.WarehouseID,
-			BinID:       origMove.BinID,
-			Quantity:    quantityAfter,
-			UpdatedAt:   time.Now(),
-		}
-		if err := txStockRepo.UpsertStock(ctx, stockToUpdate); err != nil {
+		origMove.ReversedAt = &now
+		if err := txMovementRepo.MarkReversed(ctx, origMove); err != nil {
 			return err
 		}
-
-		// 7. Create StockLedger entry
-		ledgerEntry := &stock.StockLedger{
-			ID:              uuid.New(),
-			StockMovementID: movement.ID,
-			ItemID:          origMove.ItemID,
-			WarehouseID:     origMove.WarehouseID,
-			BinID:           origMove.BinID,
-			MovementType:    reverseType,
-			QuantityChange:  origMove.Quantity,
-			QuantityBefore:  quantityBefore,
-			QuantityAfter:   quantityAfter,
-			Reason:          movement.Reason,
-			HappenedAt:      movement.HappenedAt,
-			RecordedAt:      time.Now(),
-			RecordedBy:      userID,
-		}
-
-		return txLedgerRepo.Create(ctx, ledgerEntry)
+		reversedMovement = movement
+		return nil
 	})
 
 	if err == nil {
@@ -441,3 +618,136 @@ func (uc *stockUseCase) ReverseStockMovement(ctx context.Context, movementID uui
 
 	return reversedMovement, err
 }
+
+// TransferStock moves a quantity of an item from one warehouse/bin to another in a single
+// transaction. Both Stock rows are locked in a deterministic order so that two transfers
+// running in opposite directions cannot deadlock. A transfer does not change the value of
+// the inventory, so the item's AverageCost and FIFO layers are left untouched and both legs
+// are recorded at the current unit cost of the item. The lots of a tracked item
+// leave the source and arrive at the destination unchanged. Reserved stock cannot be transferred.
+// The quantity and lots are converted from unit to the base unit of the item.
+func (uc *stockUseCase) TransferStock(ctx context.Context, itemID, fromWarehouseID, fromBinID, toWarehouseID, toBinID uuid.UUID, quantity float64, unit string, reason string, lots []stock.LotQuantity) (*stock.StockTransfer, error) {
+	if fromBinID == uuid.Nil || toBinID == uuid.Nil {
+		return nil, ErrBinRequired
+	}
+	if fromWarehouseID == toWarehouseID && fromBinID == toBinID {
+		return nil, ErrSameLocation
+	}
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	transfer := &stock.StockTransfer{ID: uuid.New()}
+	var sourceBefore, destinationBefore float64
+
+	err := uc.txManager.Transaction(ctx, func(tx *gorm.DB) error {
+		txStockRepo := uc.stockRepo.WithTx(tx)
+		txMovementRepo := uc.stockMoveRepo.WithTx(tx)
+		txLedgerRepo := uc.stockLedgerRepo.WithTx(tx)
+		txLotRepo := uc.lotRepo.WithTx(tx)
+		txItemRepo := uc.itemRepo.WithTx(tx)
+		txWarehouseRepo := uc.warehouseRepo.WithTx(tx)
+		txBinRepo := uc.binRepo.WithTx(tx)
+
+		it, err := txItemRepo.GetByID(ctx, itemID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("item not found")
+			}
+			return err
+		}
+		factor, err := uom_uc.BaseFactor(ctx, uc.unitRepo, it, unit)
+		if err != nil {
+			return err
+		}
+		quantity, lots = quantity*factor, ScaleLots(lots, factor)
+		if err := stock.ValidateLots(it.TrackingMode, quantity, lots); err != nil {
+			return err
+		}
+
+		if err := validateLocation(ctx, txWarehouseRepo, txBinRepo, fromWarehouseID, fromBinID); err != nil {
+			return fmt.Errorf("source location: %w", err)
+		}
+		if err := validateLocation(ctx, txWarehouseRepo, txBinRepo, toWarehouseID, toBinID); err != nil {
+			return fmt.Errorf("destination location: %w", err)
+		}
+
+		// 1. Lock both locations, always in the same global order.
+		source := stockLocation{WarehouseID: fromWarehouseID, BinID: fromBinID}
+		destination := stockLocation{WarehouseID: toWarehouseID, BinID: toBinID}
+		quantities := make(map[stockLocation]float64, 2)
+		for _, loc := range sortLocations(source, destination) {
+			binID := loc.BinID
+			qty, err := LockedQuantity(ctx, txStockRepo, itemID, loc.WarehouseID, &binID)
+			if err != nil {
+				return err
+			}
+			quantities[loc] = qty
+		}
+		sourceBefore = quantities[source]
+		destinationBefore = quantities[destination]
+		sourceReserved, err := uc.reservationRepo.WithTx(tx).ReservedQuantity(ctx, itemID, fromWarehouseID, &fromBinID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// 2. Post the OUT leg, then the IN leg, both tagged with the transfer ID.
+		userID, _ := domain.UserIDFromContext(ctx)
+		now := time.Now()
+		repos := PostingRepositories{Stock: txStockRepo, Movements: txMovementRepo, Ledger: txLedgerRepo, Lots: txLotRepo}
+		outMovement, _, err := PostMovement(ctx, repos, Posting{
+			ItemID:         itemID,
+			WarehouseID:    fromWarehouseID,
+			BinID:          &fromBinID,
+			Type:           stock.MovementTypeOut,
+			Quantity:       quantity,
+			QuantityBefore: sourceBefore,
+			Reserved:       sourceReserved,
+			Reason:         reason,
+			TransferID:     &transfer.ID,
+			Tracking:       it.TrackingMode,
+			Lots:           lots,
+			UnitCost:       CurrentUnitCost(it),
+			HappenedAt:     now,
+			UserID:         userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		inMovement, _, err := PostMovement(ctx, repos, Posting{
+			ItemID:         itemID,
+			WarehouseID:    toWarehouseID,
+			BinID:          &toBinID,
+			Type:           stock.MovementTypeIn,
+			Quantity:       quantity,
+			QuantityBefore: destinationBefore,
+			Reason:         reason,
+			TransferID:     &transfer.ID,
+			Tracking:       it.TrackingMode,
+			Lots:           lots,
+			UnitCost:       CurrentUnitCost(it),
+			HappenedAt:     now,
+			UserID:         userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		transfer.OutMovement = outMovement
+		transfer.InMovement = inMovement
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "stock", transfer.ID.String(), "TRANSFER",
+		map[string]interface{}{"source_quantity": sourceBefore, "destination_quantity": destinationBefore},
+		transfer,
+		corrID)
+
+	return transfer, nil
+}
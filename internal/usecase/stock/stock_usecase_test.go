@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"doligo_001/internal/domain"
 	"doligo_001/internal/domain/item"
 	"doligo_001/internal/domain/stock"
+	"doligo_001/internal/domain/uom"
 	usecase "doligo_001/internal/usecase/stock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -172,6 +174,14 @@ func (m *MockStockRepository) GetTotalQuantity(ctx context.Context, itemID uuid.
 	return args.Get(0).(float64), args.Error(1)
 }
 
+func (m *MockStockRepository) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID) ([]*stock.Stock, error) {
+	args := m.Called(ctx, warehouseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.Stock), args.Error(1)
+}
+
 func (m *MockStockRepository) UpsertStock(ctx context.Context, stock *stock.Stock) error {
 	args := m.Called(ctx, stock)
 	return args.Error(0)
@@ -199,6 +209,27 @@ func (m *MockStockMovementRepository) GetByID(ctx context.Context, id uuid.UUID)
 	return args.Get(0).(*stock.StockMovement), args.Error(1)
 }
 
+func (m *MockStockMovementRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.StockMovement, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.StockMovement), args.Error(1)
+}
+
+func (m *MockStockMovementRepository) MarkReversed(ctx context.Context, movement *stock.StockMovement) error {
+	args := m.Called(ctx, movement)
+	return args.Error(0)
+}
+
+func (m *MockStockMovementRepository) ListByProductionRecord(ctx context.Context, recordID uuid.UUID) ([]*stock.StockMovement, error) {
+	args := m.Called(ctx, recordID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.StockMovement), args.Error(1)
+}
+
 // MockStockLedgerRepository
 type MockStockLedgerRepository struct {
 	mock.Mock
@@ -212,6 +243,170 @@ func (m *MockStockLedgerRepository) Create(ctx context.Context, entry *stock.Sto
 	args := m.Called(ctx, entry)
 	return args.Error(0)
 }
+func (m *MockStockLedgerRepository) List(ctx context.Context, filter stock.LedgerFilter) ([]*stock.StockLedger, int64, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*stock.StockLedger), args.Get(1).(int64), args.Error(2)
+}
+func (m *MockStockLedgerRepository) ListBalancesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.Stock, error) {
+	args := m.Called(ctx, asOf, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.Stock), args.Error(1)
+}
+func (m *MockStockLedgerRepository) ListItemValuesAsOf(ctx context.Context, asOf time.Time, filter stock.LedgerFilter) ([]*stock.ItemValue, error) {
+	args := m.Called(ctx, asOf, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.ItemValue), args.Error(1)
+}
+
+// MockStockLotRepository
+type MockStockLotRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockLotRepository) WithTx(tx *gorm.DB) stock.StockLotRepository {
+	m.Called(tx)
+	return m
+}
+func (m *MockStockLotRepository) GetForUpdate(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, lotNumber string) (*stock.StockLot, error) {
+	args := m.Called(ctx, itemID, warehouseID, binID, lotNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.StockLot), args.Error(1)
+}
+func (m *MockStockLotRepository) GetTotalQuantity(ctx context.Context, itemID uuid.UUID, lotNumber string) (float64, error) {
+	args := m.Called(ctx, itemID, lotNumber)
+	return args.Get(0).(float64), args.Error(1)
+}
+func (m *MockStockLotRepository) Upsert(ctx context.Context, lot *stock.StockLot) error {
+	args := m.Called(ctx, lot)
+	return args.Error(0)
+}
+func (m *MockStockLotRepository) List(ctx context.Context, itemID uuid.UUID, lotNumber string) ([]*stock.StockLot, error) {
+	args := m.Called(ctx, itemID, lotNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.StockLot), args.Error(1)
+}
+
+// MockCostLayerRepository
+type MockCostLayerRepository struct {
+	mock.Mock
+}
+
+func (m *MockCostLayerRepository) WithTx(tx *gorm.DB) stock.CostLayerRepository {
+	m.Called(tx)
+	return m
+}
+func (m *MockCostLayerRepository) Create(ctx context.Context, layer *stock.CostLayer) error {
+	args := m.Called(ctx, layer)
+	return args.Error(0)
+}
+func (m *MockCostLayerRepository) ListOpen(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	args := m.Called(ctx, itemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.CostLayer), args.Error(1)
+}
+func (m *MockCostLayerRepository) ListOpenForUpdate(ctx context.Context, itemID uuid.UUID) ([]*stock.CostLayer, error) {
+	args := m.Called(ctx, itemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.CostLayer), args.Error(1)
+}
+func (m *MockCostLayerRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*stock.CostLayer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.CostLayer), args.Error(1)
+}
+func (m *MockCostLayerRepository) GetByMovementForUpdate(ctx context.Context, movementID uuid.UUID) (*stock.CostLayer, error) {
+	args := m.Called(ctx, movementID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.CostLayer), args.Error(1)
+}
+func (m *MockCostLayerRepository) UpdateRemaining(ctx context.Context, layer *stock.CostLayer) error {
+	args := m.Called(ctx, layer)
+	return args.Error(0)
+}
+func (m *MockCostLayerRepository) CreateConsumption(ctx context.Context, c *stock.CostLayerConsumption) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+func (m *MockCostLayerRepository) ListConsumptions(ctx context.Context, movementID uuid.UUID) ([]*stock.CostLayerConsumption, error) {
+	args := m.Called(ctx, movementID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.CostLayerConsumption), args.Error(1)
+}
+
+// MockReservationRepository
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepository) WithTx(tx *gorm.DB) stock.ReservationRepository {
+	m.Called(tx)
+	return m
+}
+func (m *MockReservationRepository) Create(ctx context.Context, r *stock.Reservation) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+func (m *MockReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.Reservation), args.Error(1)
+}
+func (m *MockReservationRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*stock.Reservation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.Reservation), args.Error(1)
+}
+func (m *MockReservationRepository) FindActive(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, sourceType, sourceID string) (*stock.Reservation, error) {
+	args := m.Called(ctx, itemID, warehouseID, binID, sourceType, sourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stock.Reservation), args.Error(1)
+}
+func (m *MockReservationRepository) List(ctx context.Context, filter stock.ReservationFilter) ([]*stock.Reservation, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stock.Reservation), args.Error(1)
+}
+func (m *MockReservationRepository) Update(ctx context.Context, r *stock.Reservation) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+func (m *MockReservationRepository) ReservedQuantity(ctx context.Context, itemID, warehouseID uuid.UUID, binID *uuid.UUID, asOf time.Time) (float64, error) {
+	args := m.Called(ctx, itemID, warehouseID, binID, asOf)
+	return args.Get(0).(float64), args.Error(1)
+}
+func (m *MockReservationRepository) ExpireDue(ctx context.Context, asOf time.Time) (int64, error) {
+	args := m.Called(ctx, asOf)
+	return args.Get(0).(int64), args.Error(1)
+}
 
 // MockAuditService
 type MockAuditService struct {
@@ -222,6 +417,52 @@ func (m *MockAuditService) Log(ctx context.Context, userID uuid.UUID, resourceNa
 	m.Called(ctx, userID, resourceName, resourceID, action, oldValues, newValues, correlationID)
 }
 
+// MockUnitRepository
+type MockUnitRepository struct {
+	mock.Mock
+}
+
+func (m *MockUnitRepository) WithTx(tx *gorm.DB) uom.Repository {
+	m.Called(tx)
+	return m
+}
+
+func (m *MockUnitRepository) CreateCategory(ctx context.Context, category *uom.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockUnitRepository) GetCategory(ctx context.Context, id uuid.UUID) (*uom.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uom.Category), args.Error(1)
+}
+
+func (m *MockUnitRepository) ListCategories(ctx context.Context) ([]*uom.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*uom.Category), args.Error(1)
+}
+
+func (m *MockUnitRepository) CreateUnit(ctx context.Context, unit *uom.Unit) error {
+	args := m.Called(ctx, unit)
+	return args.Error(0)
+}
+
+func (m *MockUnitRepository) GetUnitByCode(ctx context.Context, code string) (*uom.Unit, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*uom.Unit), args.Error(1)
+}
+
+func (m *MockUnitRepository) ListUnits(ctx context.Context, categoryID *uuid.UUID) ([]*uom.Unit, error) {
+	args := m.Called(ctx, categoryID)
+	return args.Get(0).([]*uom.Unit), args.Error(1)
+}
+
 // --- Test Suite Setup ---
 
 type stockUseCaseTestSuite struct {
@@ -232,6 +473,10 @@ type stockUseCaseTestSuite struct {
 	stockRepo       *MockStockRepository
 	stockMoveRepo   *MockStockMovementRepository
 	stockLedgerRepo *MockStockLedgerRepository
+	lotRepo         *MockStockLotRepository
+	costLayerRepo   *MockCostLayerRepository
+	reservationRepo *MockReservationRepository
+	unitRepo        *MockUnitRepository
 	auditService    *MockAuditService
 	useCase         usecase.UseCase
 	ctx             context.Context
@@ -250,6 +495,10 @@ func setupTestSuite() *stockUseCaseTestSuite {
 		stockRepo:       new(MockStockRepository),
 		stockMoveRepo:   new(MockStockMovementRepository),
 		stockLedgerRepo: new(MockStockLedgerRepository),
+		lotRepo:         new(MockStockLotRepository),
+		costLayerRepo:   new(MockCostLayerRepository),
+		reservationRepo: new(MockReservationRepository),
+		unitRepo:        new(MockUnitRepository),
 		auditService:    new(MockAuditService),
 		userID:          uuid.New(),
 		itemID:          uuid.New(),
@@ -262,9 +511,13 @@ func setupTestSuite() *stockUseCaseTestSuite {
 		s.stockRepo,
 		s.stockMoveRepo,
 		s.stockLedgerRepo,
+		s.lotRepo,
+		s.costLayerRepo,
+		s.reservationRepo,
 		s.warehouseRepo,
 		s.binRepo,
 		s.itemRepo,
+		s.unitRepo,
 		s.auditService,
 	)
 
@@ -277,6 +530,9 @@ func setupTestSuite() *stockUseCaseTestSuite {
 	s.stockRepo.On("WithTx", mock.Anything).Return(s.stockRepo).Maybe()
 	s.stockMoveRepo.On("WithTx", mock.Anything).Return(s.stockMoveRepo).Maybe()
 	s.stockLedgerRepo.On("WithTx", mock.Anything).Return(s.stockLedgerRepo).Maybe()
+	s.lotRepo.On("WithTx", mock.Anything).Return(s.lotRepo).Maybe()
+	s.costLayerRepo.On("WithTx", mock.Anything).Return(s.costLayerRepo).Maybe()
+	s.reservationRepo.On("WithTx", mock.Anything).Return(s.reservationRepo).Maybe()
 
 	// Default mock for Bin validation
 	mockBin := &stock.Bin{ID: s.binID, WarehouseID: s.warehouseID, IsActive: true}
@@ -303,7 +559,7 @@ func TestCreateStockMovement_In_HappyPath_NoExistingStock(t *testing.T) {
 	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Once()
 	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
 
-	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 10.0, 120.0, "Initial Stock")
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 10.0, "", 120.0, "Initial Stock", nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, movement)
@@ -311,6 +567,53 @@ func TestCreateStockMovement_In_HappyPath_NoExistingStock(t *testing.T) {
 	assert.Equal(t, s.binID, *movement.BinID)
 }
 
+func TestCreateStockMovement_In_ConvertsToBaseUnit(t *testing.T) {
+	s := setupTestSuite()
+	mass := uuid.New()
+	mockItem := &item.Item{ID: s.itemID, Name: "Flour", Type: item.Storable, BaseUnit: "kg"}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.unitRepo.On("GetUnitByCode", mock.Anything, "g").Return(&uom.Unit{CategoryID: mass, Code: "g", Factor: 0.001}, nil).Once()
+	s.unitRepo.On("GetUnitByCode", mock.Anything, "kg").Return(&uom.Unit{CategoryID: mass, Code: "kg", Factor: 1}, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(0.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.MatchedBy(func(i *item.Item) bool {
+		return i.AverageCost == 5.0
+	})).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool {
+		return st.Quantity == 2.5
+	})).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
+
+	// 2500 g at 0.005 per gram are 2.5 kg at 5 per kilogram
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 2500.0, "g", 0.005, "Receipt", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, movement.Quantity)
+	assert.Equal(t, 5.0, movement.UnitCost)
+	s.stockRepo.AssertExpectations(t)
+}
+
+func TestCreateStockMovement_IncompatibleUnit(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Flour", Type: item.Storable, BaseUnit: "kg"}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.unitRepo.On("GetUnitByCode", mock.Anything, "l").Return(&uom.Unit{CategoryID: uuid.New(), Code: "l", Factor: 1}, nil).Once()
+	s.unitRepo.On("GetUnitByCode", mock.Anything, "kg").Return(&uom.Unit{CategoryID: uuid.New(), Code: "kg", Factor: 1}, nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 2.0, "l", 1.0, "Receipt", nil)
+
+	assert.ErrorIs(t, err, uom.ErrIncompatibleUnits)
+	assert.Nil(t, movement)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestCreateStockMovement_Out_InsufficientStock(t *testing.T) {
 	s := setupTestSuite()
 	mockItem := &item.Item{ID: s.itemID, Name: "Test Item"}
@@ -322,12 +625,128 @@ func TestCreateStockMovement_Out_InsufficientStock(t *testing.T) {
 	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
 	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
 
-	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeOut, 10.0, 0.0, "Selling Item")
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeOut, 10.0, "", 0.0, "Selling Item", nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, movement)
 }
 
+func TestCreateStockMovement_Out_ReservedStockIsNotAvailable(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item"}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(8.0, nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeOut, 5.0, "", 0.0, "Selling Item", nil)
+
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	assert.Nil(t, movement)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateStockMovement_LotTrackedItemRequiresLots(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Tracked Item", Type: item.Storable, TrackingMode: item.TrackingLot}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 10.0, "", 5.0, "Receipt", nil)
+
+	assert.ErrorIs(t, err, stock.ErrLotRequired)
+	assert.Nil(t, movement)
+	s.itemRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateStockMovement_SerialReceipt_FillsLotBuckets(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Serialized Item", Type: item.Storable, TrackingMode: item.TrackingSerial}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	lots := []stock.LotQuantity{{LotNumber: "SN-002", Quantity: 1}, {LotNumber: "SN-001", Quantity: 1}}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(0.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.AnythingOfType("*item.Item")).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.lotRepo.On("GetForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(nil, gorm.ErrRecordNotFound).Twice()
+	s.lotRepo.On("GetTotalQuantity", mock.Anything, s.itemID, mock.Anything).Return(0.0, nil).Twice()
+	s.lotRepo.On("Upsert", mock.Anything, mock.MatchedBy(func(l *stock.StockLot) bool {
+		return l.Quantity == 1.0
+	})).Return(nil).Twice()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *stock.StockMovement) bool {
+		return len(m.Lots) == 2
+	})).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 2.0, "", 50.0, "Receipt", lots)
+
+	assert.NoError(t, err)
+	assert.Len(t, movement.Lots, 2)
+	// Buckets are locked in lot number order.
+	lockCalls := []string{}
+	for _, call := range s.lotRepo.Calls {
+		if call.Method == "GetForUpdate" {
+			lockCalls = append(lockCalls, call.Arguments.String(4))
+		}
+	}
+	assert.Equal(t, []string{"SN-001", "SN-002"}, lockCalls)
+	s.lotRepo.AssertExpectations(t)
+}
+
+func TestCreateStockMovement_SerialAlreadyInStock(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Serialized Item", Type: item.Storable, TrackingMode: item.TrackingSerial}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(1.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.AnythingOfType("*item.Item")).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.lotRepo.On("GetForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID, "SN-001").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.lotRepo.On("GetTotalQuantity", mock.Anything, s.itemID, "SN-001").Return(1.0, nil).Once()
+
+	lots := []stock.LotQuantity{{LotNumber: "SN-001", Quantity: 1}}
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 1.0, "", 50.0, "Receipt", lots)
+
+	assert.ErrorIs(t, err, stock.ErrSerialInStock)
+	assert.Nil(t, movement)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateStockMovement_LotOut_InsufficientLotStock(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Tracked Item", Type: item.Storable, TrackingMode: item.TrackingLot}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+	lotBucket := &stock.StockLot{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, LotNumber: "LOT-A", Quantity: 3.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(0.0, nil).Once()
+	s.lotRepo.On("GetForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID, "LOT-A").Return(lotBucket, nil).Once()
+
+	lots := []stock.LotQuantity{{LotNumber: "LOT-A", Quantity: 5}}
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeOut, 5.0, "", 0.0, "Picking", lots)
+
+	assert.ErrorIs(t, err, stock.ErrInsufficientLotStock)
+	assert.Nil(t, movement)
+	s.lotRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
 func TestCreateWarehouse_HappyPath(t *testing.T) {
 	s := setupTestSuite()
 	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
@@ -350,6 +769,72 @@ func TestCreateBin_HappyPath(t *testing.T) {
 	assert.NotNil(t, bin)
 }
 
+func TestUpdateBin_DeactivateWithStock(t *testing.T) {
+	s := setupTestSuite()
+	otherBinID := uuid.New()
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockRepo.On("ListByWarehouse", mock.Anything, s.warehouseID).Return([]*stock.Stock{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &otherBinID, Quantity: 8.0},
+		{ItemID: uuid.New(), WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 2.0},
+	}, nil).Once()
+
+	bin, err := s.useCase.UpdateBin(s.ctx, s.binID, "Renamed", false)
+
+	assert.ErrorIs(t, err, usecase.ErrLocationHasStock)
+	assert.Nil(t, bin)
+	s.binRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateBin_DeactivateEmptyBin(t *testing.T) {
+	s := setupTestSuite()
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockRepo.On("ListByWarehouse", mock.Anything, s.warehouseID).Return([]*stock.Stock{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 0},
+	}, nil).Once()
+	s.binRepo.On("Update", mock.Anything, mock.MatchedBy(func(b *stock.Bin) bool {
+		return b.Name == "Renamed" && !b.IsActive && b.UpdatedBy == s.userID
+	})).Return(nil).Once()
+
+	bin, err := s.useCase.UpdateBin(s.ctx, s.binID, "Renamed", false)
+
+	assert.NoError(t, err)
+	assert.False(t, bin.IsActive)
+	s.binRepo.AssertExpectations(t)
+}
+
+func TestDeleteWarehouse_DeletesBinsWhenEmpty(t *testing.T) {
+	s := setupTestSuite()
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("ListByWarehouse", mock.Anything, s.warehouseID).Return([]*stock.Stock{}, nil).Once()
+	s.binRepo.On("ListByWarehouse", mock.Anything, s.warehouseID).Return([]*stock.Bin{{ID: s.binID, WarehouseID: s.warehouseID}}, nil).Once()
+	s.binRepo.On("Delete", mock.Anything, s.binID).Return(nil).Once()
+	s.warehouseRepo.On("Delete", mock.Anything, s.warehouseID).Return(nil).Once()
+
+	err := s.useCase.DeleteWarehouse(s.ctx, s.warehouseID)
+
+	assert.NoError(t, err)
+	s.binRepo.AssertExpectations(t)
+	s.warehouseRepo.AssertExpectations(t)
+}
+
+func TestListBinStock_OnlyNonZeroStockOfTheBin(t *testing.T) {
+	s := setupTestSuite()
+	otherBinID := uuid.New()
+	onHand := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 4.0}
+	s.stockRepo.On("ListByWarehouse", mock.Anything, s.warehouseID).Return([]*stock.Stock{
+		onHand,
+		{ItemID: uuid.New(), WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 0},
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &otherBinID, Quantity: 6.0},
+	}, nil).Once()
+
+	stocks, err := s.useCase.ListBinStock(s.ctx, s.binID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*stock.Stock{onHand}, stocks)
+}
+
 func TestReverseStockMovement_ReversingOut_UpdatesCMP(t *testing.T) {
 	s := setupTestSuite()
 	origMoveID := uuid.New()
@@ -362,6 +847,7 @@ func TestReverseStockMovement_ReversingOut_UpdatesCMP(t *testing.T) {
 		BinID:       &s.binID,
 		Type:        stock.MovementTypeOut,
 		Quantity:    5.0,
+		UnitCost:    15.0,
 	}
 
 	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", AverageCost: 15.0}
@@ -370,7 +856,7 @@ func TestReverseStockMovement_ReversingOut_UpdatesCMP(t *testing.T) {
 	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
 	
 	// 1. Get Original Movement
-	s.stockMoveRepo.On("GetByID", mock.Anything, origMoveID).Return(origMove, nil).Once()
+	s.stockMoveRepo.On("GetByIDForUpdate", mock.Anything, origMoveID).Return(origMove, nil).Once()
 	
 	// 2. CMP Logic (Reversing OUT -> IN)
 	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
@@ -393,10 +879,404 @@ func TestReverseStockMovement_ReversingOut_UpdatesCMP(t *testing.T) {
 	// 6. Ledger
 	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
 
+	// 7. Mark Original Movement Reversed
+	s.stockMoveRepo.On("MarkReversed", mock.Anything, origMove).Return(nil).Once()
+
 	reversedMovement, err := s.useCase.ReverseStockMovement(s.ctx, origMoveID, "Customer Return")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, reversedMovement)
 	assert.Equal(t, stock.MovementTypeIn, reversedMovement.Type)
 	assert.Equal(t, 5.0, reversedMovement.Quantity)
+	assert.NotNil(t, origMove.ReversedAt)
+	s.stockMoveRepo.AssertExpectations(t)
+}
+
+func TestReverseStockMovement_ReversingIn_LeavesReservedStock(t *testing.T) {
+	s := setupTestSuite()
+	origMoveID := uuid.New()
+	origMove := &stock.StockMovement{
+		ID:          origMoveID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		Type:        stock.MovementTypeIn,
+		Quantity:    5.0,
+		UnitCost:    10.0,
+	}
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", AverageCost: 10.0}
+	currentStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockMoveRepo.On("GetByIDForUpdate", mock.Anything, origMoveID).Return(origMove, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(currentStock, nil).Once()
+	// 8 of the 10 units on hand are reserved, so only 2 can be taken back out
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(8.0, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(10.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.AnythingOfType("*item.Item")).Return(nil).Maybe()
+
+	reversed, err := s.useCase.ReverseStockMovement(s.ctx, origMoveID, "Wrong receipt")
+
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	assert.Nil(t, reversed)
+	s.reservationRepo.AssertExpectations(t)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.stockMoveRepo.AssertNotCalled(t, "MarkReversed", mock.Anything, mock.Anything)
+}
+
+func TestReverseStockMovement_AlreadyReversed(t *testing.T) {
+	s := setupTestSuite()
+	origMoveID := uuid.New()
+	reversedAt := time.Now()
+	origMove := &stock.StockMovement{
+		ID:          origMoveID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		Type:        stock.MovementTypeOut,
+		Quantity:    5.0,
+		UnitCost:    15.0,
+		ReversedAt:  &reversedAt,
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockMoveRepo.On("GetByIDForUpdate", mock.Anything, origMoveID).Return(origMove, nil).Once()
+
+	reversed, err := s.useCase.ReverseStockMovement(s.ctx, origMoveID, "Customer Return")
+
+	assert.ErrorIs(t, err, stock.ErrMovementReversed)
+	assert.Nil(t, reversed)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.stockRepo.AssertNotCalled(t, "GetStockForUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReverseStockMovement_TransferLegRejected(t *testing.T) {
+	s := setupTestSuite()
+	origMoveID := uuid.New()
+	transferID := uuid.New()
+	origMove := &stock.StockMovement{
+		ID:          origMoveID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		Type:        stock.MovementTypeOut,
+		Quantity:    5.0,
+		UnitCost:    15.0,
+		TransferID:  &transferID,
+	}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockMoveRepo.On("GetByIDForUpdate", mock.Anything, origMoveID).Return(origMove, nil).Once()
+
+	reversed, err := s.useCase.ReverseStockMovement(s.ctx, origMoveID, "Wrong transfer")
+
+	assert.ErrorIs(t, err, stock.ErrMovementNotReversible)
+	assert.Nil(t, reversed)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.stockMoveRepo.AssertNotCalled(t, "MarkReversed", mock.Anything, mock.Anything)
+}
+
+func TestCreateStockMovement_In_StandardCostRecordsVariance(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "Standard Item", Type: item.Storable, CostingMethod: item.CostingStandard, StandardCost: 10.0, AverageCost: 10.0}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(6.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.MatchedBy(func(i *item.Item) bool {
+		return i.AverageCost == (6*10+4*12)/10.0
+	})).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.MatchedBy(func(l *stock.StockLedger) bool {
+		return l.UnitCost == 10.0 && l.CostVariance == 8.0
+	})).Return(nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 4.0, "", 12.0, "Receipt", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, movement.UnitCost)
+	assert.Equal(t, 8.0, movement.CostVariance)
+	s.costLayerRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.stockLedgerRepo.AssertExpectations(t)
+}
+
+func TestCreateStockMovement_In_FIFOOpensLayer(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "FIFO Item", Type: item.Storable, CostingMethod: item.CostingFIFO}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(0.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.AnythingOfType("*item.Item")).Return(nil).Once()
+	s.costLayerRepo.On("Create", mock.Anything, mock.MatchedBy(func(l *stock.CostLayer) bool {
+		return l.UnitCost == 7.5 && l.OriginalQuantity == 10.0 && l.RemainingQuantity == 10.0
+	})).Return(nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeIn, 10.0, "", 7.5, "Receipt", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7.5, movement.UnitCost)
+	// The layer belongs to the movement that was posted.
+	layer := s.costLayerRepo.Calls[len(s.costLayerRepo.Calls)-1].Arguments.Get(1).(*stock.CostLayer)
+	assert.Equal(t, movement.ID, layer.MovementID)
+	s.costLayerRepo.AssertExpectations(t)
+}
+
+func TestCreateStockMovement_Out_FIFOConsumesOldestLayers(t *testing.T) {
+	s := setupTestSuite()
+	mockItem := &item.Item{ID: s.itemID, Name: "FIFO Item", Type: item.Storable, CostingMethod: item.CostingFIFO, AverageCost: 11.0}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	existingStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 15.0}
+	oldest := &stock.CostLayer{ID: uuid.New(), ItemID: s.itemID, UnitCost: 10.0, OriginalQuantity: 5, RemainingQuantity: 5}
+	newest := &stock.CostLayer{ID: uuid.New(), ItemID: s.itemID, UnitCost: 12.0, OriginalQuantity: 10, RemainingQuantity: 10}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(existingStock, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(0.0, nil).Once()
+	s.costLayerRepo.On("ListOpenForUpdate", mock.Anything, s.itemID).Return([]*stock.CostLayer{oldest, newest}, nil).Once()
+	s.costLayerRepo.On("UpdateRemaining", mock.Anything, mock.AnythingOfType("*stock.CostLayer")).Return(nil).Twice()
+	s.costLayerRepo.On("CreateConsumption", mock.Anything, mock.AnythingOfType("*stock.CostLayerConsumption")).Return(nil).Twice()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
+
+	movement, err := s.useCase.CreateStockMovement(s.ctx, s.itemID, s.warehouseID, s.binID, stock.MovementTypeOut, 8.0, "", 0.0, "Picking", nil)
+
+	assert.NoError(t, err)
+	assert.InDelta(t, 10.75, movement.UnitCost, 1e-9) // (5*10 + 3*12)/8
+	assert.Equal(t, 0.0, oldest.RemainingQuantity)
+	assert.Equal(t, 7.0, newest.RemainingQuantity)
+	for _, call := range s.costLayerRepo.Calls {
+		if call.Method == "CreateConsumption" {
+			assert.Equal(t, movement.ID, call.Arguments.Get(1).(*stock.CostLayerConsumption).MovementID)
+		}
+	}
+	s.itemRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	s.costLayerRepo.AssertExpectations(t)
+}
+
+func TestReverseStockMovement_FIFOIssueRestoresLayers(t *testing.T) {
+	s := setupTestSuite()
+	origMoveID := uuid.New()
+	origMove := &stock.StockMovement{
+		ID:          origMoveID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		Type:        stock.MovementTypeOut,
+		Quantity:    8.0,
+		UnitCost:    10.75,
+	}
+	mockItem := &item.Item{ID: s.itemID, Name: "FIFO Item", Type: item.Storable, CostingMethod: item.CostingFIFO, AverageCost: 12.0}
+	oldest := &stock.CostLayer{ID: uuid.New(), ItemID: s.itemID, UnitCost: 10.0, OriginalQuantity: 5, RemainingQuantity: 0}
+	newest := &stock.CostLayer{ID: uuid.New(), ItemID: s.itemID, UnitCost: 12.0, OriginalQuantity: 10, RemainingQuantity: 7}
+	consumptions := []*stock.CostLayerConsumption{
+		{ID: uuid.New(), LayerID: oldest.ID, MovementID: origMoveID, Quantity: 5, UnitCost: 10.0},
+		{ID: uuid.New(), LayerID: newest.ID, MovementID: origMoveID, Quantity: 3, UnitCost: 12.0},
+	}
+	currentStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 7.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockMoveRepo.On("GetByIDForUpdate", mock.Anything, origMoveID).Return(origMove, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(currentStock, nil).Once()
+	s.costLayerRepo.On("ListOpenForUpdate", mock.Anything, s.itemID).Return([]*stock.CostLayer{newest}, nil).Once()
+	s.costLayerRepo.On("ListConsumptions", mock.Anything, origMoveID).Return(consumptions, nil).Once()
+	s.costLayerRepo.On("GetForUpdate", mock.Anything, oldest.ID).Return(oldest, nil).Once()
+	s.costLayerRepo.On("GetForUpdate", mock.Anything, newest.ID).Return(newest, nil).Once()
+	s.costLayerRepo.On("UpdateRemaining", mock.Anything, mock.AnythingOfType("*stock.CostLayer")).Return(nil).Twice()
+	s.stockRepo.On("GetTotalQuantity", mock.Anything, s.itemID).Return(7.0, nil).Once()
+	s.itemRepo.On("Update", mock.Anything, mock.MatchedBy(func(i *item.Item) bool {
+		return i.AverageCost == (7*12+8*10.75)/15
+	})).Return(nil).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.AnythingOfType("*stock.Stock")).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Once()
+	s.stockMoveRepo.On("MarkReversed", mock.Anything, origMove).Return(nil).Once()
+
+	reversed, err := s.useCase.ReverseStockMovement(s.ctx, origMoveID, "Return")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.75, reversed.UnitCost)
+	assert.Equal(t, 5.0, oldest.RemainingQuantity)
+	assert.Equal(t, 10.0, newest.RemainingQuantity)
+	s.costLayerRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.itemRepo.AssertExpectations(t)
+}
+
+func TestReverseStockMovement_FIFOReceiptAlreadyConsumed(t *testing.T) {
+	s := setupTestSuite()
+	origMoveID := uuid.New()
+	origMove := &stock.StockMovement{
+		ID:          origMoveID,
+		ItemID:      s.itemID,
+		WarehouseID: s.warehouseID,
+		BinID:       &s.binID,
+		Type:        stock.MovementTypeIn,
+		Quantity:    10.0,
+		UnitCost:    7.5,
+	}
+	mockItem := &item.Item{ID: s.itemID, Name: "FIFO Item", Type: item.Storable, CostingMethod: item.CostingFIFO}
+	layer := &stock.CostLayer{ID: uuid.New(), ItemID: s.itemID, MovementID: origMoveID, UnitCost: 7.5, OriginalQuantity: 10, RemainingQuantity: 4}
+	currentStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.stockMoveRepo.On("GetByIDForUpdate", mock.Anything, origMoveID).Return(origMove, nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(currentStock, nil).Once()
+	s.costLayerRepo.On("ListOpenForUpdate", mock.Anything, s.itemID).Return([]*stock.CostLayer{layer}, nil).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(0.0, nil).Once()
+	s.costLayerRepo.On("GetByMovementForUpdate", mock.Anything, origMoveID).Return(layer, nil).Once()
+
+	reversed, err := s.useCase.ReverseStockMovement(s.ctx, origMoveID, "Wrong receipt")
+
+	assert.ErrorIs(t, err, stock.ErrCostLayerConsumed)
+	assert.Nil(t, reversed)
+	s.costLayerRepo.AssertNotCalled(t, "UpdateRemaining", mock.Anything, mock.Anything)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	s.stockMoveRepo.AssertNotCalled(t, "MarkReversed", mock.Anything, mock.Anything)
+}
+
+func TestTransferStock_HappyPath(t *testing.T) {
+	s := setupTestSuite()
+	destWarehouseID := uuid.New()
+	destBinID := uuid.New()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item", AverageCost: 15.0}
+	sourceWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	destWarehouse := &stock.Warehouse{ID: destWarehouseID, Name: "Secondary Warehouse", IsActive: true}
+	destBin := &stock.Bin{ID: destBinID, WarehouseID: destWarehouseID, IsActive: true}
+	sourceStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 10.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(sourceWarehouse, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, destWarehouseID).Return(destWarehouse, nil).Once()
+	s.binRepo.On("GetByID", mock.Anything, destBinID).Return(destBin, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(sourceStock, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, destWarehouseID, &destBinID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(0.0, nil).Once()
+	s.stockMoveRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockMovement")).Return(nil).Twice()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool {
+		return st.WarehouseID == s.warehouseID && st.Quantity == 6.0
+	})).Return(nil).Once()
+	s.stockRepo.On("UpsertStock", mock.Anything, mock.MatchedBy(func(st *stock.Stock) bool {
+		return st.WarehouseID == destWarehouseID && st.Quantity == 4.0
+	})).Return(nil).Once()
+	s.stockLedgerRepo.On("Create", mock.Anything, mock.AnythingOfType("*stock.StockLedger")).Return(nil).Twice()
+
+	transfer, err := s.useCase.TransferStock(s.ctx, s.itemID, s.warehouseID, s.binID, destWarehouseID, destBinID, 4.0, "", "Rebalance", nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, transfer)
+	assert.Equal(t, stock.MovementTypeOut, transfer.OutMovement.Type)
+	assert.Equal(t, stock.MovementTypeIn, transfer.InMovement.Type)
+	assert.Equal(t, transfer.ID, *transfer.OutMovement.TransferID)
+	assert.Equal(t, transfer.ID, *transfer.InMovement.TransferID)
+	// A transfer never touches the item's average cost.
+	s.itemRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	s.stockRepo.AssertExpectations(t)
+}
+
+func TestTransferStock_InsufficientStock(t *testing.T) {
+	s := setupTestSuite()
+	destBinID := uuid.New()
+	mockItem := &item.Item{ID: s.itemID, Name: "Test Item"}
+	mockWarehouse := &stock.Warehouse{ID: s.warehouseID, Name: "Main Warehouse", IsActive: true}
+	destBin := &stock.Bin{ID: destBinID, WarehouseID: s.warehouseID, IsActive: true}
+	sourceStock := &stock.Stock{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 2.0}
+
+	s.txManager.On("Transaction", mock.Anything, mock.Anything).Return(nil).Once()
+	s.itemRepo.On("GetByID", mock.Anything, s.itemID).Return(mockItem, nil).Once()
+	s.warehouseRepo.On("GetByID", mock.Anything, s.warehouseID).Return(mockWarehouse, nil).Twice()
+	s.binRepo.On("GetByID", mock.Anything, destBinID).Return(destBin, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &s.binID).Return(sourceStock, nil).Once()
+	s.stockRepo.On("GetStockForUpdate", mock.Anything, s.itemID, s.warehouseID, &destBinID).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.reservationRepo.On("ReservedQuantity", mock.Anything, s.itemID, s.warehouseID, &s.binID, mock.Anything).Return(0.0, nil).Once()
+
+	transfer, err := s.useCase.TransferStock(s.ctx, s.itemID, s.warehouseID, s.binID, s.warehouseID, destBinID, 5.0, "", "Rebalance", nil)
+
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	assert.Nil(t, transfer)
+	s.stockMoveRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestTransferStock_SameLocation(t *testing.T) {
+	s := setupTestSuite()
+
+	transfer, err := s.useCase.TransferStock(s.ctx, s.itemID, s.warehouseID, s.binID, s.warehouseID, s.binID, 1.0, "", "No-op", nil)
+
+	assert.ErrorIs(t, err, usecase.ErrSameLocation)
+	assert.Nil(t, transfer)
+}
+
+func TestListLedgerEntries_AppliesDefaultPageSize(t *testing.T) {
+	s := setupTestSuite()
+	entries := []*stock.StockLedger{
+		{ID: uuid.New(), ItemID: s.itemID, QuantityBefore: 0, QuantityAfter: 10},
+		{ID: uuid.New(), ItemID: s.itemID, QuantityBefore: 10, QuantityAfter: 7},
+	}
+
+	s.stockLedgerRepo.On("List", mock.Anything, mock.MatchedBy(func(f stock.LedgerFilter) bool {
+		return f.Limit == usecase.DefaultLedgerPageSize && *f.ItemID == s.itemID
+	})).Return(entries, int64(2), nil).Once()
+
+	result, total, err := s.useCase.ListLedgerEntries(s.ctx, stock.LedgerFilter{ItemID: &s.itemID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, result, 2)
+	s.stockLedgerRepo.AssertExpectations(t)
+}
+
+func TestListLedgerEntries_CapsPageSize(t *testing.T) {
+	s := setupTestSuite()
+
+	s.stockLedgerRepo.On("List", mock.Anything, mock.MatchedBy(func(f stock.LedgerFilter) bool {
+		return f.Limit == usecase.MaxLedgerPageSize
+	})).Return([]*stock.StockLedger{}, int64(0), nil).Once()
+
+	_, _, err := s.useCase.ListLedgerEntries(s.ctx, stock.LedgerFilter{Limit: 10000})
+
+	assert.NoError(t, err)
+	s.stockLedgerRepo.AssertExpectations(t)
+}
+
+func TestListLedgerEntries_InvalidDateRange(t *testing.T) {
+	s := setupTestSuite()
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	_, _, err := s.useCase.ListLedgerEntries(s.ctx, stock.LedgerFilter{From: &from, To: &to})
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidDateRange)
+	s.stockLedgerRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestGetStockAsOf(t *testing.T) {
+	s := setupTestSuite()
+	asOf := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+	balances := []*stock.Stock{
+		{ItemID: s.itemID, WarehouseID: s.warehouseID, BinID: &s.binID, Quantity: 42},
+	}
+
+	s.stockLedgerRepo.On("ListBalancesAsOf", mock.Anything, asOf, stock.LedgerFilter{WarehouseID: &s.warehouseID}).Return(balances, nil).Once()
+
+	result, err := s.useCase.GetStockAsOf(s.ctx, asOf, stock.LedgerFilter{WarehouseID: &s.warehouseID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, balances, result)
+	s.stockLedgerRepo.AssertExpectations(t)
 }
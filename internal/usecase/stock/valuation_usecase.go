@@ -0,0 +1,204 @@
+package stock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidValuationGroup is returned when a valuation is grouped by an unknown dimension.
+var ErrInvalidValuationGroup = errors.New("group_by must only contain warehouse, bin and item_type")
+
+// ValuationUseCase defines the interface for inventory valuation reports.
+type ValuationUseCase interface {
+	GetInventoryValuation(ctx context.Context, query stock.ValuationQuery) (*stock.ValuationReport, error)
+}
+
+// valuationUseCase implements the ValuationUseCase interface.
+type valuationUseCase struct {
+	stockLedgerRepo stock.StockLedgerRepository
+	warehouseRepo   stock.WarehouseRepository
+	binRepo         stock.BinRepository
+	itemRepo        item.Repository
+}
+
+// NewValuationUseCase creates a new valuationUseCase.
+func NewValuationUseCase(
+	stockLedgerRepo stock.StockLedgerRepository,
+	warehouseRepo stock.WarehouseRepository,
+	binRepo stock.BinRepository,
+	itemRepo item.Repository,
+) ValuationUseCase {
+	return &valuationUseCase{
+		stockLedgerRepo: stockLedgerRepo,
+		warehouseRepo:   warehouseRepo,
+		binRepo:         binRepo,
+		itemRepo:        itemRepo,
+	}
+}
+
+// GetInventoryValuation values the quantity of every location at query.AsOf, or now.
+// Quantities and costs are both rebuilt from the stock ledger, so a past date gives the
+// same figures as a report run at that moment: the valuation cost of an item is the value
+// of its ledger entries up to that date divided by its quantity, which is the weighted
+// average cost for AVERAGE items, the cost of the open layers for FIFO items and the
+// standard cost for STANDARD items. Items without a positive ledger quantity fall back
+// to their current unit cost.
+func (uc *valuationUseCase) GetInventoryValuation(ctx context.Context, query stock.ValuationQuery) (*stock.ValuationReport, error) {
+	for _, group := range query.GroupBy {
+		if group != stock.GroupByWarehouse && group != stock.GroupByBin && group != stock.GroupByItemType {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidValuationGroup, group)
+		}
+	}
+	asOf := time.Now()
+	if query.AsOf != nil {
+		asOf = *query.AsOf
+	}
+
+	balances, err := uc.stockLedgerRepo.ListBalancesAsOf(ctx, asOf, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+	itemValues, err := uc.stockLedgerRepo.ListItemValuesAsOf(ctx, asOf, stock.LedgerFilter{ItemID: query.Filter.ItemID})
+	if err != nil {
+		return nil, err
+	}
+	valueByItem := make(map[uuid.UUID]*stock.ItemValue, len(itemValues))
+	for _, v := range itemValues {
+		valueByItem[v.ItemID] = v
+	}
+
+	items, err := uc.itemRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	itemByID := make(map[uuid.UUID]*item.Item, len(items))
+	for _, it := range items {
+		itemByID[it.ID] = it
+	}
+	warehouses, err := uc.warehouseRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	warehouseNames := make(map[uuid.UUID]string, len(warehouses))
+	for _, w := range warehouses {
+		warehouseNames[w.ID] = w.Name
+	}
+	binNames := make(map[uuid.UUID]string)
+
+	report := &stock.ValuationReport{AsOf: asOf, GroupBy: query.GroupBy, Lines: []*stock.ValuationLine{}, Totals: []*stock.ValuationTotal{}}
+	for _, b := range balances {
+		if math.Abs(b.Quantity) < quantityEpsilon {
+			continue
+		}
+		it, ok := itemByID[b.ItemID]
+		if !ok {
+			return nil, fmt.Errorf("item %s of the stock ledger not found", b.ItemID)
+		}
+		if query.ItemType != "" && it.Type != query.ItemType {
+			continue
+		}
+
+		unitCost := CurrentUnitCost(it)
+		if v, ok := valueByItem[it.ID]; ok && v.Quantity > quantityEpsilon {
+			unitCost = v.Value / v.Quantity
+		}
+		line := &stock.ValuationLine{
+			ItemID:        it.ID,
+			ItemName:      it.Name,
+			ItemType:      it.Type,
+			WarehouseID:   b.WarehouseID,
+			WarehouseName: warehouseNames[b.WarehouseID],
+			BinID:         b.BinID,
+			Quantity:      b.Quantity,
+			UnitCost:      unitCost,
+			Value:         b.Quantity * unitCost,
+		}
+		if b.BinID != nil {
+			name, ok := binNames[*b.BinID]
+			if !ok {
+				bin, err := uc.binRepo.GetByID(ctx, *b.BinID)
+				if err != nil {
+					return nil, err
+				}
+				name = bin.Name
+				binNames[*b.BinID] = name
+			}
+			line.BinName = name
+		}
+		report.Lines = append(report.Lines, line)
+		report.TotalValue += line.Value
+	}
+
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		a, b := report.Lines[i], report.Lines[j]
+		if a.WarehouseName != b.WarehouseName {
+			return a.WarehouseName < b.WarehouseName
+		}
+		if a.BinName != b.BinName {
+			return a.BinName < b.BinName
+		}
+		return a.ItemName < b.ItemName
+	})
+	if len(query.GroupBy) > 0 {
+		report.Totals = groupValuationLines(report.Lines, query.GroupBy)
+	}
+	return report, nil
+}
+
+// groupValuationLines totals the lines by the given dimensions, ordered by warehouse, bin and item type.
+func groupValuationLines(lines []*stock.ValuationLine, groupBy []stock.ValuationGroup) []*stock.ValuationTotal {
+	var totals []*stock.ValuationTotal
+	byKey := make(map[string]*stock.ValuationTotal)
+	for _, line := range lines {
+		var key strings.Builder
+		total := &stock.ValuationTotal{}
+		for _, group := range groupBy {
+			switch group {
+			case stock.GroupByWarehouse:
+				total.WarehouseID = &line.WarehouseID
+				total.WarehouseName = line.WarehouseName
+				key.WriteString(line.WarehouseID.String())
+			case stock.GroupByBin:
+				total.BinID = line.BinID
+				total.BinName = line.BinName
+				if line.BinID != nil {
+					key.WriteString(line.BinID.String())
+				}
+			case stock.GroupByItemType:
+				total.ItemType = line.ItemType
+				key.WriteString(string(line.ItemType))
+			}
+			key.WriteByte('|')
+		}
+
+		if existing, ok := byKey[key.String()]; ok {
+			total = existing
+		} else {
+			byKey[key.String()] = total
+			totals = append(totals, total)
+		}
+		total.Lines++
+		total.Value += line.Value
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		if a.WarehouseName != b.WarehouseName {
+			return a.WarehouseName < b.WarehouseName
+		}
+		if a.BinName != b.BinName {
+			return a.BinName < b.BinName
+		}
+		return a.ItemType < b.ItemType
+	})
+	return totals
+}
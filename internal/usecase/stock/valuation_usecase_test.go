@@ -0,0 +1,76 @@
+package stock_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"doligo_001/internal/domain/item"
+	"doligo_001/internal/domain/stock"
+	usecase "doligo_001/internal/usecase/stock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetInventoryValuation_AsOfGroupedByWarehouse(t *testing.T) {
+	s := setupTestSuite()
+	uc := usecase.NewValuationUseCase(s.stockLedgerRepo, s.warehouseRepo, s.binRepo, s.itemRepo)
+
+	asOf := time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC)
+	annexID, shelfID := uuid.New(), uuid.New()
+	averaged := &item.Item{ID: s.itemID, Name: "Bolt", Type: item.Storable, CostingMethod: item.CostingAverage, AverageCost: 9}
+	fifo := &item.Item{ID: uuid.New(), Name: "Nut", Type: item.Storable, CostingMethod: item.CostingFIFO, AverageCost: 3}
+	emptied := &item.Item{ID: uuid.New(), Name: "Washer", Type: item.Storable, AverageCost: 1}
+
+	s.stockLedgerRepo.On("ListBalancesAsOf", mock.Anything, asOf, stock.LedgerFilter{}).Return([]*stock.Stock{
+		{ItemID: averaged.ID, WarehouseID: s.warehouseID, BinID: &shelfID, Quantity: 10},
+		{ItemID: averaged.ID, WarehouseID: annexID, Quantity: 5},
+		{ItemID: fifo.ID, WarehouseID: s.warehouseID, BinID: &shelfID, Quantity: 4},
+		{ItemID: emptied.ID, WarehouseID: s.warehouseID, BinID: &shelfID, Quantity: 0},
+	}, nil).Once()
+	// The fifo item has no ledger value and falls back to its current unit cost.
+	s.stockLedgerRepo.On("ListItemValuesAsOf", mock.Anything, asOf, stock.LedgerFilter{}).Return([]*stock.ItemValue{
+		{ItemID: averaged.ID, Quantity: 15, Value: 150},
+	}, nil).Once()
+	s.itemRepo.On("List", mock.Anything).Return([]*item.Item{averaged, fifo, emptied}, nil).Once()
+	s.warehouseRepo.On("List", mock.Anything).Return([]*stock.Warehouse{
+		{ID: s.warehouseID, Name: "Main"},
+		{ID: annexID, Name: "Annex"},
+	}, nil).Once()
+	s.binRepo.On("GetByID", mock.Anything, shelfID).Return(&stock.Bin{ID: shelfID, Name: "A-01"}, nil).Once()
+
+	report, err := uc.GetInventoryValuation(s.ctx, stock.ValuationQuery{AsOf: &asOf, GroupBy: []stock.ValuationGroup{stock.GroupByWarehouse}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, asOf, report.AsOf)
+	assert.Len(t, report.Lines, 3)
+	assert.Equal(t, "Annex", report.Lines[0].WarehouseName)
+	assert.InDelta(t, 10.0, report.Lines[0].UnitCost, 1e-9)
+	assert.InDelta(t, 50.0, report.Lines[0].Value, 1e-9)
+	assert.Equal(t, "Nut", report.Lines[2].ItemName)
+	assert.Equal(t, "A-01", report.Lines[2].BinName)
+	assert.InDelta(t, 12.0, report.Lines[2].Value, 1e-9)
+	assert.InDelta(t, 162.0, report.TotalValue, 1e-9)
+
+	assert.Len(t, report.Totals, 2)
+	assert.Equal(t, "Annex", report.Totals[0].WarehouseName)
+	assert.Equal(t, 1, report.Totals[0].Lines)
+	assert.Equal(t, "Main", report.Totals[1].WarehouseName)
+	assert.Equal(t, 2, report.Totals[1].Lines)
+	assert.InDelta(t, 112.0, report.Totals[1].Value, 1e-9)
+	assert.Nil(t, report.Totals[1].BinID)
+	s.stockLedgerRepo.AssertExpectations(t)
+	s.binRepo.AssertExpectations(t)
+}
+
+func TestGetInventoryValuation_InvalidGroup(t *testing.T) {
+	s := setupTestSuite()
+	uc := usecase.NewValuationUseCase(s.stockLedgerRepo, s.warehouseRepo, s.binRepo, s.itemRepo)
+
+	report, err := uc.GetInventoryValuation(s.ctx, stock.ValuationQuery{GroupBy: []stock.ValuationGroup{"supplier"}})
+
+	assert.Nil(t, report)
+	assert.True(t, errors.Is(err, usecase.ErrInvalidValuationGroup))
+	s.stockLedgerRepo.AssertNotCalled(t, "ListBalancesAsOf", mock.Anything, mock.Anything, mock.Anything)
+}
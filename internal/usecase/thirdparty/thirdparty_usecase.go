@@ -39,6 +39,10 @@ func (u *usecase) Create(ctx context.Context, req *dto.CreateThirdPartyRequest)
 		Email: req.Email,
 		Type:  thirdparty.ThirdPartyType(req.Type),
 		IsActive: true,
+		PaymentTermDays: thirdparty.DefaultPaymentTermDays,
+	}
+	if req.PaymentTermDays != nil {
+		tp.PaymentTermDays = *req.PaymentTermDays
 	}
 	tp.SetCreatedBy(userID)
 	tp.SetUpdatedBy(userID)
@@ -67,6 +71,9 @@ func (u *usecase) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateThird
 	tp.Email = req.Email
 	tp.Type = thirdparty.ThirdPartyType(req.Type)
 	tp.IsActive = req.IsActive
+	if req.PaymentTermDays != nil {
+		tp.PaymentTermDays = *req.PaymentTermDays
+	}
 	tp.SetUpdatedBy(userID)
 
 	if err := u.repo.Update(ctx, tp); err != nil {
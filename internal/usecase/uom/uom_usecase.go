@@ -0,0 +1,123 @@
+// Package uom contains the use case for units of measure and the conversion of entered
+// quantities into the base unit of an item.
+package uom
+
+import (
+	"context"
+	"errors"
+
+	"doligo_001/internal/api/middleware"
+	"doligo_001/internal/domain"
+	"doligo_001/internal/domain/item"
+	domainUoM "doligo_001/internal/domain/uom"
+	"doligo_001/internal/usecase"
+	"github.com/google/uuid"
+)
+
+// Usecase defines the contract for units of measure and their conversions.
+type Usecase interface {
+	CreateCategory(ctx context.Context, category *domainUoM.Category) error
+	ListCategories(ctx context.Context) ([]*domainUoM.Category, error)
+	CreateUnit(ctx context.Context, unit *domainUoM.Unit) error
+	GetUnit(ctx context.Context, code string) (*domainUoM.Unit, error)
+	ListUnits(ctx context.Context, categoryID *uuid.UUID) ([]*domainUoM.Unit, error)
+	// Convert returns the quantity in from units expressed in to units.
+	Convert(ctx context.Context, quantity float64, from, to string) (float64, error)
+}
+
+type uomUsecase struct {
+	repo         domainUoM.Repository
+	auditService usecase.AuditService
+}
+
+// NewUsecase creates a new unit of measure usecase.
+func NewUsecase(repo domainUoM.Repository, auditService usecase.AuditService) Usecase {
+	return &uomUsecase{repo: repo, auditService: auditService}
+}
+
+func (uc *uomUsecase) CreateCategory(ctx context.Context, category *domainUoM.Category) error {
+	userID, _ := domain.UserIDFromContext(ctx)
+	category.ID = uuid.New()
+	category.SetCreatedBy(userID)
+	category.SetUpdatedBy(userID)
+	if err := uc.repo.CreateCategory(ctx, category); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "uom_category", category.ID.String(), "CREATE", nil, category, corrID)
+	return nil
+}
+
+func (uc *uomUsecase) ListCategories(ctx context.Context) ([]*domainUoM.Category, error) {
+	return uc.repo.ListCategories(ctx)
+}
+
+// CreateUnit adds a unit to an existing category. Units are never changed once created, as
+// the stored quantities were converted with their factor.
+func (uc *uomUsecase) CreateUnit(ctx context.Context, unit *domainUoM.Unit) error {
+	if unit.Factor <= 0 {
+		return domainUoM.ErrInvalidFactor
+	}
+	if _, err := uc.repo.GetCategory(ctx, unit.CategoryID); err != nil {
+		return err
+	}
+	if _, err := uc.repo.GetUnitByCode(ctx, unit.Code); err == nil {
+		return domainUoM.ErrUnitExists
+	} else if !errors.Is(err, domainUoM.ErrUnitNotFound) {
+		return err
+	}
+
+	userID, _ := domain.UserIDFromContext(ctx)
+	unit.ID = uuid.New()
+	unit.SetCreatedBy(userID)
+	unit.SetUpdatedBy(userID)
+	if err := uc.repo.CreateUnit(ctx, unit); err != nil {
+		return err
+	}
+
+	corrID, _ := middleware.FromContext(ctx)
+	uc.auditService.Log(ctx, userID, "uom_unit", unit.ID.String(), "CREATE", nil, unit, corrID)
+	return nil
+}
+
+func (uc *uomUsecase) GetUnit(ctx context.Context, code string) (*domainUoM.Unit, error) {
+	return uc.repo.GetUnitByCode(ctx, code)
+}
+
+func (uc *uomUsecase) ListUnits(ctx context.Context, categoryID *uuid.UUID) ([]*domainUoM.Unit, error) {
+	return uc.repo.ListUnits(ctx, categoryID)
+}
+
+func (uc *uomUsecase) Convert(ctx context.Context, quantity float64, from, to string) (float64, error) {
+	factor, err := factor(ctx, uc.repo, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return quantity * factor, nil
+}
+
+// BaseFactor returns the number of base units of the item in one unit. A quantity entered
+// without a unit, or for an item without a base unit, is taken as already in the base unit.
+func BaseFactor(ctx context.Context, units domainUoM.Repository, it *item.Item, unit string) (float64, error) {
+	if unit == "" || it.BaseUnit == "" {
+		return 1, nil
+	}
+	return factor(ctx, units, unit, it.BaseUnit)
+}
+
+// factor returns the number of to units in one from unit.
+func factor(ctx context.Context, units domainUoM.Repository, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromUnit, err := units.GetUnitByCode(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+	toUnit, err := units.GetUnitByCode(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	return domainUoM.ConversionFactor(fromUnit, toUnit)
+}